@@ -3,6 +3,7 @@
 package alias
 
 import (
+	"sort"
 	"strings"
 
 	"goquake/cbuf"
@@ -115,6 +116,16 @@ func (al *Aliases) Execute() func(cb *cbuf.CommandBuffer, a cbuf.Arguments) (boo
 	}
 }
 
+// Names returns the sorted names of all aliases.
+func (al *Aliases) Names() []string {
+	r := make([]string, 0, len(*al))
+	for n := range *al {
+		r = append(r, n)
+	}
+	sort.Strings(r)
+	return r
+}
+
 func (al *Aliases) Commands(c *cmd.Commands) error {
 	if err := c.Add("alias", al.alias()); err != nil {
 		return err
@@ -0,0 +1,356 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package bsp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+
+	"goquake/math/vec"
+
+	"github.com/chewxy/math32"
+)
+
+// BSPX is an extension of the bsp format used by ericw-tools and FTE. The
+// extra lumps are stored after the standard lumps, starting at the next 4
+// byte boundary with the "BSPX" magic, the number of lumps and a directory
+// of named lumps.
+
+const bspxMagic = 'B' | 'S'<<8 | 'P'<<16 | 'X'<<24
+
+type bspxEntry struct {
+	Name [24]byte
+	directory
+}
+
+// bspx holds the extra lumps by name.
+type bspx map[string]*io.SectionReader
+
+// loadBSPX reads the BSPX directory of the file. Files without one return
+// nil.
+func loadBSPX(h *header, file io.ReadSeeker) (bspx, error) {
+	r, ok := file.(io.ReaderAt)
+	if !ok {
+		return nil, nil
+	}
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	ds := []directory{
+		h.Entities, h.Planes, h.Textures, h.Vertexes, h.Visibility, h.Nodes,
+		h.Texinfo, h.Faces, h.Lighting, h.ClipNodes, h.Leafs, h.MarkSurfaces,
+		h.Edges, h.SurfaceEdges, h.Models,
+	}
+	var end int64
+	for _, d := range ds {
+		end = max(end, int64(d.Offset)+int64(d.Size))
+	}
+	end = (end + 3) &^ 3
+	var xh struct {
+		Magic uint32
+		Count uint32
+	}
+	if end+int64(binary.Size(xh)) > size {
+		return nil, nil
+	}
+	sr := io.NewSectionReader(r, end, size-end)
+	if err := binary.Read(sr, binary.LittleEndian, &xh); err != nil {
+		return nil, err
+	}
+	if xh.Magic != bspxMagic {
+		return nil, nil
+	}
+	if int64(xh.Count)*int64(binary.Size(bspxEntry{})) > sr.Size() {
+		return nil, fmt.Errorf("BSPX has a broken lump directory")
+	}
+	entries := make([]bspxEntry, xh.Count)
+	if err := binary.Read(sr, binary.LittleEndian, entries); err != nil {
+		return nil, err
+	}
+	x := make(bspx)
+	for _, e := range entries {
+		name := string(e.Name[:])
+		if i := bytes.IndexByte(e.Name[:], 0); i >= 0 {
+			name = name[:i]
+		}
+		if e.Offset < 0 || e.Size < 0 || int64(e.Offset)+int64(e.Size) > size {
+			log.Printf("BSPX lump %s out of bounds", name)
+			continue
+		}
+		x[name] = io.NewSectionReader(r, int64(e.Offset), int64(e.Size))
+	}
+	return x, nil
+}
+
+// lighting returns the colored light data of the RGBLIGHTING or
+// LIGHTING_E5BGR9 lumps. samples is the size of the standard lighting lump,
+// the lumps have to contain as many colored samples.
+func (x bspx) lighting(samples int64) []byte {
+	if samples == 0 {
+		return nil
+	}
+	if l, ok := x["LIGHTING_E5BGR9"]; ok && l.Size() == 4*samples {
+		b, err := io.ReadAll(l)
+		if err == nil {
+			return decodeE5BGR9(b)
+		}
+		log.Printf("read LIGHTING_E5BGR9: %v", err)
+	}
+	if l, ok := x["RGBLIGHTING"]; ok && l.Size() == 3*samples {
+		b, err := io.ReadAll(l)
+		if err == nil {
+			return b
+		}
+		log.Printf("read RGBLIGHTING: %v", err)
+	}
+	return nil
+}
+
+// decodeE5BGR9 converts HDR light samples to RGB. Each sample is a 9 bit
+// mantissa per color with a shared 5 bit exponent, 1 is full brightness.
+func decodeE5BGR9(b []byte) []byte {
+	out := make([]byte, 0, len(b)/4*3)
+	for i := 0; i+4 <= len(b); i += 4 {
+		v := binary.LittleEndian.Uint32(b[i:])
+		scale := 255 * math32.Ldexp(1, int(v>>27)-15-9)
+		for _, m := range []uint32{v & 0x1ff, (v >> 9) & 0x1ff, (v >> 18) & 0x1ff} {
+			out = append(out, byte(min(255, float32(m)*scale+0.5)))
+		}
+	}
+	return out
+}
+
+// setLightmapScales applies the LMSHIFT and DECOUPLED_LM lumps to the
+// surfaces. Both need to have one entry per face.
+func (x bspx) setLightmapScales(ss []*Surface) error {
+	if l, ok := x["LMSHIFT"]; ok {
+		if l.Size() != int64(len(ss)) {
+			log.Printf("LMSHIFT has %d entries for %d faces", l.Size(), len(ss))
+		} else {
+			shifts := make([]byte, len(ss))
+			if _, err := io.ReadFull(l, shifts); err != nil {
+				return fmt.Errorf("read LMSHIFT: %v", err)
+			}
+			for i, s := range ss {
+				if shifts[i] > 7 {
+					return fmt.Errorf("LMSHIFT %d of face %d out of range", shifts[i], i)
+				}
+				s.lightShift = shifts[i]
+			}
+		}
+	}
+	l, ok := x["DECOUPLED_LM"]
+	if !ok {
+		return nil
+	}
+	type decoupledLM struct {
+		Width, Height uint16
+		Offset        int32
+		Vecs          [2]TexInfoPos // world to lightmap samples
+	}
+	if l.Size() != int64(len(ss)*binary.Size(decoupledLM{})) {
+		log.Printf("DECOUPLED_LM has the wrong size for %d faces", len(ss))
+		return nil
+	}
+	lms := make([]decoupledLM, len(ss))
+	if err := binary.Read(l, binary.LittleEndian, lms); err != nil {
+		return fmt.Errorf("read DECOUPLED_LM: %v", err)
+	}
+	for i, s := range ss {
+		lm := &lms[i]
+		// Express the lightmap in the same texel space as normal ones
+		// so everything working on textureMins and extents keeps working.
+		scale := float32(s.lightScale())
+		s.decoupled = true
+		s.lightMapOfs = lm.Offset
+		for j := range s.lightVecs {
+			s.lightVecs[j].Pos = vec.Scale(scale, lm.Vecs[j].Pos)
+			s.lightVecs[j].Offset = lm.Vecs[j].Offset * scale
+		}
+		s.textureMins = [2]int{0, 0}
+		s.extents[S] = max(0, int(lm.Width)-1) << s.lightShift
+		s.extents[T] = max(0, int(lm.Height)-1) << s.lightShift
+	}
+	return nil
+}
+
+// brush is a convex solid of the BRUSHLIST lump. The axial planes are
+// created from the bounds.
+type brush struct {
+	mins, maxs vec.Vec3
+	contents   int
+	planes     []tracePlane
+}
+
+// brushes returns the brushes of the BRUSHLIST lump for each of the models.
+func (x bspx) brushes(models int) ([][]*brush, error) {
+	l, ok := x["BRUSHLIST"]
+	if !ok {
+		return nil, nil
+	}
+	type modelHeader struct {
+		Version   uint32
+		Model     uint32
+		NumBrush  uint32
+		NumPlanes uint32
+	}
+	type brushHeader struct {
+		Mins, Maxs [3]float32
+		Contents   int16
+		NumPlanes  uint16
+	}
+	type brushPlane struct {
+		Normal [3]float32
+		Dist   float32
+	}
+	ret := make([][]*brush, models)
+	for {
+		var mh modelHeader
+		err := binary.Read(l, binary.LittleEndian, &mh)
+		if err == io.EOF {
+			return ret, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read BRUSHLIST: %v", err)
+		}
+		if mh.Version != 1 {
+			return nil, fmt.Errorf("BRUSHLIST version %d not supported", mh.Version)
+		}
+		if mh.Model >= uint32(models) {
+			return nil, fmt.Errorf("BRUSHLIST for unknown model %d", mh.Model)
+		}
+		if int64(mh.NumBrush)*int64(binary.Size(brushHeader{})) > l.Size() {
+			return nil, fmt.Errorf("BRUSHLIST of model %d too short", mh.Model)
+		}
+		planes := 0
+		bs := make([]*brush, 0, mh.NumBrush)
+		for range mh.NumBrush {
+			var bh brushHeader
+			if err := binary.Read(l, binary.LittleEndian, &bh); err != nil {
+				return nil, fmt.Errorf("read BRUSHLIST: %v", err)
+			}
+			b := &brush{
+				mins:     bh.Mins,
+				maxs:     bh.Maxs,
+				contents: int(bh.Contents),
+				planes:   make([]tracePlane, 0, 6+int(bh.NumPlanes)),
+			}
+			for i := range 3 {
+				var n vec.Vec3
+				n[i] = 1
+				b.planes = append(b.planes, tracePlane{Normal: n, Distance: b.maxs[i]})
+				n[i] = -1
+				b.planes = append(b.planes, tracePlane{Normal: n, Distance: -b.mins[i]})
+			}
+			if int64(bh.NumPlanes)*int64(binary.Size(brushPlane{})) > l.Size() {
+				return nil, fmt.Errorf("BRUSHLIST of model %d too short", mh.Model)
+			}
+			ps := make([]brushPlane, bh.NumPlanes)
+			if err := binary.Read(l, binary.LittleEndian, ps); err != nil {
+				return nil, fmt.Errorf("read BRUSHLIST: %v", err)
+			}
+			for _, p := range ps {
+				b.planes = append(b.planes, tracePlane{Normal: p.Normal, Distance: p.Dist})
+			}
+			planes += int(bh.NumPlanes)
+			bs = append(bs, b)
+		}
+		if planes != int(mh.NumPlanes) {
+			return nil, fmt.Errorf("BRUSHLIST of model %d has %d planes, want %d", mh.Model, planes, mh.NumPlanes)
+		}
+		ret[mh.Model] = append(ret[mh.Model], bs...)
+	}
+}
+
+// solid reports if the brush blocks movement of boxes. Clip brushes only
+// block boxes, same as in the clip hulls.
+func (b *brush) solid() bool {
+	return b.contents == CONTENTS_SOLID || b.contents == CONTENTS_CLIP
+}
+
+// trace clips the move of the box from start to end against the brush. This
+// moves the brush planes by the box instead of using precomputed hulls.
+func (b *brush) trace(start, end, mins, maxs vec.Vec3, t *Trace) {
+	const epsilon = 0.03125 // same as in RecursiveCheck
+	enter, leave := float32(-1), float32(1)
+	var clip tracePlane
+	startOut, endOut := false, false
+	for _, p := range b.planes {
+		// the corner of the box which is furthest behind the plane
+		var corner vec.Vec3
+		for i := range 3 {
+			if p.Normal[i] < 0 {
+				corner[i] = maxs[i]
+			} else {
+				corner[i] = mins[i]
+			}
+		}
+		dist := p.Distance - vec.Dot(corner, p.Normal)
+		d1 := vec.Dot(start, p.Normal) - dist
+		d2 := vec.Dot(end, p.Normal) - dist
+		if d1 > 0 {
+			startOut = true
+		}
+		if d2 > 0 {
+			endOut = true
+		}
+		if d1 > 0 && (d2 >= epsilon || d2 >= d1) {
+			// completely in front of the plane
+			return
+		}
+		if d1 <= 0 && d2 <= 0 {
+			continue
+		}
+		if d1 > d2 {
+			f := max(0, (d1-epsilon)/(d1-d2))
+			if f > enter {
+				enter = f
+				clip = tracePlane{Normal: p.Normal, Distance: dist}
+			}
+		} else {
+			f := min(1, (d1+epsilon)/(d1-d2))
+			leave = min(leave, f)
+		}
+	}
+	if !startOut {
+		t.StartSolid = true
+		if !endOut {
+			t.AllSolid = true
+			t.Fraction = 0
+		}
+		return
+	}
+	if enter < leave && enter > -1 && enter < t.Fraction {
+		t.Fraction = enter
+		t.Plane = clip
+	}
+}
+
+// HasBrushes reports if the model got brushes from a BRUSHLIST lump.
+func (m *Model) HasBrushes() bool {
+	return len(m.brushes) > 0
+}
+
+// TraceBrushes traces a box of size mins, maxs from start to end through the
+// brushes of the model. start and end are relative to the model origin.
+func (m *Model) TraceBrushes(start, end, mins, maxs vec.Vec3, t *Trace) {
+	t.AllSolid = false
+	t.InOpen = true
+	t.Fraction = 1
+	for _, b := range m.brushes {
+		if !b.solid() {
+			continue
+		}
+		b.trace(start, end, mins, maxs, t)
+		if t.AllSolid {
+			return
+		}
+	}
+	if t.Fraction < 1 {
+		t.EndPos = vec.Lerp(start, end, t.Fraction)
+	}
+}
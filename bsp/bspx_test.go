@@ -0,0 +1,184 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package bsp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"goquake/math/vec"
+)
+
+// bspxFile returns a bsp with a lighting lump of size light followed by the
+// given BSPX lumps.
+func bspxFile(light int, lumps map[string][]byte) []byte {
+	h := header{Version: bspVersion}
+	h.Lighting = directory{Offset: int32(binary.Size(h)), Size: int32(light)}
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, h)
+	buf.Write(make([]byte, light))
+	for buf.Len()%4 != 0 {
+		buf.WriteByte(0)
+	}
+	binary.Write(&buf, binary.LittleEndian, [2]uint32{bspxMagic, uint32(len(lumps))})
+	entries := buf.Len()
+	buf.Write(make([]byte, len(lumps)*binary.Size(bspxEntry{})))
+	var es []bspxEntry
+	for n, l := range lumps {
+		e := bspxEntry{directory: directory{Offset: int32(buf.Len()), Size: int32(len(l))}}
+		copy(e.Name[:], n)
+		es = append(es, e)
+		buf.Write(l)
+	}
+	b := buf.Bytes()
+	var eb bytes.Buffer
+	binary.Write(&eb, binary.LittleEndian, es)
+	copy(b[entries:], eb.Bytes())
+	return b
+}
+
+func readBSPX(t *testing.T, b []byte) bspx {
+	t.Helper()
+	r := bytes.NewReader(b)
+	var h header
+	if err := binary.Read(r, binary.LittleEndian, &h); err != nil {
+		t.Fatal(err)
+	}
+	x, err := loadBSPX(&h, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return x
+}
+
+func TestLoadBSPX(t *testing.T) {
+	rgb := []byte{1, 2, 3, 4, 5, 6}
+	x := readBSPX(t, bspxFile(2, map[string][]byte{
+		"RGBLIGHTING": rgb,
+		"LMSHIFT":     {3},
+	}))
+	if len(x) != 2 {
+		t.Fatalf("got %d lumps, want 2", len(x))
+	}
+	if got := x.lighting(2); !bytes.Equal(got, rgb) {
+		t.Errorf("lighting = %v, want %v", got, rgb)
+	}
+	if got := x.lighting(3); got != nil {
+		t.Errorf("lighting with the wrong size = %v", got)
+	}
+
+	if x := readBSPX(t, bspxFile(2, nil)[:binary.Size(header{})+4]); x != nil {
+		t.Errorf("bsp without BSPX got %v", x)
+	}
+}
+
+func TestDecodeE5BGR9(t *testing.T) {
+	enc := func(r, g, b, e uint32) []byte {
+		return binary.LittleEndian.AppendUint32(nil, e<<27|b<<18|g<<9|r)
+	}
+	for _, tt := range []struct {
+		in   []byte
+		want []byte
+	}{
+		// 256 * 2^(16-24) == 1
+		{enc(256, 128, 0, 16), []byte{255, 128, 0}},
+		{enc(511, 64, 32, 17), []byte{255, 128, 64}},
+		{enc(0, 0, 0, 0), []byte{0, 0, 0}},
+	} {
+		if got := decodeE5BGR9(tt.in); !bytes.Equal(got, tt.want) {
+			t.Errorf("decodeE5BGR9(%x) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLightmapScales(t *testing.T) {
+	ss := []*Surface{{lightShift: 4}, {lightShift: 4}}
+	ss[0].extents = [2]int{64, 32}
+	ss[1].extents = [2]int{64, 32}
+	x := readBSPX(t, bspxFile(0, map[string][]byte{"LMSHIFT": {4, 3}}))
+	if err := x.setLightmapScales(ss); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range [][2]int{{5, 3}, {9, 5}} {
+		if s, t2 := ss[i].lightmapSize(); s != want[0] || t2 != want[1] {
+			t.Errorf("surface %d has size %dx%d, want %dx%d", i, s, t2, want[0], want[1])
+		}
+	}
+}
+
+func brushList(model uint32, mins, maxs [3]float32, planes [][4]float32) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, [4]uint32{1, model, 1, uint32(len(planes))})
+	binary.Write(&buf, binary.LittleEndian, mins)
+	binary.Write(&buf, binary.LittleEndian, maxs)
+	binary.Write(&buf, binary.LittleEndian, [2]int16{CONTENTS_SOLID, int16(len(planes))})
+	binary.Write(&buf, binary.LittleEndian, planes)
+	return buf.Bytes()
+}
+
+func TestBrushTrace(t *testing.T) {
+	// a ramp, the cube from -64 to 64 below the plane z == x
+	n := float32(1 / 1.4142135)
+	lump := brushList(1, [3]float32{-64, -64, -64}, [3]float32{64, 64, 64}, [][4]float32{{-n, 0, n, 0}})
+	x := readBSPX(t, bspxFile(0, map[string][]byte{"BRUSHLIST": lump}))
+	bs, err := x.brushes(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bs[0]) != 0 || len(bs[1]) != 1 || len(bs[1][0].planes) != 7 {
+		t.Fatalf("got brushes %v", bs)
+	}
+	m := &Model{brushes: bs[1]}
+	mins, maxs := vec.Vec3{-8, -8, -8}, vec.Vec3{8, 8, 8}
+
+	var tr Trace
+	m.TraceBrushes(vec.Vec3{200, 0, -32}, vec.Vec3{0, 0, -32}, mins, maxs, &tr)
+	if tr.StartSolid || tr.Fraction == 1 {
+		t.Fatalf("trace into the ramp got %+v", tr)
+	}
+	if got := tr.EndPos[0]; got < 72 || got > 72.1 {
+		t.Errorf("stopped at x %v, want 72", got)
+	}
+	if tr.Plane.Normal != (vec.Vec3{1, 0, 0}) {
+		t.Errorf("hit plane %v", tr.Plane.Normal)
+	}
+
+	// above the bottom the box only hits the slope
+	tr = Trace{}
+	m.TraceBrushes(vec.Vec3{-200, 0, 40}, vec.Vec3{200, 0, 40}, mins, maxs, &tr)
+	if tr.Fraction == 1 || tr.Plane.Normal != (vec.Vec3{-n, 0, n}) {
+		t.Errorf("trace onto the slope got %+v", tr)
+	}
+	// the corner of the box touches the plane at x+8 == z-8
+	if got := tr.EndPos[0] + 16 - tr.EndPos[2]; got < -0.1 || got > 0 {
+		t.Errorf("stopped at %v", tr.EndPos)
+	}
+
+	tr = Trace{}
+	m.TraceBrushes(vec.Vec3{-200, 0, 80}, vec.Vec3{200, 0, 80}, mins, maxs, &tr)
+	if tr.Fraction != 1 || tr.AllSolid {
+		t.Errorf("trace above the brush got %+v", tr)
+	}
+
+	tr = Trace{}
+	m.TraceBrushes(vec.Vec3{-32, 0, -32}, vec.Vec3{-40, 0, -40}, mins, maxs, &tr)
+	if !tr.StartSolid || !tr.AllSolid {
+		t.Errorf("trace inside the brush got %+v", tr)
+	}
+}
+
+func TestBrushListErrors(t *testing.T) {
+	lump := brushList(3, [3]float32{}, [3]float32{1, 1, 1}, nil)
+	x := bspx{"BRUSHLIST": io.NewSectionReader(bytes.NewReader(lump), 0, int64(len(lump)))}
+	if _, err := x.brushes(2); err == nil {
+		t.Errorf("brushes of an unknown model got no error")
+	}
+	lump = brushList(0, [3]float32{}, [3]float32{1, 1, 1}, nil)
+	lump = lump[:len(lump)-4]
+	x = bspx{"BRUSHLIST": io.NewSectionReader(bytes.NewReader(lump), 0, int64(len(lump)))}
+	if _, err := x.brushes(1); err == nil {
+		t.Errorf("truncated BRUSHLIST got no error")
+	}
+}
@@ -4,6 +4,8 @@ package bsp
 
 import (
 	"bytes"
+	"encoding/binary"
+	"fmt"
 	"io"
 )
 
@@ -125,3 +127,17 @@ func ParseEntities(buf io.Reader) []*Entity {
 	}
 	return es
 }
+
+// ReadEntities returns the entities of the bsp file r without loading the
+// rest of the map.
+func ReadEntities(r io.ReaderAt, size int64) ([]*Entity, error) {
+	h := header{}
+	if err := binary.Read(io.NewSectionReader(r, 0, size), binary.LittleEndian, &h); err != nil {
+		return nil, err
+	}
+	d := h.Entities
+	if d.Offset < 0 || d.Size < 0 || int64(d.Offset)+int64(d.Size) > size {
+		return nil, fmt.Errorf("bad entities lump, offset %d, size %d", d.Offset, d.Size)
+	}
+	return ParseEntities(io.NewSectionReader(r, int64(d.Offset), int64(d.Size))), nil
+}
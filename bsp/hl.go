@@ -0,0 +1,197 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package bsp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+
+	"goquake/math/vec"
+	"goquake/texture"
+	"goquake/wad"
+)
+
+// Half-Life uses BSP version 30. The lumps are the same as in version 29 but
+// the lighting is RGB and every texture brings its own palette. Textures
+// without data are stored in the WAD3 files listed by the worldspawn.
+
+const mipTexSize = 40 // size of mipTex
+
+// wadFiles returns the wad files of the worldspawn "wad" key. The list is
+// separated by ';' and usually holds absolute paths of the mapper, only the
+// base names are used.
+func wadFiles(ents []*Entity) []string {
+	if len(ents) == 0 {
+		return nil
+	}
+	v, ok := ents[0].Property("wad")
+	if !ok {
+		return nil
+	}
+	var r []string
+	for _, w := range strings.Split(v, ";") {
+		w = strings.TrimSpace(strings.ReplaceAll(w, "\\", "/"))
+		if w == "" {
+			continue
+		}
+		r = append(r, path.Base(w))
+	}
+	return r
+}
+
+// loadWads opens the wad files of the worldspawn, missing ones get skipped.
+func loadWads(ents []*Entity) []*wad.File {
+	var r []*wad.File
+	for _, n := range wadFiles(ents) {
+		w, err := wad.Open(n)
+		if err != nil {
+			log.Printf("Could not load wad %s: %v", n, err)
+			continue
+		}
+		r = append(r, w)
+	}
+	return r
+}
+
+// hlMipTex reads the texture at offset of the texture lump. Textures
+// without data are looked up in wads.
+func hlMipTex(buf *io.SectionReader, offset int64, mt *mipTex, wads []*wad.File) ([]byte, error) {
+	name := mt.name()
+	if mt.Offsets[0] == 0 {
+		for _, w := range wads {
+			if b, ok := w.MipTex(name); ok {
+				return b, nil
+			}
+		}
+		return nil, fmt.Errorf("Texture %s not found in wads", name)
+	}
+	pixels := int64(mt.Width) * int64(mt.Height)
+	// 4 mip levels, the palette size and the palette
+	size := mipTexSize + pixels*85/64 + 2 + 256*3
+	size = min(size, buf.Size()-offset)
+	b := make([]byte, size)
+	if _, err := buf.ReadAt(b, offset); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("Texture %s: %v", name, err)
+	}
+	return b, nil
+}
+
+// hlTexture returns the RGBA data of the first mip level of a texture with
+// palette. Color 255 of alpha masked textures is transparent.
+func hlTexture(b []byte) (w, h int, rgba []byte, err error) {
+	var mt mipTex
+	if err := binary.Read(bytes.NewReader(b), binary.LittleEndian, &mt); err != nil {
+		return 0, 0, nil, err
+	}
+	name := mt.name()
+	w, h = int(mt.Width), int(mt.Height)
+	if w <= 0 || h <= 0 || w&15 != 0 || h&15 != 0 {
+		return 0, 0, nil, fmt.Errorf("Texture %s not 16 aligned", name)
+	}
+	start := int(mt.Offsets[0])
+	pal := int(mt.Offsets[3]) + (w/8)*(h/8)
+	if start+w*h > len(b) || pal+2 > len(b) {
+		return 0, 0, nil, fmt.Errorf("Texture %s not enough bytes", name)
+	}
+	colors := int(binary.LittleEndian.Uint16(b[pal:]))
+	pal += 2
+	if colors > 256 || pal+colors*3 > len(b) {
+		return 0, 0, nil, fmt.Errorf("Texture %s has a broken palette", name)
+	}
+	masked := strings.HasPrefix(name, "{")
+	rgba = make([]byte, 0, w*h*4)
+	for _, p := range b[start : start+w*h] {
+		switch {
+		case masked && p == 255:
+			rgba = append(rgba, 0, 0, 0, 0)
+		case int(p) >= colors:
+			rgba = append(rgba, 0, 0, 0, 255)
+		default:
+			c := b[pal+int(p)*3:]
+			rgba = append(rgba, c[0], c[1], c[2], 255)
+		}
+	}
+	return w, h, rgba, nil
+}
+
+// missingTexture is a black and magenta checker board shown for textures
+// which could not be loaded.
+func missingTexture(w, h int) []byte {
+	rgba := make([]byte, 0, w*h*4)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if (x/8+y/8)%2 == 0 {
+				rgba = append(rgba, 255, 0, 255, 255)
+			} else {
+				rgba = append(rgba, 0, 0, 0, 255)
+			}
+		}
+	}
+	return rgba
+}
+
+// loadHLTexture creates the textures from the miptex b. If b is nil the
+// texture is missing.
+func (t *Texture) loadHLTexture(b []byte, modelName string) {
+	var rgba []byte
+	if b == nil {
+		rgba = missingTexture(t.Width, t.Height)
+	} else if w, h, data, err := hlTexture(b); err != nil {
+		log.Printf("%s: %v", modelName, err)
+		rgba = missingTexture(t.Width, t.Height)
+	} else if w != t.Width || h != t.Height {
+		log.Printf("%s: texture %s has size %dx%d in wad and %dx%d in the map", modelName, t.name, w, h, t.Width, t.Height)
+		rgba = missingTexture(t.Width, t.Height)
+	} else {
+		rgba = data
+	}
+	tName := fmt.Sprintf("%s:%s", modelName, t.name)
+	if strings.HasPrefix(t.name, "sky") {
+		t.loadHLSkyTexture(rgba, tName)
+		return
+	}
+	flags := texture.TexPrefMipMap
+	if strings.HasPrefix(t.name, "{") {
+		flags |= texture.TexPrefAlpha
+	}
+	t.Texture = texture.NewTexture(int32(t.Width), int32(t.Height), flags, tName, texture.ColorTypeRGBA, rgba)
+}
+
+// loadHLSkyTexture uses the texture as solid sky. Half-Life draws a sky box
+// instead, so there is no cloud layer.
+func (t *Texture) loadHLSkyTexture(rgba []byte, name string) {
+	var r, g, b int
+	for i := 0; i < len(rgba); i += 4 {
+		r += int(rgba[i])
+		g += int(rgba[i+1])
+		b += int(rgba[i+2])
+	}
+	count := float32(max(1, len(rgba)/4))
+	t.FlatSky = Color{
+		R: float32(r) / (count * 255),
+		G: float32(g) / (count * 255),
+		B: float32(b) / (count * 255),
+	}
+	t.SolidSky = texture.NewTexture(int32(t.Width), int32(t.Height), texture.TexPrefNone, name+"_front", texture.ColorTypeRGBA, rgba)
+	t.AlphaSky = texture.NewTexture(int32(t.Width), int32(t.Height), texture.TexPrefAlpha, name+"_back", texture.ColorTypeRGBA, make([]byte, len(rgba)))
+}
+
+// setHLHulls sets the sizes of the Half-Life hulls. Hull 1 is a standing
+// player, hull 2 a large monster and hull 3 a crouching player.
+func setHLHulls(hs *[4]Hull) {
+	hs[1].ClipMins = vec.Vec3{-16, -16, -36}
+	hs[1].ClipMaxs = vec.Vec3{16, 16, 36}
+	hs[2].ClipMins = vec.Vec3{-32, -32, -32}
+	hs[2].ClipMaxs = vec.Vec3{32, 32, 32}
+	hs[3].ClipMins = vec.Vec3{-16, -16, -18}
+	hs[3].ClipMaxs = vec.Vec3{16, 16, 18}
+	hs[3].ClipNodes = hs[1].ClipNodes
+	hs[3].FirstClipNode = 0
+	hs[3].LastClipNode = hs[1].LastClipNode
+	hs[3].Planes = hs[1].Planes
+}
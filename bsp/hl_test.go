@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package bsp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"slices"
+	"testing"
+
+	"goquake/wad"
+)
+
+// hlMipTexData returns a 16x16 texture with palette. Pixel i has color i.
+func hlMipTexData(name string, withData bool) []byte {
+	const w, h = 16, 16
+	mt := mipTex{Width: w, Height: h}
+	copy(mt.Name[:], name)
+	var buf bytes.Buffer
+	if withData {
+		o := uint32(mipTexSize)
+		for i := range mt.Offsets {
+			mt.Offsets[i] = o
+			o += uint32((w >> i) * (h >> i))
+		}
+	}
+	binary.Write(&buf, binary.LittleEndian, mt)
+	if !withData {
+		return buf.Bytes()
+	}
+	for i := 0; i < w*h*85/64; i++ {
+		buf.WriteByte(byte(i))
+	}
+	binary.Write(&buf, binary.LittleEndian, uint16(256))
+	for i := 0; i < 256; i++ {
+		buf.Write([]byte{byte(i), 255 - byte(i), 7})
+	}
+	buf.Write([]byte{0, 0})
+	return buf.Bytes()
+}
+
+func TestHLTexture(t *testing.T) {
+	w, h, rgba, err := hlTexture(hlMipTexData("wall", true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w != 16 || h != 16 || len(rgba) != 16*16*4 {
+		t.Fatalf("got %dx%d with %d bytes", w, h, len(rgba))
+	}
+	if got := rgba[5*4 : 6*4]; !bytes.Equal(got, []byte{5, 250, 7, 255}) {
+		t.Errorf("pixel 5 = %v", got)
+	}
+	if got := rgba[255*4:]; !bytes.Equal(got, []byte{255, 0, 7, 255}) {
+		t.Errorf("pixel 255 = %v", got)
+	}
+
+	_, _, rgba, err = hlTexture(hlMipTexData("{fence", true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rgba[255*4:]; !bytes.Equal(got, []byte{0, 0, 0, 0}) {
+		t.Errorf("masked pixel 255 = %v", got)
+	}
+
+	if _, _, _, err := hlTexture(hlMipTexData("wall", true)[:200]); err == nil {
+		t.Errorf("truncated texture got no error")
+	}
+}
+
+func TestWadFiles(t *testing.T) {
+	ents := ParseEntities(bytes.NewReader([]byte(
+		"{\n\"classname\" \"worldspawn\"\n\"wad\" \"\\\\half-life\\\\valve\\\\halflife.wad;c:/maps/my.wad;;\"\n}\n")))
+	if got, want := wadFiles(ents), []string{"halflife.wad", "my.wad"}; !slices.Equal(got, want) {
+		t.Errorf("wadFiles = %q, want %q", got, want)
+	}
+}
+
+// wad3 returns a WAD3 file with the given miptex lumps.
+func wad3(lumps map[string][]byte) []byte {
+	type lump struct {
+		Offset      int32
+		Dsize       int32
+		Size        int32
+		Typ         byte
+		Compression byte
+		Dummy       int16
+		Name        [16]byte
+	}
+	var data bytes.Buffer
+	var dir []lump
+	data.Write(make([]byte, 12))
+	for n, b := range lumps {
+		l := lump{Offset: int32(data.Len()), Dsize: int32(len(b)), Size: int32(len(b)), Typ: 0x43}
+		copy(l.Name[:], n)
+		dir = append(dir, l)
+		data.Write(b)
+	}
+	out := data.Bytes()
+	copy(out, "WAD3")
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(dir)))
+	binary.LittleEndian.PutUint32(out[8:], uint32(len(out)))
+	var d bytes.Buffer
+	binary.Write(&d, binary.LittleEndian, dir)
+	return append(out, d.Bytes()...)
+}
+
+func TestHLMipTexFromWad(t *testing.T) {
+	w, err := wad.Parse("test.wad", wad3(map[string][]byte{"WALL": hlMipTexData("WALL", true)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the map only has the header of the texture
+	lump := hlMipTexData("wall", false)
+	buf := io.NewSectionReader(bytes.NewReader(lump), 0, int64(len(lump)))
+	var mt mipTex
+	binary.Read(buf, binary.LittleEndian, &mt)
+
+	b, err := hlMipTex(buf, 0, &mt, []*wad.File{w})
+	if err != nil {
+		t.Fatalf("hlMipTex: %v", err)
+	}
+	if _, _, _, err := hlTexture(b); err != nil {
+		t.Errorf("texture from wad: %v", err)
+	}
+	if _, err := hlMipTex(buf, 0, &mt, nil); err == nil {
+		t.Errorf("texture without wad got no error")
+	}
+}
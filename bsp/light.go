@@ -52,9 +52,9 @@ func (m *Model) recursiveLight(s *LightStyles, node Node, start, end vec.Vec3, c
 		if surface.Flags&SurfaceDrawTiled != 0 {
 			continue
 		}
-		ti := surface.TexInfo
-		ds := int(vec.DoublePrecDot(mid, ti.Vecs[S].Pos) + float64(ti.Vecs[S].Offset))
-		dt := int(vec.DoublePrecDot(mid, ti.Vecs[T].Pos) + float64(ti.Vecs[T].Offset))
+		lv := &surface.lightVecs
+		ds := int(vec.DoublePrecDot(mid, lv[S].Pos) + float64(lv[S].Offset))
+		dt := int(vec.DoublePrecDot(mid, lv[T].Pos) + float64(lv[T].Offset))
 		if ds < surface.textureMins[0] || dt < surface.textureMins[1] {
 			continue
 		}
@@ -65,12 +65,15 @@ func (m *Model) recursiveLight(s *LightStyles, node Node, start, end vec.Vec3, c
 		}
 		if len(surface.LightSamples) > 0 {
 			var c00, c01, c10, c11 color
-			dsfrac := ds & 15
-			dtfrac := dt & 15
-			ds >>= 4
-			dt >>= 4
-			es := surface.extents[S] >> 4
-			et := surface.extents[T] >> 4
+			// the fractions are in 1/16 of a sample for every lightmap scale
+			shift := surface.lightShift
+			mask := surface.lightScale() - 1
+			dsfrac := ((ds & mask) << 4) >> shift
+			dtfrac := ((dt & mask) << 4) >> shift
+			ds >>= shift
+			dt >>= shift
+			es := surface.extents[S] >> shift
+			et := surface.extents[T] >> shift
 			lineLength := (es + 1) * 3
 			rowLength := et + 1
 			// We want to interpolate and on the far right/bottom we can not read
@@ -130,7 +133,7 @@ func (m *Model) LightAt(p vec.Vec3, s *LightStyles) vec.Vec3 {
 }
 
 func (s *Surface) LightImpactCenter(impact vec.Vec3, st ST) float32 {
-	v := s.TexInfo.Vecs[st]
+	v := s.lightVecs[st]
 	// clamp center of light to corner and check brightness
 	l := vec.Dot(impact, v.Pos) + v.Offset - float32(s.textureMins[st])
 	return l - math.Clamp(0, l+0.5, float32(s.extents[st]))
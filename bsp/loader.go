@@ -11,21 +11,25 @@ import (
 	"math"
 	"strings"
 
+	"goquake/crc"
 	"goquake/filesystem"
 	"goquake/math/vec"
 	qm "goquake/model"
+	"goquake/wad"
 
 	"github.com/chewxy/math32"
 )
 
 func init() {
 	qm.Register(bspVersion, loadM)
+	qm.Register(bsp30Version, loadM)
 	qm.Register(bsp2Version2psb, loadM)
 	qm.Register(bsp2Versionbsp2, loadM)
 }
 
 const (
 	bspVersion          = 29
+	bsp30Version        = 30 // Half-Life
 	bsp2Version2psb     = 'B'<<24 | 'S'<<16 | 'P'<<8 | '2'
 	bsp2Versionbsp2     = '2'<<24 | 'P'<<16 | 'S'<<8 | 'B'
 	qlit                = 'Q' | 'L'<<8 | 'I'<<16 | 'T'<<24
@@ -69,7 +73,19 @@ func load(name string, file filesystem.File) ([]*Model, error) {
 		return io.NewSectionReader(f, int64(d.Offset), int64(d.Size))
 	}
 	switch h.Version {
-	case bspVersion:
+	case bspVersion, bsp30Version:
+		hl := h.Version == bsp30Version
+		x, err := loadBSPX(&h, file)
+		if err != nil {
+			log.Printf("%s: %v", name, err)
+		}
+		// the entities are needed first, they list the wad files
+		mod.Entities, mod.EntitiesCRC = loadEntities(fs(h.Entities, file))
+		var wads []*wad.File
+		if hl {
+			wads = loadWads(mod.Entities)
+		}
+
 		vertexes, err := loadVertexes(fs(h.Vertexes, file))
 		if err != nil {
 			return nil, err
@@ -85,12 +101,20 @@ func load(name string, file filesystem.File) ([]*Model, error) {
 			return nil, err
 		}
 		mod.SurfaceEdges = surfaceEdges
-		textures, err := loadTextures(fs(h.Textures, file), name)
+		textures, err := loadTextures(fs(h.Textures, file), name, hl, wads)
 		if err != nil {
 			return nil, err
 		}
 		mod.Textures = textures
-		mod.lightData = loadLighting(fs(h.Lighting, file), name)
+		if hl {
+			// already RGB
+			mod.lightData, err = io.ReadAll(fs(h.Lighting, file))
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			mod.lightData = loadLighting(fs(h.Lighting, file), name, x)
+		}
 		splanes, err := loadPlanes(fs(h.Planes, file))
 		if err != nil {
 			return nil, err
@@ -109,6 +133,17 @@ func load(name string, file filesystem.File) ([]*Model, error) {
 		if err != nil {
 			return nil, err
 		}
+		if hl {
+			// the offsets are into the RGB data instead of samples
+			for _, sf := range msurfaces {
+				if sf.lightMapOfs != -1 {
+					sf.lightMapOfs /= 3
+				}
+			}
+		}
+		if err := x.setLightmapScales(msurfaces); err != nil {
+			return nil, err
+		}
 		if err := calcSurfaceExtras(msurfaces, mod.Vertexes, mod.Edges, mod.SurfaceEdges, mod.lightData); err != nil {
 			return nil, err
 		}
@@ -156,8 +191,6 @@ func load(name string, file filesystem.File) ([]*Model, error) {
 		}
 		mod.ClipNodes = mcn
 
-		mod.Entities = ParseEntities(fs(h.Entities, file))
-
 		submod, err := loadSubmodels(fs(h.Models, file))
 		if err != nil {
 			return nil, err
@@ -167,8 +200,16 @@ func load(name string, file filesystem.File) ([]*Model, error) {
 			return nil, err
 		}
 		mod.Submodels = msm
+		brushes, err := x.brushes(len(msm))
+		if err != nil {
+			log.Printf("%s: %v", name, err)
+			brushes = nil
+		}
 
 		makeHulls(&mod.Hulls, mod.ClipNodes, mod.Planes, mod.Nodes)
+		if hl {
+			setHLHulls(&mod.Hulls)
+		}
 		mod.FrameCount = 2
 
 		mod.Node = mod.Nodes[0]
@@ -189,6 +230,9 @@ func load(name string, file filesystem.File) ([]*Model, error) {
 				m.Hulls[j].LastClipNode = len(mod.ClipNodes) - 1
 			}
 			m.Surfaces = m.Surfaces[sub.FirstFace : sub.FirstFace+sub.FaceCount]
+			if brushes != nil {
+				m.brushes = brushes[i]
+			}
 			m.mins = sub.Mins
 			m.maxs = sub.Maxs
 			m.calcRadius()
@@ -400,26 +444,32 @@ func calcSurfaceExtras(ss []*Surface, vs []*MVertex, es []*MEdge, ses []int32, l
 				}
 			}
 		}
-		// Despite the claim above only a limited number of bits are stored
-		mi := [2]int{
-			int(math32.Floor(mins[S] / 16)),
-			int(math32.Floor(mins[T] / 16)),
-		}
-		ma := [2]int{
-			int(math32.Ceil(maxs[S] / 16)),
-			int(math32.Ceil(maxs[T] / 16)),
-		}
-		s.textureMins = [2]int{
-			mi[S] * 16,
-			mi[T] * 16,
+		scale := s.lightScale()
+		if !s.decoupled {
+			// Despite the claim above only a limited number of bits are stored
+			fs := float32(scale)
+			mi := [2]int{
+				int(math32.Floor(mins[S] / fs)),
+				int(math32.Floor(mins[T] / fs)),
+			}
+			ma := [2]int{
+				int(math32.Ceil(maxs[S] / fs)),
+				int(math32.Ceil(maxs[T] / fs)),
+			}
+			s.textureMins = [2]int{
+				mi[S] * scale,
+				mi[T] * scale,
+			}
+			s.extents[S] = (ma[S] - mi[S]) * scale
+			s.extents[T] = (ma[T] - mi[T]) * scale
 		}
-		s.extents[S] = (ma[S] - mi[S]) * 16
-		s.extents[T] = (ma[T] - mi[T]) * 16
 		if tex.Flags&texSpecial == 0 {
-			if s.extents[S] > 2000 {
+			// 2000 texels at the default scale of 16, 125 samples
+			limit := 2000 / 16 * scale
+			if s.extents[S] > limit {
 				s.extents[S] = 1
 			}
-			if s.extents[T] > 2000 {
+			if s.extents[T] > limit {
 				s.extents[T] = 1
 			}
 		}
@@ -431,7 +481,8 @@ func calcSurfaceExtras(ss []*Surface, vs []*MVertex, es []*MEdge, ses []int32, l
 
 		if s.lightMapOfs != -1 {
 			s.LightSamples = lightData[3*s.lightMapOfs:]
-			size := 3 * ((s.extents[S] >> 4) + 1) * ((s.extents[T] >> 4) + 1)
+			smax, tmax := s.lightmapSize()
+			size := 3 * smax * tmax
 			switch {
 			case s.Styles[0] == 255:
 				s.LightSamples = nil
@@ -471,8 +522,11 @@ func calcSurfaceExtras(ss []*Surface, vs []*MVertex, es []*MEdge, ses []int32, l
 				bt := (vec.Dot(v.Pos, tex.Vecs[T].Pos) + tex.Vecs[T].Offset)
 				v.S = bs / float32(tex.Texture.Width)
 				v.T = bt / float32(tex.Texture.Height)
-				v.LightMapS = (bs - float32(s.textureMins[S]) + 8 /*+ float32(s.lightS)*16*/) / (LightMapBlockWidth * 16)
-				v.LightMapT = (bt - float32(s.textureMins[T]) + 8 /*+ float32(s.lightT)*16*/) / (LightMapBlockHeight * 16)
+				ls := (vec.Dot(v.Pos, s.lightVecs[S].Pos) + s.lightVecs[S].Offset)
+				lt := (vec.Dot(v.Pos, s.lightVecs[T].Pos) + s.lightVecs[T].Offset)
+				half := float32(scale) / 2
+				v.LightMapS = (ls - float32(s.textureMins[S]) + half /*+ float32(s.lightS)*16*/) / (LightMapBlockWidth * float32(scale))
+				v.LightMapT = (lt - float32(s.textureMins[T]) + half /*+ float32(s.lightT)*16*/) / (LightMapBlockHeight * float32(scale))
 			}
 		}
 
@@ -490,6 +544,7 @@ func buildSurfacesV0(f []*faceV0, plane []*Plane, texinfo []*TexInfo, modelName
 			NumEdges:     int(sf.ListEdgeNumber),
 			Styles:       sf.LightStyle,
 			lightMapOfs:  sf.LightMapOfs,
+			lightShift:   4,
 			lightmapName: fmt.Sprintf("%s_lightmap%3d", modelName, i),
 		}
 		if sf.Side != 0 {
@@ -497,6 +552,7 @@ func buildSurfacesV0(f []*faceV0, plane []*Plane, texinfo []*TexInfo, modelName
 		}
 		nsf.Plane = plane[sf.PlaneID]
 		nsf.TexInfo = texinfo[sf.TexInfoID]
+		nsf.lightVecs = nsf.TexInfo.Vecs
 
 		if strings.HasPrefix(nsf.TexInfo.Texture.name, "sky") {
 			nsf.Flags |= SurfaceDrawSky | SurfaceDrawTiled
@@ -771,13 +827,21 @@ var (
 	}
 )
 
-func loadTextures(buf *io.SectionReader, modelName string) ([]*Texture, error) {
-	type mipTex struct {
-		Name    [16]byte
-		Width   uint32
-		Height  uint32
-		Offsets [4]uint32
-	}
+// mipTex is the header of a texture in the texture lump or a wad.
+type mipTex struct {
+	Name    [16]byte
+	Width   uint32
+	Height  uint32
+	Offsets [4]uint32
+}
+
+func (m *mipTex) name() string {
+	// 16 or num chars till first '\0', excluding the 0
+	idxn := (bytes.IndexByte(m.Name[:], 0) + 17) % 17
+	return string(m.Name[:idxn])
+}
+
+func loadTextures(buf *io.SectionReader, modelName string, hl bool, wads []*wad.File) ([]*Texture, error) {
 	var numTex int32
 	err := binary.Read(buf, binary.LittleEndian, &numTex)
 	if err != nil || numTex == 0 {
@@ -795,15 +859,12 @@ func loadTextures(buf *io.SectionReader, modelName string) ([]*Texture, error) {
 		if offsets[i] == -1 {
 			continue
 		}
-		// TODO(therjak): external textures
 		buf.Seek(int64(offsets[i]), io.SeekStart)
 		if err := binary.Read(buf, binary.LittleEndian, &mTex); err != nil {
 			// Not checked in orig...
 			return nil, nil
 		}
-		// 16 or num chars till first '\0', excluding the 0
-		idxn := (bytes.IndexByte(mTex.Name[:], 0) + 17) % 17
-		name := string(mTex.Name[:idxn])
+		name := mTex.name()
 		if mTex.Width&15 != 0 || mTex.Height&15 != 0 {
 			return nil, fmt.Errorf("Texture %s not 16 aligned", name)
 		}
@@ -812,6 +873,17 @@ func loadTextures(buf *io.SectionReader, modelName string) ([]*Texture, error) {
 			Width:  int(mTex.Width),
 			Height: int(mTex.Height),
 		}
+		if hl {
+			b, err := hlMipTex(buf, int64(offsets[i]), &mTex, wads)
+			if err != nil {
+				log.Printf("%s: %v", modelName, err)
+			}
+			t[i].loadHLTexture(b, modelName)
+			if !strings.HasPrefix(name, "sky") {
+				t[i].loadExternalTexture(name, modelName)
+			}
+			continue
+		}
 		switch {
 		case strings.HasPrefix(name, "sky"):
 			td := make([]byte, 256*128)
@@ -826,6 +898,7 @@ func loadTextures(buf *io.SectionReader, modelName string) ([]*Texture, error) {
 				return nil, fmt.Errorf("Texture %s not enough bytes", name)
 			}
 			t[i].loadBspTexture(td, name, modelName)
+			t[i].loadExternalTexture(name, modelName)
 		}
 	}
 	t[len(t)-1] = noTextureMip  // lightmapped surfs
@@ -928,7 +1001,19 @@ func loadSurfaceEdges(buf *io.SectionReader) ([]int32, error) {
 	return out, nil
 }
 
-func loadLighting(buf *io.SectionReader, name string) []byte {
+// loadEntities returns the entities of the map and the CRC of the lump.
+func loadEntities(buf *io.SectionReader) ([]*Entity, uint16) {
+	data, err := io.ReadAll(buf)
+	if err != nil {
+		log.Printf("read entities: %v", err)
+		return nil, 0
+	}
+	return ParseEntities(bytes.NewReader(data)), crc.Update(data)
+}
+
+// loadLighting returns the RGB light data. Colored light comes from a .lit
+// file or the BSPX lumps, otherwise the white light of the lump is used.
+func loadLighting(buf *io.SectionReader, name string, x bspx) []byte {
 	litName := filesystem.StripExt(name) + ".lit"
 	litFile, err := filesystem.Open(litName)
 	if err == nil {
@@ -951,6 +1036,9 @@ func loadLighting(buf *io.SectionReader, name string) []byte {
 			log.Printf("read lit: %v", err)
 		}
 	}
+	if rgb := x.lighting(buf.Size()); rgb != nil {
+		return rgb
+	}
 	data, err := io.ReadAll(buf)
 	if err != nil || len(data) == 0 {
 		return nil
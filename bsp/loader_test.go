@@ -5,8 +5,10 @@ package bsp
 import (
 	"bytes"
 	"encoding/binary"
+	"io"
 	"testing"
 
+	"goquake/crc"
 	qm "goquake/model"
 )
 
@@ -25,3 +27,14 @@ func TestQLit(t *testing.T) {
 }
 
 var m qm.Model = &Model{}
+
+func TestLoadEntities(t *testing.T) {
+	lump := []byte("{\n\"classname\" \"worldspawn\"\n}\n")
+	es, c := loadEntities(io.NewSectionReader(bytes.NewReader(lump), 0, int64(len(lump))))
+	if len(es) != 1 {
+		t.Fatalf("got %d entities, want 1", len(es))
+	}
+	if c != crc.Update(lump) {
+		t.Errorf("crc = %04x, want %04x", c, crc.Update(lump))
+	}
+}
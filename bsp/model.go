@@ -169,6 +169,9 @@ type Surface struct {
 	// CachedDLight bool
 	LightSamples []byte
 	lightMapOfs  int32
+	lightShift   uint8         // log2 of the texels per lightmap sample, 4 unless LMSHIFT
+	lightVecs    [2]TexInfoPos // lightmap projection, the texture one unless DECOUPLED_LM
+	decoupled    bool          // textureMins and extents come from DECOUPLED_LM
 }
 
 type TexInfoPos struct {
@@ -263,8 +266,12 @@ type Model struct {
 	Hulls     [MaxMapHulls]Hull
 	VisData   []byte
 	lightData []byte
+	brushes   []*brush // from the BSPX BRUSHLIST, nil for most maps
 
 	Entities []*Entity
+	// EntitiesCRC is the CRC of the entity lump, it names the external
+	// entity file of this version of the map.
+	EntitiesCRC uint16
 
 	Node Node
 }
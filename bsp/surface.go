@@ -11,9 +11,18 @@ var (
 	blockLights [128 * 128 * 3]uint32
 )
 
+// lightScale returns the number of texels per lightmap sample.
+func (s *Surface) lightScale() int {
+	return 1 << s.lightShift
+}
+
+// lightmapSize returns the number of lightmap samples in s and t direction.
+func (s *Surface) lightmapSize() (int, int) {
+	return (s.extents[S] >> s.lightShift) + 1, (s.extents[T] >> s.lightShift) + 1
+}
+
 func (s *Surface) createSurfaceLightmap() {
-	smax := (s.extents[S] >> 4) + 1
-	tmax := (s.extents[T] >> 4) + 1
+	smax, tmax := s.lightmapSize()
 	s.LightmapData = make([]byte, smax*tmax*4)
 	s.LightmapTexture = texture.NewTexture(int32(smax), int32(tmax),
 		texture.TexPrefLinear|texture.TexPrefNoPicMip,
@@ -38,8 +47,7 @@ type DynamicLight interface {
 }
 
 func (s *Surface) BuildLightMap(dynamicStyles LightStyles, frame int, lights []DynamicLight, overbright bool) {
-	smax := (s.extents[S] >> 4) + 1
-	tmax := (s.extents[T] >> 4) + 1
+	smax, tmax := s.lightmapSize()
 	size := smax * tmax
 	lightmap := s.LightSamples
 	for b := range blockLights {
@@ -94,9 +102,9 @@ func (s *Surface) BuildLightMap(dynamicStyles LightStyles, frame int, lights []D
 }
 
 func (s *Surface) addDynamicLights(lights []DynamicLight) {
-	smax := (s.extents[S] >> 4) + 1
-	tmax := (s.extents[T] >> 4) + 1
-	tex := s.TexInfo
+	smax, tmax := s.lightmapSize()
+	scale := s.lightScale()
+	lv := &s.lightVecs
 	for i, l := range lights {
 		if len(s.DLightBits) >= i {
 			break
@@ -114,8 +122,8 @@ func (s *Surface) addDynamicLights(lights []DynamicLight) {
 		minLight = rad - minLight
 		impact := vec.Sub(l.Origin(), vec.Scale(dist, s.Plane.Normal))
 		var local [2]float32
-		local[S] = vec.Dot(impact, tex.Vecs[S].Pos) + tex.Vecs[S].Offset
-		local[T] = vec.Dot(impact, tex.Vecs[T].Pos) + tex.Vecs[T].Offset
+		local[S] = vec.Dot(impact, lv[S].Pos) + lv[S].Offset
+		local[T] = vec.Dot(impact, lv[T].Pos) + lv[T].Offset
 		local[S] -= float32(s.textureMins[S])
 		local[T] -= float32(s.textureMins[T])
 		// 542
@@ -124,9 +132,9 @@ func (s *Surface) addDynamicLights(lights []DynamicLight) {
 		b := l.Color()[2] * 256
 		bidx := 0
 		for t := 0; t < tmax; t++ {
-			td := math32.Abs(local[T] - float32(t*16))
+			td := math32.Abs(local[T] - float32(t*scale))
 			for s := 0; s < smax; s++ {
-				sd := math32.Abs(local[S] - float32(s*16))
+				sd := math32.Abs(local[S] - float32(s*scale))
 				var dist float32
 				if sd > td {
 					dist = sd + td/2
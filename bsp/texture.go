@@ -3,9 +3,12 @@ package bsp
 
 import (
 	"fmt"
+	"path"
+	"strings"
+
+	"goquake/filesystem"
 	"goquake/palette"
 	"goquake/texture"
-	"strings"
 )
 
 func (t *Texture) loadSkyTexture(data []byte, textureName, modelName string) {
@@ -88,3 +91,23 @@ func (t *Texture) loadBspTexture(data []byte, textureName, modelName string) {
 			data)
 	}
 }
+
+// loadExternalTexture replaces the texture with an external image from
+// textures/<mapname>/ or textures/ if there is one.
+func (t *Texture) loadExternalTexture(textureName, modelName string) {
+	flags := texture.TexPrefMipMap
+	if strings.HasPrefix(textureName, "{") {
+		flags |= texture.TexPrefAlpha
+	}
+	// '*' is not allowed in file names on all systems
+	fn := strings.ReplaceAll(textureName, "*", "#")
+	mapName := path.Base(filesystem.StripExt(modelName))
+	tx, fb := texture.External(fmt.Sprintf("%s:%s", modelName, textureName), flags,
+		path.Join("textures", mapName, fn),
+		path.Join("textures", fn))
+	if tx == nil {
+		return
+	}
+	t.Texture = tx
+	t.Fullbright = fb
+}
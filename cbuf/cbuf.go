@@ -83,6 +83,11 @@ func (c *CommandBuffer) Buf() string {
 	return c.buf
 }
 
+// Default returns the process wide command buffer.
+func Default() *CommandBuffer {
+	return &cbuf
+}
+
 // TODO(therjak): the following functions are deprecated and should be removed
 
 func Execute() {
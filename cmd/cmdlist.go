@@ -21,6 +21,11 @@ func (c *Commands) list() cmdList {
 	return cmds
 }
 
+// Names returns the sorted names of all commands.
+func (c *Commands) Names() []string {
+	return c.list()
+}
+
 func (c *Commands) printCmdList() QFunc {
 	return func(a cbuf.Arguments) error {
 		//TODO(therjak):
@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package cmd
+
+import (
+	"slices"
+	"strings"
+)
+
+// ArgsFunc returns the possible values of the next argument of a command.
+// args are the words already typed, args[0] is the command name.
+type ArgsFunc func(args []string) []string
+
+// Completer completes console lines. The first word is completed with the
+// names of its sources, later words by the ArgsFunc of the command.
+type Completer struct {
+	names []func() []string
+	args  map[string]ArgsFunc
+
+	// state to cycle through the matches of the last completion
+	line    string
+	prefix  string
+	matches []string
+	current int
+}
+
+// NewCompleter returns a Completer for the command names returned by names.
+func NewCompleter(names ...func() []string) *Completer {
+	return &Completer{
+		names: names,
+		args:  make(map[string]ArgsFunc),
+	}
+}
+
+// AddArgs sets the completion of the arguments of the command name.
+func (c *Completer) AddArgs(name string, f ArgsFunc) {
+	c.args[strings.ToLower(name)] = f
+}
+
+// Matches returns the sorted completions of the last word of line and the
+// position of this word in line.
+func (c *Completer) Matches(line string) (int, []string) {
+	// only the last command of the line is completed
+	cmd := line[strings.LastIndexByte(line, ';')+1:]
+	words := strings.Fields(cmd)
+	partial := ""
+	if len(words) > 0 && !strings.HasSuffix(cmd, " ") && !strings.HasSuffix(cmd, "\t") {
+		partial = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+	start := len(line) - len(partial)
+
+	var candidates []string
+	if len(words) == 0 {
+		for _, n := range c.names {
+			candidates = append(candidates, n()...)
+		}
+	} else if f, ok := c.args[strings.ToLower(words[0])]; ok {
+		candidates = f(words)
+	}
+
+	lp := strings.ToLower(partial)
+	var matches []string
+	for _, m := range candidates {
+		if strings.HasPrefix(strings.ToLower(m), lp) {
+			matches = append(matches, m)
+		}
+	}
+	slices.Sort(matches)
+	return start, slices.Compact(matches)
+}
+
+// Complete returns line with the last word completed by the first match.
+// Calling it again with the returned line replaces the completion with the
+// next match. If there are several matches they are returned once.
+func (c *Completer) Complete(line string) (string, []string) {
+	if len(c.matches) > 1 && line == c.line {
+		c.current = (c.current + 1) % len(c.matches)
+		c.line = c.prefix + c.matches[c.current]
+		return c.line, nil
+	}
+	start, matches := c.Matches(line)
+	c.matches = matches
+	c.current = 0
+	switch len(matches) {
+	case 0:
+		c.line = ""
+		return line, nil
+	case 1:
+		c.line = line[:start] + matches[0] + " "
+		return c.line, nil
+	}
+	c.prefix = line[:start]
+	c.line = c.prefix + matches[0]
+	return c.line, matches
+}
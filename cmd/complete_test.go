@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package cmd
+
+import (
+	"slices"
+	"testing"
+)
+
+func testCompleter() *Completer {
+	c := NewCompleter(
+		func() []string { return []string{"map", "maps", "echo"} },
+		func() []string { return []string{"Mapper", "echo"} },
+	)
+	c.AddArgs("map", func(args []string) []string {
+		if len(args) != 1 {
+			return nil
+		}
+		return []string{"e1m1", "e1m2", "start"}
+	})
+	return c
+}
+
+func TestMatches(t *testing.T) {
+	c := testCompleter()
+	tests := []struct {
+		line  string
+		start int
+		want  []string
+	}{
+		{"", 0, []string{"Mapper", "echo", "map", "maps"}},
+		{"ma", 0, []string{"Mapper", "map", "maps"}},
+		{"ec", 0, []string{"echo"}},
+		{"echo foo; MA", 10, []string{"Mapper", "map", "maps"}},
+		{"map ", 4, []string{"e1m1", "e1m2", "start"}},
+		{"MAP e1", 4, []string{"e1m1", "e1m2"}},
+		{"map start ", 10, nil},
+		{"echo ", 5, nil},
+		{"x", 0, nil},
+	}
+	for _, test := range tests {
+		start, got := c.Matches(test.line)
+		if start != test.start || !slices.Equal(got, test.want) {
+			t.Errorf("Matches(%q) = %d, %v; want %d, %v", test.line, start, got, test.start, test.want)
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	c := testCompleter()
+	if got, list := c.Complete("ec"); got != "echo " || list != nil {
+		t.Errorf("Complete(ec) = %q, %v", got, list)
+	}
+	got, list := c.Complete("map e")
+	if got != "map e1m1" || !slices.Equal(list, []string{"e1m1", "e1m2"}) {
+		t.Errorf("Complete(map e) = %q, %v", got, list)
+	}
+	// cycle through the matches
+	for _, want := range []string{"map e1m2", "map e1m1", "map e1m2"} {
+		got, list = c.Complete(got)
+		if got != want || list != nil {
+			t.Errorf("cycle = %q, %v; want %q", got, list, want)
+		}
+	}
+	// a changed line starts a new completion
+	if got, list := c.Complete("map s"); got != "map start " || list != nil {
+		t.Errorf("Complete(map s) = %q, %v", got, list)
+	}
+	if got, list := c.Complete("nothing"); got != "nothing" || list != nil {
+		t.Errorf("Complete(nothing) = %q, %v", got, list)
+	}
+}
@@ -5,6 +5,7 @@ package cvar
 import (
 	"fmt"
 	"log/slog"
+	"math"
 	"sort"
 	"strconv"
 
@@ -49,6 +50,11 @@ type Cvar struct {
 	rom          bool
 	user         bool
 	seta         bool
+
+	description string
+	kind        Kind
+	min, max    float64
+	values      []string // allowed values of KindEnum
 }
 
 func (cv *Cvar) Archive() bool {
@@ -71,10 +77,16 @@ func (cv *Cvar) SetCallback(cb CallbackFunc) {
 	cv.callback = cb
 }
 
+// SetByString sets the value to s. Values which are not valid for the type
+// of cv are rejected with a message.
 func (cv *Cvar) SetByString(s string) {
 	if cv.rom {
 		return
 	}
+	if err := cv.Validate(s); err != nil {
+		conlog.Printf("%s: %v\n", cv.name, err)
+		return
+	}
 	cv.stringValue = s
 	pf, _ := strconv.ParseFloat(cv.stringValue, 32)
 	cv.value = float32(pf)
@@ -91,6 +103,11 @@ func (cv *Cvar) String() string {
 	return cv.stringValue
 }
 
+// Default returns the value the cvar was created with.
+func (cv *Cvar) Default() string {
+	return cv.defaultValue
+}
+
 func (cv *Cvar) Name() string {
 	return cv.name
 }
@@ -121,9 +138,19 @@ func (cv *Cvar) Bool() bool {
 	return cv.stringValue != "0"
 }
 
-func New(name, value string, flags flag) *Cvar {
-	cv := &Cvar{name: name, defaultValue: value}
+// New creates a cvar with the default value value. The options add the
+// metadata which is used to validate new values.
+func New(name, value string, flags flag, opts ...Option) *Cvar {
+	cv := &Cvar{
+		name:         name,
+		defaultValue: value,
+		min:          math.Inf(-1),
+		max:          math.Inf(1),
+	}
 	cv.SetByString(value)
+	for _, o := range opts {
+		o(cv)
+	}
 
 	if flags&ARCHIVE != 0 {
 		cv.archive = true
@@ -151,6 +178,16 @@ func (c *Cvars) All() []*Cvar {
 	return r
 }
 
+// Names returns the sorted names of all cvars.
+func (c *Cvars) Names() []string {
+	r := make([]string, 0, len(*c))
+	for n := range *c {
+		r = append(r, n)
+	}
+	sort.Strings(r)
+	return r
+}
+
 func (c *Cvars) Add(cv *Cvar) error {
 	if _, ok := (*c)[cv.name]; ok {
 		return fmt.Errorf("Can't register variable %s, already defined\n", cv.name)
@@ -0,0 +1,173 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package cvar
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Kind is the type of the values of a cvar.
+type Kind int
+
+const (
+	KindString Kind = iota
+	KindBool
+	KindInt
+	KindFloat
+	KindEnum
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindBool:
+		return "bool"
+	case KindInt:
+		return "int"
+	case KindFloat:
+		return "float"
+	case KindEnum:
+		return "enum"
+	}
+	return "string"
+}
+
+// Option adds metadata to a cvar.
+type Option func(cv *Cvar)
+
+// Desc sets the description shown by help.
+func Desc(text string) Option {
+	return func(cv *Cvar) { cv.description = text }
+}
+
+// Bool only allows the values 0 and 1.
+func Bool() Option {
+	return func(cv *Cvar) { cv.kind = KindBool }
+}
+
+// Int only allows integer values.
+func Int() Option {
+	return func(cv *Cvar) { cv.kind = KindInt }
+}
+
+// Float only allows numbers.
+func Float() Option {
+	return func(cv *Cvar) { cv.kind = KindFloat }
+}
+
+// Range limits the values of int and float cvars to [lo,hi].
+func Range(lo, hi float32) Option {
+	return func(cv *Cvar) {
+		cv.min = float64(lo)
+		cv.max = float64(hi)
+	}
+}
+
+// Min limits the values of int and float cvars to values >= lo.
+func Min(lo float32) Option {
+	return func(cv *Cvar) { cv.min = float64(lo) }
+}
+
+// Enum only allows the given values.
+func Enum(values ...string) Option {
+	return func(cv *Cvar) {
+		cv.kind = KindEnum
+		cv.values = values
+	}
+}
+
+func (cv *Cvar) Description() string {
+	return cv.description
+}
+
+func (cv *Cvar) Kind() Kind {
+	return cv.kind
+}
+
+// Validate returns an error if s is not an allowed value of cv.
+func (cv *Cvar) Validate(s string) error {
+	switch cv.kind {
+	case KindString:
+		return nil
+	case KindEnum:
+		if !slices.Contains(cv.values, s) {
+			return fmt.Errorf("\"%s\" is not one of %s", s, strings.Join(cv.values, ", "))
+		}
+		return nil
+	}
+	// the bounds are float32 as well
+	v, err := strconv.ParseFloat(s, 32)
+	if err != nil {
+		return fmt.Errorf("\"%s\" is not a number", s)
+	}
+	switch cv.kind {
+	case KindBool:
+		if v != 0 && v != 1 {
+			return fmt.Errorf("\"%s\" is neither 0 nor 1", s)
+		}
+	case KindInt:
+		if v != math.Trunc(v) {
+			return fmt.Errorf("\"%s\" is not an integer", s)
+		}
+	}
+	if v < cv.min || v > cv.max {
+		return fmt.Errorf("%s is invalid, allowed is %s", s, cv.rangeString())
+	}
+	return nil
+}
+
+func (cv *Cvar) rangeString() string {
+	f := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 32)
+	}
+	switch {
+	case cv.min == math.Inf(-1) && cv.max == math.Inf(1):
+		return ""
+	case cv.max == math.Inf(1):
+		return fmt.Sprintf("%s or more", f(cv.min))
+	case cv.min == math.Inf(-1):
+		return fmt.Sprintf("%s or less", f(cv.max))
+	}
+	return fmt.Sprintf("%s to %s", f(cv.min), f(cv.max))
+}
+
+// Help returns the description, the allowed values and the flags of cv.
+func (cv *Cvar) Help() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\"%s\" is \"%s\", default \"%s\"\n", cv.name, cv.stringValue, cv.defaultValue)
+	if cv.description != "" {
+		fmt.Fprintf(&b, "  %s\n", cv.description)
+	}
+	fmt.Fprintf(&b, "  type: %s", cv.kind)
+	switch cv.kind {
+	case KindInt, KindFloat:
+		if r := cv.rangeString(); r != "" {
+			fmt.Fprintf(&b, ", %s", r)
+		}
+	case KindEnum:
+		fmt.Fprintf(&b, ", %s", strings.Join(cv.values, ", "))
+	}
+	b.WriteString("\n")
+	var flags []string
+	for _, f := range []struct {
+		set  bool
+		name string
+	}{
+		{cv.archive, "archive"},
+		{cv.notify, "notify"},
+		{cv.serverinfo, "serverinfo"},
+		{cv.rom, "read only"},
+		{cv.user, "user defined"},
+	} {
+		if f.set {
+			flags = append(flags, f.name)
+		}
+	}
+	if len(flags) != 0 {
+		fmt.Fprintf(&b, "  flags: %s\n", strings.Join(flags, ", "))
+	}
+	return b.String()
+}
@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package cvar
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"goquake/conlog"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		cv    *Cvar
+		value string
+		ok    bool
+	}{
+		{New("s", "x", NONE), "abc", true},
+		{New("b", "0", NONE, Bool()), "1", true},
+		{New("b", "0", NONE, Bool()), "2", false},
+		{New("b", "0", NONE, Bool()), "yes", false},
+		{New("i", "1", NONE, Int(), Range(0, 3)), "3", true},
+		{New("i", "1", NONE, Int(), Range(0, 3)), "2.0", true},
+		{New("i", "1", NONE, Int(), Range(0, 3)), "17", false},
+		{New("i", "1", NONE, Int(), Range(0, 3)), "1.5", false},
+		{New("i", "1", NONE, Int(), Range(0, 3)), "abc", false},
+		{New("f", "1", NONE, Float(), Min(0.1)), "0.1", true},
+		{New("f", "1", NONE, Float(), Min(0.1)), "1e6", true},
+		{New("f", "1", NONE, Float(), Min(0.1)), "0", false},
+		{New("e", "a", NONE, Enum("a", "b")), "b", true},
+		{New("e", "a", NONE, Enum("a", "b")), "c", false},
+	}
+	for _, test := range tests {
+		if err := test.cv.Validate(test.value); (err == nil) != test.ok {
+			t.Errorf("%s.Validate(%q) = %v", test.cv.Name(), test.value, err)
+		}
+	}
+}
+
+func TestSetRejects(t *testing.T) {
+	var log strings.Builder
+	conlog.SetPrintf(func(format string, v ...any) {
+		fmt.Fprintf(&log, format, v...)
+	})
+	defer conlog.SetPrintf(nil)
+	cv := New("skill", "1", NONE, Int(), Range(0, 3))
+	cv.SetByString("17")
+	if cv.String() != "1" || cv.Value() != 1 {
+		t.Errorf("skill 17 was accepted: %q", cv.String())
+	}
+	if want := "skill: 17 is invalid, allowed is 0 to 3\n"; log.String() != want {
+		t.Errorf("got message %q, want %q", log.String(), want)
+	}
+	cv.SetValue(2)
+	if cv.String() != "2" {
+		t.Errorf("SetValue(2) = %q", cv.String())
+	}
+	cv.SetValue(2.5)
+	if cv.String() != "2" {
+		t.Errorf("SetValue(2.5) was accepted: %q", cv.String())
+	}
+}
+
+func TestHelp(t *testing.T) {
+	cv := New("fov", "90", ARCHIVE, Float(), Range(10, 170), Desc("field of view"))
+	h := cv.Help()
+	for _, want := range []string{`"fov" is "90", default "90"`, "field of view", "float, 10 to 170", "archive"} {
+		if !strings.Contains(h, want) {
+			t.Errorf("Help() = %q, missing %q", h, want)
+		}
+	}
+}
@@ -12,202 +12,220 @@ import (
 )
 
 var (
-	ClientColor = cvar.New("_cl_color", "0", cvar.ARCHIVE)
-	ClientName  = cvar.New("_cl_name", "player", cvar.ARCHIVE)
-
-	AmbientFade           = cvar.New("ambient_fade", "100", cvar.NONE)
-	AmbientLevel          = cvar.New("ambient_level", "0.3", cvar.NONE)
-	BackgroundVolume      = cvar.New("bgmvolume", "1", cvar.ARCHIVE) // cd music volume, therjak: this is dead, only used in menu
-	Campaign              = cvar.New("campaign", "0", cvar.NONE)     // 2021 release
-	CfgUnbindAll          = cvar.New("cfg_unbindall", "1", cvar.ARCHIVE)
-	ChaseActive           = cvar.New("chase_active", "0", cvar.NONE)
-	ChaseBack             = cvar.New("chase_back", "100", cvar.NONE)
-	ChaseRight            = cvar.New("chase_right", "0", cvar.NONE)
-	ChaseUp               = cvar.New("chase_up", "16", cvar.NONE)
-	ClientAngleSpeedKey   = cvar.New("cl_anglespeedkey", "1.5", cvar.NONE)
-	ClientBackSpeed       = cvar.New("cl_backspeed", "200", cvar.ARCHIVE)
-	ClientBob             = cvar.New("cl_bob", "0.02", cvar.NONE)
-	ClientBobCycle        = cvar.New("cl_bobcycle", "0.6", cvar.NONE)
-	ClientBobUp           = cvar.New("cl_bobup", "0.5", cvar.NONE)
-	ClientForwardSpeed    = cvar.New("cl_forwardspeed", "200", cvar.ARCHIVE)
-	ClientMaxPitch        = cvar.New("cl_maxpitch", "90", cvar.ARCHIVE)
-	ClientMinPitch        = cvar.New("cl_minpitch", "-90", cvar.ARCHIVE)
-	ClientMoveSpeedKey    = cvar.New("cl_movespeedkey", "2.0", cvar.NONE)
-	ClientNoLerp          = cvar.New("cl_nolerp", "0", cvar.NONE)
-	ClientPitchSpeed      = cvar.New("cl_pitchspeed", "150", cvar.NONE)
-	ClientRollAngle       = cvar.New("cl_rollangle", "2.0", cvar.NONE)
-	ClientRollSpeed       = cvar.New("cl_rollspeed", "200", cvar.NONE)
-	ClientShowNet         = cvar.New("cl_shownet", "0", cvar.NONE)
-	ClientSideSpeed       = cvar.New("cl_sidespeed", "350", cvar.NONE)
-	ClientUpSpeed         = cvar.New("cl_upspeed", "200", cvar.NONE)
-	ClientYawSpeed        = cvar.New("cl_yawspeed", "140", cvar.NONE)
-	ConsoleLogCenterPrint = cvar.New("con_logcenterprint", "1", cvar.NONE)
-	ConsoleNotifyTime     = cvar.New("con_notifytime", "3", cvar.NONE)
-	Contrast              = cvar.New("contrast", "1", cvar.ARCHIVE)
-	Coop                  = cvar.New("coop", "0", cvar.NONE)
-	Crosshair             = cvar.New("crosshair", "0", cvar.ARCHIVE)
-	DeathMatch            = cvar.New("deathmatch", "0", cvar.NONE)
-	DevStats              = cvar.New("devstats", "0", cvar.NONE)
-	Developer             = cvar.New("developer", "0", cvar.NONE)
-	ExternalEnts          = cvar.New("external_ents", "1", cvar.ARCHIVE)
-	Fov                   = cvar.New("fov", "90", cvar.NONE)
-	FovAdapt              = cvar.New("fov_adapt", "1", cvar.ARCHIVE)
-	FragLimit             = cvar.New("fraglimit", "0", cvar.NOTIFY|cvar.SERVERINFO)
-	GameCfg               = cvar.New("gamecfg", "0", cvar.NONE)
-	Gamma                 = cvar.New("gamma", "1", cvar.ARCHIVE)
-	GlAffineModels        = cvar.New("gl_affinemodels", "0", cvar.NONE)
-	GlColorShiftPercent   = cvar.New("gl_cshiftpercent", "100", cvar.NONE)
-	GlClear               = cvar.New("gl_clear", "1", cvar.NONE)
-	GlCull                = cvar.New("gl_cull", "1", cvar.NONE)
-	GlFarClip             = cvar.New("gl_farclip", "16384", cvar.ARCHIVE)
-	GlFinish              = cvar.New("gl_finish", "0", cvar.NONE)
-	GlFlashBlend          = cvar.New("gl_flashblend", "0", cvar.ARCHIVE)
-	GlFullBrights         = cvar.New("gl_fullbrights", "1", cvar.ARCHIVE)
-	GlMaxSize             = cvar.New("gl_max_size", "0", cvar.NONE)
-	GlNoColors            = cvar.New("gl_nocolors", "0", cvar.NONE)
-	GlOverBright          = cvar.New("gl_overbright", "1", cvar.ARCHIVE)
-	GlOverBrightModels    = cvar.New("gl_overbright_models", "1", cvar.ARCHIVE)
-	GlPicMip              = cvar.New("gl_picmip", "0", cvar.NONE)
-	GlPlayerMip           = cvar.New("gl_playermip", "0", cvar.NONE)
-	GlPolyBlend           = cvar.New("gl_polyblend", "1", cvar.NONE)
-	GlSmoothModels        = cvar.New("gl_smoothmodels", "1", cvar.NONE)
-	GlSubdivideSize       = cvar.New("gl_subdivide_size", "128", cvar.ARCHIVE)
+	ClientColor = cvar.New("_cl_color", "0", cvar.ARCHIVE, cvar.Int(), cvar.Range(0, 255), cvar.Desc("player colors, shirt * 16 + pants"))
+	ClientName  = cvar.New("_cl_name", "player", cvar.ARCHIVE, cvar.Desc("player name"))
+
+	AmbientFade           = cvar.New("ambient_fade", "100", cvar.NONE, cvar.Float(), cvar.Min(0), cvar.Desc("ambient sound volume change per second"))
+	AmbientLevel          = cvar.New("ambient_level", "0.3", cvar.NONE, cvar.Float(), cvar.Range(0, 1), cvar.Desc("ambient sound volume"))
+	BackgroundVolume      = cvar.New("bgmvolume", "1", cvar.ARCHIVE, cvar.Float(), cvar.Range(0, 1), cvar.Desc("music volume"))
+	Campaign              = cvar.New("campaign", "0", cvar.NONE, cvar.Int(), cvar.Desc("used by the progs of the 2021 release"))
+	CfgUnbindAll          = cvar.New("cfg_unbindall", "1", cvar.ARCHIVE, cvar.Bool(), cvar.Desc("unbind all keys before config.cfg gets executed"))
+	ChaseActive           = cvar.New("chase_active", "0", cvar.NONE, cvar.Bool(), cvar.Desc("third person camera"))
+	ChaseBack             = cvar.New("chase_back", "100", cvar.NONE, cvar.Float(), cvar.Desc("distance of the third person camera behind the player"))
+	ChaseRight            = cvar.New("chase_right", "0", cvar.NONE, cvar.Float(), cvar.Desc("sideways offset of the third person camera"))
+	ChaseUp               = cvar.New("chase_up", "16", cvar.NONE, cvar.Float(), cvar.Desc("height of the third person camera"))
+	ClientAngleSpeedKey   = cvar.New("cl_anglespeedkey", "1.5", cvar.NONE, cvar.Float(), cvar.Desc("turn speed multiplier while running"))
+	ClientBackSpeed       = cvar.New("cl_backspeed", "200", cvar.ARCHIVE, cvar.Float(), cvar.Min(0), cvar.Desc("backward movement speed"))
+	ClientBob             = cvar.New("cl_bob", "0.02", cvar.NONE, cvar.Float(), cvar.Desc("amount of the view bobbing"))
+	ClientBobCycle        = cvar.New("cl_bobcycle", "0.6", cvar.NONE, cvar.Float(), cvar.Min(0), cvar.Desc("seconds of one view bobbing cycle"))
+	ClientBobUp           = cvar.New("cl_bobup", "0.5", cvar.NONE, cvar.Float(), cvar.Range(0, 1), cvar.Desc("fraction of the view bobbing cycle moving up"))
+	ClientForwardSpeed    = cvar.New("cl_forwardspeed", "200", cvar.ARCHIVE, cvar.Float(), cvar.Min(0), cvar.Desc("forward movement speed"))
+	ClientMaxPitch        = cvar.New("cl_maxpitch", "90", cvar.ARCHIVE, cvar.Float(), cvar.Range(-90, 90), cvar.Desc("maximum pitch when looking down"))
+	ClientMinPitch        = cvar.New("cl_minpitch", "-90", cvar.ARCHIVE, cvar.Float(), cvar.Range(-90, 90), cvar.Desc("minimum pitch when looking up"))
+	ClientMoveSpeedKey    = cvar.New("cl_movespeedkey", "2.0", cvar.NONE, cvar.Float(), cvar.Desc("movement speed multiplier while running"))
+	ClientNoLerp          = cvar.New("cl_nolerp", "0", cvar.NONE, cvar.Bool(), cvar.Desc("disable the interpolation of entity movement"))
+	ClientNoPrediction    = cvar.New("cl_nopred", "0", cvar.NONE, cvar.Bool(), cvar.Desc("disable the client side movement prediction"))
+	ClientPitchSpeed      = cvar.New("cl_pitchspeed", "150", cvar.NONE, cvar.Float(), cvar.Desc("keyboard look up and down speed"))
+	ClientProtobuf        = cvar.New("cl_protobuf", "1", cvar.ARCHIVE, cvar.Bool(), cvar.Desc("use the protobuf messages of the goquake protocol"))
+	ClientRollAngle       = cvar.New("cl_rollangle", "2.0", cvar.NONE, cvar.Float(), cvar.Desc("view roll angle while strafing"))
+	ClientRollSpeed       = cvar.New("cl_rollspeed", "200", cvar.NONE, cvar.Float(), cvar.Min(0), cvar.Desc("strafe speed needed for the full view roll angle"))
+	ClientShowNet         = cvar.New("cl_shownet", "0", cvar.NONE, cvar.Int(), cvar.Range(0, 2), cvar.Desc("print the received network messages"))
+	ClientSideSpeed       = cvar.New("cl_sidespeed", "350", cvar.NONE, cvar.Float(), cvar.Min(0), cvar.Desc("sideways movement speed"))
+	ClientSpectator       = cvar.New("spectator", "0", cvar.ARCHIVE, cvar.Bool(), cvar.Desc("join servers as spectator"))
+	ClientUpSpeed         = cvar.New("cl_upspeed", "200", cvar.NONE, cvar.Float(), cvar.Min(0), cvar.Desc("up and down movement speed when swimming"))
+	ClientYawSpeed        = cvar.New("cl_yawspeed", "140", cvar.NONE, cvar.Float(), cvar.Desc("keyboard turn speed"))
+	ConsoleLogCenterPrint = cvar.New("con_logcenterprint", "1", cvar.NONE, cvar.Int(), cvar.Range(0, 2), cvar.Desc("log centerprints to the console, 1 in single player, 2 always"))
+	ConsoleNotifyTime     = cvar.New("con_notifytime", "3", cvar.NONE, cvar.Float(), cvar.Min(0), cvar.Desc("seconds the notify lines stay visible"))
+	Contrast              = cvar.New("contrast", "1", cvar.ARCHIVE, cvar.Float(), cvar.Min(0), cvar.Desc("brightness of the screen"))
+	Coop                  = cvar.New("coop", "0", cvar.NONE, cvar.Int(), cvar.Min(0), cvar.Desc("cooperative game"))
+	Crosshair             = cvar.New("crosshair", "0", cvar.ARCHIVE, cvar.Bool(), cvar.Desc("show the crosshair"))
+	CSQCProgCRC           = cvar.New("csqc_progcrc", "-1", cvar.NONE, cvar.Int(), cvar.Desc("crc of the csqc progs, set by the server"))
+	CSQCProgSize          = cvar.New("csqc_progsize", "-1", cvar.NONE, cvar.Int(), cvar.Desc("size of the csqc progs, set by the server"))
+	DeathMatch            = cvar.New("deathmatch", "0", cvar.NONE, cvar.Int(), cvar.Min(0), cvar.Desc("deathmatch mode, the progs define the meaning of values above 1"))
+	DevStats              = cvar.New("devstats", "0", cvar.NONE, cvar.Bool(), cvar.Desc("print the peak values of the developer statistics"))
+	Developer             = cvar.New("developer", "0", cvar.NONE, cvar.Int(), cvar.Min(0), cvar.Desc("print developer messages"))
+	ExternalEnts          = cvar.New("external_ents", "1", cvar.ARCHIVE, cvar.Bool(), cvar.Desc("load entities from maps/<map>.ent"))
+	Fov                   = cvar.New("fov", "90", cvar.NONE, cvar.Float(), cvar.Range(10, 170), cvar.Desc("horizontal field of view in degrees"))
+	FovAdapt              = cvar.New("fov_adapt", "1", cvar.ARCHIVE, cvar.Bool(), cvar.Desc("adapt the field of view to the aspect ratio of the screen"))
+	FragLimit             = cvar.New("fraglimit", "0", cvar.NOTIFY|cvar.SERVERINFO, cvar.Int(), cvar.Min(0), cvar.Desc("frags needed to end a deathmatch map, 0 means no limit"))
+	GameCfg               = cvar.New("gamecfg", "0", cvar.NONE, cvar.Float(), cvar.Desc("kept for the progs"))
+	Gamma                 = cvar.New("gamma", "1", cvar.ARCHIVE, cvar.Float(), cvar.Min(0), cvar.Desc("gamma correction, lower values are brighter"))
+	GlAffineModels        = cvar.New("gl_affinemodels", "0", cvar.NONE, cvar.Bool(), cvar.Desc("draw models without perspective correct texturing"))
+	GlColorShiftPercent   = cvar.New("gl_cshiftpercent", "100", cvar.NONE, cvar.Float(), cvar.Range(0, 100), cvar.Desc("strength of the color shifts like damage and powerups"))
+	GlClear               = cvar.New("gl_clear", "1", cvar.NONE, cvar.Bool(), cvar.Desc("clear the screen before every frame"))
+	GlCull                = cvar.New("gl_cull", "1", cvar.NONE, cvar.Bool(), cvar.Desc("cull back faces"))
+	GlExternalTextures    = cvar.New("gl_externaltextures", "1", cvar.ARCHIVE, cvar.Bool(), cvar.Desc("use png, jpeg and tga replacements of textures"))
+	GlFarClip             = cvar.New("gl_farclip", "16384", cvar.ARCHIVE, cvar.Float(), cvar.Min(0), cvar.Desc("far clipping plane distance"))
+	GlFinish              = cvar.New("gl_finish", "0", cvar.NONE, cvar.Bool(), cvar.Desc("wait for the gpu to finish every frame"))
+	GlFlashBlend          = cvar.New("gl_flashblend", "0", cvar.ARCHIVE, cvar.Bool(), cvar.Desc("draw dynamic lights as glowing spheres instead of lightmap updates"))
+	GlFullBrights         = cvar.New("gl_fullbrights", "1", cvar.ARCHIVE, cvar.Bool(), cvar.Desc("draw the fullbright colors of textures"))
+	GlMaxSize             = cvar.New("gl_max_size", "0", cvar.NONE, cvar.Int(), cvar.Min(0), cvar.Desc("maximum texture size, 0 uses the hardware limit"))
+	GlNoColors            = cvar.New("gl_nocolors", "0", cvar.NONE, cvar.Bool(), cvar.Desc("do not colorize player skins"))
+	GlOverBright          = cvar.New("gl_overbright", "1", cvar.ARCHIVE, cvar.Bool(), cvar.Desc("allow lightmaps brighter than the textures"))
+	GlOverBrightModels    = cvar.New("gl_overbright_models", "1", cvar.ARCHIVE, cvar.Bool(), cvar.Desc("allow models brighter than their skins"))
+	GlPicMip              = cvar.New("gl_picmip", "0", cvar.NONE, cvar.Int(), cvar.Min(0), cvar.Desc("mip levels skipped for world textures"))
+	GlPlayerMip           = cvar.New("gl_playermip", "0", cvar.NONE, cvar.Int(), cvar.Min(0), cvar.Desc("mip levels skipped for player skins"))
+	GlPolyBlend           = cvar.New("gl_polyblend", "1", cvar.NONE, cvar.Bool(), cvar.Desc("draw the color shifts like damage and powerups"))
+	GlSmoothModels        = cvar.New("gl_smoothmodels", "1", cvar.NONE, cvar.Bool(), cvar.Desc("smooth shading of models"))
+	GlSubdivideSize       = cvar.New("gl_subdivide_size", "128", cvar.ARCHIVE, cvar.Float(), cvar.Min(8), cvar.Desc("size of the polygons of warped water surfaces"))
 	// correct value is filled in later.
-	GlTextureMode          = cvar.New("gl_texturemode", "", cvar.ARCHIVE)
-	GlTextureAnisotropy    = cvar.New("gl_texture_anisotropy", "1", cvar.ARCHIVE)
-	GlTripleBuffer         = cvar.New("gl_triplebuffer", "1", cvar.ARCHIVE)
-	GlZFix                 = cvar.New("gl_zfix", "0", cvar.NONE)
-	HostFrameRate          = cvar.New("host_framerate", "0", cvar.NONE)
-	HostMaxFps             = cvar.New("host_maxfps", "72", cvar.ARCHIVE)
-	HostName               = cvar.New("hostname", "UNNAMED", cvar.NONE)
-	HostSpeeds             = cvar.New("host_speeds", "0", cvar.NONE)
-	HostTimeScale          = cvar.New("host_timescale", "0", cvar.NONE)
-	InputDebugKeys         = cvar.New("in_debugkeys", "0", cvar.NONE)
-	LoadAs8Bit             = cvar.New("loadas8bit", "0", cvar.NONE)
-	LookSpring             = cvar.New("lookspring", "0", cvar.ARCHIVE)
-	LookStrafe             = cvar.New("lookstrafe", "0", cvar.ARCHIVE)
-	MaxEdicts              = cvar.New("max_edicts", "15000", cvar.NONE)
-	MouseForward           = cvar.New("m_forward", "1", cvar.ARCHIVE)
-	MousePitch             = cvar.New("m_pitch", "0.022", cvar.ARCHIVE)
-	MouseSide              = cvar.New("m_side", "0.8", cvar.ARCHIVE)
-	MouseYaw               = cvar.New("m_yaw", "0.022", cvar.ARCHIVE)
-	NetMessageTimeout      = cvar.New("net_messagetimeout", "300", cvar.NONE)
-	NoExit                 = cvar.New("noexit", "0", cvar.NOTIFY|cvar.SERVERINFO)
-	NoMonsters             = cvar.New("nomonsters", "0", cvar.NONE)
-	NoSound                = cvar.New("nosound", "0", cvar.NONE)
-	Pausable               = cvar.New("pausable", "1", cvar.NONE)
-	Precache               = cvar.New("precache", "1", cvar.NONE)
-	RClearColor            = cvar.New("r_clearcolor", "2", cvar.ARCHIVE)
-	RDrawEntities          = cvar.New("r_drawentities", "1", cvar.NONE)
-	RDrawFlat              = cvar.New("r_drawflat", "0", cvar.NONE)
-	RDrawViewModel         = cvar.New("r_drawviewmodel", "1", cvar.NONE)
-	RDrawWorld             = cvar.New("r_drawworld", "1", cvar.NONE)
-	RDynamic               = cvar.New("r_dynamic", "1", cvar.ARCHIVE)
-	RFastSky               = cvar.New("r_fastsky", "0", cvar.NONE)
-	RFlatLightStyles       = cvar.New("r_flatlightstyles", "0", cvar.NONE)
-	RFullBright            = cvar.New("r_fullbright", "0", cvar.NONE)
-	RLavaAlpha             = cvar.New("r_lavaalpha", "0", cvar.NONE)
-	RLerpModels            = cvar.New("r_lerpmodels", "1", cvar.NONE)
-	RLerpMove              = cvar.New("r_lerpmove", "1", cvar.NONE)
-	RLightMap              = cvar.New("r_lightmap", "0", cvar.NONE)
-	RNoRefresh             = cvar.New("r_norefresh", "0", cvar.NONE)
-	RNoVis                 = cvar.New("r_novis", "0", cvar.ARCHIVE)
-	ROldSkyLeaf            = cvar.New("r_oldskyleaf", "0", cvar.NONE)
-	ROldWater              = cvar.New("r_oldwater", "0", cvar.ARCHIVE)
-	RParticles             = cvar.New("r_particles", "1", cvar.ARCHIVE)
-	RPos                   = cvar.New("r_pos", "0", cvar.NONE)
-	RQuadParticles         = cvar.New("r_quadparticles", "1", cvar.ARCHIVE)
-	RShadows               = cvar.New("r_shadows", "0", cvar.ARCHIVE)
-	RShowBoxes             = cvar.New("r_showbboxes", "0", cvar.NONE)
-	RShowTris              = cvar.New("r_showtris", "0", cvar.NONE)
-	RSkyAlpha              = cvar.New("r_skyalpha", "1", cvar.NONE)
-	RSkyFog                = cvar.New("r_skyfog", "0.5", cvar.NONE)
-	RSkyQuality            = cvar.New("r_sky_quality", "12", cvar.NONE)
-	RSlimeAlpha            = cvar.New("r_slimealpha", "0", cvar.NONE)
-	RSpeeds                = cvar.New("r_speeds", "0", cvar.NONE)
-	RTeleAlpha             = cvar.New("r_telealpha", "0", cvar.NONE)
-	RWaterAlpha            = cvar.New("r_wateralpha", "1", cvar.ARCHIVE)
-	RWaterQuality          = cvar.New("r_waterquality", "8", cvar.NONE)
-	RWaterWarp             = cvar.New("r_waterwarp", "1", cvar.NONE)
-	SameLevel              = cvar.New("samelevel", "0", cvar.NONE)
-	Saved1                 = cvar.New("saved1", "0", cvar.ARCHIVE)
-	Saved2                 = cvar.New("saved2", "0", cvar.ARCHIVE)
-	Saved3                 = cvar.New("saved3", "0", cvar.ARCHIVE)
-	Saved4                 = cvar.New("saved4", "0", cvar.ARCHIVE)
-	SavedGameCfg           = cvar.New("savedgamecfg", "0", cvar.ARCHIVE)
-	Scratch1               = cvar.New("scratch1", "0", cvar.NONE)
-	Scratch2               = cvar.New("scratch2", "0", cvar.NONE)
-	Scratch3               = cvar.New("scratch3", "0", cvar.NONE)
-	Scratch4               = cvar.New("scratch4", "0", cvar.NONE)
-	ScreenCenterTime       = cvar.New("scr_centertime", "2", cvar.NONE)
-	ScreenClock            = cvar.New("scr_clock", "0", cvar.NONE)
-	ScreenConsoleAlpha     = cvar.New("scr_conalpha", "0.5", cvar.ARCHIVE)
-	ScreenConsoleSpeed     = cvar.New("scr_conspeed", "500", cvar.ARCHIVE)
-	ScreenConsoleScale     = cvar.New("scr_conscale", "1", cvar.ARCHIVE)
-	ScreenConsoleWidth     = cvar.New("scr_conwidth", "0", cvar.ARCHIVE)
-	ScreenCrosshairScale   = cvar.New("scr_crosshairscale", "1", cvar.ARCHIVE)
-	ScreenMenuScale        = cvar.New("scr_menuscale", "1", cvar.ARCHIVE)
-	ScreenOffsetX          = cvar.New("scr_ofsx", "0", cvar.NONE)
-	ScreenOffsetY          = cvar.New("scr_ofsy", "0", cvar.NONE)
-	ScreenOffsetZ          = cvar.New("scr_ofsz", "0", cvar.NONE)
-	ScreenPrintSpeed       = cvar.New("scr_printspeed", "8", cvar.NONE)
-	ScreenStatusbarAlpha   = cvar.New("scr_sbaralpha", "0.75", cvar.ARCHIVE)
-	ScreenStatusbarScale   = cvar.New("scr_sbarscale", "1", cvar.ARCHIVE)
-	ScreenShowFps          = cvar.New("scr_showfps", "0", cvar.NONE)
-	Sensitivity            = cvar.New("sensitivity", "3", cvar.ARCHIVE)
-	ServerAccelerate       = cvar.New("sv_accelerate", "10", cvar.NONE)
-	ServerAim              = cvar.New("sv_aim", "1", cvar.NONE)
-	ServerAltNoClip        = cvar.New("sv_altnoclip", "1", cvar.ARCHIVE)
-	ServerEdgeFriction     = cvar.New("edgefriction", "2", cvar.NONE)
-	ServerFreezeNonClients = cvar.New("sv_freezenonclients", "0", cvar.NONE)
-	ServerFriction         = cvar.New("sv_friction", "4", cvar.NOTIFY|cvar.SERVERINFO)
-	ServerGravity          = cvar.New("sv_gravity", "800", cvar.NOTIFY|cvar.SERVERINFO)
-	ServerIdealPitchScale  = cvar.New("sv_idealpitchscale", "0.8", cvar.NONE)
-	ServerMaxSpeed         = cvar.New("sv_maxspeed", "320", cvar.NOTIFY|cvar.SERVERINFO)
-	ServerMaxVelocity      = cvar.New("sv_maxvelocity", "2000", cvar.NONE)
-	ServerNoStep           = cvar.New("sv_nostep", "0", cvar.NONE)
-	ServerProfile          = cvar.New("serverprofile", "0", cvar.NONE)
-	ServerStopSpeed        = cvar.New("sv_stopspeed", "100", cvar.NONE)
-	ShowPause              = cvar.New("showpause", "1", cvar.NONE)
-	ShowRAM                = cvar.New("showram", "1", cvar.NONE)
-	ShowTurtle             = cvar.New("showturtle", "0", cvar.NONE)
-	Skill                  = cvar.New("skill", "1", cvar.NONE)
-	SoundFilterQuality     = cvar.New("snd_filterquality", "1", cvar.NONE) // 5 on win, 1 on all other
-	SoundMixAhead          = cvar.New("snd_mixahead", "0.1", cvar.ARCHIVE)
-	SoundMixSpeed          = cvar.New("snd_mixspeed", "44100", cvar.NONE)
-	SoundNoExtraUpdate     = cvar.New("snd_noextraupdate", "0", cvar.NONE)
-	SoundShow              = cvar.New("snd_show", "0", cvar.NONE)
-	SoundSpeed             = cvar.New("sndspeed", "11025", cvar.NONE)
-	TeamPlay               = cvar.New("teamplay", "0", cvar.NOTIFY|cvar.SERVERINFO)
-	Temp1                  = cvar.New("temp1", "0", cvar.NONE)
-	Throttle               = cvar.New("sys_throttle", "0.02", cvar.ARCHIVE)
-	TicRate                = cvar.New("sys_ticrate", "0.05", cvar.NONE)
-	TimeLimit              = cvar.New("timelimit", "0", cvar.NOTIFY|cvar.SERVERINFO)
-	VideoBorderLess        = cvar.New("vid_borderless", "0", cvar.ARCHIVE)
-	VideoDesktopFullscreen = cvar.New("vid_desktopfullscreen", "0", cvar.ARCHIVE)
-	VideoFsaa              = cvar.New("vid_fsaa", "0", cvar.ARCHIVE)
-	VideoFullscreen        = cvar.New("vid_fullscreen", "0", cvar.ARCHIVE)
-	VideoHeight            = cvar.New("vid_height", "600", cvar.ARCHIVE)
-	VideoVerticalSync      = cvar.New("vid_vsync", "0", cvar.ARCHIVE)
-	VideoWidth             = cvar.New("vid_width", "800", cvar.ARCHIVE)
-	ViewCenterMove         = cvar.New("v_centermove", "0.15", cvar.NONE)
-	ViewCenterSpeed        = cvar.New("v_centerspeed", "500", cvar.NONE)
-	ViewGunKick            = cvar.New("v_gunkick", "1", cvar.NONE)
-	ViewIPitchCycle        = cvar.New("v_ipitch_cycle", "1", cvar.NONE)
-	ViewIPitchLevel        = cvar.New("v_ipitch_level", "0.3", cvar.NONE)
-	ViewIRollCycle         = cvar.New("v_iroll_cycle", "0.5", cvar.NONE)
-	ViewIRollLevel         = cvar.New("v_iroll_level", "0.1", cvar.NONE)
-	ViewIYawCycle          = cvar.New("v_iyaw_cycle", "2", cvar.NONE)
-	ViewIYawLevel          = cvar.New("v_iyaw_level", "0.3", cvar.NONE)
-	ViewIdleScale          = cvar.New("v_idlescale", "0", cvar.NONE)
-	ViewKickPitch          = cvar.New("v_kickpitch", "0.6", cvar.NONE)
-	ViewKickRoll           = cvar.New("v_kickroll", "0.6", cvar.NONE)
-	ViewKickTime           = cvar.New("v_kicktime", "0.5", cvar.NONE)
-	ViewSize               = cvar.New("viewsize", "100", cvar.ARCHIVE)
-	Volume                 = cvar.New("volume", "0.7", cvar.ARCHIVE)
+	GlTextureMode          = cvar.New("gl_texturemode", "", cvar.ARCHIVE, cvar.Desc("texture filter mode, like GL_LINEAR_MIPMAP_LINEAR or 1 to 6"))
+	GlTextureAnisotropy    = cvar.New("gl_texture_anisotropy", "1", cvar.ARCHIVE, cvar.Float(), cvar.Range(1, 16), cvar.Desc("anisotropic texture filtering"))
+	GlTripleBuffer         = cvar.New("gl_triplebuffer", "1", cvar.ARCHIVE, cvar.Bool(), cvar.Desc("use triple buffering"))
+	GlZFix                 = cvar.New("gl_zfix", "0", cvar.NONE, cvar.Bool(), cvar.Desc("prevent z-fighting of brush models"))
+	HostFrameRate          = cvar.New("host_framerate", "0", cvar.NONE, cvar.Float(), cvar.Min(0), cvar.Desc("fixed seconds per frame, 0 uses the real time"))
+	HostMaxFps             = cvar.New("host_maxfps", "72", cvar.ARCHIVE, cvar.Float(), cvar.Min(0), cvar.Desc("maximum frames per second, 0 means no limit"))
+	HostName               = cvar.New("hostname", "UNNAMED", cvar.NONE, cvar.Desc("server name shown to clients"))
+	HostSpeeds             = cvar.New("host_speeds", "0", cvar.NONE, cvar.Bool(), cvar.Desc("print the time spent in the server, client and renderer"))
+	HostTimeScale          = cvar.New("host_timescale", "0", cvar.NONE, cvar.Float(), cvar.Min(0), cvar.Desc("speed of the game time, 0 uses the real time"))
+	InputDebugKeys         = cvar.New("in_debugkeys", "0", cvar.NONE, cvar.Bool(), cvar.Desc("print all key events"))
+	LoadAs8Bit             = cvar.New("loadas8bit", "0", cvar.NONE, cvar.Bool(), cvar.Desc("load textures with the quake palette"))
+	LookSpring             = cvar.New("lookspring", "0", cvar.ARCHIVE, cvar.Bool(), cvar.Desc("center the view when mouse look ends"))
+	LookStrafe             = cvar.New("lookstrafe", "0", cvar.ARCHIVE, cvar.Bool(), cvar.Desc("strafe instead of turn in mouse look"))
+	MaxEdicts              = cvar.New("max_edicts", "15000", cvar.NONE, cvar.Int(), cvar.Range(265, 32000), cvar.Desc("maximum number of entities, used when the next map gets loaded"))
+	MouseForward           = cvar.New("m_forward", "1", cvar.ARCHIVE, cvar.Float(), cvar.Desc("mouse forward movement speed"))
+	MousePitch             = cvar.New("m_pitch", "0.022", cvar.ARCHIVE, cvar.Float(), cvar.Desc("mouse pitch speed, negative values invert the mouse"))
+	MouseSide              = cvar.New("m_side", "0.8", cvar.ARCHIVE, cvar.Float(), cvar.Desc("mouse sideways movement speed"))
+	MouseYaw               = cvar.New("m_yaw", "0.022", cvar.ARCHIVE, cvar.Float(), cvar.Desc("mouse yaw speed"))
+	NetMessageTimeout      = cvar.New("net_messagetimeout", "300", cvar.NONE, cvar.Float(), cvar.Min(0), cvar.Desc("seconds without messages until a connection times out"))
+	NoExit                 = cvar.New("noexit", "0", cvar.NOTIFY|cvar.SERVERINFO, cvar.Int(), cvar.Min(0), cvar.Desc("prevent leaving deathmatch maps through exits"))
+	NoMonsters             = cvar.New("nomonsters", "0", cvar.NONE, cvar.Bool(), cvar.Desc("do not spawn monsters"))
+	NoSound                = cvar.New("nosound", "0", cvar.NONE, cvar.Bool(), cvar.Desc("disable all sound"))
+	Pausable               = cvar.New("pausable", "1", cvar.NONE, cvar.Bool(), cvar.Desc("allow clients to pause the server"))
+	Precache               = cvar.New("precache", "1", cvar.NONE, cvar.Bool(), cvar.Desc("load all precached models and sounds at map start"))
+	ProgsMaxStatements     = cvar.New("pr_maxstatements", "16777216", cvar.NONE, cvar.Int(), cvar.Min(0), cvar.Desc("statements a progs function may run before it gets aborted, 0 is unlimited"))
+	ProgsUnchecked         = cvar.New("pr_unchecked", "0", cvar.NONE, cvar.Bool(), cvar.Desc("skip entity field validation for trusted mods"))
+	RClearColor            = cvar.New("r_clearcolor", "2", cvar.ARCHIVE, cvar.Int(), cvar.Range(0, 255), cvar.Desc("palette color used to clear the screen"))
+	RDrawEntities          = cvar.New("r_drawentities", "1", cvar.NONE, cvar.Bool(), cvar.Desc("draw entities"))
+	RDrawFlat              = cvar.New("r_drawflat", "0", cvar.NONE, cvar.Bool(), cvar.Desc("draw the world with flat colors"))
+	RDrawViewModel         = cvar.New("r_drawviewmodel", "1", cvar.NONE, cvar.Bool(), cvar.Desc("draw the weapon"))
+	RDrawWorld             = cvar.New("r_drawworld", "1", cvar.NONE, cvar.Bool(), cvar.Desc("draw the world"))
+	RDynamic               = cvar.New("r_dynamic", "1", cvar.ARCHIVE, cvar.Bool(), cvar.Desc("dynamic lights"))
+	RFastSky               = cvar.New("r_fastsky", "0", cvar.NONE, cvar.Bool(), cvar.Desc("draw the sky with a single color"))
+	RFlatLightStyles       = cvar.New("r_flatlightstyles", "0", cvar.NONE, cvar.Int(), cvar.Range(0, 2), cvar.Desc("light animations, 1 uses the average, 2 the peak brightness"))
+	RFullBright            = cvar.New("r_fullbright", "0", cvar.NONE, cvar.Bool(), cvar.Desc("draw the world without lightmaps"))
+	RLavaAlpha             = cvar.New("r_lavaalpha", "0", cvar.NONE, cvar.Float(), cvar.Range(0, 1), cvar.Desc("lava transparency, 0 uses r_wateralpha"))
+	RLerpModels            = cvar.New("r_lerpmodels", "1", cvar.NONE, cvar.Int(), cvar.Range(0, 2), cvar.Desc("interpolate model animations, 2 also models of r_nolerp_list"))
+	RLerpMove              = cvar.New("r_lerpmove", "1", cvar.NONE, cvar.Bool(), cvar.Desc("interpolate entity movement"))
+	RLightMap              = cvar.New("r_lightmap", "0", cvar.NONE, cvar.Bool(), cvar.Desc("draw only the lightmaps"))
+	RNoRefresh             = cvar.New("r_norefresh", "0", cvar.NONE, cvar.Bool(), cvar.Desc("do not draw the 3d view"))
+	RNoVis                 = cvar.New("r_novis", "0", cvar.ARCHIVE, cvar.Bool(), cvar.Desc("ignore the visibility information of maps"))
+	ROldSkyLeaf            = cvar.New("r_oldskyleaf", "0", cvar.NONE, cvar.Bool(), cvar.Desc("the original sky visibility for broken maps"))
+	ROldWater              = cvar.New("r_oldwater", "0", cvar.ARCHIVE, cvar.Bool(), cvar.Desc("the original subdivided water warp"))
+	RParticles             = cvar.New("r_particles", "1", cvar.ARCHIVE, cvar.Int(), cvar.Range(0, 2), cvar.Desc("particles, 1 round, 2 square"))
+	RPos                   = cvar.New("r_pos", "0", cvar.NONE, cvar.Bool(), cvar.Desc("print the camera position"))
+	RQuadParticles         = cvar.New("r_quadparticles", "1", cvar.ARCHIVE, cvar.Bool(), cvar.Desc("draw particles as quads"))
+	RReplaceModels         = cvar.New("r_replacemodels", "", cvar.ARCHIVE, cvar.Desc("model extensions tried instead of mdl, e.g. \"md3 iqm\""))
+	RShadows               = cvar.New("r_shadows", "0", cvar.ARCHIVE, cvar.Bool(), cvar.Desc("draw simple model shadows"))
+	RShowBoxes             = cvar.New("r_showbboxes", "0", cvar.NONE, cvar.Bool(), cvar.Desc("draw the bounding boxes of entities"))
+	RShowTris              = cvar.New("r_showtris", "0", cvar.NONE, cvar.Int(), cvar.Range(0, 2), cvar.Desc("draw the triangle outlines"))
+	RSkyAlpha              = cvar.New("r_skyalpha", "1", cvar.NONE, cvar.Float(), cvar.Range(0, 1), cvar.Desc("transparency of the sky layer"))
+	RSkyFog                = cvar.New("r_skyfog", "0.5", cvar.NONE, cvar.Float(), cvar.Range(0, 1), cvar.Desc("fog density of the sky"))
+	RSkyQuality            = cvar.New("r_sky_quality", "12", cvar.NONE, cvar.Int(), cvar.Min(1), cvar.Desc("subdivisions of the sky polygons"))
+	RSlimeAlpha            = cvar.New("r_slimealpha", "0", cvar.NONE, cvar.Float(), cvar.Range(0, 1), cvar.Desc("slime transparency, 0 uses r_wateralpha"))
+	RSpeeds                = cvar.New("r_speeds", "0", cvar.NONE, cvar.Bool(), cvar.Desc("print the render time and polygon counts"))
+	RTeleAlpha             = cvar.New("r_telealpha", "0", cvar.NONE, cvar.Float(), cvar.Range(0, 1), cvar.Desc("teleporter transparency, 0 uses r_wateralpha"))
+	RWaterAlpha            = cvar.New("r_wateralpha", "1", cvar.ARCHIVE, cvar.Float(), cvar.Range(0, 1), cvar.Desc("water transparency"))
+	RWaterQuality          = cvar.New("r_waterquality", "8", cvar.NONE, cvar.Float(), cvar.Min(3), cvar.Desc("subdivisions of water surfaces"))
+	RWaterWarp             = cvar.New("r_waterwarp", "1", cvar.NONE, cvar.Bool(), cvar.Desc("warp the view under water"))
+	SameLevel              = cvar.New("samelevel", "0", cvar.NONE, cvar.Int(), cvar.Min(0), cvar.Desc("stay on the same map when a deathmatch map ends"))
+	Saved1                 = cvar.New("saved1", "0", cvar.ARCHIVE, cvar.Float(), cvar.Desc("kept for the progs"))
+	Saved2                 = cvar.New("saved2", "0", cvar.ARCHIVE, cvar.Float(), cvar.Desc("kept for the progs"))
+	Saved3                 = cvar.New("saved3", "0", cvar.ARCHIVE, cvar.Float(), cvar.Desc("kept for the progs"))
+	Saved4                 = cvar.New("saved4", "0", cvar.ARCHIVE, cvar.Float(), cvar.Desc("kept for the progs"))
+	SavedGameCfg           = cvar.New("savedgamecfg", "0", cvar.ARCHIVE, cvar.Float(), cvar.Desc("kept for the progs"))
+	Scratch1               = cvar.New("scratch1", "0", cvar.NONE, cvar.Float(), cvar.Desc("free for the progs"))
+	Scratch2               = cvar.New("scratch2", "0", cvar.NONE, cvar.Float(), cvar.Desc("free for the progs"))
+	Scratch3               = cvar.New("scratch3", "0", cvar.NONE, cvar.Float(), cvar.Desc("free for the progs"))
+	Scratch4               = cvar.New("scratch4", "0", cvar.NONE, cvar.Float(), cvar.Desc("free for the progs"))
+	ScreenCenterTime       = cvar.New("scr_centertime", "2", cvar.NONE, cvar.Float(), cvar.Min(0), cvar.Desc("seconds centerprints stay visible"))
+	ScreenClock            = cvar.New("scr_clock", "0", cvar.NONE, cvar.Bool(), cvar.Desc("show the time on the status bar"))
+	ScreenConsoleAlpha     = cvar.New("scr_conalpha", "0.5", cvar.ARCHIVE, cvar.Float(), cvar.Range(0, 1), cvar.Desc("console transparency"))
+	ScreenConsoleSpeed     = cvar.New("scr_conspeed", "500", cvar.ARCHIVE, cvar.Float(), cvar.Min(0), cvar.Desc("console scroll speed"))
+	ScreenConsoleScale     = cvar.New("scr_conscale", "1", cvar.ARCHIVE, cvar.Float(), cvar.Min(0), cvar.Desc("console text scale"))
+	ScreenConsoleWidth     = cvar.New("scr_conwidth", "0", cvar.ARCHIVE, cvar.Int(), cvar.Min(0), cvar.Desc("console width in pixels, 0 uses scr_conscale"))
+	ScreenCrosshairScale   = cvar.New("scr_crosshairscale", "1", cvar.ARCHIVE, cvar.Float(), cvar.Min(0), cvar.Desc("crosshair scale"))
+	ScreenMenuScale        = cvar.New("scr_menuscale", "1", cvar.ARCHIVE, cvar.Float(), cvar.Min(0), cvar.Desc("menu scale"))
+	ScreenOffsetX          = cvar.New("scr_ofsx", "0", cvar.NONE, cvar.Float(), cvar.Desc("camera offset forward"))
+	ScreenOffsetY          = cvar.New("scr_ofsy", "0", cvar.NONE, cvar.Float(), cvar.Desc("camera offset to the right"))
+	ScreenOffsetZ          = cvar.New("scr_ofsz", "0", cvar.NONE, cvar.Float(), cvar.Desc("camera offset up"))
+	ScreenPrintSpeed       = cvar.New("scr_printspeed", "8", cvar.NONE, cvar.Float(), cvar.Min(0), cvar.Desc("characters per second of the finale text"))
+	ScreenStatusbarAlpha   = cvar.New("scr_sbaralpha", "0.75", cvar.ARCHIVE, cvar.Float(), cvar.Range(0, 1), cvar.Desc("status bar transparency"))
+	ScreenStatusbarScale   = cvar.New("scr_sbarscale", "1", cvar.ARCHIVE, cvar.Float(), cvar.Min(0), cvar.Desc("status bar scale"))
+	ScreenShowFps          = cvar.New("scr_showfps", "0", cvar.NONE, cvar.Bool(), cvar.Desc("show the frames per second"))
+	Sensitivity            = cvar.New("sensitivity", "3", cvar.ARCHIVE, cvar.Float(), cvar.Desc("mouse sensitivity"))
+	ServerAccelerate       = cvar.New("sv_accelerate", "10", cvar.NONE, cvar.Float(), cvar.Desc("player acceleration"))
+	ServerAim              = cvar.New("sv_aim", "1", cvar.NONE, cvar.Float(), cvar.Range(0, 1), cvar.Desc("cosine of the autoaim angle, 1 disables autoaim"))
+	ServerAllowVote        = cvar.New("sv_allowvote", "1", cvar.NONE, cvar.Bool(), cvar.Desc("allow clients to vote"))
+	ServerAltNoClip        = cvar.New("sv_altnoclip", "1", cvar.ARCHIVE, cvar.Bool(), cvar.Desc("noclip moves in the view direction"))
+	ServerCountdown        = cvar.New("sv_countdown", "10", cvar.NONE, cvar.Float(), cvar.Min(0), cvar.Desc("seconds between all players ready and match start"))
+	ServerEdgeFriction     = cvar.New("edgefriction", "2", cvar.NONE, cvar.Float(), cvar.Desc("friction multiplier near edges"))
+	ServerFreezeNonClients = cvar.New("sv_freezenonclients", "0", cvar.NONE, cvar.Bool(), cvar.Desc("only move players"))
+	ServerFriction         = cvar.New("sv_friction", "4", cvar.NOTIFY|cvar.SERVERINFO, cvar.Float(), cvar.Desc("player friction on the ground"))
+	ServerGravity          = cvar.New("sv_gravity", "800", cvar.NOTIFY|cvar.SERVERINFO, cvar.Float(), cvar.Desc("gravity"))
+	ServerIdealPitchScale  = cvar.New("sv_idealpitchscale", "0.8", cvar.NONE, cvar.Float(), cvar.Desc("how strongly the view follows slopes"))
+	ServerMapList          = cvar.New("sv_maplist", "", cvar.NONE, cvar.Desc("file with the map rotation"))
+	ServerMaxSpectators    = cvar.New("sv_maxspectators", "8", cvar.NONE, cvar.Int(), cvar.Min(0), cvar.Desc("maximum number of spectators"))
+	ServerMaxSpeed         = cvar.New("sv_maxspeed", "320", cvar.NOTIFY|cvar.SERVERINFO, cvar.Float(), cvar.Desc("maximum player speed"))
+	ServerMaxVelocity      = cvar.New("sv_maxvelocity", "2000", cvar.NONE, cvar.Float(), cvar.Desc("maximum speed of all entities"))
+	ServerMoveCheck        = cvar.New("sv_movecheck", "1", cvar.NONE, cvar.Bool(), cvar.Desc("check client movement for invalid values"))
+	ServerMoveKick         = cvar.New("sv_movekick", "20", cvar.NONE, cvar.Int(), cvar.Min(0), cvar.Desc("movement violations until a kick, 0 never kicks"))
+	ServerNoStep           = cvar.New("sv_nostep", "0", cvar.NONE, cvar.Bool(), cvar.Desc("players can not climb steps"))
+	ServerProfile          = cvar.New("serverprofile", "0", cvar.NONE, cvar.Bool(), cvar.Desc("print the time spent in the server"))
+	ServerStopSpeed        = cvar.New("sv_stopspeed", "100", cvar.NONE, cvar.Float(), cvar.Desc("speed below which players stop"))
+	ServerUnlag            = cvar.New("sv_unlag", "0.3", cvar.NONE, cvar.Float(), cvar.Min(0), cvar.Desc("max seconds hitscan traces get rewound"))
+	ServerVoteTime         = cvar.New("sv_votetime", "30", cvar.NONE, cvar.Float(), cvar.Min(0), cvar.Desc("seconds a vote is open"))
+	ServerWarmup           = cvar.New("sv_warmup", "0", cvar.NONE, cvar.Bool(), cvar.Desc("start deathmatch maps in warmup until all players are ready"))
+	ShowPause              = cvar.New("showpause", "1", cvar.NONE, cvar.Bool(), cvar.Desc("show the pause picture"))
+	ShowRAM                = cvar.New("showram", "1", cvar.NONE, cvar.Bool(), cvar.Desc("show the ram icon"))
+	ShowTurtle             = cvar.New("showturtle", "0", cvar.NONE, cvar.Bool(), cvar.Desc("show the turtle icon when the frame rate is low"))
+	Skill                  = cvar.New("skill", "1", cvar.NONE, cvar.Int(), cvar.Range(0, 3), cvar.Desc("difficulty, 0 easy to 3 nightmare"))
+	SoundFilterQuality     = cvar.New("snd_filterquality", "1", cvar.NONE, cvar.Int(), cvar.Range(1, 5), cvar.Desc("quality of the sound resampling filter"))
+	SoundMixAhead          = cvar.New("snd_mixahead", "0.1", cvar.ARCHIVE, cvar.Float(), cvar.Min(0), cvar.Desc("seconds of sound mixed in advance"))
+	SoundMixSpeed          = cvar.New("snd_mixspeed", "44100", cvar.NONE, cvar.Int(), cvar.Min(1), cvar.Desc("sample rate of the sound mixer"))
+	SoundNoExtraUpdate     = cvar.New("snd_noextraupdate", "0", cvar.NONE, cvar.Bool(), cvar.Desc("skip the extra sound updates while loading"))
+	SoundShow              = cvar.New("snd_show", "0", cvar.NONE, cvar.Bool(), cvar.Desc("print the playing sounds"))
+	SoundSpeed             = cvar.New("sndspeed", "11025", cvar.NONE, cvar.Int(), cvar.Min(1), cvar.Desc("sample rate of the sound output"))
+	TeamPlay               = cvar.New("teamplay", "0", cvar.NOTIFY|cvar.SERVERINFO, cvar.Int(), cvar.Min(0), cvar.Desc("team play mode, the progs define the meaning"))
+	Temp1                  = cvar.New("temp1", "0", cvar.NONE, cvar.Float(), cvar.Desc("free for the progs"))
+	Throttle               = cvar.New("sys_throttle", "0.02", cvar.ARCHIVE, cvar.Float(), cvar.Min(0), cvar.Desc("seconds to sleep when the game is idle"))
+	TicRate                = cvar.New("sys_ticrate", "0.05", cvar.NONE, cvar.Float(), cvar.Min(0), cvar.Desc("seconds between network updates of a dedicated server"))
+	TimeLimit              = cvar.New("timelimit", "0", cvar.NOTIFY|cvar.SERVERINFO, cvar.Float(), cvar.Min(0), cvar.Desc("minutes until a deathmatch map ends, 0 means no limit"))
+	VideoBorderLess        = cvar.New("vid_borderless", "0", cvar.ARCHIVE, cvar.Bool(), cvar.Desc("window without border"))
+	VideoDesktopFullscreen = cvar.New("vid_desktopfullscreen", "0", cvar.ARCHIVE, cvar.Bool(), cvar.Desc("fullscreen with the desktop resolution"))
+	VideoFsaa              = cvar.New("vid_fsaa", "0", cvar.ARCHIVE, cvar.Int(), cvar.Min(0), cvar.Desc("multisample anti aliasing samples"))
+	VideoFullscreen        = cvar.New("vid_fullscreen", "0", cvar.ARCHIVE, cvar.Bool(), cvar.Desc("fullscreen"))
+	VideoHeight            = cvar.New("vid_height", "600", cvar.ARCHIVE, cvar.Int(), cvar.Min(1), cvar.Desc("window height"))
+	VideoVerticalSync      = cvar.New("vid_vsync", "0", cvar.ARCHIVE, cvar.Bool(), cvar.Desc("wait for vertical sync"))
+	VideoWidth             = cvar.New("vid_width", "800", cvar.ARCHIVE, cvar.Int(), cvar.Min(1), cvar.Desc("window width"))
+	ViewCenterMove         = cvar.New("v_centermove", "0.15", cvar.NONE, cvar.Float(), cvar.Desc("distance to move before the view centers"))
+	ViewCenterSpeed        = cvar.New("v_centerspeed", "500", cvar.NONE, cvar.Float(), cvar.Desc("speed of the view centering"))
+	ViewGunKick            = cvar.New("v_gunkick", "1", cvar.NONE, cvar.Int(), cvar.Range(0, 2), cvar.Desc("view kick when firing, 1 original, 2 smooth"))
+	ViewIPitchCycle        = cvar.New("v_ipitch_cycle", "1", cvar.NONE, cvar.Float(), cvar.Desc("idle pitch sway cycle"))
+	ViewIPitchLevel        = cvar.New("v_ipitch_level", "0.3", cvar.NONE, cvar.Float(), cvar.Desc("idle pitch sway amount"))
+	ViewIRollCycle         = cvar.New("v_iroll_cycle", "0.5", cvar.NONE, cvar.Float(), cvar.Desc("idle roll sway cycle"))
+	ViewIRollLevel         = cvar.New("v_iroll_level", "0.1", cvar.NONE, cvar.Float(), cvar.Desc("idle roll sway amount"))
+	ViewIYawCycle          = cvar.New("v_iyaw_cycle", "2", cvar.NONE, cvar.Float(), cvar.Desc("idle yaw sway cycle"))
+	ViewIYawLevel          = cvar.New("v_iyaw_level", "0.3", cvar.NONE, cvar.Float(), cvar.Desc("idle yaw sway amount"))
+	ViewIdleScale          = cvar.New("v_idlescale", "0", cvar.NONE, cvar.Float(), cvar.Desc("scale of the idle view sway"))
+	ViewKickPitch          = cvar.New("v_kickpitch", "0.6", cvar.NONE, cvar.Float(), cvar.Desc("view pitch kick when taking damage"))
+	ViewKickRoll           = cvar.New("v_kickroll", "0.6", cvar.NONE, cvar.Float(), cvar.Desc("view roll kick when taking damage"))
+	ViewKickTime           = cvar.New("v_kicktime", "0.5", cvar.NONE, cvar.Float(), cvar.Desc("seconds of the view kick when taking damage"))
+	ViewSize               = cvar.New("viewsize", "100", cvar.ARCHIVE, cvar.Float(), cvar.Range(30, 120), cvar.Desc("size of the 3d view, 110 hides the status bar and 120 the inventory"))
+	Volume                 = cvar.New("volume", "0.7", cvar.ARCHIVE, cvar.Float(), cvar.Range(0, 1), cvar.Desc("sound volume"))
 
 	// this cvar gets read from within the vm
-	registered = cvar.New("registered", "1", cvar.ROM)
+	registered = cvar.New("registered", "1", cvar.ROM, cvar.Bool(), cvar.Desc("the registered version, read by the progs"))
 
 	RNoLerpList = cvar.New("r_nolerp_list", strings.Join([]string{
 		"progs/flame.mdl",
@@ -219,7 +237,7 @@ var (
 		"progs/v_saw.mdl",
 		"progs/v_xfist.mdl",
 		"progs/h2stuff/newfire.mdl",
-	}, ","), cvar.NONE)
+	}, ","), cvar.NONE, cvar.Desc("models which are not interpolated"))
 	RNoShadowList = cvar.New("r_noshadow_list", strings.Join([]string{
 		"progs/flame2.mdl",
 		"progs/flame.mdl",
@@ -227,13 +245,13 @@ var (
 		"progs/bolt2.mdl",
 		"progs/bolt3.mdl",
 		"progs/laser.mdl",
-	}, ","), cvar.NONE)
+	}, ","), cvar.NONE, cvar.Desc("models without shadows"))
 
 	RFullBrightList = cvar.New("r_fullbright_list", strings.Join([]string{
 		"progs/flame2.mdl",
 		"progs/flame.mdl",
 		"progs/boss.mdl",
-	}, ","), cvar.NONE)
+	}, ","), cvar.NONE, cvar.Desc("models drawn without lighting"))
 )
 
 func Register(c *cvar.Cvars) error {
@@ -320,11 +338,18 @@ func Register(c *cvar.Cvars) error {
 	if err := c.Add(ClientNoLerp); err != nil {
 		return err
 	}
+	if err := c.Add(ClientNoPrediction); err != nil {
+		return err
+	}
 
 	if err := c.Add(ClientPitchSpeed); err != nil {
 		return err
 	}
 
+	if err := c.Add(ClientProtobuf); err != nil {
+		return err
+	}
+
 	if err := c.Add(ClientRollAngle); err != nil {
 		return err
 	}
@@ -341,6 +366,10 @@ func Register(c *cvar.Cvars) error {
 		return err
 	}
 
+	if err := c.Add(ClientSpectator); err != nil {
+		return err
+	}
+
 	if err := c.Add(ClientUpSpeed); err != nil {
 		return err
 	}
@@ -369,6 +398,14 @@ func Register(c *cvar.Cvars) error {
 		return err
 	}
 
+	if err := c.Add(CSQCProgCRC); err != nil {
+		return err
+	}
+
+	if err := c.Add(CSQCProgSize); err != nil {
+		return err
+	}
+
 	if err := c.Add(DeathMatch); err != nil {
 		return err
 	}
@@ -433,6 +470,10 @@ func Register(c *cvar.Cvars) error {
 		return err
 	}
 
+	if err := c.Add(GlExternalTextures); err != nil {
+		return err
+	}
+
 	if err := c.Add(GlFullBrights); err != nil {
 		return err
 	}
@@ -569,6 +610,14 @@ func Register(c *cvar.Cvars) error {
 		return err
 	}
 
+	if err := c.Add(ProgsMaxStatements); err != nil {
+		return err
+	}
+
+	if err := c.Add(ProgsUnchecked); err != nil {
+		return err
+	}
+
 	if err := c.Add(RClearColor); err != nil {
 		return err
 	}
@@ -661,6 +710,10 @@ func Register(c *cvar.Cvars) error {
 		return err
 	}
 
+	if err := c.Add(RReplaceModels); err != nil {
+		return err
+	}
+
 	if err := c.Add(RShadows); err != nil {
 		return err
 	}
@@ -821,10 +874,18 @@ func Register(c *cvar.Cvars) error {
 		return err
 	}
 
+	if err := c.Add(ServerAllowVote); err != nil {
+		return err
+	}
+
 	if err := c.Add(ServerAltNoClip); err != nil {
 		return err
 	}
 
+	if err := c.Add(ServerCountdown); err != nil {
+		return err
+	}
+
 	if err := c.Add(ServerEdgeFriction); err != nil {
 		return err
 	}
@@ -845,6 +906,14 @@ func Register(c *cvar.Cvars) error {
 		return err
 	}
 
+	if err := c.Add(ServerMapList); err != nil {
+		return err
+	}
+
+	if err := c.Add(ServerMaxSpectators); err != nil {
+		return err
+	}
+
 	if err := c.Add(ServerMaxSpeed); err != nil {
 		return err
 	}
@@ -853,6 +922,14 @@ func Register(c *cvar.Cvars) error {
 		return err
 	}
 
+	if err := c.Add(ServerMoveCheck); err != nil {
+		return err
+	}
+
+	if err := c.Add(ServerMoveKick); err != nil {
+		return err
+	}
+
 	if err := c.Add(ServerNoStep); err != nil {
 		return err
 	}
@@ -865,6 +942,18 @@ func Register(c *cvar.Cvars) error {
 		return err
 	}
 
+	if err := c.Add(ServerUnlag); err != nil {
+		return err
+	}
+
+	if err := c.Add(ServerVoteTime); err != nil {
+		return err
+	}
+
+	if err := c.Add(ServerWarmup); err != nil {
+		return err
+	}
+
 	if err := c.Add(ShowPause); err != nil {
 		return err
 	}
@@ -0,0 +1,22 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package cvars
+
+import (
+	"testing"
+)
+
+func TestDefaultsAreValid(t *testing.T) {
+	c := New()
+	if err := Register(c); err != nil {
+		t.Fatal(err)
+	}
+	for _, cv := range c.All() {
+		if err := cv.Validate(cv.Default()); err != nil {
+			t.Errorf("%s: default %v", cv.Name(), err)
+		}
+		if cv.Description() == "" {
+			t.Errorf("%s has no description", cv.Name())
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"log"
 	"os"
 	"path/filepath"
 	"strings"
@@ -55,6 +56,7 @@ func (*closer) Close() error {
 type fileInfo struct {
 	name string // base name of the file
 	size int64  // length in bytes for regular files; system-dependent for others
+	dir  bool
 }
 
 func (f *fileInfo) Name() string {
@@ -64,13 +66,16 @@ func (f *fileInfo) Size() int64 {
 	return f.size
 }
 func (f *fileInfo) Mode() fs.FileMode {
+	if f.dir {
+		return fs.ModeDir
+	}
 	return 0
 }
 func (f *fileInfo) ModTime() time.Time {
 	return time.Time{}
 }
 func (f *fileInfo) IsDir() bool {
-	return false
+	return f.dir
 }
 func (f *fileInfo) Sys() any {
 	return nil
@@ -99,6 +104,10 @@ func (p packFileSystem) Stat(path string) (os.FileInfo, error) {
 	return p.stat(path)
 }
 
+func (p packFileSystem) ReadDir(path string) ([]os.FileInfo, error) {
+	return p.p.ReadDir(path)
+}
+
 func (p packFileSystem) String() string {
 	return p.p.String()
 }
@@ -134,13 +143,15 @@ func UseGameDir(dir string) {
 	gameNS.Bind("/", vfs.OS(root), "/", vfs.BindReplace)
 	useDir(&gameNS, root)
 	gameDir = filepath.Join(baseDir, dir)
-	gameNS.Bind("/", vfs.OS(dir), "/", vfs.BindBefore)
+	gameNS.Bind("/", vfs.OS(gameDir), "/", vfs.BindBefore)
 	useDir(&gameNS, gameDir)
 }
 
 func useDir(ns *vfs.NameSpace, dir string) {
 	// 1) Add pak[i].pak files to the beginning order high number to low number
-	// 2) add quakespasm.pak to the beginning
+	// 2) Add *.pk3 files to the beginning in reverse lexical order, so
+	//    pak1.pk3 overrides pak0.pk3 and all paks
+	// 3) add quakespasm.pak to the beginning
 	for i := 0; ; i++ {
 		pfn := fmt.Sprintf("pak%d.pak", i)
 		pfp := filepath.Join(dir, pfn)
@@ -150,6 +161,14 @@ func useDir(ns *vfs.NameSpace, dir string) {
 		}
 		ns.Bind("/", packFileSystem{p}, "/", vfs.BindBefore)
 	}
+	for _, zfp := range pk3Files(dir) {
+		z, err := newZipFileSystem(zfp)
+		if err != nil {
+			log.Printf("Could not open %s: %v", zfp, err)
+			continue
+		}
+		ns.Bind("/", z, "/", vfs.BindBefore)
+	}
 	qsm := filepath.Join(dir, "quakespasm.pak")
 	qsmp, err := pack.NewPackReader(qsm)
 	if err == nil {
@@ -157,12 +176,52 @@ func useDir(ns *vfs.NameSpace, dir string) {
 	}
 }
 
+// pk3Files returns the pk3 files of dir sorted by name.
+func pk3Files(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var r []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.EqualFold(Ext(e.Name()), ".pk3") {
+			continue
+		}
+		r = append(r, filepath.Join(dir, e.Name()))
+	}
+	return r
+}
+
 func Stat(path string) (os.FileInfo, error) {
 	mutex.RLock()
 	defer mutex.RUnlock()
 	return gameNS.Stat(path)
 }
 
+// ReadDir returns the union of the directory dir of all game directories and
+// packs. Files of higher priority shadow files with the same name.
+func ReadDir(dir string) ([]os.FileInfo, error) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return gameNS.ReadDir(dir)
+}
+
+// Glob returns the names of all files matching pattern, see path.Match for
+// the syntax. The names are relative to the root of the game directory, e.g.
+// Glob("maps/*.bsp") returns "maps/e1m1.bsp".
+func Glob(pattern string) ([]string, error) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return fs.Glob(vfs.IOFS(gameNS), strings.TrimPrefix(pattern, "/"))
+}
+
+// FS returns the current game directory as fs.FS.
+func FS() fs.FS {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return vfs.IOFS(gameNS)
+}
+
 func Open(name string) (File, error) {
 	mutex.RLock()
 	defer mutex.RUnlock()
@@ -4,6 +4,7 @@ package filesystem
 
 import (
 	"io"
+	"slices"
 	"testing"
 
 	"goquake/pack"
@@ -116,3 +117,42 @@ func TestStripExt(t *testing.T) {
 	}
 
 }
+
+func TestFilesystemGlob(t *testing.T) {
+	UseGameDir("testdir")
+	for _, tt := range []struct {
+		pattern string
+		want    []string
+	}{
+		{"doc*.txt", []string{"doc1.txt", "doc2.txt", "doc3.txt", "doc4.txt", "doc5.txt"}},
+		{"/testdir/doc[12].txt", []string{"testdir/doc1.txt", "testdir/doc2.txt"}},
+		{"*.pak", []string{"pak0.pak", "pak1.pak"}},
+		{"maps/*.bsp", nil},
+	} {
+		got, err := Glob(tt.pattern)
+		if err != nil {
+			t.Fatalf("Glob(%q): %v", tt.pattern, err)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Glob(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestFilesystemReadDirShadow(t *testing.T) {
+	UseGameDir("testdir")
+	fis, err := ReadDir("/")
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	for _, fi := range fis {
+		// doc1.txt of pak1.pak shadows the one of pak0.pak and the OS
+		if fi.Name() == "doc1.txt" {
+			if fi.Size() != 34 {
+				t.Errorf("doc1.txt size = %d, want 34", fi.Size())
+			}
+			return
+		}
+	}
+	t.Errorf("doc1.txt not found")
+}
@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package vfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	pathpkg "path"
+)
+
+// IOFS returns an io/fs.FS view of fsys. The paths of io/fs are unrooted,
+// "maps/e1m1.bsp" is "/maps/e1m1.bsp" of fsys.
+// The result implements fs.ReadDirFS and fs.StatFS, so fs.Glob and
+// fs.WalkDir see the directories of all mounts.
+func IOFS(fsys FileSystem) fs.FS {
+	return ioFS{fsys}
+}
+
+type ioFS struct {
+	fsys FileSystem
+}
+
+func (f ioFS) path(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return pathpkg.Join("/", name), nil
+}
+
+func (f ioFS) Open(name string) (fs.File, error) {
+	p, err := f.path("open", name)
+	if err != nil {
+		return nil, err
+	}
+	r, err := f.fsys.Open(p)
+	if err == nil {
+		fi, err := f.fsys.Stat(p)
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		return &ioFile{r, fi}, nil
+	}
+	// directories inside of packs can not be opened, only listed
+	entries, err1 := f.fsys.ReadDir(p)
+	if err1 != nil {
+		return nil, err
+	}
+	return &ioDir{name: pathpkg.Base(p), entries: dirEntries(entries)}, nil
+}
+
+func (f ioFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	p, err := f.path("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := f.fsys.ReadDir(p)
+	if err != nil {
+		return nil, err
+	}
+	return dirEntries(entries), nil
+}
+
+func (f ioFS) Stat(name string) (fs.FileInfo, error) {
+	p, err := f.path("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.fsys.Stat(p)
+	if err == nil {
+		return fi, nil
+	}
+	if _, err1 := f.fsys.ReadDir(p); err1 == nil {
+		return dirInfo(pathpkg.Base(p)), nil
+	}
+	return nil, err
+}
+
+func dirEntries(infos []os.FileInfo) []fs.DirEntry {
+	r := make([]fs.DirEntry, len(infos))
+	for i, fi := range infos {
+		r[i] = fs.FileInfoToDirEntry(fi)
+	}
+	return r
+}
+
+type ioFile struct {
+	io.ReadSeekCloser
+	info os.FileInfo
+}
+
+func (f *ioFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+// ioDir is an opened directory, it implements fs.ReadDirFile.
+type ioDir struct {
+	name    string
+	entries []fs.DirEntry
+}
+
+func (d *ioDir) Stat() (fs.FileInfo, error) {
+	return dirInfo(d.name), nil
+}
+
+func (d *ioDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("is a directory")}
+}
+
+func (d *ioDir) Close() error {
+	return nil
+}
+
+func (d *ioDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		r := d.entries
+		d.entries = nil
+		return r, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(d.entries))
+	r := d.entries[:n]
+	d.entries = d.entries[n:]
+	return r, nil
+}
@@ -9,7 +9,9 @@ import (
 	"io"
 	"os"
 	pathpkg "path"
+	"sort"
 	"strings"
+	"time"
 )
 
 // A NameSpace is a file system made up of other file systems
@@ -233,3 +235,81 @@ func (ns NameSpace) stat(path string, f func(FileSystem, string) (os.FileInfo, e
 func (ns NameSpace) Stat(path string) (os.FileInfo, error) {
 	return ns.stat(path, FileSystem.Stat)
 }
+
+// dirInfo is a trivial implementation of os.FileInfo for a directory.
+type dirInfo string
+
+func (d dirInfo) Name() string       { return string(d) }
+func (d dirInfo) Size() int64        { return 0 }
+func (d dirInfo) Mode() os.FileMode  { return os.ModeDir | 0555 }
+func (d dirInfo) ModTime() time.Time { return startTime }
+func (d dirInfo) IsDir() bool        { return true }
+func (d dirInfo) Sys() interface{}   { return nil }
+
+var startTime = time.Now()
+
+// ReadDir implements the FileSystem ReadDir method. It returns the union of
+// the directories of all mounts at path, a name found in a mount consulted
+// earlier shadows the same name of later mounts.
+func (ns NameSpace) ReadDir(path string) ([]os.FileInfo, error) {
+	path = ns.clean(path)
+
+	var (
+		found    = false
+		haveName = map[string]bool{}
+		all      []os.FileInfo
+		err      error
+	)
+
+	for _, m := range ns.resolve(path) {
+		dir, err1 := m.fs.ReadDir(m.translate(path))
+		if err1 != nil {
+			if err == nil {
+				err = err1
+			}
+			continue
+		}
+		found = true
+		for _, d := range dir {
+			name := d.Name()
+			if !haveName[name] {
+				haveName[name] = true
+				all = append(all, d)
+			}
+		}
+	}
+
+	// Add any missing directories needed to reach mount points.
+	for old := range ns {
+		if hasPathPrefix(old, path) && old != path {
+			// Find next element after path in old.
+			elem := old[len(path):]
+			elem = strings.TrimPrefix(elem, "/")
+			if i := strings.Index(elem, "/"); i >= 0 {
+				elem = elem[:i]
+			}
+			if !haveName[elem] {
+				haveName[elem] = true
+				all = append(all, dirInfo(elem))
+			}
+			found = true
+		}
+	}
+
+	if !found {
+		if err == nil {
+			err = &os.PathError{Op: "readdir", Path: path, Err: os.ErrNotExist}
+		}
+		return nil, err
+	}
+
+	sort.Sort(byName(all))
+	return all, nil
+}
+
+// byName implements sort.Interface.
+type byName []os.FileInfo
+
+func (f byName) Len() int           { return len(f) }
+func (f byName) Less(i, j int) bool { return f[i].Name() < f[j].Name() }
+func (f byName) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
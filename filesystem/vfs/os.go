@@ -57,3 +57,20 @@ func (root osFS) Open(path string) (io.ReadSeekCloser, error) {
 func (root osFS) Stat(path string) (os.FileInfo, error) {
 	return os.Stat(root.resolve(path))
 }
+
+func (root osFS) ReadDir(path string) ([]os.FileInfo, error) {
+	entries, err := os.ReadDir(root.resolve(path))
+	if err != nil {
+		return nil, err
+	}
+	r := make([]os.FileInfo, 0, len(entries))
+	for _, e := range entries {
+		fi, err := e.Info()
+		if err != nil {
+			// removed since the directory was read
+			continue
+		}
+		r = append(r, fi)
+	}
+	return r, nil
+}
@@ -15,6 +15,7 @@ import (
 // to access the file system for which it serves documentation.
 type FileSystem interface {
 	Open(name string) (io.ReadSeekCloser, error)
+	ReadDir(path string) ([]os.FileInfo, error)
 	Stat(path string) (os.FileInfo, error)
 	String() string
 }
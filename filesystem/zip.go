@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package filesystem
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	pathpkg "path"
+	"sort"
+	"strings"
+)
+
+// zipFileSystem gives access to the files of a zip archive, usually a pk3.
+type zipFileSystem struct {
+	f     *os.File
+	name  string
+	files map[string]*zip.File
+}
+
+type memFile struct {
+	*bytes.Reader
+}
+
+func (*memFile) Close() error {
+	return nil
+}
+
+func newZipFileSystem(name string) (*zipFileSystem, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r, err := zip.NewReader(f, fi.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	z := &zipFileSystem{
+		f:     f,
+		name:  name,
+		files: make(map[string]*zip.File, len(r.File)),
+	}
+	for _, zf := range r.File {
+		if zf.FileInfo().IsDir() {
+			continue
+		}
+		z.files[zipPath(zf.Name)] = zf
+	}
+	return z, nil
+}
+
+// zipPath returns the name used inside the archive. As with pack files there
+// is no 'root', all files are relative to '.'
+func zipPath(path string) string {
+	return strings.TrimPrefix(pathpkg.Clean("/"+path), "/")
+}
+
+func (z *zipFileSystem) lookup(op, path string) (*zip.File, error) {
+	zf, ok := z.files[zipPath(path)]
+	if !ok {
+		return nil, &os.PathError{Op: op, Path: path, Err: os.ErrNotExist}
+	}
+	return zf, nil
+}
+
+func (z *zipFileSystem) Open(path string) (io.ReadSeekCloser, error) {
+	zf, err := z.lookup("open", path)
+	if err != nil {
+		return nil, err
+	}
+	if zf.Method == zip.Store {
+		// stored files can be read in place like the files of a pak
+		offset, err := zf.DataOffset()
+		if err != nil {
+			return nil, err
+		}
+		return &closer{io.NewSectionReader(z.f, offset, int64(zf.UncompressedSize64))}, nil
+	}
+	rc, err := zf.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	return &memFile{bytes.NewReader(b)}, nil
+}
+
+func (z *zipFileSystem) Stat(path string) (os.FileInfo, error) {
+	zf, err := z.lookup("stat", path)
+	if err != nil {
+		return nil, err
+	}
+	return &fileInfo{
+		name: zipPath(path),
+		size: int64(zf.UncompressedSize64),
+	}, nil
+}
+
+func (z *zipFileSystem) ReadDir(path string) ([]os.FileInfo, error) {
+	prefix := zipPath(path)
+	if prefix != "" {
+		prefix += "/"
+	}
+	dirs := make(map[string]bool)
+	var r []os.FileInfo
+	for name, zf := range z.files {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		rest := name[len(prefix):]
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			rest = rest[:i]
+			if !dirs[rest] {
+				dirs[rest] = true
+				r = append(r, &fileInfo{name: rest, dir: true})
+			}
+			continue
+		}
+		r = append(r, &fileInfo{name: rest, size: int64(zf.UncompressedSize64)})
+	}
+	if len(r) == 0 && prefix != "" {
+		return nil, &os.PathError{Op: "readdir", Path: path, Err: os.ErrNotExist}
+	}
+	sort.Slice(r, func(i, j int) bool { return r[i].Name() < r[j].Name() })
+	return r, nil
+}
+
+func (z *zipFileSystem) String() string {
+	return z.name
+}
+
+func (z *zipFileSystem) Close() error {
+	return z.f.Close()
+}
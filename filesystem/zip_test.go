@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package filesystem
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeZip creates a zip file with the given files. Files with a name
+// starting with "s:" are stored instead of deflated.
+func writeZip(t *testing.T, name string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for n, c := range files {
+		h := &zip.FileHeader{Name: n, Method: zip.Deflate}
+		if len(n) > 2 && n[:2] == "s:" {
+			h.Name = n[2:]
+			h.Method = zip.Store
+		}
+		fw, err := w.CreateHeader(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(fw, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readAll(t *testing.T, f io.ReadSeekCloser) string {
+	t.Helper()
+	defer f.Close()
+	b, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("Could not read file: %v", err)
+	}
+	return string(b)
+}
+
+func TestZipFileSystem(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.pk3")
+	writeZip(t, name, map[string]string{
+		"s:maps/stored.txt":  "stored file",
+		"progs/deflated.txt": "deflated file deflated file deflated file",
+	})
+	z, err := newZipFileSystem(name)
+	if err != nil {
+		t.Fatalf("Could not open pk3: %v", err)
+	}
+	defer z.Close()
+	for _, tt := range []struct {
+		path, want string
+	}{
+		{"/maps/stored.txt", "stored file"},
+		{"progs/deflated.txt", "deflated file deflated file deflated file"},
+	} {
+		f, err := z.Open(tt.path)
+		if err != nil {
+			t.Fatalf("Could not open %s: %v", tt.path, err)
+		}
+		if got := readAll(t, f); got != tt.want {
+			t.Errorf("contents of %s: %q", tt.path, got)
+		}
+		fi, err := z.Stat(tt.path)
+		if err != nil {
+			t.Fatalf("Could not stat %s: %v", tt.path, err)
+		}
+		if fi.Size() != int64(len(tt.want)) {
+			t.Errorf("size of %s: %d", tt.path, fi.Size())
+		}
+	}
+	if _, err := z.Open("maps"); !os.IsNotExist(err) {
+		t.Errorf("Open of a directory: %v", err)
+	}
+}
+
+func TestFilesystemPk3Order(t *testing.T) {
+	old := BaseDir()
+	t.Cleanup(func() { UseBaseDir(old) })
+
+	base := t.TempDir()
+	id1 := filepath.Join(base, "id1")
+	if err := os.Mkdir(id1, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(id1, "doc.txt"), []byte("os"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeZip(t, filepath.Join(id1, "b.pk3"), map[string]string{"doc.txt": "b"})
+	writeZip(t, filepath.Join(id1, "a.pk3"), map[string]string{"doc.txt": "a", "a.txt": "only a"})
+	UseBaseDir(base)
+
+	for _, tt := range []struct {
+		path, want string
+	}{
+		{"doc.txt", "b"},
+		{"a.txt", "only a"},
+	} {
+		f, err := Open(tt.path)
+		if err != nil {
+			t.Fatalf("No file %s: %v", tt.path, err)
+		}
+		if got := readAll(t, f); got != tt.want {
+			t.Errorf("contents of %s: %q, want %q", tt.path, got, tt.want)
+		}
+	}
+	got, err := Glob("*.*")
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	if want := []string{"a.pk3", "a.txt", "b.pk3", "doc.txt"}; !slices.Equal(got, want) {
+		t.Errorf("Glob = %v, want %v", got, want)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"encoding/binary"
 	"fmt"
 	"image"
+	"image/draw"
+	_ "image/jpeg"
 	"image/png"
 	"io"
 	"log"
@@ -40,30 +42,67 @@ func Write(name string, data []byte, width, height int) error {
 	return nil
 }
 
+var loaders = []struct {
+	ext  string
+	load func(name string) (*image.NRGBA, error)
+}{
+	{".png", loadStd},
+	{".jpg", loadStd},
+	{".jpeg", loadStd},
+	{".tga", loadTGA},
+	{".pcx", loadPCX},
+}
+
+// Load loads the image name, name is without extension. The formats are
+// tried in the order png, jpeg, tga and pcx.
 func Load(name string) (*image.NRGBA, error) {
-	// Have not seen an image loaded, wait with further implementation
-	// until we found an image we could not load.
-	if _, err := filesystem.Stat(name + ".tga"); err == nil {
-		i, err := loadTGA(name + ".tga")
-		if err != nil {
-			log.Printf("Failed to load %v.tga, %v", name, err)
-		} else {
-			log.Printf("Succeeded in loading %v.tga", name)
+	for _, l := range loaders {
+		fn := name + l.ext
+		if _, err := filesystem.Stat(fn); err != nil {
+			continue
 		}
-		return i, err
-	}
-	if _, err := filesystem.Stat(name + ".pcx"); err == nil {
-		i, err := loadPCX(name + ".pcx")
+		i, err := l.load(fn)
 		if err != nil {
-			log.Printf("Faild to load %v.pcx, %v", name, err)
+			log.Printf("Failed to load %v, %v", fn, err)
 		} else {
-			log.Printf("Succeeded in loading %v.pcx", name)
+			log.Printf("Succeeded in loading %v", fn)
 		}
 		return i, err
 	}
 	return nil, fmt.Errorf("Image %v not found", name)
 }
 
+// Replacement returns the first of the images names which could be loaded
+// and its name. It returns nil if there is none.
+func Replacement(names ...string) (*image.NRGBA, string) {
+	for _, n := range names {
+		if i, err := Load(n); err == nil {
+			return i, n
+		}
+	}
+	return nil, ""
+}
+
+// loadStd loads the formats supported by the standard library.
+func loadStd(name string) (*image.NRGBA, error) {
+	f, err := filesystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	if n, ok := img.(*image.NRGBA); ok && b.Min == (image.Point{}) {
+		return n, nil
+	}
+	nrgba := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(nrgba, nrgba.Bounds(), img, b.Min, draw.Src)
+	return nrgba, nil
+}
+
 type tgaHeader struct {
 	IDLength       uint8
 	ColormapType   uint8
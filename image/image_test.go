@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package image
+
+import (
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"goquake/filesystem"
+)
+
+func useTestDir(t *testing.T) string {
+	t.Helper()
+	old := filesystem.BaseDir()
+	t.Cleanup(func() { filesystem.UseBaseDir(old) })
+	base := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(base, "id1", "gfx"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	filesystem.UseBaseDir(base)
+	return filepath.Join(base, "id1")
+}
+
+func writeImage(t *testing.T, name string, c color.NRGBA) {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.SetNRGBA(0, 0, c)
+	img.SetNRGBA(1, 0, c)
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if filepath.Ext(name) == ".png" {
+		err = png.Encode(f, img)
+	} else {
+		err = jpeg.Encode(f, img, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := useTestDir(t)
+	red := color.NRGBA{255, 0, 0, 255}
+	writeImage(t, filepath.Join(dir, "gfx", "a.jpg"), color.NRGBA{0, 0, 255, 255})
+	writeImage(t, filepath.Join(dir, "gfx", "a.png"), red)
+
+	img, err := Load("gfx/a")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := img.NRGBAAt(1, 0); got != red {
+		t.Errorf("png should be preferred, got %v", got)
+	}
+	if img.Bounds() != image.Rect(0, 0, 2, 1) {
+		t.Errorf("bounds = %v", img.Bounds())
+	}
+
+	os.Remove(filepath.Join(dir, "gfx", "a.png"))
+	img, err = Load("gfx/a")
+	if err != nil {
+		t.Fatalf("Load jpeg: %v", err)
+	}
+	if got := img.NRGBAAt(0, 0); got.B < 200 || got.R > 50 {
+		t.Errorf("jpeg pixel = %v, want blue", got)
+	}
+
+	if _, err := Load("gfx/missing"); err == nil {
+		t.Errorf("Load of missing image succeeded")
+	}
+}
+
+func TestReplacement(t *testing.T) {
+	dir := useTestDir(t)
+	writeImage(t, filepath.Join(dir, "gfx", "b.png"), color.NRGBA{1, 2, 3, 255})
+
+	img, name := Replacement("gfx/a", "gfx/b")
+	if img == nil || name != "gfx/b" {
+		t.Errorf("Replacement = %v, %q, want gfx/b", img, name)
+	}
+	if img, name := Replacement("gfx/a"); img != nil || name != "" {
+		t.Errorf("Replacement of missing = %v, %q", img, name)
+	}
+}
@@ -0,0 +1,360 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+// Package iqm loads Inter-Quake Models. The skeletal animation is applied
+// while loading, every frame of the model becomes a pose of the mesh model.
+// The animations become frames which animate on their own, so an entity
+// frame selects an animation. The joints are available as tags.
+package iqm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"goquake/filesystem"
+	"goquake/math/vec"
+	qmesh "goquake/mesh"
+	qm "goquake/model"
+)
+
+func init() {
+	qm.Register(Magic, loadM)
+}
+
+func loadM(name string, file filesystem.File) ([]qm.Model, error) {
+	mod, err := load(name, file)
+	if err != nil {
+		return nil, err
+	}
+	return []qm.Model{mod}, nil
+}
+
+type iqmFile struct {
+	name string
+	data []byte
+	text []byte
+}
+
+// read reads data at offset ofs.
+func (f *iqmFile) read(ofs uint32, data any) error {
+	size := binary.Size(data)
+	if size < 0 || uint64(ofs)+uint64(size) > uint64(len(f.data)) {
+		return fmt.Errorf("model %s is truncated", f.name)
+	}
+	return binary.Read(bytes.NewReader(f.data[ofs:int(ofs)+size]), binary.LittleEndian, data)
+}
+
+// readSlice reads count values of T at offset ofs. The count comes from the
+// file, so it is checked against the file size before allocating.
+func readSlice[T any](f *iqmFile, ofs uint32, count uint64) ([]T, error) {
+	var v T
+	if uint64(ofs) > uint64(len(f.data)) || count > (uint64(len(f.data))-uint64(ofs))/uint64(binary.Size(v)) {
+		return nil, fmt.Errorf("model %s is truncated", f.name)
+	}
+	d := make([]T, count)
+	if err := f.read(ofs, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (f *iqmFile) str(ofs uint32) string {
+	if int64(ofs) >= int64(len(f.text)) {
+		return ""
+	}
+	s := f.text[ofs:]
+	if i := bytes.IndexByte(s, 0); i >= 0 {
+		s = s[:i]
+	}
+	return string(s)
+}
+
+type vertices struct {
+	position []vec.Vec3
+	normal   []vec.Vec3
+	texCoord [][2]float32
+	indexes  [][4]uint8
+	weights  [][4]uint8
+}
+
+func load(name string, file io.Reader) (*qmesh.Model, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	f := &iqmFile{name: name, data: data}
+	var h header
+	if err := f.read(0, &h); err != nil {
+		return nil, err
+	}
+	if string(h.Magic[:]) != magic {
+		return nil, fmt.Errorf("%s is not an iqm file", name)
+	}
+	if h.Version != version {
+		return nil, fmt.Errorf("%s has wrong version number (%d should be %d)", name, h.Version, version)
+	}
+	if h.MeshCount == 0 || h.VertexCount == 0 || h.TriangleCount == 0 {
+		return nil, fmt.Errorf("model %s has no meshes", name)
+	}
+	if h.FrameCount > 0 && h.PoseCount != h.JointCount {
+		return nil, fmt.Errorf("model %s has %d poses for %d joints", name, h.PoseCount, h.JointCount)
+	}
+	if f.text, err = readSlice[byte](f, h.TextOffset, uint64(h.TextCount)); err != nil {
+		return nil, err
+	}
+
+	vs, err := f.vertices(&h)
+	if err != nil {
+		return nil, err
+	}
+	tris, err := readSlice[[3]uint32](f, h.TriangleOffset, uint64(h.TriangleCount))
+	if err != nil {
+		return nil, err
+	}
+	meshes, err := readSlice[mesh](f, h.MeshOffset, uint64(h.MeshCount))
+	if err != nil {
+		return nil, err
+	}
+	joints, err := readSlice[joint](f, h.JointOffset, uint64(h.JointCount))
+	if err != nil {
+		return nil, err
+	}
+	for i, j := range joints {
+		if j.Parent >= int32(i) {
+			return nil, fmt.Errorf("model %s: joint %d has parent %d", name, i, j.Parent)
+		}
+	}
+	poses, err := readSlice[pose](f, h.PoseOffset, uint64(h.PoseCount))
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range poses {
+		if p.Parent >= int32(i) {
+			return nil, fmt.Errorf("model %s: pose %d has parent %d", name, i, p.Parent)
+		}
+	}
+	anims, err := readSlice[anim](f, h.AnimOffset, uint64(h.AnimCount))
+	if err != nil {
+		return nil, err
+	}
+	frameData, err := readSlice[uint16](f, h.FrameOffset, uint64(h.FrameCount)*uint64(h.FrameChannelCount))
+	if err != nil {
+		return nil, err
+	}
+
+	skins, tags, err := f.animate(joints, poses, frameData, int(h.FrameCount), int(h.FrameChannelCount))
+	if err != nil {
+		return nil, err
+	}
+
+	var ms []*qmesh.Mesh
+	for _, m := range meshes {
+		if uint64(m.FirstVertex)+uint64(m.VertexCount) > uint64(h.VertexCount) ||
+			uint64(m.FirstTriangle)+uint64(m.TriangleCount) > uint64(h.TriangleCount) {
+			return nil, fmt.Errorf("model %s: mesh %s out of bounds", name, f.str(m.Name))
+		}
+		ms = append(ms, f.mesh(&m, vs, tris, skins))
+	}
+
+	frames := make([]qmesh.Frame, 0, len(anims))
+	for _, a := range anims {
+		if a.FrameCount == 0 || uint64(a.FirstFrame)+uint64(a.FrameCount) > uint64(h.FrameCount) {
+			return nil, fmt.Errorf("model %s: animation %s out of bounds", name, f.str(a.Name))
+		}
+		interval := float32(0.1)
+		if a.FrameRate > 0 {
+			interval = 1 / a.FrameRate
+		}
+		frames = append(frames, qmesh.Frame{
+			Name:      f.str(a.Name),
+			FirstPose: int(a.FirstFrame),
+			PoseCount: int(a.FrameCount),
+			Interval:  interval,
+		})
+	}
+	if len(frames) == 0 {
+		// without animations every pose is a frame of its own
+		for i := range len(skins) {
+			frames = append(frames, qmesh.Frame{FirstPose: i, PoseCount: 1, Interval: 0.1})
+		}
+	}
+	return qmesh.New(name, int(h.Flags), ms, frames, tags)
+}
+
+// vertices reads the vertex arrays used for rendering.
+func (f *iqmFile) vertices(h *header) (*vertices, error) {
+	arrays, err := readSlice[vertexArray](f, h.VertexArrayOffset, uint64(h.VertexArrayCount))
+	if err != nil {
+		return nil, err
+	}
+	n := uint64(h.VertexCount)
+	vs := &vertices{}
+	for _, a := range arrays {
+		var err error
+		switch a.Type {
+		case vaPosition:
+			vs.position, err = readArray[vec.Vec3](f, &a, formatFloat, 3, n)
+		case vaNormal:
+			vs.normal, err = readArray[vec.Vec3](f, &a, formatFloat, 3, n)
+		case vaTexCoord:
+			vs.texCoord, err = readArray[[2]float32](f, &a, formatFloat, 2, n)
+		case vaBlendIndexes:
+			vs.indexes, err = readArray[[4]uint8](f, &a, formatUByte, 4, n)
+		case vaBlendWeights:
+			vs.weights, err = readArray[[4]uint8](f, &a, formatUByte, 4, n)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if vs.position == nil {
+		return nil, fmt.Errorf("model %s has no vertex positions", f.name)
+	}
+	if vs.texCoord == nil {
+		vs.texCoord = make([][2]float32, n)
+	}
+	if (vs.indexes == nil) != (vs.weights == nil) {
+		vs.indexes, vs.weights = nil, nil
+	}
+	return vs, nil
+}
+
+func readArray[T any](f *iqmFile, a *vertexArray, format, size uint32, n uint64) ([]T, error) {
+	if a.Format != format || a.Size != size {
+		return nil, fmt.Errorf("model %s: vertex array %d has format %d and size %d, want %d and %d", f.name, a.Type, a.Format, a.Size, format, size)
+	}
+	return readSlice[T](f, a.Offset, n)
+}
+
+// animate returns the skinning matrices and the tags of all frames. Models
+// without frames get the base pose.
+func (f *iqmFile) animate(joints []joint, poses []pose, frameData []uint16, frames, channels int) ([][]matrix, [][]qmesh.Tag, error) {
+	base := make([]matrix, len(joints))
+	inverse := make([]matrix, len(joints))
+	for i, j := range joints {
+		base[i] = fromTRS(j.Translate, j.Rotate, j.Scale)
+		if j.Parent >= 0 {
+			base[i] = base[j.Parent].mul(&base[i])
+		}
+		inverse[i] = base[i].invert()
+	}
+	tags := func(global []matrix) []qmesh.Tag {
+		ts := make([]qmesh.Tag, len(joints))
+		for i, j := range joints {
+			ts[i] = qmesh.Tag{
+				Name:   f.str(j.Name),
+				Origin: global[i].column(3),
+				Axis:   [3]vec.Vec3{global[i].column(0), global[i].column(1), global[i].column(2)},
+			}
+		}
+		return ts
+	}
+	if frames == 0 {
+		skin := make([]matrix, len(joints))
+		for i := range skin {
+			skin[i] = identity()
+		}
+		return [][]matrix{skin}, [][]qmesh.Tag{tags(base)}, nil
+	}
+
+	skins := make([][]matrix, frames)
+	ts := make([][]qmesh.Tag, frames)
+	fd := frameData
+	for fr := range frames {
+		global := make([]matrix, len(poses))
+		skin := make([]matrix, len(poses))
+		start := len(fd)
+		for i, p := range poses {
+			var ch [10]float32
+			for c := range ch {
+				ch[c] = p.ChannelOffset[c]
+				if p.Mask&(1<<c) != 0 {
+					if len(fd) == 0 {
+						return nil, nil, fmt.Errorf("model %s: frame %d is missing channels", f.name, fr)
+					}
+					ch[c] += float32(fd[0]) * p.ChannelScale[c]
+					fd = fd[1:]
+				}
+			}
+			global[i] = fromTRS(vec.Vec3{ch[0], ch[1], ch[2]},
+				[4]float32{ch[3], ch[4], ch[5], ch[6]},
+				vec.Vec3{ch[7], ch[8], ch[9]})
+			if p.Parent >= 0 {
+				global[i] = global[p.Parent].mul(&global[i])
+			}
+			skin[i] = global[i].mul(&inverse[i])
+		}
+		if used := start - len(fd); used != channels {
+			return nil, nil, fmt.Errorf("model %s: frame %d uses %d channels, want %d", f.name, fr, used, channels)
+		}
+		skins[fr] = skin
+		ts[fr] = tags(global)
+	}
+	return skins, ts, nil
+}
+
+// mesh creates the mesh m with a pose for each of the skinning matrices.
+func (f *iqmFile) mesh(m *mesh, vs *vertices, tris [][3]uint32, skins [][]matrix) *qmesh.Mesh {
+	first, count := int(m.FirstVertex), int(m.VertexCount)
+	ms := &qmesh.Mesh{
+		Name:      f.str(m.Name),
+		TexCoords: vs.texCoord[first : first+count],
+		Poses:     make([][]qmesh.Vertex, len(skins)),
+	}
+	for _, t := range tris[m.FirstTriangle : m.FirstTriangle+m.TriangleCount] {
+		for _, v := range t {
+			// the index gets checked by mesh.New
+			ms.Indices = append(ms.Indices, v-m.FirstVertex)
+		}
+	}
+	for p, skin := range skins {
+		pose := make([]qmesh.Vertex, count)
+		for i := range pose {
+			v := first + i
+			mat := f.blend(vs, v, skin)
+			pose[i].Point = mat.transformPoint(vs.position[v])
+			if vs.normal != nil {
+				if n := mat.transformVector(vs.normal[v]); n != (vec.Vec3{}) {
+					pose[i].Normal = n.Normalize()
+				}
+			}
+		}
+		ms.Poses[p] = pose
+	}
+	mat := f.str(m.Material)
+	if mat != "" && !strings.Contains(mat, "/") {
+		mat = path.Join(path.Dir(f.name), mat)
+	}
+	skin := qmesh.LoadSkin(mat)
+	if skin == nil {
+		skin = qmesh.LoadSkin(filesystem.StripExt(f.name) + "_0")
+	}
+	ms.Skins = append(ms.Skins, skin)
+	return ms
+}
+
+// blend returns the weighted sum of the skinning matrices of vertex v.
+func (f *iqmFile) blend(vs *vertices, v int, skin []matrix) matrix {
+	if vs.indexes == nil || len(skin) == 0 {
+		return identity()
+	}
+	var r matrix
+	var total float32
+	for k := range 4 {
+		w := vs.weights[v][k]
+		j := int(vs.indexes[v][k])
+		if w == 0 || j >= len(skin) {
+			continue
+		}
+		s := skin[j].scaled(float32(w) / 255)
+		r.add(&s)
+		total += float32(w) / 255
+	}
+	if total == 0 {
+		return identity()
+	}
+	return r
+}
@@ -0,0 +1,183 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package iqm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"goquake/math/vec"
+)
+
+// testModel returns an iqm with a single triangle bound to one joint and
+// an animation moving the joint along x.
+func testModel(t *testing.T) []byte {
+	t.Helper()
+	var h header
+	copy(h.Magic[:], magic)
+	h.Version = version
+
+	buf := &bytes.Buffer{}
+	buf.Write(make([]byte, binary.Size(h)))
+	add := func(d any) uint32 {
+		ofs := uint32(buf.Len())
+		if err := binary.Write(buf, binary.LittleEndian, d); err != nil {
+			t.Fatal(err)
+		}
+		return ofs
+	}
+
+	text := []byte("\x00body\x00root\x00walk\x00")
+	h.TextCount = uint32(len(text))
+	h.TextOffset = add(text)
+
+	h.VertexCount = 3
+	positions := add([3][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}})
+	normals := add([3][3]float32{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}})
+	texCoords := add([3][2]float32{{0, 0}, {1, 0}, {0, 1}})
+	indexes := add([3][4]uint8{})
+	weights := add([3][4]uint8{{255}, {255}, {255}})
+	arrays := []vertexArray{
+		{Type: vaPosition, Format: formatFloat, Size: 3, Offset: positions},
+		{Type: vaNormal, Format: formatFloat, Size: 3, Offset: normals},
+		{Type: vaTexCoord, Format: formatFloat, Size: 2, Offset: texCoords},
+		{Type: vaBlendIndexes, Format: formatUByte, Size: 4, Offset: indexes},
+		{Type: vaBlendWeights, Format: formatUByte, Size: 4, Offset: weights},
+	}
+	h.VertexArrayCount = uint32(len(arrays))
+	h.VertexArrayOffset = add(arrays)
+
+	h.TriangleCount = 1
+	h.TriangleOffset = add([1][3]uint32{{0, 1, 2}})
+	h.MeshCount = 1
+	h.MeshOffset = add([1]mesh{{Name: 1, VertexCount: 3, TriangleCount: 1}})
+
+	h.JointCount = 1
+	h.JointOffset = add([1]joint{{Name: 6, Parent: -1, Rotate: [4]float32{0, 0, 0, 1}, Scale: [3]float32{1, 1, 1}}})
+	h.PoseCount = 1
+	h.PoseOffset = add([1]pose{{
+		Parent:        -1,
+		Mask:          1, // translate x
+		ChannelOffset: [10]float32{0, 0, 0, 0, 0, 0, 1, 1, 1, 1},
+		ChannelScale:  [10]float32{1},
+	}})
+	h.AnimCount = 1
+	h.AnimOffset = add([1]anim{{Name: 11, FrameCount: 2, FrameRate: 20, Flags: animLoop}})
+	h.FrameCount = 2
+	h.FrameChannelCount = 1
+	h.FrameOffset = add([2]uint16{0, 10})
+
+	h.FileSize = uint32(buf.Len())
+	data := buf.Bytes()
+	hb := &bytes.Buffer{}
+	if err := binary.Write(hb, binary.LittleEndian, h); err != nil {
+		t.Fatal(err)
+	}
+	copy(data, hb.Bytes())
+	return data
+}
+
+func TestLoad(t *testing.T) {
+	m, err := load("progs/test.iqm", bytes.NewReader(testModel(t)))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(m.Meshes) != 1 || m.Meshes[0].Name != "body" {
+		t.Fatalf("meshes = %v", m.Meshes)
+	}
+	if got := m.PoseCount(); got != 2 {
+		t.Errorf("PoseCount() = %d, want 2", got)
+	}
+	if len(m.Frames) != 1 || m.Frames[0].Name != "walk" || m.Frames[0].PoseCount != 2 || m.Frames[0].Interval != 0.05 {
+		t.Errorf("frames = %v", m.Frames)
+	}
+	ms := m.Meshes[0]
+	if got := ms.Poses[0][1].Point; got != (vec.Vec3{1, 0, 0}) {
+		t.Errorf("pose 0 vertex 1 = %v, want [1 0 0]", got)
+	}
+	if got := ms.Poses[1][1].Point; got != (vec.Vec3{11, 0, 0}) {
+		t.Errorf("pose 1 vertex 1 = %v, want [11 0 0]", got)
+	}
+	if got := ms.Poses[1][2].Normal; vec.Sub(got, vec.Vec3{0, 0, 1}).Length() > 0.01 {
+		t.Errorf("pose 1 normal 2 = %v, want [0 0 1]", got)
+	}
+	if got, want := m.Maxs(), (vec.Vec3{11, 1, 0}); got != want {
+		t.Errorf("Maxs() = %v, want %v", got, want)
+	}
+	tag, ok := m.Tag(1, "root")
+	if !ok || tag.Origin != (vec.Vec3{10, 0, 0}) {
+		t.Errorf("Tag(1, root) = %v, %v", tag, ok)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	data := testModel(t)
+	bad := bytes.Clone(data)
+	bad[0] = 'X'
+	if _, err := load("magic.iqm", bytes.NewReader(bad)); err == nil {
+		t.Errorf("load with wrong magic succeeded")
+	}
+	if _, err := load("short.iqm", bytes.NewReader(data[:len(data)-2])); err == nil {
+		t.Errorf("load of truncated file succeeded")
+	}
+	// a frame needing more channels than stored
+	bad = bytes.Clone(data)
+	var h header
+	if err := binary.Read(bytes.NewReader(bad), binary.LittleEndian, &h); err != nil {
+		t.Fatal(err)
+	}
+	binary.LittleEndian.PutUint32(bad[h.PoseOffset+4:], 3)
+	if _, err := load("channels.iqm", bytes.NewReader(bad)); err == nil {
+		t.Errorf("load with missing channels succeeded")
+	}
+}
+
+func TestLoadHugeCounts(t *testing.T) {
+	data := testModel(t)
+	var h header
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &h); err != nil {
+		t.Fatal(err)
+	}
+	// counts far beyond the file size must fail before allocating
+	for name, c := range map[string]*uint32{
+		"text":      &h.TextCount,
+		"vertices":  &h.VertexCount,
+		"triangles": &h.TriangleCount,
+		"meshes":    &h.MeshCount,
+		"joints":    &h.JointCount,
+		"anims":     &h.AnimCount,
+		"frames":    &h.FrameCount,
+	} {
+		old := *c
+		*c = 0xffffffff
+		if name == "joints" {
+			h.PoseCount = *c
+		}
+		hb := &bytes.Buffer{}
+		if err := binary.Write(hb, binary.LittleEndian, h); err != nil {
+			t.Fatal(err)
+		}
+		bad := bytes.Clone(data)
+		copy(bad, hb.Bytes())
+		if _, err := load(name+".iqm", bytes.NewReader(bad)); err == nil {
+			t.Errorf("load with huge %s count succeeded", name)
+		}
+		*c = old
+		h.PoseCount = h.JointCount
+	}
+}
+
+func TestMatrixInvert(t *testing.T) {
+	m := fromTRS(vec.Vec3{1, 2, 3}, [4]float32{0, 0, 0.7071068, 0.7071068}, vec.Vec3{2, 2, 2})
+	inv := m.invert()
+	p := vec.Vec3{4, -5, 6}
+	q := m.transformPoint(p)
+	if got := inv.transformPoint(q); vec.Sub(got, p).Length() > 1e-4 {
+		t.Errorf("invert(m)(m(%v)) = %v", p, got)
+	}
+	// rotation by 90 degrees around z
+	if got, want := m.transformVector(vec.Vec3{1, 0, 0}), (vec.Vec3{0, 2, 0}); vec.Sub(got, want).Length() > 1e-4 {
+		t.Errorf("m(%v) = %v, want %v", vec.Vec3{1, 0, 0}, got, want)
+	}
+}
@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package iqm
+
+import (
+	"goquake/math/vec"
+
+	"github.com/chewxy/math32"
+)
+
+// matrix is an affine transformation, the rotation and scale in the first
+// three columns and the translation in the last one.
+type matrix [3][4]float32
+
+// fromTRS returns the matrix which scales by s, rotates by the quaternion q
+// and translates by t.
+func fromTRS(t vec.Vec3, q [4]float32, s vec.Vec3) matrix {
+	if l := math32.Sqrt(q[0]*q[0] + q[1]*q[1] + q[2]*q[2] + q[3]*q[3]); l > 0 {
+		for i := range q {
+			q[i] /= l
+		}
+	}
+	x, y, z, w := q[0], q[1], q[2], q[3]
+	r := [3][3]float32{
+		{1 - 2*(y*y+z*z), 2 * (x*y - z*w), 2 * (x*z + y*w)},
+		{2 * (x*y + z*w), 1 - 2*(x*x+z*z), 2 * (y*z - x*w)},
+		{2 * (x*z - y*w), 2 * (y*z + x*w), 1 - 2*(x*x+y*y)},
+	}
+	var m matrix
+	for i := range 3 {
+		for j := range 3 {
+			m[i][j] = r[i][j] * s[j]
+		}
+		m[i][3] = t[i]
+	}
+	return m
+}
+
+// mul returns the matrix applying b first and a second.
+func (a *matrix) mul(b *matrix) matrix {
+	var m matrix
+	for i := range 3 {
+		for j := range 4 {
+			m[i][j] = a[i][0]*b[0][j] + a[i][1]*b[1][j] + a[i][2]*b[2][j]
+		}
+		m[i][3] += a[i][3]
+	}
+	return m
+}
+
+// invert returns the inverse of m. m needs to be invertible.
+func (m *matrix) invert() matrix {
+	a := m
+	det := a[0][0]*(a[1][1]*a[2][2]-a[1][2]*a[2][1]) -
+		a[0][1]*(a[1][0]*a[2][2]-a[1][2]*a[2][0]) +
+		a[0][2]*(a[1][0]*a[2][1]-a[1][1]*a[2][0])
+	if det == 0 {
+		return identity()
+	}
+	id := 1 / det
+	var r matrix
+	r[0][0] = (a[1][1]*a[2][2] - a[1][2]*a[2][1]) * id
+	r[0][1] = (a[0][2]*a[2][1] - a[0][1]*a[2][2]) * id
+	r[0][2] = (a[0][1]*a[1][2] - a[0][2]*a[1][1]) * id
+	r[1][0] = (a[1][2]*a[2][0] - a[1][0]*a[2][2]) * id
+	r[1][1] = (a[0][0]*a[2][2] - a[0][2]*a[2][0]) * id
+	r[1][2] = (a[0][2]*a[1][0] - a[0][0]*a[1][2]) * id
+	r[2][0] = (a[1][0]*a[2][1] - a[1][1]*a[2][0]) * id
+	r[2][1] = (a[0][1]*a[2][0] - a[0][0]*a[2][1]) * id
+	r[2][2] = (a[0][0]*a[1][1] - a[0][1]*a[1][0]) * id
+	for i := range 3 {
+		r[i][3] = -(r[i][0]*a[0][3] + r[i][1]*a[1][3] + r[i][2]*a[2][3])
+	}
+	return r
+}
+
+func identity() matrix {
+	return matrix{{1, 0, 0, 0}, {0, 1, 0, 0}, {0, 0, 1, 0}}
+}
+
+// scaled returns m with every element multiplied by s.
+func (m *matrix) scaled(s float32) matrix {
+	var r matrix
+	for i := range 3 {
+		for j := range 4 {
+			r[i][j] = m[i][j] * s
+		}
+	}
+	return r
+}
+
+func (m *matrix) add(b *matrix) {
+	for i := range 3 {
+		for j := range 4 {
+			m[i][j] += b[i][j]
+		}
+	}
+}
+
+func (m *matrix) transformPoint(p vec.Vec3) vec.Vec3 {
+	return vec.Vec3{
+		m[0][0]*p[0] + m[0][1]*p[1] + m[0][2]*p[2] + m[0][3],
+		m[1][0]*p[0] + m[1][1]*p[1] + m[1][2]*p[2] + m[1][3],
+		m[2][0]*p[0] + m[2][1]*p[1] + m[2][2]*p[2] + m[2][3],
+	}
+}
+
+func (m *matrix) transformVector(v vec.Vec3) vec.Vec3 {
+	return vec.Vec3{
+		m[0][0]*v[0] + m[0][1]*v[1] + m[0][2]*v[2],
+		m[1][0]*v[0] + m[1][1]*v[1] + m[1][2]*v[2],
+		m[2][0]*v[0] + m[2][1]*v[1] + m[2][2]*v[2],
+	}
+}
+
+// column returns column i of the matrix.
+func (m *matrix) column(i int) vec.Vec3 {
+	return vec.Vec3{m[0][i], m[1][i], m[2][i]}
+}
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package iqm
+
+const (
+	version = 2
+	// Magic are the first 4 bytes of "INTERQUAKEMODEL\x00"
+	Magic = 'E'<<24 | 'T'<<16 | 'N'<<8 | 'I'
+	magic = "INTERQUAKEMODEL\x00"
+
+	animLoop = 1 << 0
+)
+
+// vertex array types
+const (
+	vaPosition = iota
+	vaTexCoord
+	vaNormal
+	vaTangent
+	vaBlendIndexes
+	vaBlendWeights
+	vaColor
+)
+
+// vertex array formats
+const (
+	formatByte = iota
+	formatUByte
+	formatShort
+	formatUShort
+	formatInt
+	formatUInt
+	formatHalf
+	formatFloat
+	formatDouble
+)
+
+type header struct {
+	Magic             [16]byte
+	Version           uint32
+	FileSize          uint32
+	Flags             uint32
+	TextCount         uint32
+	TextOffset        uint32
+	MeshCount         uint32
+	MeshOffset        uint32
+	VertexArrayCount  uint32
+	VertexCount       uint32
+	VertexArrayOffset uint32
+	TriangleCount     uint32
+	TriangleOffset    uint32
+	AdjacencyOffset   uint32
+	JointCount        uint32
+	JointOffset       uint32
+	PoseCount         uint32
+	PoseOffset        uint32
+	AnimCount         uint32
+	AnimOffset        uint32
+	FrameCount        uint32
+	FrameChannelCount uint32
+	FrameOffset       uint32
+	BoundsOffset      uint32
+	CommentCount      uint32
+	CommentOffset     uint32
+	ExtensionCount    uint32
+	ExtensionOffset   uint32
+}
+
+type mesh struct {
+	Name          uint32 // offsets into the text
+	Material      uint32
+	FirstVertex   uint32
+	VertexCount   uint32
+	FirstTriangle uint32
+	TriangleCount uint32
+}
+
+type vertexArray struct {
+	Type   uint32
+	Flags  uint32
+	Format uint32
+	Size   uint32 // components per vertex
+	Offset uint32
+}
+
+type joint struct {
+	Name      uint32
+	Parent    int32 // parents come before their children, -1 for none
+	Translate [3]float32
+	Rotate    [4]float32 // quaternion x, y, z, w
+	Scale     [3]float32
+}
+
+// pose describes how the frame data modifies a joint. The 10 channels are
+// translate, rotate and scale, a channel is stored in the frame data if its
+// bit in Mask is set.
+type pose struct {
+	Parent        int32
+	Mask          uint32
+	ChannelOffset [10]float32
+	ChannelScale  [10]float32
+}
+
+type anim struct {
+	Name       uint32
+	FirstFrame uint32
+	FrameCount uint32
+	FrameRate  float32
+	Flags      uint32
+}
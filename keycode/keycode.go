@@ -2,6 +2,10 @@
 
 package keycode
 
+import (
+	"sort"
+)
+
 type KeyCode int
 
 type KeyCodeSlice []KeyCode
@@ -247,6 +251,16 @@ func reverseMap(m map[string]KeyCode) map[KeyCode]string {
 	return r
 }
 
+// Names returns the sorted names of the keys which are no single character.
+func Names() []string {
+	r := make([]string, 0, len(s2k))
+	for n := range s2k {
+		r = append(r, n)
+	}
+	sort.Strings(r)
+	return r
+}
+
 func KeyToString(k KeyCode) string {
 	if k == -1 {
 		return "<KEY NOT FOUND>"
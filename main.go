@@ -14,6 +14,8 @@ import (
 
 	// register the model loaders
 	_ "goquake/bsp"
+	_ "goquake/iqm"
+	_ "goquake/md3"
 	_ "goquake/mdl"
 	_ "goquake/spr"
 )
@@ -0,0 +1,204 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+// Package md3 loads Quake 3 models. Every frame of an md3 is a pose, the
+// surfaces become meshes with a skin per shader.
+package md3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"goquake/filesystem"
+	"goquake/math/vec"
+	"goquake/mesh"
+	qm "goquake/model"
+
+	"github.com/chewxy/math32"
+)
+
+func init() {
+	qm.Register(Magic, loadM)
+}
+
+func loadM(name string, file filesystem.File) ([]qm.Model, error) {
+	mod, err := load(name, file)
+	if err != nil {
+		return nil, err
+	}
+	return []qm.Model{mod}, nil
+}
+
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+func load(name string, file io.ReadSeeker) (*mesh.Model, error) {
+	var h header
+	if err := binary.Read(file, binary.LittleEndian, &h); err != nil {
+		return nil, err
+	}
+	if h.Version != version {
+		return nil, fmt.Errorf("%s has wrong version number (%d should be %d)", name, h.Version, version)
+	}
+	switch {
+	case h.FrameCount < 1 || h.FrameCount > MaxFrames:
+		return nil, fmt.Errorf("model %s has invalid # of frames: %d", name, h.FrameCount)
+	case h.TagCount < 0 || h.TagCount > MaxTags:
+		return nil, fmt.Errorf("model %s has invalid # of tags: %d", name, h.TagCount)
+	case h.SurfCount < 1 || h.SurfCount > MaxSurfaces:
+		return nil, fmt.Errorf("model %s has invalid # of surfaces: %d", name, h.SurfCount)
+	}
+
+	frames := make([]frame, h.FrameCount)
+	if _, err := file.Seek(int64(h.FrameOffset), io.SeekStart); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(file, binary.LittleEndian, frames); err != nil {
+		return nil, fmt.Errorf("model %s: frames: %v", name, err)
+	}
+	mframes := make([]mesh.Frame, len(frames))
+	for i, f := range frames {
+		mframes[i] = mesh.Frame{
+			Name:      cString(f.Name[:]),
+			FirstPose: i,
+			PoseCount: 1,
+			Interval:  0.1,
+		}
+	}
+
+	var tags [][]mesh.Tag
+	if h.TagCount > 0 {
+		ts := make([]tag, h.FrameCount*h.TagCount)
+		if _, err := file.Seek(int64(h.TagOffset), io.SeekStart); err != nil {
+			return nil, err
+		}
+		if err := binary.Read(file, binary.LittleEndian, ts); err != nil {
+			return nil, fmt.Errorf("model %s: tags: %v", name, err)
+		}
+		tags = make([][]mesh.Tag, h.FrameCount)
+		for i := range tags {
+			tags[i] = make([]mesh.Tag, h.TagCount)
+			for j := range tags[i] {
+				t := &ts[i*int(h.TagCount)+j]
+				tags[i][j] = mesh.Tag{
+					Name:   cString(t.Name[:]),
+					Origin: t.Origin,
+					Axis:   [3]vec.Vec3{t.Axis[0], t.Axis[1], t.Axis[2]},
+				}
+			}
+		}
+	}
+
+	meshes := make([]*mesh.Mesh, 0, h.SurfCount)
+	offset := int64(h.SurfOffset)
+	for i := int32(0); i < h.SurfCount; i++ {
+		ms, end, err := loadSurface(name, file, offset, int(h.FrameCount))
+		if err != nil {
+			return nil, err
+		}
+		meshes = append(meshes, ms)
+		offset = end
+	}
+	return mesh.New(name, int(h.Flags), meshes, mframes, tags)
+}
+
+// loadSurface reads the surface at offset and returns the offset of the next
+// one.
+func loadSurface(name string, file io.ReadSeeker, offset int64, frames int) (*mesh.Mesh, int64, error) {
+	read := func(at int32, data any) error {
+		if _, err := file.Seek(offset+int64(at), io.SeekStart); err != nil {
+			return err
+		}
+		return binary.Read(file, binary.LittleEndian, data)
+	}
+	var s surface
+	if err := read(0, &s); err != nil {
+		return nil, 0, fmt.Errorf("model %s: surface: %v", name, err)
+	}
+	sname := cString(s.Name[:])
+	switch {
+	case s.ID != Magic:
+		return nil, 0, fmt.Errorf("model %s: surface %s has a wrong id", name, sname)
+	case int(s.FrameCount) != frames:
+		return nil, 0, fmt.Errorf("model %s: surface %s has %d frames, want %d", name, sname, s.FrameCount, frames)
+	case s.ShaderCount < 0 || s.ShaderCount > MaxShaders:
+		return nil, 0, fmt.Errorf("model %s: surface %s has invalid # of shaders: %d", name, sname, s.ShaderCount)
+	case s.VertCount < 1 || s.VertCount > MaxVerts:
+		return nil, 0, fmt.Errorf("model %s: surface %s has invalid # of vertices: %d", name, sname, s.VertCount)
+	case s.TriangleCount < 1 || s.TriangleCount > MaxTris:
+		return nil, 0, fmt.Errorf("model %s: surface %s has invalid # of triangles: %d", name, sname, s.TriangleCount)
+	}
+
+	shaders := make([]shader, s.ShaderCount)
+	if err := read(s.ShaderOffset, shaders); err != nil {
+		return nil, 0, fmt.Errorf("model %s: shaders: %v", name, err)
+	}
+	tris := make([][3]int32, s.TriangleCount)
+	if err := read(s.TriangleOffset, tris); err != nil {
+		return nil, 0, fmt.Errorf("model %s: triangles: %v", name, err)
+	}
+	st := make([][2]float32, s.VertCount)
+	if err := read(s.STOffset, st); err != nil {
+		return nil, 0, fmt.Errorf("model %s: texture coordinates: %v", name, err)
+	}
+	xyz := make([]xyzNormal, int(s.VertCount)*frames)
+	if err := read(s.XYZOffset, xyz); err != nil {
+		return nil, 0, fmt.Errorf("model %s: vertices: %v", name, err)
+	}
+
+	ms := &mesh.Mesh{
+		Name:      sname,
+		TexCoords: st,
+		Indices:   make([]uint32, 0, 3*len(tris)),
+		Poses:     make([][]mesh.Vertex, frames),
+	}
+	for _, t := range tris {
+		for _, v := range t {
+			if v < 0 || v >= s.VertCount {
+				return nil, 0, fmt.Errorf("model %s: surface %s has vertex index %d out of bounds", name, sname, v)
+			}
+			ms.Indices = append(ms.Indices, uint32(v))
+		}
+	}
+	for f := range ms.Poses {
+		p := make([]mesh.Vertex, s.VertCount)
+		for i := range p {
+			v := &xyz[f*int(s.VertCount)+i]
+			p[i] = mesh.Vertex{
+				Point: vec.Vec3{
+					float32(v.XYZ[0]) * xyzScale,
+					float32(v.XYZ[1]) * xyzScale,
+					float32(v.XYZ[2]) * xyzScale,
+				},
+				Normal: decodeNormal(v.Normal),
+			}
+		}
+		ms.Poses[f] = p
+	}
+	for i, sh := range shaders {
+		n := cString(sh.Name[:])
+		if n == "" {
+			// shaders without name use <model>_<n>
+			n = fmt.Sprintf("%s_%d", filesystem.StripExt(name), i)
+		}
+		ms.Skins = append(ms.Skins, mesh.LoadSkin(n))
+	}
+	if len(ms.Skins) == 0 {
+		ms.Skins = append(ms.Skins, mesh.LoadSkin(filesystem.StripExt(name)+"_0"))
+	}
+	return ms, offset + int64(s.EndOffset), nil
+}
+
+// decodeNormal converts the packed latitude and longitude to a vector.
+func decodeNormal(n uint16) vec.Vec3 {
+	lat := float32((n>>8)&0xff) * (2 * math32.Pi / 255)
+	lng := float32(n&0xff) * (2 * math32.Pi / 255)
+	sl, cl := math32.Sincos(lat)
+	sg, cg := math32.Sincos(lng)
+	return vec.Vec3{cl * sg, sl * sg, cg}
+}
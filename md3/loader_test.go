@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package md3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"goquake/math/vec"
+	"goquake/mesh"
+	qm "goquake/model"
+)
+
+var _ qm.Model = &mesh.Model{}
+
+// testModel returns an md3 with 2 frames, a tag and a single triangle.
+func testModel(t *testing.T) []byte {
+	t.Helper()
+	h := header{
+		ID:         Magic,
+		Version:    version,
+		FrameCount: 2,
+		TagCount:   1,
+		SurfCount:  1,
+	}
+	h.FrameOffset = int32(binary.Size(h))
+	h.TagOffset = h.FrameOffset + 2*int32(binary.Size(frame{}))
+	h.SurfOffset = h.TagOffset + 2*int32(binary.Size(tag{}))
+
+	s := surface{
+		ID:            Magic,
+		FrameCount:    2,
+		VertCount:     3,
+		TriangleCount: 1,
+	}
+	copy(s.Name[:], "body")
+	s.TriangleOffset = int32(binary.Size(s))
+	s.ShaderOffset = s.TriangleOffset + 12
+	s.STOffset = s.ShaderOffset
+	s.XYZOffset = s.STOffset + 3*8
+	s.EndOffset = s.XYZOffset + 2*3*8
+
+	var frames [2]frame
+	copy(frames[0].Name[:], "stand1")
+	copy(frames[1].Name[:], "stand2")
+	var tags [2]tag
+	for i := range tags {
+		copy(tags[i].Name[:], "tag_weapon")
+		tags[i].Origin = [3]float32{float32(i), 0, 8}
+		tags[i].Axis = [3][3]float32{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+	}
+	tris := [1][3]int32{{0, 1, 2}}
+	st := [3][2]float32{{0, 0}, {1, 0}, {0, 1}}
+	xyz := [2][3]xyzNormal{
+		{{XYZ: [3]int16{0, 0, 0}}, {XYZ: [3]int16{64, 0, 0}}, {XYZ: [3]int16{0, 64, 0}}},
+		{{XYZ: [3]int16{0, 0, 64}}, {XYZ: [3]int16{128, 0, 64}}, {XYZ: [3]int16{0, 128, 64}}},
+	}
+
+	buf := &bytes.Buffer{}
+	for _, d := range []any{h, frames, tags, s, tris, st, xyz} {
+		if err := binary.Write(buf, binary.LittleEndian, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestLoad(t *testing.T) {
+	m, err := load("progs/test.md3", bytes.NewReader(testModel(t)))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(m.Meshes) != 1 || m.Meshes[0].Name != "body" {
+		t.Fatalf("meshes = %v", m.Meshes)
+	}
+	if got := m.PoseCount(); got != 2 {
+		t.Errorf("PoseCount() = %d, want 2", got)
+	}
+	if len(m.Frames) != 2 || m.Frames[1].Name != "stand2" || m.Frames[1].FirstPose != 1 {
+		t.Errorf("frames = %v", m.Frames)
+	}
+	ms := m.Meshes[0]
+	if got := ms.Poses[1][1].Point; got != (vec.Vec3{2, 0, 1}) {
+		t.Errorf("pose 1 vertex 1 = %v, want [2 0 1]", got)
+	}
+	if got, want := m.Mins(), (vec.Vec3{0, 0, 0}); got != want {
+		t.Errorf("Mins() = %v, want %v", got, want)
+	}
+	if got, want := m.Maxs(), (vec.Vec3{2, 2, 1}); got != want {
+		t.Errorf("Maxs() = %v, want %v", got, want)
+	}
+	tag, ok := m.Tag(1, "tag_weapon")
+	if !ok || tag.Origin != (vec.Vec3{1, 0, 8}) {
+		t.Errorf("Tag(1, tag_weapon) = %v, %v", tag, ok)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	data := testModel(t)
+	// break the triangle
+	bad := bytes.Clone(data)
+	h := header{}
+	off := binary.Size(h) + 2*binary.Size(frame{}) + 2*binary.Size(tag{}) + binary.Size(surface{})
+	binary.LittleEndian.PutUint32(bad[off+8:], 3)
+	if _, err := load("bad.md3", bytes.NewReader(bad)); err == nil {
+		t.Errorf("load with vertex index out of bounds succeeded")
+	}
+	if _, err := load("short.md3", bytes.NewReader(data[:len(data)-4])); err == nil {
+		t.Errorf("load of truncated file succeeded")
+	}
+	bad = bytes.Clone(data)
+	binary.LittleEndian.PutUint32(bad[4:], 3)
+	if _, err := load("old.md3", bytes.NewReader(bad)); err == nil {
+		t.Errorf("load with wrong version succeeded")
+	}
+}
+
+func TestDecodeNormal(t *testing.T) {
+	for _, tc := range []struct {
+		n    uint16
+		want vec.Vec3
+	}{
+		{0x0000, vec.Vec3{0, 0, 1}},
+		{0x0080, vec.Vec3{0, 0, -1}},
+	} {
+		got := decodeNormal(tc.n)
+		if vec.Sub(got, tc.want).Length() > 0.05 {
+			t.Errorf("decodeNormal(%#x) = %v, want %v", tc.n, got, tc.want)
+		}
+	}
+}
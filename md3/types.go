@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package md3
+
+const (
+	version = 15
+	Magic   = '3'<<24 | 'P'<<16 | 'D'<<8 | 'I'
+
+	MaxFrames   = 1024
+	MaxTags     = 16
+	MaxSurfaces = 32
+	MaxShaders  = 256
+	MaxVerts    = 4096
+	MaxTris     = 8192
+
+	// xyzScale converts the packed positions to units
+	xyzScale = 1.0 / 64
+)
+
+type header struct {
+	ID          int32
+	Version     int32
+	Name        [64]byte
+	Flags       int32
+	FrameCount  int32
+	TagCount    int32
+	SurfCount   int32
+	SkinCount   int32
+	FrameOffset int32
+	TagOffset   int32
+	SurfOffset  int32
+	EndOffset   int32
+}
+
+type frame struct {
+	Mins   [3]float32
+	Maxs   [3]float32
+	Origin [3]float32
+	Radius float32
+	Name   [16]byte
+}
+
+type tag struct {
+	Name   [64]byte
+	Origin [3]float32
+	Axis   [3][3]float32
+}
+
+// surface offsets are relative to the start of the surface
+type surface struct {
+	ID             int32
+	Name           [64]byte
+	Flags          int32
+	FrameCount     int32
+	ShaderCount    int32
+	VertCount      int32
+	TriangleCount  int32
+	TriangleOffset int32
+	ShaderOffset   int32
+	STOffset       int32
+	XYZOffset      int32
+	EndOffset      int32
+}
+
+type shader struct {
+	Name  [64]byte
+	Index int32
+}
+
+type xyzNormal struct {
+	XYZ    [3]int16
+	Normal uint16 // latitude and longitude, 8 bit each
+}
@@ -205,7 +205,15 @@ func load(name string, buf io.ReadSeeker) (*Model, error) {
 			tn := fmt.Sprintf("%s:frame%d", name, i)
 			data := make([]byte, skinSize)
 			buf.Read(data)
-			if fullBright(data) {
+			if t, fbt := texture.External(tn, texture.TexPrefPad, fmt.Sprintf("%s_%d", filesystem.StripExt(name), i)); t != nil {
+				// external skins replace progs/<model>_<skin>
+				fbts := []*texture.Texture{}
+				if fbt != nil {
+					fbts = append(fbts, fbt)
+				}
+				mod.Textures = append(mod.Textures, []*texture.Texture{t})
+				mod.FBTextures = append(mod.FBTextures, fbts)
+			} else if fullBright(data) {
 				fbtn := fmt.Sprintf("%s:frame%d_glow", name, i)
 				fbtf := texture.TexPrefPad | texture.TexPrefFullBright
 				tf := texture.TexPrefPad | texture.TexPrefNoBright
@@ -0,0 +1,254 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+// Package mesh holds triangle meshes animated by interpolating between
+// poses. This is the in memory form of the md3 and iqm models, skeletal
+// animations are turned into poses while loading.
+package mesh
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"math"
+
+	"goquake/filesystem"
+	"goquake/glh"
+	"goquake/image"
+	"goquake/math/vec"
+	"goquake/texture"
+
+	"github.com/chewxy/math32"
+)
+
+type Vertex struct {
+	Point  vec.Vec3
+	Normal vec.Vec3
+}
+
+// Mesh is a part of a model with its own skins.
+type Mesh struct {
+	Name string
+	// Skins holds one texture per skin number, nil if the skin is missing.
+	Skins     []*texture.Texture
+	TexCoords [][2]float32
+	Indices   []uint32
+	// Poses holds the vertices of each pose, all poses have the same
+	// number of vertices as TexCoords.
+	Poses [][]Vertex
+
+	// VertexArrayBuffer is a gl.Buffer with following data layout:
+	// ([ 3 float32 xyz, 3 float32 normal ] * numverts ) * posecount
+	// (2 float32 s,t texcoord) * numverts
+	// The texcoords start at STOffset.
+	VertexArrayBuffer        *glh.Buffer
+	VertexElementArrayBuffer *glh.Buffer
+	STOffset                 int
+	vertexArrayBufferData    []byte
+}
+
+// VertexSize is the size of one vertex of a pose in the VertexArrayBuffer.
+const VertexSize = 6 * 4
+
+// Skin returns the texture for skin number n.
+func (m *Mesh) Skin(n int) *texture.Texture {
+	if len(m.Skins) == 0 {
+		return nil
+	}
+	if n < 0 || n >= len(m.Skins) {
+		n = 0
+	}
+	return m.Skins[n]
+}
+
+// Frame is a sequence of poses. Frames with more than one pose animate on
+// their own, same as frame groups of mdl models.
+type Frame struct {
+	Name      string
+	FirstPose int
+	PoseCount int
+	Interval  float32 // time per pose
+}
+
+// Tag is a named position of a pose to attach other models.
+type Tag struct {
+	Name   string
+	Origin vec.Vec3
+	Axis   [3]vec.Vec3 // forward, left, up
+}
+
+type Model struct {
+	name  string
+	mins  vec.Vec3
+	maxs  vec.Vec3
+	flags int
+
+	Radius    float32
+	YawRadius float32
+
+	Meshes []*Mesh
+	Frames []Frame
+	// Tags holds the tags of each pose.
+	Tags [][]Tag
+}
+
+func (m *Model) Name() string {
+	return m.name
+}
+
+func (m *Model) Mins() vec.Vec3 {
+	return m.mins
+}
+
+func (m *Model) Maxs() vec.Vec3 {
+	return m.maxs
+}
+
+func (m *Model) Flags() int {
+	return m.flags
+}
+
+func (m *Model) AddFlag(f int) {
+	m.flags |= f
+}
+
+// PoseCount returns the number of poses of the model.
+func (m *Model) PoseCount() int {
+	if len(m.Meshes) == 0 {
+		return 0
+	}
+	return len(m.Meshes[0].Poses)
+}
+
+// Tag returns the tag with the given name of the pose.
+func (m *Model) Tag(pose int, name string) (Tag, bool) {
+	if pose < 0 || pose >= len(m.Tags) {
+		return Tag{}, false
+	}
+	for _, t := range m.Tags[pose] {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Tag{}, false
+}
+
+// New checks the meshes and frames and calculates the bounds of the model.
+func New(name string, flags int, meshes []*Mesh, frames []Frame, tags [][]Tag) (*Model, error) {
+	if len(meshes) == 0 {
+		return nil, fmt.Errorf("model %s has no meshes", name)
+	}
+	poses := len(meshes[0].Poses)
+	if poses == 0 {
+		return nil, fmt.Errorf("model %s has no poses", name)
+	}
+	for _, ms := range meshes {
+		if len(ms.Poses) != poses {
+			return nil, fmt.Errorf("model %s: mesh %s has %d poses, want %d", name, ms.Name, len(ms.Poses), poses)
+		}
+		for _, p := range ms.Poses {
+			if len(p) != len(ms.TexCoords) {
+				return nil, fmt.Errorf("model %s: mesh %s has a pose with %d vertices, want %d", name, ms.Name, len(p), len(ms.TexCoords))
+			}
+		}
+		if len(ms.Indices)%3 != 0 {
+			return nil, fmt.Errorf("model %s: mesh %s has incomplete triangles", name, ms.Name)
+		}
+		for _, i := range ms.Indices {
+			if int(i) >= len(ms.TexCoords) {
+				return nil, fmt.Errorf("model %s: mesh %s has vertex index %d out of bounds", name, ms.Name, i)
+			}
+		}
+	}
+	for _, f := range frames {
+		if f.PoseCount < 1 || f.FirstPose < 0 || f.FirstPose+f.PoseCount > poses {
+			return nil, fmt.Errorf("model %s: frame %s has invalid poses", name, f.Name)
+		}
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("model %s has no frames", name)
+	}
+	if tags != nil && len(tags) != poses {
+		return nil, fmt.Errorf("model %s has tags for %d of %d poses", name, len(tags), poses)
+	}
+	m := &Model{
+		name:   name,
+		flags:  flags,
+		Meshes: meshes,
+		Frames: frames,
+		Tags:   tags,
+	}
+	m.calcBounds()
+	for _, ms := range meshes {
+		ms.setupBuffers()
+	}
+	return m, nil
+}
+
+func (m *Model) calcBounds() {
+	mins := vec.Vec3{math.MaxFloat32, math.MaxFloat32, math.MaxFloat32}
+	maxs := vec.Vec3{-math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32}
+	var radius, yawRadius float32
+	for _, ms := range m.Meshes {
+		for _, p := range ms.Poses {
+			for _, v := range p {
+				for i := range 3 {
+					mins[i] = min(mins[i], v.Point[i])
+					maxs[i] = max(maxs[i], v.Point[i])
+				}
+				dist := v.Point[0]*v.Point[0] + v.Point[1]*v.Point[1]
+				yawRadius = max(yawRadius, dist)
+				radius = max(radius, dist+v.Point[2]*v.Point[2])
+			}
+		}
+	}
+	if mins[0] > maxs[0] {
+		// no vertices at all
+		mins, maxs = vec.Vec3{}, vec.Vec3{}
+	}
+	m.mins = mins
+	m.maxs = maxs
+	m.Radius = math32.Sqrt(radius)
+	m.YawRadius = math32.Sqrt(yawRadius)
+}
+
+func (ms *Mesh) setupBuffers() {
+	size := len(ms.Poses)*len(ms.TexCoords)*VertexSize + len(ms.TexCoords)*8
+	buf := bytes.NewBuffer(make([]byte, 0, size))
+	for _, p := range ms.Poses {
+		for _, v := range p {
+			binary.Write(buf, binary.LittleEndian, v)
+		}
+	}
+	ms.STOffset = buf.Len()
+	binary.Write(buf, binary.LittleEndian, ms.TexCoords)
+	ms.vertexArrayBufferData = buf.Bytes()
+}
+
+// UploadBuffer creates the gl buffers of all meshes.
+func (m *Model) UploadBuffer() {
+	for _, ms := range m.Meshes {
+		ms.VertexElementArrayBuffer = glh.NewBuffer(glh.ElementArrayBuffer)
+		ms.VertexElementArrayBuffer.Bind()
+		ms.VertexElementArrayBuffer.SetData(4*len(ms.Indices), glh.Ptr(ms.Indices))
+
+		ms.VertexArrayBuffer = glh.NewBuffer(glh.ArrayBuffer)
+		ms.VertexArrayBuffer.Bind()
+		ms.VertexArrayBuffer.SetData(len(ms.vertexArrayBufferData), glh.Ptr(ms.vertexArrayBufferData))
+	}
+}
+
+// LoadSkin loads the image name, the extension of name is ignored and the
+// formats supported by image.Load are tried instead. It returns nil if there
+// is no such image.
+func LoadSkin(name string) *texture.Texture {
+	if name == "" {
+		return nil
+	}
+	img, err := image.Load(filesystem.StripExt(name))
+	if err != nil {
+		log.Printf("Could not load skin %s: %v", name, err)
+		return nil
+	}
+	return texture.FromImage(img, texture.TexPrefMipMap|texture.TexPrefAlpha, name)
+}
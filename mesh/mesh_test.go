@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package mesh
+
+import (
+	"testing"
+
+	"goquake/math/vec"
+	qm "goquake/model"
+)
+
+var _ qm.Model = &Model{}
+
+func triangle() *Mesh {
+	return &Mesh{
+		Name:      "tri",
+		TexCoords: [][2]float32{{0, 0}, {1, 0}, {0, 1}},
+		Indices:   []uint32{0, 1, 2},
+		Poses: [][]Vertex{{
+			{Point: vec.Vec3{0, 0, -1}},
+			{Point: vec.Vec3{3, 0, 1}},
+			{Point: vec.Vec3{0, 4, 1}},
+		}},
+	}
+}
+
+func TestNew(t *testing.T) {
+	m, err := New("tri", 0, []*Mesh{triangle()}, []Frame{{PoseCount: 1}}, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if got, want := m.Mins(), (vec.Vec3{0, 0, -1}); got != want {
+		t.Errorf("Mins() = %v, want %v", got, want)
+	}
+	if got, want := m.Maxs(), (vec.Vec3{3, 4, 1}); got != want {
+		t.Errorf("Maxs() = %v, want %v", got, want)
+	}
+	if m.YawRadius != 4 {
+		t.Errorf("YawRadius = %v, want 4", m.YawRadius)
+	}
+	ms := m.Meshes[0]
+	if ms.STOffset != 3*VertexSize {
+		t.Errorf("STOffset = %d, want %d", ms.STOffset, 3*VertexSize)
+	}
+	if got, want := len(ms.vertexArrayBufferData), 3*VertexSize+3*8; got != want {
+		t.Errorf("buffer size = %d, want %d", got, want)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	frames := []Frame{{PoseCount: 1}}
+	bad := triangle()
+	bad.Indices = []uint32{0, 1, 3}
+	if _, err := New("bad", 0, []*Mesh{bad}, frames, nil); err == nil {
+		t.Errorf("New with index out of bounds succeeded")
+	}
+	if _, err := New("frame", 0, []*Mesh{triangle()}, []Frame{{FirstPose: 1, PoseCount: 1}}, nil); err == nil {
+		t.Errorf("New with frame out of bounds succeeded")
+	}
+	if _, err := New("tags", 0, []*Mesh{triangle()}, frames, make([][]Tag, 2)); err == nil {
+		t.Errorf("New with wrong number of tags succeeded")
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"goquake/protocol"
@@ -38,6 +40,7 @@ type Connection struct {
 	addr         string
 	uuid         uuid.UUID
 	canWrite     bool
+	listener     *Listener // nil for client connections
 }
 
 type msg struct {
@@ -45,20 +48,19 @@ type msg struct {
 }
 
 var (
-	netTime            time.Time
-	loopClient         *Connection
-	loopServer         *Connection
-	loopConnectPending = false
-	port               = 26000
-	address            string
+	netTime   atomic.Int64 // unix nano
+	addressMu sync.Mutex
+	address   string
 )
 
+// Port returns the port of the default listener which is also the port
+// clients connect to.
 func Port() int {
-	return port
+	return defaultListener.Port()
 }
 
 func SetPort(p int) {
-	port = p
+	defaultListener.SetPort(p)
 }
 
 func localIP() string {
@@ -74,6 +76,8 @@ func localIP() string {
 }
 
 func Address() string {
+	addressMu.Lock()
+	defer addressMu.Unlock()
 	if len(address) > 0 {
 		return address
 	}
@@ -107,20 +111,20 @@ func (c *Connection) Address() string {
 }
 
 func SetTime() {
-	netTime = time.Now()
+	netTime.Store(time.Now().UnixNano())
 }
 
 func Time() time.Time {
-	return netTime
+	return time.Unix(0, netTime.Load())
 }
 
 func Connect(host string) (*Connection, error) {
 	SetTime()
 	// loopback only
 	if strings.ToUpper(host) != LocalAddress {
-		return udpConnect(host, port)
+		return udpConnect(host, Port())
 	}
-	return localConnect()
+	return defaultListener.ConnectLocal()
 }
 
 func udpConnect(host string, port int) (*Connection, error) {
@@ -141,7 +145,7 @@ func udpConnect(host string, port int) (*Connection, error) {
 	// to block the receiving chan.
 	canWrite := make(chan bool, 1)
 	client := &Connection{
-		connectTime:  netTime,
+		connectTime:  Time(),
 		con:          c,
 		in:           s2c,
 		out:          c2s,
@@ -494,22 +498,59 @@ func writeUDP(c net.Conn, in <-chan msg, acks <-chan uint32, canWrite chan<- boo
 	}
 }
 
-var (
-	conuuids   = make(map[uuid.UUID]*Connection)
-	maxClients = 4
-)
+// Listener accepts the connections of one server. Every server of a process
+// needs its own Listener with a distinct port.
+type Listener struct {
+	port       int
+	maxClients int
+	conn       *net.UDPConn
+	requests   chan listenRequest
+	conns      map[uuid.UUID]*Connection
+
+	loopClient         *Connection
+	loopServer         *Connection
+	loopConnectPending bool
+}
+
+func NewListener(port int) *Listener {
+	return &Listener{
+		port:       port,
+		maxClients: 4,
+		requests:   make(chan listenRequest, 4),
+		conns:      make(map[uuid.UUID]*Connection),
+	}
+}
+
+// defaultListener is used by the package level functions.
+var defaultListener = NewListener(26000)
+
+// DefaultListener returns the listener of the process wide port.
+func DefaultListener() *Listener {
+	return defaultListener
+}
+
+func (l *Listener) Port() int {
+	return l.port
+}
+
+// SetPort changes the port, it takes effect with the next call to Listen.
+func (l *Listener) SetPort(p int) {
+	l.port = p
+}
 
 const (
 	// LocalAddress is a sentinel value only used for a loopback address
 	LocalAddress = "LOCAL"
 )
 
-func localConnect() (*Connection, error) {
-	loopConnectPending = true
+// ConnectLocal returns the client side of a loopback connection to the
+// server of l.
+func (l *Listener) ConnectLocal() (*Connection, error) {
+	l.loopConnectPending = true
 	c2s := make(chan msg, chanBufLength)
 	s2c := make(chan msg, chanBufLength)
-	loopClient = &Connection{
-		connectTime: netTime,
+	l.loopClient = &Connection{
+		connectTime: Time(),
 		addr:        "localhost",
 		in:          s2c,
 		out:         c2s,
@@ -517,26 +558,31 @@ func localConnect() (*Connection, error) {
 		uuid:        uuid.New(),
 	}
 	// this 'server' is the connection from the server to the client
-	loopServer = &Connection{
-		connectTime: netTime,
+	l.loopServer = &Connection{
+		connectTime: Time(),
 		addr:        LocalAddress,
 		in:          c2s,
 		out:         s2c,
 		canWrite:    true,
 		uuid:        uuid.New(),
+		listener:    l,
 	}
-	conuuids[loopServer.uuid] = loopServer
-	return loopClient, nil
+	l.conns[l.loopServer.uuid] = l.loopServer
+	return l.loopClient, nil
 }
 
 func CheckNewConnections() *Connection {
+	return defaultListener.CheckNewConnections()
+}
+
+// CheckNewConnections returns a new client connection or nil if there is
+// none.
+func (l *Listener) CheckNewConnections() *Connection {
 	SetTime()
-	// This needs to get logic for reading from the listenChan
-	// <-listenChan
 	select {
-	case req := <-listenChan:
+	case req := <-l.requests:
 		log.Printf("ListenRequest from %v", req.addr.IP)
-		for _, c := range conuuids {
+		for _, c := range l.conns {
 			if c.con != nil && c.con.RemoteAddr() == req.addr {
 				log.Printf("ListenRequest from %v already known", req.addr.IP)
 				if c.connectTime.Add(2 * time.Second).After(time.Now()) {
@@ -550,7 +596,7 @@ func CheckNewConnections() *Connection {
 				return nil
 			}
 		}
-		if len(conuuids) >= maxClients {
+		if len(l.conns) >= l.maxClients {
 			go req.conn.WriteToUDP([]byte(serverFullError), req.addr)
 			return nil
 		}
@@ -581,19 +627,20 @@ func CheckNewConnections() *Connection {
 		c2s := make(chan msg, chanBufLength)
 		canWrite := make(chan bool, 1)
 		client := &Connection{
-			connectTime:  netTime,
+			connectTime:  Time(),
 			con:          newConn,
 			in:           c2s,
 			out:          s2c,
 			canWriteChan: canWrite,
 			canWrite:     true,
 			uuid:         uuid.New(),
+			listener:     l,
 		}
 		acks := make(chan uint32, 1)
 		go readUDP(newConn, c2s, acks)
 		go writeUDP(newConn, s2c, acks, canWrite)
 
-		conuuids[client.uuid] = client
+		l.conns[client.uuid] = client
 		return client
 
 	default:
@@ -601,18 +648,18 @@ func CheckNewConnections() *Connection {
 	}
 
 	// loopback only
-	if !loopConnectPending {
+	if !l.loopConnectPending {
 		return nil
 	}
-	loopConnectPending = false
+	l.loopConnectPending = false
 	//Dangerous chan clear
-	for len(loopServer.in) > 0 {
-		<-loopServer.in
+	for len(l.loopServer.in) > 0 {
+		<-l.loopServer.in
 	}
-	for len(loopClient.in) > 0 {
-		<-loopClient.in
+	for len(l.loopClient.in) > 0 {
+		<-l.loopClient.in
 	}
-	return loopServer
+	return l.loopServer
 }
 
 func (c *Connection) Close() {
@@ -625,7 +672,9 @@ func (c *Connection) Close() {
 		// loop server/client
 		close(c.out)
 	}
-	delete(conuuids, c.uuid)
+	if c.listener != nil {
+		delete(c.listener.conns, c.uuid)
+	}
 	// TODO: see loop.Close
 }
 
@@ -718,28 +767,31 @@ func Shutdown() {
 	StopListen()
 }
 
-var (
-	listenConn *net.UDPConn
-	listenChan chan listenRequest
-)
-
-func init() {
-	listenChan = make(chan listenRequest, 4)
-}
-
 type listenRequest struct {
 	addr *net.UDPAddr
 	conn *net.UDPConn
 }
 
 func Listening() bool {
-	return listenConn != nil
+	return defaultListener.Listening()
 }
 
 func Listen(numMaxClients int) {
-	maxClients = numMaxClients
-	StopListen()
-	addr, err := net.ResolveUDPAddr("udp", ":"+strconv.Itoa(port))
+	defaultListener.Listen(numMaxClients)
+}
+
+func StopListen() {
+	defaultListener.StopListen()
+}
+
+func (l *Listener) Listening() bool {
+	return l.conn != nil
+}
+
+func (l *Listener) Listen(numMaxClients int) {
+	l.maxClients = numMaxClients
+	l.StopListen()
+	addr, err := net.ResolveUDPAddr("udp", ":"+strconv.Itoa(l.port))
 	if err != nil {
 		log.Printf("Listen could not create addr: %v", err)
 		return
@@ -749,18 +801,18 @@ func Listen(numMaxClients int) {
 		log.Printf("Listen could not create connection: %v", err)
 		return
 	}
-	listenConn = con
-	go listenToNewClients(listenConn, listenChan)
+	l.conn = con
+	go listenToNewClients(l.conn, l.requests)
 }
 
-func StopListen() {
-	if listenConn != nil {
-		listenConn.Close()
-		listenConn = nil
+func (l *Listener) StopListen() {
+	if l.conn != nil {
+		l.conn.Close()
+		l.conn = nil
 		// Just drain pending connection requests
 		for {
 			select {
-			case <-listenChan:
+			case <-l.requests:
 				log.Printf("draining listenChan")
 			default:
 				return
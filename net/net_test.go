@@ -340,3 +340,34 @@ func TestUDPWriteReliable(t *testing.T) {
 // Missing tests:
 // Long UDPWriteReliable with split message (needs message at least 32001 long)
 // Resend case if no ack for UDPWriteReliable (find good way to mock the timer)
+
+func TestListenersAreIndependent(t *testing.T) {
+	l1 := NewListener(26001)
+	l2 := NewListener(26002)
+	c1, err := l1.ConnectLocal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := l2.CheckNewConnections(); s != nil {
+		t.Fatalf("l2 got a connection of l1")
+	}
+	s1 := l1.CheckNewConnections()
+	if s1 == nil {
+		t.Fatalf("l1 got no connection")
+	}
+	if s := l1.CheckNewConnections(); s != nil {
+		t.Fatalf("l1 got a second connection")
+	}
+	c1.SendMessage([]byte{42})
+	got, err := s1.GetMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{1, 42}; !bytes.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	s1.Close()
+	if len(l1.conns) != 0 {
+		t.Errorf("l1 still has %d connections", len(l1.conns))
+	}
+}
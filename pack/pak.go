@@ -7,7 +7,11 @@ import (
 	"encoding/binary"
 	"errors"
 	"io"
+	"io/fs"
 	"os"
+	"sort"
+	"strings"
+	"time"
 )
 
 type header struct {
@@ -25,6 +29,7 @@ type entry struct {
 type Pack struct {
 	f     *os.File
 	files map[string]*qfile
+	order []string // names in directory order
 	name  string
 }
 
@@ -44,6 +49,64 @@ func (p *Pack) Open(name string) (*io.SectionReader, error) {
 	return io.NewSectionReader(p.f, q.offset, q.size), nil
 }
 
+type fileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (f *fileInfo) Name() string       { return f.name }
+func (f *fileInfo) Size() int64        { return f.size }
+func (f *fileInfo) ModTime() time.Time { return time.Time{} }
+func (f *fileInfo) IsDir() bool        { return f.dir }
+func (f *fileInfo) Sys() any           { return nil }
+func (f *fileInfo) Mode() fs.FileMode {
+	if f.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+// ReadDir returns the files and directories within dir sorted by name. Pak
+// files only store file names, a directory exists if a file name has it as
+// prefix. The root directory is "" or ".".
+func (p *Pack) ReadDir(dir string) ([]fs.FileInfo, error) {
+	prefix := strings.Trim(dir, "/")
+	if prefix == "." {
+		prefix = ""
+	}
+	if prefix != "" {
+		prefix += "/"
+	}
+	seen := make(map[string]bool)
+	var r []fs.FileInfo
+	for name, q := range p.files {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		rest := name[len(prefix):]
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			rest = rest[:i]
+			if !seen[rest] {
+				seen[rest] = true
+				r = append(r, &fileInfo{name: rest, dir: true})
+			}
+			continue
+		}
+		r = append(r, &fileInfo{name: rest, size: q.size})
+	}
+	if len(r) == 0 && prefix != "" {
+		return nil, os.ErrNotExist
+	}
+	sort.Slice(r, func(i, j int) bool { return r[i].Name() < r[j].Name() })
+	return r, nil
+}
+
+// Files returns the names of all files in the order of the pak directory.
+func (p *Pack) Files() []string {
+	return append([]string(nil), p.order...)
+}
+
 func (p *Pack) String() string {
 	return p.name
 }
@@ -92,6 +155,7 @@ func (p *Pack) init() error {
 			offset: int64(e.Offset),
 			size:   int64(e.Size),
 		}
+		p.order = append(p.order, name)
 	}
 	return nil
 }
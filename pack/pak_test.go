@@ -4,6 +4,7 @@ package pack
 
 import (
 	"io"
+	"strings"
 	"testing"
 )
 
@@ -48,3 +49,39 @@ func TestPak(t *testing.T) {
 	}
 
 }
+
+func TestPakReadDir(t *testing.T) {
+	p, err := NewPackReader(pakFile)
+	if err != nil {
+		t.Fatalf("could not open %s: %v", pakFile, err)
+	}
+	defer p.Close()
+	for _, tt := range []struct {
+		dir  string
+		want string
+	}{
+		{"", "doc1.txt doc2.txt doc3.txt doc4.txt testdir/"},
+		{"/", "doc1.txt doc2.txt doc3.txt doc4.txt testdir/"},
+		{"testdir", "doc1.txt doc2.txt doc3.txt doc4.txt"},
+		{"/testdir/", "doc1.txt doc2.txt doc3.txt doc4.txt"},
+	} {
+		fis, err := p.ReadDir(tt.dir)
+		if err != nil {
+			t.Fatalf("ReadDir(%q): %v", tt.dir, err)
+		}
+		var names []string
+		for _, fi := range fis {
+			n := fi.Name()
+			if fi.IsDir() {
+				n += "/"
+			}
+			names = append(names, n)
+		}
+		if got := strings.Join(names, " "); got != tt.want {
+			t.Errorf("ReadDir(%q) = %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+	if _, err := p.ReadDir("doc"); err == nil {
+		t.Errorf("ReadDir of a missing directory succeeded")
+	}
+}
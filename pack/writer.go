@@ -0,0 +1,150 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package pack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// MaxNameLength is the longest file name a pak can store, the name field
+// needs room for the terminating 0.
+const MaxNameLength = len(entry{}.Name) - 1
+
+var errClosed = errors.New("pack writer is closed")
+
+// Writer creates a pak file. The file data is written in the order the files
+// are created, the directory is written by Close.
+type Writer struct {
+	w       io.WriteSeeker
+	start   int64 // position of the header
+	offset  int64 // position of the next write relative to start
+	entries []entry
+	names   map[string]bool
+	current *fileWriter
+	closed  bool
+}
+
+// NewWriter returns a Writer writing a new pak file to w, starting at the
+// current position of w.
+func NewWriter(w io.WriteSeeker) (*Writer, error) {
+	start, err := w.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	// the header gets filled in by Close
+	if err := binary.Write(w, binary.LittleEndian, header{}); err != nil {
+		return nil, err
+	}
+	return &Writer{
+		w:      w,
+		start:  start,
+		offset: int64(binary.Size(header{})),
+		names:  make(map[string]bool),
+	}, nil
+}
+
+// CheckName returns an error if name can not be stored in a pak.
+func CheckName(name string) error {
+	switch {
+	case name == "":
+		return errors.New("empty file name")
+	case len(name) > MaxNameLength:
+		return fmt.Errorf("file name %q is longer than %d bytes", name, MaxNameLength)
+	case strings.ContainsRune(name, 0):
+		return fmt.Errorf("file name %q contains a 0 byte", name)
+	case strings.Contains(name, "\\"), path.IsAbs(name), path.Clean(name) != name,
+		name == "..", strings.HasPrefix(name, "../"):
+		return fmt.Errorf("file name %q is not a clean relative path", name)
+	}
+	return nil
+}
+
+type fileWriter struct {
+	p *Writer
+	e *entry
+}
+
+func (f *fileWriter) Write(b []byte) (int, error) {
+	if f.p.current != f {
+		return 0, errors.New("write to a finished pak file entry")
+	}
+	if int64(f.e.Size)+int64(len(b)) > int64(^uint32(0)>>1) {
+		return 0, errors.New("pak file entry too large")
+	}
+	n, err := f.p.w.Write(b)
+	f.e.Size += int32(n)
+	f.p.offset += int64(n)
+	return n, err
+}
+
+// Create adds a file with the given name to the pak. The returned writer is
+// valid until the next call to Create or Close.
+func (p *Writer) Create(name string) (io.Writer, error) {
+	if p.closed {
+		return nil, errClosed
+	}
+	if err := CheckName(name); err != nil {
+		return nil, err
+	}
+	if p.names[name] {
+		return nil, fmt.Errorf("file %q is already in the pak", name)
+	}
+	if p.offset > int64(^uint32(0)>>1) {
+		return nil, errors.New("pak file too large")
+	}
+	p.names[name] = true
+	var e entry
+	copy(e.Name[:], name)
+	e.Offset = int32(p.offset)
+	p.entries = append(p.entries, e)
+	p.current = &fileWriter{p: p, e: &p.entries[len(p.entries)-1]}
+	return p.current, nil
+}
+
+// Add copies r into the pak as a file with the given name.
+func (p *Writer) Add(name string, r io.Reader) error {
+	w, err := p.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// Close writes the directory and the header. It does not close the
+// underlying writer.
+func (p *Writer) Close() error {
+	if p.closed {
+		return errClosed
+	}
+	p.closed = true
+	p.current = nil
+	dirOffset := p.offset
+	var dir bytes.Buffer
+	if err := binary.Write(&dir, binary.LittleEndian, p.entries); err != nil {
+		return err
+	}
+	if _, err := p.w.Write(dir.Bytes()); err != nil {
+		return err
+	}
+	end := p.start + dirOffset + int64(dir.Len())
+	h := header{
+		Offset: int32(dirOffset),
+		Size:   int32(dir.Len()),
+	}
+	copy(h.ID[:], "PACK")
+	if _, err := p.w.Seek(p.start, io.SeekStart); err != nil {
+		return err
+	}
+	if err := binary.Write(p.w, binary.LittleEndian, h); err != nil {
+		return err
+	}
+	_, err := p.w.Seek(end, io.SeekStart)
+	return err
+}
@@ -0,0 +1,178 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package pack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriterRoundTrip(t *testing.T) {
+	const orig = "pak0.pak"
+	p, err := NewPackReader(orig)
+	if err != nil {
+		t.Fatalf("could not open %s: %v", orig, err)
+	}
+	defer p.Close()
+
+	name := filepath.Join(t.TempDir(), "copy.pak")
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewWriter(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range p.Files() {
+		r, err := p.Open(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Add(n, r); err != nil {
+			t.Fatalf("Add(%q): %v", n, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := os.ReadFile(orig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the original has garbage after the terminating 0 of the names
+	clearNamePadding(want)
+	if !bytes.Equal(got, want) {
+		t.Errorf("written pak differs from %s", orig)
+	}
+
+	c, err := NewPackReader(name)
+	if err != nil {
+		t.Fatalf("could not read written pak: %v", err)
+	}
+	defer c.Close()
+	for _, n := range p.Files() {
+		r1, _ := p.Open(n)
+		r2, err := c.Open(n)
+		if err != nil {
+			t.Fatalf("written pak has no %s", n)
+		}
+		b1, _ := io.ReadAll(r1)
+		b2, _ := io.ReadAll(r2)
+		if !bytes.Equal(b1, b2) {
+			t.Errorf("%s: got %q, want %q", n, b2, b1)
+		}
+	}
+}
+
+func clearNamePadding(b []byte) {
+	dir := int(binary.LittleEndian.Uint32(b[4:]))
+	size := int(binary.LittleEndian.Uint32(b[8:]))
+	for e := dir; e < dir+size; e += binary.Size(entry{}) {
+		name := b[e : e+len(entry{}.Name)]
+		clear(name[bytes.IndexByte(name, 0):])
+	}
+}
+
+func TestWriterDirectories(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "dirs.pak")
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w, err := NewWriter(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []string{"progs.dat", "maps/e1m1.bsp", "sound/weapons/r_exp3.wav"}
+	for _, n := range files {
+		if err := w.Add(n, strings.NewReader(n)); err != nil {
+			t.Fatalf("Add(%q): %v", n, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewPackReader(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if got := strings.Join(p.Files(), " "); got != strings.Join(files, " ") {
+		t.Errorf("Files = %v, want %v", got, files)
+	}
+	r, err := p.Open("sound/weapons/r_exp3.wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(r); string(b) != "sound/weapons/r_exp3.wav" {
+		t.Errorf("contents: %q", b)
+	}
+}
+
+func TestWriterNames(t *testing.T) {
+	w, err := NewWriter(&seekBuffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	long := strings.Repeat("a", MaxNameLength)
+	for _, tt := range []struct {
+		name string
+		ok   bool
+	}{
+		{long, true},
+		{long + "a", false},
+		{"", false},
+		{"/maps/e1m1.bsp", false},
+		{"maps\\e1m1.bsp", false},
+		{"../progs.dat", false},
+		{"maps/../progs.dat", false},
+		{"maps/e1m1.bsp", true},
+		{"maps/e1m1.bsp", false}, // duplicate
+	} {
+		_, err := w.Create(tt.name)
+		if (err == nil) != tt.ok {
+			t.Errorf("Create(%q) = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+// seekBuffer is an in memory io.WriteSeeker.
+type seekBuffer struct {
+	b   []byte
+	pos int64
+}
+
+func (s *seekBuffer) Write(p []byte) (int, error) {
+	if end := s.pos + int64(len(p)); end > int64(len(s.b)) {
+		s.b = append(s.b, make([]byte, end-int64(len(s.b)))...)
+	}
+	n := copy(s.b[s.pos:], p)
+	s.pos += int64(n)
+	return n, nil
+}
+
+func (s *seekBuffer) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += s.pos
+	case io.SeekEnd:
+		offset += int64(len(s.b))
+	}
+	s.pos = offset
+	return offset, nil
+}
@@ -2,6 +2,9 @@
 package palette
 
 import (
+	"image"
+	"image/color"
+
 	"goquake/texture"
 )
 
@@ -57,3 +60,63 @@ func (p *Palette) Convert(data []byte) []byte {
 	}
 	return nd
 }
+
+// Index returns the index of the opaque color of p closest to c. Colors
+// with an alpha below 128 get the first transparent color of p if there is
+// one.
+func (p *Palette) Index(c color.Color) byte {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if n.A < 128 {
+		for i := 0; i < 256; i++ {
+			if p[i*4+3] == 0 {
+				return byte(i)
+			}
+		}
+	}
+	best, bestDist := 0, -1
+	for i := 0; i < 256; i++ {
+		pixel := p[i*4 : i*4+4]
+		if pixel[3] == 0 {
+			continue
+		}
+		dr := int(pixel[0]) - int(n.R)
+		dg := int(pixel[1]) - int(n.G)
+		db := int(pixel[2]) - int(n.B)
+		d := dr*dr + dg*dg + db*db
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+			if d == 0 {
+				break
+			}
+		}
+	}
+	return byte(best)
+}
+
+// Quantize returns the palette indices of the pixels of img, row by row.
+func (p *Palette) Quantize(img image.Image) []byte {
+	b := img.Bounds()
+	data := make([]byte, 0, b.Dx()*b.Dy())
+	cache := make(map[color.NRGBA]byte)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			idx, ok := cache[c]
+			if !ok {
+				idx = p.Index(c)
+				cache[c] = idx
+			}
+			data = append(data, idx)
+		}
+	}
+	return data
+}
+
+// Image returns the w*h indexed pixels of data as image.
+func (p *Palette) Image(data []byte, w, h int) *image.NRGBA {
+	return &image.NRGBA{
+		Pix:    p.Convert(data[:w*h]),
+		Stride: w * 4,
+		Rect:   image.Rect(0, 0, w, h),
+	}
+}
@@ -0,0 +1,365 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package pmove
+
+import (
+	"fmt"
+
+	"goquake/bsp"
+	"goquake/math/vec"
+	"goquake/progs"
+
+	"github.com/chewxy/math32"
+)
+
+const stepSize = 18
+
+// ClipVelocity slides off of the impacting object.
+// It returns the blocked flags (1 = floor, 2 = step / wall) and clipped velocity
+func ClipVelocity(in, normal vec.Vec3, overbounce float32) (int, vec.Vec3) {
+	blocked := func() int {
+		switch {
+		case normal[2] > 0:
+			return 1 // floor
+		case normal[2] == 0:
+			return 2 // step
+		default:
+			return 0
+		}
+	}()
+
+	backoff := vec.Dot(in, normal) * overbounce
+
+	e := func(x float32) float32 {
+		const EPSILON = 0.1
+		if x > -EPSILON && x < EPSILON {
+			return 0
+		}
+		return x
+	}
+
+	out := vec.Vec3{
+		e(in[0] - normal[0]*backoff),
+		e(in[1] - normal[1]*backoff),
+		e(in[2] - normal[2]*backoff),
+	}
+
+	return blocked, out
+}
+
+// FlyMove is the basic solid body movement clip that slides along multiple planes
+// Returns the clipflags if the velocity was modified (hit something solid)
+// 1 = floor
+// 2 = wall / step
+// 4 = dead stop
+// If steptrace is not nil, the trace of any vertical wall hit will be stored
+func FlyMove(p *State, time float32, steptrace *bsp.Trace, w World) (int, error) {
+	const MAX_CLIP_PLANES = 5
+	planes := [MAX_CLIP_PLANES]vec.Vec3{}
+
+	numbumps := 4
+
+	blocked := 0
+	original_velocity := p.Velocity
+	primal_velocity := p.Velocity
+	numplanes := 0
+
+	time_left := time
+
+	for bumpcount := 0; bumpcount < numbumps; bumpcount++ {
+		if p.Velocity == (vec.Vec3{}) {
+			break
+		}
+
+		end := vec.Add(p.Origin, vec.Scale(time_left, p.Velocity))
+
+		t := w.Trace(p.Origin, p.Mins, p.Maxs, end, false)
+
+		if t.AllSolid {
+			// entity is trapped in another solid
+			p.Velocity = vec.Vec3{}
+			return 3, nil
+		}
+
+		if t.Fraction > 0 {
+			// actually covered some distance
+			p.Origin = t.EndPos
+			original_velocity = p.Velocity
+			numplanes = 0
+		}
+		if t.Fraction == 1 {
+			// moved the entire distance
+			break
+		}
+		if !t.EntPointer {
+			return 0, fmt.Errorf("FlyMove: !trace.ent")
+		}
+		if t.Plane.Normal[2] > 0.7 {
+			blocked |= 1 // floor
+			if w.Ground(t.EntNumber) {
+				p.Flags |= progs.FlagOnGround
+				p.GroundEntity = t.EntNumber
+			}
+		}
+		if t.Plane.Normal[2] == 0 {
+			blocked |= 2 // step
+			if steptrace != nil {
+				*steptrace = t // save for player extrafriction
+			}
+		}
+		if ok, err := w.Impact(p, t.EntNumber); err != nil {
+			return 0, err
+		} else if !ok {
+			// removed by the impact function
+			break
+		}
+		time_left -= time_left * t.Fraction
+
+		// cliped to another plane
+		if numplanes >= MAX_CLIP_PLANES {
+			// this shouldn't really happen
+			p.Velocity = vec.Vec3{}
+			return 3, nil
+		}
+
+		planes[numplanes] = t.Plane.Normal
+		numplanes++
+
+		// modify original_velocity so it parallels all of the clip planes
+		new_velocity := vec.Vec3{}
+		i := 0
+		for i = 0; i < numplanes; i++ {
+			j := 0
+			_, new_velocity = ClipVelocity(original_velocity, planes[i], 1)
+			for j = 0; j < numplanes; j++ {
+				if j != i {
+					if vec.Dot(new_velocity, planes[j]) < 0 {
+						break // not ok
+					}
+				}
+			}
+			if j == numplanes {
+				break
+			}
+		}
+
+		if i != numplanes { // go along this plane
+			p.Velocity = new_velocity
+		} else { // go along the crease
+			if numplanes != 2 {
+				p.Velocity = vec.Vec3{}
+				return 7, nil
+			}
+			dir := vec.Cross(planes[0], planes[1])
+			d := vec.Dot(dir, p.Velocity)
+			p.Velocity = vec.Scale(d, dir)
+		}
+
+		// if original velocity is against the original velocity, stop dead
+		// to avoid tiny occilations in sloping corners
+		if vec.Dot(p.Velocity, primal_velocity) <= 0 {
+			p.Velocity = vec.Vec3{}
+			return blocked, nil
+		}
+	}
+	return blocked, nil
+}
+
+// CheckWater updates the water level and type of p and returns whether
+// p is swimming.
+func CheckWater(p *State, w World) bool {
+	point := vec.Vec3{
+		p.Origin[0],
+		p.Origin[1],
+		p.Origin[2] + p.Mins[2] + 1,
+	}
+
+	p.WaterLevel = 0
+	p.WaterType = bsp.CONTENTS_EMPTY
+
+	cont := w.PointContents(point)
+	if cont <= bsp.CONTENTS_WATER {
+		p.WaterType = cont
+		p.WaterLevel = 1
+		point[2] = p.Origin[2] + (p.Mins[2]+p.Maxs[2])*0.5
+		cont = w.PointContents(point)
+		if cont <= bsp.CONTENTS_WATER {
+			p.WaterLevel = 2
+			point[2] = p.Origin[2] + p.ViewOfs[2]
+			cont = w.PointContents(point)
+			if cont <= bsp.CONTENTS_WATER {
+				p.WaterLevel = 3
+			}
+		}
+	}
+
+	return p.WaterLevel > 1
+}
+
+// Player has come to a dead stop, possibly due to the problem with limited
+// float precision at some angle joins in the BSP hull.
+//
+// Try fixing by pushing one pixel in each direction.
+//
+// This is a hack, but in the interest of good gameplay...
+func tryUnstick(p *State, oldvel vec.Vec3, w World) (int, error) {
+	oldorg := p.Origin
+
+	for _, dir := range []vec.Vec3{
+		// try pushing a little in an axial direction
+		{2, 0, 0},
+		{0, 2, 0},
+		{-2, 0, 0},
+		{0, -2, 0},
+		{2, 2, 0},
+		{-2, 2, 0},
+		{2, -2, 0},
+		{-2, -2, 0},
+	} {
+		if _, err := w.Push(p, dir); err != nil {
+			return 0, err
+		}
+		// retry the original move
+		p.Velocity = oldvel
+		p.Velocity[2] = 0 // TODO: why?
+		steptrace := bsp.Trace{}
+		clip, err := FlyMove(p, 0.1, &steptrace, w)
+		if err != nil {
+			return 0, err
+		}
+		if math32.Abs(oldorg[1]-p.Origin[1]) > 4 ||
+			math32.Abs(oldorg[0]-p.Origin[0]) > 4 {
+			return clip, nil
+		}
+		// go back to the original pos and try again
+		p.Origin = oldorg
+	}
+	p.Velocity = vec.Vec3{}
+	// still not moving
+	return 7, nil
+}
+
+func wallFriction(p *State, planeNormal vec.Vec3) {
+	const deg = math32.Pi * 2 / 360
+
+	sp, cp := math32.Sincos(p.VAngle[0] * deg) // PITCH
+	sy, cy := math32.Sincos(p.VAngle[1] * deg) // YAW
+	forward := vec.Vec3{cp * cy, cp * sy, -sp}
+	d := vec.Dot(planeNormal, forward)
+
+	d += 0.5
+	if d >= 0 {
+		return
+	}
+
+	// cut the tangential velocity
+	v := p.Velocity
+	i := vec.Dot(planeNormal, v)
+	into := vec.Scale(i, planeNormal)
+	side := vec.Sub(v, into)
+	p.Velocity[0] = side[0] * (1 + d)
+	p.Velocity[1] = side[1] * (1 + d)
+}
+
+// WalkMove moves a player by its velocity and tries to step up stairs.
+func WalkMove(p *State, par *Params, w World) error {
+	// do a regular slide move unless it looks like you ran into a step
+	oldOnGround := p.onGround()
+	p.Flags &^= progs.FlagOnGround
+
+	oldOrigin := p.Origin
+	oldVelocity := p.Velocity
+
+	time := par.FrameTime
+	steptrace := bsp.Trace{}
+	clip, err := FlyMove(p, time, &steptrace, w)
+	if err != nil {
+		return err
+	}
+
+	if (clip & 2) == 0 {
+		// move didn't block on a step
+		return nil
+	}
+
+	if !oldOnGround && p.WaterLevel == 0 {
+		// don't stair up while jumping
+		return nil
+	}
+
+	if p.MoveType != progs.MoveTypeWalk {
+		// gibbed by a trigger
+		return nil
+	}
+
+	if par.NoStep {
+		return nil
+	}
+
+	if p.Flags&progs.FlagWaterJump != 0 {
+		return nil
+	}
+
+	noStepOrigin := p.Origin
+	noStepVelocity := p.Velocity
+
+	// try moving up and forward to go up a step
+
+	// back to start pos
+	p.Origin = oldOrigin
+	upMove := vec.Vec3{0, 0, stepSize}
+	downMove := vec.Vec3{0, 0, -stepSize + oldVelocity[2]*time}
+
+	// move up
+	if _, err := w.Push(p, upMove); err != nil { // FIXME: don't link?
+		return err
+	}
+
+	// move forward
+	p.Velocity = oldVelocity
+	p.Velocity[2] = 0
+	clip, err = FlyMove(p, time, &steptrace, w)
+	if err != nil {
+		return err
+	}
+
+	// check for stuckness, possibly due to the limited precision of floats
+	// in the clipping hulls
+	if clip != 0 {
+		if math32.Abs(oldOrigin[1]-p.Origin[1]) < 0.03125 &&
+			math32.Abs(oldOrigin[0]-p.Origin[0]) < 0.03125 {
+			// stepping up didn't make any progress
+			var err error
+			clip, err = tryUnstick(p, oldVelocity, w)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// extra friction based on view angle
+	if clip&2 != 0 {
+		wallFriction(p, steptrace.Plane.Normal)
+	}
+
+	// move down
+	downTrace, err := w.Push(p, downMove) // FIXME: don't link?
+	if err != nil {
+		return err
+	}
+
+	if downTrace.Plane.Normal[2] > 0.7 {
+		if p.Solid == solidBSP {
+			p.Flags |= progs.FlagOnGround
+			p.GroundEntity = downTrace.EntNumber
+		}
+		return nil
+	}
+
+	// if the push down didn't end up on good ground, use the move without
+	// the step up.  This happens near wall / slope combinations, and can
+	// cause the player to hop up higher on a slope too steep to climb
+	p.Origin = noStepOrigin
+	p.Velocity = noStepVelocity
+	return nil
+}
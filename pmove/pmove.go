@@ -0,0 +1,386 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+// Package pmove holds the player movement code. It is used by the server to
+// run the physics of the clients and by the client to predict the movement of
+// the local player.
+package pmove
+
+import (
+	"goquake/bsp"
+	"goquake/cvars"
+	"goquake/math/vec"
+	"goquake/progs"
+	"goquake/protos"
+
+	"github.com/chewxy/math32"
+)
+
+// solidBSP matches the SOLID_BSP of the server
+const solidBSP = 4
+
+// State is the part of a player entity the movement works on.
+type State struct {
+	Origin       vec.Vec3
+	Velocity     vec.Vec3
+	Angles       vec.Vec3
+	VAngle       vec.Vec3
+	PunchAngle   vec.Vec3
+	Mins         vec.Vec3
+	Maxs         vec.Vec3
+	ViewOfs      vec.Vec3
+	MoveDir      vec.Vec3
+	MoveType     int
+	Solid        int
+	Flags        int // progs.Flag*
+	GroundEntity int
+	WaterLevel   int
+	WaterType    int
+	TeleportTime float32
+	Health       float32
+	FixAngle     bool
+}
+
+// Cmd holds the intended movement of a player.
+type Cmd struct {
+	ForwardMove float32
+	SideMove    float32
+	UpMove      float32
+}
+
+// Params holds the values the server gets from its cvars.
+type Params struct {
+	FrameTime    float32
+	Accelerate   float32
+	Friction     float32
+	EdgeFriction float32
+	StopSpeed    float32
+	MaxSpeed     float32
+	Gravity      float32
+	NoStep       bool
+	AltNoClip    bool
+}
+
+// ServerParams returns the Params as configured by the sv_* cvars.
+func ServerParams(frameTime float32) *Params {
+	return &Params{
+		FrameTime:    frameTime,
+		Accelerate:   cvars.ServerAccelerate.Value(),
+		Friction:     cvars.ServerFriction.Value(),
+		EdgeFriction: cvars.ServerEdgeFriction.Value(),
+		StopSpeed:    cvars.ServerStopSpeed.Value(),
+		MaxSpeed:     cvars.ServerMaxSpeed.Value(),
+		Gravity:      cvars.ServerGravity.Value(),
+		NoStep:       cvars.ServerNoStep.Bool(),
+		AltNoClip:    cvars.ServerAltNoClip.Bool(),
+	}
+}
+
+// Proto returns the Params without the FrameTime as they get sent to the
+// clients for the prediction.
+func (p *Params) Proto() *protos.MoveParams {
+	return protos.MoveParams_builder{
+		Accelerate:   p.Accelerate,
+		Friction:     p.Friction,
+		EdgeFriction: p.EdgeFriction,
+		StopSpeed:    p.StopSpeed,
+		MaxSpeed:     p.MaxSpeed,
+		Gravity:      p.Gravity,
+		NoStep:       p.NoStep,
+		AltNoclip:    p.AltNoClip,
+	}.Build()
+}
+
+// ProtoParams returns the Params the server sent in mp.
+func ProtoParams(frameTime float32, mp *protos.MoveParams) *Params {
+	return &Params{
+		FrameTime:    frameTime,
+		Accelerate:   mp.GetAccelerate(),
+		Friction:     mp.GetFriction(),
+		EdgeFriction: mp.GetEdgeFriction(),
+		StopSpeed:    mp.GetStopSpeed(),
+		MaxSpeed:     mp.GetMaxSpeed(),
+		Gravity:      mp.GetGravity(),
+		NoStep:       mp.GetNoStep(),
+		AltNoClip:    mp.GetAltNoclip(),
+	}
+}
+
+// World is the environment a player moves in.
+type World interface {
+	// Trace moves a box from start to end. If noMonsters is set only bsp
+	// models block the move.
+	Trace(start, mins, maxs, end vec.Vec3, noMonsters bool) bsp.Trace
+	PointContents(p vec.Vec3) int
+	// Impact is called if the move of p got blocked by ent. It returns false
+	// if p got removed.
+	Impact(p *State, ent int) (bool, error)
+	// Push moves p by push without changing its velocity.
+	Push(p *State, push vec.Vec3) (bsp.Trace, error)
+	// Ground returns whether p can stand on ent.
+	Ground(ent int) bool
+}
+
+func (p *State) onGround() bool {
+	return p.Flags&progs.FlagOnGround != 0
+}
+
+func accelerate(p *State, wishspeed float32, wishdir vec.Vec3, par *Params) {
+	currentspeed := vec.Dot(p.Velocity, wishdir)
+	addspeed := wishspeed - currentspeed
+	if addspeed <= 0 {
+		return
+	}
+	accelspeed := par.Accelerate * par.FrameTime * wishspeed
+	if accelspeed > addspeed {
+		accelspeed = addspeed
+	}
+	p.Velocity = vec.Add(p.Velocity, vec.Scale(accelspeed, wishdir))
+}
+
+func airAccelerate(p *State, wishspeed float32, wishveloc vec.Vec3, par *Params) {
+	wishspd := wishveloc.Length()
+	if wishspd <= 0 {
+		return
+	}
+	wishveloc = vec.Scale(1/wishspd, wishveloc)
+	if wishspd > 30 {
+		wishspd = 30
+	}
+	addspeed := wishspd - vec.Dot(p.Velocity, wishveloc)
+	if addspeed <= 0 {
+		return
+	}
+	accelspeed := par.Accelerate * par.FrameTime * wishspeed
+	if accelspeed > addspeed {
+		accelspeed = addspeed
+	}
+	p.Velocity = vec.Add(p.Velocity, vec.Scale(accelspeed, wishveloc))
+}
+
+func noclipMove(p *State, cmd *Cmd, par *Params) {
+	forward, right, _ := vec.AngleVectors(p.VAngle)
+
+	velocity := vec.Vec3{
+		forward[0]*cmd.ForwardMove + right[0]*cmd.SideMove,
+		forward[1]*cmd.ForwardMove + right[1]*cmd.SideMove,
+		forward[2]*cmd.ForwardMove + right[2]*cmd.SideMove,
+	}
+	// doubled to match running speed
+	velocity[2] += cmd.UpMove * 2
+
+	if velocity.Length() > par.MaxSpeed {
+		velocity = vec.Scale(par.MaxSpeed, velocity.Normalize())
+	}
+	p.Velocity = velocity
+}
+
+func waterMove(p *State, cmd *Cmd, par *Params) {
+	// user intentions
+	forward, right, _ := vec.AngleVectors(p.VAngle)
+
+	wishvel := vec.Vec3{
+		forward[0]*cmd.ForwardMove + right[0]*cmd.SideMove,
+		forward[1]*cmd.ForwardMove + right[1]*cmd.SideMove,
+		forward[2]*cmd.ForwardMove + right[2]*cmd.SideMove,
+	}
+
+	if cmd.ForwardMove == 0 && cmd.SideMove == 0 && cmd.UpMove == 0 {
+		// drift towards bottom
+		wishvel[2] -= 60
+	} else {
+		wishvel[2] += cmd.UpMove
+	}
+
+	wishspeed := wishvel.Length()
+	if wishspeed > par.MaxSpeed {
+		wishvel = vec.Scale(par.MaxSpeed/wishspeed, wishvel)
+		wishspeed = par.MaxSpeed
+	}
+	wishspeed *= 0.7
+
+	// water friction
+	velocity := p.Velocity
+	speed := velocity.Length()
+	newspeed := float32(0)
+	if speed != 0 {
+		newspeed = speed - par.FrameTime*speed*par.Friction
+		if newspeed < 0 {
+			newspeed = 0
+		}
+		velocity = vec.Scale(newspeed/speed, velocity)
+	}
+	// water acceleration
+	if wishspeed == 0 {
+		return
+	}
+
+	addspeed := wishspeed - newspeed
+	if addspeed <= 0 {
+		return
+	}
+
+	wishvel = wishvel.Normalize()
+	accelspeed := par.Accelerate * wishspeed * par.FrameTime
+	if accelspeed > addspeed {
+		accelspeed = addspeed
+	}
+	p.Velocity = vec.Add(velocity, vec.Scale(accelspeed, wishvel))
+}
+
+func userFriction(p *State, par *Params, w World) {
+	velocity := p.Velocity
+	speed2 := velocity[0]*velocity[0] + velocity[1]*velocity[1]
+	if speed2 == 0 {
+		return
+	}
+	speed := math32.Sqrt(speed2)
+
+	// if the leading edge is over a dropoff, increase friction
+	start := vec.Vec3{
+		p.Origin[0] + velocity[0]/speed*16,
+		p.Origin[1] + velocity[1]/speed*16,
+		p.Origin[2] + p.Mins[2],
+	}
+	stop := start
+	stop[2] -= 34
+
+	t := w.Trace(start, vec.Vec3{}, vec.Vec3{}, stop, true)
+
+	friction := par.Friction
+	if t.Fraction == 1.0 {
+		friction *= par.EdgeFriction
+	}
+
+	control := func() float32 {
+		if speed < par.StopSpeed {
+			return par.StopSpeed
+		}
+		return speed
+	}()
+	newspeed := speed - par.FrameTime*control*friction
+
+	if newspeed <= 0 {
+		p.Velocity = vec.Vec3{}
+		return
+	}
+	newspeed /= speed
+	p.Velocity = vec.Scale(newspeed, velocity)
+}
+
+func airMove(p *State, cmd *Cmd, time float32, par *Params, w World) {
+	forward, right, _ := vec.AngleVectors(p.Angles)
+	fmove := cmd.ForwardMove
+	smove := cmd.SideMove
+
+	// hack to not let you back into teleporter
+	if time < p.TeleportTime && fmove < 0 {
+		fmove = 0
+	}
+
+	wishvel := vec.Vec3{
+		forward[0]*fmove + right[0]*smove,
+		forward[1]*fmove + right[1]*smove,
+		0,
+	}
+
+	if p.MoveType != progs.MoveTypeWalk {
+		wishvel[2] = cmd.UpMove
+	}
+
+	wishspeed := wishvel.Length()
+	wishdir := func() vec.Vec3 {
+		if wishspeed != 0 {
+			return vec.Scale(1/wishspeed, wishvel)
+		}
+		return wishvel
+	}()
+
+	if wishspeed > par.MaxSpeed {
+		wishvel = vec.Scale(par.MaxSpeed/wishspeed, wishvel)
+		wishspeed = par.MaxSpeed
+	}
+
+	if p.MoveType == progs.MoveTypeNoClip {
+		p.Velocity = wishvel
+	} else if p.onGround() {
+		userFriction(p, par, w)
+		accelerate(p, wishspeed, wishdir, par)
+	} else {
+		// not on ground, so little effect on velocity
+		airAccelerate(p, wishspeed, wishvel, par)
+	}
+}
+
+func dropPunchAngle(p *State, frametime float32) {
+	len := p.PunchAngle.Length()
+	if len == 0 {
+		len = 1
+	}
+	len2 := 1 - (10 * frametime / len)
+	if len2 < 0 {
+		len2 = 0
+	}
+	p.PunchAngle = vec.Scale(len2, p.PunchAngle)
+}
+
+func waterJump(p *State, time float32) {
+	if time > p.TeleportTime || p.WaterLevel == 0 {
+		p.Flags &^= progs.FlagWaterJump
+		p.TeleportTime = 0
+	}
+	p.Velocity[0] = p.MoveDir[0]
+	p.Velocity[1] = p.MoveDir[1]
+}
+
+// Think applies the intended movement of cmd to the velocity of p.
+// The move fields specify an intended velocity in pix/sec,
+// the angle fields specify an exact angular motion in degrees.
+func Think(p *State, cmd *Cmd, time float32, par *Params, w World) {
+	if p.MoveType == progs.MoveTypeNone {
+		return
+	}
+	dropPunchAngle(p, par.FrameTime)
+	if p.Health <= 0 {
+		// if dead, behave differently
+		return
+	}
+
+	// show 1/3 the pitch angle and all the roll angle
+	vAngle := vec.Add(p.VAngle, p.PunchAngle)
+	p.Angles[2] = cvars.CalcRoll(p.Angles, p.Velocity) * 4 // ROLL
+	if !p.FixAngle {
+		p.Angles[0] = -vAngle[0] / 3 // PITCH
+		p.Angles[1] = vAngle[1]      // YAW
+	}
+
+	if p.Flags&progs.FlagWaterJump != 0 {
+		waterJump(p, time)
+		return
+	}
+	// walk
+	if p.MoveType == progs.MoveTypeNoClip && par.AltNoClip {
+		noclipMove(p, cmd, par)
+	} else if p.WaterLevel >= 2 && p.MoveType != progs.MoveTypeNoClip {
+		waterMove(p, cmd, par)
+	} else {
+		airMove(p, cmd, time, par, w)
+	}
+}
+
+// Move runs the physics of a player for one frame as the server does without
+// the involvement of QuakeC.
+func Move(p *State, par *Params, w World) error {
+	switch p.MoveType {
+	case progs.MoveTypeWalk:
+		if !CheckWater(p, w) && p.Flags&progs.FlagWaterJump == 0 {
+			p.Velocity[2] -= par.Gravity * par.FrameTime
+		}
+		return WalkMove(p, par, w)
+	case progs.MoveTypeFly:
+		_, err := FlyMove(p, par.FrameTime, nil, w)
+		return err
+	case progs.MoveTypeNoClip:
+		p.Origin = vec.Add(p.Origin, vec.Scale(par.FrameTime, p.Velocity))
+	}
+	return nil
+}
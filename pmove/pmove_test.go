@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package pmove
+
+import (
+	"testing"
+
+	"goquake/bsp"
+	"goquake/math/vec"
+	"goquake/progs"
+)
+
+// floorWorld is an empty world with a solid floor at z = 0.
+type floorWorld struct{}
+
+func (floorWorld) Trace(start, mins, maxs, end vec.Vec3, noMonsters bool) bsp.Trace {
+	t := bsp.Trace{Fraction: 1, EndPos: end}
+	s := start[2] + mins[2]
+	e := end[2] + mins[2]
+	if e >= 0 || s < 0 {
+		return t
+	}
+	t.Fraction = s / (s - e)
+	t.EndPos = vec.Add(start, vec.Scale(t.Fraction, vec.Sub(end, start)))
+	t.Plane.Normal = vec.Vec3{0, 0, 1}
+	t.EntPointer = true
+	return t
+}
+
+func (floorWorld) PointContents(p vec.Vec3) int {
+	if p[2] < 0 {
+		return bsp.CONTENTS_SOLID
+	}
+	return bsp.CONTENTS_EMPTY
+}
+
+func (floorWorld) Impact(p *State, ent int) (bool, error) { return true, nil }
+
+func (w floorWorld) Push(p *State, push vec.Vec3) (bsp.Trace, error) {
+	t := w.Trace(p.Origin, p.Mins, p.Maxs, vec.Add(p.Origin, push), false)
+	p.Origin = t.EndPos
+	return t, nil
+}
+
+func (floorWorld) Ground(ent int) bool { return true }
+
+func testPlayer() *State {
+	return &State{
+		Origin:   vec.Vec3{0, 0, 24},
+		Mins:     vec.Vec3{-16, -16, -24},
+		Maxs:     vec.Vec3{16, 16, 32},
+		ViewOfs:  vec.Vec3{0, 0, 22},
+		MoveType: progs.MoveTypeWalk,
+		Health:   100,
+	}
+}
+
+func testParams() *Params {
+	return &Params{
+		FrameTime:    0.05,
+		Accelerate:   10,
+		Friction:     4,
+		EdgeFriction: 2,
+		StopSpeed:    100,
+		MaxSpeed:     320,
+		Gravity:      800,
+	}
+}
+
+func TestClipVelocity(t *testing.T) {
+	blocked, v := ClipVelocity(vec.Vec3{100, 0, -100}, vec.Vec3{0, 0, 1}, 1)
+	if blocked != 1 {
+		t.Errorf("blocked = %d, want 1", blocked)
+	}
+	if v != (vec.Vec3{100, 0, 0}) {
+		t.Errorf("velocity = %v, want [100 0 0]", v)
+	}
+}
+
+func TestFallToFloor(t *testing.T) {
+	p := testPlayer()
+	p.Origin[2] = 100
+	par := testParams()
+	w := floorWorld{}
+	for i := 0; i < 40; i++ {
+		if err := Move(p, par, w); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if p.Flags&progs.FlagOnGround == 0 {
+		t.Errorf("not on ground, origin %v", p.Origin)
+	}
+	if p.Origin[2] < 23.9 || p.Origin[2] > 24.1 {
+		t.Errorf("origin z = %v, want 24", p.Origin[2])
+	}
+}
+
+func TestWalkForward(t *testing.T) {
+	p := testPlayer()
+	p.Flags |= progs.FlagOnGround
+	par := testParams()
+	w := floorWorld{}
+	cmd := &Cmd{ForwardMove: 200}
+	for i := 0; i < 20; i++ {
+		Think(p, cmd, 0, par, w)
+		if err := Move(p, par, w); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if p.Velocity[0] < 199 || p.Velocity[0] > 201 {
+		t.Errorf("velocity = %v, want 200 along x", p.Velocity)
+	}
+	if p.Origin[0] <= 0 || p.Origin[1] != 0 {
+		t.Errorf("origin = %v, want movement along x", p.Origin)
+	}
+}
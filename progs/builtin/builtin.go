@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+// Package builtin lists the builtin functions the server provides to
+// progs.dat.
+package builtin
+
+// names holds the builtins implemented by the server VM, indexed by their
+// number. Numbers without name are not implemented.
+var names = [...]string{
+	1:  "makevectors",
+	2:  "setorigin",
+	3:  "setmodel",
+	4:  "setsize",
+	6:  "break",
+	7:  "random",
+	8:  "sound",
+	9:  "normalize",
+	10: "error",
+	11: "objerror",
+	12: "vlen",
+	13: "vectoyaw",
+	14: "spawn",
+	15: "remove",
+	16: "traceline",
+	17: "checkclient",
+	18: "find",
+	19: "precache_sound",
+	20: "precache_model",
+	21: "stuffcmd",
+	22: "findradius",
+	23: "bprint",
+	24: "sprint",
+	25: "dprint",
+	26: "ftos",
+	27: "vtos",
+	28: "coredump",
+	29: "traceon",
+	30: "traceoff",
+	31: "eprint",
+	32: "walkmove",
+	34: "droptofloor",
+	35: "lightstyle",
+	36: "rint",
+	37: "floor",
+	38: "ceil",
+	40: "checkbottom",
+	41: "pointcontents",
+	43: "fabs",
+	44: "aim",
+	45: "cvar",
+	46: "localcmd",
+	47: "nextent",
+	48: "particle",
+	49: "ChangeYaw",
+	51: "vectoangles",
+	52: "WriteByte",
+	53: "WriteChar",
+	54: "WriteShort",
+	55: "WriteLong",
+	56: "WriteCoord",
+	57: "WriteAngle",
+	58: "WriteString",
+	59: "WriteEntity",
+	67: "movetogoal",
+	68: "precache_file",
+	69: "makestatic",
+	70: "changelevel",
+	72: "cvar_set",
+	73: "centerprint",
+	74: "ambientsound",
+	75: "precache_model2",
+	76: "precache_sound2",
+	77: "precache_file2",
+	78: "setspawnparms",
+	79: "finaleFinished",
+	90: "centerprint",
+	91: "bprint",
+	92: "sprint",
+	93: "csqcevent",
+}
+
+// Count is the number of builtin slots of the server VM.
+const Count = len(names)
+
+// Implemented returns whether the server provides the builtin num.
+func Implemented(num int) bool {
+	return Name(num) != ""
+}
+
+// Name returns the name of the builtin num or "" if it is not implemented.
+func Name(num int) string {
+	if num <= 0 || num >= len(names) {
+		return ""
+	}
+	return names[num]
+}
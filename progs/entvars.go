@@ -3,48 +3,171 @@
 package progs
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"strings"
 	"unsafe"
 )
 
+// ErrBadField is returned by the checked accessors if a progs tries to
+// access memory outside of the entity fields.
+var ErrBadField = errors.New("bad entity field access")
+
 // TODO: rename to Vars?
 type EntityVars struct {
-	g_entvars    unsafe.Pointer
-	progsdat     *LoadedProg
-	virtmem      []int32
+	progsdat *LoadedProg
+	// entvars point into chunks of entity memory which never move so that
+	// the result of Get stays valid when more edicts get allocated
 	entvars      [][]int32
 	entityFields int
 	maxEdicts    int
+	// fields marks all offsets which are covered by a FieldDef
+	fields []bool
+	// Unchecked skips the validation of entity numbers and field offsets
+	// against the FieldDefs. Go still panics on accesses outside of the
+	// entity memory so this is only meant for trusted progs.
+	Unchecked bool
 }
 
 // TODO: change to (*LoadedProg) AllocVars(numEdicts int) *Vars
 func AllocEntvars(numEdicts int, entityfields int, pg *LoadedProg) *EntityVars {
 	ev := &EntityVars{}
 	ev.entityFields = entityfields
-	ev.maxEdicts = numEdicts
-	ev.virtmem = make([]int32, ev.maxEdicts*ev.entityFields)
-	ev.g_entvars = unsafe.Pointer(&ev.virtmem[0])
-	ev.entvars = make([][]int32, ev.maxEdicts)
-	for i := 0; i < ev.maxEdicts; i++ {
-		ev.entvars[i] = ev.virtmem[i*ev.entityFields : (i+1)*ev.entityFields]
-	}
+	ev.Grow(numEdicts)
 	ev.progsdat = pg
+	ev.fields = make([]bool, ev.entityFields)
+	for _, d := range pg.FieldDefs {
+		n := 1
+		if d.Type&^(1<<15) == EV_Vector {
+			n = 3
+		}
+		for i := int(d.Offset); i < int(d.Offset)+n && i < ev.entityFields; i++ {
+			ev.fields[i] = true
+		}
+	}
 	return ev
 }
 
-func (e *EntityVars) Address(idx, off int32) int32 {
-	return idx*int32(e.entityFields)*4 + off*4
+// Grow extends the entity memory to numEdicts edicts.
+func (e *EntityVars) Grow(numEdicts int) {
+	if numEdicts <= e.maxEdicts {
+		return
+	}
+	mem := make([]int32, (numEdicts-e.maxEdicts)*e.entityFields)
+	for i := 0; i < numEdicts-e.maxEdicts; i++ {
+		e.entvars = append(e.entvars, mem[i*e.entityFields:(i+1)*e.entityFields])
+	}
+	e.maxEdicts = numEdicts
+}
+
+// Len returns the number of allocated edicts.
+func (e *EntityVars) Len() int {
+	return e.maxEdicts
+}
+
+// checkField validates the n values starting at field offset off of entity idx.
+func (e *EntityVars) checkField(idx, off int32, n int) error {
+	if idx < 0 || int(idx) >= e.maxEdicts {
+		return fmt.Errorf("%w: entity %d out of range", ErrBadField, idx)
+	}
+	if off < 0 || int(off)+n > e.entityFields {
+		return fmt.Errorf("%w: field offset %d out of range", ErrBadField, off)
+	}
+	for i := int(off); i < int(off)+n; i++ {
+		if !e.fields[i] {
+			return fmt.Errorf("%w: no field at offset %d", ErrBadField, i)
+		}
+	}
+	return nil
+}
+
+// checkPointer validates a pointer created by Address to n values and returns
+// the index into the entity memory.
+func (e *EntityVars) checkPointer(ptr int32, n int) (int, error) {
+	if ptr < 0 || ptr%4 != 0 || int(ptr/4)+n > e.maxEdicts*e.entityFields {
+		return 0, fmt.Errorf("%w: pointer %d out of range", ErrBadField, ptr)
+	}
+	i := int(ptr / 4)
+	if err := e.checkField(int32(i/e.entityFields), int32(i%e.entityFields), n); err != nil {
+		return 0, err
+	}
+	return i, nil
+}
+
+// Address returns a pointer to the field offset off of entity idx as used by
+// the STOREP opcodes.
+func (e *EntityVars) Address(idx, off int32) (int32, error) {
+	if !e.Unchecked {
+		if err := e.checkField(idx, off, 1); err != nil {
+			return 0, err
+		}
+	}
+	return idx*int32(e.entityFields)*4 + off*4, nil
+}
+
+// Load returns the field offset off of entity idx.
+func (e *EntityVars) Load(idx, off int32) (int32, error) {
+	if !e.Unchecked {
+		if err := e.checkField(idx, off, 1); err != nil {
+			return 0, err
+		}
+	}
+	return e.entvars[idx][off], nil
+}
+
+// LoadVector returns the vector field at offset off of entity idx.
+func (e *EntityVars) LoadVector(idx, off int32) ([3]float32, error) {
+	if !e.Unchecked {
+		if err := e.checkField(idx, off, 3); err != nil {
+			return [3]float32{}, err
+		}
+	}
+	v := e.entvars[idx][off : off+3]
+	return [3]float32{
+		math.Float32frombits(uint32(v[0])),
+		math.Float32frombits(uint32(v[1])),
+		math.Float32frombits(uint32(v[2])),
+	}, nil
+}
+
+// Store writes value to the field ptr points to.
+func (e *EntityVars) Store(ptr, value int32) error {
+	i := int(ptr / 4)
+	if !e.Unchecked {
+		var err error
+		if i, err = e.checkPointer(ptr, 1); err != nil {
+			return err
+		}
+	}
+	e.entvars[i/e.entityFields][i%e.entityFields] = value
+	return nil
+}
+
+// StoreVector writes value to the vector field ptr points to.
+func (e *EntityVars) StoreVector(ptr int32, value [3]float32) error {
+	i := int(ptr / 4)
+	if !e.Unchecked {
+		var err error
+		if i, err = e.checkPointer(ptr, 3); err != nil {
+			return err
+		}
+	}
+	o := i % e.entityFields
+	v := e.entvars[i/e.entityFields][o : o+3]
+	v[0] = int32(math.Float32bits(value[0]))
+	v[1] = int32(math.Float32bits(value[1]))
+	v[2] = int32(math.Float32bits(value[2]))
+	return nil
 }
 
 func (e *EntityVars) Free() {
 	if e == nil {
 		return
 	}
-	e.g_entvars = nil
 	e.entvars = nil
-	e.virtmem = nil
+	e.maxEdicts = 0
 }
 
 func (e *EntityVars) Clear(idx int) {
@@ -71,11 +194,14 @@ func (e *EntityVars) Sprint(idx int, d Def) string {
 		}
 		return s
 	case EV_Float:
-		v := *(*float32)(unsafe.Pointer(vp))
+		v := math.Float32frombits(uint32(*vp))
 		return fmt.Sprintf("%5.1f", v)
 	case EV_Vector:
-		v := *(*[3]float32)(unsafe.Pointer(vp))
-		return fmt.Sprintf("%5.1f %5.1f %5.1f", v[0], v[1], v[2])
+		v := e.entvars[idx][d.Offset : d.Offset+3]
+		return fmt.Sprintf("%5.1f %5.1f %5.1f",
+			math.Float32frombits(uint32(v[0])),
+			math.Float32frombits(uint32(v[1])),
+			math.Float32frombits(uint32(v[2])))
 	case EV_Entity:
 		v := *vp
 		return fmt.Sprintf("entity %d", v)
@@ -97,34 +223,27 @@ func (e *EntityVars) Sprint(idx int, d Def) string {
 	}
 }
 
+// RawI returns the field offset off of entity idx without any validation
+// against the FieldDefs. It is meant for entity numbers and offsets provided
+// by the engine, never for values coming from QuakeC.
 func (e *EntityVars) RawI(idx, off int32) int32 {
 	return (e.entvars[idx][off])
 }
 
+// SetRawI sets the field offset off of entity idx. Like RawI it is only meant
+// for entity numbers and offsets provided by the engine.
 func (e *EntityVars) SetRawI(idx, off int32, value int32) {
 	e.entvars[idx][off] = value
 }
 
-func (e *EntityVars) getUnsafe(off int32) unsafe.Pointer {
-	// go 1.17:
-	// return unsafe.Add(g_entvars, off)
-	return unsafe.Pointer(uintptr(e.g_entvars) + uintptr(off))
-}
-
-func (e *EntityVars) Set0RawI(off int32, value int32) {
-	*(*int32)(e.getUnsafe(off)) = value
-}
-
-func (e *EntityVars) Set0RawF(off int32, value float32) {
-	*(*float32)(e.getUnsafe(off)) = value
-}
-
+// RawF is the float version of RawI.
 func (e *EntityVars) RawF(idx, off int32) float32 {
-	return *(*float32)(unsafe.Pointer(&(e.entvars[idx][off])))
+	return math.Float32frombits(uint32(e.entvars[idx][off]))
 }
 
+// SetRawF is the float version of SetRawI.
 func (e *EntityVars) SetRawF(idx, off int32, value float32) {
-	*(*float32)(unsafe.Pointer(&(e.entvars[idx][off]))) = value
+	e.entvars[idx][off] = int32(math.Float32bits(value))
 }
 
 func (e *EntityVars) FieldValue(idx int, name string) (float32, error) {
@@ -133,7 +252,7 @@ func (e *EntityVars) FieldValue(idx int, name string) (float32, error) {
 	if err != nil {
 		return 0, err
 	}
-	return *(*float32)(unsafe.Pointer(&(e.entvars[idx][d.Offset]))), nil
+	return e.RawF(int32(idx), int32(d.Offset)), nil
 }
 
 func (e *EntityVars) ParsePair(idx int, key Def, val string) {
@@ -149,7 +268,7 @@ func (e *EntityVars) ParsePair(idx int, key Def, val string) {
 		if err != nil {
 			slog.Debug("Can't convert to float32", slog.String("val", val))
 		}
-		*(*float32)(unsafe.Pointer(vp)) = v
+		*vp = int32(math.Float32bits(v))
 	case EV_Vector:
 		var v [3]float32
 		n, err := fmt.Sscanf(val, "%f %f %f", &v[0], &v[1], &v[2])
@@ -159,7 +278,9 @@ func (e *EntityVars) ParsePair(idx int, key Def, val string) {
 		for ; n < 3; n++ {
 			v[n] = 0
 		}
-		*(*[3]float32)(unsafe.Pointer(vp)) = v
+		for i := range v {
+			e.SetRawF(int32(idx), int32(key.Offset)+int32(i), v[i])
+		}
 	case EV_Entity:
 		var v int32
 		val = strings.TrimPrefix(val, "entity ") // fix for eto
@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package progs
+
+import (
+	"errors"
+	"testing"
+)
+
+func testEntityVars() *EntityVars {
+	p := &LoadedProg{prog: &prog{
+		FieldDefs: []Def{
+			{Type: EV_Float, Offset: 0},
+			{Type: EV_Vector, Offset: 1},
+			{Type: EV_Float, Offset: 5},
+		},
+	}}
+	// offset 4 has no field def
+	return AllocEntvars(4, 6, p)
+}
+
+func TestEntityVarsChecked(t *testing.T) {
+	e := testEntityVars()
+	tests := []struct {
+		name string
+		idx  int32
+		off  int32
+		ok   bool
+	}{
+		{"float", 1, 0, true},
+		{"last entity", 3, 5, true},
+		{"no def", 1, 4, false},
+		{"negative offset", 1, -1, false},
+		{"offset too large", 1, 6, false},
+		{"negative entity", -1, 0, false},
+		{"entity too large", 4, 0, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ptr, err := e.Address(tc.idx, tc.off)
+			if (err == nil) != tc.ok {
+				t.Fatalf("Address(%d, %d): %v", tc.idx, tc.off, err)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrBadField) {
+					t.Errorf("got %v, want ErrBadField", err)
+				}
+				return
+			}
+			if err := e.Store(ptr, 42); err != nil {
+				t.Fatalf("Store: %v", err)
+			}
+			if v, err := e.Load(tc.idx, tc.off); err != nil || v != 42 {
+				t.Errorf("Load: got %d, %v", v, err)
+			}
+		})
+	}
+}
+
+func TestEntityVarsVector(t *testing.T) {
+	e := testEntityVars()
+	ptr, err := e.Address(2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [3]float32{1, 2, 3}
+	if err := e.StoreVector(ptr, want); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := e.LoadVector(2, 1); err != nil || v != want {
+		t.Errorf("LoadVector: got %v, %v, want %v", v, err, want)
+	}
+	if e.RawF(2, 2) != 2 {
+		t.Errorf("RawF: got %v, want 2", e.RawF(2, 2))
+	}
+	// the vector would reach into offset 4 which has no def
+	if _, err := e.LoadVector(2, 2); err == nil {
+		t.Errorf("LoadVector(2, 2): no error")
+	}
+	if err := e.StoreVector(ptr+1, want); err == nil {
+		t.Errorf("StoreVector to unaligned pointer: no error")
+	}
+}
+
+func TestEntityVarsGrow(t *testing.T) {
+	e := testEntityVars()
+	old := e.entvars[3]
+	e.Grow(10)
+	if e.Len() != 10 {
+		t.Fatalf("Len = %d, want 10", e.Len())
+	}
+	// memory from before stays valid
+	old[0] = 8
+	if v := e.RawI(3, 0); v != 8 {
+		t.Errorf("RawI(3, 0) = %d, want 8", v)
+	}
+	ptr, err := e.Address(9, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Store(ptr, 42); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := e.Load(9, 5); err != nil || v != 42 {
+		t.Errorf("Load: got %d, %v", v, err)
+	}
+}
+
+func FuzzEntityVars(f *testing.F) {
+	f.Add(int32(1), int32(0), int32(4), int32(7))
+	f.Add(int32(-1), int32(5), int32(-4), int32(0))
+	f.Add(int32(3), int32(1), int32(95), int32(1))
+	f.Fuzz(func(t *testing.T, idx, off, ptr, value int32) {
+		e := testEntityVars()
+		e.Address(idx, off)
+		e.Load(idx, off)
+		e.LoadVector(idx, off)
+		e.Store(ptr, value)
+		e.StoreVector(ptr, [3]float32{1, 2, 3})
+	})
+}
@@ -11,6 +11,7 @@ import (
 
 	"goquake/crc"
 	"goquake/filesystem"
+	"goquake/progs/op"
 )
 
 type prog struct {
@@ -26,21 +27,28 @@ type prog struct {
 	// The progs.dat expects an edict to have EdictSize 32bit values
 	EdictSize int
 	CRC       uint16
+	Size      int
 	Alpha     bool
 }
 
-func loadProgs() (*prog, error) {
-	var crcVal uint16
-	b, err := filesystem.ReadFile("progs.dat")
+func loadProgs(name string) (*prog, error) {
+	b, err := filesystem.ReadFile(name)
 	if err != nil {
-		return nil, fmt.Errorf("Could not load progs.dat, %v", err)
+		return nil, fmt.Errorf("Could not load %s, %v", name, err)
 	}
-	crcVal = crc.Update(b)
+	return parseProgs(b)
+}
+
+func parseProgs(b []byte) (*prog, error) {
+	crcVal := crc.Update(b)
 	r := bytes.NewReader(b)
 	hdr, err := readHeader(r)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkSections(hdr, len(b)); err != nil {
+		return nil, err
+	}
 	st, err := readStatements(hdr, r)
 	if err != nil {
 		return nil, fmt.Errorf("Could not read statements: %v", err)
@@ -75,10 +83,18 @@ func loadProgs() (*prog, error) {
 		}
 	}
 
+	if err := checkCode(st, fu, len(rgli)); err != nil {
+		return nil, err
+	}
+
 	ez := int(hdr.EntityFields)
+	if ez < 0 {
+		return nil, fmt.Errorf("Bad number of entity fields %d", ez)
+	}
 
 	return &prog{
 		CRC:         crcVal,
+		Size:        len(b),
 		EdictSize:   ez,
 		Header:      hdr,
 		Functions:   fu,
@@ -102,12 +118,116 @@ func readHeader(file io.ReadSeeker) (*Header, error) {
 	if v.Version != ProgVersion {
 		return nil, fmt.Errorf("ProgVersion must be %v but is %v", ProgVersion, v.Version)
 	}
-	if v.CRC != ProgHeaderCRC {
-		return nil, fmt.Errorf("progdefs.h is out of date")
-	}
 	return &v, nil
 }
 
+// checkSections makes sure all lumps are inside the file. This also limits the
+// allocations done for a malformed file.
+func checkSections(h *Header, size int) error {
+	sections := []struct {
+		name   string
+		offset int32
+		num    int32
+		size   int
+	}{
+		{"statements", h.OffsetStatements, h.NumStatements, binary.Size(Statement{})},
+		{"global defs", h.OffsetGlobalDefs, h.NumGlobalDefs, binary.Size(Def{})},
+		{"field defs", h.OffsetFieldDefs, h.NumFieldDefs, binary.Size(Def{})},
+		{"functions", h.OffsetFunctions, h.NumFunctions, binary.Size(Function{})},
+		{"strings", h.OffsetStrings, h.NumStrings, 1},
+		{"globals", h.OffsetGlobals, h.NumGlobals, 4},
+	}
+	for _, s := range sections {
+		if s.offset < 0 || s.num < 0 || int64(s.offset)+int64(s.num)*int64(s.size) > int64(size) {
+			return fmt.Errorf("Bad %s lump, offset %d, count %d", s.name, s.offset, s.num)
+		}
+	}
+	return nil
+}
+
+// vectorOperands holds for the opcodes which read or write vectors which of
+// the operands A, B and C are vectors.
+var vectorOperands = map[uint16][3]bool{
+	op.MUL_V:    {true, true, false},
+	op.MUL_FV:   {false, true, true},
+	op.MUL_VF:   {true, false, true},
+	op.ADD_V:    {true, true, true},
+	op.SUB_V:    {true, true, true},
+	op.EQ_V:     {true, true, false},
+	op.NE_V:     {true, true, false},
+	op.NOT_V:    {true, false, false},
+	op.STORE_V:  {true, true, false},
+	op.STOREP_V: {true, false, false},
+	op.LOAD_V:   {false, false, true},
+	op.RETURN:   {true, false, false},
+	op.DONE:     {true, false, false},
+}
+
+// checkCode validates the functions and statements so the VMs only need to
+// check values which are computed at runtime.
+func checkCode(st []Statement, fu []Function, numGlobals int) error {
+	for i, f := range fu {
+		if int(f.FirstStatement) >= len(st) {
+			return fmt.Errorf("Function %d starts at bad statement %d", i, f.FirstStatement)
+		}
+		if f.FirstStatement < 0 {
+			// builtins may have a negative number of parms for varargs
+			continue
+		}
+		if f.ParmStart < 0 || f.Locals < 0 || int64(f.ParmStart)+int64(f.Locals) > int64(numGlobals) {
+			return fmt.Errorf("Function %d has bad locals", i)
+		}
+		if f.NumParms < 0 || f.NumParms > MaxParms {
+			return fmt.Errorf("Function %d has bad number of parms %d", i, f.NumParms)
+		}
+		size := int32(0)
+		for _, ps := range f.ParmSize[:f.NumParms] {
+			if ps > 3 {
+				return fmt.Errorf("Function %d has bad parm size %d", i, ps)
+			}
+			size += int32(ps)
+		}
+		if size > f.Locals {
+			return fmt.Errorf("Function %d has more parms than locals", i)
+		}
+	}
+	for i, s := range st {
+		operands := [3]int16{s.A, s.B, s.C}
+		// the jump offsets are relative to the statement and may be negative
+		jump := -1
+		switch s.Operator {
+		case op.IF, op.IFNOT:
+			jump = 1
+		case op.GOTO:
+			jump = 0
+		}
+		vo := vectorOperands[s.Operator]
+		for j, o := range operands {
+			if j == jump {
+				if t := i + int(o); t < 0 || t >= len(st) {
+					return fmt.Errorf("Statement %d jumps to bad statement %d", i, t)
+				}
+				continue
+			}
+			n := 1
+			if vo[j] {
+				n = 3
+			}
+			if o < 0 || int(o)+n > numGlobals {
+				return fmt.Errorf("Statement %d has bad operand %d", i, o)
+			}
+		}
+	}
+	if len(st) > 0 {
+		switch st[len(st)-1].Operator {
+		case op.DONE, op.RETURN, op.GOTO:
+		default:
+			return fmt.Errorf("Statements do not end with a return")
+		}
+	}
+	return nil
+}
+
 func readStatements(pr *Header, file io.ReadSeeker) ([]Statement, error) {
 	v := make([]Statement, pr.NumStatements)
 	_, err := file.Seek(int64(pr.OffsetStatements), io.SeekStart)
@@ -157,7 +277,9 @@ func readFunctions(pr *Header, file io.ReadSeeker) ([]Function, error) {
 }
 
 func readGlobals(pr *Header, file io.ReadSeeker) (*GlobalVars, []int32, []float32, error) {
-	v := make([]int32, pr.NumGlobals)
+	// GlobalVars must always be backed by memory, even for progs with less globals
+	n := max(int(pr.NumGlobals), int(unsafe.Sizeof(GlobalVars{})/4))
+	v := make([]int32, n)[:pr.NumGlobals]
 	_, err := file.Seek(int64(pr.OffsetGlobals), io.SeekStart)
 	if err != nil {
 		return nil, nil, nil, err
@@ -165,7 +287,7 @@ func readGlobals(pr *Header, file io.ReadSeeker) (*GlobalVars, []int32, []float3
 	if err := binary.Read(file, binary.LittleEndian, &v); err != nil {
 		return nil, nil, nil, err
 	}
-	gp := unsafe.Pointer(&v[0])
+	gp := unsafe.Pointer(unsafe.SliceData(v))
 	vf := *(*[]float32)(unsafe.Pointer(&v))
 	return (*GlobalVars)(gp), v, vf, nil
 }
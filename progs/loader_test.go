@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package progs_test
+
+import (
+	"testing"
+
+	"goquake/progs"
+	"goquake/progs/op"
+	"goquake/progs/progstest"
+)
+
+// testProgs returns a progs.dat with a single function main running st.
+func testProgs(st []progs.Statement) []byte {
+	globals := make([]int32, progs.ReservedOffset+4)
+	globals[progs.ReservedOffset] = 1 // main
+	p := &progstest.Progs{
+		EntityFields: 1,
+		Statements:   append([]progs.Statement{{}}, st...),
+		GlobalDefs:   []progs.Def{{Type: progs.EV_Function, Offset: progs.ReservedOffset, SName: 1}},
+		FieldDefs:    []progs.Def{{Type: progs.EV_Float, Offset: 0, SName: 0}},
+		Functions:    []progs.Function{{}, {FirstStatement: 1, SName: 1}},
+		Strings:      []byte("\x00main\x00"),
+		Globals:      globals,
+	}
+	return p.Bytes()
+}
+
+func TestParseProgs(t *testing.T) {
+	p, err := progs.ParseProgs(testProgs([]progs.Statement{{Operator: op.DONE}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f, err := p.FindFunction("main"); err != nil || f != 1 {
+		t.Errorf("FindFunction: got %d, %v", f, err)
+	}
+}
+
+func TestParseProgsBackwardJump(t *testing.T) {
+	// while (x) x = x - 1;
+	st := []progs.Statement{
+		{Operator: op.IFNOT, A: progs.ReservedOffset + 1, B: 3},
+		{Operator: op.SUB_F, A: progs.ReservedOffset + 1, B: progs.ReservedOffset + 2, C: progs.ReservedOffset + 1},
+		{Operator: op.GOTO, A: -2},
+		{Operator: op.DONE},
+	}
+	if _, err := progs.ParseProgs(testProgs(st)); err != nil {
+		t.Errorf("ParseProgs: %v", err)
+	}
+}
+
+func TestParseProgsBadCode(t *testing.T) {
+	tests := []struct {
+		name string
+		st   []progs.Statement
+	}{
+		{"negative operand", []progs.Statement{{Operator: op.ADD_F, A: -1}, {Operator: op.DONE}}},
+		{"operand too large", []progs.Statement{{Operator: op.ADD_F, C: 1000}, {Operator: op.DONE}}},
+		{"vector past the globals", []progs.Statement{{Operator: op.STORE_V, A: progs.ReservedOffset + 2}, {Operator: op.DONE}}},
+		{"jump out of code", []progs.Statement{{Operator: op.GOTO, A: 10}, {Operator: op.DONE}}},
+		{"jump before code", []progs.Statement{{Operator: op.IF, B: -2}, {Operator: op.DONE}}},
+		{"no return", []progs.Statement{{Operator: op.ADD_F}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := progs.ParseProgs(testProgs(tc.st)); err == nil {
+				t.Errorf("ParseProgs: no error")
+			}
+		})
+	}
+}
+
+func FuzzParseProgs(f *testing.F) {
+	f.Add(testProgs([]progs.Statement{{Operator: op.DONE}}))
+	f.Add(testProgs([]progs.Statement{{Operator: op.GOTO, A: -1}, {Operator: op.DONE}}))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		progs.ParseProgs(b)
+	})
+}
@@ -8,6 +8,7 @@ const (
 	ProgVersion   = 6
 	MaxParms      = 8 // matches OffsetParm0-7
 	ProgHeaderCRC = 5927
+	CSProgsName   = "csprogs.dat"
 )
 
 // etype_t
@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+// Package progstest builds progs.dat files for tests.
+package progstest
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"goquake/progs"
+)
+
+// Progs holds the lumps of a progs.dat.
+type Progs struct {
+	EntityFields int32
+	Statements   []progs.Statement
+	GlobalDefs   []progs.Def
+	FieldDefs    []progs.Def
+	Functions    []progs.Function
+	Strings      []byte
+	Globals      []int32
+}
+
+// Bytes returns the encoded progs.dat.
+func (p *Progs) Bytes() []byte {
+	h := progs.Header{Version: progs.ProgVersion, EntityFields: p.EntityFields}
+	var body bytes.Buffer
+	section := func(data any, n int) (int32, int32) {
+		o := int32(binary.Size(h) + body.Len())
+		binary.Write(&body, binary.LittleEndian, data)
+		return o, int32(n)
+	}
+	h.OffsetStatements, h.NumStatements = section(p.Statements, len(p.Statements))
+	h.OffsetGlobalDefs, h.NumGlobalDefs = section(p.GlobalDefs, len(p.GlobalDefs))
+	h.OffsetFieldDefs, h.NumFieldDefs = section(p.FieldDefs, len(p.FieldDefs))
+	h.OffsetFunctions, h.NumFunctions = section(p.Functions, len(p.Functions))
+	h.OffsetStrings, h.NumStrings = section(p.Strings, len(p.Strings))
+	h.OffsetGlobals, h.NumGlobals = section(p.Globals, len(p.Globals))
+
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, h)
+	b.Write(body.Bytes())
+	return b.Bytes()
+}
@@ -19,7 +19,33 @@ type LoadedProg struct {
 
 // -- call this LoadProgs and let it return something called progs.LoadedProg
 func LoadProgs() (*LoadedProg, error) {
-	lp, err := loadProgs()
+	lp, err := loadProgs("progs.dat")
+	if err != nil {
+		return nil, err
+	}
+	if lp.Header.CRC != ProgHeaderCRC {
+		return nil, fmt.Errorf("progdefs.h is out of date")
+	}
+	r := &LoadedProg{lp, make([]string, 0)}
+	r.AddString("")
+	return r, nil
+}
+
+// ParseProgs parses a progs file which is not read from the game directory.
+func ParseProgs(b []byte) (*LoadedProg, error) {
+	lp, err := parseProgs(b)
+	if err != nil {
+		return nil, err
+	}
+	r := &LoadedProg{lp, make([]string, 0)}
+	r.AddString("")
+	return r, nil
+}
+
+// LoadCSProgs loads the client side csprogs.dat. Its globals are only
+// accessed by name so the header CRC of the defs is not checked.
+func LoadCSProgs() (*LoadedProg, error) {
+	lp, err := loadProgs(CSProgsName)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,429 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package progs
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"goquake/math/vec"
+	"goquake/progs/op"
+)
+
+// ErrProgram is returned if the VM aborted a program.
+var ErrProgram = errors.New("Program error")
+
+// Host provides the parts of the interpreter which differ between the server
+// and the client VM.
+type Host interface {
+	// CallBuiltin calls the builtin function with number num.
+	CallBuiltin(num int) error
+	// CheckAddress is called before OP_ADDRESS takes the address of a field
+	// of the entity ent.
+	CheckAddress(ent int32) error
+	// State implements OP_STATE by setting the frame and think function of
+	// self.
+	State(frame float32, think int32) error
+}
+
+const (
+	maxStackDepth = 1024
+	maxLocalStack = 16384
+)
+
+type stackElem struct {
+	function  *Function
+	statement int32
+}
+
+// VM is the QuakeC interpreter shared by the server and the client VM.
+type VM struct {
+	Prog    *LoadedProg
+	Entvars *EntityVars
+
+	// Name is added to the log messages to tell the VMs apart.
+	Name string
+	// Trace logs every executed statement.
+	Trace bool
+	// MaxStatements aborts programs running more statements in one call,
+	// 0 means no limit.
+	MaxStatements int
+
+	// Argc is the number of arguments of the called builtin.
+	Argc int
+	// XFunction is the running function.
+	XFunction *Function
+	// Statement is the current statement when a builtin is called or the
+	// program got aborted.
+	Statement int32
+
+	stack      []stackElem
+	localStack []int32
+}
+
+func NewVM(name string) *VM {
+	return &VM{
+		Name:       name,
+		stack:      make([]stackElem, 0, maxStackDepth),
+		localStack: make([]int32, 0, maxLocalStack),
+	}
+}
+
+// FuncName returns the name of the running function.
+func (v *VM) FuncName() string {
+	if v.XFunction == nil {
+		return ""
+	}
+	s, err := v.Prog.String(v.XFunction.SName)
+	if err != nil {
+		return ""
+	}
+	return s
+}
+
+func (v *VM) printStatement(s Statement) {
+	name := "unknown"
+	if int(s.Operator) < len(op.Names) {
+		name = op.Names[s.Operator]
+	}
+	switch {
+	case s.Operator == op.IF || s.Operator == op.IFNOT:
+		slog.Info(name,
+			slog.String("vm", v.Name),
+			slog.String("branch", v.Prog.GlobalString(s.A)),
+			slog.Int("B", int(s.B)))
+	case s.Operator == op.GOTO:
+		slog.Info(name, slog.String("vm", v.Name), slog.Int("branch", int(s.A)))
+	case s.Operator >= op.STORE_F && s.Operator <= op.STORE_FNC:
+		slog.Info(name,
+			slog.String("vm", v.Name),
+			slog.String("A", v.Prog.GlobalString(s.A)),
+			slog.String("B", v.Prog.GlobalStringNoContents(s.B)))
+	default:
+		a, b, c := "", "", ""
+		if s.A != 0 {
+			a = v.Prog.GlobalString(s.A)
+		}
+		if s.B != 0 {
+			b = v.Prog.GlobalString(s.B)
+		}
+		if s.C != 0 {
+			c = v.Prog.GlobalStringNoContents(s.C)
+		}
+		slog.Info(name, slog.String("vm", v.Name),
+			slog.String("A", a), slog.String("B", b), slog.String("C", c))
+	}
+}
+
+func (v *VM) printFunction(f *Function) {
+	if f == nil {
+		slog.Warn("<NO FUNCTION>", slog.String("vm", v.Name))
+		return
+	}
+	file, _ := v.Prog.String(f.SFile)
+	name, _ := v.Prog.String(f.SName)
+	slog.Info("FUNCTION", slog.String("vm", v.Name), slog.String("file", file), slog.String("name", name))
+}
+
+func (v *VM) stackTrace() {
+	v.printFunction(v.XFunction)
+	if len(v.stack) == 0 {
+		slog.Warn("<NO STACK>", slog.String("vm", v.Name))
+		return
+	}
+	for i := len(v.stack) - 1; i >= 0; i-- {
+		v.printFunction(v.stack[i].function)
+	}
+}
+
+// Abort prints the current statement and the stack and drops the stack so
+// a new program can be started.
+func (v *VM) Abort() {
+	if int(v.Statement) < len(v.Prog.Statements) {
+		v.printStatement(v.Prog.Statements[v.Statement])
+	}
+	v.stackTrace()
+	v.stack = v.stack[:0]
+	v.localStack = v.localStack[:0]
+}
+
+// fail logs msg and aborts the program at statement.
+func (v *VM) fail(statement int32, msg string, args ...any) error {
+	v.Statement = statement
+	slog.Error(msg, append([]any{slog.String("vm", v.Name)}, args...)...)
+	v.Abort()
+	return ErrProgram
+}
+
+// Returns the new program statement counter
+func (v *VM) enterFunction(f *Function) (int32, error) {
+	if len(v.stack) == cap(v.stack) {
+		return 0, v.fail(v.Statement, "stack overflow")
+	}
+	v.stack = append(v.stack, stackElem{
+		statement: v.Statement,
+		function:  v.XFunction,
+	})
+
+	// save off any locals that the new function steps on
+	c := f.Locals
+	if len(v.localStack)+int(c) > cap(v.localStack) {
+		return 0, v.fail(v.Statement, "locals stack overflow")
+	}
+	for i := int32(0); i < c; i++ {
+		v.localStack = append(v.localStack, v.Prog.RawGlobalsI[f.ParmStart+i])
+	}
+
+	// copy parameters
+	o := f.ParmStart
+	for i := int32(0); i < f.NumParms; i++ {
+		for j := byte(0); j < f.ParmSize[i]; j++ {
+			v.Prog.RawGlobalsI[o] = v.Prog.RawGlobalsI[OffsetParm0+i*3+int32(j)]
+			o++
+		}
+	}
+
+	v.XFunction = f
+	return f.FirstStatement, nil
+}
+
+func (v *VM) leaveFunction() (int32, error) {
+	if len(v.stack) == 0 {
+		return 0, fmt.Errorf("prog stack underflow")
+	}
+
+	// Restore locals from the stack
+	c := int(v.XFunction.Locals)
+	if len(v.localStack) < c {
+		return 0, v.fail(v.Statement, "locals stack underflow")
+	}
+
+	nl := len(v.localStack) - c
+	for i := 0; i < c; i++ {
+		v.Prog.RawGlobalsI[int(v.XFunction.ParmStart)+i] = v.localStack[nl+i]
+	}
+	v.localStack = v.localStack[:nl]
+
+	// up stack
+	top := v.stack[len(v.stack)-1]
+	v.stack = v.stack[:len(v.stack)-1]
+	v.XFunction = top.function
+	return top.statement, nil
+}
+
+// Execute runs the function fnum. The engine specific opcodes and the
+// builtins are handled by h.
+func (v *VM) Execute(fnum int32, h Host) error {
+	if fnum == 0 || int(fnum) >= len(v.Prog.Functions) {
+		return fmt.Errorf("PR_ExecuteProgram: NULL function, %d", fnum)
+	}
+
+	f := &v.Prog.Functions[fnum]
+	v.Trace = false
+
+	// make a stack frame
+	exitdepth := len(v.stack)
+
+	currentStatement, err := v.enterFunction(f)
+	if err != nil {
+		return err
+	}
+
+	g := v.Prog.RawGlobalsF
+	gi := v.Prog.RawGlobalsI
+	vecAt := func(o int16) vec.Vec3 {
+		return vec.Vec3{g[o], g[o+1], g[o+2]}
+	}
+	setVecAt := func(o int16, x vec.Vec3) {
+		g[o], g[o+1], g[o+2] = x[0], x[1], x[2]
+	}
+	BOOL := func(X bool) float32 {
+		if X {
+			return 1
+		}
+		return 0
+	}
+	stringEq := func(a, b int32) bool {
+		sa, erra := v.Prog.String(a)
+		sb, errb := v.Prog.String(b)
+		return (erra != nil && errb != nil) || (erra == nil && errb == nil && sa == sb)
+	}
+
+	//hack to offset the first increment of currentStatement
+	currentStatement--
+	profile := 0
+	for {
+		currentStatement++
+		s := &v.Prog.Statements[currentStatement]
+
+		profile++
+		if v.MaxStatements > 0 && profile > v.MaxStatements {
+			return v.fail(currentStatement, "runaway loop error")
+		}
+
+		if v.Trace {
+			v.printStatement(*s)
+		}
+
+		switch s.Operator {
+		case op.ADD_F:
+			g[s.C] = g[s.A] + g[s.B]
+		case op.ADD_V:
+			setVecAt(s.C, vec.Add(vecAt(s.A), vecAt(s.B)))
+		case op.SUB_F:
+			g[s.C] = g[s.A] - g[s.B]
+		case op.SUB_V:
+			setVecAt(s.C, vec.Sub(vecAt(s.A), vecAt(s.B)))
+		case op.MUL_F:
+			g[s.C] = g[s.A] * g[s.B]
+		case op.MUL_V:
+			g[s.C] = vec.Dot(vecAt(s.A), vecAt(s.B))
+		case op.MUL_FV:
+			setVecAt(s.C, vec.Scale(g[s.A], vecAt(s.B)))
+		case op.MUL_VF:
+			setVecAt(s.C, vec.Scale(g[s.B], vecAt(s.A)))
+		case op.DIV_F:
+			g[s.C] = g[s.A] / g[s.B]
+
+		case op.BITAND:
+			g[s.C] = float32(int(g[s.A]) & int(g[s.B]))
+		case op.BITOR:
+			g[s.C] = float32(int(g[s.A]) | int(g[s.B]))
+
+		case op.GE:
+			g[s.C] = BOOL(g[s.A] >= g[s.B])
+		case op.LE:
+			g[s.C] = BOOL(g[s.A] <= g[s.B])
+		case op.GT:
+			g[s.C] = BOOL(g[s.A] > g[s.B])
+		case op.LT:
+			g[s.C] = BOOL(g[s.A] < g[s.B])
+		case op.AND:
+			g[s.C] = BOOL(g[s.A] != 0 && g[s.B] != 0)
+		case op.OR:
+			g[s.C] = BOOL(g[s.A] != 0 || g[s.B] != 0)
+
+		case op.NOT_F:
+			g[s.C] = BOOL(g[s.A] == 0)
+		case op.NOT_V:
+			g[s.C] = BOOL(vecAt(s.A) == vec.Vec3{})
+		case op.NOT_S:
+			_, err := v.Prog.String(gi[s.A])
+			g[s.C] = BOOL(gi[s.A] == 0 || err != nil)
+		case op.NOT_FNC, op.NOT_ENT:
+			g[s.C] = BOOL(gi[s.A] == 0)
+
+		case op.EQ_F:
+			g[s.C] = BOOL(g[s.A] == g[s.B])
+		case op.EQ_V:
+			g[s.C] = BOOL(vecAt(s.A) == vecAt(s.B))
+		case op.EQ_S:
+			g[s.C] = BOOL(stringEq(gi[s.A], gi[s.B]))
+		case op.EQ_E, op.EQ_FNC:
+			g[s.C] = BOOL(gi[s.A] == gi[s.B])
+		case op.NE_F:
+			g[s.C] = BOOL(g[s.A] != g[s.B])
+		case op.NE_V:
+			g[s.C] = BOOL(vecAt(s.A) != vecAt(s.B))
+		case op.NE_S:
+			g[s.C] = BOOL(!stringEq(gi[s.A], gi[s.B]))
+		case op.NE_E, op.NE_FNC:
+			g[s.C] = BOOL(gi[s.A] != gi[s.B])
+
+		case op.STORE_F, op.STORE_ENT, op.STORE_FLD, op.STORE_S, op.STORE_FNC:
+			gi[s.B] = gi[s.A]
+		case op.STORE_V:
+			setVecAt(s.B, vecAt(s.A))
+
+		case op.STOREP_F, op.STOREP_ENT, op.STOREP_FLD, op.STOREP_S, op.STOREP_FNC:
+			if err := v.Entvars.Store(gi[s.B], gi[s.A]); err != nil {
+				return v.fail(currentStatement, "STOREP", slog.Any("err", err))
+			}
+		case op.STOREP_V:
+			if err := v.Entvars.StoreVector(gi[s.B], vecAt(s.A)); err != nil {
+				return v.fail(currentStatement, "STOREP_V", slog.Any("err", err))
+			}
+
+		case op.ADDRESS:
+			if err := h.CheckAddress(gi[s.A]); err != nil {
+				return v.fail(currentStatement, "ADDRESS", slog.Any("err", err))
+			}
+			a, err := v.Entvars.Address(gi[s.A], gi[s.B])
+			if err != nil {
+				return v.fail(currentStatement, "ADDRESS", slog.Any("err", err))
+			}
+			gi[s.C] = a
+
+		case op.LOAD_F, op.LOAD_FLD, op.LOAD_ENT, op.LOAD_S, op.LOAD_FNC:
+			i, err := v.Entvars.Load(gi[s.A], gi[s.B])
+			if err != nil {
+				return v.fail(currentStatement, "LOAD", slog.Any("err", err))
+			}
+			gi[s.C] = i
+		case op.LOAD_V:
+			ve, err := v.Entvars.LoadVector(gi[s.A], gi[s.B])
+			if err != nil {
+				return v.fail(currentStatement, "LOAD_V", slog.Any("err", err))
+			}
+			setVecAt(s.C, ve)
+
+		case op.IFNOT:
+			if gi[s.A] == 0 {
+				currentStatement += int32(s.B) - 1 // -1 to offset the st++
+			}
+		case op.IF:
+			if gi[s.A] != 0 {
+				currentStatement += int32(s.B) - 1 // -1 to offset the st++
+			}
+		case op.GOTO:
+			currentStatement += int32(s.A) - 1 // -1 to offset the st++
+
+		case op.CALL0, op.CALL1, op.CALL2, op.CALL3, op.CALL4,
+			op.CALL5, op.CALL6, op.CALL7, op.CALL8:
+			v.Statement = currentStatement
+			v.Argc = int(s.Operator) - op.CALL0
+			fn := gi[s.A]
+			if fn == 0 {
+				return v.fail(currentStatement, "NULL function")
+			}
+			if fn < 0 || int(fn) >= len(v.Prog.Functions) {
+				return v.fail(currentStatement, "Bad function", slog.Int("num", int(fn)))
+			}
+			newf := &v.Prog.Functions[fn]
+			if newf.FirstStatement < 0 {
+				// Built-in function
+				if err := h.CallBuiltin(int(-newf.FirstStatement)); err != nil {
+					return err
+				}
+			} else {
+				// Normal function
+				ns, err := v.enterFunction(newf)
+				if err != nil {
+					return err
+				}
+				currentStatement = ns - 1
+			}
+
+		case op.DONE, op.RETURN:
+			v.Statement = currentStatement
+			*(v.Prog.Globals.Returnf()) = vecAt(s.A)
+			ns, err := v.leaveFunction()
+			if err != nil {
+				return err
+			}
+			currentStatement = ns
+			if len(v.stack) == exitdepth { // Done
+				return nil
+			}
+
+		case op.STATE:
+			if err := h.State(g[s.A], gi[s.B]); err != nil {
+				return v.fail(currentStatement, "STATE", slog.Any("err", err))
+			}
+
+		default:
+			return v.fail(currentStatement, "Bad opcode", slog.Int("opcode", int(s.Operator)))
+		}
+	}
+}
@@ -22,6 +22,26 @@ const (
 	MaxDatagram = 32000
 )
 
+// Limits are the numbers of edicts, models and sounds a protocol can address.
+type Limits struct {
+	Edicts int
+	Models int
+	Sounds int
+}
+
+// MaxLimits returns the limits of the protocol pcol. GoQuake sends entity
+// numbers, model and sound indices as 32-bit values.
+func MaxLimits(pcol int) Limits {
+	switch pcol {
+	case NetQuake:
+		return Limits{Edicts: 32000, Models: 256, Sounds: 256}
+	case GoQuake:
+		return Limits{Edicts: 1 << 20, Models: 1 << 20, Sounds: 1 << 20}
+	default:
+		return Limits{Edicts: 32000, Models: 2048, Sounds: 2048}
+	}
+}
+
 const (
 	ANGLESHORT  = 1 << 1
 	ANGLEFLOAT  = 1 << 2
@@ -0,0 +1,571 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"fmt"
+	"slices"
+
+	"goquake/math/vec"
+	"goquake/net"
+	"goquake/protos"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// UpdateBackup is the number of entity frames kept on both sides. Deltas can
+// only be made against frames not older than this.
+const UpdateBackup = 64
+
+// bits of an entity in a packet entities message
+const (
+	deltaRemove = 1 << iota
+	deltaModel
+	deltaFrame
+	deltaColorMap
+	deltaSkin
+	deltaEffects
+	deltaOrigin1
+	deltaOrigin2
+	deltaOrigin3
+	deltaAngle1
+	deltaAngle2
+	deltaAngle3
+	deltaAlpha
+	deltaStep       // no data follows, the bit is the value
+	deltaLerpFinish // the entity has a think interval, sent every time
+)
+
+// EntityState is the state of an entity as sent in a packet entities message.
+type EntityState struct {
+	Number     int
+	ModelIndex int
+	Frame      int
+	ColorMap   int
+	Skin       int
+	Effects    int
+	Origin     vec.Vec3
+	Angles     vec.Vec3
+	Alpha      int
+	MoveStep   bool
+	Interval   bool // LerpFinish is valid
+	LerpFinish int
+}
+
+// EntityFrame holds all entities sent in a single packet entities message.
+type EntityFrame struct {
+	Number   uint32
+	Entities []EntityState // sorted by Number
+}
+
+// deltaBits returns the bits needed to get from from to to and whether
+// anything changed at all.
+func deltaBits(from, to *EntityState) (int, bool) {
+	bits := 0
+	if from.ModelIndex != to.ModelIndex {
+		bits |= deltaModel
+	}
+	if from.Frame != to.Frame {
+		bits |= deltaFrame
+	}
+	if from.ColorMap != to.ColorMap {
+		bits |= deltaColorMap
+	}
+	if from.Skin != to.Skin {
+		bits |= deltaSkin
+	}
+	if from.Effects != to.Effects {
+		bits |= deltaEffects
+	}
+	if from.Origin[0] != to.Origin[0] {
+		bits |= deltaOrigin1
+	}
+	if from.Origin[1] != to.Origin[1] {
+		bits |= deltaOrigin2
+	}
+	if from.Origin[2] != to.Origin[2] {
+		bits |= deltaOrigin3
+	}
+	if from.Angles[0] != to.Angles[0] {
+		bits |= deltaAngle1
+	}
+	if from.Angles[1] != to.Angles[1] {
+		bits |= deltaAngle2
+	}
+	if from.Angles[2] != to.Angles[2] {
+		bits |= deltaAngle3
+	}
+	if from.Alpha != to.Alpha {
+		bits |= deltaAlpha
+	}
+	if bits == 0 && from.MoveStep == to.MoveStep &&
+		from.Interval == to.Interval && from.LerpFinish == to.LerpFinish {
+		return 0, false
+	}
+	if to.MoveStep {
+		bits |= deltaStep
+	}
+	if to.Interval {
+		bits |= deltaLerpFinish
+	}
+	return bits, true
+}
+
+// deltaUpdate returns the changed fields of to as entity update, nil if
+// nothing changed.
+func deltaUpdate(from, to *EntityState) *protos.EntityUpdate {
+	bits, changed := deltaBits(from, to)
+	if !changed {
+		return nil
+	}
+	eu := &protos.EntityUpdate{}
+	eu.SetEntity(int32(to.Number))
+	eu.SetLerpMoveStep(to.MoveStep)
+	if bits&deltaModel != 0 {
+		eu.SetModel(int32(to.ModelIndex))
+	}
+	if bits&deltaFrame != 0 {
+		eu.SetFrame(int32(to.Frame))
+	}
+	if bits&deltaColorMap != 0 {
+		eu.SetColorMap(int32(to.ColorMap))
+	}
+	if bits&deltaSkin != 0 {
+		eu.SetSkin(int32(to.Skin))
+	}
+	if bits&deltaEffects != 0 {
+		eu.SetEffects(int32(to.Effects))
+	}
+	if bits&deltaOrigin1 != 0 {
+		eu.SetOriginX(to.Origin[0])
+	}
+	if bits&deltaOrigin2 != 0 {
+		eu.SetOriginY(to.Origin[1])
+	}
+	if bits&deltaOrigin3 != 0 {
+		eu.SetOriginZ(to.Origin[2])
+	}
+	if bits&deltaAngle1 != 0 {
+		eu.SetAngleX(to.Angles[0])
+	}
+	if bits&deltaAngle2 != 0 {
+		eu.SetAngleY(to.Angles[1])
+	}
+	if bits&deltaAngle3 != 0 {
+		eu.SetAngleZ(to.Angles[2])
+	}
+	if bits&deltaAlpha != 0 {
+		eu.SetAlpha(int32(to.Alpha))
+	}
+	if bits&deltaLerpFinish != 0 {
+		eu.SetLerpFinish(int32(to.LerpFinish))
+	}
+	return eu
+}
+
+// packetEntities returns the entities of f as delta against the frame from.
+// If from is nil the delta is made against empty entities.
+func packetEntities(f, from *EntityFrame) *protos.PacketEntities {
+	pe := &protos.PacketEntities{}
+	pe.SetFrame(f.Number)
+	var old []EntityState
+	if from != nil {
+		pe.SetDeltaFrame(from.Number)
+		old = from.Entities
+	}
+	var removed []int32
+	var updates []*protos.EntityUpdate
+	add := func(from, to *EntityState) {
+		if eu := deltaUpdate(from, to); eu != nil {
+			updates = append(updates, eu)
+		}
+	}
+	nw := f.Entities
+	for len(old) > 0 || len(nw) > 0 {
+		switch {
+		case len(nw) == 0 || (len(old) > 0 && old[0].Number < nw[0].Number):
+			// the entity is no longer sent
+			removed = append(removed, int32(old[0].Number))
+			old = old[1:]
+		case len(old) == 0 || nw[0].Number < old[0].Number:
+			add(&EntityState{Number: nw[0].Number}, &nw[0])
+			nw = nw[1:]
+		default:
+			add(&old[0], &nw[0])
+			old = old[1:]
+			nw = nw[1:]
+		}
+	}
+	pe.SetRemoved(removed)
+	pe.SetEntities(updates)
+	return pe
+}
+
+func writeDelta(eu *protos.EntityUpdate, flags uint32, m *net.Message) {
+	bits := 0
+	if eu.HasModel() {
+		bits |= deltaModel
+	}
+	if eu.HasFrame() {
+		bits |= deltaFrame
+	}
+	if eu.HasColorMap() {
+		bits |= deltaColorMap
+	}
+	if eu.HasSkin() {
+		bits |= deltaSkin
+	}
+	if eu.HasEffects() {
+		bits |= deltaEffects
+	}
+	if eu.HasOriginX() {
+		bits |= deltaOrigin1
+	}
+	if eu.HasOriginY() {
+		bits |= deltaOrigin2
+	}
+	if eu.HasOriginZ() {
+		bits |= deltaOrigin3
+	}
+	if eu.HasAngleX() {
+		bits |= deltaAngle1
+	}
+	if eu.HasAngleY() {
+		bits |= deltaAngle2
+	}
+	if eu.HasAngleZ() {
+		bits |= deltaAngle3
+	}
+	if eu.HasAlpha() {
+		bits |= deltaAlpha
+	}
+	if eu.GetLerpMoveStep() {
+		bits |= deltaStep
+	}
+	if eu.HasLerpFinish() {
+		bits |= deltaLerpFinish
+	}
+	m.WriteLong(int(eu.GetEntity()))
+	m.WriteShort(bits)
+	if eu.HasModel() {
+		m.WriteLong(int(eu.GetModel()))
+	}
+	if eu.HasFrame() {
+		m.WriteShort(int(eu.GetFrame()))
+	}
+	if eu.HasColorMap() {
+		m.WriteByte(int(eu.GetColorMap()))
+	}
+	if eu.HasSkin() {
+		m.WriteByte(int(eu.GetSkin()))
+	}
+	if eu.HasEffects() {
+		m.WriteShort(int(eu.GetEffects()))
+	}
+	if eu.HasOriginX() {
+		m.WriteCoord(eu.GetOriginX(), flags)
+	}
+	if eu.HasOriginY() {
+		m.WriteCoord(eu.GetOriginY(), flags)
+	}
+	if eu.HasOriginZ() {
+		m.WriteCoord(eu.GetOriginZ(), flags)
+	}
+	if eu.HasAngleX() {
+		m.WriteAngle(eu.GetAngleX(), flags)
+	}
+	if eu.HasAngleY() {
+		m.WriteAngle(eu.GetAngleY(), flags)
+	}
+	if eu.HasAngleZ() {
+		m.WriteAngle(eu.GetAngleZ(), flags)
+	}
+	if eu.HasAlpha() {
+		m.WriteByte(int(eu.GetAlpha()))
+	}
+	if eu.HasLerpFinish() {
+		m.WriteByte(int(eu.GetLerpFinish()))
+	}
+}
+
+// WritePacketEntities writes the entities of f as delta against the frame
+// from. If from is nil the delta is made against empty entities.
+func WritePacketEntities(f, from *EntityFrame, flags uint32, m *Message) {
+	start := m.Len()
+	pe := packetEntities(f, from)
+	m.WriteByte(PacketEntities)
+	m.WriteLong(int(pe.GetFrame()))
+	m.WriteLong(int(pe.GetDeltaFrame()))
+	removed := pe.GetRemoved()
+	updates := pe.GetEntities()
+	// both are sorted by entity, merge them
+	for len(removed) > 0 || len(updates) > 0 {
+		if len(updates) == 0 || (len(removed) > 0 && removed[0] < updates[0].GetEntity()) {
+			m.WriteLong(int(removed[0]))
+			m.WriteShort(deltaRemove)
+			removed = removed[1:]
+			continue
+		}
+		writeDelta(updates[0], flags, &m.Message)
+		updates = updates[1:]
+	}
+	m.WriteLong(0)
+	m.add(start, protos.SCmd_builder{PacketEntities: pe}.Build())
+}
+
+// readDelta reads the changed fields of entity n as entity update.
+func readDelta(msg *net.QReader, flags uint32, n, bits int) (*protos.EntityUpdate, error) {
+	eu := &protos.EntityUpdate{}
+	eu.SetEntity(int32(n))
+	eu.SetLerpMoveStep(bits&deltaStep != 0)
+	var err error
+	readShort := func(set func(int32)) {
+		if err != nil {
+			return
+		}
+		var v uint16
+		v, err = msg.ReadUint16()
+		set(int32(v))
+	}
+	readLong := func(set func(int32)) {
+		if err != nil {
+			return
+		}
+		var v int32
+		v, err = msg.ReadInt32()
+		set(v)
+	}
+	readByte := func(set func(int32)) {
+		if err != nil {
+			return
+		}
+		var v byte
+		v, err = msg.ReadByte()
+		set(int32(v))
+	}
+	read := func(set func(float32), r func(uint32) (float32, error)) {
+		if err != nil {
+			return
+		}
+		var v float32
+		v, err = r(flags)
+		set(v)
+	}
+	if bits&deltaModel != 0 {
+		readLong(eu.SetModel)
+	}
+	if bits&deltaFrame != 0 {
+		readShort(eu.SetFrame)
+	}
+	if bits&deltaColorMap != 0 {
+		readByte(eu.SetColorMap)
+	}
+	if bits&deltaSkin != 0 {
+		readByte(eu.SetSkin)
+	}
+	if bits&deltaEffects != 0 {
+		readShort(eu.SetEffects)
+	}
+	if bits&deltaOrigin1 != 0 {
+		read(eu.SetOriginX, msg.ReadCoord)
+	}
+	if bits&deltaOrigin2 != 0 {
+		read(eu.SetOriginY, msg.ReadCoord)
+	}
+	if bits&deltaOrigin3 != 0 {
+		read(eu.SetOriginZ, msg.ReadCoord)
+	}
+	if bits&deltaAngle1 != 0 {
+		read(eu.SetAngleX, msg.ReadAngle)
+	}
+	if bits&deltaAngle2 != 0 {
+		read(eu.SetAngleY, msg.ReadAngle)
+	}
+	if bits&deltaAngle3 != 0 {
+		read(eu.SetAngleZ, msg.ReadAngle)
+	}
+	if bits&deltaAlpha != 0 {
+		readByte(eu.SetAlpha)
+	}
+	if bits&deltaLerpFinish != 0 {
+		readByte(eu.SetLerpFinish)
+	}
+	return eu, err
+}
+
+func parsePacketEntities(msg *net.QReader, flags uint32) (*protos.PacketEntities, error) {
+	pe := &protos.PacketEntities{}
+	num, err := msg.ReadUint32()
+	if err != nil {
+		return nil, err
+	}
+	pe.SetFrame(num)
+	delta, err := msg.ReadUint32()
+	if err != nil {
+		return nil, err
+	}
+	pe.SetDeltaFrame(delta)
+	for {
+		n, err := msg.ReadUint32()
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return pe, nil
+		}
+		bits, err := msg.ReadUint16()
+		if err != nil {
+			return nil, err
+		}
+		if bits&deltaRemove != 0 {
+			pe.SetRemoved(append(pe.GetRemoved(), int32(n)))
+			continue
+		}
+		eu, err := readDelta(msg, flags, int(n), int(bits))
+		if err != nil {
+			return nil, err
+		}
+		pe.SetEntities(append(pe.GetEntities(), eu))
+	}
+}
+
+// apply changes s by the fields set in eu.
+func (s *EntityState) apply(eu *protos.EntityUpdate) {
+	if eu.HasModel() {
+		s.ModelIndex = int(eu.GetModel())
+	}
+	if eu.HasFrame() {
+		s.Frame = int(eu.GetFrame())
+	}
+	if eu.HasColorMap() {
+		s.ColorMap = int(eu.GetColorMap())
+	}
+	if eu.HasSkin() {
+		s.Skin = int(eu.GetSkin())
+	}
+	if eu.HasEffects() {
+		s.Effects = int(eu.GetEffects())
+	}
+	if eu.HasOriginX() {
+		s.Origin[0] = eu.GetOriginX()
+	}
+	if eu.HasOriginY() {
+		s.Origin[1] = eu.GetOriginY()
+	}
+	if eu.HasOriginZ() {
+		s.Origin[2] = eu.GetOriginZ()
+	}
+	if eu.HasAngleX() {
+		s.Angles[0] = eu.GetAngleX()
+	}
+	if eu.HasAngleY() {
+		s.Angles[1] = eu.GetAngleY()
+	}
+	if eu.HasAngleZ() {
+		s.Angles[2] = eu.GetAngleZ()
+	}
+	if eu.HasAlpha() {
+		s.Alpha = int(eu.GetAlpha())
+	}
+	s.MoveStep = eu.GetLerpMoveStep()
+	s.Interval = eu.HasLerpFinish()
+	s.LerpFinish = int(eu.GetLerpFinish())
+}
+
+// EntityFrames keeps the last received entity frames of a client to rebuild
+// delta compressed packet entities.
+type EntityFrames struct {
+	frames [UpdateBackup]EntityFrame
+	// Ack is the last frame which got rebuilt, 0 if a full update is needed.
+	Ack uint32
+	// full is set while waiting for a requested full update
+	full bool
+}
+
+// Reset forgets all frames and requests a full update.
+func (f *EntityFrames) Reset() {
+	*f = EntityFrames{}
+}
+
+// RequestFull keeps Ack at 0 until a frame without delta arrives.
+func (f *EntityFrames) RequestFull() {
+	f.full = true
+	f.Ack = 0
+}
+
+// Apply rebuilds the full frame from the delta in pe. It returns nil if the
+// frame the delta was made against is no longer known.
+func (f *EntityFrames) Apply(pe *protos.PacketEntities) (*EntityFrame, error) {
+	num := pe.GetFrame()
+	delta := pe.GetDeltaFrame()
+	var old []EntityState
+	if delta != 0 {
+		from := &f.frames[delta%UpdateBackup]
+		if from.Number != delta || delta >= num || num-delta >= UpdateBackup {
+			f.Ack = 0
+			return nil, nil
+		}
+		old = from.Entities
+	}
+	removed := pe.GetRemoved()
+	var nw []EntityState
+	for _, eu := range pe.GetEntities() {
+		n := int(eu.GetEntity())
+		if len(nw) > 0 && nw[len(nw)-1].Number >= n {
+			return nil, fmt.Errorf("packet entities: entity %d out of order", n)
+		}
+		// copy all unchanged entities in front of n
+		for len(old) > 0 && old[0].Number < n {
+			if !slices.Contains(removed, int32(old[0].Number)) {
+				nw = append(nw, old[0])
+			}
+			old = old[1:]
+		}
+		s := EntityState{Number: n}
+		if len(old) > 0 && old[0].Number == n {
+			s = old[0]
+			old = old[1:]
+		}
+		s.apply(eu)
+		nw = append(nw, s)
+	}
+	for _, o := range old {
+		if !slices.Contains(removed, int32(o.Number)) {
+			nw = append(nw, o)
+		}
+	}
+	fr := &f.frames[num%UpdateBackup]
+	fr.Number = num
+	fr.Entities = nw
+	if delta == 0 {
+		f.full = false
+	}
+	if !f.full {
+		f.Ack = num
+	}
+	return fr, nil
+}
+
+// EntityUpdate returns the full state of s as entity update.
+func (s *EntityState) EntityUpdate() *protos.EntityUpdate {
+	eu := protos.EntityUpdate_builder{
+		Entity:       int32(s.Number),
+		LerpMoveStep: s.MoveStep,
+		Model:        proto.Int32(int32(s.ModelIndex)),
+		Frame:        proto.Int32(int32(s.Frame)),
+		ColorMap:     proto.Int32(int32(s.ColorMap)),
+		Skin:         proto.Int32(int32(s.Skin)),
+		Effects:      proto.Int32(int32(s.Effects)),
+		OriginX:      proto.Float32(s.Origin[0]),
+		OriginY:      proto.Float32(s.Origin[1]),
+		OriginZ:      proto.Float32(s.Origin[2]),
+		AngleX:       proto.Float32(s.Angles[0]),
+		AngleY:       proto.Float32(s.Angles[1]),
+		AngleZ:       proto.Float32(s.Angles[2]),
+		Alpha:        proto.Int32(int32(s.Alpha)),
+	}.Build()
+	if s.Interval {
+		eu.SetLerpFinish(int32(s.LerpFinish))
+	}
+	return eu
+}
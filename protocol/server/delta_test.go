@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"testing"
+
+	"goquake/math/vec"
+	"goquake/net"
+	"goquake/protocol"
+)
+
+const deltaFlags = protocol.COORDFLOAT | protocol.ANGLEFLOAT
+
+func parseFrame(t *testing.T, f *EntityFrames, m *Message) map[int]EntityState {
+	t.Helper()
+	sm, err := ParseServerMessage(net.NewQReader(m.Bytes()), protocol.GoQuake, deltaFlags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := make(map[int]EntityState)
+	for _, c := range sm.GetCmds() {
+		fr, err := f.Apply(c.GetPacketEntities())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fr == nil {
+			continue
+		}
+		for _, e := range fr.Entities {
+			r[e.Number] = e
+		}
+	}
+	return r
+}
+
+func checkFrame(t *testing.T, got map[int]EntityState, want *EntityFrame) {
+	t.Helper()
+	if len(got) != len(want.Entities) {
+		t.Fatalf("frame %d: got %d entities, want %d", want.Number, len(got), len(want.Entities))
+	}
+	for _, w := range want.Entities {
+		if g := got[w.Number]; g != w {
+			t.Errorf("frame %d: entity %d = %+v, want %+v", want.Number, w.Number, g, w)
+		}
+	}
+}
+
+func TestPacketEntities(t *testing.T) {
+	frames := []*EntityFrame{
+		{Number: 1, Entities: []EntityState{
+			{Number: 1, ModelIndex: 2, Origin: vec.Vec3{1, 2, 3}},
+			{Number: 5, ModelIndex: 7, Frame: 3, Skin: 1, Alpha: 128},
+			{Number: 300, ModelIndex: 9, Effects: 8, Interval: true, LerpFinish: 25},
+		}},
+		{Number: 2, Entities: []EntityState{
+			{Number: 1, ModelIndex: 2, Origin: vec.Vec3{4, 2, 3}, Angles: vec.Vec3{0, 90, 0}},
+			// 5 got removed
+			{Number: 7, ModelIndex: 70000, MoveStep: true},
+			{Number: 300, ModelIndex: 9, Effects: 8, Interval: true, LerpFinish: 25},
+		}},
+		{Number: 3, Entities: []EntityState{
+			{Number: 1, ModelIndex: 2, Origin: vec.Vec3{4, 2, 3}, Angles: vec.Vec3{0, 90, 0}},
+			{Number: 7, ModelIndex: 70001},
+			{Number: 300, ModelIndex: 9},
+		}},
+	}
+	var client EntityFrames
+	var from *EntityFrame
+	for _, f := range frames {
+		var m Message
+		WritePacketEntities(f, from, deltaFlags, &m)
+		checkFrame(t, parseFrame(t, &client, &m), f)
+		if client.Ack != f.Number {
+			t.Errorf("Ack = %d, want %d", client.Ack, f.Number)
+		}
+		from = f
+	}
+
+	// a delta against a frame the client does not have
+	lost := &EntityFrame{Number: 4, Entities: frames[0].Entities}
+	var m Message
+	WritePacketEntities(&EntityFrame{Number: 10}, lost, deltaFlags, &m)
+	if got := parseFrame(t, &client, &m); len(got) != 0 {
+		t.Errorf("got %d entities from an unknown delta frame", len(got))
+	}
+	if client.Ack != 0 {
+		t.Errorf("Ack = %d, want 0", client.Ack)
+	}
+}
+
+func TestPacketEntitiesRequestFull(t *testing.T) {
+	f1 := &EntityFrame{Number: 1, Entities: []EntityState{{Number: 1, ModelIndex: 1}}}
+	f2 := &EntityFrame{Number: 2, Entities: []EntityState{{Number: 1, ModelIndex: 2}}}
+	f3 := &EntityFrame{Number: 3, Entities: []EntityState{{Number: 1, ModelIndex: 3}}}
+	var client EntityFrames
+	var m Message
+	WritePacketEntities(f1, nil, deltaFlags, &m)
+	parseFrame(t, &client, &m)
+
+	client.RequestFull()
+	m = Message{}
+	WritePacketEntities(f2, f1, deltaFlags, &m)
+	checkFrame(t, parseFrame(t, &client, &m), f2)
+	if client.Ack != 0 {
+		t.Errorf("Ack = %d while waiting for a full update", client.Ack)
+	}
+	m = Message{}
+	WritePacketEntities(f3, nil, deltaFlags, &m)
+	checkFrame(t, parseFrame(t, &client, &m), f3)
+	if client.Ack != 3 {
+		t.Errorf("Ack = %d, want 3", client.Ack)
+	}
+}
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"slices"
+	"unicode/utf8"
+
+	"goquake/net"
+	"goquake/protos"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Message is a server message under construction. Next to the byte encoding
+// it keeps the commands written by the Write functions to send them as a
+// protos.ServerMessage without parsing the bytes again.
+type Message struct {
+	net.Message
+	cmds []*protos.SCmd
+	done int // bytes covered by cmds
+}
+
+// Reset clears the bytes and the commands.
+func (m *Message) Reset() {
+	m.Message.Reset()
+	m.cmds = nil
+	m.done = 0
+}
+
+// Deprecated: use Reset()
+func (m *Message) ClearMessage() {
+	m.Reset()
+}
+
+// flush keeps the bytes written up to end without a command, e.g. by QuakeC,
+// as legacy command.
+func (m *Message) flush(end int) {
+	if end > m.done {
+		m.cmds = append(m.cmds, protos.SCmd_builder{
+			Legacy: slices.Clone(m.Bytes()[m.done:end]),
+		}.Build())
+	}
+	m.done = end
+}
+
+// add appends the command c which got written starting at start.
+func (m *Message) add(start int, c *protos.SCmd) {
+	m.flush(start)
+	if m.Len() > start {
+		m.cmds = append(m.cmds, c)
+	}
+	m.done = m.Len()
+}
+
+// addText is add for commands holding text. Proto strings need to be valid
+// UTF-8 which the quake charset is not, so other text stays byte encoded.
+func (m *Message) addText(start int, c *protos.SCmd, text ...string) {
+	for _, t := range text {
+		if !utf8.ValidString(t) {
+			return
+		}
+	}
+	m.add(start, c)
+}
+
+// Append adds the bytes and commands of o.
+func (m *Message) Append(o *Message) {
+	start := m.Len()
+	m.flush(start)
+	m.cmds = append(m.cmds, o.cmds...)
+	m.WriteBytes(o.Bytes())
+	m.done = start + o.done
+}
+
+// Proto returns the message as ProtoMessage holding the serialized
+// protos.ServerMessage.
+func (m *Message) Proto() ([]byte, error) {
+	m.flush(m.Len())
+	b, err := proto.Marshal(protos.ServerMessage_builder{Cmds: m.cmds}.Build())
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{ProtoMessage}, b...), nil
+}
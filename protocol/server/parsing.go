@@ -4,6 +4,7 @@ package server
 
 import (
 	"fmt"
+	"slices"
 
 	"goquake/math/vec"
 	"goquake/net"
@@ -65,6 +66,10 @@ var (
 		"",                      // 50
 		"",                      // 51
 		"svc_achievement",       // 52
+		"svc_csqcevent",         // 53 [short] length [bytes] payload
+		"svc_playerstate",       // 54
+		"svc_packetentities",    // 55
+		"svc_protomessage",      // 56
 	}
 )
 
@@ -284,6 +289,13 @@ func parseClientData(msg *net.QReader) (*protos.ClientData, error) {
 		}
 		clientData.SetWeaponAlpha(int32(v))
 	}
+	if has(SU_WEAPON3) {
+		v, err := msg.ReadUint16()
+		if err != nil {
+			return nil, err
+		}
+		weapon |= int32(v) << 16
+	}
 	clientData.SetAmmo(ammo)
 	clientData.SetShells(shells)
 	clientData.SetNails(nails)
@@ -538,6 +550,26 @@ func parseTempEntity(msg *net.QReader, protocolFlags uint32) (*protos.TempEntity
 	return nil, fmt.Errorf("CL_ParseTEnt: bad type")
 }
 
+// readEntityNumber reads an entity number of a baseline. GoQuake uses 32 bit
+// to address more than 32767 entities.
+func readEntityNumber(msg *net.QReader, pcol int) (int32, error) {
+	if pcol == protocol.GoQuake {
+		return msg.ReadInt32()
+	}
+	i, err := msg.ReadInt16()
+	return int32(i), err
+}
+
+// readSoundNumber reads the sound number of a large static sound. GoQuake uses
+// 32 bit to address more than 65535 sounds.
+func readSoundNumber(msg *net.QReader, pcol int) (int32, error) {
+	if pcol == protocol.GoQuake {
+		return msg.ReadInt32()
+	}
+	i, err := msg.ReadUint16()
+	return int32(i), err
+}
+
 func parseSoundMessage(msg *net.QReader, protocolFlags uint32) (*protos.Sound, error) {
 	message := &protos.Sound{}
 
@@ -562,7 +594,18 @@ func parseSoundMessage(msg *net.QReader, protocolFlags uint32) (*protos.Sound, e
 		message.SetAttenuation(int32(a))
 	}
 
-	if fieldMask&SoundLargeEntity != 0 {
+	if fieldMask&SoundLongEntity != 0 {
+		e, err := msg.ReadInt32() // int32
+		if err != nil {
+			return nil, fmt.Errorf("CL_ParseStartSoundPacket: %v", err)
+		}
+		c, err := msg.ReadByte() // byte
+		if err != nil {
+			return nil, fmt.Errorf("CL_ParseStartSoundPacket: %v", err)
+		}
+		message.SetEntity(e)
+		message.SetChannel(int32(c))
+	} else if fieldMask&SoundLargeEntity != 0 {
 		e, err := msg.ReadInt16() // int16
 		if err != nil {
 			return nil, fmt.Errorf("CL_ParseStartSoundPacket: %v", err)
@@ -582,7 +625,13 @@ func parseSoundMessage(msg *net.QReader, protocolFlags uint32) (*protos.Sound, e
 		message.SetChannel(int32(s & 7))
 	}
 
-	if fieldMask&SoundLargeSound != 0 {
+	if fieldMask&SoundLongSound != 0 {
+		n, err := msg.ReadInt32() // int32
+		if err != nil {
+			return nil, fmt.Errorf("CL_ParseStartSoundPacket: %v", err)
+		}
+		message.SetSoundNum(n - 1)
+	} else if fieldMask&SoundLargeSound != 0 {
 		n, err := msg.ReadInt16() // int16
 		if err != nil {
 			return nil, fmt.Errorf("CL_ParseStartSoundPacket: %v", err)
@@ -613,7 +662,13 @@ func parseBaseline(msg *net.QReader, protocolFlags uint32, version int) (*protos
 			return nil, err
 		}
 	}
-	if bits&EntityBaselineLargeModel != 0 {
+	if bits&EntityBaselineLongModel != 0 {
+		if i, err := msg.ReadInt32(); err != nil {
+			return nil, err
+		} else {
+			bl.SetModelIndex(i)
+		}
+	} else if bits&EntityBaselineLargeModel != 0 {
 		if i, err := msg.ReadUint16(); err != nil {
 			return nil, err
 		} else {
@@ -959,6 +1014,37 @@ func parseEntityUpdate(msg *net.QReader, pcol int, protocolFlags uint32, cmd byt
 }
 
 func ParseServerMessage(msg *net.QReader, protocol int, protocolFlags uint32) (*protos.ServerMessage, error) {
+	sm := &protos.ServerMessage{}
+	if b := msg.Bytes(); msg.Len() > 0 && msg.Len() == len(b) && b[0] == ProtoMessage {
+		// the whole message is a serialized ServerMessage
+		if err := proto.Unmarshal(b[1:], sm); err != nil {
+			return nil, err
+		}
+		return expandLegacy(sm, protocol, protocolFlags)
+	}
+	return parseServerMessage(msg, protocol, protocolFlags)
+}
+
+// expandLegacy replaces the legacy commands of sm by the commands parsed from
+// their bytes.
+func expandLegacy(sm *protos.ServerMessage, protocol int, protocolFlags uint32) (*protos.ServerMessage, error) {
+	var cmds []*protos.SCmd
+	for _, c := range sm.GetCmds() {
+		if !c.HasLegacy() {
+			cmds = append(cmds, c)
+			continue
+		}
+		l, err := parseServerMessage(net.NewQReader(c.GetLegacy()), protocol, protocolFlags)
+		if err != nil {
+			return nil, err
+		}
+		cmds = append(cmds, l.GetCmds()...)
+	}
+	sm.SetCmds(cmds)
+	return sm, nil
+}
+
+func parseServerMessage(msg *net.QReader, protocol int, protocolFlags uint32) (*protos.ServerMessage, error) {
 	sm := &protos.ServerMessage{}
 	lastcmd := byte(0)
 	for {
@@ -1189,10 +1275,10 @@ func ParseServerMessage(msg *net.QReader, protocol int, protocolFlags uint32) (*
 			}.Build()))
 		case SpawnBaseline:
 			eb := &protos.EntityBaseline{}
-			if i, err := msg.ReadInt16(); err != nil {
+			if i, err := readEntityNumber(msg, protocol); err != nil {
 				return nil, err
 			} else {
-				eb.SetIndex(int32(i))
+				eb.SetIndex(i)
 			}
 			if pb, err := parseBaseline(msg, protocolFlags, 1); err != nil {
 				return nil, err
@@ -1352,10 +1438,10 @@ func ParseServerMessage(msg *net.QReader, protocol int, protocolFlags uint32) (*
 			}.Build()))
 		case SpawnBaseline2:
 			sb := &protos.EntityBaseline{}
-			if i, err := msg.ReadInt16(); err != nil {
+			if i, err := readEntityNumber(msg, protocol); err != nil {
 				return nil, err
 			} else {
-				sb.SetIndex(int32(i))
+				sb.SetIndex(i)
 			}
 			if pb, err := parseBaseline(msg, protocolFlags, 2); err != nil {
 				return nil, err
@@ -1381,15 +1467,18 @@ func ParseServerMessage(msg *net.QReader, protocol int, protocolFlags uint32) (*
 			} else {
 				ss.SetOrigin(org)
 			}
+			if n, err := readSoundNumber(msg, protocol); err != nil {
+				return nil, err
+			} else {
+				ss.SetIndex(n)
+			}
 			var data struct {
-				Num uint16
 				Vol uint8
 				Att uint8
 			}
 			if err := msg.Read(&data); err != nil {
 				return nil, err
 			}
-			ss.SetIndex(int32(data.Num))
 			ss.SetVolume(int32(data.Vol))
 			ss.SetAttenuation(int32(data.Att))
 			sm.SetCmds(append(sm.GetCmds(), protos.SCmd_builder{
@@ -1403,6 +1492,58 @@ func ParseServerMessage(msg *net.QReader, protocol int, protocolFlags uint32) (*
 					Achievement: proto.String(s),
 				}.Build()))
 			}
+		case CSQCEvent:
+			l, err := msg.ReadUint16()
+			if err != nil {
+				return nil, err
+			}
+			data := make([]byte, l)
+			if err := msg.Read(data); err != nil {
+				return nil, err
+			}
+			sm.SetCmds(append(sm.GetCmds(), protos.SCmd_builder{
+				CsqcEvent: data,
+			}.Build()))
+		case PacketEntities:
+			pe, err := parsePacketEntities(msg, protocolFlags)
+			if err != nil {
+				return nil, err
+			}
+			sm.SetCmds(append(sm.GetCmds(), protos.SCmd_builder{
+				PacketEntities: pe,
+			}.Build()))
+		case PlayerState:
+			var data struct {
+				Sequence uint32
+				Origin   [3]float32
+				Velocity [3]float32
+				Flags    int32
+				MoveType uint8
+				Params   [6]float32
+				Switches uint8
+			}
+			if err := msg.Read(&data); err != nil {
+				return nil, err
+			}
+			sm.SetCmds(append(sm.GetCmds(), protos.SCmd_builder{
+				PlayerState: protos.PlayerState_builder{
+					Sequence: data.Sequence,
+					Origin:   protos.Coord_builder{X: data.Origin[0], Y: data.Origin[1], Z: data.Origin[2]}.Build(),
+					Velocity: protos.Coord_builder{X: data.Velocity[0], Y: data.Velocity[1], Z: data.Velocity[2]}.Build(),
+					Flags:    data.Flags,
+					MoveType: int32(data.MoveType),
+					Params: protos.MoveParams_builder{
+						Accelerate:   data.Params[0],
+						Friction:     data.Params[1],
+						EdgeFriction: data.Params[2],
+						StopSpeed:    data.Params[3],
+						MaxSpeed:     data.Params[4],
+						Gravity:      data.Params[5],
+						NoStep:       data.Switches&moveNoStep != 0,
+						AltNoclip:    data.Switches&moveAltNoClip != 0,
+					}.Build(),
+				}.Build(),
+			}.Build()))
 		}
 		lastcmd = cmd
 	}
@@ -1420,9 +1561,10 @@ func writeAngle(a *protos.Coord, protocolFlags uint32, m *net.Message) {
 	m.WriteAngle(a.GetZ(), protocolFlags)
 }
 
-func WriteParticle(p *protos.Particle, protocolFlags uint32, m *net.Message) {
+func WriteParticle(p *protos.Particle, protocolFlags uint32, m *Message) {
+	start := m.Len()
 	m.WriteByte(Particle)
-	writeCoord(p.GetOrigin(), protocolFlags, m)
+	writeCoord(p.GetOrigin(), protocolFlags, &m.Message)
 	df := func(d float32) int {
 		v := d * 16
 		if v > 127 {
@@ -1438,17 +1580,27 @@ func WriteParticle(p *protos.Particle, protocolFlags uint32, m *net.Message) {
 	m.WriteChar(df(p.GetDirection().GetZ()))
 	m.WriteByte(int(p.GetCount()))
 	m.WriteByte(int(p.GetColor()))
+	if p.GetCount() == 255 {
+		// the parser turns 255 into 1024
+		p = proto.CloneOf(p)
+		p.SetCount(1024)
+	}
+	m.add(start, protos.SCmd_builder{Particle: p}.Build())
 }
 
-func WriteSound(s *protos.Sound, pcol int, flags uint32, m *net.Message) {
+func WriteSound(s *protos.Sound, pcol int, flags uint32, m *Message) {
 	fieldMask := 0
-	if s.GetEntity() >= 8192 {
+	if s.GetEntity() > 0x7fff && pcol == protocol.GoQuake {
+		fieldMask |= SoundLongEntity
+	} else if s.GetEntity() >= 8192 {
 		if pcol == protocol.NetQuake {
 			return
 		}
 		fieldMask |= SoundLargeEntity
 	}
-	if s.GetSoundNum() >= 256 || s.GetChannel() >= 8 {
+	if s.GetSoundNum() > 0x7fff && pcol == protocol.GoQuake {
+		fieldMask |= SoundLongSound
+	} else if s.GetSoundNum() >= 256 || s.GetChannel() >= 8 {
 		if pcol == protocol.NetQuake {
 			return
 		}
@@ -1460,6 +1612,7 @@ func WriteSound(s *protos.Sound, pcol int, flags uint32, m *net.Message) {
 	if s.HasAttenuation() {
 		fieldMask |= SoundAttenuation
 	}
+	start := m.Len()
 	m.WriteByte(Sound)
 	m.WriteByte(fieldMask)
 	if s.HasVolume() {
@@ -1468,33 +1621,150 @@ func WriteSound(s *protos.Sound, pcol int, flags uint32, m *net.Message) {
 	if s.HasAttenuation() {
 		m.WriteByte(int(s.GetAttenuation()))
 	}
-	if fieldMask&SoundLargeEntity != 0 {
+	if fieldMask&SoundLongEntity != 0 {
+		m.WriteLong(int(s.GetEntity()))
+		m.WriteByte(int(s.GetChannel()))
+	} else if fieldMask&SoundLargeEntity != 0 {
 		m.WriteShort(int(s.GetEntity()))
 		m.WriteByte(int(s.GetChannel()))
 	} else {
 		m.WriteShort(int(s.GetEntity()<<3 | s.GetChannel()))
 	}
-	if fieldMask&SoundLargeSound != 0 {
+	if fieldMask&SoundLongSound != 0 {
+		m.WriteLong(int(s.GetSoundNum()))
+	} else if fieldMask&SoundLargeSound != 0 {
 		m.WriteShort(int(s.GetSoundNum()))
 	} else {
 		m.WriteByte(int(s.GetSoundNum()))
 	}
-	writeCoord(s.GetOrigin(), flags, m)
+	writeCoord(s.GetOrigin(), flags, &m.Message)
+	// the parser returns the index without the empty precache entry 0
+	s = proto.CloneOf(s)
+	s.SetSoundNum(s.GetSoundNum() - 1)
+	m.add(start, protos.SCmd_builder{Sound: s}.Build())
+}
+
+// baselineBits returns the bits of the version 2 baseline messages needed to
+// send b, 0 if version 1 is enough.
+func baselineBits(b *protos.Baseline, pcol int) int {
+	if pcol == protocol.NetQuake {
+		return 0
+	}
+	bits := 0
+	if pcol == protocol.GoQuake && b.GetModelIndex() > 0xffff {
+		bits |= EntityBaselineLongModel
+	} else if b.GetModelIndex()&0xFF00 != 0 {
+		bits |= EntityBaselineLargeModel
+	}
+	if b.GetFrame()&0xFF00 != 0 {
+		bits |= EntityBaselineLargeFrame
+	}
+	if b.GetAlpha() != EntityAlphaDefault {
+		bits |= EntityBaselineAlpha
+	}
+	return bits
+}
+
+func writeBaseline(b *protos.Baseline, bits int, flags uint32, m *net.Message) {
+	switch {
+	case bits&EntityBaselineLongModel != 0:
+		m.WriteLong(int(b.GetModelIndex()))
+	case bits&EntityBaselineLargeModel != 0:
+		m.WriteShort(int(b.GetModelIndex()))
+	default:
+		m.WriteByte(int(b.GetModelIndex()))
+	}
+	if bits&EntityBaselineLargeFrame != 0 {
+		m.WriteShort(int(b.GetFrame()))
+	} else {
+		m.WriteByte(int(b.GetFrame()))
+	}
+	m.WriteByte(int(b.GetColorMap()))
+	m.WriteByte(int(b.GetSkin()))
+	m.WriteCoord(b.GetOrigin().GetX(), flags)
+	m.WriteAngle(b.GetAngles().GetX(), flags)
+	m.WriteCoord(b.GetOrigin().GetY(), flags)
+	m.WriteAngle(b.GetAngles().GetY(), flags)
+	m.WriteCoord(b.GetOrigin().GetZ(), flags)
+	m.WriteAngle(b.GetAngles().GetZ(), flags)
+	if bits&EntityBaselineAlpha != 0 {
+		m.WriteByte(int(b.GetAlpha()))
+	}
+}
+
+func WriteSpawnBaseline(eb *protos.EntityBaseline, pcol int, flags uint32, m *Message) {
+	start := m.Len()
+	bits := baselineBits(eb.GetBaseline(), pcol)
+	if bits != 0 {
+		m.WriteByte(SpawnBaseline2)
+	} else {
+		m.WriteByte(SpawnBaseline)
+	}
+	if pcol == protocol.GoQuake {
+		m.WriteLong(int(eb.GetIndex()))
+	} else {
+		m.WriteShort(int(eb.GetIndex()))
+	}
+	if bits != 0 {
+		m.WriteByte(bits)
+	}
+	writeBaseline(eb.GetBaseline(), bits, flags, &m.Message)
+	m.add(start, protos.SCmd_builder{SpawnBaseline: eb}.Build())
+}
+
+func WriteSpawnStatic(b *protos.Baseline, pcol int, flags uint32, m *Message) {
+	start := m.Len()
+	bits := baselineBits(b, pcol)
+	if bits != 0 {
+		m.WriteByte(SpawnStatic2)
+		m.WriteByte(bits)
+	} else {
+		m.WriteByte(SpawnStatic)
+	}
+	writeBaseline(b, bits, flags, &m.Message)
+	m.add(start, protos.SCmd_builder{SpawnStatic: b}.Build())
+}
+
+func WriteSpawnStaticSound(s *protos.StaticSound, pcol int, flags uint32, m *Message) {
+	start := m.Len()
+	large := s.GetIndex() > 255
+	if large {
+		m.WriteByte(SpawnStaticSound2)
+	} else {
+		m.WriteByte(SpawnStaticSound)
+	}
+	writeCoord(s.GetOrigin(), flags, &m.Message)
+	switch {
+	case large && pcol == protocol.GoQuake:
+		m.WriteLong(int(s.GetIndex()))
+	case large:
+		m.WriteShort(int(s.GetIndex()))
+	default:
+		m.WriteByte(int(s.GetIndex()))
+	}
+	m.WriteByte(int(s.GetVolume()))
+	m.WriteByte(int(s.GetAttenuation()))
+	m.add(start, protos.SCmd_builder{SpawnStaticSound: s}.Build())
 }
 
-func WriteDamage(d *protos.Damage, pcol int, flags uint32, m *net.Message) {
+func WriteDamage(d *protos.Damage, pcol int, flags uint32, m *Message) {
+	start := m.Len()
 	m.WriteByte(Damage)
 	m.WriteByte(int(d.GetArmor()))
 	m.WriteByte(int(d.GetBlood()))
-	writeCoord(d.GetPosition(), flags, m)
+	writeCoord(d.GetPosition(), flags, &m.Message)
+	m.add(start, protos.SCmd_builder{Damage: d}.Build())
 }
 
-func WriteSetAngle(a *protos.Coord, pcol int, flags uint32, m *net.Message) {
+func WriteSetAngle(a *protos.Coord, pcol int, flags uint32, m *Message) {
+	start := m.Len()
 	m.WriteByte(SetAngle)
-	writeAngle(a, flags, m)
+	writeAngle(a, flags, &m.Message)
+	m.add(start, protos.SCmd_builder{SetAngle: a}.Build())
 }
 
-func WriteClientData(cd *protos.ClientData, pcol int, flags uint32, m *net.Message) {
+func WriteClientData(cd *protos.ClientData, pcol int, flags uint32, m *Message) {
+	start := m.Len()
 	bits := 0
 	if cd.HasViewHeight() {
 		bits |= SU_VIEWHEIGHT
@@ -1564,6 +1834,9 @@ func WriteClientData(cd *protos.ClientData, pcol int, flags uint32, m *net.Messa
 		if cd.GetWeaponAlpha() != 0 {
 			bits |= SU_WEAPONALPHA
 		}
+		if pcol == protocol.GoQuake && cd.GetWeapon() > 0xffff {
+			bits |= SU_WEAPON3
+		}
 		if bits >= 65536 {
 			bits |= SU_EXTEND1
 		}
@@ -1647,20 +1920,67 @@ func WriteClientData(cd *protos.ClientData, pcol int, flags uint32, m *net.Messa
 	if (bits & SU_WEAPONALPHA) != 0 {
 		m.WriteByte(int(cd.GetWeaponAlpha()))
 	}
+	if (bits & SU_WEAPON3) != 0 {
+		m.WriteShort(int(cd.GetWeapon() >> 16))
+	}
+	m.add(start, protos.SCmd_builder{ClientData: cd}.Build())
 }
 
-func WriteTime(t float32, pcol int, flags uint32, m *net.Message) {
+func WriteTime(t float32, pcol int, flags uint32, m *Message) {
+	start := m.Len()
 	m.WriteByte(Time)
 	m.WriteFloat(t)
+	m.add(start, protos.SCmd_builder{Time: proto.Float32(t)}.Build())
+}
+
+func WriteCSQCEvent(data []byte, pcol int, flags uint32, m *Message) {
+	start := m.Len()
+	m.WriteByte(CSQCEvent)
+	m.WriteShort(len(data))
+	m.WriteBytes(data)
+	m.add(start, protos.SCmd_builder{CsqcEvent: slices.Clone(data)}.Build())
+}
+
+func WritePlayerState(ps *protos.PlayerState, pcol int, flags uint32, m *Message) {
+	start := m.Len()
+	m.WriteByte(PlayerState)
+	m.WriteLong(int(ps.GetSequence()))
+	m.WriteFloat(ps.GetOrigin().GetX())
+	m.WriteFloat(ps.GetOrigin().GetY())
+	m.WriteFloat(ps.GetOrigin().GetZ())
+	m.WriteFloat(ps.GetVelocity().GetX())
+	m.WriteFloat(ps.GetVelocity().GetY())
+	m.WriteFloat(ps.GetVelocity().GetZ())
+	m.WriteLong(int(ps.GetFlags()))
+	m.WriteByte(int(ps.GetMoveType()))
+	mp := ps.GetParams()
+	m.WriteFloat(mp.GetAccelerate())
+	m.WriteFloat(mp.GetFriction())
+	m.WriteFloat(mp.GetEdgeFriction())
+	m.WriteFloat(mp.GetStopSpeed())
+	m.WriteFloat(mp.GetMaxSpeed())
+	m.WriteFloat(mp.GetGravity())
+	switches := 0
+	if mp.GetNoStep() {
+		switches |= moveNoStep
+	}
+	if mp.GetAltNoclip() {
+		switches |= moveAltNoClip
+	}
+	m.WriteByte(switches)
+	m.add(start, protos.SCmd_builder{PlayerState: ps}.Build())
 }
 
-func WriteUpdateFrags(uf *protos.UpdateFrags, pcol int, flags uint32, m *net.Message) {
+func WriteUpdateFrags(uf *protos.UpdateFrags, pcol int, flags uint32, m *Message) {
+	start := m.Len()
 	m.WriteByte(UpdateFrags)
 	m.WriteByte(int(uf.GetPlayer()))
 	m.WriteShort(int(uf.GetNewFrags()))
+	m.add(start, protos.SCmd_builder{UpdateFrags: uf}.Build())
 }
 
-func WriteEntityUpdate(eu *protos.EntityUpdate, pcol int, flags uint32, m *net.Message) {
+func WriteEntityUpdate(eu *protos.EntityUpdate, pcol int, flags uint32, m *Message) {
+	start := m.Len()
 	bits := 0
 	if eu.HasOriginX() {
 		bits |= U_ORIGIN1
@@ -1795,21 +2115,27 @@ func WriteEntityUpdate(eu *protos.EntityUpdate, pcol int, flags uint32, m *net.M
 	if bits&U_LERPFINISH != 0 {
 		m.WriteByte(int(eu.GetLerpFinish()))
 	}
+	m.add(start, protos.SCmd_builder{EntityUpdate: eu}.Build())
 }
 
-func WriteUpdateColors(uc *protos.UpdateColors, pcol int, flags uint32, m *net.Message) {
+func WriteUpdateColors(uc *protos.UpdateColors, pcol int, flags uint32, m *Message) {
+	start := m.Len()
 	m.WriteByte(UpdateColors)
 	m.WriteByte(int(uc.GetPlayer()))
 	m.WriteByte(int(uc.GetNewColor()))
+	m.add(start, protos.SCmd_builder{UpdateColors: uc}.Build())
 }
 
-func WriteUpdateName(un *protos.UpdateName, pcol int, flags uint32, m *net.Message) {
+func WriteUpdateName(un *protos.UpdateName, pcol int, flags uint32, m *Message) {
+	start := m.Len()
 	m.WriteByte(UpdateName)
 	m.WriteByte(int(un.GetPlayer()))
 	m.WriteString(un.GetNewName())
+	m.addText(start, protos.SCmd_builder{UpdateName: un}.Build(), un.GetNewName())
 }
 
-func WriteSetPause(p bool, pcol int, flags uint32, m *net.Message) {
+func WriteSetPause(p bool, pcol int, flags uint32, m *Message) {
+	start := m.Len()
 	m.WriteByte(SetPause)
 	m.WriteByte(func() int {
 		if p {
@@ -1817,4 +2143,99 @@ func WriteSetPause(p bool, pcol int, flags uint32, m *net.Message) {
 		}
 		return 0
 	}())
+	m.add(start, protos.SCmd_builder{SetPause: proto.Bool(p)}.Build())
+}
+
+func WriteNop(m *Message) {
+	start := m.Len()
+	m.WriteByte(Nop)
+	m.add(start, &protos.SCmd{})
+}
+
+func WriteDisconnect(m *Message) {
+	start := m.Len()
+	m.WriteByte(Disconnect)
+	m.add(start, protos.SCmd_builder{Disconnect: proto.Bool(true)}.Build())
+}
+
+func WritePrint(s string, m *Message) {
+	start := m.Len()
+	m.WriteByte(Print)
+	m.WriteString(s)
+	m.addText(start, protos.SCmd_builder{Print: proto.String(s)}.Build(), s)
+}
+
+func WriteCenterPrint(s string, m *Message) {
+	start := m.Len()
+	m.WriteByte(CenterPrint)
+	m.WriteString(s)
+	m.addText(start, protos.SCmd_builder{CenterPrint: proto.String(s)}.Build(), s)
+}
+
+func WriteStuffText(s string, m *Message) {
+	start := m.Len()
+	m.WriteByte(StuffText)
+	m.WriteString(s)
+	m.addText(start, protos.SCmd_builder{StuffText: proto.String(s)}.Build(), s)
+}
+
+func WriteLightStyle(ls *protos.LightStyle, m *Message) {
+	start := m.Len()
+	m.WriteByte(LightStyle)
+	m.WriteByte(int(ls.GetIdx()))
+	m.WriteString(ls.GetNewStyle())
+	m.addText(start, protos.SCmd_builder{LightStyle: ls}.Build(), ls.GetNewStyle())
+}
+
+func WriteUpdateStat(us *protos.UpdateStat, m *Message) {
+	start := m.Len()
+	m.WriteByte(UpdateStat)
+	m.WriteByte(int(us.GetStat()))
+	m.WriteLong(int(us.GetValue()))
+	m.add(start, protos.SCmd_builder{UpdateStat: us}.Build())
+}
+
+func WriteSignonNum(n int, m *Message) {
+	start := m.Len()
+	m.WriteByte(SignonNum)
+	m.WriteByte(n)
+	m.add(start, protos.SCmd_builder{SignonNum: proto.Int32(int32(n))}.Build())
+}
+
+func WriteSetView(entity int, m *Message) {
+	start := m.Len()
+	m.WriteByte(SetView)
+	m.WriteShort(entity)
+	m.add(start, protos.SCmd_builder{SetViewEntity: proto.Int32(int32(entity))}.Build())
+}
+
+func WriteCDTrack(t *protos.CDTrack, m *Message) {
+	start := m.Len()
+	m.WriteByte(CDTrack)
+	m.WriteByte(int(t.GetTrackNumber()))
+	m.WriteByte(int(t.GetLoopTrack()))
+	m.add(start, protos.SCmd_builder{CdTrack: t}.Build())
+}
+
+func WriteServerInfo(si *protos.ServerInfo, m *Message) {
+	start := m.Len()
+	m.WriteByte(ServerInfo)
+	m.WriteLong(int(si.GetProtocol()))
+	if si.GetProtocol() == protocol.RMQ {
+		m.WriteLong(int(si.GetFlags()))
+	}
+	m.WriteByte(int(si.GetMaxClients()))
+	m.WriteByte(int(si.GetGameType()))
+	m.WriteString(si.GetLevelName())
+	for _, mn := range si.GetModelPrecache() {
+		m.WriteString(mn)
+	}
+	m.WriteByte(0)
+	for _, sn := range si.GetSoundPrecache() {
+		m.WriteString(sn)
+	}
+	m.WriteByte(0)
+	text := append([]string{si.GetLevelName()}, si.GetModelPrecache()...)
+	text = append(text, si.GetSoundPrecache()...)
+	m.addText(start, protos.SCmd_builder{ServerInfo: si}.Build(), text...)
 }
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"testing"
+
+	"goquake/net"
+	"goquake/protocol"
+	"goquake/protos"
+)
+
+func TestIndices(t *testing.T) {
+	tests := []struct {
+		name  string
+		pcol  int
+		flags uint32
+		index int32
+	}{
+		{"netquake", protocol.NetQuake, 0, 200},
+		{"fitzquake", protocol.FitzQuake, 0, 2000},
+		{"goquake", protocol.GoQuake, 0, 70000},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			origin := protos.Coord_builder{X: 1, Y: 2, Z: 3}.Build()
+			baseline := protos.Baseline_builder{
+				ModelIndex: tc.index,
+				Frame:      3,
+				Origin:     origin,
+				Angles:     protos.Coord_builder{}.Build(),
+			}.Build()
+			var m Message
+			WriteSpawnBaseline(protos.EntityBaseline_builder{Index: 5, Baseline: baseline}.Build(), tc.pcol, tc.flags, &m)
+			WriteSpawnStatic(baseline, tc.pcol, tc.flags, &m)
+			WriteSpawnStaticSound(protos.StaticSound_builder{
+				Origin: origin, Index: tc.index, Volume: 255, Attenuation: 64,
+			}.Build(), tc.pcol, tc.flags, &m)
+			WriteSound(protos.Sound_builder{
+				Entity: 1, Channel: 2, SoundNum: tc.index, Origin: origin,
+			}.Build(), tc.pcol, tc.flags, &m)
+			WriteClientData(protos.ClientData_builder{
+				Weapon:     tc.index,
+				PunchAngle: protos.IntCoord_builder{}.Build(),
+				Velocity:   protos.IntCoord_builder{}.Build(),
+			}.Build(), tc.pcol, tc.flags, &m)
+
+			sm, err := ParseServerMessage(net.NewQReader(m.Bytes()), tc.pcol, tc.flags)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cmds := sm.GetCmds()
+			if len(cmds) != 5 {
+				t.Fatalf("got %d commands, want 5", len(cmds))
+			}
+			if got := cmds[0].GetSpawnBaseline(); got.GetIndex() != 5 || got.GetBaseline().GetModelIndex() != tc.index {
+				t.Errorf("baseline: got entity %d, model %d", got.GetIndex(), got.GetBaseline().GetModelIndex())
+			}
+			if got := cmds[1].GetSpawnStatic(); got.GetModelIndex() != tc.index || got.GetFrame() != 3 ||
+				got.GetOrigin().GetZ() != 3 {
+				t.Errorf("static: got %v", got)
+			}
+			if got := cmds[2].GetSpawnStaticSound(); got.GetIndex() != tc.index || got.GetAttenuation() != 64 {
+				t.Errorf("static sound: got %v", got)
+			}
+			// the parser returns the index into the precache list without the empty entry 0
+			if got := cmds[3].GetSound(); got.GetSoundNum() != tc.index-1 || got.GetChannel() != 2 {
+				t.Errorf("sound: got %v", got)
+			}
+			if got := cmds[4].GetClientData(); got.GetWeapon() != tc.index {
+				t.Errorf("client data: got weapon %d", got.GetWeapon())
+			}
+		})
+	}
+}
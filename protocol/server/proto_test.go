@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"testing"
+
+	"goquake/math/vec"
+	"goquake/net"
+	"goquake/protocol"
+	"goquake/protos"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// floatFlags make the byte encoding lossless to compare it with the commands.
+const floatFlags = protocol.PRFL_FLOATCOORD | protocol.PRFL_FLOATANGLE
+
+func parseProto(t *testing.T, m *Message, flags uint32) *protos.ServerMessage {
+	t.Helper()
+	b, err := m.Proto()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b[0] != ProtoMessage {
+		t.Fatalf("message starts with %d, want %d", b[0], ProtoMessage)
+	}
+	sm, err := ParseServerMessage(net.NewQReader(b), protocol.GoQuake, flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sm
+}
+
+func checkProto(t *testing.T, m *Message) {
+	t.Helper()
+	want, err := ParseServerMessage(net.NewQReader(m.Bytes()), protocol.GoQuake, floatFlags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := parseProto(t, m, floatFlags); !proto.Equal(got, want) {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+}
+
+func TestProtoMessage(t *testing.T) {
+	const pcol = protocol.GoQuake
+	origin := protos.Coord_builder{X: 1.5, Y: -2, Z: 300.25}.Build()
+	var m Message
+	WriteTime(12.5, pcol, floatFlags, &m)
+	WriteSetAngle(protos.Coord_builder{X: 0.5, Y: 123.25}.Build(), pcol, floatFlags, &m)
+	WritePrint("hello\n", &m)
+	// quake text which is no valid UTF-8
+	WritePrint("\x80\x81\n", &m)
+	WriteSound(protos.Sound_builder{Entity: 1, Channel: 2, SoundNum: 3, Origin: origin}.Build(), pcol, floatFlags, &m)
+	WriteParticle(protos.Particle_builder{
+		Origin:    origin,
+		Direction: protos.Coord_builder{X: 1, Y: -0.5, Z: 0.0625}.Build(),
+		Count:     255,
+		Color:     73,
+	}.Build(), floatFlags, &m)
+	// raw bytes written by QuakeC
+	m.WriteByte(KilledMonster)
+	m.WriteByte(FoundSecret)
+	WriteUpdateStat(protos.UpdateStat_builder{Stat: 13, Value: 5}.Build(), &m)
+	WriteLightStyle(protos.LightStyle_builder{Idx: 3, NewStyle: "az"}.Build(), &m)
+	WriteCDTrack(protos.CDTrack_builder{TrackNumber: 2, LoopTrack: 2}.Build(), &m)
+	WriteSetView(7, &m)
+	WriteSignonNum(1, &m)
+	WriteClientData(protos.ClientData_builder{
+		Weapon:     3,
+		Health:     100,
+		PunchAngle: protos.IntCoord_builder{X: -2}.Build(),
+		Velocity:   protos.IntCoord_builder{Z: 4}.Build(),
+	}.Build(), pcol, floatFlags, &m)
+	WritePacketEntities(&EntityFrame{Number: 1, Entities: []EntityState{
+		{Number: 1, ModelIndex: 2, Origin: vec.Vec3{1, 2, 3}},
+	}}, nil, floatFlags, &m)
+	WritePlayerState(protos.PlayerState_builder{
+		Sequence: 9,
+		Origin:   origin,
+		Velocity: protos.Coord_builder{Z: -10}.Build(),
+		MoveType: 3,
+		Params: protos.MoveParams_builder{
+			Accelerate: 10,
+			MaxSpeed:   400,
+			Gravity:    100,
+			AltNoclip:  true,
+		}.Build(),
+	}.Build(), pcol, floatFlags, &m)
+	m.WriteByte(Intermission)
+	checkProto(t, &m)
+
+	var legacy int
+	for _, c := range m.cmds {
+		if c.HasLegacy() {
+			legacy++
+		}
+	}
+	// the non UTF-8 print, the QuakeC bytes and the trailing intermission
+	if legacy != 3 {
+		t.Errorf("got %d legacy commands, want 3", legacy)
+	}
+}
+
+func TestProtoMessagePrecision(t *testing.T) {
+	var m Message
+	WriteSetAngle(protos.Coord_builder{X: 0.5, Y: 123.25}.Build(), protocol.GoQuake, 0, &m)
+	sm := parseProto(t, &m, 0)
+	if a := sm.GetCmds()[0].GetSetAngle(); a.GetX() != 0.5 || a.GetY() != 123.25 {
+		t.Errorf("angle = %v, want full precision", a)
+	}
+}
+
+func TestMessageAppend(t *testing.T) {
+	var a, b Message
+	WriteTime(1, protocol.GoQuake, floatFlags, &a)
+	a.WriteByte(BF)
+	b.WriteByte(SellScreen)
+	WriteSetPause(true, protocol.GoQuake, floatFlags, &b)
+	b.WriteByte(Intermission)
+	a.Append(&b)
+	WriteNop(&a)
+	checkProto(t, &a)
+
+	a.Reset()
+	WriteDisconnect(&a)
+	if sm := parseProto(t, &a, floatFlags); len(sm.GetCmds()) != 1 || !sm.GetCmds()[0].GetDisconnect() {
+		t.Errorf("got %v after Reset, want a single disconnect", sm)
+	}
+}
+
+func TestProtoMessageBroken(t *testing.T) {
+	var m Message
+	WriteTime(1, protocol.GoQuake, floatFlags, &m)
+	// a truncated command written by QuakeC
+	m.WriteByte(Time)
+	m.WriteByte(1)
+	b, err := m.Proto()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseServerMessage(net.NewQReader(b), protocol.GoQuake, floatFlags); err == nil {
+		t.Error("parsed a truncated legacy command")
+	}
+}
@@ -87,12 +87,30 @@ const (
 
 	// Used by 2021 release
 	Achievement = 52
+
+	// GOQUAKE
+
+	// [short] length [bytes] payload, handed to CSQC_Parse_Event
+	CSQCEvent = 53
+	// [long] last move sequence [float3] origin [float3] velocity [long] flags [byte] movetype
+	// [float6] accelerate friction edgefriction stopspeed maxspeed gravity [byte] move switches
+	PlayerState = 54
+	// [long] frame [long] delta frame <entity deltas> [long] 0
+	PacketEntities = 55
+	// [bytes] serialized protos.ServerMessage, the rest of the message
+	ProtoMessage = 56
 )
 
 const (
 	MaxClientStats = 32
 )
 
+// switches of the movement parameters in PlayerState
+const (
+	moveNoStep = 1 << iota
+	moveAltNoClip
+)
+
 const (
 	StatHealth = iota
 	StatFrags
@@ -122,6 +140,8 @@ const (
 	SoundLooping     = 1 << iota
 	SoundLargeEntity = 1 << iota // fitzquake
 	SoundLargeSound  = 1 << iota // fitzquake
+	SoundLongEntity  = 1 << iota // goquake, 32-bit entity number
+	SoundLongSound   = 1 << iota // goquake, 32-bit sound number
 )
 
 const (
@@ -142,6 +162,7 @@ const (
 	EntityBaselineLargeModel = (1 << iota) // modelindex is 16bit
 	EntityBaselineLargeFrame               // frame in 16bit
 	EntityBaselineAlpha                    // uses ENTALPHA_ENCODE
+	EntityBaselineLongModel                // goquake, modelindex is 32bit
 )
 
 const (
@@ -181,7 +202,7 @@ const (
 	SU_EXTEND2      = (1 << iota) // another byte to follow
 	SU_WEAPONFRAME2 = (1 << iota) // 1 byte, this is .weaponframe & 0xFF00 (second byte)
 	SU_WEAPONALPHA  = (1 << iota) // 1 byte, this is alpha for weaponmodel, uses ENTALPHA_ENCODE, not sent if ENTALPHA_DEFAULT
-	SU_UNUSED26     = (1 << iota)
+	SU_WEAPON3      = (1 << iota) // goquake, 2 bytes, this is .weaponmodel >> 16
 	SU_UNUSED27     = (1 << iota)
 	SU_UNUSED28     = (1 << iota)
 	SU_UNUSED29     = (1 << iota)
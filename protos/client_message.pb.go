@@ -34,6 +34,8 @@ type UsrCmd struct {
 	xxx_hidden_Attack      bool                   `protobuf:"varint,8,opt,name=attack" json:"attack,omitempty"`
 	xxx_hidden_Jump        bool                   `protobuf:"varint,9,opt,name=jump" json:"jump,omitempty"`
 	xxx_hidden_Impulse     int32                  `protobuf:"varint,10,opt,name=impulse" json:"impulse,omitempty"`
+	xxx_hidden_Sequence    uint32                 `protobuf:"varint,11,opt,name=sequence" json:"sequence,omitempty"`
+	xxx_hidden_EntityFrame uint32                 `protobuf:"varint,12,opt,name=entity_frame,json=entityFrame" json:"entity_frame,omitempty"`
 	unknownFields          protoimpl.UnknownFields
 	sizeCache              protoimpl.SizeCache
 }
@@ -133,6 +135,20 @@ func (x *UsrCmd) GetImpulse() int32 {
 	return 0
 }
 
+func (x *UsrCmd) GetSequence() uint32 {
+	if x != nil {
+		return x.xxx_hidden_Sequence
+	}
+	return 0
+}
+
+func (x *UsrCmd) GetEntityFrame() uint32 {
+	if x != nil {
+		return x.xxx_hidden_EntityFrame
+	}
+	return 0
+}
+
 func (x *UsrCmd) SetMessageTime(v float32) {
 	x.xxx_hidden_MessageTime = v
 }
@@ -173,6 +189,14 @@ func (x *UsrCmd) SetImpulse(v int32) {
 	x.xxx_hidden_Impulse = v
 }
 
+func (x *UsrCmd) SetSequence(v uint32) {
+	x.xxx_hidden_Sequence = v
+}
+
+func (x *UsrCmd) SetEntityFrame(v uint32) {
+	x.xxx_hidden_EntityFrame = v
+}
+
 type UsrCmd_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
@@ -186,6 +210,8 @@ type UsrCmd_builder struct {
 	Attack      bool
 	Jump        bool
 	Impulse     int32
+	Sequence    uint32
+	EntityFrame uint32
 }
 
 func (b0 UsrCmd_builder) Build() *UsrCmd {
@@ -202,6 +228,8 @@ func (b0 UsrCmd_builder) Build() *UsrCmd {
 	x.xxx_hidden_Attack = b.Attack
 	x.xxx_hidden_Jump = b.Jump
 	x.xxx_hidden_Impulse = b.Impulse
+	x.xxx_hidden_Sequence = b.Sequence
+	x.xxx_hidden_EntityFrame = b.EntityFrame
 	return m0
 }
 
@@ -264,6 +292,15 @@ func (x *Cmd) GetMoveCmd() *UsrCmd {
 	return nil
 }
 
+func (x *Cmd) GetProtobuf() bool {
+	if x != nil {
+		if x, ok := x.xxx_hidden_Union.(*cmd_Protobuf); ok {
+			return x.Protobuf
+		}
+	}
+	return false
+}
+
 func (x *Cmd) SetDisconnect(v bool) {
 	x.xxx_hidden_Union = &cmd_Disconnect{v}
 }
@@ -280,6 +317,10 @@ func (x *Cmd) SetMoveCmd(v *UsrCmd) {
 	x.xxx_hidden_Union = &cmd_MoveCmd{v}
 }
 
+func (x *Cmd) SetProtobuf(v bool) {
+	x.xxx_hidden_Union = &cmd_Protobuf{v}
+}
+
 func (x *Cmd) HasUnion() bool {
 	if x == nil {
 		return false
@@ -311,6 +352,14 @@ func (x *Cmd) HasMoveCmd() bool {
 	return ok
 }
 
+func (x *Cmd) HasProtobuf() bool {
+	if x == nil {
+		return false
+	}
+	_, ok := x.xxx_hidden_Union.(*cmd_Protobuf)
+	return ok
+}
+
 func (x *Cmd) ClearUnion() {
 	x.xxx_hidden_Union = nil
 }
@@ -333,10 +382,17 @@ func (x *Cmd) ClearMoveCmd() {
 	}
 }
 
+func (x *Cmd) ClearProtobuf() {
+	if _, ok := x.xxx_hidden_Union.(*cmd_Protobuf); ok {
+		x.xxx_hidden_Union = nil
+	}
+}
+
 const Cmd_Union_not_set_case case_Cmd_Union = 0
 const Cmd_Disconnect_case case_Cmd_Union = 1
 const Cmd_StringCmd_case case_Cmd_Union = 2
 const Cmd_MoveCmd_case case_Cmd_Union = 3
+const Cmd_Protobuf_case case_Cmd_Union = 4
 
 func (x *Cmd) WhichUnion() case_Cmd_Union {
 	if x == nil {
@@ -349,6 +405,8 @@ func (x *Cmd) WhichUnion() case_Cmd_Union {
 		return Cmd_StringCmd_case
 	case *cmd_MoveCmd:
 		return Cmd_MoveCmd_case
+	case *cmd_Protobuf:
+		return Cmd_Protobuf_case
 	default:
 		return Cmd_Union_not_set_case
 	}
@@ -361,6 +419,7 @@ type Cmd_builder struct {
 	Disconnect *bool
 	StringCmd  *string
 	MoveCmd    *UsrCmd
+	Protobuf   *bool
 	// -- end of xxx_hidden_Union
 }
 
@@ -377,6 +436,9 @@ func (b0 Cmd_builder) Build() *Cmd {
 	if b.MoveCmd != nil {
 		x.xxx_hidden_Union = &cmd_MoveCmd{b.MoveCmd}
 	}
+	if b.Protobuf != nil {
+		x.xxx_hidden_Union = &cmd_Protobuf{*b.Protobuf}
+	}
 	return m0
 }
 
@@ -406,12 +468,18 @@ type cmd_MoveCmd struct {
 	MoveCmd *UsrCmd `protobuf:"bytes,3,opt,name=move_cmd,json=moveCmd,oneof"`
 }
 
+type cmd_Protobuf struct {
+	Protobuf bool `protobuf:"varint,4,opt,name=protobuf,oneof"` // GoQuake only, requests ProtoMessage server messages
+}
+
 func (*cmd_Disconnect) isCmd_Union() {}
 
 func (*cmd_StringCmd) isCmd_Union() {}
 
 func (*cmd_MoveCmd) isCmd_Union() {}
 
+func (*cmd_Protobuf) isCmd_Union() {}
+
 type ClientMessage struct {
 	state           protoimpl.MessageState `protogen:"opaque.v1"`
 	xxx_hidden_Cmds *[]*Cmd                `protobuf:"bytes,1,rep,name=cmds" json:"cmds,omitempty"`
@@ -478,7 +546,7 @@ var file_client_message_proto_rawDesc = []byte{
 	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x1a, 0x21,
 	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f,
 	0x67, 0x6f, 0x5f, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x22, 0xeb, 0x01, 0x0a, 0x06, 0x55, 0x73, 0x72, 0x43, 0x6d, 0x64, 0x12, 0x21, 0x0a, 0x0c,
+	0x6f, 0x22, 0xaa, 0x02, 0x0a, 0x06, 0x55, 0x73, 0x72, 0x43, 0x6d, 0x64, 0x12, 0x21, 0x0a, 0x0c,
 	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01,
 	0x28, 0x02, 0x52, 0x0b, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x12,
 	0x14, 0x0a, 0x05, 0x70, 0x69, 0x74, 0x63, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x02, 0x52, 0x05,
@@ -492,23 +560,29 @@ var file_client_message_proto_rawDesc = []byte{
 	0x61, 0x63, 0x6b, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x61, 0x74, 0x74, 0x61, 0x63,
 	0x6b, 0x12, 0x12, 0x0a, 0x04, 0x6a, 0x75, 0x6d, 0x70, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52,
 	0x04, 0x6a, 0x75, 0x6d, 0x70, 0x12, 0x18, 0x0a, 0x07, 0x69, 0x6d, 0x70, 0x75, 0x6c, 0x73, 0x65,
-	0x18, 0x0a, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x69, 0x6d, 0x70, 0x75, 0x6c, 0x73, 0x65, 0x22,
-	0x7e, 0x0a, 0x03, 0x43, 0x6d, 0x64, 0x12, 0x20, 0x0a, 0x0a, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x6e,
+	0x18, 0x0a, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x69, 0x6d, 0x70, 0x75, 0x6c, 0x73, 0x65, 0x12,
+	0x1a, 0x0a, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x65,
+	0x6e, 0x74, 0x69, 0x74, 0x79, 0x5f, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x0b, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x22, 0x9c,
+	0x01, 0x0a, 0x03, 0x43, 0x6d, 0x64, 0x12, 0x20, 0x0a, 0x0a, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x6e,
 	0x6e, 0x65, 0x63, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x0a, 0x64, 0x69,
 	0x73, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x12, 0x1f, 0x0a, 0x0a, 0x73, 0x74, 0x72, 0x69,
 	0x6e, 0x67, 0x5f, 0x63, 0x6d, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x09,
 	0x73, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x43, 0x6d, 0x64, 0x12, 0x2b, 0x0a, 0x08, 0x6d, 0x6f, 0x76,
 	0x65, 0x5f, 0x63, 0x6d, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x70, 0x72,
 	0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x55, 0x73, 0x72, 0x43, 0x6d, 0x64, 0x48, 0x00, 0x52, 0x07, 0x6d,
-	0x6f, 0x76, 0x65, 0x43, 0x6d, 0x64, 0x42, 0x07, 0x0a, 0x05, 0x75, 0x6e, 0x69, 0x6f, 0x6e, 0x22,
-	0x30, 0x0a, 0x0d, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
-	0x12, 0x1f, 0x0a, 0x04, 0x63, 0x6d, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0b,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x43, 0x6d, 0x64, 0x52, 0x04, 0x63, 0x6d, 0x64,
-	0x73, 0x42, 0x33, 0x5a, 0x21, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
-	0x74, 0x68, 0x65, 0x72, 0x6a, 0x61, 0x6b, 0x2f, 0x67, 0x6f, 0x71, 0x75, 0x61, 0x6b, 0x65, 0x2f,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x92, 0x03, 0x0d, 0xd2, 0x3e, 0x02, 0x10, 0x03, 0x08, 0x02,
-	0x10, 0x01, 0x20, 0x02, 0x30, 0x01, 0x62, 0x08, 0x65, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73,
-	0x70, 0xe8, 0x07,
+	0x6f, 0x76, 0x65, 0x43, 0x6d, 0x64, 0x12, 0x1c, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x42, 0x07, 0x0a, 0x05, 0x75, 0x6e, 0x69, 0x6f, 0x6e, 0x22, 0x30, 0x0a,
+	0x0d, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1f,
+	0x0a, 0x04, 0x63, 0x6d, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x43, 0x6d, 0x64, 0x52, 0x04, 0x63, 0x6d, 0x64, 0x73, 0x42,
+	0x33, 0x5a, 0x21, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x74, 0x68,
+	0x65, 0x72, 0x6a, 0x61, 0x6b, 0x2f, 0x67, 0x6f, 0x71, 0x75, 0x61, 0x6b, 0x65, 0x2f, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x73, 0x92, 0x03, 0x0d, 0xd2, 0x3e, 0x02, 0x10, 0x03, 0x08, 0x02, 0x10, 0x01,
+	0x20, 0x02, 0x30, 0x01, 0x62, 0x08, 0x65, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x70, 0xe8,
+	0x07,
 }
 
 var file_client_message_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
@@ -536,6 +610,7 @@ func file_client_message_proto_init() {
 		(*cmd_Disconnect)(nil),
 		(*cmd_StringCmd)(nil),
 		(*cmd_MoveCmd)(nil),
+		(*cmd_Protobuf)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
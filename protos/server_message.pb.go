@@ -2497,6 +2497,7 @@ func (x *EntityUpdate) SetSkin(v int32) {
 
 func (x *EntityUpdate) SetEffects(v int32) {
 	x.xxx_hidden_Effects = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 6, 15)
 }
 
 func (x *EntityUpdate) SetOriginX(v float32) {
@@ -2567,6 +2568,13 @@ func (x *EntityUpdate) HasSkin() bool {
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 5)
 }
 
+func (x *EntityUpdate) HasEffects() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 6)
+}
+
 func (x *EntityUpdate) HasOriginX() bool {
 	if x == nil {
 		return false
@@ -2643,6 +2651,11 @@ func (x *EntityUpdate) ClearSkin() {
 	x.xxx_hidden_Skin = 0
 }
 
+func (x *EntityUpdate) ClearEffects() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 6)
+	x.xxx_hidden_Effects = 0
+}
+
 func (x *EntityUpdate) ClearOriginX() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 7)
 	x.xxx_hidden_OriginX = 0
@@ -2692,7 +2705,7 @@ type EntityUpdate_builder struct {
 	Frame        *int32
 	ColorMap     *int32
 	Skin         *int32
-	Effects      int32
+	Effects      *int32
 	OriginX      *float32
 	OriginY      *float32
 	OriginZ      *float32
@@ -2725,7 +2738,10 @@ func (b0 EntityUpdate_builder) Build() *EntityUpdate {
 		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 5, 15)
 		x.xxx_hidden_Skin = *b.Skin
 	}
-	x.xxx_hidden_Effects = b.Effects
+	if b.Effects != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 6, 15)
+		x.xxx_hidden_Effects = *b.Effects
+	}
 	if b.OriginX != nil {
 		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 7, 15)
 		x.xxx_hidden_OriginX = *b.OriginX
@@ -3343,6 +3359,424 @@ func (b0 Fog_builder) Build() *Fog {
 	return m0
 }
 
+type PlayerState struct {
+	state               protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Sequence uint32                 `protobuf:"varint,1,opt,name=sequence" json:"sequence,omitempty"`
+	xxx_hidden_Origin   *Coord                 `protobuf:"bytes,2,opt,name=origin" json:"origin,omitempty"`
+	xxx_hidden_Velocity *Coord                 `protobuf:"bytes,3,opt,name=velocity" json:"velocity,omitempty"`
+	xxx_hidden_Flags    int32                  `protobuf:"varint,4,opt,name=flags" json:"flags,omitempty"`
+	xxx_hidden_MoveType int32                  `protobuf:"varint,5,opt,name=move_type,json=moveType" json:"move_type,omitempty"`
+	xxx_hidden_Params   *MoveParams            `protobuf:"bytes,6,opt,name=params" json:"params,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *PlayerState) Reset() {
+	*x = PlayerState{}
+	mi := &file_server_message_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlayerState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlayerState) ProtoMessage() {}
+
+func (x *PlayerState) ProtoReflect() protoreflect.Message {
+	mi := &file_server_message_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *PlayerState) GetSequence() uint32 {
+	if x != nil {
+		return x.xxx_hidden_Sequence
+	}
+	return 0
+}
+
+func (x *PlayerState) GetOrigin() *Coord {
+	if x != nil {
+		return x.xxx_hidden_Origin
+	}
+	return nil
+}
+
+func (x *PlayerState) GetVelocity() *Coord {
+	if x != nil {
+		return x.xxx_hidden_Velocity
+	}
+	return nil
+}
+
+func (x *PlayerState) GetFlags() int32 {
+	if x != nil {
+		return x.xxx_hidden_Flags
+	}
+	return 0
+}
+
+func (x *PlayerState) GetMoveType() int32 {
+	if x != nil {
+		return x.xxx_hidden_MoveType
+	}
+	return 0
+}
+
+func (x *PlayerState) GetParams() *MoveParams {
+	if x != nil {
+		return x.xxx_hidden_Params
+	}
+	return nil
+}
+
+func (x *PlayerState) SetSequence(v uint32) {
+	x.xxx_hidden_Sequence = v
+}
+
+func (x *PlayerState) SetOrigin(v *Coord) {
+	x.xxx_hidden_Origin = v
+}
+
+func (x *PlayerState) SetVelocity(v *Coord) {
+	x.xxx_hidden_Velocity = v
+}
+
+func (x *PlayerState) SetFlags(v int32) {
+	x.xxx_hidden_Flags = v
+}
+
+func (x *PlayerState) SetMoveType(v int32) {
+	x.xxx_hidden_MoveType = v
+}
+
+func (x *PlayerState) SetParams(v *MoveParams) {
+	x.xxx_hidden_Params = v
+}
+
+func (x *PlayerState) HasOrigin() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_Origin != nil
+}
+
+func (x *PlayerState) HasVelocity() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_Velocity != nil
+}
+
+func (x *PlayerState) HasParams() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_Params != nil
+}
+
+func (x *PlayerState) ClearOrigin() {
+	x.xxx_hidden_Origin = nil
+}
+
+func (x *PlayerState) ClearVelocity() {
+	x.xxx_hidden_Velocity = nil
+}
+
+func (x *PlayerState) ClearParams() {
+	x.xxx_hidden_Params = nil
+}
+
+type PlayerState_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Sequence uint32
+	Origin   *Coord
+	Velocity *Coord
+	Flags    int32
+	MoveType int32
+	Params   *MoveParams
+}
+
+func (b0 PlayerState_builder) Build() *PlayerState {
+	m0 := &PlayerState{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Sequence = b.Sequence
+	x.xxx_hidden_Origin = b.Origin
+	x.xxx_hidden_Velocity = b.Velocity
+	x.xxx_hidden_Flags = b.Flags
+	x.xxx_hidden_MoveType = b.MoveType
+	x.xxx_hidden_Params = b.Params
+	return m0
+}
+
+// physics settings of the server the client predicts the movement with
+type MoveParams struct {
+	state                   protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Accelerate   float32                `protobuf:"fixed32,1,opt,name=accelerate" json:"accelerate,omitempty"`
+	xxx_hidden_Friction     float32                `protobuf:"fixed32,2,opt,name=friction" json:"friction,omitempty"`
+	xxx_hidden_EdgeFriction float32                `protobuf:"fixed32,3,opt,name=edge_friction,json=edgeFriction" json:"edge_friction,omitempty"`
+	xxx_hidden_StopSpeed    float32                `protobuf:"fixed32,4,opt,name=stop_speed,json=stopSpeed" json:"stop_speed,omitempty"`
+	xxx_hidden_MaxSpeed     float32                `protobuf:"fixed32,5,opt,name=max_speed,json=maxSpeed" json:"max_speed,omitempty"`
+	xxx_hidden_Gravity      float32                `protobuf:"fixed32,6,opt,name=gravity" json:"gravity,omitempty"`
+	xxx_hidden_NoStep       bool                   `protobuf:"varint,7,opt,name=no_step,json=noStep" json:"no_step,omitempty"`
+	xxx_hidden_AltNoclip    bool                   `protobuf:"varint,8,opt,name=alt_noclip,json=altNoclip" json:"alt_noclip,omitempty"`
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
+}
+
+func (x *MoveParams) Reset() {
+	*x = MoveParams{}
+	mi := &file_server_message_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MoveParams) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MoveParams) ProtoMessage() {}
+
+func (x *MoveParams) ProtoReflect() protoreflect.Message {
+	mi := &file_server_message_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *MoveParams) GetAccelerate() float32 {
+	if x != nil {
+		return x.xxx_hidden_Accelerate
+	}
+	return 0
+}
+
+func (x *MoveParams) GetFriction() float32 {
+	if x != nil {
+		return x.xxx_hidden_Friction
+	}
+	return 0
+}
+
+func (x *MoveParams) GetEdgeFriction() float32 {
+	if x != nil {
+		return x.xxx_hidden_EdgeFriction
+	}
+	return 0
+}
+
+func (x *MoveParams) GetStopSpeed() float32 {
+	if x != nil {
+		return x.xxx_hidden_StopSpeed
+	}
+	return 0
+}
+
+func (x *MoveParams) GetMaxSpeed() float32 {
+	if x != nil {
+		return x.xxx_hidden_MaxSpeed
+	}
+	return 0
+}
+
+func (x *MoveParams) GetGravity() float32 {
+	if x != nil {
+		return x.xxx_hidden_Gravity
+	}
+	return 0
+}
+
+func (x *MoveParams) GetNoStep() bool {
+	if x != nil {
+		return x.xxx_hidden_NoStep
+	}
+	return false
+}
+
+func (x *MoveParams) GetAltNoclip() bool {
+	if x != nil {
+		return x.xxx_hidden_AltNoclip
+	}
+	return false
+}
+
+func (x *MoveParams) SetAccelerate(v float32) {
+	x.xxx_hidden_Accelerate = v
+}
+
+func (x *MoveParams) SetFriction(v float32) {
+	x.xxx_hidden_Friction = v
+}
+
+func (x *MoveParams) SetEdgeFriction(v float32) {
+	x.xxx_hidden_EdgeFriction = v
+}
+
+func (x *MoveParams) SetStopSpeed(v float32) {
+	x.xxx_hidden_StopSpeed = v
+}
+
+func (x *MoveParams) SetMaxSpeed(v float32) {
+	x.xxx_hidden_MaxSpeed = v
+}
+
+func (x *MoveParams) SetGravity(v float32) {
+	x.xxx_hidden_Gravity = v
+}
+
+func (x *MoveParams) SetNoStep(v bool) {
+	x.xxx_hidden_NoStep = v
+}
+
+func (x *MoveParams) SetAltNoclip(v bool) {
+	x.xxx_hidden_AltNoclip = v
+}
+
+type MoveParams_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Accelerate   float32
+	Friction     float32
+	EdgeFriction float32
+	StopSpeed    float32
+	MaxSpeed     float32
+	Gravity      float32
+	NoStep       bool
+	AltNoclip    bool
+}
+
+func (b0 MoveParams_builder) Build() *MoveParams {
+	m0 := &MoveParams{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Accelerate = b.Accelerate
+	x.xxx_hidden_Friction = b.Friction
+	x.xxx_hidden_EdgeFriction = b.EdgeFriction
+	x.xxx_hidden_StopSpeed = b.StopSpeed
+	x.xxx_hidden_MaxSpeed = b.MaxSpeed
+	x.xxx_hidden_Gravity = b.Gravity
+	x.xxx_hidden_NoStep = b.NoStep
+	x.xxx_hidden_AltNoclip = b.AltNoclip
+	return m0
+}
+
+// delta of the visible entities against an acknowledged frame
+type PacketEntities struct {
+	state                 protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Frame      uint32                 `protobuf:"varint,1,opt,name=frame" json:"frame,omitempty"`
+	xxx_hidden_DeltaFrame uint32                 `protobuf:"varint,2,opt,name=delta_frame,json=deltaFrame" json:"delta_frame,omitempty"`
+	xxx_hidden_Entities   *[]*EntityUpdate       `protobuf:"bytes,3,rep,name=entities" json:"entities,omitempty"`
+	xxx_hidden_Removed    []int32                `protobuf:"varint,4,rep,packed,name=removed" json:"removed,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *PacketEntities) Reset() {
+	*x = PacketEntities{}
+	mi := &file_server_message_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PacketEntities) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PacketEntities) ProtoMessage() {}
+
+func (x *PacketEntities) ProtoReflect() protoreflect.Message {
+	mi := &file_server_message_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *PacketEntities) GetFrame() uint32 {
+	if x != nil {
+		return x.xxx_hidden_Frame
+	}
+	return 0
+}
+
+func (x *PacketEntities) GetDeltaFrame() uint32 {
+	if x != nil {
+		return x.xxx_hidden_DeltaFrame
+	}
+	return 0
+}
+
+func (x *PacketEntities) GetEntities() []*EntityUpdate {
+	if x != nil {
+		if x.xxx_hidden_Entities != nil {
+			return *x.xxx_hidden_Entities
+		}
+	}
+	return nil
+}
+
+func (x *PacketEntities) GetRemoved() []int32 {
+	if x != nil {
+		return x.xxx_hidden_Removed
+	}
+	return nil
+}
+
+func (x *PacketEntities) SetFrame(v uint32) {
+	x.xxx_hidden_Frame = v
+}
+
+func (x *PacketEntities) SetDeltaFrame(v uint32) {
+	x.xxx_hidden_DeltaFrame = v
+}
+
+func (x *PacketEntities) SetEntities(v []*EntityUpdate) {
+	x.xxx_hidden_Entities = &v
+}
+
+func (x *PacketEntities) SetRemoved(v []int32) {
+	x.xxx_hidden_Removed = v
+}
+
+type PacketEntities_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Frame      uint32
+	DeltaFrame uint32
+	Entities   []*EntityUpdate
+	Removed    []int32
+}
+
+func (b0 PacketEntities_builder) Build() *PacketEntities {
+	m0 := &PacketEntities{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Frame = b.Frame
+	x.xxx_hidden_DeltaFrame = b.DeltaFrame
+	x.xxx_hidden_Entities = &b.Entities
+	x.xxx_hidden_Removed = b.Removed
+	return m0
+}
+
 type SCmd struct {
 	state            protoimpl.MessageState `protogen:"opaque.v1"`
 	xxx_hidden_Union isSCmd_Union           `protobuf_oneof:"union"`
@@ -3352,7 +3786,7 @@ type SCmd struct {
 
 func (x *SCmd) Reset() {
 	*x = SCmd{}
-	mi := &file_server_message_proto_msgTypes[22]
+	mi := &file_server_message_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3364,7 +3798,7 @@ func (x *SCmd) String() string {
 func (*SCmd) ProtoMessage() {}
 
 func (x *SCmd) ProtoReflect() protoreflect.Message {
-	mi := &file_server_message_proto_msgTypes[22]
+	mi := &file_server_message_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3708,6 +4142,42 @@ func (x *SCmd) GetAchievement() string {
 	return ""
 }
 
+func (x *SCmd) GetCsqcEvent() []byte {
+	if x != nil {
+		if x, ok := x.xxx_hidden_Union.(*sCmd_CsqcEvent); ok {
+			return x.CsqcEvent
+		}
+	}
+	return nil
+}
+
+func (x *SCmd) GetPlayerState() *PlayerState {
+	if x != nil {
+		if x, ok := x.xxx_hidden_Union.(*sCmd_PlayerState); ok {
+			return x.PlayerState
+		}
+	}
+	return nil
+}
+
+func (x *SCmd) GetPacketEntities() *PacketEntities {
+	if x != nil {
+		if x, ok := x.xxx_hidden_Union.(*sCmd_PacketEntities); ok {
+			return x.PacketEntities
+		}
+	}
+	return nil
+}
+
+func (x *SCmd) GetLegacy() []byte {
+	if x != nil {
+		if x, ok := x.xxx_hidden_Union.(*sCmd_Legacy); ok {
+			return x.Legacy
+		}
+	}
+	return nil
+}
+
 func (x *SCmd) SetDisconnect(v bool) {
 	x.xxx_hidden_Union = &sCmd_Disconnect{v}
 }
@@ -3948,6 +4418,36 @@ func (x *SCmd) SetAchievement(v string) {
 	x.xxx_hidden_Union = &sCmd_Achievement{v}
 }
 
+func (x *SCmd) SetCsqcEvent(v []byte) {
+	if v == nil {
+		v = []byte{}
+	}
+	x.xxx_hidden_Union = &sCmd_CsqcEvent{v}
+}
+
+func (x *SCmd) SetPlayerState(v *PlayerState) {
+	if v == nil {
+		x.xxx_hidden_Union = nil
+		return
+	}
+	x.xxx_hidden_Union = &sCmd_PlayerState{v}
+}
+
+func (x *SCmd) SetPacketEntities(v *PacketEntities) {
+	if v == nil {
+		x.xxx_hidden_Union = nil
+		return
+	}
+	x.xxx_hidden_Union = &sCmd_PacketEntities{v}
+}
+
+func (x *SCmd) SetLegacy(v []byte) {
+	if v == nil {
+		v = []byte{}
+	}
+	x.xxx_hidden_Union = &sCmd_Legacy{v}
+}
+
 func (x *SCmd) HasUnion() bool {
 	if x == nil {
 		return false
@@ -4251,6 +4751,38 @@ func (x *SCmd) HasAchievement() bool {
 	return ok
 }
 
+func (x *SCmd) HasCsqcEvent() bool {
+	if x == nil {
+		return false
+	}
+	_, ok := x.xxx_hidden_Union.(*sCmd_CsqcEvent)
+	return ok
+}
+
+func (x *SCmd) HasPlayerState() bool {
+	if x == nil {
+		return false
+	}
+	_, ok := x.xxx_hidden_Union.(*sCmd_PlayerState)
+	return ok
+}
+
+func (x *SCmd) HasPacketEntities() bool {
+	if x == nil {
+		return false
+	}
+	_, ok := x.xxx_hidden_Union.(*sCmd_PacketEntities)
+	return ok
+}
+
+func (x *SCmd) HasLegacy() bool {
+	if x == nil {
+		return false
+	}
+	_, ok := x.xxx_hidden_Union.(*sCmd_Legacy)
+	return ok
+}
+
 func (x *SCmd) ClearUnion() {
 	x.xxx_hidden_Union = nil
 }
@@ -4477,6 +5009,30 @@ func (x *SCmd) ClearAchievement() {
 	}
 }
 
+func (x *SCmd) ClearCsqcEvent() {
+	if _, ok := x.xxx_hidden_Union.(*sCmd_CsqcEvent); ok {
+		x.xxx_hidden_Union = nil
+	}
+}
+
+func (x *SCmd) ClearPlayerState() {
+	if _, ok := x.xxx_hidden_Union.(*sCmd_PlayerState); ok {
+		x.xxx_hidden_Union = nil
+	}
+}
+
+func (x *SCmd) ClearPacketEntities() {
+	if _, ok := x.xxx_hidden_Union.(*sCmd_PacketEntities); ok {
+		x.xxx_hidden_Union = nil
+	}
+}
+
+func (x *SCmd) ClearLegacy() {
+	if _, ok := x.xxx_hidden_Union.(*sCmd_Legacy); ok {
+		x.xxx_hidden_Union = nil
+	}
+}
+
 const SCmd_Union_not_set_case case_SCmd_Union = 0
 const SCmd_Disconnect_case case_SCmd_Union = 2
 const SCmd_EntityUpdate_case case_SCmd_Union = 45
@@ -4515,6 +5071,10 @@ const SCmd_Skybox_case case_SCmd_Union = 37
 const SCmd_BackgroundFlash_case case_SCmd_Union = 40
 const SCmd_Fog_case case_SCmd_Union = 41
 const SCmd_Achievement_case case_SCmd_Union = 42
+const SCmd_CsqcEvent_case case_SCmd_Union = 46
+const SCmd_PlayerState_case case_SCmd_Union = 47
+const SCmd_PacketEntities_case case_SCmd_Union = 48
+const SCmd_Legacy_case case_SCmd_Union = 49
 
 func (x *SCmd) WhichUnion() case_SCmd_Union {
 	if x == nil {
@@ -4595,6 +5155,14 @@ func (x *SCmd) WhichUnion() case_SCmd_Union {
 		return SCmd_Fog_case
 	case *sCmd_Achievement:
 		return SCmd_Achievement_case
+	case *sCmd_CsqcEvent:
+		return SCmd_CsqcEvent_case
+	case *sCmd_PlayerState:
+		return SCmd_PlayerState_case
+	case *sCmd_PacketEntities:
+		return SCmd_PacketEntities_case
+	case *sCmd_Legacy:
+		return SCmd_Legacy_case
 	default:
 		return SCmd_Union_not_set_case
 	}
@@ -4644,7 +5212,11 @@ type SCmd_builder struct {
 	// EntityBaseline spawn_baseline2 = 42; -- not needed, covered by spawn_baseline
 	// Baseline spawn_static2 = 43; -- not needed, covered by spawn_static
 	// SpawnStaticSound2 spawn_static_sound2 = 44; -- not needed, covered by spawn_static_sound
-	Achievement *string
+	Achievement    *string
+	CsqcEvent      []byte
+	PlayerState    *PlayerState
+	PacketEntities *PacketEntities
+	Legacy         []byte
 	// -- end of xxx_hidden_Union
 }
 
@@ -4763,13 +5335,25 @@ func (b0 SCmd_builder) Build() *SCmd {
 	if b.Achievement != nil {
 		x.xxx_hidden_Union = &sCmd_Achievement{*b.Achievement}
 	}
+	if b.CsqcEvent != nil {
+		x.xxx_hidden_Union = &sCmd_CsqcEvent{b.CsqcEvent}
+	}
+	if b.PlayerState != nil {
+		x.xxx_hidden_Union = &sCmd_PlayerState{b.PlayerState}
+	}
+	if b.PacketEntities != nil {
+		x.xxx_hidden_Union = &sCmd_PacketEntities{b.PacketEntities}
+	}
+	if b.Legacy != nil {
+		x.xxx_hidden_Union = &sCmd_Legacy{b.Legacy}
+	}
 	return m0
 }
 
 type case_SCmd_Union protoreflect.FieldNumber
 
 func (x case_SCmd_Union) String() string {
-	md := file_server_message_proto_msgTypes[22].Descriptor()
+	md := file_server_message_proto_msgTypes[25].Descriptor()
 	if x == 0 {
 		return "not set"
 	}
@@ -4932,6 +5516,22 @@ type sCmd_Achievement struct {
 	Achievement string `protobuf:"bytes,42,opt,name=achievement,oneof"`
 }
 
+type sCmd_CsqcEvent struct {
+	CsqcEvent []byte `protobuf:"bytes,46,opt,name=csqc_event,json=csqcEvent,oneof"` // payload for CSQC_Parse_Event
+}
+
+type sCmd_PlayerState struct {
+	PlayerState *PlayerState `protobuf:"bytes,47,opt,name=player_state,json=playerState,oneof"`
+}
+
+type sCmd_PacketEntities struct {
+	PacketEntities *PacketEntities `protobuf:"bytes,48,opt,name=packet_entities,json=packetEntities,oneof"`
+}
+
+type sCmd_Legacy struct {
+	Legacy []byte `protobuf:"bytes,49,opt,name=legacy,oneof"` // byte encoded commands, e.g. written by QuakeC
+}
+
 func (*sCmd_Disconnect) isSCmd_Union() {}
 
 func (*sCmd_EntityUpdate) isSCmd_Union() {}
@@ -5006,6 +5606,14 @@ func (*sCmd_Fog) isSCmd_Union() {}
 
 func (*sCmd_Achievement) isSCmd_Union() {}
 
+func (*sCmd_CsqcEvent) isSCmd_Union() {}
+
+func (*sCmd_PlayerState) isSCmd_Union() {}
+
+func (*sCmd_PacketEntities) isSCmd_Union() {}
+
+func (*sCmd_Legacy) isSCmd_Union() {}
+
 type ServerMessage struct {
 	state           protoimpl.MessageState `protogen:"opaque.v1"`
 	xxx_hidden_Cmds *[]*SCmd               `protobuf:"bytes,1,rep,name=cmds" json:"cmds,omitempty"`
@@ -5015,7 +5623,7 @@ type ServerMessage struct {
 
 func (x *ServerMessage) Reset() {
 	*x = ServerMessage{}
-	mi := &file_server_message_proto_msgTypes[23]
+	mi := &file_server_message_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -5027,7 +5635,7 @@ func (x *ServerMessage) String() string {
 func (*ServerMessage) ProtoMessage() {}
 
 func (x *ServerMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_server_message_proto_msgTypes[23]
+	mi := &file_server_message_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -5233,7 +5841,7 @@ var file_server_message_proto_rawDesc = []byte{
 	0x64, 0x65, 0x6c, 0x50, 0x72, 0x65, 0x63, 0x61, 0x63, 0x68, 0x65, 0x12, 0x25, 0x0a, 0x0e, 0x73,
 	0x6f, 0x75, 0x6e, 0x64, 0x5f, 0x70, 0x72, 0x65, 0x63, 0x61, 0x63, 0x68, 0x65, 0x18, 0x07, 0x20,
 	0x03, 0x28, 0x09, 0x52, 0x0d, 0x73, 0x6f, 0x75, 0x6e, 0x64, 0x50, 0x72, 0x65, 0x63, 0x61, 0x63,
-	0x68, 0x65, 0x22, 0xea, 0x03, 0x0a, 0x0c, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x55, 0x70, 0x64,
+	0x68, 0x65, 0x22, 0xf1, 0x03, 0x0a, 0x0c, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x55, 0x70, 0x64,
 	0x61, 0x74, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x18, 0x01, 0x20,
 	0x01, 0x28, 0x05, 0x52, 0x06, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x12, 0x24, 0x0a, 0x0e, 0x6c,
 	0x65, 0x72, 0x70, 0x5f, 0x6d, 0x6f, 0x76, 0x65, 0x5f, 0x73, 0x74, 0x65, 0x70, 0x18, 0x02, 0x20,
@@ -5245,168 +5853,219 @@ var file_server_message_proto_rawDesc = []byte{
 	0x6f, 0x6c, 0x6f, 0x72, 0x5f, 0x6d, 0x61, 0x70, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x42, 0x05,
 	0xaa, 0x01, 0x02, 0x08, 0x01, 0x52, 0x08, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x4d, 0x61, 0x70, 0x12,
 	0x19, 0x0a, 0x04, 0x73, 0x6b, 0x69, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x42, 0x05, 0xaa,
-	0x01, 0x02, 0x08, 0x01, 0x52, 0x04, 0x73, 0x6b, 0x69, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x66,
-	0x66, 0x65, 0x63, 0x74, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x65, 0x66, 0x66,
-	0x65, 0x63, 0x74, 0x73, 0x12, 0x20, 0x0a, 0x08, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x5f, 0x78,
-	0x18, 0x08, 0x20, 0x01, 0x28, 0x02, 0x42, 0x05, 0xaa, 0x01, 0x02, 0x08, 0x01, 0x52, 0x07, 0x6f,
-	0x72, 0x69, 0x67, 0x69, 0x6e, 0x58, 0x12, 0x20, 0x0a, 0x08, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e,
-	0x5f, 0x79, 0x18, 0x09, 0x20, 0x01, 0x28, 0x02, 0x42, 0x05, 0xaa, 0x01, 0x02, 0x08, 0x01, 0x52,
-	0x07, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x59, 0x12, 0x20, 0x0a, 0x08, 0x6f, 0x72, 0x69, 0x67,
-	0x69, 0x6e, 0x5f, 0x7a, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x02, 0x42, 0x05, 0xaa, 0x01, 0x02, 0x08,
-	0x01, 0x52, 0x07, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x5a, 0x12, 0x1e, 0x0a, 0x07, 0x61, 0x6e,
-	0x67, 0x6c, 0x65, 0x5f, 0x78, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x02, 0x42, 0x05, 0xaa, 0x01, 0x02,
-	0x08, 0x01, 0x52, 0x06, 0x61, 0x6e, 0x67, 0x6c, 0x65, 0x58, 0x12, 0x1e, 0x0a, 0x07, 0x61, 0x6e,
-	0x67, 0x6c, 0x65, 0x5f, 0x79, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x02, 0x42, 0x05, 0xaa, 0x01, 0x02,
-	0x08, 0x01, 0x52, 0x06, 0x61, 0x6e, 0x67, 0x6c, 0x65, 0x59, 0x12, 0x1e, 0x0a, 0x07, 0x61, 0x6e,
-	0x67, 0x6c, 0x65, 0x5f, 0x7a, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x02, 0x42, 0x05, 0xaa, 0x01, 0x02,
-	0x08, 0x01, 0x52, 0x06, 0x61, 0x6e, 0x67, 0x6c, 0x65, 0x5a, 0x12, 0x26, 0x0a, 0x0b, 0x6c, 0x65,
-	0x72, 0x70, 0x5f, 0x66, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x05, 0x42,
-	0x05, 0xaa, 0x01, 0x02, 0x08, 0x01, 0x52, 0x0a, 0x6c, 0x65, 0x72, 0x70, 0x46, 0x69, 0x6e, 0x69,
-	0x73, 0x68, 0x12, 0x1b, 0x0a, 0x05, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x18, 0x0f, 0x20, 0x01, 0x28,
-	0x05, 0x42, 0x05, 0xaa, 0x01, 0x02, 0x08, 0x01, 0x52, 0x05, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x22,
-	0x84, 0x01, 0x0a, 0x0b, 0x53, 0x74, 0x61, 0x74, 0x69, 0x63, 0x53, 0x6f, 0x75, 0x6e, 0x64, 0x12,
-	0x25, 0x0a, 0x06, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x43, 0x6f, 0x6f, 0x72, 0x64, 0x52, 0x06,
-	0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x16, 0x0a, 0x06,
-	0x76, 0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x76, 0x6f,
-	0x6c, 0x75, 0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x61, 0x74, 0x74, 0x65, 0x6e, 0x75, 0x61, 0x74,
-	0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x61, 0x74, 0x74, 0x65, 0x6e,
-	0x75, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x5f, 0x0a, 0x06, 0x44, 0x61, 0x6d, 0x61, 0x67, 0x65,
-	0x12, 0x14, 0x0a, 0x05, 0x61, 0x72, 0x6d, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52,
-	0x05, 0x61, 0x72, 0x6d, 0x6f, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x6f, 0x64, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x62, 0x6c, 0x6f, 0x6f, 0x64, 0x12, 0x29, 0x0a, 0x08,
-	0x70, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x43, 0x6f, 0x6f, 0x72, 0x64, 0x52, 0x08, 0x70,
-	0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x4b, 0x0a, 0x07, 0x43, 0x44, 0x54, 0x72, 0x61,
-	0x63, 0x6b, 0x12, 0x21, 0x0a, 0x0c, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x5f, 0x6e, 0x75, 0x6d, 0x62,
-	0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x4e,
-	0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x1d, 0x0a, 0x0a, 0x6c, 0x6f, 0x6f, 0x70, 0x5f, 0x74, 0x72,
-	0x61, 0x63, 0x6b, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x6c, 0x6f, 0x6f, 0x70, 0x54,
-	0x72, 0x61, 0x63, 0x6b, 0x22, 0x36, 0x0a, 0x0a, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x53, 0x74,
-	0x61, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x74, 0x61, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05,
-	0x52, 0x04, 0x73, 0x74, 0x61, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x8a, 0x01, 0x0a,
-	0x08, 0x50, 0x61, 0x72, 0x74, 0x69, 0x63, 0x6c, 0x65, 0x12, 0x25, 0x0a, 0x06, 0x6f, 0x72, 0x69,
-	0x67, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x73, 0x2e, 0x43, 0x6f, 0x6f, 0x72, 0x64, 0x52, 0x06, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e,
-	0x12, 0x2b, 0x0a, 0x09, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x43, 0x6f, 0x6f,
-	0x72, 0x64, 0x52, 0x09, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a,
-	0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x63, 0x6f,
-	0x75, 0x6e, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x04, 0x20, 0x01,
-	0x28, 0x05, 0x52, 0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x22, 0x6f, 0x0a, 0x03, 0x46, 0x6f, 0x67,
-	0x12, 0x18, 0x0a, 0x07, 0x64, 0x65, 0x6e, 0x73, 0x69, 0x74, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x02, 0x52, 0x07, 0x64, 0x65, 0x6e, 0x73, 0x69, 0x74, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x72, 0x65,
-	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x02, 0x52, 0x03, 0x72, 0x65, 0x64, 0x12, 0x14, 0x0a, 0x05,
-	0x67, 0x72, 0x65, 0x65, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x02, 0x52, 0x05, 0x67, 0x72, 0x65,
-	0x65, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x62, 0x6c, 0x75, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x02,
-	0x52, 0x04, 0x62, 0x6c, 0x75, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x05,
-	0x20, 0x01, 0x28, 0x02, 0x52, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x22, 0x8a, 0x0d, 0x0a, 0x04, 0x53,
-	0x43, 0x6d, 0x64, 0x12, 0x20, 0x0a, 0x0a, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63,
-	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x0a, 0x64, 0x69, 0x73, 0x63, 0x6f,
-	0x6e, 0x6e, 0x65, 0x63, 0x74, 0x12, 0x3b, 0x0a, 0x0d, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x5f,
-	0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x18, 0x2d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x55, 0x70, 0x64, 0x61,
-	0x74, 0x65, 0x48, 0x00, 0x52, 0x0c, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x55, 0x70, 0x64, 0x61,
-	0x74, 0x65, 0x12, 0x35, 0x0a, 0x0b, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x5f, 0x73, 0x74, 0x61,
-	0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73,
-	0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x53, 0x74, 0x61, 0x74, 0x48, 0x00, 0x52, 0x0a, 0x75,
-	0x70, 0x64, 0x61, 0x74, 0x65, 0x53, 0x74, 0x61, 0x74, 0x12, 0x1a, 0x0a, 0x07, 0x76, 0x65, 0x72,
-	0x73, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x48, 0x00, 0x52, 0x07, 0x76, 0x65,
-	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x28, 0x0a, 0x0f, 0x73, 0x65, 0x74, 0x5f, 0x76, 0x69, 0x65,
-	0x77, 0x5f, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x48, 0x00,
-	0x52, 0x0d, 0x73, 0x65, 0x74, 0x56, 0x69, 0x65, 0x77, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x12,
-	0x25, 0x0a, 0x05, 0x73, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x53, 0x6f, 0x75, 0x6e, 0x64, 0x48, 0x00, 0x52,
-	0x05, 0x73, 0x6f, 0x75, 0x6e, 0x64, 0x12, 0x14, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x07,
-	0x20, 0x01, 0x28, 0x02, 0x48, 0x00, 0x52, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x05,
-	0x70, 0x72, 0x69, 0x6e, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x05, 0x70,
-	0x72, 0x69, 0x6e, 0x74, 0x12, 0x1f, 0x0a, 0x0a, 0x73, 0x74, 0x75, 0x66, 0x66, 0x5f, 0x74, 0x65,
-	0x78, 0x74, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x09, 0x73, 0x74, 0x75, 0x66,
-	0x66, 0x54, 0x65, 0x78, 0x74, 0x12, 0x2c, 0x0a, 0x09, 0x73, 0x65, 0x74, 0x5f, 0x61, 0x6e, 0x67,
-	0x6c, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x73, 0x2e, 0x43, 0x6f, 0x6f, 0x72, 0x64, 0x48, 0x00, 0x52, 0x08, 0x73, 0x65, 0x74, 0x41, 0x6e,
-	0x67, 0x6c, 0x65, 0x12, 0x35, 0x0a, 0x0b, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x69, 0x6e,
-	0x66, 0x6f, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x73, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x48, 0x00, 0x52, 0x0a,
-	0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x35, 0x0a, 0x0b, 0x6c, 0x69,
-	0x67, 0x68, 0x74, 0x5f, 0x73, 0x74, 0x79, 0x6c, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x4c, 0x69, 0x67, 0x68, 0x74, 0x53, 0x74,
-	0x79, 0x6c, 0x65, 0x48, 0x00, 0x52, 0x0a, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x53, 0x74, 0x79, 0x6c,
-	0x65, 0x12, 0x35, 0x0a, 0x0b, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x5f, 0x6e, 0x61, 0x6d, 0x65,
-	0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e,
-	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x48, 0x00, 0x52, 0x0a, 0x75, 0x70,
-	0x64, 0x61, 0x74, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x38, 0x0a, 0x0c, 0x75, 0x70, 0x64, 0x61,
-	0x74, 0x65, 0x5f, 0x66, 0x72, 0x61, 0x67, 0x73, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x46, 0x72,
-	0x61, 0x67, 0x73, 0x48, 0x00, 0x52, 0x0b, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x46, 0x72, 0x61,
-	0x67, 0x73, 0x12, 0x35, 0x0a, 0x0b, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x64, 0x61, 0x74,
-	0x61, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73,
-	0x2e, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x44, 0x61, 0x74, 0x61, 0x48, 0x00, 0x52, 0x0a, 0x63,
-	0x6c, 0x69, 0x65, 0x6e, 0x74, 0x44, 0x61, 0x74, 0x61, 0x12, 0x1f, 0x0a, 0x0a, 0x73, 0x74, 0x6f,
-	0x70, 0x5f, 0x73, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x10, 0x20, 0x01, 0x28, 0x05, 0x48, 0x00, 0x52,
-	0x09, 0x73, 0x74, 0x6f, 0x70, 0x53, 0x6f, 0x75, 0x6e, 0x64, 0x12, 0x3b, 0x0a, 0x0d, 0x75, 0x70,
-	0x64, 0x61, 0x74, 0x65, 0x5f, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x73, 0x18, 0x11, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74,
-	0x65, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x73, 0x48, 0x00, 0x52, 0x0c, 0x75, 0x70, 0x64, 0x61, 0x74,
-	0x65, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x73, 0x12, 0x2e, 0x0a, 0x08, 0x70, 0x61, 0x72, 0x74, 0x69,
-	0x63, 0x6c, 0x65, 0x18, 0x12, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x73, 0x2e, 0x50, 0x61, 0x72, 0x74, 0x69, 0x63, 0x6c, 0x65, 0x48, 0x00, 0x52, 0x08, 0x70,
-	0x61, 0x72, 0x74, 0x69, 0x63, 0x6c, 0x65, 0x12, 0x28, 0x0a, 0x06, 0x64, 0x61, 0x6d, 0x61, 0x67,
-	0x65, 0x18, 0x13, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73,
-	0x2e, 0x44, 0x61, 0x6d, 0x61, 0x67, 0x65, 0x48, 0x00, 0x52, 0x06, 0x64, 0x61, 0x6d, 0x61, 0x67,
-	0x65, 0x12, 0x35, 0x0a, 0x0c, 0x73, 0x70, 0x61, 0x77, 0x6e, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x69,
-	0x63, 0x18, 0x14, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73,
-	0x2e, 0x42, 0x61, 0x73, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x48, 0x00, 0x52, 0x0b, 0x73, 0x70, 0x61,
-	0x77, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x69, 0x63, 0x12, 0x3f, 0x0a, 0x0e, 0x73, 0x70, 0x61, 0x77,
-	0x6e, 0x5f, 0x62, 0x61, 0x73, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x16, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79,
-	0x42, 0x61, 0x73, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x48, 0x00, 0x52, 0x0d, 0x73, 0x70, 0x61, 0x77,
-	0x6e, 0x42, 0x61, 0x73, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x35, 0x0a, 0x0b, 0x74, 0x65, 0x6d,
-	0x70, 0x5f, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x18, 0x17, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x54, 0x65, 0x6d, 0x70, 0x45, 0x6e, 0x74, 0x69,
-	0x74, 0x79, 0x48, 0x00, 0x52, 0x0a, 0x74, 0x65, 0x6d, 0x70, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79,
-	0x12, 0x1d, 0x0a, 0x09, 0x73, 0x65, 0x74, 0x5f, 0x70, 0x61, 0x75, 0x73, 0x65, 0x18, 0x18, 0x20,
-	0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x08, 0x73, 0x65, 0x74, 0x50, 0x61, 0x75, 0x73, 0x65, 0x12,
-	0x1f, 0x0a, 0x0a, 0x73, 0x69, 0x67, 0x6e, 0x6f, 0x6e, 0x5f, 0x6e, 0x75, 0x6d, 0x18, 0x19, 0x20,
-	0x01, 0x28, 0x05, 0x48, 0x00, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x6f, 0x6e, 0x4e, 0x75, 0x6d,
-	0x12, 0x23, 0x0a, 0x0c, 0x63, 0x65, 0x6e, 0x74, 0x65, 0x72, 0x5f, 0x70, 0x72, 0x69, 0x6e, 0x74,
-	0x18, 0x1a, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x0b, 0x63, 0x65, 0x6e, 0x74, 0x65, 0x72,
-	0x50, 0x72, 0x69, 0x6e, 0x74, 0x12, 0x36, 0x0a, 0x0e, 0x6b, 0x69, 0x6c, 0x6c, 0x65, 0x64, 0x5f,
-	0x6d, 0x6f, 0x6e, 0x73, 0x74, 0x65, 0x72, 0x18, 0x1b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x48, 0x00, 0x52, 0x0d,
-	0x6b, 0x69, 0x6c, 0x6c, 0x65, 0x64, 0x4d, 0x6f, 0x6e, 0x73, 0x74, 0x65, 0x72, 0x12, 0x32, 0x0a,
-	0x0c, 0x66, 0x6f, 0x75, 0x6e, 0x64, 0x5f, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x18, 0x1c, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x45, 0x6d, 0x70,
-	0x74, 0x79, 0x48, 0x00, 0x52, 0x0b, 0x66, 0x6f, 0x75, 0x6e, 0x64, 0x53, 0x65, 0x63, 0x72, 0x65,
-	0x74, 0x12, 0x43, 0x0a, 0x12, 0x73, 0x70, 0x61, 0x77, 0x6e, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x69,
-	0x63, 0x5f, 0x73, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x1d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x69, 0x63, 0x53, 0x6f, 0x75,
-	0x6e, 0x64, 0x48, 0x00, 0x52, 0x10, 0x73, 0x70, 0x61, 0x77, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x69,
-	0x63, 0x53, 0x6f, 0x75, 0x6e, 0x64, 0x12, 0x33, 0x0a, 0x0c, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6d,
-	0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x48, 0x00, 0x52, 0x0c, 0x69,
-	0x6e, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x06, 0x66,
-	0x69, 0x6e, 0x61, 0x6c, 0x65, 0x18, 0x1f, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x06, 0x66,
-	0x69, 0x6e, 0x61, 0x6c, 0x65, 0x12, 0x2c, 0x0a, 0x08, 0x63, 0x64, 0x5f, 0x74, 0x72, 0x61, 0x63,
-	0x6b, 0x18, 0x20, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73,
-	0x2e, 0x43, 0x44, 0x54, 0x72, 0x61, 0x63, 0x6b, 0x48, 0x00, 0x52, 0x07, 0x63, 0x64, 0x54, 0x72,
-	0x61, 0x63, 0x6b, 0x12, 0x30, 0x0a, 0x0b, 0x73, 0x65, 0x6c, 0x6c, 0x5f, 0x73, 0x63, 0x72, 0x65,
-	0x65, 0x6e, 0x18, 0x21, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x73, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x48, 0x00, 0x52, 0x0a, 0x73, 0x65, 0x6c, 0x6c, 0x53,
-	0x63, 0x72, 0x65, 0x65, 0x6e, 0x12, 0x1c, 0x0a, 0x08, 0x63, 0x75, 0x74, 0x73, 0x63, 0x65, 0x6e,
-	0x65, 0x18, 0x22, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x08, 0x63, 0x75, 0x74, 0x73, 0x63,
-	0x65, 0x6e, 0x65, 0x12, 0x18, 0x0a, 0x06, 0x73, 0x6b, 0x79, 0x62, 0x6f, 0x78, 0x18, 0x25, 0x20,
-	0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x06, 0x73, 0x6b, 0x79, 0x62, 0x6f, 0x78, 0x12, 0x3a, 0x0a,
-	0x10, 0x62, 0x61, 0x63, 0x6b, 0x67, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x5f, 0x66, 0x6c, 0x61, 0x73,
-	0x68, 0x18, 0x28, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73,
-	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x48, 0x00, 0x52, 0x0f, 0x62, 0x61, 0x63, 0x6b, 0x67, 0x72,
-	0x6f, 0x75, 0x6e, 0x64, 0x46, 0x6c, 0x61, 0x73, 0x68, 0x12, 0x1f, 0x0a, 0x03, 0x66, 0x6f, 0x67,
-	0x18, 0x29, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e,
-	0x46, 0x6f, 0x67, 0x48, 0x00, 0x52, 0x03, 0x66, 0x6f, 0x67, 0x12, 0x22, 0x0a, 0x0b, 0x61, 0x63,
-	0x68, 0x69, 0x65, 0x76, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x2a, 0x20, 0x01, 0x28, 0x09, 0x48,
-	0x00, 0x52, 0x0b, 0x61, 0x63, 0x68, 0x69, 0x65, 0x76, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x42, 0x07,
+	0x01, 0x02, 0x08, 0x01, 0x52, 0x04, 0x73, 0x6b, 0x69, 0x6e, 0x12, 0x1f, 0x0a, 0x07, 0x65, 0x66,
+	0x66, 0x65, 0x63, 0x74, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x05, 0x42, 0x05, 0xaa, 0x01, 0x02,
+	0x08, 0x01, 0x52, 0x07, 0x65, 0x66, 0x66, 0x65, 0x63, 0x74, 0x73, 0x12, 0x20, 0x0a, 0x08, 0x6f,
+	0x72, 0x69, 0x67, 0x69, 0x6e, 0x5f, 0x78, 0x18, 0x08, 0x20, 0x01, 0x28, 0x02, 0x42, 0x05, 0xaa,
+	0x01, 0x02, 0x08, 0x01, 0x52, 0x07, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x58, 0x12, 0x20, 0x0a,
+	0x08, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x5f, 0x79, 0x18, 0x09, 0x20, 0x01, 0x28, 0x02, 0x42,
+	0x05, 0xaa, 0x01, 0x02, 0x08, 0x01, 0x52, 0x07, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x59, 0x12,
+	0x20, 0x0a, 0x08, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x5f, 0x7a, 0x18, 0x0a, 0x20, 0x01, 0x28,
+	0x02, 0x42, 0x05, 0xaa, 0x01, 0x02, 0x08, 0x01, 0x52, 0x07, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e,
+	0x5a, 0x12, 0x1e, 0x0a, 0x07, 0x61, 0x6e, 0x67, 0x6c, 0x65, 0x5f, 0x78, 0x18, 0x0b, 0x20, 0x01,
+	0x28, 0x02, 0x42, 0x05, 0xaa, 0x01, 0x02, 0x08, 0x01, 0x52, 0x06, 0x61, 0x6e, 0x67, 0x6c, 0x65,
+	0x58, 0x12, 0x1e, 0x0a, 0x07, 0x61, 0x6e, 0x67, 0x6c, 0x65, 0x5f, 0x79, 0x18, 0x0c, 0x20, 0x01,
+	0x28, 0x02, 0x42, 0x05, 0xaa, 0x01, 0x02, 0x08, 0x01, 0x52, 0x06, 0x61, 0x6e, 0x67, 0x6c, 0x65,
+	0x59, 0x12, 0x1e, 0x0a, 0x07, 0x61, 0x6e, 0x67, 0x6c, 0x65, 0x5f, 0x7a, 0x18, 0x0d, 0x20, 0x01,
+	0x28, 0x02, 0x42, 0x05, 0xaa, 0x01, 0x02, 0x08, 0x01, 0x52, 0x06, 0x61, 0x6e, 0x67, 0x6c, 0x65,
+	0x5a, 0x12, 0x26, 0x0a, 0x0b, 0x6c, 0x65, 0x72, 0x70, 0x5f, 0x66, 0x69, 0x6e, 0x69, 0x73, 0x68,
+	0x18, 0x0e, 0x20, 0x01, 0x28, 0x05, 0x42, 0x05, 0xaa, 0x01, 0x02, 0x08, 0x01, 0x52, 0x0a, 0x6c,
+	0x65, 0x72, 0x70, 0x46, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x12, 0x1b, 0x0a, 0x05, 0x61, 0x6c, 0x70,
+	0x68, 0x61, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x05, 0x42, 0x05, 0xaa, 0x01, 0x02, 0x08, 0x01, 0x52,
+	0x05, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x22, 0x84, 0x01, 0x0a, 0x0b, 0x53, 0x74, 0x61, 0x74, 0x69,
+	0x63, 0x53, 0x6f, 0x75, 0x6e, 0x64, 0x12, 0x25, 0x0a, 0x06, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e,
+	0x43, 0x6f, 0x6f, 0x72, 0x64, 0x52, 0x06, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x12, 0x14, 0x0a,
+	0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x69, 0x6e,
+	0x64, 0x65, 0x78, 0x12, 0x16, 0x0a, 0x06, 0x76, 0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x06, 0x76, 0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x61,
+	0x74, 0x74, 0x65, 0x6e, 0x75, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x0b, 0x61, 0x74, 0x74, 0x65, 0x6e, 0x75, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x5f, 0x0a,
+	0x06, 0x44, 0x61, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x72, 0x6d, 0x6f, 0x72,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x61, 0x72, 0x6d, 0x6f, 0x72, 0x12, 0x14, 0x0a,
+	0x05, 0x62, 0x6c, 0x6f, 0x6f, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x62, 0x6c,
+	0x6f, 0x6f, 0x64, 0x12, 0x29, 0x0a, 0x08, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x43,
+	0x6f, 0x6f, 0x72, 0x64, 0x52, 0x08, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x4b,
+	0x0a, 0x07, 0x43, 0x44, 0x54, 0x72, 0x61, 0x63, 0x6b, 0x12, 0x21, 0x0a, 0x0c, 0x74, 0x72, 0x61,
+	0x63, 0x6b, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x0b, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x1d, 0x0a, 0x0a,
+	0x6c, 0x6f, 0x6f, 0x70, 0x5f, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x09, 0x6c, 0x6f, 0x6f, 0x70, 0x54, 0x72, 0x61, 0x63, 0x6b, 0x22, 0x36, 0x0a, 0x0a, 0x55,
+	0x70, 0x64, 0x61, 0x74, 0x65, 0x53, 0x74, 0x61, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x74, 0x61,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x73, 0x74, 0x61, 0x74, 0x12, 0x14, 0x0a,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x22, 0x8a, 0x01, 0x0a, 0x08, 0x50, 0x61, 0x72, 0x74, 0x69, 0x63, 0x6c, 0x65,
+	0x12, 0x25, 0x0a, 0x06, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x43, 0x6f, 0x6f, 0x72, 0x64, 0x52,
+	0x06, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x12, 0x2b, 0x0a, 0x09, 0x64, 0x69, 0x72, 0x65, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x73, 0x2e, 0x43, 0x6f, 0x6f, 0x72, 0x64, 0x52, 0x09, 0x64, 0x69, 0x72, 0x65, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f,
+	0x6c, 0x6f, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72,
+	0x22, 0x6f, 0x0a, 0x03, 0x46, 0x6f, 0x67, 0x12, 0x18, 0x0a, 0x07, 0x64, 0x65, 0x6e, 0x73, 0x69,
+	0x74, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x02, 0x52, 0x07, 0x64, 0x65, 0x6e, 0x73, 0x69, 0x74,
+	0x79, 0x12, 0x10, 0x0a, 0x03, 0x72, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x02, 0x52, 0x03,
+	0x72, 0x65, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x67, 0x72, 0x65, 0x65, 0x6e, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x02, 0x52, 0x05, 0x67, 0x72, 0x65, 0x65, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x62, 0x6c, 0x75,
+	0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x02, 0x52, 0x04, 0x62, 0x6c, 0x75, 0x65, 0x12, 0x12, 0x0a,
+	0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x02, 0x52, 0x04, 0x74, 0x69, 0x6d,
+	0x65, 0x22, 0xda, 0x01, 0x0a, 0x0b, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74,
+	0x65, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x25, 0x0a,
+	0x06, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x43, 0x6f, 0x6f, 0x72, 0x64, 0x52, 0x06, 0x6f, 0x72,
+	0x69, 0x67, 0x69, 0x6e, 0x12, 0x29, 0x0a, 0x08, 0x76, 0x65, 0x6c, 0x6f, 0x63, 0x69, 0x74, 0x79,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e,
+	0x43, 0x6f, 0x6f, 0x72, 0x64, 0x52, 0x08, 0x76, 0x65, 0x6c, 0x6f, 0x63, 0x69, 0x74, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x66, 0x6c, 0x61, 0x67, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05,
+	0x66, 0x6c, 0x61, 0x67, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x6d, 0x6f, 0x76, 0x65, 0x5f, 0x74, 0x79,
+	0x70, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x6d, 0x6f, 0x76, 0x65, 0x54, 0x79,
+	0x70, 0x65, 0x12, 0x2a, 0x0a, 0x06, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x4d, 0x6f, 0x76, 0x65,
+	0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x52, 0x06, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x22, 0xfb,
+	0x01, 0x0a, 0x0a, 0x4d, 0x6f, 0x76, 0x65, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x12, 0x1e, 0x0a,
+	0x0a, 0x61, 0x63, 0x63, 0x65, 0x6c, 0x65, 0x72, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x02, 0x52, 0x0a, 0x61, 0x63, 0x63, 0x65, 0x6c, 0x65, 0x72, 0x61, 0x74, 0x65, 0x12, 0x1a, 0x0a,
+	0x08, 0x66, 0x72, 0x69, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x02, 0x52,
+	0x08, 0x66, 0x72, 0x69, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x23, 0x0a, 0x0d, 0x65, 0x64, 0x67,
+	0x65, 0x5f, 0x66, 0x72, 0x69, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x02,
+	0x52, 0x0c, 0x65, 0x64, 0x67, 0x65, 0x46, 0x72, 0x69, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1d,
+	0x0a, 0x0a, 0x73, 0x74, 0x6f, 0x70, 0x5f, 0x73, 0x70, 0x65, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x02, 0x52, 0x09, 0x73, 0x74, 0x6f, 0x70, 0x53, 0x70, 0x65, 0x65, 0x64, 0x12, 0x1b, 0x0a,
+	0x09, 0x6d, 0x61, 0x78, 0x5f, 0x73, 0x70, 0x65, 0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x02,
+	0x52, 0x08, 0x6d, 0x61, 0x78, 0x53, 0x70, 0x65, 0x65, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x67, 0x72,
+	0x61, 0x76, 0x69, 0x74, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x02, 0x52, 0x07, 0x67, 0x72, 0x61,
+	0x76, 0x69, 0x74, 0x79, 0x12, 0x17, 0x0a, 0x07, 0x6e, 0x6f, 0x5f, 0x73, 0x74, 0x65, 0x70, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6e, 0x6f, 0x53, 0x74, 0x65, 0x70, 0x12, 0x1d, 0x0a,
+	0x0a, 0x61, 0x6c, 0x74, 0x5f, 0x6e, 0x6f, 0x63, 0x6c, 0x69, 0x70, 0x18, 0x08, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x09, 0x61, 0x6c, 0x74, 0x4e, 0x6f, 0x63, 0x6c, 0x69, 0x70, 0x22, 0x93, 0x01, 0x0a,
+	0x0e, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x69, 0x65, 0x73, 0x12,
+	0x14, 0x0a, 0x05, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05,
+	0x66, 0x72, 0x61, 0x6d, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x64, 0x65, 0x6c, 0x74, 0x61, 0x5f, 0x66,
+	0x72, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x64, 0x65, 0x6c, 0x74,
+	0x61, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x12, 0x30, 0x0a, 0x08, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x69,
+	0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x73, 0x2e, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x08,
+	0x65, 0x6e, 0x74, 0x69, 0x74, 0x69, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x6d, 0x6f,
+	0x76, 0x65, 0x64, 0x18, 0x04, 0x20, 0x03, 0x28, 0x05, 0x52, 0x07, 0x72, 0x65, 0x6d, 0x6f, 0x76,
+	0x65, 0x64, 0x22, 0xc2, 0x0e, 0x0a, 0x04, 0x53, 0x43, 0x6d, 0x64, 0x12, 0x20, 0x0a, 0x0a, 0x64,
+	0x69, 0x73, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x48,
+	0x00, 0x52, 0x0a, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x12, 0x3b, 0x0a,
+	0x0d, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x5f, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x18, 0x2d,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x45, 0x6e,
+	0x74, 0x69, 0x74, 0x79, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x48, 0x00, 0x52, 0x0c, 0x65, 0x6e,
+	0x74, 0x69, 0x74, 0x79, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x12, 0x35, 0x0a, 0x0b, 0x75, 0x70,
+	0x64, 0x61, 0x74, 0x65, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x53,
+	0x74, 0x61, 0x74, 0x48, 0x00, 0x52, 0x0a, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x53, 0x74, 0x61,
+	0x74, 0x12, 0x1a, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x05, 0x48, 0x00, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x28, 0x0a,
+	0x0f, 0x73, 0x65, 0x74, 0x5f, 0x76, 0x69, 0x65, 0x77, 0x5f, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x48, 0x00, 0x52, 0x0d, 0x73, 0x65, 0x74, 0x56, 0x69, 0x65,
+	0x77, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x12, 0x25, 0x0a, 0x05, 0x73, 0x6f, 0x75, 0x6e, 0x64,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e,
+	0x53, 0x6f, 0x75, 0x6e, 0x64, 0x48, 0x00, 0x52, 0x05, 0x73, 0x6f, 0x75, 0x6e, 0x64, 0x12, 0x14,
+	0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x02, 0x48, 0x00, 0x52, 0x04,
+	0x74, 0x69, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x05, 0x70, 0x72, 0x69, 0x6e, 0x74, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x05, 0x70, 0x72, 0x69, 0x6e, 0x74, 0x12, 0x1f, 0x0a, 0x0a,
+	0x73, 0x74, 0x75, 0x66, 0x66, 0x5f, 0x74, 0x65, 0x78, 0x74, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09,
+	0x48, 0x00, 0x52, 0x09, 0x73, 0x74, 0x75, 0x66, 0x66, 0x54, 0x65, 0x78, 0x74, 0x12, 0x2c, 0x0a,
+	0x09, 0x73, 0x65, 0x74, 0x5f, 0x61, 0x6e, 0x67, 0x6c, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x43, 0x6f, 0x6f, 0x72, 0x64, 0x48,
+	0x00, 0x52, 0x08, 0x73, 0x65, 0x74, 0x41, 0x6e, 0x67, 0x6c, 0x65, 0x12, 0x35, 0x0a, 0x0b, 0x73,
+	0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72,
+	0x49, 0x6e, 0x66, 0x6f, 0x48, 0x00, 0x52, 0x0a, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x49, 0x6e,
+	0x66, 0x6f, 0x12, 0x35, 0x0a, 0x0b, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x5f, 0x73, 0x74, 0x79, 0x6c,
+	0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73,
+	0x2e, 0x4c, 0x69, 0x67, 0x68, 0x74, 0x53, 0x74, 0x79, 0x6c, 0x65, 0x48, 0x00, 0x52, 0x0a, 0x6c,
+	0x69, 0x67, 0x68, 0x74, 0x53, 0x74, 0x79, 0x6c, 0x65, 0x12, 0x35, 0x0a, 0x0b, 0x75, 0x70, 0x64,
+	0x61, 0x74, 0x65, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x4e, 0x61,
+	0x6d, 0x65, 0x48, 0x00, 0x52, 0x0a, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x4e, 0x61, 0x6d, 0x65,
+	0x12, 0x38, 0x0a, 0x0c, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x5f, 0x66, 0x72, 0x61, 0x67, 0x73,
+	0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e,
+	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x46, 0x72, 0x61, 0x67, 0x73, 0x48, 0x00, 0x52, 0x0b, 0x75,
+	0x70, 0x64, 0x61, 0x74, 0x65, 0x46, 0x72, 0x61, 0x67, 0x73, 0x12, 0x35, 0x0a, 0x0b, 0x63, 0x6c,
+	0x69, 0x65, 0x6e, 0x74, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x44,
+	0x61, 0x74, 0x61, 0x48, 0x00, 0x52, 0x0a, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x44, 0x61, 0x74,
+	0x61, 0x12, 0x1f, 0x0a, 0x0a, 0x73, 0x74, 0x6f, 0x70, 0x5f, 0x73, 0x6f, 0x75, 0x6e, 0x64, 0x18,
+	0x10, 0x20, 0x01, 0x28, 0x05, 0x48, 0x00, 0x52, 0x09, 0x73, 0x74, 0x6f, 0x70, 0x53, 0x6f, 0x75,
+	0x6e, 0x64, 0x12, 0x3b, 0x0a, 0x0d, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x5f, 0x63, 0x6f, 0x6c,
+	0x6f, 0x72, 0x73, 0x18, 0x11, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x73, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x73, 0x48,
+	0x00, 0x52, 0x0c, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x73, 0x12,
+	0x2e, 0x0a, 0x08, 0x70, 0x61, 0x72, 0x74, 0x69, 0x63, 0x6c, 0x65, 0x18, 0x12, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x10, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x50, 0x61, 0x72, 0x74, 0x69,
+	0x63, 0x6c, 0x65, 0x48, 0x00, 0x52, 0x08, 0x70, 0x61, 0x72, 0x74, 0x69, 0x63, 0x6c, 0x65, 0x12,
+	0x28, 0x0a, 0x06, 0x64, 0x61, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x13, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x0e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x44, 0x61, 0x6d, 0x61, 0x67, 0x65, 0x48,
+	0x00, 0x52, 0x06, 0x64, 0x61, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x35, 0x0a, 0x0c, 0x73, 0x70, 0x61,
+	0x77, 0x6e, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x69, 0x63, 0x18, 0x14, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x10, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x42, 0x61, 0x73, 0x65, 0x6c, 0x69, 0x6e,
+	0x65, 0x48, 0x00, 0x52, 0x0b, 0x73, 0x70, 0x61, 0x77, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x69, 0x63,
+	0x12, 0x3f, 0x0a, 0x0e, 0x73, 0x70, 0x61, 0x77, 0x6e, 0x5f, 0x62, 0x61, 0x73, 0x65, 0x6c, 0x69,
+	0x6e, 0x65, 0x18, 0x16, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x73, 0x2e, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x42, 0x61, 0x73, 0x65, 0x6c, 0x69, 0x6e, 0x65,
+	0x48, 0x00, 0x52, 0x0d, 0x73, 0x70, 0x61, 0x77, 0x6e, 0x42, 0x61, 0x73, 0x65, 0x6c, 0x69, 0x6e,
+	0x65, 0x12, 0x35, 0x0a, 0x0b, 0x74, 0x65, 0x6d, 0x70, 0x5f, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79,
+	0x18, 0x17, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e,
+	0x54, 0x65, 0x6d, 0x70, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x48, 0x00, 0x52, 0x0a, 0x74, 0x65,
+	0x6d, 0x70, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x12, 0x1d, 0x0a, 0x09, 0x73, 0x65, 0x74, 0x5f,
+	0x70, 0x61, 0x75, 0x73, 0x65, 0x18, 0x18, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x08, 0x73,
+	0x65, 0x74, 0x50, 0x61, 0x75, 0x73, 0x65, 0x12, 0x1f, 0x0a, 0x0a, 0x73, 0x69, 0x67, 0x6e, 0x6f,
+	0x6e, 0x5f, 0x6e, 0x75, 0x6d, 0x18, 0x19, 0x20, 0x01, 0x28, 0x05, 0x48, 0x00, 0x52, 0x09, 0x73,
+	0x69, 0x67, 0x6e, 0x6f, 0x6e, 0x4e, 0x75, 0x6d, 0x12, 0x23, 0x0a, 0x0c, 0x63, 0x65, 0x6e, 0x74,
+	0x65, 0x72, 0x5f, 0x70, 0x72, 0x69, 0x6e, 0x74, 0x18, 0x1a, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00,
+	0x52, 0x0b, 0x63, 0x65, 0x6e, 0x74, 0x65, 0x72, 0x50, 0x72, 0x69, 0x6e, 0x74, 0x12, 0x36, 0x0a,
+	0x0e, 0x6b, 0x69, 0x6c, 0x6c, 0x65, 0x64, 0x5f, 0x6d, 0x6f, 0x6e, 0x73, 0x74, 0x65, 0x72, 0x18,
+	0x1b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x45,
+	0x6d, 0x70, 0x74, 0x79, 0x48, 0x00, 0x52, 0x0d, 0x6b, 0x69, 0x6c, 0x6c, 0x65, 0x64, 0x4d, 0x6f,
+	0x6e, 0x73, 0x74, 0x65, 0x72, 0x12, 0x32, 0x0a, 0x0c, 0x66, 0x6f, 0x75, 0x6e, 0x64, 0x5f, 0x73,
+	0x65, 0x63, 0x72, 0x65, 0x74, 0x18, 0x1c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x48, 0x00, 0x52, 0x0b, 0x66, 0x6f,
+	0x75, 0x6e, 0x64, 0x53, 0x65, 0x63, 0x72, 0x65, 0x74, 0x12, 0x43, 0x0a, 0x12, 0x73, 0x70, 0x61,
+	0x77, 0x6e, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x69, 0x63, 0x5f, 0x73, 0x6f, 0x75, 0x6e, 0x64, 0x18,
+	0x1d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x53,
+	0x74, 0x61, 0x74, 0x69, 0x63, 0x53, 0x6f, 0x75, 0x6e, 0x64, 0x48, 0x00, 0x52, 0x10, 0x73, 0x70,
+	0x61, 0x77, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x69, 0x63, 0x53, 0x6f, 0x75, 0x6e, 0x64, 0x12, 0x33,
+	0x0a, 0x0c, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x1e,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x45, 0x6d,
+	0x70, 0x74, 0x79, 0x48, 0x00, 0x52, 0x0c, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x06, 0x66, 0x69, 0x6e, 0x61, 0x6c, 0x65, 0x18, 0x1f, 0x20,
+	0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x06, 0x66, 0x69, 0x6e, 0x61, 0x6c, 0x65, 0x12, 0x2c, 0x0a,
+	0x08, 0x63, 0x64, 0x5f, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x18, 0x20, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x43, 0x44, 0x54, 0x72, 0x61, 0x63, 0x6b,
+	0x48, 0x00, 0x52, 0x07, 0x63, 0x64, 0x54, 0x72, 0x61, 0x63, 0x6b, 0x12, 0x30, 0x0a, 0x0b, 0x73,
+	0x65, 0x6c, 0x6c, 0x5f, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x18, 0x21, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x48,
+	0x00, 0x52, 0x0a, 0x73, 0x65, 0x6c, 0x6c, 0x53, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x12, 0x1c, 0x0a,
+	0x08, 0x63, 0x75, 0x74, 0x73, 0x63, 0x65, 0x6e, 0x65, 0x18, 0x22, 0x20, 0x01, 0x28, 0x09, 0x48,
+	0x00, 0x52, 0x08, 0x63, 0x75, 0x74, 0x73, 0x63, 0x65, 0x6e, 0x65, 0x12, 0x18, 0x0a, 0x06, 0x73,
+	0x6b, 0x79, 0x62, 0x6f, 0x78, 0x18, 0x25, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x06, 0x73,
+	0x6b, 0x79, 0x62, 0x6f, 0x78, 0x12, 0x3a, 0x0a, 0x10, 0x62, 0x61, 0x63, 0x6b, 0x67, 0x72, 0x6f,
+	0x75, 0x6e, 0x64, 0x5f, 0x66, 0x6c, 0x61, 0x73, 0x68, 0x18, 0x28, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x48, 0x00,
+	0x52, 0x0f, 0x62, 0x61, 0x63, 0x6b, 0x67, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x46, 0x6c, 0x61, 0x73,
+	0x68, 0x12, 0x1f, 0x0a, 0x03, 0x66, 0x6f, 0x67, 0x18, 0x29, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x46, 0x6f, 0x67, 0x48, 0x00, 0x52, 0x03, 0x66,
+	0x6f, 0x67, 0x12, 0x22, 0x0a, 0x0b, 0x61, 0x63, 0x68, 0x69, 0x65, 0x76, 0x65, 0x6d, 0x65, 0x6e,
+	0x74, 0x18, 0x2a, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x0b, 0x61, 0x63, 0x68, 0x69, 0x65,
+	0x76, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x1f, 0x0a, 0x0a, 0x63, 0x73, 0x71, 0x63, 0x5f, 0x65,
+	0x76, 0x65, 0x6e, 0x74, 0x18, 0x2e, 0x20, 0x01, 0x28, 0x0c, 0x48, 0x00, 0x52, 0x09, 0x63, 0x73,
+	0x71, 0x63, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x38, 0x0a, 0x0c, 0x70, 0x6c, 0x61, 0x79, 0x65,
+	0x72, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x2f, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x53, 0x74, 0x61,
+	0x74, 0x65, 0x48, 0x00, 0x52, 0x0b, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74,
+	0x65, 0x12, 0x41, 0x0a, 0x0f, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x5f, 0x65, 0x6e, 0x74, 0x69,
+	0x74, 0x69, 0x65, 0x73, 0x18, 0x30, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x73, 0x2e, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x69,
+	0x65, 0x73, 0x48, 0x00, 0x52, 0x0e, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x45, 0x6e, 0x74, 0x69,
+	0x74, 0x69, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x06, 0x6c, 0x65, 0x67, 0x61, 0x63, 0x79, 0x18, 0x31,
+	0x20, 0x01, 0x28, 0x0c, 0x48, 0x00, 0x52, 0x06, 0x6c, 0x65, 0x67, 0x61, 0x63, 0x79, 0x42, 0x07,
 	0x0a, 0x05, 0x75, 0x6e, 0x69, 0x6f, 0x6e, 0x22, 0x31, 0x0a, 0x0d, 0x53, 0x65, 0x72, 0x76, 0x65,
 	0x72, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x20, 0x0a, 0x04, 0x63, 0x6d, 0x64, 0x73,
 	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e,
@@ -5417,7 +6076,7 @@ var file_server_message_proto_rawDesc = []byte{
 	0x08, 0x65, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x70, 0xe8, 0x07,
 }
 
-var file_server_message_proto_msgTypes = make([]protoimpl.MessageInfo, 24)
+var file_server_message_proto_msgTypes = make([]protoimpl.MessageInfo, 27)
 var file_server_message_proto_goTypes = []any{
 	(*Coord)(nil),          // 0: protos.Coord
 	(*IntCoord)(nil),       // 1: protos.IntCoord
@@ -5441,8 +6100,11 @@ var file_server_message_proto_goTypes = []any{
 	(*UpdateStat)(nil),     // 19: protos.UpdateStat
 	(*Particle)(nil),       // 20: protos.Particle
 	(*Fog)(nil),            // 21: protos.Fog
-	(*SCmd)(nil),           // 22: protos.SCmd
-	(*ServerMessage)(nil),  // 23: protos.ServerMessage
+	(*PlayerState)(nil),    // 22: protos.PlayerState
+	(*MoveParams)(nil),     // 23: protos.MoveParams
+	(*PacketEntities)(nil), // 24: protos.PacketEntities
+	(*SCmd)(nil),           // 25: protos.SCmd
+	(*ServerMessage)(nil),  // 26: protos.ServerMessage
 }
 var file_server_message_proto_depIdxs = []int32{
 	0,  // 0: protos.Line.start:type_name -> protos.Coord
@@ -5472,35 +6134,41 @@ var file_server_message_proto_depIdxs = []int32{
 	0,  // 24: protos.Damage.position:type_name -> protos.Coord
 	0,  // 25: protos.Particle.origin:type_name -> protos.Coord
 	0,  // 26: protos.Particle.direction:type_name -> protos.Coord
-	15, // 27: protos.SCmd.entity_update:type_name -> protos.EntityUpdate
-	19, // 28: protos.SCmd.update_stat:type_name -> protos.UpdateStat
-	7,  // 29: protos.SCmd.sound:type_name -> protos.Sound
-	0,  // 30: protos.SCmd.set_angle:type_name -> protos.Coord
-	14, // 31: protos.SCmd.server_info:type_name -> protos.ServerInfo
-	6,  // 32: protos.SCmd.light_style:type_name -> protos.LightStyle
-	8,  // 33: protos.SCmd.update_name:type_name -> protos.UpdateName
-	9,  // 34: protos.SCmd.update_frags:type_name -> protos.UpdateFrags
-	11, // 35: protos.SCmd.client_data:type_name -> protos.ClientData
-	10, // 36: protos.SCmd.update_colors:type_name -> protos.UpdateColors
-	20, // 37: protos.SCmd.particle:type_name -> protos.Particle
-	17, // 38: protos.SCmd.damage:type_name -> protos.Damage
-	12, // 39: protos.SCmd.spawn_static:type_name -> protos.Baseline
-	13, // 40: protos.SCmd.spawn_baseline:type_name -> protos.EntityBaseline
-	5,  // 41: protos.SCmd.temp_entity:type_name -> protos.TempEntity
-	4,  // 42: protos.SCmd.killed_monster:type_name -> protos.Empty
-	4,  // 43: protos.SCmd.found_secret:type_name -> protos.Empty
-	16, // 44: protos.SCmd.spawn_static_sound:type_name -> protos.StaticSound
-	4,  // 45: protos.SCmd.intermission:type_name -> protos.Empty
-	18, // 46: protos.SCmd.cd_track:type_name -> protos.CDTrack
-	4,  // 47: protos.SCmd.sell_screen:type_name -> protos.Empty
-	4,  // 48: protos.SCmd.background_flash:type_name -> protos.Empty
-	21, // 49: protos.SCmd.fog:type_name -> protos.Fog
-	22, // 50: protos.ServerMessage.cmds:type_name -> protos.SCmd
-	51, // [51:51] is the sub-list for method output_type
-	51, // [51:51] is the sub-list for method input_type
-	51, // [51:51] is the sub-list for extension type_name
-	51, // [51:51] is the sub-list for extension extendee
-	0,  // [0:51] is the sub-list for field type_name
+	0,  // 27: protos.PlayerState.origin:type_name -> protos.Coord
+	0,  // 28: protos.PlayerState.velocity:type_name -> protos.Coord
+	23, // 29: protos.PlayerState.params:type_name -> protos.MoveParams
+	15, // 30: protos.PacketEntities.entities:type_name -> protos.EntityUpdate
+	15, // 31: protos.SCmd.entity_update:type_name -> protos.EntityUpdate
+	19, // 32: protos.SCmd.update_stat:type_name -> protos.UpdateStat
+	7,  // 33: protos.SCmd.sound:type_name -> protos.Sound
+	0,  // 34: protos.SCmd.set_angle:type_name -> protos.Coord
+	14, // 35: protos.SCmd.server_info:type_name -> protos.ServerInfo
+	6,  // 36: protos.SCmd.light_style:type_name -> protos.LightStyle
+	8,  // 37: protos.SCmd.update_name:type_name -> protos.UpdateName
+	9,  // 38: protos.SCmd.update_frags:type_name -> protos.UpdateFrags
+	11, // 39: protos.SCmd.client_data:type_name -> protos.ClientData
+	10, // 40: protos.SCmd.update_colors:type_name -> protos.UpdateColors
+	20, // 41: protos.SCmd.particle:type_name -> protos.Particle
+	17, // 42: protos.SCmd.damage:type_name -> protos.Damage
+	12, // 43: protos.SCmd.spawn_static:type_name -> protos.Baseline
+	13, // 44: protos.SCmd.spawn_baseline:type_name -> protos.EntityBaseline
+	5,  // 45: protos.SCmd.temp_entity:type_name -> protos.TempEntity
+	4,  // 46: protos.SCmd.killed_monster:type_name -> protos.Empty
+	4,  // 47: protos.SCmd.found_secret:type_name -> protos.Empty
+	16, // 48: protos.SCmd.spawn_static_sound:type_name -> protos.StaticSound
+	4,  // 49: protos.SCmd.intermission:type_name -> protos.Empty
+	18, // 50: protos.SCmd.cd_track:type_name -> protos.CDTrack
+	4,  // 51: protos.SCmd.sell_screen:type_name -> protos.Empty
+	4,  // 52: protos.SCmd.background_flash:type_name -> protos.Empty
+	21, // 53: protos.SCmd.fog:type_name -> protos.Fog
+	22, // 54: protos.SCmd.player_state:type_name -> protos.PlayerState
+	24, // 55: protos.SCmd.packet_entities:type_name -> protos.PacketEntities
+	25, // 56: protos.ServerMessage.cmds:type_name -> protos.SCmd
+	57, // [57:57] is the sub-list for method output_type
+	57, // [57:57] is the sub-list for method input_type
+	57, // [57:57] is the sub-list for extension type_name
+	57, // [57:57] is the sub-list for extension extendee
+	0,  // [0:57] is the sub-list for field type_name
 }
 
 func init() { file_server_message_proto_init() }
@@ -5524,7 +6192,7 @@ func file_server_message_proto_init() {
 		(*tempEntity_Explosion2)(nil),
 		(*tempEntity_Beam)(nil),
 	}
-	file_server_message_proto_msgTypes[22].OneofWrappers = []any{
+	file_server_message_proto_msgTypes[25].OneofWrappers = []any{
 		(*sCmd_Disconnect)(nil),
 		(*sCmd_EntityUpdate)(nil),
 		(*sCmd_UpdateStat)(nil),
@@ -5562,6 +6230,10 @@ func file_server_message_proto_init() {
 		(*sCmd_BackgroundFlash)(nil),
 		(*sCmd_Fog)(nil),
 		(*sCmd_Achievement)(nil),
+		(*sCmd_CsqcEvent)(nil),
+		(*sCmd_PlayerState)(nil),
+		(*sCmd_PacketEntities)(nil),
+		(*sCmd_Legacy)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -5569,7 +6241,7 @@ func file_server_message_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_server_message_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   24,
+			NumMessages:   27,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
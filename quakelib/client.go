@@ -30,6 +30,7 @@ import (
 	"goquake/model"
 	"goquake/net"
 	"goquake/progs"
+	"goquake/protocol"
 	clc "goquake/protocol/client"
 	svc "goquake/protocol/server"
 	"goquake/protos"
@@ -226,6 +227,7 @@ type Client struct {
 	yaw            float32     // 1
 	roll           float32     // 2
 	cmdForwardMove float32     // last command sent to the server
+	entityFrames   svc.EntityFrames
 	protocolFlags  uint32
 	idealPitch     float32
 	pitchVel       float32
@@ -281,6 +283,43 @@ func (c *Client) DeathMatch() bool {
 	return cl.gameType == svc.GameDeathmatch
 }
 
+func cl_getStat(s int) int {
+	switch s {
+	case stat.Health:
+		return cl.stats.health
+	case stat.Frags:
+		return cl.stats.frags
+	case stat.Weapon:
+		return cl.stats.weapon
+	case stat.Ammo:
+		return cl.stats.ammo
+	case stat.Armor:
+		return cl.stats.armor
+	case stat.WeaponFrame:
+		return cl.stats.weaponFrame
+	case stat.Shells:
+		return cl.stats.shells
+	case stat.Nails:
+		return cl.stats.nails
+	case stat.Rockets:
+		return cl.stats.rockets
+	case stat.Cells:
+		return cl.stats.cells
+	case stat.ActiveWeapon:
+		return cl.stats.activeWeapon
+	case stat.TotalSecrets:
+		return cl.stats.totalSecrets
+	case stat.TotalMonsters:
+		return cl.stats.totalMonsters
+	case stat.Secrets:
+		return cl.stats.secrets
+	case stat.Monsters:
+		return cl.stats.monsters
+	default:
+		return 0
+	}
+}
+
 func cl_setStats(s, v int) {
 	switch s {
 	case stat.Health:
@@ -426,6 +465,7 @@ func (c *Client) ReadFromServer() (serverState, error) {
 	}
 
 	c.RelinkEntities(frac)
+	c.predictMove()
 	c.updateTempEntities()
 	return serverRunning, nil
 }
@@ -496,6 +536,8 @@ func (c *ClientStatic) Disconnect() error {
 
 	// stop sounds (especially looping!)
 	snd.StopAll()
+	snd.StopMusic()
+	csqcShutdown()
 
 	// if running a local server, shut it down
 	if c.demoPlayback {
@@ -599,6 +641,12 @@ func CL_SignonReply() {
 		}.Build()))
 
 	case 2:
+		csqcInit()
+		if cvars.ClientSpectator.Bool() {
+			cls.outProto.SetCmds(append(cls.outProto.GetCmds(), protos.Cmd_builder{
+				StringCmd: proto.String("spectator 1"),
+			}.Build()))
+		}
 		color := int(cvars.ClientColor.Value())
 		cls.outProto.SetCmds(append(cls.outProto.GetCmds(),
 			protos.Cmd_builder{
@@ -661,13 +709,11 @@ func CL_SendCmd() error {
 }
 
 func CL_ParseStartSoundPacket(m *protos.Sound) error {
-	const (
-		maxSounds = 2048
-	)
-	if m.GetSoundNum() > maxSounds {
+	limits := protocol.MaxLimits(cl.protocol)
+	if m.GetSoundNum() > int32(limits.Sounds) {
 		return fmt.Errorf("CL_ParseStartSoundPacket: %d > MAX_SOUNDS", m.GetSoundNum())
 	}
-	if m.GetEntity() > int32(cap(cl.entities)) {
+	if m.GetEntity() > int32(max(cap(cl.entities), limits.Edicts)) {
 		return fmt.Errorf("CL_ParseStartSoundPacket: ent = %d", m.GetEntity())
 	}
 	volume := float32(1.0)
@@ -1510,6 +1556,8 @@ func clientRecordDemo(a cbuf.Arguments) error {
 
 		buf.WriteByte(svc.SignonNum)
 		buf.WriteByte(3)
+		// the demo has none of the entity frames the server uses for deltas
+		cl.entityFrames.RequestFull()
 
 		if err := cls.writeDemoMessage(cls.demoSignon[0].Bytes()); err != nil {
 			return fmt.Errorf("Could not write demo: %w", err)
@@ -1887,6 +1935,11 @@ func (c *Client) ColorForEntity(e *Entity) vec.Vec3 {
 
 func (c *Client) ClearState() error {
 	cls.signon = 0
+	csqcShutdown()
+	clearPrediction()
+	// the server stuffs new values if it runs csqc
+	cvars.CSQCProgCRC.Reset()
+	cvars.CSQCProgSize.Reset()
 	cl = Client{
 		staticEntities: make([]Entity, 0, 512),
 	}
@@ -15,11 +15,12 @@ import (
 	"goquake/cvars"
 	"goquake/math/vec"
 	"goquake/mdl"
-	"goquake/model"
 	"goquake/protocol"
 	"goquake/protos"
 	qsnd "goquake/snd"
 	"goquake/spr"
+
+	"google.golang.org/protobuf/proto"
 )
 
 func parseBaseline(pb *protos.Baseline, e *Entity) {
@@ -91,6 +92,8 @@ func (c *Client) ParseServerMessage(pb *protos.ServerMessage) (serverState, erro
 			c.roll = scmd.GetSetAngle().GetZ()
 		case protos.SCmd_SetViewEntity_case:
 			c.viewentity = int(scmd.GetSetViewEntity())
+			// the player states belong to the old view entity
+			prediction.valid = false
 		case protos.SCmd_LightStyle_case:
 			if err := readLightStyle(scmd.GetLightStyle().GetIdx(), scmd.GetLightStyle().GetNewStyle()); err != nil {
 				return serverDisconnected, err
@@ -181,7 +184,8 @@ func (c *Client) ParseServerMessage(pb *protos.ServerMessage) (serverState, erro
 				vec.Vec3{org.GetX(), org.GetY(), org.GetZ()},
 				float32(s.GetVolume())/255, float32(s.GetAttenuation())/64)
 		case protos.SCmd_CdTrack_case:
-			// We do not play cds
+			t := scmd.GetCdTrack()
+			playCDTrack(int(t.GetTrackNumber()), int(t.GetLoopTrack()))
 		case protos.SCmd_Intermission_case:
 			c.intermission = 1
 			c.intermissionTime = int(c.time)
@@ -214,6 +218,24 @@ func (c *Client) ParseServerMessage(pb *protos.ServerMessage) (serverState, erro
 			fog.Update(f.GetDensity(), f.GetRed(), f.GetGreen(), f.GetBlue(), float64(f.GetTime()))
 		case protos.SCmd_Achievement_case:
 			slog.Debug("Ignoring svc_achievement", slog.String("Archievement", scmd.GetAchievement()))
+		case protos.SCmd_CsqcEvent_case:
+			csqc.ParseEvent(scmd.GetCsqcEvent())
+		case protos.SCmd_PacketEntities_case:
+			f, err := c.entityFrames.Apply(scmd.GetPacketEntities())
+			if err != nil {
+				return serverDisconnected, err
+			}
+			if f == nil {
+				// lost the delta frame, wait for a full update
+				continue
+			}
+			for i := range f.Entities {
+				if err := c.ParseEntityUpdate(f.Entities[i].EntityUpdate()); err != nil {
+					return serverDisconnected, err
+				}
+			}
+		case protos.SCmd_PlayerState_case:
+			prediction.parsePlayerState(scmd.GetPlayerState())
 		}
 	}
 	return serverRunning, nil
@@ -253,6 +275,13 @@ func (c *Client) ParseServerInfo(si *protos.ServerInfo) error {
 		}
 	}
 
+	if c.protocol == protocol.GoQuake && cvars.ClientProtobuf.Bool() {
+		// ask for serialized server messages instead of the byte encoding
+		cls.outProto.SetCmds(append(cls.outProto.GetCmds(), protos.Cmd_builder{
+			Protobuf: proto.Bool(true),
+		}.Build()))
+	}
+
 	if si.GetMaxClients() < 1 || si.GetMaxClients() > 16 {
 		return fmt.Errorf("Bad maxclients (%d) from server", si.GetMaxClients())
 	}
@@ -269,15 +298,16 @@ func (c *Client) ParseServerInfo(si *protos.ServerInfo) error {
 	conlog.Printf("Using protocol %d\n", c.protocol)
 
 	c.modelPrecache = c.modelPrecache[:0]
-	if len(si.GetModelPrecache()) >= 2048 {
-		return fmt.Errorf("Server sent too many model precaches")
+	limits := protocol.MaxLimits(c.protocol)
+	if len(si.GetModelPrecache()) >= limits.Models {
+		return fmt.Errorf("Server sent too many model precaches for protocol %d", c.protocol)
 	}
 	if len(si.GetModelPrecache()) >= 256 {
 		slog.Debug("models exceeds standard limit of 256.", slog.Int("Count", len(si.GetModelPrecache())))
 	}
 
-	if len(si.GetSoundPrecache()) >= 2048 {
-		return fmt.Errorf("Server sent too many sound precaches")
+	if len(si.GetSoundPrecache()) >= limits.Sounds {
+		return fmt.Errorf("Server sent too many sound precaches for protocol %d", c.protocol)
 	}
 	if len(si.GetSoundPrecache()) >= 256 {
 		slog.Debug("sounds exceeds standard limit of 256.", slog.Int("Count", len(si.GetSoundPrecache())))
@@ -405,7 +435,7 @@ func (c *Client) ParseEntityUpdate(eu *protos.EntityUpdate) error {
 	if eu.HasModel() {
 		modNum = int(eu.GetModel())
 	}
-	if modNum >= model.MAX_MODELS {
+	if modNum >= protocol.MaxLimits(c.protocol).Models {
 		return fmt.Errorf("CL_ParseModel: mad modnum")
 	}
 	if eu.HasFrame() {
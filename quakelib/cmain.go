@@ -95,7 +95,8 @@ func CallCMain() error {
 	// TODO(therjak): this should get the cmdl passed in
 	//  this is probably also the correct place to create the svTODO instead of
 	//  above and call HostInit within NewServer
-	server.HostInit()
+	svTODO.HostInit()
+	svTODO.WatchCvars()
 	if err := wad.Load(); err != nil {
 		return err
 	}
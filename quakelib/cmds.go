@@ -42,6 +42,15 @@ func addCommand(name string, f cmd.QFunc) {
 	must(commands.Add(name, f))
 }
 
+func cvarHelp(name string) {
+	cv, ok := (*commandVars)[name]
+	if !ok {
+		conlog.Printf("help: variable %v not found\n", name)
+		return
+	}
+	conlog.Printf("%s", cv.Help())
+}
+
 func echo(a cbuf.Arguments) error {
 	for _, arg := range a.Args()[1:] {
 		conlog.Printf("%s ", arg)
@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package quakelib
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"goquake/cmd"
+	"goquake/conlog"
+	"goquake/filesystem"
+	kc "goquake/keycode"
+)
+
+var completer = cmd.NewCompleter(commands.Names, aliases.Names, commandVars.Names)
+
+func addCompletion(name string, f cmd.ArgsFunc) {
+	completer.AddArgs(name, f)
+}
+
+// complete completes the word in front of the cursor. All matches get
+// printed if there is more than one.
+func (k *qKeyInput) complete() {
+	line := string(k.buf[:k.cursorXPos])
+	rest := string(k.buf[k.cursorXPos:])
+	r, matches := completer.Complete(line)
+	if len(matches) > 0 {
+		conlog.Printf("%s\n", line)
+		for _, m := range matches {
+			conlog.Printf("  %s\n", m)
+		}
+	}
+	k.buf = append([]byte(r), rest...)
+	k.cursorXPos = len(r)
+}
+
+// completeFirst returns an ArgsFunc completing only the first argument.
+func completeFirst(f func() []string) cmd.ArgsFunc {
+	return func(args []string) []string {
+		if len(args) != 1 {
+			return nil
+		}
+		return f()
+	}
+}
+
+// globNames returns the names without directory and extension of all game
+// files matching pattern.
+func globNames(pattern string) []string {
+	files, _ := filesystem.Glob(pattern)
+	r := make([]string, 0, len(files))
+	for _, f := range files {
+		r = append(r, filesystem.StripExt(path.Base(f)))
+	}
+	return r
+}
+
+func mapNames() []string {
+	return globNames("maps/*.bsp")
+}
+
+func demoNames() []string {
+	return globNames("*.dem")
+}
+
+func configNames() []string {
+	files, _ := filesystem.Glob("*.cfg")
+	return files
+}
+
+// saveNames returns the savegames, they are only read from the game
+// directory and not from paks.
+func saveNames() []string {
+	files, _ := filepath.Glob(filepath.Join(filesystem.GameDir(), "*.sav"))
+	r := make([]string, 0, len(files))
+	for _, f := range files {
+		r = append(r, strings.TrimSuffix(filepath.Base(f), ".sav"))
+	}
+	return r
+}
+
+// cvarValues completes the cvar name and its current and default value.
+func cvarValues(args []string) []string {
+	if len(args) == 1 {
+		return commandVars.Names()
+	}
+	cv, ok := (*commandVars)[args[1]]
+	if !ok {
+		return nil
+	}
+	return []string{cv.String(), cv.Default()}
+}
+
+// bindArgs completes the key name and the command.
+func bindArgs(args []string) []string {
+	switch len(args) {
+	case 1:
+		return kc.Names()
+	case 2:
+		return append(commands.Names(), aliases.Names()...)
+	}
+	return nil
+}
+
+func init() {
+	addCompletion("map", completeFirst(mapNames))
+	addCompletion("changelevel", completeFirst(mapNames))
+	addCompletion("playdemo", completeFirst(demoNames))
+	addCompletion("timedemo", completeFirst(demoNames))
+	addCompletion("load", completeFirst(saveNames))
+	addCompletion("save", completeFirst(saveNames))
+	addCompletion("exec", completeFirst(configNames))
+	addCompletion("cycle", cvarValues)
+	addCompletion("set", cvarValues)
+	addCompletion("seta", cvarValues)
+	addCompletion("toggle", completeFirst(commandVars.Names))
+	addCompletion("inc", completeFirst(commandVars.Names))
+	addCompletion("reset", completeFirst(commandVars.Names))
+	addCompletion("help", completeFirst(commandVars.Names))
+	addCompletion("bind", bindArgs)
+	addCompletion("unbind", completeFirst(kc.Names))
+}
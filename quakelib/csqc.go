@@ -0,0 +1,245 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package quakelib
+
+import (
+	"log/slog"
+
+	"goquake/conlog"
+	"goquake/cvars"
+	"goquake/net"
+	"goquake/progs"
+	"goquake/version"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// csqc is nil if the game has no csprogs.dat or if the local copy does not
+// match the one announced by the server. The engine falls back to its own
+// hud in that case.
+var csqc *csqcVM
+
+// csqcGlobals holds the offsets of the globals the engine writes to.
+// An offset of 0 means the csprogs.dat does not define the global.
+type csqcGlobals struct {
+	self         int32
+	other        int32
+	world        int32
+	time         int32
+	frameTime    int32
+	localEntNum  int32
+	localNum     int32
+	maxClients   int32
+	intermission int32
+	vForward     int32
+	vRight       int32
+	vUp          int32
+}
+
+// csqcInit loads csprogs.dat if the server announced one and calls CSQC_Init.
+// It is called after the serverinfo got parsed and the stuffed csqc_progcrc
+// and csqc_progsize got executed.
+func csqcInit() {
+	csqcShutdown()
+
+	size := int(cvars.CSQCProgSize.Value())
+	if size <= 0 {
+		// server does not run csqc
+		return
+	}
+	p, err := progs.LoadCSProgs()
+	if err != nil {
+		conlog.Warning("CSQC: %v\n", err)
+		return
+	}
+	if int(p.CRC) != int(cvars.CSQCProgCRC.Value()) || p.Size != size {
+		conlog.Warning("CSQC: %s does not match the server version, ignoring it\n", progs.CSProgsName)
+		return
+	}
+	v := newCSQC(p)
+	csqc = v
+
+	if v.initFunc != 0 {
+		// void CSQC_Init(float apilevel, string enginename, float engineversion)
+		v.Prog.RawGlobalsF[progs.OffsetParm0] = 0
+		v.Prog.Globals.Parm1[0] = v.Prog.AddString("GoQuake")
+		v.Prog.RawGlobalsF[progs.OffsetParm2] = version.Base
+		v.call(v.initFunc)
+	}
+}
+
+// csqcShutdown calls CSQC_Shutdown and drops the csqc VM.
+func csqcShutdown() {
+	v := csqc
+	if v == nil {
+		return
+	}
+	csqc = nil
+	if v.shutdownFunc != 0 {
+		v.setGlobals()
+		if err := v.executeProgram(v.shutdownFunc); err != nil {
+			slog.Error("CSQC_Shutdown", slog.Any("err", err))
+		}
+	}
+	v.Entvars.Free()
+}
+
+func newCSQC(p *progs.LoadedProg) *csqcVM {
+	v := &csqcVM{
+		VM:        progs.NewVM("csqc"),
+		free:      make([]bool, csqcMaxEdicts),
+		numEdicts: 1, // the world
+	}
+	v.Prog = p
+	v.Entvars = progs.AllocEntvars(csqcMaxEdicts, p.EdictSize, p)
+	v.builtins = v.newBuiltins()
+
+	function := func(name string) int32 {
+		f, err := p.FindFunction(name)
+		if err != nil {
+			return 0
+		}
+		return int32(f)
+	}
+	v.initFunc = function("CSQC_Init")
+	v.updateViewFunc = function("CSQC_UpdateView")
+	v.drawHudFunc = function("CSQC_DrawHud")
+	v.parseEventFunc = function("CSQC_Parse_Event")
+	v.shutdownFunc = function("CSQC_Shutdown")
+
+	global := func(name string) int32 {
+		d, err := p.FindGlobalDef(name)
+		if err != nil {
+			return 0
+		}
+		return int32(d.Offset)
+	}
+	v.globals = csqcGlobals{
+		self:         global("self"),
+		other:        global("other"),
+		world:        global("world"),
+		time:         global("time"),
+		frameTime:    global("frametime"),
+		localEntNum:  global("player_localentnum"),
+		localNum:     global("player_localnum"),
+		maxClients:   global("maxclients"),
+		intermission: global("intermission"),
+		vForward:     global("v_forward"),
+		vRight:       global("v_right"),
+		vUp:          global("v_up"),
+	}
+	return v
+}
+
+func (v *csqcVM) setGlobalF(o int32, f float32) {
+	if o != 0 {
+		v.Prog.RawGlobalsF[o] = f
+	}
+}
+
+func (v *csqcVM) setGlobalI(o int32, i int32) {
+	if o != 0 {
+		v.Prog.RawGlobalsI[o] = i
+	}
+}
+
+func (v *csqcVM) setGlobalV(o int32, f [3]float32) {
+	if o != 0 {
+		copy(v.Prog.RawGlobalsF[o:o+3], f[:])
+	}
+}
+
+// setGlobals updates the globals which reflect the client state
+func (v *csqcVM) setGlobals() {
+	v.setGlobalI(v.globals.self, 0)
+	v.setGlobalI(v.globals.other, 0)
+	v.setGlobalI(v.globals.world, 0)
+	v.setGlobalF(v.globals.time, float32(cl.time))
+	v.setGlobalF(v.globals.frameTime, float32(cl.time-cl.oldTime))
+	v.setGlobalF(v.globals.localEntNum, float32(cl.viewentity))
+	v.setGlobalF(v.globals.localNum, float32(cl.viewentity-1))
+	v.setGlobalF(v.globals.maxClients, float32(cl.maxClients))
+	v.setGlobalF(v.globals.intermission, float32(cl.intermission))
+}
+
+// call runs the function fnum. On a program error the csqc VM gets dropped and
+// the engine continues with its own hud.
+func (v *csqcVM) call(fnum int32) bool {
+	v.setGlobals()
+	if err := v.executeProgram(fnum); err != nil {
+		conlog.Printf("CSQC error in %s: %v, disabling csqc\n", v.FuncName(), err)
+		gl.Disable(gl.SCISSOR_TEST)
+		csqc = nil
+		return false
+	}
+	return true
+}
+
+// UpdateView calls CSQC_UpdateView. Unlike in other engines the 3d view is
+// already rendered by the engine, csqc can only draw on top of it.
+func (v *csqcVM) UpdateView(width, height int) {
+	if v == nil || v.updateViewFunc == 0 {
+		return
+	}
+	qCanvas.Set(CANVAS_DEFAULT)
+	// void CSQC_UpdateView(float vwidth, float vheight, float notmenu)
+	v.Prog.RawGlobalsF[progs.OffsetParm0] = float32(width)
+	v.Prog.RawGlobalsF[progs.OffsetParm1] = float32(height)
+	v.Prog.RawGlobalsF[progs.OffsetParm2] = 1
+	v.call(v.updateViewFunc)
+	gl.Disable(gl.SCISSOR_TEST)
+}
+
+// DrawHud calls CSQC_DrawHud and returns false if the engine should draw its
+// own statusbar.
+func (v *csqcVM) DrawHud(width, height int, showScores bool) bool {
+	if v == nil || v.drawHudFunc == 0 {
+		return false
+	}
+	qCanvas.Set(CANVAS_DEFAULT)
+	// void CSQC_DrawHud(vector virtsize, float showscores)
+	*v.Prog.Globals.Parm0f() = [3]float32{float32(width), float32(height), 0}
+	v.Prog.RawGlobalsF[progs.OffsetParm1] = 0
+	if showScores {
+		v.Prog.RawGlobalsF[progs.OffsetParm1] = 1
+	}
+	ok := v.call(v.drawHudFunc)
+	gl.Disable(gl.SCISSOR_TEST)
+	return ok
+}
+
+// ParseEvent hands the payload of a svc_csqcevent to CSQC_Parse_Event.
+func (v *csqcVM) ParseEvent(data []byte) {
+	if v == nil || v.parseEventFunc == 0 {
+		return
+	}
+	v.msg = net.NewQReader(data)
+	v.call(v.parseEventFunc)
+	v.msg = nil
+}
+
+func (v *csqcVM) edictAlloc() (int32, error) {
+	for i := 1; i < v.numEdicts; i++ {
+		if v.free[i] {
+			v.free[i] = false
+			v.Entvars.Clear(i)
+			return int32(i), nil
+		}
+	}
+	if v.numEdicts == csqcMaxEdicts {
+		slog.Error("CSQC: no free edicts")
+		return 0, errCSQC
+	}
+	i := v.numEdicts
+	v.numEdicts++
+	v.Entvars.Clear(i)
+	return int32(i), nil
+}
+
+func (v *csqcVM) edictFree(i int32) {
+	if i <= 0 || int(i) >= v.numEdicts {
+		return
+	}
+	v.Entvars.Clear(int(i))
+	v.free[i] = true
+}
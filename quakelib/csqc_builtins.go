@@ -0,0 +1,673 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package quakelib
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"goquake/cbuf"
+	"goquake/conlog"
+	"goquake/math"
+	"goquake/math/vec"
+	"goquake/progs"
+	"goquake/protos"
+	"goquake/wad"
+
+	"github.com/chewxy/math32"
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// The builtin numbers follow the csqc definitions of FTE and DP.
+func (v *csqcVM) newBuiltins() []func() error {
+	return []func() error{
+		1:  v.makeVectors,   // void(vector ang) makevectors
+		2:  v.setOrigin,     // void(entity e, vector o) setorigin
+		6:  v.doBreak,       // void() break
+		7:  v.random,        // float() random
+		9:  v.normalize,     // vector(vector v) normalize
+		10: v.terminalError, // void(string e) error
+		12: v.vlen,          // float(vector v) vlen
+		13: v.vecToYaw,      // float(vector v) vectoyaw
+		14: v.spawn,         // entity() spawn
+		15: v.remove,        // void(entity e) remove
+		18: v.find,          // entity(entity start, .string fld, string match) find
+		25: v.dprint,        // void(string s) dprint
+		26: v.ftos,          // string(float f) ftos
+		27: v.vtos,          // string(vector v) vtos
+		29: v.traceOn,       // void() traceon
+		30: v.traceOff,      // void() traceoff
+		36: v.rint,          // float(float v) rint
+		37: v.floor,         // float(float v) floor
+		38: v.ceil,          // float(float v) ceil
+		43: v.fabs,          // float(float f) fabs
+		45: v.cvar,          // float(string s) cvar
+		46: v.localCmd,      // void(string s) localcmd
+		47: v.nextEnt,       // entity(entity e) nextent
+		48: v.particle,      // void(vector o, vector d, float color, float count) particle
+		51: v.vecToAngles,   // vector(vector v) vectoangles
+		72: v.cvarSet,       // void(string var, string val) cvar_set
+		81: v.stof,          // float(string s) stof
+
+		114: v.strlen,    // float(string s) strlen
+		115: v.strcat,    // string(string s1, ...) strcat
+		116: v.substring, // string(string s, float start, float length) substring
+
+		316: v.isCachedPic,       // float(string name) iscachedpic
+		317: v.precachePic,       // string(string name) precache_pic
+		318: v.drawGetImageSize,  // vector(string name) drawgetimagesize
+		320: v.drawCharacter,     // float(vector position, float character, vector size, vector rgb, float alpha, float flag) drawcharacter
+		321: v.drawString,        // float(vector position, string text, vector size, vector rgb, float alpha, float flag) drawrawstring
+		322: v.drawPic,           // float(vector position, string pic, vector size, vector rgb, float alpha, float flag) drawpic
+		323: v.drawFill,          // float(vector position, vector size, vector rgb, float alpha, float flag) drawfill
+		324: v.drawSetClipArea,   // void(float x, float y, float width, float height) drawsetcliparea
+		325: v.drawResetClipArea, // void() drawresetcliparea
+		326: v.drawString,        // float(vector position, string text, vector size, vector rgb, float alpha, float flag) drawstring
+		327: v.stringWidth,       // float(string text, float usecolours, vector fontsize) stringwidth
+
+		330: v.getStatF, // float(float stnum) getstatf
+		331: v.getStatI, // float(float stnum) getstati
+		339: v.print,    // void(string s, ...) print
+
+		360: v.readByte,   // float() readbyte
+		361: v.readChar,   // float() readchar
+		362: v.readShort,  // float() readshort
+		363: v.readLong,   // float() readlong
+		364: v.readCoord,  // float() readcoord
+		365: v.readAngle,  // float() readangle
+		366: v.readString, // string() readstring
+		367: v.readFloat,  // float() readfloat
+
+		418: v.teGunshot,      // void(vector org) te_gunshot
+		419: v.teSpike,        // void(vector org) te_spike
+		420: v.teSuperSpike,   // void(vector org) te_superspike
+		421: v.teExplosion,    // void(vector org) te_explosion
+		422: v.teTarExplosion, // void(vector org) te_tarexplosion
+		423: v.teWizSpike,     // void(vector org) te_wizspike
+		424: v.teKnightSpike,  // void(vector org) te_knightspike
+		425: v.teLavaSplash,   // void(vector org) te_lavasplash
+		426: v.teTeleport,     // void(vector org) te_teleport
+		427: v.teExplosion2,   // void(vector org, float color, float colorlength) te_explosion2
+	}
+}
+
+func (v *csqcVM) parmString(i int) (string, error) {
+	return v.Prog.String(v.Prog.RawGlobalsI[progs.OffsetParm0+i*3])
+}
+
+func (v *csqcVM) varString(first int) string {
+	var b strings.Builder
+	for i := first; i < v.Argc; i++ {
+		s, err := v.parmString(i)
+		if err != nil {
+			break
+		}
+		b.WriteString(s)
+	}
+	return b.String()
+}
+
+func (v *csqcVM) returnBool(b bool) {
+	v.Prog.Globals.Returnf()[0] = 0
+	if b {
+		v.Prog.Globals.Returnf()[0] = 1
+	}
+}
+
+func (v *csqcVM) makeVectors() error {
+	f, r, u := vec.AngleVectors(vec.VFromA(*v.Prog.Globals.Parm0f()))
+	v.setGlobalV(v.globals.vForward, f)
+	v.setGlobalV(v.globals.vRight, r)
+	v.setGlobalV(v.globals.vUp, u)
+	return nil
+}
+
+func (v *csqcVM) setOrigin() error {
+	e := v.Prog.Globals.Parm0[0]
+	o := *v.Prog.Globals.Parm1f()
+	d, err := v.Prog.FindFieldDef("origin")
+	if err != nil {
+		return nil
+	}
+	if err := v.CheckAddress(e); err != nil {
+		slog.Error("CSQC setorigin", slog.Any("err", err))
+		v.Abort()
+		return errCSQC
+	}
+	ptr, err := v.Entvars.Address(e, int32(d.Offset))
+	if err == nil {
+		err = v.Entvars.StoreVector(ptr, o)
+	}
+	if err != nil {
+		slog.Error("CSQC setorigin", slog.Any("err", err))
+		v.Abort()
+		return errCSQC
+	}
+	return nil
+}
+
+func (v *csqcVM) doBreak() error {
+	slog.Info("CSQC break statement")
+	return nil
+}
+
+func (v *csqcVM) random() error {
+	v.Prog.Globals.Returnf()[0] = cRand.Float32()
+	return nil
+}
+
+func (v *csqcVM) normalize() error {
+	ve := vec.VFromA(*v.Prog.Globals.Parm0f())
+	*v.Prog.Globals.Returnf() = ve.Normalize()
+	return nil
+}
+
+func (v *csqcVM) terminalError() error {
+	slog.Error("======CSQC ERROR======", slog.String("function", v.FuncName()), slog.String("var", v.varString(0)))
+	return fmt.Errorf("Program error")
+}
+
+func (v *csqcVM) vlen() error {
+	ve := vec.VFromA(*v.Prog.Globals.Parm0f())
+	v.Prog.Globals.Returnf()[0] = ve.Length()
+	return nil
+}
+
+func (v *csqcVM) vecToYaw() error {
+	ve := vec.VFromA(*v.Prog.Globals.Parm0f())
+	yaw := float32(0)
+	if ve[0] != 0 || ve[1] != 0 {
+		yaw = math32.Trunc((math32.Atan2(ve[1], ve[0]) * 180) / math32.Pi)
+		if yaw < 0 {
+			yaw += 360
+		}
+	}
+	v.Prog.Globals.Returnf()[0] = yaw
+	return nil
+}
+
+func (v *csqcVM) vecToAngles() error {
+	ve := vec.VFromA(*v.Prog.Globals.Parm0f())
+	var yaw, pitch float32
+	if ve[0] == 0 && ve[1] == 0 {
+		pitch = 270
+		if ve[2] > 0 {
+			pitch = 90
+		}
+	} else {
+		yaw = math32.Trunc((math32.Atan2(ve[1], ve[0]) * 180) / math32.Pi)
+		if yaw < 0 {
+			yaw += 360
+		}
+		forward := math32.Sqrt(ve[0]*ve[0] + ve[1]*ve[1])
+		pitch = math32.Trunc((math32.Atan2(ve[2], forward) * 180) / math32.Pi)
+		if pitch < 0 {
+			pitch += 360
+		}
+	}
+	*v.Prog.Globals.Returnf() = [3]float32{pitch, yaw, 0}
+	return nil
+}
+
+func (v *csqcVM) spawn() error {
+	e, err := v.edictAlloc()
+	if err != nil {
+		return err
+	}
+	v.Prog.Globals.Return[0] = e
+	return nil
+}
+
+func (v *csqcVM) remove() error {
+	v.edictFree(v.Prog.Globals.Parm0[0])
+	return nil
+}
+
+func (v *csqcVM) find() error {
+	e := v.Prog.Globals.Parm0[0]
+	f := v.Prog.Globals.Parm1[0]
+	st, err := v.parmString(2)
+	if err != nil {
+		slog.Error("CSQC find: bad search string")
+		v.Abort()
+		return errCSQC
+	}
+	for e = max(e+1, 1); int(e) < v.numEdicts; e++ {
+		if v.free[e] {
+			continue
+		}
+		ti, err := v.Entvars.Load(e, f)
+		if err != nil {
+			slog.Error("CSQC find", slog.Any("err", err))
+			v.Abort()
+			return errCSQC
+		}
+		t, err := v.Prog.String(ti)
+		if err != nil {
+			continue
+		}
+		if t == st {
+			v.Prog.Globals.Return[0] = e
+			return nil
+		}
+	}
+	v.Prog.Globals.Return[0] = 0
+	return nil
+}
+
+func (v *csqcVM) nextEnt() error {
+	for i := max(v.Prog.Globals.Parm0[0]+1, 1); int(i) < v.numEdicts; i++ {
+		if !v.free[i] {
+			v.Prog.Globals.Return[0] = i
+			return nil
+		}
+	}
+	v.Prog.Globals.Return[0] = 0
+	return nil
+}
+
+func (v *csqcVM) dprint() error {
+	slog.Debug(v.varString(0))
+	return nil
+}
+
+func (v *csqcVM) print() error {
+	conlog.Printf("%s", v.varString(0))
+	return nil
+}
+
+func (v *csqcVM) ftos() error {
+	f := v.Prog.RawGlobalsF[progs.OffsetParm0]
+	st := fmt.Sprintf("%5.1f", f)
+	if iv := int(f); f == float32(iv) {
+		st = fmt.Sprintf("%d", iv)
+	}
+	v.Prog.Globals.Return[0] = v.Prog.AddString(st)
+	return nil
+}
+
+func (v *csqcVM) vtos() error {
+	p := *v.Prog.Globals.Parm0f()
+	st := fmt.Sprintf("'%5.1f %5.1f %5.1f'", p[0], p[1], p[2])
+	v.Prog.Globals.Return[0] = v.Prog.AddString(st)
+	return nil
+}
+
+func (v *csqcVM) traceOn() error {
+	v.Trace = true
+	return nil
+}
+
+func (v *csqcVM) traceOff() error {
+	v.Trace = false
+	return nil
+}
+
+func (v *csqcVM) rint() error {
+	v.Prog.Globals.Returnf()[0] = math.RoundToEven(v.Prog.RawGlobalsF[progs.OffsetParm0])
+	return nil
+}
+
+func (v *csqcVM) floor() error {
+	v.Prog.Globals.Returnf()[0] = math32.Floor(v.Prog.RawGlobalsF[progs.OffsetParm0])
+	return nil
+}
+
+func (v *csqcVM) ceil() error {
+	v.Prog.Globals.Returnf()[0] = math32.Ceil(v.Prog.RawGlobalsF[progs.OffsetParm0])
+	return nil
+}
+
+func (v *csqcVM) fabs() error {
+	v.Prog.Globals.Returnf()[0] = math32.Abs(v.Prog.RawGlobalsF[progs.OffsetParm0])
+	return nil
+}
+
+func (v *csqcVM) cvar() error {
+	n, err := v.parmString(0)
+	if err != nil {
+		slog.Error("CSQC cvar: no string")
+		v.Abort()
+		return errCSQC
+	}
+	v.Prog.Globals.Returnf()[0] = 0
+	if cv, ok := (*commandVars)[n]; ok {
+		v.Prog.Globals.Returnf()[0] = cv.Value()
+	}
+	return nil
+}
+
+func (v *csqcVM) cvarSet() error {
+	n, err := v.parmString(0)
+	if err != nil {
+		slog.Error("CSQC cvar_set: no name string")
+		v.Abort()
+		return errCSQC
+	}
+	val, err := v.parmString(1)
+	if err != nil {
+		slog.Error("CSQC cvar_set: no value string")
+		v.Abort()
+		return errCSQC
+	}
+	if cv, ok := (*commandVars)[n]; ok {
+		cv.SetByString(val)
+	} else {
+		slog.Debug("CSQC cvar_set: variable not found", slog.String("name", n))
+	}
+	return nil
+}
+
+func (v *csqcVM) localCmd() error {
+	s, err := v.parmString(0)
+	if err != nil {
+		slog.Error("CSQC localcmd: no string")
+		v.Abort()
+		return errCSQC
+	}
+	cbuf.AddText(s)
+	return nil
+}
+
+func (v *csqcVM) stof() error {
+	s, _ := v.parmString(0)
+	f, _ := strconv.ParseFloat(strings.TrimSpace(s), 32)
+	v.Prog.Globals.Returnf()[0] = float32(f)
+	return nil
+}
+
+func (v *csqcVM) strlen() error {
+	s, _ := v.parmString(0)
+	v.Prog.Globals.Returnf()[0] = float32(len(s))
+	return nil
+}
+
+func (v *csqcVM) strcat() error {
+	v.Prog.Globals.Return[0] = v.Prog.AddString(v.varString(0))
+	return nil
+}
+
+func (v *csqcVM) substring() error {
+	s, _ := v.parmString(0)
+	start := int(v.Prog.RawGlobalsF[progs.OffsetParm1])
+	length := int(v.Prog.RawGlobalsF[progs.OffsetParm2])
+	start = math.Clamp(0, start, len(s))
+	end := len(s)
+	if length >= 0 && start+length < end {
+		end = start + length
+	}
+	v.Prog.Globals.Return[0] = v.Prog.AddString(s[start:end])
+	return nil
+}
+
+// csqcPicture returns the picture or nil if it does not exist. Unlike
+// GetCachedPicture a missing picture is not an error.
+func csqcPicture(name string) *QPic {
+	if p, ok := cachePics[name]; ok {
+		return p
+	}
+	for _, n := range []string{name, name + ".lmp"} {
+		if p, err := loadPicFromFile(n); err == nil {
+			cachePics[name] = p
+			return p
+		}
+	}
+	if wad.GetPic(name) != nil {
+		p := GetPictureFromWad(name)
+		cachePics[name] = p
+		return p
+	}
+	return nil
+}
+
+func (v *csqcVM) isCachedPic() error {
+	n, _ := v.parmString(0)
+	_, ok := cachePics[n]
+	v.returnBool(ok)
+	return nil
+}
+
+func (v *csqcVM) precachePic() error {
+	n, _ := v.parmString(0)
+	csqcPicture(n)
+	v.Prog.Globals.Return[0] = v.Prog.Globals.Parm0[0]
+	return nil
+}
+
+func (v *csqcVM) drawGetImageSize() error {
+	n, _ := v.parmString(0)
+	*v.Prog.Globals.Returnf() = [3]float32{}
+	if p := csqcPicture(n); p != nil {
+		*v.Prog.Globals.Returnf() = [3]float32{float32(p.Width), float32(p.Height), 0}
+	}
+	return nil
+}
+
+// The rgb tint of the draw functions is ignored and characters are always
+// drawn with the 8x8 console font.
+
+func (v *csqcVM) drawCharacter() error {
+	pos := *v.Prog.Globals.Parm0f()
+	c := byte(v.Prog.RawGlobalsF[progs.OffsetParm1])
+	DrawCharacterWhite(int(pos[0]), int(pos[1]), c)
+	v.returnBool(true)
+	return nil
+}
+
+func (v *csqcVM) drawString() error {
+	pos := *v.Prog.Globals.Parm0f()
+	s, _ := v.parmString(1)
+	DrawStringWhite(int(pos[0]), int(pos[1]), s)
+	v.returnBool(true)
+	return nil
+}
+
+func (v *csqcVM) stringWidth() error {
+	s, _ := v.parmString(0)
+	v.Prog.Globals.Returnf()[0] = float32(8 * len(s))
+	return nil
+}
+
+func (v *csqcVM) drawPic() error {
+	pos := *v.Prog.Globals.Parm0f()
+	n, _ := v.parmString(1)
+	size := *v.Prog.Globals.Parm2f()
+	alpha := v.Prog.RawGlobalsF[progs.OffsetParm4]
+	p := csqcPicture(n)
+	if p == nil {
+		v.returnBool(false)
+		return nil
+	}
+	w, h := size[0], size[1]
+	if w == 0 && h == 0 {
+		w, h = float32(p.Width), float32(p.Height)
+	}
+	if alpha < 1 {
+		gl.BlendColor(0, 0, 0, alpha)
+		gl.BlendFunc(gl.CONSTANT_ALPHA, gl.ONE_MINUS_CONSTANT_ALPHA)
+		gl.Enable(gl.BLEND)
+		qDrawer.Draw(pos[0], pos[1], w, h, p.Texture)
+		gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+		gl.Disable(gl.BLEND)
+	} else {
+		qDrawer.Draw(pos[0], pos[1], w, h, p.Texture)
+	}
+	v.returnBool(true)
+	return nil
+}
+
+func (v *csqcVM) drawFill() error {
+	pos := *v.Prog.Globals.Parm0f()
+	size := *v.Prog.Globals.Parm1f()
+	rgb := *v.Prog.Globals.Parm2f()
+	alpha := v.Prog.RawGlobalsF[progs.OffsetParm3]
+	qRecDrawer.Draw(pos[0], pos[1], size[0], size[1], Color{rgb[0], rgb[1], rgb[2], alpha})
+	v.returnBool(true)
+	return nil
+}
+
+func (v *csqcVM) drawSetClipArea() error {
+	x := v.Prog.RawGlobalsF[progs.OffsetParm0]
+	y := v.Prog.RawGlobalsF[progs.OffsetParm1]
+	w := v.Prog.RawGlobalsF[progs.OffsetParm2]
+	h := v.Prog.RawGlobalsF[progs.OffsetParm3]
+	// gl counts y from the bottom
+	gl.Scissor(int32(x), int32(float32(screen.Height())-y-h), int32(w), int32(h))
+	gl.Enable(gl.SCISSOR_TEST)
+	return nil
+}
+
+func (v *csqcVM) drawResetClipArea() error {
+	gl.Disable(gl.SCISSOR_TEST)
+	return nil
+}
+
+func (v *csqcVM) getStatF() error {
+	v.Prog.Globals.Returnf()[0] = float32(cl_getStat(int(v.Prog.RawGlobalsF[progs.OffsetParm0])))
+	return nil
+}
+
+func (v *csqcVM) getStatI() error {
+	v.Prog.Globals.Returnf()[0] = float32(cl_getStat(int(v.Prog.RawGlobalsF[progs.OffsetParm0])))
+	return nil
+}
+
+func (v *csqcVM) readValue(f func() (float32, error)) error {
+	if v.msg == nil {
+		slog.Error("CSQC read outside of CSQC_Parse_Event")
+		v.Abort()
+		return errCSQC
+	}
+	r, err := f()
+	if err != nil {
+		slog.Error("CSQC read past the end of the event")
+		v.Abort()
+		return errCSQC
+	}
+	v.Prog.Globals.Returnf()[0] = r
+	return nil
+}
+
+func (v *csqcVM) readByte() error {
+	return v.readValue(func() (float32, error) {
+		b, err := v.msg.ReadByte()
+		return float32(b), err
+	})
+}
+
+func (v *csqcVM) readChar() error {
+	return v.readValue(func() (float32, error) {
+		b, err := v.msg.ReadInt8()
+		return float32(b), err
+	})
+}
+
+func (v *csqcVM) readShort() error {
+	return v.readValue(func() (float32, error) {
+		b, err := v.msg.ReadInt16()
+		return float32(b), err
+	})
+}
+
+func (v *csqcVM) readLong() error {
+	return v.readValue(func() (float32, error) {
+		b, err := v.msg.ReadInt32()
+		return float32(b), err
+	})
+}
+
+func (v *csqcVM) readCoord() error {
+	return v.readValue(func() (float32, error) {
+		return v.msg.ReadCoord(cl.protocolFlags)
+	})
+}
+
+func (v *csqcVM) readAngle() error {
+	return v.readValue(func() (float32, error) {
+		return v.msg.ReadAngle(cl.protocolFlags)
+	})
+}
+
+func (v *csqcVM) readFloat() error {
+	return v.readValue(v.msg.ReadFloat32)
+}
+
+func (v *csqcVM) readString() error {
+	if v.msg == nil {
+		slog.Error("CSQC read outside of CSQC_Parse_Event")
+		v.Abort()
+		return errCSQC
+	}
+	s, err := v.msg.ReadString()
+	if err != nil {
+		slog.Error("CSQC read past the end of the event")
+		v.Abort()
+		return errCSQC
+	}
+	v.Prog.Globals.Return[0] = v.Prog.AddString(s)
+	return nil
+}
+
+func (v *csqcVM) particle() error {
+	org := vec.VFromA(*v.Prog.Globals.Parm0f())
+	dir := vec.VFromA(*v.Prog.Globals.Parm1f())
+	color := v.Prog.RawGlobalsF[progs.OffsetParm2]
+	count := v.Prog.RawGlobalsF[progs.OffsetParm3]
+	particlesRunEffect(org, dir, int(color), int(count), cl.time)
+	return nil
+}
+
+func (v *csqcVM) parmCoord() *protos.Coord {
+	o := *v.Prog.Globals.Parm0f()
+	return protos.Coord_builder{X: o[0], Y: o[1], Z: o[2]}.Build()
+}
+
+// The te_ builtins run the same effects as the temp entities sent by the server.
+
+func (v *csqcVM) teGunshot() error {
+	return cl.parseTempEntity(protos.TempEntity_builder{Gunshot: v.parmCoord()}.Build())
+}
+
+func (v *csqcVM) teSpike() error {
+	return cl.parseTempEntity(protos.TempEntity_builder{Spike: v.parmCoord()}.Build())
+}
+
+func (v *csqcVM) teSuperSpike() error {
+	return cl.parseTempEntity(protos.TempEntity_builder{SuperSpike: v.parmCoord()}.Build())
+}
+
+func (v *csqcVM) teExplosion() error {
+	return cl.parseTempEntity(protos.TempEntity_builder{Explosion: v.parmCoord()}.Build())
+}
+
+func (v *csqcVM) teTarExplosion() error {
+	return cl.parseTempEntity(protos.TempEntity_builder{TarExplosion: v.parmCoord()}.Build())
+}
+
+func (v *csqcVM) teWizSpike() error {
+	return cl.parseTempEntity(protos.TempEntity_builder{WizSpike: v.parmCoord()}.Build())
+}
+
+func (v *csqcVM) teKnightSpike() error {
+	return cl.parseTempEntity(protos.TempEntity_builder{KnightSpike: v.parmCoord()}.Build())
+}
+
+func (v *csqcVM) teLavaSplash() error {
+	return cl.parseTempEntity(protos.TempEntity_builder{LavaSplash: v.parmCoord()}.Build())
+}
+
+func (v *csqcVM) teTeleport() error {
+	return cl.parseTempEntity(protos.TempEntity_builder{Teleport: v.parmCoord()}.Build())
+}
+
+func (v *csqcVM) teExplosion2() error {
+	return cl.parseTempEntity(protos.TempEntity_builder{
+		Explosion2: protos.Explosion2_builder{
+			Position:   v.parmCoord(),
+			StartColor: int32(v.Prog.RawGlobalsF[progs.OffsetParm1]),
+			StopColor:  int32(v.Prog.RawGlobalsF[progs.OffsetParm2]),
+		}.Build(),
+	}.Build())
+}
@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package quakelib
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+
+	"goquake/cvars"
+	"goquake/net"
+	"goquake/progs"
+)
+
+var errCSQC = progs.ErrProgram
+
+const csqcMaxEdicts = 2048
+
+// csqcVM is the client side QuakeC VM running csprogs.dat.
+type csqcVM struct {
+	*progs.VM
+
+	// free marks removed entities, entity 0 is the world
+	free      []bool
+	numEdicts int
+
+	builtins []func() error
+
+	// entry points, 0 if csprogs.dat does not provide them
+	initFunc       int32
+	updateViewFunc int32
+	drawHudFunc    int32
+	parseEventFunc int32
+	shutdownFunc   int32
+
+	globals csqcGlobals
+
+	// the event currently handled by CSQC_Parse_Event
+	msg *net.QReader
+}
+
+func (v *csqcVM) CallBuiltin(num int) error {
+	if num >= len(v.builtins) || v.builtins[num] == nil {
+		slog.Error("CSQC bad builtin call number", slog.Int("num", num))
+		v.Abort()
+		return errCSQC
+	}
+	return v.builtins[num]()
+}
+
+func (v *csqcVM) CheckAddress(ent int32) error {
+	if ent < 0 || int(ent) >= v.numEdicts {
+		return fmt.Errorf("address of a bad entity %d", ent)
+	}
+	return nil
+}
+
+func (v *csqcVM) State(frame float32, think int32) error {
+	self := v.Prog.RawGlobalsI[v.globals.self]
+	v.setFieldF(self, "nextthink", v.Prog.RawGlobalsF[v.globals.time]+0.1)
+	v.setFieldF(self, "frame", frame)
+	v.setFieldI(self, "think", think)
+	return nil
+}
+
+// executeProgram runs the function fnum of csprogs.dat.
+func (v *csqcVM) executeProgram(fnum int32) error {
+	v.MaxStatements = int(cvars.ProgsMaxStatements.Value())
+	return v.Execute(fnum, v)
+}
+
+func (v *csqcVM) setFieldF(ent int32, name string, value float32) {
+	v.setFieldI(ent, name, int32(math.Float32bits(value)))
+}
+
+// setFieldI sets the field name of ent, which may come from csprogs.dat.
+// Bad entities and missing fields are ignored.
+func (v *csqcVM) setFieldI(ent int32, name string, value int32) {
+	if v.CheckAddress(ent) != nil {
+		return
+	}
+	if d, err := v.Prog.FindFieldDef(name); err == nil {
+		if ptr, err := v.Entvars.Address(ent, int32(d.Offset)); err == nil {
+			v.Entvars.Store(ptr, value)
+		}
+	}
+}
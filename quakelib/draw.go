@@ -9,6 +9,7 @@ import (
 	"goquake/cvars"
 	"goquake/filesystem"
 	"goquake/glh"
+	qimage "goquake/image"
 	"goquake/palette"
 	"goquake/texture"
 	"goquake/wad"
@@ -539,12 +540,19 @@ func loadPicFromFile(name string) (*QPic, error) {
 	if err != nil {
 		return nil, err
 	}
-	p := &QPic{
+	p := &wad.QPic{
 		Width:  int(binary.LittleEndian.Uint32(b[0:])),
 		Height: int(binary.LittleEndian.Uint32(b[4:])),
+		Data:   b[8:],
 	}
-	p.Texture = textureManager.LoadWadTex(name, p.Width, p.Height, b[8:])
-	return p, nil
+	if cvars.GlExternalTextures.Bool() {
+		p.Image, _ = qimage.Replacement(filesystem.StripExt(name))
+	}
+	return &QPic{
+		Width:   p.Width,
+		Height:  p.Height,
+		Texture: textureManager.loadWadPic(name, p),
+	}, nil
 }
 
 var nullPic *QPic
@@ -571,7 +579,7 @@ func GetPictureFromWad(name string) *QPic {
 		return getNullPic()
 	}
 	n := fmt.Sprintf("gfx.wad:%s", name)
-	t := textureManager.LoadWadTex(n, p.Width, p.Height, p.Data)
+	t := textureManager.loadWadPic(n, p)
 	return &QPic{
 		Width:   p.Width,
 		Height:  p.Height,
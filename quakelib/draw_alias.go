@@ -79,10 +79,14 @@ func (l *lerpData) setupAliasFrame(e *Entity, m *mdl.Model) {
 		poseNum += len(f.Group)
 	}
 	f := &m.Frames[frame]
-	fg := &f.Group
-	e.LerpTime = float64(f.Interval)
+	l.setupPoses(e, poseNum, len(f.Group), f.Interval, m.Flags()&mdl.NoLerp != 0)
+}
+
+// setupPoses selects the poses to lerp between for a frame starting at
+// poseNum with numPoses poses which animate every interval.
+func (l *lerpData) setupPoses(e *Entity, poseNum, numPoses int, interval float32, noLerp bool) {
+	e.LerpTime = float64(interval)
 
-	numPoses := len(*fg)
 	if numPoses > 1 {
 		poseNum += int((cl.time / e.LerpTime)) % numPoses
 	}
@@ -103,7 +107,7 @@ func (l *lerpData) setupAliasFrame(e *Entity, m *mdl.Model) {
 			e.CurrentPose = poseNum
 		}
 	}
-	if cvars.RLerpModels.Bool() && !(cvars.RLerpModels.Value() != 2 && m.Flags()&mdl.NoLerp != 0) {
+	if cvars.RLerpModels.Bool() && !(cvars.RLerpModels.Value() != 2 && noLerp) {
 		if e.LerpFlags&lerpFinish != 0 && numPoses == 1 {
 			l.blend = qmath.Clamp(0, (cl.time-e.LerpStart)/(e.LerpFinish-e.LerpStart), 1)
 		} else {
@@ -178,19 +182,24 @@ func (r *qRenderer) cullBrush(e *Entity, model *bsp.Model) bool {
 }
 
 func (r *qRenderer) cullAlias(e *Entity, model *mdl.Model) bool {
+	return r.cullRotated(e, model.Mins(), model.Maxs(), model.Radius, model.YawRadius)
+}
+
+// cullRotated culls a model which can be rotated around all axes.
+func (r *qRenderer) cullRotated(e *Entity, mins, maxs vec.Vec3, radius, yawRadius float32) bool {
 	if e.Angles[0] != 0 || e.Angles[2] != 0 {
 		return r.CullBox(
-			vec.Add(e.Origin, vec.Vec3{-model.Radius, -model.Radius, -model.Radius}),
-			vec.Add(e.Origin, vec.Vec3{model.Radius, model.Radius, model.Radius}))
+			vec.Add(e.Origin, vec.Vec3{-radius, -radius, -radius}),
+			vec.Add(e.Origin, vec.Vec3{radius, radius, radius}))
 	}
 	if e.Angles[1] != 0 {
 		return r.CullBox(
-			vec.Add(e.Origin, vec.Vec3{-model.YawRadius, -model.YawRadius, model.Mins()[2]}),
-			vec.Add(e.Origin, vec.Vec3{model.YawRadius, model.YawRadius, model.Maxs()[2]}))
+			vec.Add(e.Origin, vec.Vec3{-yawRadius, -yawRadius, mins[2]}),
+			vec.Add(e.Origin, vec.Vec3{yawRadius, yawRadius, maxs[2]}))
 	}
 	return r.CullBox(
-		vec.Add(e.Origin, model.Mins()),
-		vec.Add(e.Origin, model.Maxs()))
+		vec.Add(e.Origin, mins),
+		vec.Add(e.Origin, maxs))
 }
 
 func (r *qRenderer) DrawAliasModel(e *Entity, model *mdl.Model) {
@@ -256,13 +265,6 @@ func calcShadeVector(e *Entity) vec.Vec3 {
 }
 
 func drawAliasFrame(m *mdl.Model, ld *lerpData, tx, fb *texture.Texture, e *Entity, alpha float32, mv, p qUniform) {
-	lightColor := cl.ColorForEntity(e)
-	shadeVec := calcShadeVector(e)
-
-	var blend float32
-	if ld.pose1 != ld.pose2 {
-		blend = float32(ld.blend)
-	}
 	aliasDrawer.prog.Use()
 	m.VertexArrayBuffer.Bind()
 	m.VertexElementArrayBuffer.Bind()
@@ -290,30 +292,42 @@ func drawAliasFrame(m *mdl.Model, ld *lerpData, tx, fb *texture.Texture, e *Enti
 	gl.VertexAttribPointerWithOffset(3, 4, gl.BYTE, true, 8, p2+4)
 	gl.VertexAttribPointerWithOffset(4, 2, gl.FLOAT, false, 0, uintptr(m.STOffset))
 
-	gl.Uniform1f(aliasDrawer.blend, blend)
-	gl.Uniform3f(aliasDrawer.shadeVec, shadeVec[0], shadeVec[1], shadeVec[2])
-	gl.Uniform4f(aliasDrawer.lightColor, lightColor[0], lightColor[1], lightColor[2], alpha)
-	gl.Uniform1i(aliasDrawer.tex, 0)
-	gl.Uniform1i(aliasDrawer.fullBrightTex, 1)
+	aliasDrawer.setUniforms(ld, fb, e, alpha, mv, p)
+
+	textureManager.BindUnit(tx, gl.TEXTURE0)
+	textureManager.BindUnit(fb, gl.TEXTURE1)
+
+	gl.DrawElements(gl.TRIANGLES, int32(m.IndiceCount), gl.UNSIGNED_SHORT, gl.PtrOffset(0))
+}
+
+// setUniforms sets all uniforms of the alias shader, prog needs to be in use.
+func (d *qAliasDrawer) setUniforms(ld *lerpData, fb *texture.Texture, e *Entity, alpha float32, mv, p qUniform) {
+	lightColor := cl.ColorForEntity(e)
+	shadeVec := calcShadeVector(e)
+
+	var blend float32
+	if ld.pose1 != ld.pose2 {
+		blend = float32(ld.blend)
+	}
+	gl.Uniform1f(d.blend, blend)
+	gl.Uniform3f(d.shadeVec, shadeVec[0], shadeVec[1], shadeVec[2])
+	gl.Uniform4f(d.lightColor, lightColor[0], lightColor[1], lightColor[2], alpha)
+	gl.Uniform1i(d.tex, 0)
+	gl.Uniform1i(d.fullBrightTex, 1)
 	var useFullBright int32
 	if fb != nil {
 		useFullBright = 1
 	}
-	gl.Uniform1i(aliasDrawer.useFullBright, useFullBright)
+	gl.Uniform1i(d.useFullBright, useFullBright)
 	var useOverBright int32
 	if cvars.GlOverBrightModels.Bool() {
 		useOverBright = 1
 	}
-	gl.Uniform1i(aliasDrawer.useOverBright, useOverBright)
-	gl.Uniform1f(aliasDrawer.fogDensity, fog.Density)
-	gl.Uniform4f(aliasDrawer.fogColor, fog.Color.R, fog.Color.G, fog.Color.B, 0)
-	p.SetAsUniform(aliasDrawer.projection)
-	mv.SetAsUniform(aliasDrawer.modelview)
-
-	textureManager.BindUnit(tx, gl.TEXTURE0)
-	textureManager.BindUnit(fb, gl.TEXTURE1)
-
-	gl.DrawElements(gl.TRIANGLES, int32(m.IndiceCount), gl.UNSIGNED_SHORT, gl.PtrOffset(0))
+	gl.Uniform1i(d.useOverBright, useOverBright)
+	gl.Uniform1f(d.fogDensity, fog.Density)
+	gl.Uniform4f(d.fogColor, fog.Color.R, fog.Color.G, fog.Color.B, 0)
+	p.SetAsUniform(d.projection)
+	mv.SetAsUniform(d.modelview)
 }
 
 var aliasDrawer *qAliasDrawer
@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package quakelib
+
+import (
+	"goquake/mdl"
+	"goquake/mesh"
+	"goquake/texture"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+func (l *lerpData) setupMeshFrame(e *Entity, m *mesh.Model) {
+	frame := e.Frame
+	if frame >= len(m.Frames) || frame < 0 {
+		frame = 0
+	}
+	f := &m.Frames[frame]
+	l.setupPoses(e, f.FirstPose, f.PoseCount, f.Interval, m.Flags()&mdl.NoLerp != 0)
+}
+
+// DrawMeshModel draws md3 and iqm models with the alias shader.
+func (r *qRenderer) DrawMeshModel(e *Entity, model *mesh.Model) {
+	ld := &lerpData{}
+	ld.setupMeshFrame(e, model)
+	ld.setupEntityTransform(e)
+	if r.cullRotated(e, model.Mins(), model.Maxs(), model.Radius, model.YawRadius) {
+		return
+	}
+	alpha := entAlphaDecode(e.Alpha)
+	if alpha == 0 {
+		return
+	}
+	if alpha < 1 {
+		gl.DepthMask(false)
+		gl.Enable(gl.BLEND)
+		defer gl.DepthMask(true)
+		defer gl.Disable(gl.BLEND)
+	}
+
+	modelview := view.modelView.Copy()
+	modelview.Translate(ld.origin[0], ld.origin[1], ld.origin[2])
+	modelview.RotateZ(ld.angles[1])
+	modelview.RotateY(-ld.angles[0])
+	modelview.RotateX(ld.angles[2])
+
+	for _, ms := range model.Meshes {
+		drawMesh(ms, ld, ms.Skin(e.SkinNum), e, alpha, modelview, view.projection)
+	}
+}
+
+func drawMesh(m *mesh.Mesh, ld *lerpData, tx *texture.Texture, e *Entity, alpha float32, mv, p qUniform) {
+	aliasDrawer.prog.Use()
+	m.VertexArrayBuffer.Bind()
+	m.VertexElementArrayBuffer.Bind()
+
+	gl.EnableVertexAttribArray(0) // pose1vert
+	defer gl.DisableVertexAttribArray(0)
+	gl.EnableVertexAttribArray(1) // pose1normal
+	defer gl.DisableVertexAttribArray(1)
+	gl.EnableVertexAttribArray(2) // pose2vert
+	defer gl.DisableVertexAttribArray(2)
+	gl.EnableVertexAttribArray(3) // pose2normal
+	defer gl.DisableVertexAttribArray(3)
+	gl.EnableVertexAttribArray(4) // texcoords
+	defer gl.DisableVertexAttribArray(4)
+
+	// layout:
+	// 3*float32 + 3*float32, for each pose and vertex
+	// 2*float32 for each vertex
+
+	verts := len(m.TexCoords)
+	p1 := uintptr(ld.pose1 * verts * mesh.VertexSize)
+	p2 := uintptr(ld.pose2 * verts * mesh.VertexSize)
+	gl.VertexAttribPointerWithOffset(0, 3, gl.FLOAT, false, mesh.VertexSize, p1)
+	gl.VertexAttribPointerWithOffset(1, 3, gl.FLOAT, false, mesh.VertexSize, p1+12)
+	gl.VertexAttribPointerWithOffset(2, 3, gl.FLOAT, false, mesh.VertexSize, p2)
+	gl.VertexAttribPointerWithOffset(3, 3, gl.FLOAT, false, mesh.VertexSize, p2+12)
+	gl.VertexAttribPointerWithOffset(4, 2, gl.FLOAT, false, 0, uintptr(m.STOffset))
+
+	aliasDrawer.setUniforms(ld, nil, e, alpha, mv, p)
+
+	textureManager.BindUnit(tx, gl.TEXTURE0)
+	textureManager.BindUnit(nil, gl.TEXTURE1)
+
+	gl.DrawElements(gl.TRIANGLES, int32(len(m.Indices)), gl.UNSIGNED_INT, gl.PtrOffset(0))
+}
@@ -11,6 +11,7 @@ import (
 	"goquake/math/vec"
 	"goquake/mdl"
 	"goquake/model"
+	"goquake/protocol"
 	"goquake/texture"
 )
 
@@ -85,7 +86,7 @@ func createPlayerSkin(i int, e *Entity) {
 	flags := texture.TexPrefPad | texture.TexPrefOverwrite
 	t := texture.NewTexture(ot.Width, ot.Height, flags, name, ot.Typ, ot.Data)
 	textureManager.addActiveTexture(t)
-	textureManager.loadIndexed(t, t.Data)
+	textureManager.load(t)
 	playerTextures[e] = t
 	translatePlayerSkin(e)
 }
@@ -304,12 +305,13 @@ func (c *Client) CreateStaticEntity() (*Entity, error) {
 }
 
 // GetOrCreateEntity returns cl.entities[num] and extends cl.entities if not long enough.
+// Past max_edicts it only grows up to the limit of the protocol.
 func (c *Client) GetOrCreateEntity(num int) (*Entity, error) {
 	if num < 0 {
 		return nil, fmt.Errorf("CL_EntityNum: %d is an invalid number", num)
 	}
 	if num >= len(cl.entities) {
-		if num >= cap(cl.entities) {
+		if num >= cap(cl.entities) && num >= protocol.MaxLimits(c.protocol).Edicts {
 			return nil, fmt.Errorf("CL_EntityNum: %d is an invalid number", num)
 		}
 		for i := len(cl.entities); i <= num; i++ {
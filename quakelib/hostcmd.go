@@ -50,6 +50,14 @@ func init() {
 	addCommand("status", hostFwd)
 	addCommand("ping", hostFwd)
 	addCommand("kill", hostFwd)
+	addCommand("ready", hostFwd)
+	addCommand("notready", hostFwd)
+	addCommand("callvote", hostFwd)
+	addCommand("vote", hostFwd)
+	addCommand("maplist", hostMapList)
+	addCommand("nextmap", hostNextMap)
+	addCommand("addbot", hostAddBot)
+	addCommand("removebot", hostRemoveBot)
 }
 
 // Return to looping demos
@@ -346,7 +354,10 @@ func hostChangelevel(a cbuf.Arguments) error {
 		conlog.Printf("Only the server may changelevel\n")
 		return nil
 	}
-	level := args[0].String()
+	return changeLevel(args[0].String())
+}
+
+func changeLevel(level string) error {
 	if _, err := filesystem.Stat(fmt.Sprintf("maps/%s.bsp", level)); err != nil {
 		return fmt.Errorf("cannot find map %s", level)
 	}
@@ -362,6 +373,85 @@ func hostChangelevel(a cbuf.Arguments) error {
 	return nil
 }
 
+// change to the next map of the sv_maplist rotation
+func hostNextMap(a cbuf.Arguments) error {
+	if cls.demoPlayback || !svTODO.Active() {
+		conlog.Printf("Only the server may change the map\n")
+		return nil
+	}
+	level, err := svTODO.NextMap()
+	if err != nil {
+		return err
+	}
+	if level == "" {
+		conlog.Printf("No map rotation, set sv_maplist\n")
+		return nil
+	}
+	return changeLevel(level)
+}
+
+func hostMapList(a cbuf.Arguments) error {
+	if !svTODO.Active() {
+		forwardToServer(a)
+		return nil
+	}
+	maps, err := svTODO.MapList()
+	if err != nil {
+		return err
+	}
+	if len(maps) == 0 {
+		conlog.Printf("No map rotation\n")
+		return nil
+	}
+	next, _ := svTODO.NextMap()
+	for _, m := range maps {
+		if m == next {
+			conlog.Printf("> %s\n", m)
+		} else {
+			conlog.Printf("  %s\n", m)
+		}
+	}
+	return nil
+}
+
+func hostAddBot(a cbuf.Arguments) error {
+	if !svTODO.Active() {
+		forwardToServer(a)
+		return nil
+	}
+	name := ""
+	if args := a.Args(); len(args) > 1 {
+		name = args[1].String()
+	}
+	b, err := svTODO.AddBot(name, nil)
+	if err != nil {
+		conlog.Printf("Can't add bot: %v\n", err)
+		return nil
+	}
+	conlog.Printf("Added bot %s\n", b.Name())
+	return nil
+}
+
+func hostRemoveBot(a cbuf.Arguments) error {
+	args := a.Args()
+	if len(args) < 2 {
+		conlog.Printf("removebot <name> | all\n")
+		return nil
+	}
+	if !svTODO.Active() {
+		forwardToServer(a)
+		return nil
+	}
+	n, err := svTODO.RemoveBot(args[1].String())
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		conlog.Printf("No bot %s\n", args[1].String())
+	}
+	return nil
+}
+
 // Restarts the current server for a dead player
 func hostRestart(a cbuf.Arguments) error {
 	if cls.demoPlayback {
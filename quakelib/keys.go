@@ -57,7 +57,7 @@ func (k *qKeyInput) consoleKeyEvent(key kc.KeyCode) {
 		k.buf = make([]byte, 0, 40)
 		k.cursorXPos = 0
 	case kc.TAB:
-		// TODO(therjak): tap completion
+		k.complete()
 	case kc.BACKSPACE:
 		if k.cursorXPos > 0 {
 			k.buf = append(k.buf[:k.cursorXPos-1], k.buf[k.cursorXPos:]...)
@@ -65,7 +65,12 @@ func init() {
 		enterMenuVideo()
 		return nil
 	})
-	addCommand("help", func(_ cbuf.Arguments) error {
+	addCommand("help", func(a cbuf.Arguments) error {
+		// help <cvar> describes the cvar instead of showing the help menu
+		if args := a.Args()[1:]; len(args) > 0 {
+			cvarHelp(args[0].String())
+			return nil
+		}
 		enterMenuHelp()
 		return nil
 	})
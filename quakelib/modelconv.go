@@ -8,7 +8,9 @@ import (
 
 	"goquake/bsp"
 	"goquake/cvars"
+	"goquake/filesystem"
 	"goquake/mdl"
+	"goquake/mesh"
 	"goquake/model"
 	"goquake/spr"
 )
@@ -35,6 +37,10 @@ func loadModel(name string) (model.Model, error) {
 		// No need, already loaded
 		return m, nil
 	}
+	if m := loadReplacementModel(name); m != nil {
+		models[name] = m
+		return m, nil
+	}
 	mods, err := model.Load(name)
 	if err != nil {
 		log.Printf("LoadModel err: %v", err)
@@ -58,6 +64,30 @@ func loadModel(name string) (model.Model, error) {
 	return nil, fmt.Errorf("LoadModel err: %v", err)
 }
 
+// loadReplacementModel tries the extensions of r_replacemodels instead of
+// the mdl extension of name. It returns nil if there is no replacement.
+func loadReplacementModel(name string) model.Model {
+	if filesystem.Ext(name) != ".mdl" {
+		return nil
+	}
+	for _, ext := range strings.Fields(cvars.RReplaceModels.String()) {
+		rn := filesystem.StripExt(name) + "." + strings.TrimPrefix(ext, ".")
+		if _, err := filesystem.Stat(rn); err != nil {
+			continue
+		}
+		mods, err := model.Load(rn)
+		if err != nil {
+			log.Printf("LoadModel err: %v", err)
+			continue
+		}
+		m := mods[0]
+		setExtraFlags(m)
+		loadTextures(m)
+		return m
+	}
+	return nil
+}
+
 func setExtraFlags(m model.Model) {
 	switch mt := m.(type) {
 	case *mdl.Model:
@@ -70,6 +100,10 @@ func setExtraFlags(m model.Model) {
 		if strings.Contains(cvars.RFullBrightList.String(), mt.Name()) {
 			mt.AddFlag(mdl.FullBrightHack)
 		}
+	case *mesh.Model:
+		if strings.Contains(cvars.RNoLerpList.String(), mt.Name()) {
+			mt.AddFlag(mdl.NoLerp)
+		}
 	}
 }
 
@@ -86,13 +120,24 @@ func loadTextures(m model.Model) {
 		for _, t := range mt.Textures {
 			for _, st := range t {
 				textureManager.addActiveTexture(st)
-				textureManager.loadIndexed(st, st.Data)
+				textureManager.load(st)
 			}
 		}
 		for _, t := range mt.FBTextures {
 			for _, st := range t {
 				textureManager.addActiveTexture(st)
-				textureManager.loadIndexed(st, st.Data)
+				textureManager.load(st)
+			}
+		}
+		mt.UploadBuffer()
+	case *mesh.Model:
+		for _, ms := range mt.Meshes {
+			for _, t := range ms.Skins {
+				if t == nil {
+					continue
+				}
+				textureManager.addActiveTexture(t)
+				textureManager.loadRGBA(t, t.Data)
 			}
 		}
 		mt.UploadBuffer()
@@ -104,19 +149,19 @@ func loadTextures(m model.Model) {
 			}
 			if t.SolidSky != nil {
 				textureManager.addActiveTexture(t.SolidSky)
-				textureManager.loadIndexed(t.SolidSky, t.SolidSky.Data)
+				textureManager.load(t.SolidSky)
 			}
 			if t.AlphaSky != nil {
 				textureManager.addActiveTexture(t.AlphaSky)
-				textureManager.loadIndexed(t.AlphaSky, t.AlphaSky.Data)
+				textureManager.load(t.AlphaSky)
 			}
 			if t.Texture != nil {
 				textureManager.addActiveTexture(t.Texture)
-				textureManager.loadIndexed(t.Texture, t.Texture.Data)
+				textureManager.load(t.Texture)
 			}
 			if t.Fullbright != nil {
 				textureManager.addActiveTexture(t.Fullbright)
-				textureManager.loadIndexed(t.Fullbright, t.Fullbright.Data)
+				textureManager.load(t.Fullbright)
 			}
 		}
 		for _, s := range mt.Surfaces {
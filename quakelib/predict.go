@@ -0,0 +1,231 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package quakelib
+
+import (
+	"goquake/bsp"
+	"goquake/cvars"
+	"goquake/math/vec"
+	"goquake/net"
+	"goquake/pmove"
+	"goquake/progs"
+	"goquake/protocol"
+	"goquake/protos"
+)
+
+const (
+	predictBackup = 64 // number of moves kept for replay
+	predictJump   = 270
+)
+
+// predictedMove is a move command as it was sent to the server.
+type predictedMove struct {
+	sequence  uint32
+	cmd       pmove.Cmd
+	vAngle    vec.Vec3
+	frameTime float32
+	jump      bool
+}
+
+type predictionState struct {
+	sequence uint32 // sequence of the last sent move
+	moves    [predictBackup]predictedMove
+
+	// last state acknowledged by the server
+	ack      uint32
+	valid    bool
+	origin   vec.Vec3
+	velocity vec.Vec3
+	flags    int
+	moveType int
+	params   *protos.MoveParams // physics of the server, nil if not sent
+}
+
+var prediction predictionState
+
+func clearPrediction() {
+	prediction = predictionState{}
+}
+
+// recordMove stores the move for later replay and returns its sequence.
+func (p *predictionState) recordMove(v userView, m userMove, jump bool, frameTime float32) uint32 {
+	p.sequence++
+	p.moves[p.sequence%predictBackup] = predictedMove{
+		sequence:  p.sequence,
+		cmd:       pmove.Cmd{ForwardMove: m.forward, SideMove: m.side, UpMove: m.up},
+		vAngle:    vec.Vec3{v.pitch, v.yaw, v.roll},
+		frameTime: frameTime,
+		jump:      jump,
+	}
+	return p.sequence
+}
+
+func (p *predictionState) parsePlayerState(ps *protos.PlayerState) {
+	p.valid = true
+	p.ack = ps.GetSequence()
+	p.origin = vec.Vec3{ps.GetOrigin().GetX(), ps.GetOrigin().GetY(), ps.GetOrigin().GetZ()}
+	p.velocity = vec.Vec3{ps.GetVelocity().GetX(), ps.GetVelocity().GetY(), ps.GetVelocity().GetZ()}
+	p.flags = int(ps.GetFlags())
+	p.moveType = int(ps.GetMoveType())
+	if ps.HasParams() {
+		p.params = ps.GetParams()
+	}
+}
+
+// moveParams returns the physics settings of the server. The local cvars
+// only match them if the server runs in this process.
+func (p *predictionState) moveParams(frameTime float32) *pmove.Params {
+	if p.params != nil {
+		return pmove.ProtoParams(frameTime, p.params)
+	}
+	return pmove.ServerParams(frameTime)
+}
+
+func localServer() bool {
+	return cls.connection != nil && cls.connection.Address() == net.LocalAddress
+}
+
+// predictWorld is the view of the world the client has. Only the world and
+// brush models clip the player and nothing gets touched.
+type predictWorld struct {
+	c *Client
+}
+
+func predictHull(m *bsp.Model, mins, maxs vec.Vec3) *bsp.Hull {
+	s := maxs[0] - mins[0]
+	if s < 3 {
+		return &m.Hulls[0]
+	} else if s <= 32 {
+		return &m.Hulls[1]
+	}
+	return &m.Hulls[2]
+}
+
+func clipToModel(m *bsp.Model, origin, start, mins, maxs, end vec.Vec3) bsp.Trace {
+	t := bsp.Trace{
+		Fraction: 1,
+		AllSolid: true,
+		EndPos:   end,
+	}
+	hull := predictHull(m, mins, maxs)
+	offset := vec.Add(vec.Sub(hull.ClipMins, mins), origin)
+	hull.RecursiveCheck(hull.FirstClipNode, 0, 1, vec.Sub(start, offset), vec.Sub(end, offset), &t)
+	if t.Fraction != 1 {
+		t.EndPos = vec.Add(t.EndPos, offset)
+	}
+	return t
+}
+
+func (w *predictWorld) Trace(start, mins, maxs, end vec.Vec3, noMonsters bool) bsp.Trace {
+	t := clipToModel(w.c.worldModel, vec.Vec3{}, start, mins, maxs, end)
+	if t.Fraction < 1 || t.StartSolid {
+		t.EntPointer = true
+	}
+	for i, e := range w.c.entities {
+		if i == 0 || i == w.c.viewentity || t.AllSolid {
+			continue
+		}
+		m, ok := e.Model.(*bsp.Model)
+		if !ok || e.MsgTime != w.c.messageTime {
+			continue
+		}
+		et := clipToModel(m, e.Origin, start, mins, maxs, end)
+		if et.AllSolid || et.StartSolid || et.Fraction < t.Fraction {
+			et.EntNumber = i
+			et.EntPointer = true
+			if t.StartSolid {
+				et.StartSolid = true
+			}
+			t = et
+		} else if et.StartSolid {
+			t.StartSolid = true
+		}
+	}
+	return t
+}
+
+func (w *predictWorld) PointContents(p vec.Vec3) int {
+	return w.c.worldModel.Hulls[0].PointContents(0, p)
+}
+
+func (w *predictWorld) Impact(p *pmove.State, ent int) (bool, error) {
+	return true, nil
+}
+
+func (w *predictWorld) Push(p *pmove.State, push vec.Vec3) (bsp.Trace, error) {
+	t := w.Trace(p.Origin, p.Mins, p.Maxs, vec.Add(p.Origin, push), false)
+	p.Origin = t.EndPos
+	return t, nil
+}
+
+func (w *predictWorld) Ground(ent int) bool {
+	return true
+}
+
+func (c *Client) shouldPredict() bool {
+	return !cvars.ClientNoPrediction.Bool() &&
+		c.protocol == protocol.GoQuake &&
+		!cls.demoPlayback &&
+		c.intermission == 0 &&
+		c.worldModel != nil &&
+		prediction.valid &&
+		(prediction.params != nil || localServer()) &&
+		c.viewentity > 0 && c.viewentity < len(c.entities)
+}
+
+// predictMove replays all moves the server has not yet acknowledged on top
+// of the last state received from the server and moves the player entity to
+// the result.
+func (c *Client) predictMove() {
+	if !c.shouldPredict() {
+		return
+	}
+	pr := &prediction
+	if pr.sequence-pr.ack >= predictBackup {
+		// too far behind, the moves got overwritten
+		return
+	}
+	p := pmove.State{
+		Origin:   pr.origin,
+		Velocity: pr.velocity,
+		Angles:   vec.Vec3{c.pitch, c.yaw, c.roll},
+		Mins:     vec.Vec3{-16, -16, -24},
+		Maxs:     vec.Vec3{16, 16, 32},
+		ViewOfs:  vec.Vec3{0, 0, 22},
+		MoveType: pr.moveType,
+		Flags:    pr.flags,
+		Health:   float32(c.stats.health),
+	}
+	w := &predictWorld{c}
+	for s := pr.ack + 1; s != pr.sequence+1; s++ {
+		m := &pr.moves[s%predictBackup]
+		if m.sequence != s {
+			return
+		}
+		par := pr.moveParams(m.frameTime)
+		p.VAngle = m.vAngle
+		pmove.CheckWater(&p, w)
+		pmove.Think(&p, &m.cmd, float32(c.time), par, w)
+		c.predictJump(&p, m.jump)
+		if err := pmove.Move(&p, par, w); err != nil {
+			return
+		}
+	}
+	c.Entities(c.viewentity).Origin = p.Origin
+}
+
+// predictJump mimics the jump handling of the id1 PlayerPreThink.
+func (c *Client) predictJump(p *pmove.State, jump bool) {
+	if !jump {
+		p.Flags |= progs.FlagJumpRelease
+		return
+	}
+	if p.WaterLevel >= 2 || p.Flags&progs.FlagWaterJump != 0 {
+		return
+	}
+	if p.Flags&progs.FlagOnGround == 0 || p.Flags&progs.FlagJumpRelease == 0 {
+		return
+	}
+	p.Flags &^= progs.FlagJumpRelease | progs.FlagOnGround
+	p.Velocity[2] += predictJump
+}
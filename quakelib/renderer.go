@@ -8,6 +8,7 @@ import (
 	"goquake/glh"
 	"goquake/math/vec"
 	"goquake/mdl"
+	"goquake/mesh"
 	"goquake/palette"
 	"goquake/progs"
 	"goquake/spr"
@@ -151,6 +152,10 @@ func (r *qRenderer) DrawWeaponModel() {
 		gl.DepthRange(0, 0.3)
 		r.DrawAliasModel(weapon, m)
 		gl.DepthRange(0, 1)
+	case *mesh.Model:
+		gl.DepthRange(0, 0.3)
+		r.DrawMeshModel(weapon, m)
+		gl.DepthRange(0, 1)
 	}
 }
 
@@ -300,6 +305,8 @@ func (r *qRenderer) DrawEntitiesOnList(alphaPass bool) {
 		switch m := e.Model.(type) {
 		case *mdl.Model:
 			r.DrawAliasModel(e, m)
+		case *mesh.Model:
+			r.DrawMeshModel(e, m)
 		case *bsp.Model:
 			r.DrawBrushModel(e, m)
 		case *spr.Model:
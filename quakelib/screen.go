@@ -542,12 +542,15 @@ func (scr *qScreen) Update() error {
 		statusbar.FinaleOverlay()
 		scr.CheckDrawCenterPrint()
 	} else {
+		csqc.UpdateView(scr.Width(), scr.Height())
 		scr.drawCrosshair()
 		scr.drawNet()
 		scr.drawTurtle()
 		scr.drawPause()
 		scr.CheckDrawCenterPrint()
-		statusbar.Draw()
+		if !csqc.DrawHud(scr.Width(), scr.Height(), statusbar.showScores) {
+			statusbar.Draw()
+		}
 		scr.drawFPS()
 		scr.drawClock()
 		scr.drawConsole()
@@ -3,9 +3,11 @@ package quakelib
 
 import (
 	"log"
+	"strconv"
 
 	"goquake/cbuf"
 	"goquake/commandline"
+	"goquake/conlog"
 	"goquake/cvar"
 	"goquake/cvars"
 	"goquake/math/vec"
@@ -25,6 +27,10 @@ type soundsystem interface {
 	Block()
 	SetVolume(v float32)
 	NewPrecache(snds ...qsnd.Sound) *qsnd.SoundPrecache
+	PlayMusic(m qsnd.Music)
+	StopMusic()
+	PauseMusic(pause bool)
+	SetMusicVolume(v float32)
 }
 
 var (
@@ -58,6 +64,7 @@ func soundInit(stop chan struct{}) {
 	}
 	snd = qsnd.InitSoundSystem(stop)
 	onVolumeChange(cvars.Volume)
+	onMusicVolumeChange(cvars.BackgroundVolume)
 	defaultSounds = snd.NewPrecache(
 		qsnd.Sound{ID: int(lsMenu1), Name: "misc/menu1.wav"},
 		qsnd.Sound{ID: int(lsMenu2), Name: "misc/menu2.wav"},
@@ -83,21 +90,48 @@ func clientSound(sfx lSound, pos vec.Vec3) {
 
 func init() {
 	cvars.Volume.SetCallback(onVolumeChange)
+	cvars.BackgroundVolume.SetCallback(onMusicVolumeChange)
 }
 
-func onVolumeChange(cv *cvar.Cvar) {
+// clampVolume returns false if cv was outside of [0,1] and got reset.
+func clampVolume(cv *cvar.Cvar) bool {
 	v := cv.Value()
 	if v > 1 {
 		cv.SetByString("1")
 		// recursion so exit early
-		return
+		return false
 	}
 	if v < 0 {
 		cv.SetByString("0")
 		// recursion so exit early
+		return false
+	}
+	return true
+}
+
+func onVolumeChange(cv *cvar.Cvar) {
+	if clampVolume(cv) {
+		snd.SetVolume(cv.Value())
+	}
+}
+
+func onMusicVolumeChange(cv *cvar.Cvar) {
+	if clampVolume(cv) && snd != nil {
+		snd.SetMusicVolume(cv.Value())
+	}
+}
+
+// playCDTrack plays the music replacing cd track track. After it finished
+// loop gets repeated, 0 means no repetition.
+func playCDTrack(track, loop int) {
+	if snd == nil {
 		return
 	}
-	snd.SetVolume(v)
+	m := qsnd.Music{Name: qsnd.TrackName(track)}
+	if loop != 0 {
+		m.Loop = qsnd.TrackName(loop)
+	}
+	snd.PlayMusic(m)
 }
 
 func init() {
@@ -106,6 +140,10 @@ func init() {
 	addCommand("stopsound", stopSoundCmd)
 	addCommand("soundlist", soundListCmd)
 	addCommand("soundinfo", soundInfoCmd)
+	addCommand("music", musicCmd)
+	addCommand("music_pause", musicPauseCmd)
+	addCommand("music_resume", musicResumeCmd)
+	addCommand("music_stop", musicStopCmd)
 }
 
 func playCmd(args cbuf.Arguments) error {
@@ -128,3 +166,41 @@ func soundInfoCmd(args cbuf.Arguments) error {
 	log.Println("sound info CMD from snd")
 	return nil
 }
+
+func musicCmd(a cbuf.Arguments) error {
+	args := a.Args()[1:]
+	if len(args) != 1 {
+		conlog.Printf("usage: music <filename or track number>\n")
+		return nil
+	}
+	if snd == nil {
+		return nil
+	}
+	name := args[0].String()
+	if n, err := strconv.Atoi(name); err == nil {
+		name = qsnd.TrackName(n)
+	}
+	snd.PlayMusic(qsnd.Music{Name: name, Loop: name})
+	return nil
+}
+
+func musicPauseCmd(a cbuf.Arguments) error {
+	if snd != nil {
+		snd.PauseMusic(true)
+	}
+	return nil
+}
+
+func musicResumeCmd(a cbuf.Arguments) error {
+	if snd != nil {
+		snd.PauseMusic(false)
+	}
+	return nil
+}
+
+func musicStopCmd(a cbuf.Arguments) error {
+	if snd != nil {
+		snd.StopMusic()
+	}
+	return nil
+}
@@ -97,13 +97,20 @@ func (tm *texMgr) Init() {
 }
 
 func (tm *texMgr) LoadConsoleChars() (*texture.Texture, error) {
+	flags := texture.TexPrefAlpha | texture.TexPrefNearest | texture.TexPrefNoPicMip | texture.TexPrefConChars
+	if cvars.GlExternalTextures.Bool() {
+		if img, _ := qimage.Replacement("gfx/conchars"); img != nil {
+			t := texture.FromImage(img, flags, "gfx.wad:conchars")
+			tm.addActiveTexture(t)
+			tm.loadRGBA(t, t.Data)
+			return t, nil
+		}
+	}
 	data := wad.GetConsoleChars()
 	if len(data) != 128*128*4 {
 		return nil, fmt.Errorf("ConsoleChars not found")
 	}
-	t := texture.NewTexture(128, 128,
-		texture.TexPrefAlpha|texture.TexPrefNearest|texture.TexPrefNoPicMip|texture.TexPrefConChars,
-		"gfx.wad:conchars", texture.ColorTypeRGBA, data)
+	t := texture.NewTexture(128, 128, flags, "gfx.wad:conchars", texture.ColorTypeRGBA, data)
 	tm.addActiveTexture(t)
 	tm.loadRGBA(t, data)
 	return t, nil
@@ -125,6 +132,18 @@ func (tm *texMgr) LoadWadTex(name string, w, h int, data []byte) *texture.Textur
 	return tm.loadIndexdTex(name, w, h, flags, data)
 }
 
+// loadWadPic loads the wad picture p, an external replacement is used if
+// the picture has one.
+func (tm *texMgr) loadWadPic(name string, p *wad.QPic) *texture.Texture {
+	if p.Image == nil {
+		return tm.LoadWadTex(name, p.Width, p.Height, p.Data)
+	}
+	t := texture.FromImage(p.Image, texture.TexPrefAlpha|texture.TexPrefPad|texture.TexPrefNoPicMip, name)
+	tm.addActiveTexture(t)
+	tm.loadRGBA(t, t.Data)
+	return t
+}
+
 func (tm *texMgr) loadRGBATex(name string, w, h int, flags texture.TexPref, data []byte) *texture.Texture {
 	t := texture.NewTexture(int32(w), int32(h), flags, name, texture.ColorTypeRGBA, data)
 	tm.addActiveTexture(t)
@@ -151,7 +170,7 @@ func (tm *texMgr) LoadBacktile() (*texture.Texture, error) {
 	if p == nil {
 		return nil, fmt.Errorf("Draw_LoadPics: couldn't load backtile")
 	}
-	return tm.LoadWadTex(name, p.Width, p.Height, p.Data), nil
+	return tm.loadWadPic(name, p), nil
 }
 
 func (tm *texMgr) loadParticleImage(name string, width, height int32, data []byte) *texture.Texture {
@@ -182,7 +201,7 @@ func (tm *texMgr) LoadSkyBox(boxName string) *texture.Texture {
 	for i, img := range imgs {
 		s := img.Bounds().Size()
 		gl.TexImage2D(gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(i),
-			0, gl.RGB, int32(s.X), int32(s.Y), 0, gl.RGB, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+			0, gl.RGB, int32(s.X), int32(s.Y), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
 	}
 	gl.TexParameterf(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
 	gl.TexParameterf(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
@@ -519,6 +538,15 @@ func (tm *texMgr) loadLightMap(t *texture.Texture) {
 	tm.SetFilterModes(t)
 }
 
+// load uploads the data of t, indexed data gets converted with the palette.
+func (tm *texMgr) load(t *texture.Texture) {
+	if t.Typ == texture.ColorTypeRGBA {
+		tm.loadRGBA(t, t.Data)
+		return
+	}
+	tm.loadIndexed(t, t.Data)
+}
+
 func (tm *texMgr) loadIndexed(t *texture.Texture, data []byte) {
 	var p *palette.Palette
 	switch {
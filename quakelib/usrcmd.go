@@ -108,6 +108,8 @@ func (u *userMove) mouseMove() {
 
 // Send unreliable message (CL_SendMove)
 func send(v userView, m userMove) error {
+	jump := input.Jump.WentDown()
+	seq := prediction.recordMove(v, m, jump, float32(host.FrameTime()))
 	cmd := protos.UsrCmd_builder{
 		MessageTime: float32(cl.messageTime),
 		Pitch:       v.pitch,
@@ -117,8 +119,10 @@ func send(v userView, m userMove) error {
 		Side:        m.side,
 		Up:          m.up,
 		Attack:      input.Attack.WentDown(),
-		Jump:        input.Jump.WentDown(),
+		Jump:        jump,
 		Impulse:     int32(in_impulse),
+		Sequence:    seq,
+		EntityFrame: cl.entityFrames.Ack,
 	}.Build()
 	pb := &protos.ClientMessage{}
 	pb.SetCmds(append(pb.GetCmds(), protos.Cmd_builder{
@@ -0,0 +1,425 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"goquake/bsp"
+	"goquake/cbuf"
+	"goquake/math"
+	"goquake/math/vec"
+	svc "goquake/protocol/server"
+	"goquake/protos"
+
+	"github.com/chewxy/math32"
+)
+
+// BotCmd is the input of a bot for one server frame, the same a network
+// client sends with its move command.
+type BotCmd struct {
+	Forward, Side, Up float32
+	Angles            vec.Vec3 // view angles, pitch is positive downwards
+	Attack, Jump      bool
+	Impulse           int
+}
+
+// A BotController decides what a bot does. Think is called once per server
+// frame before the physics run.
+type BotController interface {
+	Think(b *Bot) BotCmd
+}
+
+// Bot is a client without a network connection. It is what a BotController
+// sees of the game.
+type Bot struct {
+	s           *Server
+	c           *SVClient
+	controller  BotController
+	connectTime time.Time
+}
+
+// Player is another player as seen by a bot.
+type Player struct {
+	Entity int
+	Name   string
+	Origin vec.Vec3
+}
+
+func (b *Bot) Name() string {
+	return b.c.name
+}
+
+// Entity returns the edict number of the bot.
+func (b *Bot) Entity() int {
+	return b.c.edictId
+}
+
+// Time returns the server time.
+func (b *Bot) Time() float32 {
+	return b.s.time
+}
+
+func (b *Bot) Origin() vec.Vec3 {
+	return vec.VFromA(b.s.entvars.Get(b.c.edictId).Origin)
+}
+
+// Eye returns the position the bot looks from.
+func (b *Bot) Eye() vec.Vec3 {
+	ev := b.s.entvars.Get(b.c.edictId)
+	return vec.Add(ev.Origin, ev.ViewOfs)
+}
+
+func (b *Bot) Velocity() vec.Vec3 {
+	return vec.VFromA(b.s.entvars.Get(b.c.edictId).Velocity)
+}
+
+func (b *Bot) Health() float32 {
+	return b.s.entvars.Get(b.c.edictId).Health
+}
+
+func (b *Bot) OnGround() bool {
+	return int(b.s.entvars.Get(b.c.edictId).Flags)&FL_ONGROUND != 0
+}
+
+// Players returns the other living players in the game.
+func (b *Bot) Players() []Player {
+	var r []Player
+	for _, c := range b.s.players() {
+		if c == b.c {
+			continue
+		}
+		ev := b.s.entvars.Get(c.edictId)
+		if ev.Health <= 0 {
+			continue
+		}
+		r = append(r, Player{
+			Entity: c.edictId,
+			Name:   c.name,
+			Origin: vec.VFromA(ev.Origin),
+		})
+	}
+	return r
+}
+
+// Trace moves the bounding box of the bot from start to end and returns
+// where it got stopped. The bot itself does not block the move.
+func (b *Bot) Trace(start, end vec.Vec3) bsp.Trace {
+	ev := b.s.entvars.Get(b.c.edictId)
+	return svMove(start, ev.Mins, ev.Maxs, end, MOVE_NORMAL, b.c.edictId, b.s)
+}
+
+// Visible returns true if nothing but monsters and players is between the
+// eye of the bot and p.
+func (b *Bot) Visible(p vec.Vec3) bool {
+	t := svMove(b.Eye(), vec.Vec3{}, vec.Vec3{}, p, MOVE_NOMONSTERS, b.c.edictId, b.s)
+	return t.Fraction == 1 && !t.AllSolid
+}
+
+// PointContents returns the bsp.CONTENTS_* of the world at p.
+func (b *Bot) PointContents(p vec.Vec3) int {
+	return pointContents(p, b.s.worldModel)
+}
+
+// think runs the controller and applies its command like a move command of
+// a network client.
+func (b *Bot) think() {
+	cmd := b.controller.Think(b)
+	sc := b.c
+	ev := b.s.entvars.Get(sc.edictId)
+	ev.VAngle = cmd.Angles
+	sc.cmd = movecmd{
+		forwardmove: cmd.Forward,
+		sidemove:    cmd.Side,
+		upmove:      cmd.Up,
+	}
+	// bots see the world as it is, unlag must not rewind it
+	sc.viewTime = b.s.time
+	ev.Button0 = 0
+	ev.Button2 = 0
+	if cmd.Attack {
+		ev.Button0 = 1
+	}
+	if cmd.Jump {
+		ev.Button2 = 1
+	}
+	if cmd.Impulse != 0 {
+		ev.Impulse = float32(cmd.Impulse)
+	}
+}
+
+// AddBot puts a bot into a free client slot. If controller is nil the bot
+// uses the built-in navigation.
+func (s *Server) AddBot(name string, controller BotController) (*Bot, error) {
+	if !s.active {
+		return nil, fmt.Errorf("server is not active")
+	}
+	n := -1
+	for i := 0; i < s.svs.maxClients; i++ {
+		if !s.clients[i].active {
+			n = i
+			break
+		}
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("no free client slot")
+	}
+	if controller == nil {
+		controller = &navController{}
+	}
+	s.clients[n].netConnection = nil
+	s.clients[n].admin = false
+	if err := s.connectClient(n); err != nil {
+		return nil, err
+	}
+	sc := s.clients[n]
+	b := &Bot{
+		s:           s,
+		c:           sc,
+		controller:  controller,
+		connectTime: time.Now(),
+	}
+	sc.bot = b
+	if name == "" {
+		name = fmt.Sprintf("bot%d", n+1)
+	}
+	sc.name = name
+	c := (n + 1) % 14
+	sc.colors = c<<4 | c
+	svc.WriteUpdateName(protos.UpdateName_builder{
+		Player:  int32(n),
+		NewName: sc.name,
+	}.Build(), s.protocol, s.protocolFlags, &s.reliableDatagram)
+	svc.WriteUpdateColors(protos.UpdateColors_builder{
+		Player:   int32(n),
+		NewColor: int32(sc.colors),
+	}.Build(), s.protocol, s.protocolFlags, &s.reliableDatagram)
+	if err := s.spawnBot(sc); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// spawnBot runs the same entrance script as for a network client. The bot
+// skips the signon as it has nothing to receive.
+func (s *Server) spawnBot(sc *SVClient) error {
+	sc.spawned = false
+	if err := s.spawnCmd(sc); err != nil {
+		return err
+	}
+	sc.spawned = true
+	sc.sendSignon = false
+	sc.ready = true
+	sc.msg.ClearMessage()
+	// the progs set FL_CLIENT in PutClientInServer, make sure it is set for
+	// progs which do not
+	ev := s.entvars.Get(sc.edictId)
+	ev.Flags = float32(int(ev.Flags) | FL_CLIENT)
+	return nil
+}
+
+// RemoveBot drops the bot with the given name or all bots if name is "all".
+// It returns the number of removed bots.
+func (s *Server) RemoveBot(name string) (int, error) {
+	removed := 0
+	for _, c := range s.clients {
+		if !c.active || c.bot == nil {
+			continue
+		}
+		if name != "all" && c.name != name {
+			continue
+		}
+		if err := s.Drop(c, false); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Bots returns the bots in the game.
+func (s *Server) Bots() []*Bot {
+	var r []*Bot
+	for _, c := range s.clients {
+		if c.active && c.bot != nil {
+			r = append(r, c.bot)
+		}
+	}
+	return r
+}
+
+func (s *Server) addBotCmd(sc *SVClient, a cbuf.Arguments) {
+	if !sc.admin {
+		sc.Printf("Only the server admin can add bots\n")
+		return
+	}
+	name := ""
+	if args := a.Args(); len(args) > 1 {
+		name = args[1].String()
+	}
+	b, err := s.AddBot(name, nil)
+	if err != nil {
+		sc.Printf("Can't add bot: %v\n", err)
+		return
+	}
+	log.Printf("Added bot %s\n", b.Name())
+}
+
+func (s *Server) removeBotCmd(sc *SVClient, a cbuf.Arguments) {
+	if !sc.admin {
+		sc.Printf("Only the server admin can remove bots\n")
+		return
+	}
+	args := a.Args()
+	if len(args) < 2 {
+		sc.Printf("removebot <name> | all\n")
+		return
+	}
+	n, err := s.RemoveBot(args[1].String())
+	if err != nil {
+		log.Printf("Drop error: %v", err)
+	}
+	if n == 0 {
+		sc.Printf("No bot %s\n", args[1].String())
+	}
+}
+
+const (
+	botSpeed      = 320  // speed of the built-in navigation
+	botLookAhead  = 32   // distance of the movement checks
+	botJumpHeight = 40   // height a jump clears
+	botMaxDrop    = 128  // deepest drop the bot walks down
+	botFireRange  = 1000 // enemies further away are not attacked
+	botStuckTime  = 1    // seconds without progress before a new direction
+)
+
+// navController is the built-in BotController. It wanders through the map
+// and attacks the nearest visible player. Like the monster movement it
+// tries the direct direction first and then turns in 45 degree steps.
+type navController struct {
+	yaw        float32  // current movement direction
+	lastOrigin vec.Vec3 // origin of the last progress
+	progress   float32  // server time of the last progress
+	attack     bool     // toggled to respawn
+}
+
+func (n *navController) Think(b *Bot) BotCmd {
+	if b.Health() <= 0 {
+		// the progs respawn on a newly pressed button
+		n.attack = !n.attack
+		return BotCmd{Attack: n.attack}
+	}
+	n.attack = false
+
+	origin := b.Origin()
+	if vec.Sub(origin, n.lastOrigin).Length() > 1 {
+		n.lastOrigin = origin
+		n.progress = b.Time()
+	} else if b.Time()-n.progress > botStuckTime {
+		n.yaw = float32(b.s.rand.Uint32n(8) * 45)
+		n.progress = b.Time()
+	}
+
+	cmd := BotCmd{Angles: vec.Vec3{0, n.yaw, 0}}
+	goal := n.yaw
+	if e, ok := n.enemy(b); ok {
+		d := vec.Sub(e.Origin, b.Eye())
+		cmd.Angles = aimAngles(d)
+		goal = cmd.Angles[1]
+		cmd.Attack = d.Length() < botFireRange
+	}
+
+	yaw, jump, ok := n.direction(b, goal)
+	if !ok {
+		return cmd
+	}
+	n.yaw = yaw
+	// the move is relative to the view direction
+	si, co := math32.Sincos((yaw - cmd.Angles[1]) * math32.Pi / 180)
+	cmd.Forward = co * botSpeed
+	cmd.Side = -si * botSpeed
+	cmd.Jump = jump
+	return cmd
+}
+
+// enemy returns the nearest visible player.
+func (n *navController) enemy(b *Bot) (Player, bool) {
+	var r Player
+	best := float32(-1)
+	for _, p := range b.Players() {
+		d := vec.Sub(p.Origin, b.Origin()).Length()
+		if best >= 0 && d >= best {
+			continue
+		}
+		if !b.Visible(p.Origin) {
+			continue
+		}
+		r = p
+		best = d
+	}
+	return r, best >= 0
+}
+
+// aimAngles returns the view angles looking along d.
+func aimAngles(d vec.Vec3) vec.Vec3 {
+	yaw := math.AngleMod32(math32.Atan2(d[1], d[0]) * 180 / math32.Pi)
+	pitch := -math32.Atan2(d[2], math32.Hypot(d[0], d[1])) * 180 / math32.Pi
+	return vec.Vec3{pitch, yaw, 0}
+}
+
+// direction picks the movement direction closest to goal the bot can walk.
+// It returns false if the bot is boxed in.
+func (n *navController) direction(b *Bot, goal float32) (float32, bool, bool) {
+	// prefer turning to the side of the current direction
+	sign := float32(1)
+	if math.AngleMod32(n.yaw-goal) > 180 {
+		sign = -1
+	}
+	for _, d := range []float32{0, 45, -45, 90, -90, 135, -135, 180} {
+		yaw := math.AngleMod32(goal + sign*d)
+		if ok, jump := canWalk(b, yaw); ok {
+			return yaw, jump, true
+		}
+	}
+	return 0, false, false
+}
+
+// canWalk checks if the bot can move a bit into the direction yaw. Like
+// monsterMoveStep it steps up stairs and does not walk off high ledges.
+// It also does not walk into lava or slime and jumps onto higher steps.
+func canWalk(b *Bot, yaw float32) (bool, bool) {
+	si, co := math32.Sincos(yaw * math32.Pi / 180)
+	origin := b.Origin()
+	end := vec.Add(origin, vec.Vec3{co * botLookAhead, si * botLookAhead, 0})
+	jump := false
+	t := b.Trace(origin, end)
+	if t.Fraction < 1 {
+		up := func(h float32) bsp.Trace {
+			s, e := origin, end
+			s[2] += h
+			e[2] += h
+			return b.Trace(s, e)
+		}
+		if t = up(kStepSize); t.Fraction < 1 || t.StartSolid {
+			if t = up(botJumpHeight); t.Fraction < 1 || t.StartSolid {
+				return false, false
+			}
+			jump = true
+		}
+	}
+	below := t.EndPos
+	below[2] -= botMaxDrop
+	g := b.Trace(t.EndPos, below)
+	if g.Fraction == 1 || g.AllSolid {
+		return false, false
+	}
+	feet := g.EndPos
+	feet[2] += b.s.entvars.Get(b.c.edictId).Mins[2] + 1
+	switch b.PointContents(feet) {
+	case bsp.CONTENTS_LAVA, bsp.CONTENTS_SLIME:
+		return false, false
+	}
+	return true, jump
+}
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"testing"
+
+	"goquake/math/vec"
+)
+
+type fixedController BotCmd
+
+func (f fixedController) Think(b *Bot) BotCmd {
+	return BotCmd(f)
+}
+
+func TestBotThink(t *testing.T) {
+	s := lagTestServer(t)
+	s.time = 3
+	sc := s.clients[0]
+	sc.bot = &Bot{s: s, c: sc, controller: fixedController{
+		Forward: 200,
+		Side:    -100,
+		Angles:  vec.Vec3{10, 90, 0},
+		Attack:  true,
+		Impulse: 7,
+	}}
+	sc.bot.think()
+	ev := s.entvars.Get(sc.edictId)
+	if sc.cmd != (movecmd{200, -100, 0}) {
+		t.Errorf("cmd = %v", sc.cmd)
+	}
+	if ev.VAngle != [3]float32{10, 90, 0} {
+		t.Errorf("angles = %v", ev.VAngle)
+	}
+	if ev.Button0 != 1 || ev.Button2 != 0 || ev.Impulse != 7 {
+		t.Errorf("buttons = %v %v, impulse = %v", ev.Button0, ev.Button2, ev.Impulse)
+	}
+	if sc.viewTime != 3 {
+		t.Errorf("viewTime = %v, want 3", sc.viewTime)
+	}
+}
+
+func TestNavControllerAttack(t *testing.T) {
+	s := lagTestServer(t)
+	for _, c := range s.clients {
+		c.spawned = true
+	}
+	sc := s.clients[0]
+	b := &Bot{s: s, c: sc}
+	s.entvars.Get(1).Health = 100
+	s.entvars.Get(2).Health = 100
+	s.entvars.Get(2).Origin = vec.Vec3{0, 200, 0}
+
+	var n navController
+	cmd := n.Think(b)
+	if !cmd.Attack || cmd.Angles[1] != 90 || cmd.Angles[0] != 0 {
+		t.Errorf("cmd = %+v, want attack at yaw 90", cmd)
+	}
+	// there is no floor to walk on
+	if cmd.Forward != 0 || cmd.Side != 0 {
+		t.Errorf("move = %v %v, want none", cmd.Forward, cmd.Side)
+	}
+
+	s.entvars.Get(2).Origin = vec.Vec3{0, 2000, 0}
+	if cmd := n.Think(b); cmd.Attack {
+		t.Errorf("attacks out of range")
+	}
+
+	// dead bots press attack every other frame to respawn
+	s.entvars.Get(1).Health = 0
+	if first, second := n.Think(b).Attack, n.Think(b).Attack; first == second {
+		t.Errorf("attack = %v, %v, want toggle", first, second)
+	}
+}
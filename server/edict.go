@@ -3,27 +3,30 @@
 package server
 
 import (
+	"bytes"
 	"fmt"
 	"log/slog"
 
 	"goquake/bsp"
 	"goquake/cvars"
+	"goquake/filesystem"
 	"goquake/math"
 	"goquake/math/vec"
 	"goquake/progs"
+	"goquake/protocol"
 )
 
 const (
 	defSaveGlobal = 1 << 15
 	MIN_EDICTS    = 265
-	MAX_EDICTS    = 32000 // edicts past 8192 can't play sounds in standard protocol
+	MAX_EDICTS    = 32000 // initial max_edicts, the protocol limits how far edicts can grow
 
 )
 
 type EntityState struct {
 	Origin     vec.Vec3
 	Angles     vec.Vec3
-	ModelIndex uint16
+	ModelIndex int
 	Frame      uint16
 	ColorMap   byte
 	Skin       byte
@@ -46,15 +49,48 @@ type Edict struct {
 
 }
 
-var entvars *progs.EntityVars
-
 func (s *Server) allocEdicts() {
-	entvars = progs.AllocEntvars(s.maxEdicts, progsdat.EdictSize, progsdat)
-	s.edicts = make([]Edict, s.maxEdicts)
+	s.maxEdicts = min(s.maxEdicts, protocol.MaxLimits(s.protocol).Edicts)
+	s.entvars = progs.AllocEntvars(s.maxEdicts, s.progsdat.EdictSize, s.progsdat)
+	s.entvars.Unchecked = cvars.ProgsUnchecked.Bool()
+	s.edicts = newEdicts(s.maxEdicts)
+}
+
+// newEdicts allocates n edicts. Edicts are referenced by pointer, so they
+// must not move when more get allocated.
+func newEdicts(n int) []*Edict {
+	mem := make([]Edict, n)
+	eds := make([]*Edict, n)
+	for i := range mem {
+		eds[i] = &mem[i]
+	}
+	return eds
+}
+
+// growEdicts makes room for at least n edicts by doubling the number of
+// allocated edicts up to the limit of the protocol.
+func (s *Server) growEdicts(n int) error {
+	if n <= s.maxEdicts {
+		return nil
+	}
+	limit := protocol.MaxLimits(s.protocol).Edicts
+	if n > limit {
+		return fmt.Errorf("ED_Alloc: no free edicts, protocol %d is limited to %d edicts", s.protocol, limit)
+	}
+	m := s.maxEdicts
+	for m < n {
+		m *= 2
+	}
+	m = min(m, limit)
+	slog.Debug("Growing edicts", slog.Int("old", s.maxEdicts), slog.Int("new", m))
+	s.entvars.Grow(m)
+	s.edicts = append(s.edicts, newEdicts(m-s.maxEdicts)...)
+	s.maxEdicts = m
+	return nil
 }
 
 func (s *Server) freeEdicts() {
-	entvars.Free()
+	s.entvars.Free()
 	s.edicts = s.edicts[:0]
 }
 
@@ -62,14 +98,14 @@ func (s *Server) freeEdicts() {
 // FIXME: walk all entities and NULL out references to this entity
 func (v *virtualMachine) edictFree(i int, s *Server) {
 	// unlink from world bsp
-	v.UnlinkEdict(i)
+	v.UnlinkEdict(i, s)
 
-	e := &s.edicts[i]
+	e := s.edicts[i]
 	e.Free = true
 	e.Alpha = 0
 	e.FreeTime = s.time
 
-	ev := entvars.Get(i)
+	ev := s.entvars.Get(i)
 	ev.Model = 0
 	ev.TakeDamage = 0
 	ev.ModelIndex = 0
@@ -83,8 +119,8 @@ func (v *virtualMachine) edictFree(i int, s *Server) {
 }
 
 func (s *Server) clearEdict(e int) {
-	s.edicts[e] = Edict{}
-	entvars.Clear(e)
+	*s.edicts[e] = Edict{}
+	s.entvars.Clear(e)
 }
 
 // Either finds a free edict, or allocates a new one.
@@ -93,20 +129,20 @@ func (s *Server) clearEdict(e int) {
 // instead of being removed and recreated, which can cause interpolated
 // angles and bad trails.
 func (s *Server) edictAlloc() (int, error) {
-	i := svs.maxClients + 1
+	i := s.svs.maxClients + 1
 	for ; i < s.numEdicts; i++ {
-		e := &s.edicts[i]
+		e := s.edicts[i]
 		// the first couple seconds of server time can involve a lot of
 		// freeing and allocating, so relax the replacement policy
 		if e.Free && (e.FreeTime < 2 || s.time-e.FreeTime > 0.5) {
-			entvars.Clear(i)
+			s.entvars.Clear(i)
 			e.Free = false
 			return i, nil
 		}
 	}
 
-	if i == s.maxEdicts {
-		return 0, fmt.Errorf("ED_Alloc: no free edicts (max_edicts is %d)", s.maxEdicts)
+	if err := s.growEdicts(i + 1); err != nil {
+		return 0, err
 	}
 
 	s.numEdicts++
@@ -123,7 +159,7 @@ func entAlphaEncode(a float32) byte {
 }
 
 func (s *Server) updateEdictAlpha(ent int) {
-	v, err := entvars.FieldValue(ent, "alpha")
+	v, err := s.entvars.FieldValue(ent, "alpha")
 	if err != nil {
 		return
 	}
@@ -132,7 +168,7 @@ func (s *Server) updateEdictAlpha(ent int) {
 
 func (s *Server) parse(ed int, data *bsp.Entity) {
 	if ed != 0 {
-		entvars.Clear(ed)
+		s.entvars.Clear(ed)
 	}
 	for _, on := range data.PropertyNames() {
 		// some hacks...
@@ -145,7 +181,7 @@ func (s *Server) parse(ed int, data *bsp.Entity) {
 		if n == "light" {
 			n = "light_lev"
 		}
-		def, err := progsdat.FindFieldDef(n)
+		def, err := s.progsdat.FindFieldDef(n)
 		if err != nil {
 			if n != "sky" && n != "fog" && n != "alpha" {
 				slog.Debug("Can't find field", slog.String("field", n))
@@ -156,7 +192,7 @@ func (s *Server) parse(ed int, data *bsp.Entity) {
 		if angleHack {
 			p = fmt.Sprintf("0 %s 0", p)
 		}
-		entvars.ParsePair(ed, def, p)
+		s.entvars.ParsePair(ed, def, p)
 	}
 }
 
@@ -167,6 +203,29 @@ const (
 	SPAWNFLAG_NOT_DEATHMATCH = 2048
 )
 
+// mapEntities returns the entities of the map. If external_ents is set an
+// entity file replaces the ones of the bsp, same as QuakeSpasm. The file for a
+// specific version of the map is maps/<map>@<crc>.ent where crc is the CRC of
+// the entity lump as 4 lower case hex digits. maps/<map>.ent applies to all
+// versions.
+func mapEntities(m *bsp.Model, mapName string) []*bsp.Entity {
+	if !cvars.ExternalEnts.Bool() {
+		return m.Entities
+	}
+	for _, n := range []string{
+		fmt.Sprintf("maps/%s@%04x.ent", mapName, m.EntitiesCRC),
+		fmt.Sprintf("maps/%s.ent", mapName),
+	} {
+		b, err := filesystem.ReadFile(n)
+		if err != nil {
+			continue
+		}
+		slog.Debug("Loaded external entity file", slog.String("file", n))
+		return bsp.ParseEntities(bytes.NewReader(b))
+	}
+	return m.Entities
+}
+
 // The entities are directly placed in the array, rather than allocated with
 // ED_Alloc, because otherwise an error loading the map would have entity
 // number references out of order.
@@ -177,7 +236,7 @@ const (
 // Used for both fresh maps and savegame loads.  A fresh map would also need
 // to call ED_CallSpawnFunctions () to let the objects initialize themselves.
 func (s *Server) loadEntities(data []*bsp.Entity, mapName string) error {
-	progsdat.Globals.Time = s.time
+	s.progsdat.Globals.Time = s.time
 	inhibit := 0
 	eNr := -1
 
@@ -195,7 +254,7 @@ func (s *Server) loadEntities(data []*bsp.Entity, mapName string) error {
 		}
 		s.parse(eNr, j)
 
-		ev := entvars.Get(eNr)
+		ev := s.entvars.Get(eNr)
 
 		// remove things from different skill levels or deathmatch
 		if cvars.DeathMatch.Bool() {
@@ -219,8 +278,8 @@ func (s *Server) loadEntities(data []*bsp.Entity, mapName string) error {
 			continue
 		}
 
-		fname, _ := progsdat.String(ev.ClassName)
-		fidx, err := progsdat.FindFunction(fname)
+		fname, _ := s.progsdat.String(ev.ClassName)
+		fidx, err := s.progsdat.FindFunction(fname)
 
 		if err != nil {
 			slog.Warn("No spawn function", slog.String("map", mapName))
@@ -229,7 +288,7 @@ func (s *Server) loadEntities(data []*bsp.Entity, mapName string) error {
 			continue
 		}
 
-		progsdat.Globals.Self = int32(eNr)
+		s.progsdat.Globals.Self = int32(eNr)
 		if err := s.vm.ExecuteProgram(int32(fidx), s); err != nil {
 			return err
 		}
@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"goquake/bsp"
+	"goquake/cvars"
+	"goquake/filesystem"
+	"goquake/protocol"
+)
+
+func TestEdictAllocGrows(t *testing.T) {
+	s := lagTestServer(t)
+	s.protocol = protocol.GoQuake
+	e := s.edicts[1]
+	ev := s.entvars.Get(1)
+	for i := 0; i < 10; i++ {
+		n, err := s.edictAlloc()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 3+i {
+			t.Errorf("edictAlloc = %d, want %d", n, 3+i)
+		}
+	}
+	if s.maxEdicts != 16 {
+		t.Errorf("maxEdicts = %d, want 16", s.maxEdicts)
+	}
+	// edicts and entity vars must not move
+	if s.edicts[1] != e || s.entvars.Get(1) != ev {
+		t.Error("edict 1 moved while growing")
+	}
+}
+
+func TestEdictAllocLimit(t *testing.T) {
+	s := lagTestServer(t)
+	s.protocol = protocol.NetQuake
+	s.numEdicts = s.maxEdicts
+	if err := s.growEdicts(protocol.MaxLimits(protocol.NetQuake).Edicts + 1); err == nil {
+		t.Error("grew past the limit of the protocol")
+	}
+}
+
+func TestMapEntities(t *testing.T) {
+	old := filesystem.BaseDir()
+	t.Cleanup(func() { filesystem.UseBaseDir(old) })
+	base := t.TempDir()
+	maps := filepath.Join(base, "id1", "maps")
+	if err := os.MkdirAll(maps, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	m := &bsp.Model{
+		Entities:    bsp.ParseEntities(strings.NewReader("{\n\"classname\" \"worldspawn\"\n}\n")),
+		EntitiesCRC: 0x1234,
+	}
+	write := func(name, classname string) {
+		t.Helper()
+		data := fmt.Sprintf("{\n\"classname\" \"%s\"\n}\n", classname)
+		if err := os.WriteFile(filepath.Join(maps, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	load := func() string {
+		t.Helper()
+		es := mapEntities(m, "test")
+		if len(es) != 1 {
+			t.Fatalf("got %d entities, want 1", len(es))
+		}
+		n, _ := es[0].Name()
+		return n
+	}
+	filesystem.UseBaseDir(base)
+
+	if got := load(); got != "worldspawn" {
+		t.Errorf("without ent file got %q", got)
+	}
+	write("test.ent", "generic")
+	if got := load(); got != "generic" {
+		t.Errorf("with ent file got %q", got)
+	}
+	write("test@1234.ent", "matching")
+	write("test@0000.ent", "other")
+	if got := load(); got != "matching" {
+		t.Errorf("with crc ent file got %q", got)
+	}
+
+	cvars.ExternalEnts.SetByString("0")
+	defer cvars.ExternalEnts.Reset()
+	if got := load(); got != "worldspawn" {
+		t.Errorf("with external_ents 0 got %q", got)
+	}
+}
@@ -13,31 +13,31 @@ import (
 	"goquake/math"
 )
 
-func HostInit() {
+func (s *Server) HostInit() {
 	// TODO: this is some random stuff and needs cleanup
-	svs.maxClients = 1
+	s.svs.maxClients = 1
 	if cmdl.Dedicated() {
-		svs.maxClients = cmdl.DedicatedNum()
+		s.svs.maxClients = cmdl.DedicatedNum()
 	}
 	if cmdl.Listen() {
 		if cmdl.Dedicated() {
 			debug.PrintStack()
 			log.Fatalf("Only one of -dedicated or -listen can be specified")
 		}
-		svs.maxClients = cmdl.ListenNum()
+		s.svs.maxClients = cmdl.ListenNum()
 	}
-	if svs.maxClients < 1 {
-		svs.maxClients = 8
-	} else if svs.maxClients > 16 {
-		svs.maxClients = 16
+	if s.svs.maxClients < 1 {
+		s.svs.maxClients = 8
+	} else if s.svs.maxClients > 16 {
+		s.svs.maxClients = 16
 	}
 
-	svs.maxClientsLimit = svs.maxClients
-	if svs.maxClientsLimit < 4 {
-		svs.maxClientsLimit = 4
+	s.svs.maxClientsLimit = s.svs.maxClients
+	if s.svs.maxClientsLimit < 4 {
+		s.svs.maxClientsLimit = 4
 	}
-	CreateSVClients()
-	if svs.maxClients > 1 {
+	s.createClients()
+	if s.svs.maxClients > 1 {
 		cvars.DeathMatch.SetByString("1")
 	} else {
 		cvars.DeathMatch.SetByString("0")
@@ -71,7 +71,7 @@ func init() {
 
 func (s *Server) ServerFrame() error {
 	// run the world state
-	progsdat.Globals.FrameTime = float32(s.gametime.FrameTime())
+	s.progsdat.Globals.FrameTime = float32(s.gametime.FrameTime())
 
 	// set the time and clear the general datagram
 	s.datagram.ClearMessage()
@@ -90,11 +90,12 @@ func (s *Server) ServerFrame() error {
 	if !s.paused {
 		// TODO(therjak): is this pause stuff really needed?
 		// always pause in single player if in console or menus
-		//if svs.maxClients > 1 || keyDestination == keys.Game {
+		//if s.svs.maxClients > 1 || keyDestination == keys.Game {
 		if err := s.runPhysics(); err != nil {
 			return err
 		}
 		//}
+		s.checkMatch()
 	}
 	// send all messages to the clients
 	if err := s.SendClientMessages(); err != nil {
@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"testing"
+
+	"goquake/math/vec"
+)
+
+func TestIndependentServers(t *testing.T) {
+	servers := []struct {
+		name string
+		s    *Server
+		x    float32 // position of the target
+	}{
+		{"left", lagTestServer(t), -200},
+		{"right", lagTestServer(t), 200},
+	}
+	for _, sv := range servers {
+		sv.s.entvars.Get(2).Origin = vec.Vec3{sv.x, 0, 0}
+		if err := sv.s.vm.LinkEdict(2, false, sv.s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, sv := range servers {
+		t.Run(sv.name, func(t *testing.T) {
+			t.Parallel()
+			for range 100 {
+				left := svMove(vec.Vec3{}, vec.Vec3{}, vec.Vec3{}, vec.Vec3{-400, 0, 0}, MOVE_NORMAL, 1, sv.s)
+				right := svMove(vec.Vec3{}, vec.Vec3{}, vec.Vec3{}, vec.Vec3{400, 0, 0}, MOVE_NORMAL, 1, sv.s)
+				if hit := left.EntPointer && left.EntNumber == 2; hit != (sv.x < 0) {
+					t.Fatalf("left trace hit = %v", hit)
+				}
+				if hit := right.EntPointer && right.EntNumber == 2; hit != (sv.x > 0) {
+					t.Fatalf("right trace hit = %v", hit)
+				}
+			}
+		})
+	}
+}
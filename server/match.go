@@ -0,0 +1,472 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"unicode"
+
+	"goquake/cbuf"
+	"goquake/cvars"
+	"goquake/filesystem"
+	svc "goquake/protocol/server"
+)
+
+type matchState int
+
+const (
+	matchWarmup       matchState = iota // players get ready, frags do not count
+	matchCountdown                      // all players are ready
+	matchLive                           // fraglimit and timelimit apply
+	matchIntermission                   // the map is over
+)
+
+func (m matchState) String() string {
+	switch m {
+	case matchWarmup:
+		return "warmup"
+	case matchCountdown:
+		return "countdown"
+	case matchLive:
+		return "live"
+	case matchIntermission:
+		return "intermission"
+	}
+	return fmt.Sprintf("matchState(%d)", int(m))
+}
+
+type voteKind int
+
+const (
+	voteNone voteKind = iota
+	voteMap
+	voteKick
+	voteRestart
+)
+
+// vote is a running callvote.
+type vote struct {
+	kind   voteKind
+	arg    string       // map name or name of the kicked player
+	target int          // client id of the kicked player
+	end    float32      // server time the vote fails
+	votes  map[int]bool // client id to yes/no
+}
+
+type match struct {
+	state     matchState
+	countdown float32 // server time the countdown ends
+	lastCount int     // last announced second of the countdown
+	goLive    bool    // the next map restart starts the match
+	vote      vote
+}
+
+// start is called for every new map.
+func (m *match) start() {
+	m.vote = vote{}
+	switch {
+	case m.goLive, !cvars.DeathMatch.Bool(), !cvars.ServerWarmup.Bool():
+		m.state = matchLive
+	default:
+		m.state = matchWarmup
+	}
+	m.goLive = false
+}
+
+// limitsActive returns true if fraglimit and timelimit should end the map.
+func (m *match) limitsActive() bool {
+	return m.state == matchLive || m.state == matchIntermission
+}
+
+// parseMapList returns the maps of a rotation file. Each line holds one map
+// name, empty lines and // comments are ignored.
+func parseMapList(data string) []string {
+	var maps []string
+	for _, l := range strings.Split(data, "\n") {
+		if i := strings.Index(l, "//"); i >= 0 {
+			l = l[:i]
+		}
+		if f := strings.Fields(l); len(f) > 0 {
+			maps = append(maps, f[0])
+		}
+	}
+	return maps
+}
+
+// nextMap returns the map after current in the rotation. If current is not
+// part of the rotation it starts from the beginning.
+func nextMap(current string, maps []string) string {
+	if len(maps) == 0 {
+		return ""
+	}
+	for i, m := range maps {
+		if strings.EqualFold(m, current) {
+			return maps[(i+1)%len(maps)]
+		}
+	}
+	return maps[0]
+}
+
+// MapList returns the map rotation read from the sv_maplist file.
+func (s *Server) MapList() ([]string, error) {
+	name := cvars.ServerMapList.String()
+	if name == "" {
+		return nil, nil
+	}
+	b, err := filesystem.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read sv_maplist %s: %w", name, err)
+	}
+	return parseMapList(string(b)), nil
+}
+
+// NextMap returns the map following the current one in the rotation or an
+// empty string if there is no rotation.
+func (s *Server) NextMap() (string, error) {
+	maps, err := s.MapList()
+	if err != nil {
+		return "", err
+	}
+	return nextMap(s.name, maps), nil
+}
+
+func (s *Server) mapListCmd(sc *SVClient) {
+	maps, err := s.MapList()
+	if err != nil {
+		log.Printf("%v\n", err)
+	}
+	if len(maps) == 0 {
+		sc.Printf("No map rotation\n")
+		return
+	}
+	next := nextMap(s.name, maps)
+	for _, m := range maps {
+		if m == next {
+			sc.Printf("> %s\n", m)
+		} else {
+			sc.Printf("  %s\n", m)
+		}
+	}
+}
+
+// rotationLevel returns the level QuakeC should change to. In deathmatch the
+// rotation overrides the level of the progs.
+func (s *Server) rotationLevel(level string) string {
+	if !cvars.DeathMatch.Bool() {
+		return level
+	}
+	m, err := s.NextMap()
+	if err != nil {
+		log.Printf("%v\n", err)
+		return level
+	}
+	if m == "" {
+		return level
+	}
+	return m
+}
+
+func (s *Server) broadcastCenterPrint(m string) {
+	for _, c := range s.clients {
+		if c.active && c.spawned {
+			svc.WriteCenterPrint(m, &c.msg)
+		}
+	}
+}
+
+// players returns the clients in the game which can ready up and vote.
+func (s *Server) players() []*SVClient {
+	var r []*SVClient
+	for _, c := range s.clients {
+		if c.active && c.spawned && !c.spectator {
+			r = append(r, c)
+		}
+	}
+	return r
+}
+
+func (s *Server) allReady() bool {
+	p := s.players()
+	if len(p) == 0 {
+		return false
+	}
+	for _, c := range p {
+		if !c.ready {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) readyCmd(sc *SVClient, ready bool) {
+	if s.match.state != matchWarmup && s.match.state != matchCountdown {
+		sc.Printf("The match is not in warmup\n")
+		return
+	}
+	if sc.ready == ready {
+		return
+	}
+	sc.ready = ready
+	if ready {
+		s.BroadcastPrintf("%s is ready\n", sc.name)
+	} else {
+		s.BroadcastPrintf("%s is not ready\n", sc.name)
+	}
+}
+
+// endMap is called before the server changes to another map.
+func (m *match) endMap(clients []*SVClient) {
+	m.goLive = false
+	for _, c := range clients {
+		// bots are always ready
+		c.ready = c.bot != nil
+	}
+}
+
+// checkMatch advances the match state once per server frame.
+func (s *Server) checkMatch() {
+	s.checkVote()
+	m := &s.match
+	switch m.state {
+	case matchWarmup:
+		if s.allReady() {
+			m.state = matchCountdown
+			m.countdown = s.time + cvars.ServerCountdown.Value()
+			m.lastCount = -1
+		}
+	case matchCountdown:
+		if !s.allReady() {
+			m.state = matchWarmup
+			s.broadcastCenterPrint("Countdown aborted")
+			return
+		}
+		left := int(math.Ceil(float64(m.countdown - s.time)))
+		if left <= 0 {
+			m.state = matchLive
+			m.goLive = true
+			s.BroadcastPrint("The match is live\n")
+			s.commands.AddText("restart\n")
+			return
+		}
+		if left != m.lastCount {
+			m.lastCount = left
+			s.broadcastCenterPrint(fmt.Sprintf("Match starts in %d", left))
+		}
+	case matchLive:
+		if s.limitReached() {
+			m.state = matchIntermission
+			log.Printf("Match on %s is over\n", s.name)
+		}
+	}
+}
+
+// limitReached returns true if timelimit or fraglimit is reached.
+func (s *Server) limitReached() bool {
+	if !cvars.DeathMatch.Bool() {
+		return false
+	}
+	if tl := cvars.TimeLimit.Value(); tl > 0 && s.time >= tl*60 {
+		return true
+	}
+	fl := cvars.FragLimit.Value()
+	if fl <= 0 {
+		return false
+	}
+	for _, c := range s.players() {
+		if s.entvars.Get(c.edictId).Frags >= fl {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) callVoteCmd(sc *SVClient, a cbuf.Arguments) {
+	if !cvars.ServerAllowVote.Bool() {
+		sc.Printf("Voting is not allowed\n")
+		return
+	}
+	if s.match.vote.kind != voteNone {
+		sc.Printf("A vote is already in progress\n")
+		return
+	}
+	if s.match.state == matchIntermission {
+		sc.Printf("Can't vote during intermission\n")
+		return
+	}
+	args := a.Args()
+	if len(args) < 2 {
+		sc.Printf("callvote map <mapname> | kick <name or # num> | restart\n")
+		return
+	}
+	v := vote{
+		end:   s.time + cvars.ServerVoteTime.Value(),
+		votes: map[int]bool{sc.id: true},
+	}
+	switch strings.ToLower(args[1].String()) {
+	case "map":
+		if len(args) < 3 {
+			sc.Printf("callvote map <mapname>\n")
+			return
+		}
+		v.kind = voteMap
+		v.arg = args[2].String()
+		// the name ends up in the command buffer
+		if !validMapName(v.arg) {
+			sc.Printf("Invalid map name %s\n", v.arg)
+			return
+		}
+		if _, err := filesystem.Stat(fmt.Sprintf("maps/%s.bsp", v.arg)); err != nil {
+			sc.Printf("Can't find map %s\n", v.arg)
+			return
+		}
+	case "kick":
+		if len(args) < 3 {
+			sc.Printf("callvote kick <name or # num>\n")
+			return
+		}
+		t := s.findClient(args[2:])
+		if t == nil {
+			sc.Printf("Can't find player %s\n", args[2].String())
+			return
+		}
+		if t == sc {
+			sc.Printf("Can't vote to kick yourself\n")
+			return
+		}
+		v.kind = voteKick
+		v.arg = t.name
+		v.target = t.id
+	case "restart":
+		v.kind = voteRestart
+	default:
+		sc.Printf("Unknown vote %s\n", args[1].String())
+		return
+	}
+	s.match.vote = v
+	s.BroadcastPrintf("%s called a vote: %s\n", sc.name, v.description())
+	s.BroadcastPrint("Type \"vote yes\" or \"vote no\"\n")
+}
+
+// findClient returns the client with the name or number given as
+// "name" or "# num".
+func (s *Server) findClient(args []cbuf.QArg) *SVClient {
+	if len(args) > 1 && args[0].String() == "#" {
+		i := args[1].Int() - 1
+		if i < 0 || i >= s.svs.maxClients || !s.clients[i].active {
+			return nil
+		}
+		return s.clients[i]
+	}
+	for _, c := range s.clients {
+		if c.active && c.name == args[0].String() {
+			return c
+		}
+	}
+	return nil
+}
+
+func (v *vote) description() string {
+	switch v.kind {
+	case voteMap:
+		return "map " + v.arg
+	case voteKick:
+		return "kick " + v.arg
+	case voteRestart:
+		return "restart"
+	}
+	return ""
+}
+
+func (s *Server) voteCmd(sc *SVClient, a cbuf.Arguments) {
+	v := &s.match.vote
+	if v.kind == voteNone {
+		sc.Printf("No vote in progress\n")
+		return
+	}
+	args := a.Args()
+	if len(args) < 2 {
+		sc.Printf("vote yes|no\n")
+		return
+	}
+	switch strings.ToLower(args[1].String()) {
+	case "yes", "y", "1":
+		v.votes[sc.id] = true
+	case "no", "n", "0":
+		v.votes[sc.id] = false
+	default:
+		sc.Printf("vote yes|no\n")
+		return
+	}
+	s.BroadcastPrintf("%s voted %s\n", sc.name, args[1].String())
+}
+
+// count returns the yes and no votes of the players and the number of
+// players.
+func (v *vote) count(players []*SVClient) (yes, no, total int) {
+	for _, c := range players {
+		if c.bot != nil {
+			// bots do not vote
+			continue
+		}
+		total++
+		if y, ok := v.votes[c.id]; ok {
+			if y {
+				yes++
+			} else {
+				no++
+			}
+		}
+	}
+	return yes, no, total
+}
+
+// checkVote passes a vote once more than half of the players agreed and fails
+// it if that can not happen anymore.
+func (s *Server) checkVote() {
+	v := &s.match.vote
+	if v.kind == voteNone {
+		return
+	}
+	yes, no, total := v.count(s.players())
+	switch {
+	case yes*2 > total:
+		s.BroadcastPrintf("Vote passed: %s\n", v.description())
+		s.passVote(*v)
+		*v = vote{}
+	case no*2 >= total || s.time >= v.end:
+		s.BroadcastPrintf("Vote failed: %s\n", v.description())
+		*v = vote{}
+	}
+}
+
+// validMapName returns whether name can be passed to changelevel without
+// adding commands or leaving the maps directory.
+func validMapName(name string) bool {
+	if name == "" || strings.Contains(name, "..") {
+		return false
+	}
+	return !strings.ContainsFunc(name, func(r rune) bool {
+		return r == ';' || r == '"' || r == '\'' || unicode.IsSpace(r) || unicode.IsControl(r)
+	})
+}
+
+func (s *Server) passVote(v vote) {
+	switch v.kind {
+	case voteMap:
+		s.commands.AddText(fmt.Sprintf("changelevel %s\n", v.arg))
+	case voteKick:
+		c := s.clients[v.target]
+		if !c.active || c.name != v.arg {
+			return
+		}
+		c.Printf("Kicked by vote\n")
+		if err := s.Drop(c, false); err != nil {
+			log.Printf("Drop error: %v", err)
+		}
+	case voteRestart:
+		s.commands.AddText("restart\n")
+	}
+}
@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMapList(t *testing.T) {
+	const data = `// deathmatch rotation
+dm1
+  dm2   // the claustrophobopolis
+
+e1m7
+dm6 ignored
+`
+	maps := parseMapList(data)
+	if want := []string{"dm1", "dm2", "e1m7", "dm6"}; !slices.Equal(maps, want) {
+		t.Fatalf("parseMapList = %v, want %v", maps, want)
+	}
+	tests := []struct {
+		current, want string
+	}{
+		{"dm1", "dm2"},
+		{"DM2", "e1m7"},
+		{"dm6", "dm1"},
+		{"start", "dm1"},
+	}
+	for _, tc := range tests {
+		if got := nextMap(tc.current, maps); got != tc.want {
+			t.Errorf("nextMap(%q) = %q, want %q", tc.current, got, tc.want)
+		}
+	}
+	if got := nextMap("dm1", nil); got != "" {
+		t.Errorf("nextMap without rotation = %q", got)
+	}
+}
+
+func TestVoteCount(t *testing.T) {
+	s := NewServer(nil)
+	s.clients = []*SVClient{
+		{id: 0, active: true, spawned: true},
+		{id: 1, active: true, spawned: true},
+		{id: 2, active: true, spawned: true},
+		{id: 3, active: true, spawned: true, spectator: true},
+		{id: 4},
+	}
+	v := vote{kind: voteRestart, votes: map[int]bool{0: true, 1: false, 3: true, 4: true}}
+	yes, no, total := v.count(s.players())
+	if yes != 1 || no != 1 || total != 3 {
+		t.Errorf("count = %d, %d, %d, want 1, 1, 3", yes, no, total)
+	}
+	s.clients[2].spectator = true
+	if yes, no, total = v.count(s.players()); yes != 1 || no != 1 || total != 2 {
+		t.Errorf("count = %d, %d, %d, want 1, 1, 2", yes, no, total)
+	}
+}
+
+func TestValidMapName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"e1m1", true},
+		{"dm/aerowalk", true},
+		{"", false},
+		{"e1m1;quit", false},
+		{"e1m1\nquit", false},
+		{"e1m1 quit", false},
+		{"\"e1m1", false},
+		{"'e1m1", false},
+		{"../e1m1", false},
+	}
+	for _, tc := range tests {
+		if got := validMapName(tc.name); got != tc.want {
+			t.Errorf("validMapName(%q) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -17,7 +17,7 @@ import (
 // pr_global_struct->trace_normal is set to the normal of the blocking wall
 func (v *virtualMachine) monsterMoveStep(ent int, move vec.Vec3, relink bool, s *Server) (bool, error) {
 	const STEPSIZE = 18
-	ev := entvars.Get(ent)
+	ev := s.entvars.Get(ent)
 	mins := vec.VFromA(ev.Mins)
 	maxs := vec.VFromA(ev.Maxs)
 	flags := int(ev.Flags)
@@ -30,7 +30,7 @@ func (v *virtualMachine) monsterMoveStep(ent int, move vec.Vec3, relink bool, s
 			neworg := vec.Add(origin, move)
 			enemy := int(ev.Enemy)
 			if i == 0 && enemy != 0 {
-				dz := origin[2] - entvars.Get(enemy).Origin[2]
+				dz := origin[2] - s.entvars.Get(enemy).Origin[2]
 				if dz > 40 {
 					neworg[2] -= 8
 				}
@@ -130,8 +130,8 @@ func (v *virtualMachine) monsterMoveStep(ent int, move vec.Vec3, relink bool, s
 }
 
 // This was a major timewaster in progs
-func changeYaw(ent int) {
-	ev := entvars.Get(ent)
+func (s *Server) changeYaw(ent int) {
+	ev := s.entvars.Get(ent)
 	current := math.AngleMod32(ev.Angles[1])
 	ideal := ev.IdealYaw
 	speed := ev.YawSpeed
@@ -164,10 +164,10 @@ func changeYaw(ent int) {
 // Turns to the movement direction, and walks the current distance if
 // facing it.
 func (v *virtualMachine) monsterStepDirection(ent int, yaw, dist float32, s *Server) (bool, error) {
-	ev := entvars.Get(ent)
+	ev := s.entvars.Get(ent)
 	ev.IdealYaw = yaw
 
-	changeYaw(ent)
+	s.changeYaw(ent)
 
 	yaw = yaw * math32.Pi * 2 / 360
 	si, co := math32.Sincos(yaw)
@@ -200,8 +200,8 @@ func (v *virtualMachine) monsterStepDirection(ent int, yaw, dist float32, s *Ser
 
 func (v *virtualMachine) monsterNewChaseDir(a, e int, dist float32, s *Server) error {
 	const DI_NODIR = -1
-	actor := entvars.Get(a)
-	enemy := entvars.Get(e)
+	actor := s.entvars.Get(a)
+	enemy := s.entvars.Get(e)
 
 	olddir := math.AngleMod32(math32.Trunc(actor.IdealYaw/45) * 45)
 	turnaround := math.AngleMod32(olddir - 180)
@@ -322,9 +322,9 @@ func (v *virtualMachine) monsterNewChaseDir(a, e int, dist float32, s *Server) e
 	return nil
 }
 
-func monsterCloseEnough(e, g int, dist float32) bool {
-	eev := entvars.Get(e)
-	gev := entvars.Get(g)
+func (s *Server) monsterCloseEnough(e, g int, dist float32) bool {
+	eev := s.entvars.Get(e)
+	gev := s.entvars.Get(g)
 
 	for i := 0; i < 3; i++ {
 		if (gev.AbsMin[i] > eev.AbsMax[i]+dist) ||
@@ -337,18 +337,18 @@ func monsterCloseEnough(e, g int, dist float32) bool {
 
 // this is part of vm_functions
 func (v *virtualMachine) monsterMoveToGoal(s *Server) error {
-	ent := int(progsdat.Globals.Self)
-	ev := entvars.Get(ent)
+	ent := int(s.progsdat.Globals.Self)
+	ev := s.entvars.Get(ent)
 
 	if int(ev.Flags)&(FL_ONGROUND|FL_FLY|FL_SWIM) == 0 {
-		progsdat.Globals.Returnf()[0] = 0
+		s.progsdat.Globals.Returnf()[0] = 0
 		return nil
 	}
 	goal := int(ev.GoalEntity)
-	dist := progsdat.RawGlobalsF[progs.OffsetParm0]
+	dist := s.progsdat.RawGlobalsF[progs.OffsetParm0]
 
 	// if the next step hits the enemy, return immediately
-	if ev.Enemy != 0 && monsterCloseEnough(ent, goal, dist) {
+	if ev.Enemy != 0 && s.monsterCloseEnough(ent, goal, dist) {
 		return nil
 	}
 
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"log/slog"
+	"math"
+
+	"goquake/cvars"
+	"goquake/protos"
+)
+
+const (
+	// moveSpeedFactor is how much faster than sv_maxspeed a client may ask to
+	// move. The physics limit the speed anyway but the default client sends
+	// cl_forwardspeed times cl_movespeedkey which is 2.5 times sv_maxspeed.
+	moveSpeedFactor = 2.5
+	// messageTimeSlop allows for rounding of the server time.
+	messageTimeSlop = 0.01
+	// forgiveTime is the server time without violations after which one
+	// violation is forgiven.
+	forgiveTime = 5
+)
+
+// moveCheck is the per client state of the movement sanity checks.
+type moveCheck struct {
+	violations int
+	bad        bool    // a violation happened in the current move
+	last       float32 // server time of the last violation or forgiven one
+}
+
+// reset restarts the forgiving, the server time starts over with every map.
+func (m *moveCheck) reset() {
+	m.last = 0
+}
+
+func (sc *SVClient) moveViolation(reason string, v float32) {
+	sc.moveCheck.violations++
+	sc.moveCheck.bad = true
+	slog.Warn("Invalid movement",
+		slog.String("player", sc.name),
+		slog.String("reason", reason),
+		slog.Float64("value", float64(v)),
+		slog.Int("violations", sc.moveCheck.violations))
+}
+
+func finite(f float32) bool {
+	return !math.IsNaN(float64(f)) && !math.IsInf(float64(f), 0)
+}
+
+// clampMove limits a move speed to what a client can send. Legitimate clients
+// exceed the limit, e.g. with mouse movement or a lowered sv_maxspeed, so only
+// values which are no numbers at all are violations.
+func (sc *SVClient) clampMove(reason string, v float32) float32 {
+	limit := cvars.ServerMaxSpeed.Value() * moveSpeedFactor
+	switch {
+	case !finite(v):
+		sc.moveViolation(reason, v)
+		return 0
+	case v > limit:
+		return limit
+	case v < -limit:
+		return -limit
+	}
+	return v
+}
+
+// normalizePitch maps a pitch into [-180, 180). The byte encoded angles of
+// the older protocols arrive as [0, 360).
+func normalizePitch(p float32) float32 {
+	p = float32(math.Mod(float64(p)+180, 360))
+	if p < 0 {
+		p += 360
+	}
+	return p - 180
+}
+
+// checkMove validates and clamps a move command. It returns false if the
+// client should be kicked.
+func (sc *SVClient) checkMove(s *Server, mc *protos.UsrCmd) bool {
+	if !cvars.ServerMoveCheck.Bool() {
+		return true
+	}
+	mt := mc.GetMessageTime()
+	switch {
+	case !finite(mt), mt < 0:
+		sc.moveViolation("message_time", mt)
+		mc.SetMessageTime(s.time)
+	case mt > s.time+messageTimeSlop:
+		// the client can not have seen the future
+		sc.moveViolation("message_time", mt)
+		mc.SetMessageTime(s.time)
+	}
+
+	mc.SetForward(sc.clampMove("forward", mc.GetForward()))
+	mc.SetSide(sc.clampMove("side", mc.GetSide()))
+	mc.SetUp(sc.clampMove("up", mc.GetUp()))
+
+	if !finite(mc.GetYaw()) {
+		sc.moveViolation("yaw", mc.GetYaw())
+		mc.SetYaw(0)
+	}
+	if !finite(mc.GetRoll()) {
+		sc.moveViolation("roll", mc.GetRoll())
+		mc.SetRoll(0)
+	}
+	if p := mc.GetPitch(); !finite(p) {
+		sc.moveViolation("pitch", p)
+		mc.SetPitch(0)
+	} else if n := normalizePitch(p); n > 90 || n < -90 {
+		sc.moveViolation("pitch", p)
+		mc.SetPitch(float32(math.Max(-90, math.Min(90, float64(n)))))
+	}
+
+	if i := mc.GetImpulse(); i < 0 || i > 255 {
+		sc.moveViolation("impulse", float32(i))
+		mc.SetImpulse(0)
+	}
+
+	m := &sc.moveCheck
+	if m.bad {
+		m.bad = false
+		m.last = s.time
+	} else if m.violations > 0 && s.time-m.last >= forgiveTime {
+		m.violations--
+		m.last = s.time
+	}
+
+	if k := int(cvars.ServerMoveKick.Value()); k > 0 && m.violations >= k {
+		slog.Warn("Kicked for invalid movement", slog.String("player", sc.name))
+		sc.Printf("Kicked for invalid movement\n")
+		s.BroadcastPrintf("%s was kicked for invalid movement\n", sc.name)
+		return false
+	}
+	return true
+}
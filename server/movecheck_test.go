@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"math"
+	"testing"
+
+	"goquake/cvars"
+	"goquake/protos"
+)
+
+func moveMessage(cmds ...protos.UsrCmd_builder) *protos.ClientMessage {
+	var r []*protos.Cmd
+	for _, c := range cmds {
+		r = append(r, protos.Cmd_builder{MoveCmd: c.Build()}.Build())
+	}
+	return protos.ClientMessage_builder{Cmds: r}.Build()
+}
+
+func TestMoveCheckClamp(t *testing.T) {
+	s := lagTestServer(t)
+	sc := s.clients[0]
+	s.time = 10
+	ok, err := sc.runClientMessage(s, moveMessage(protos.UsrCmd_builder{
+		MessageTime: 9.9,
+		Pitch:       120,
+		Yaw:         float32(math.NaN()),
+		Forward:     100000,
+		Side:        -300,
+		Up:          float32(math.Inf(1)),
+		Impulse:     1000,
+	}))
+	if !ok || err != nil {
+		t.Fatalf("runClientMessage = %v, %v", ok, err)
+	}
+	ev := s.entvars.Get(sc.edictId)
+	limit := cvars.ServerMaxSpeed.Value() * moveSpeedFactor
+	if sc.cmd.forwardmove != limit || sc.cmd.sidemove != -300 || sc.cmd.upmove != 0 {
+		t.Errorf("move = %v %v %v", sc.cmd.forwardmove, sc.cmd.sidemove, sc.cmd.upmove)
+	}
+	if ev.VAngle[0] != 90 || ev.VAngle[1] != 0 {
+		t.Errorf("angles = %v", ev.VAngle)
+	}
+	if ev.Impulse != 0 {
+		t.Errorf("impulse = %v", ev.Impulse)
+	}
+	// the clamped forward move is no violation
+	if v := sc.moveCheck.violations; v != 4 {
+		t.Errorf("violations = %d, want 4", v)
+	}
+}
+
+func TestMoveCheckValid(t *testing.T) {
+	s := lagTestServer(t)
+	sc := s.clients[0]
+	for i := range 200 {
+		s.time = 1 + float32(i)*0.1
+		ok, err := sc.runClientMessage(s, moveMessage(protos.UsrCmd_builder{
+			MessageTime: s.time - 0.05,
+			Pitch:       350, // byte encoded -10
+			Forward:     800,
+			Side:        -700,
+			Up:          400,
+			Impulse:     10,
+		}))
+		if !ok || err != nil {
+			t.Fatalf("runClientMessage = %v, %v", ok, err)
+		}
+	}
+	if v := sc.moveCheck.violations; v != 0 {
+		t.Errorf("violations = %d, want 0", v)
+	}
+	if p := s.entvars.Get(sc.edictId).VAngle[0]; p != 350 {
+		t.Errorf("pitch = %v, want 350", p)
+	}
+}
+
+func TestMoveCheckFastMove(t *testing.T) {
+	cvars.ServerMoveKick.SetByString("3")
+	defer cvars.ServerMoveKick.Reset()
+	s := lagTestServer(t)
+	sc := s.clients[0]
+	// e.g. mouse movement with mlook off
+	for i := range 100 {
+		s.time = 1 + float32(i)/72
+		ok, err := sc.runClientMessage(s, moveMessage(protos.UsrCmd_builder{
+			MessageTime: s.time,
+			Forward:     100000,
+		}))
+		if !ok || err != nil {
+			t.Fatalf("runClientMessage = %v, %v", ok, err)
+		}
+	}
+	if v := sc.moveCheck.violations; v != 0 {
+		t.Errorf("violations = %d, want 0", v)
+	}
+}
+
+func TestMoveCheckKick(t *testing.T) {
+	cvars.ServerMoveKick.SetByString("3")
+	defer cvars.ServerMoveKick.Reset()
+	s := lagTestServer(t)
+	sc := s.clients[0]
+	nan := float32(math.NaN())
+	move := func(yaw float32) bool {
+		t.Helper()
+		ok, err := sc.runClientMessage(s, moveMessage(protos.UsrCmd_builder{
+			MessageTime: s.time,
+			Yaw:         yaw,
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+	s.time = 1
+	move(nan)
+	s.time = 2
+	move(nan)
+	// a while of valid moves forgives one violation
+	s.time = 2 + forgiveTime
+	move(0)
+	if v := sc.moveCheck.violations; v != 1 {
+		t.Fatalf("violations = %d, want 1", v)
+	}
+	move(nan)
+	if !move(nan) {
+		return
+	}
+	t.Errorf("not kicked with %d violations", sc.moveCheck.violations)
+}
+
+func TestMoveCheckFuture(t *testing.T) {
+	s := lagTestServer(t)
+	sc := s.clients[0]
+	s.time = 5
+	if _, err := sc.runClientMessage(s, moveMessage(protos.UsrCmd_builder{MessageTime: 50})); err != nil {
+		t.Fatal(err)
+	}
+	if sc.viewTime != 5 {
+		t.Errorf("viewTime = %v, want 5", sc.viewTime)
+	}
+	if v := sc.moveCheck.violations; v != 1 {
+		t.Errorf("violations = %d, want 1", v)
+	}
+}
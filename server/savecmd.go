@@ -17,8 +17,8 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
-func saveGameComment(p *progs.LoadedProg) string {
-	levelName, err := p.String(entvars.Get(0).Message)
+func (s *Server) saveGameComment(p *progs.LoadedProg) string {
+	levelName, err := p.String(s.entvars.Get(0).Message)
 	if err != nil {
 		levelName = ""
 	}
@@ -36,12 +36,12 @@ func (s *Server) saveCmd(sc *SVClient, a cbuf.Arguments) {
 		return
 	}
 
-	if svs.maxClients != 1 || !sc.admin {
+	if s.svs.maxClients != 1 || !sc.admin {
 		sc.Printf("Can't save multiplayer games.\n")
 		return
 	}
 
-	if entvars.Get(sc.edictId).Health <= 0 {
+	if s.entvars.Get(sc.edictId).Health <= 0 {
 		sc.Printf("Can't savegame with a dead player\n")
 		return
 	}
@@ -62,7 +62,7 @@ func (s *Server) saveCmd(sc *SVClient, a cbuf.Arguments) {
 	sc.Printf("Saving game to %s...\n", fullname)
 
 	data := protos.SaveGame_builder{
-		Comment:      saveGameComment(progsdat),
+		Comment:      s.saveGameComment(s.progsdat),
 		SpawnParams:  sc.spawnParams[:], //[]float32
 		CurrentSkill: int32(cvars.Skill.Value()),
 		MapName:      s.name,
@@ -10,19 +10,24 @@ import (
 	"time"
 
 	"goquake/bsp"
+	"goquake/cbuf"
 	cmdl "goquake/commandline"
+	"goquake/crc"
 	"goquake/cvar"
 	"goquake/cvars"
+	"goquake/filesystem"
 	"goquake/gametime"
 	"goquake/math"
 	"goquake/math/vec"
 	"goquake/model"
 	"goquake/net"
+	"goquake/pmove"
 	"goquake/progs"
 	"goquake/protocol"
 	svc "goquake/protocol/server"
 	"goquake/protos"
 	"goquake/rand"
+	"goquake/ring"
 
 	"github.com/chewxy/math32"
 	"google.golang.org/protobuf/proto"
@@ -81,7 +86,7 @@ type Server struct {
 
 	name string // map name
 
-	edicts []Edict
+	edicts []*Edict
 
 	soundPrecache []string
 
@@ -91,13 +96,15 @@ type Server struct {
 	modelPrecache []string
 	models        []model.Model
 
-	datagram         net.Message
-	reliableDatagram net.Message
-	signon           net.Message
+	datagram         svc.Message
+	reliableDatagram svc.Message
+	signon           svc.Message
+	csqcEvent        svc.Message // payload of the next csqcevent
 
 	gametime gametime.GameTime
 
 	lastCheck int
+	checkPVS  []byte // pvs of the lastCheck client
 
 	numEdicts int
 	maxEdicts int
@@ -111,6 +118,10 @@ type Server struct {
 
 	protocolFlags uint32
 
+	// crc and size of csprogs.dat, size 0 if there is none
+	csqcCRC  uint16
+	csqcSize int
+
 	// active is used by the client to check if the server is local
 	// needs to be changed to a client state which gets the state change by channel
 	active   bool
@@ -119,25 +130,45 @@ type Server struct {
 
 	state ServerState // some actions are only valid during load
 
-}
+	lag lagHistory
 
-var (
-	svs         = ServerStatic{}
-	host_client int
-	progsdat    *progs.LoadedProg
-)
+	match match
 
-var (
-	msgBuf       = net.Message{}
-	msgBufMaxLen = 0
-)
+	svs        ServerStatic
+	clients    []*SVClient
+	hostClient int // client whose messages are currently read
+	listener   *net.Listener
+	commands   *cbuf.CommandBuffer // receives changelevel and restart
+
+	progsdat   *progs.LoadedProg
+	entvars    *progs.EntityVars
+	modelCache map[string]model.Model // created in SpawnServer
+
+	// areas for fast entity linking, created in clearWorld
+	edictToRing map[int]*ring.Ring[int]
+	area        *areaNode
+	boxHull     bsp.Hull
+
+	msgBuf       svc.Message
+	msgBufMaxLen int
+}
 
 func NewServer(cv *cvar.Cvars) *Server {
 	s := &Server{
-		models: make([]model.Model, 1),
-		vm:     NewVirtualMachine(cv),
-		rand:   rand.New(0),
+		models:   make([]model.Model, 1),
+		vm:       NewVirtualMachine(cv),
+		rand:     rand.New(0),
+		listener: net.DefaultListener(),
+		checkPVS: make([]byte, bsp.MaxMapLeafs/8),
+		commands: cbuf.Default(),
 	}
+	return s
+}
+
+// WatchCvars makes the server tell its clients about changes of the game
+// rules. The cvars are shared by the process, so only one server can watch
+// them.
+func (s *Server) WatchCvars() {
 	cvars.ServerGravity.SetCallback(s.notifyCallback)
 	cvars.ServerFriction.SetCallback(s.notifyCallback)
 	cvars.ServerMaxSpeed.SetCallback(s.notifyCallback)
@@ -145,7 +176,6 @@ func NewServer(cv *cvar.Cvars) *Server {
 	cvars.FragLimit.SetCallback(s.notifyCallback)
 	cvars.TeamPlay.SetCallback(s.notifyCallback)
 	cvars.NoExit.SetCallback(s.notifyCallback)
-	return s
 }
 
 func (s *Server) Map() string {
@@ -153,21 +183,21 @@ func (s *Server) Map() string {
 }
 
 func (s *Server) MaxClients() int {
-	return svs.maxClients
+	return s.svs.maxClients
 }
 
 func (s *Server) MaxClientsLimit() int {
-	return svs.maxClientsLimit
+	return s.svs.maxClientsLimit
 }
 
 func (s *Server) SetMaxClients(m int) {
-	svs.maxClients = m
+	s.svs.maxClients = m
 }
 
 func (s *Server) ActiveClients() int {
 	c := 0
-	for i := 0; i < svs.maxClients; i++ {
-		if sv_clients[i].active {
+	for i := 0; i < s.svs.maxClients; i++ {
+		if s.clients[i].active {
 			c++
 		}
 	}
@@ -175,19 +205,31 @@ func (s *Server) ActiveClients() int {
 }
 
 func (s *Server) ResetServerFlags() {
-	svs.serverFlags = 0
+	s.svs.serverFlags = 0
+}
+
+// SetListener makes the server accept connections from l instead of the
+// default listener. Each server of a process needs its own listener.
+func (s *Server) SetListener(l *net.Listener) {
+	s.listener = l
+}
+
+// SetCommandBuffer makes the commands issued by the server, like a
+// changelevel from QuakeC, go to c instead of the default command buffer.
+func (s *Server) SetCommandBuffer(c *cbuf.CommandBuffer) {
+	s.commands = c
 }
 
 func (s *Server) Listen() {
-	net.Listen(svs.maxClients)
+	s.listener.Listen(s.svs.maxClients)
 }
 
 func (s *Server) Listening() bool {
-	return net.Listening()
+	return s.listener.Listening()
 }
 
 func (s *Server) StopListen() {
-	net.StopListen()
+	s.listener.StopListen()
 }
 
 func (s *Server) NewSeed(seed uint32) {
@@ -226,12 +268,11 @@ func (s *Server) StartParticle(org, dir vec.Vec3, color, count int) {
 }
 
 func (s *Server) SendDatagram(sc *SVClient) (bool, error) {
-	b := msgBuf.Bytes()
 	// If there is space add the server datagram
-	if len(b)+s.datagram.Len() < protocol.MaxDatagram {
-		b = append(b, s.datagram.Bytes()...)
+	if s.msgBuf.Len()+s.datagram.Len() < protocol.MaxDatagram {
+		s.msgBuf.Append(&s.datagram)
 	}
-	if sc.netConnection.SendUnreliableMessage(b) == -1 {
+	if sc.send(&s.msgBuf, false) == -1 {
 		if err := s.Drop(sc, true); err != nil {
 			return false, err
 		}
@@ -241,17 +282,16 @@ func (s *Server) SendDatagram(sc *SVClient) (bool, error) {
 }
 
 func (s *Server) SendReliableDatagram() {
-	b := s.reliableDatagram.Bytes()
-	for _, cl := range sv_clients {
+	for _, cl := range s.clients {
 		if cl.active {
-			cl.msg.WriteBytes(b)
+			cl.msg.Append(&s.reliableDatagram)
 		}
 	}
 	s.reliableDatagram.ClearMessage()
 }
 
 func (s *Server) sendReconnect() {
-	SendReconnectToAll()
+	s.SendReconnectToAll()
 }
 
 /*
@@ -288,7 +328,7 @@ func (s *Server) StartSound(entity, channel, volume int, sample string, attenuat
 }
 
 func (s *Server) sendStartSound(entity, channel, volume, soundnum int, attenuation float32) {
-	ev := entvars.Get(entity)
+	ev := s.entvars.Get(entity)
 	snd := protos.Sound_builder{
 		Entity:   int32(entity),
 		SoundNum: int32(soundnum),
@@ -310,18 +350,18 @@ func (s *Server) sendStartSound(entity, channel, volume, soundnum int, attenuati
 
 func (s *Server) CleanupEntvarEffects() {
 	for i := 1; i < s.numEdicts; i++ {
-		ev := entvars.Get(i)
+		ev := s.entvars.Get(i)
 		eff := int(ev.Effects)
 		ev.Effects = float32(eff &^ svc.EffectMuzzleFlash)
 	}
 }
 
 func (s *Server) WriteClientdataToMessage(player int) {
-	e := entvars.Get(player)
+	e := s.entvars.Get(player)
 	alpha := s.edicts[player].Alpha
 	flags := s.protocolFlags
 	if e.DmgTake != 0 || e.DmgSave != 0 {
-		other := entvars.Get(int(e.DmgInflictor))
+		other := s.entvars.Get(int(e.DmgInflictor))
 		p := protos.Coord_builder{
 			X: other.Origin[0] + 0.5*(other.Mins[0]+other.Maxs[0]),
 			Y: other.Origin[1] + 0.5*(other.Mins[1]+other.Maxs[1]),
@@ -332,7 +372,7 @@ func (s *Server) WriteClientdataToMessage(player int) {
 			Blood:    int32(e.DmgTake),
 			Position: p,
 		}.Build()
-		svc.WriteDamage(dmg, s.protocol, flags, &msgBuf)
+		svc.WriteDamage(dmg, s.protocol, flags, &s.msgBuf)
 		e.DmgTake = 0
 		e.DmgSave = 0
 	}
@@ -347,7 +387,7 @@ func (s *Server) WriteClientdataToMessage(player int) {
 			Y: e.Angles[1],
 			Z: e.Angles[2],
 		}.Build()
-		svc.WriteSetAngle(a, s.protocol, flags, &msgBuf)
+		svc.WriteSetAngle(a, s.protocol, flags, &s.msgBuf)
 		e.FixAngle = 0
 	}
 
@@ -367,7 +407,7 @@ func (s *Server) WriteClientdataToMessage(player int) {
 							return e.Items | v.float << 23
 						}
 		*/
-		return int(e.Items) | int(progsdat.Globals.ServerFlags)<<28
+		return int(e.Items) | int(s.progsdat.Globals.ServerFlags)<<28
 	}()
 	clientData.SetItems(uint32(items))
 	if (int(e.Flags) & progs.FlagOnGround) != 0 {
@@ -378,7 +418,7 @@ func (s *Server) WriteClientdataToMessage(player int) {
 	}
 
 	wmi := 0
-	wms, err := progsdat.String(e.WeaponModel)
+	wms, err := s.progsdat.String(e.WeaponModel)
 	if err == nil {
 		wmi = s.ModelIndex(wms)
 	}
@@ -412,13 +452,13 @@ func (s *Server) WriteClientdataToMessage(player int) {
 	}
 	clientData.SetWeaponAlpha(int32(alpha))
 
-	svc.WriteClientData(clientData, s.protocol, flags, &msgBuf)
+	svc.WriteClientData(clientData, s.protocol, flags, &s.msgBuf)
 }
 
 // Initializes a client_t for a new net connection.  This will only be called
 // once for a player each game, not once for each level change.
 func (s *Server) connectClient(n int) error {
-	old := sv_clients[n]
+	old := s.clients[n]
 	newC := &SVClient{
 		netConnection: old.netConnection,
 		admin:         old.admin, // admin is a property of the connection
@@ -431,35 +471,48 @@ func (s *Server) connectClient(n int) error {
 	if s.loadGame {
 		newC.spawnParams = old.spawnParams
 	} else {
-		if err := s.vm.ExecuteProgram(progsdat.Globals.SetNewParms, s); err != nil {
+		if err := s.vm.ExecuteProgram(s.progsdat.Globals.SetNewParms, s); err != nil {
 			return err
 		}
-		newC.spawnParams = progsdat.Globals.Parm
+		newC.spawnParams = s.progsdat.Globals.Parm
 	}
-	sv_clients[n] = newC
+	s.clients[n] = newC
 	s.SendServerinfo(newC)
 	return nil
 }
 
 func (s *Server) SendClientDatagram(sc *SVClient) (bool, error) {
-	msgBuf.ClearMessage()
-	msgBufMaxLen = protocol.MaxDatagram
+	s.msgBuf.ClearMessage()
+	s.msgBufMaxLen = protocol.MaxDatagram
 	if sc.Address() != net.LocalAddress {
-		msgBufMaxLen = net.DATAGRAM_MTU
+		s.msgBufMaxLen = net.DATAGRAM_MTU
+	}
+	svc.WriteTime(s.time, s.protocol, s.protocolFlags, &s.msgBuf)
+
+	s.WriteClientdataToMessage(sc.viewEdict())
+	s.writeSpectatorView(sc)
+
+	if s.protocol == protocol.GoQuake && sc.viewEdict() == sc.edictId {
+		ev := s.entvars.Get(sc.edictId)
+		ps := protos.PlayerState_builder{
+			Sequence: sc.moveSequence,
+			Origin:   protos.Coord_builder{X: ev.Origin[0], Y: ev.Origin[1], Z: ev.Origin[2]}.Build(),
+			Velocity: protos.Coord_builder{X: ev.Velocity[0], Y: ev.Velocity[1], Z: ev.Velocity[2]}.Build(),
+			Flags:    int32(ev.Flags),
+			MoveType: int32(ev.MoveType),
+			Params:   pmove.ServerParams(0).Proto(),
+		}.Build()
+		svc.WritePlayerState(ps, s.protocol, s.protocolFlags, &s.msgBuf)
 	}
-	svc.WriteTime(s.time, s.protocol, s.protocolFlags, &msgBuf)
-
-	s.WriteClientdataToMessage(sc.edictId)
 
-	s.WriteEntitiesToClient(sc.edictId)
+	s.WriteEntitiesToClient(sc)
 
 	return s.SendDatagram(sc)
 }
 
 func (s *Server) UpdateToReliableMessages() {
-	b := s.reliableDatagram.Bytes()
-	for _, sc := range sv_clients {
-		newFrags := entvars.Get(sc.edictId).Frags
+	for _, sc := range s.clients {
+		newFrags := s.entvars.Get(sc.edictId).Frags
 		if sc.active {
 			// Does it actually matter to compare as float32?
 			// These subtle C things...
@@ -470,7 +523,7 @@ func (s *Server) UpdateToReliableMessages() {
 				}.Build()
 				svc.WriteUpdateFrags(uf, s.protocol, s.protocolFlags, &sc.msg)
 			}
-			sc.msg.WriteBytes(b)
+			sc.msg.Append(&s.reliableDatagram)
 		}
 		sc.oldFrags = int(newFrags)
 	}
@@ -478,45 +531,45 @@ func (s *Server) UpdateToReliableMessages() {
 }
 
 func (s *Server) impact(e1, e2 int) error {
-	oldSelf := progsdat.Globals.Self
-	oldOther := progsdat.Globals.Other
+	oldSelf := s.progsdat.Globals.Self
+	oldOther := s.progsdat.Globals.Other
 
-	progsdat.Globals.Time = s.time
+	s.progsdat.Globals.Time = s.time
 
-	ent1 := entvars.Get(e1)
-	ent2 := entvars.Get(e2)
+	ent1 := s.entvars.Get(e1)
+	ent2 := s.entvars.Get(e2)
 	if ent1.Touch != 0 && ent1.Solid != SOLID_NOT {
-		progsdat.Globals.Self = int32(e1)
-		progsdat.Globals.Other = int32(e2)
+		s.progsdat.Globals.Self = int32(e1)
+		s.progsdat.Globals.Other = int32(e2)
 		if err := s.vm.ExecuteProgram(ent1.Touch, s); err != nil {
 			return err
 		}
 	}
 
 	if ent2.Touch != 0 && ent2.Solid != SOLID_NOT {
-		progsdat.Globals.Self = int32(e2)
-		progsdat.Globals.Other = int32(e1)
+		s.progsdat.Globals.Self = int32(e2)
+		s.progsdat.Globals.Other = int32(e1)
 		if err := s.vm.ExecuteProgram(ent2.Touch, s); err != nil {
 			return err
 		}
 	}
 
-	progsdat.Globals.Self = oldSelf
-	progsdat.Globals.Other = oldOther
+	s.progsdat.Globals.Self = oldSelf
+	s.progsdat.Globals.Other = oldOther
 	return nil
 }
 
-func CheckVelocity(ent *progs.EntVars) {
+func (s *Server) CheckVelocity(ent *progs.EntVars) {
 	maxVelocity := cvars.ServerMaxVelocity.Value()
 	for i := 0; i < 3; i++ {
 		if ent.Velocity[i] != ent.Velocity[i] {
-			s, _ := progsdat.String(ent.ClassName)
-			slog.Warn("Got a NaN velocity", slog.String("class", s))
+			cn, _ := s.progsdat.String(ent.ClassName)
+			slog.Warn("Got a NaN velocity", slog.String("class", cn))
 			ent.Velocity[i] = 0
 		}
 		if ent.Origin[i] != ent.Origin[i] {
-			s, _ := progsdat.String(ent.ClassName)
-			slog.Warn("Got a NaN origin", slog.String("class", s))
+			cn, _ := s.progsdat.String(ent.ClassName)
+			slog.Warn("Got a NaN origin", slog.String("class", cn))
 			ent.Origin[i] = 0
 		}
 		if ent.Velocity[i] > maxVelocity {
@@ -529,12 +582,12 @@ func CheckVelocity(ent *progs.EntVars) {
 
 func (s *Server) CreateBaseline() {
 	for entnum := 0; entnum < s.numEdicts; entnum++ {
-		e := &s.edicts[entnum]
+		e := s.edicts[entnum]
 		if e.Free {
 			continue
 		}
-		sev := entvars.Get(entnum)
-		if entnum > svs.maxClients && sev.ModelIndex == 0 {
+		sev := s.entvars.Get(entnum)
+		if entnum > s.svs.maxClients && sev.ModelIndex == 0 {
 			continue
 		}
 
@@ -543,78 +596,50 @@ func (s *Server) CreateBaseline() {
 
 		e.Baseline.Frame = uint16(sev.Frame)
 		e.Baseline.Skin = byte(sev.Skin)
-		if entnum > 0 && entnum <= svs.maxClients {
+		if entnum > 0 && entnum <= s.svs.maxClients {
 			e.Baseline.ColorMap = byte(entnum)
-			e.Baseline.ModelIndex = uint16(s.ModelIndex("progs/player.mdl"))
+			e.Baseline.ModelIndex = s.ModelIndex("progs/player.mdl")
 			e.Baseline.Alpha = svc.EntityAlphaDefault
 		} else {
 			e.Baseline.ColorMap = 0
-			str, err := progsdat.String(sev.Model)
+			str, err := s.progsdat.String(sev.Model)
 			if err != nil {
 				log.Printf("Error in CreateBaseline: %v", err)
 			}
-			e.Baseline.ModelIndex = uint16(s.ModelIndex(str))
+			e.Baseline.ModelIndex = s.ModelIndex(str)
 			e.Baseline.Alpha = e.Alpha
 		}
 
-		bits := 0
-		mi := int(e.Baseline.ModelIndex)
-		frame := int(e.Baseline.Frame)
 		if s.protocol == protocol.NetQuake {
-			if mi&0xFF00 != 0 {
-				mi = 0
+			if e.Baseline.ModelIndex&^0xFF != 0 {
 				e.Baseline.ModelIndex = 0
 			}
-			if frame&0xFF00 != 0 {
-				frame = 0
+			if e.Baseline.Frame&0xFF00 != 0 {
 				e.Baseline.Frame = 0
 			}
 			e.Baseline.Alpha = svc.EntityAlphaDefault
-		} else {
-			if mi&0xFF00 != 0 {
-				bits |= svc.EntityBaselineLargeModel
-			}
-			if frame&0xFF00 != 0 {
-				bits |= svc.EntityBaselineLargeFrame
-			}
-			if e.Alpha != svc.EntityAlphaDefault {
-				bits |= svc.EntityBaselineAlpha
-			}
-		}
-
-		if bits != 0 {
-			s.signon.WriteByte(svc.SpawnBaseline2)
-		} else {
-			s.signon.WriteByte(svc.SpawnBaseline)
-		}
-
-		s.signon.WriteShort(entnum)
-		if bits != 0 {
-			s.signon.WriteByte(bits)
 		}
 
-		if bits&svc.EntityBaselineLargeModel != 0 {
-			s.signon.WriteShort(mi)
-		} else {
-			s.signon.WriteByte(mi)
-		}
-
-		if bits&svc.EntityBaselineLargeFrame != 0 {
-			s.signon.WriteShort(frame)
-		} else {
-			s.signon.WriteByte(frame)
-		}
-
-		s.signon.WriteByte(int(e.Baseline.ColorMap))
-		s.signon.WriteByte(int(e.Baseline.Skin))
-		for i := 0; i < 3; i++ {
-			s.signon.WriteCoord(float32(e.Baseline.Origin[i]), s.protocolFlags)
-			s.signon.WriteAngle(float32(e.Baseline.Angles[i]), s.protocolFlags)
-		}
-
-		if bits&svc.EntityBaselineAlpha != 0 {
-			s.signon.WriteByte(int(e.Alpha))
-		}
+		svc.WriteSpawnBaseline(protos.EntityBaseline_builder{
+			Index: int32(entnum),
+			Baseline: protos.Baseline_builder{
+				ModelIndex: int32(e.Baseline.ModelIndex),
+				Frame:      int32(e.Baseline.Frame),
+				ColorMap:   int32(e.Baseline.ColorMap),
+				Skin:       int32(e.Baseline.Skin),
+				Origin: protos.Coord_builder{
+					X: e.Baseline.Origin[0],
+					Y: e.Baseline.Origin[1],
+					Z: e.Baseline.Origin[2],
+				}.Build(),
+				Angles: protos.Coord_builder{
+					X: e.Baseline.Angles[0],
+					Y: e.Baseline.Angles[1],
+					Z: e.Baseline.Angles[2],
+				}.Build(),
+				Alpha: int32(e.Baseline.Alpha),
+			}.Build(),
+		}.Build(), s.protocol, s.protocolFlags, &s.signon)
 	}
 }
 
@@ -622,65 +647,88 @@ func (s *Server) SendClientMessages() error {
 	// update frags, names, etc
 	s.UpdateToReliableMessages()
 
-	// build individual updates
-	for _, c := range sv_clients {
-		if !c.active {
-			continue
-		}
-
-		if c.spawned {
-			if s, err := s.SendClientDatagram(c); err != nil {
+	// build individual updates. Spectators go last as writing the client data
+	// resets the damage and fixangle which the followed player needs to get.
+	for _, c := range s.clients {
+		if !c.spectator {
+			if err := s.sendClientMessage(c); err != nil {
 				return err
-			} else if !s {
-				continue
-			}
-		} else {
-			// the player isn't totally in the game yet
-			// send small keepalive messages if too much time has passed
-			// send a full message when the next signon stage has been requested
-			// some other message data (name changes, etc) may accumulate
-			// between signon stages
-			if !c.sendSignon {
-				if s.gametime.Time()-c.lastMessage > 5 {
-					if err := s.SendNop(c); err != nil {
-						return err
-					}
-				}
-				// don't send out non-signon messages
-				continue
 			}
 		}
-
-		// check for an overflowed message.  Should only happen
-		// on a very fucked up connection that backs up a lot, then
-		// changes level
-		if false { // GetClientOverflowed(i) {
-			if err := s.Drop(c, true); err != nil {
+	}
+	for _, c := range s.clients {
+		if c.spectator {
+			if err := s.sendClientMessage(c); err != nil {
 				return err
 			}
-			// SetClientOverflowed(i, false)
-			continue
 		}
+	}
 
-		if c.msg.HasMessage() {
-			if !c.CanSendMessage() {
-				continue
-			}
+	// clear muzzle flashes
+	s.CleanupEntvarEffects()
+	return nil
+}
+
+func (s *Server) sendClientMessage(c *SVClient) error {
+	if !c.active {
+		return nil
+	}
 
-			if c.SendMessage() == -1 {
-				// if the message couldn't send, kick off
-				if err := s.Drop(c, true); err != nil {
+	if c.bot != nil {
+		// nobody is listening
+		c.msg.ClearMessage()
+		return nil
+	}
+
+	if c.spawned {
+		if s, err := s.SendClientDatagram(c); err != nil {
+			return err
+		} else if !s {
+			return nil
+		}
+	} else {
+		// the player isn't totally in the game yet
+		// send small keepalive messages if too much time has passed
+		// send a full message when the next signon stage has been requested
+		// some other message data (name changes, etc) may accumulate
+		// between signon stages
+		if !c.sendSignon {
+			if s.gametime.Time()-c.lastMessage > 5 {
+				if err := s.SendNop(c); err != nil {
 					return err
 				}
 			}
-			c.msg.ClearMessage()
-			c.lastMessage = s.gametime.Time()
-			c.sendSignon = false
+			// don't send out non-signon messages
+			return nil
 		}
 	}
 
-	// clear muzzle flashes
-	s.CleanupEntvarEffects()
+	// check for an overflowed message.  Should only happen
+	// on a very fucked up connection that backs up a lot, then
+	// changes level
+	if false { // GetClientOverflowed(i) {
+		if err := s.Drop(c, true); err != nil {
+			return err
+		}
+		// SetClientOverflowed(i, false)
+		return nil
+	}
+
+	if c.msg.HasMessage() {
+		if !c.CanSendMessage() {
+			return nil
+		}
+
+		if c.SendMessage() == -1 {
+			// if the message couldn't send, kick off
+			if err := s.Drop(c, true); err != nil {
+				return err
+			}
+		}
+		c.msg.ClearMessage()
+		c.lastMessage = s.gametime.Time()
+		c.sendSignon = false
+	}
 	return nil
 }
 
@@ -689,7 +737,7 @@ func (s *Server) SendClientMessages() error {
 // in a frame.  Not used for pushmove objects, because they must be exact.
 // Returns false if the entity removed itself.
 func (s *Server) runThink(e int) (bool, error) {
-	thinktime := entvars.Get(e).NextThink
+	thinktime := s.entvars.Get(e).NextThink
 	if thinktime <= 0 || thinktime > s.time+float32(s.gametime.FrameTime()) {
 		return true, nil
 	}
@@ -701,20 +749,20 @@ func (s *Server) runThink(e int) (bool, error) {
 		thinktime = s.time
 	}
 
-	oldframe := entvars.Get(e).Frame
+	oldframe := s.entvars.Get(e).Frame
 
-	ev := entvars.Get(e)
+	ev := s.entvars.Get(e)
 	ev.NextThink = 0
-	progsdat.Globals.Time = thinktime
-	progsdat.Globals.Self = int32(e)
-	progsdat.Globals.Other = 0
+	s.progsdat.Globals.Time = thinktime
+	s.progsdat.Globals.Self = int32(e)
+	s.progsdat.Globals.Other = 0
 	if err := s.vm.ExecuteProgram(ev.Think, s); err != nil {
 		return false, err
 	}
 
 	// capture interval to nextthink here and send it to client for better
 	// lerp timing, but only if interval is not 0.1 (which client assumes)
-	ed := &s.edicts[e]
+	ed := s.edicts[e]
 	ed.SendInterval = false
 	if !ed.Free && ev.NextThink != 0 &&
 		(ev.MoveType == progs.MoveTypeStep || ev.Frame != oldframe) {
@@ -730,7 +778,7 @@ func (s *Server) runThink(e int) (bool, error) {
 
 // Does not change the entities velocity at all
 func (s *Server) pushEntity(e int, push vec.Vec3) (bsp.Trace, error) {
-	ev := entvars.Get(e)
+	ev := s.entvars.Get(e)
 	origin := ev.Origin
 	mins := ev.Mins
 	maxs := ev.Maxs
@@ -765,7 +813,7 @@ func (s *Server) pushEntity(e int, push vec.Vec3) (bsp.Trace, error) {
 func (s *Server) setIdealPitch(player int) {
 	const MAX_FORWARD = 6
 	z := [MAX_FORWARD]float32{}
-	ev := entvars.Get(player)
+	ev := s.entvars.Get(player)
 	if int(ev.Flags)&FL_ONGROUND == 0 {
 		return
 	}
@@ -830,26 +878,28 @@ const (
 	MAX_ENT_LEAFS = 32
 )
 
-func (s *Server) WriteEntitiesToClient(clent int) {
+func (s *Server) WriteEntitiesToClient(sc *SVClient) {
 	// TODO: this looks like the worst case for any branch prediction
 	// probably worth to get a better implementation
 
-	cev := entvars.Get(clent)
+	clent := sc.viewEdict()
+	var states []svc.EntityState
+	cev := s.entvars.Get(clent)
 	org := vec.Add(cev.Origin, cev.ViewOfs)
 	// find the client's PVS
 	pvs := s.worldModel.FatPVS(org)
 
 	// send over all entities (except the client) that touch the pvs
 	for ent := 1; ent < s.numEdicts; ent++ {
-		ev := entvars.Get(ent)
-		edict := &s.edicts[ent]
+		ev := s.entvars.Get(ent)
+		edict := s.edicts[ent]
 
 		// check if we need to send this edict
 		if ent != clent {
 			// clent is ALLWAYS sent
 
 			// ignore ents without visible models
-			mn, err := progsdat.String(ev.Model)
+			mn, err := s.progsdat.String(ev.Model)
 			if ev.ModelIndex == 0 || err != nil || len(mn) == 0 {
 				continue
 			}
@@ -888,9 +938,14 @@ func (s *Server) WriteEntitiesToClient(clent int) {
 			continue
 		}
 
+		if s.protocol == protocol.GoQuake {
+			states = append(states, s.entityState(ent))
+			continue
+		}
+
 		// max size for protocol 15 is 18 bytes.
 		// for protocol 85 the max size is 24 bytes.
-		if msgBuf.Len()+24 > msgBufMaxLen {
+		if s.msgBuf.Len()+24 > s.msgBufMaxLen {
 			slog.Warn("Packet overflow!")
 		}
 
@@ -940,7 +995,53 @@ func (s *Server) WriteEntitiesToClient(clent int) {
 		if edict.SendInterval {
 			eu.SetLerpFinish(int32(math.Round((ev.NextThink - s.time) * 255)))
 		}
-		svc.WriteEntityUpdate(eu, s.protocol, s.protocolFlags, &msgBuf)
+		svc.WriteEntityUpdate(eu, s.protocol, s.protocolFlags, &s.msgBuf)
+	}
+
+	if s.protocol == protocol.GoQuake {
+		s.writePacketEntities(sc, states)
+	}
+}
+
+func (s *Server) entityState(ent int) svc.EntityState {
+	ev := s.entvars.Get(ent)
+	edict := s.edicts[ent]
+	es := svc.EntityState{
+		Number:     ent,
+		ModelIndex: int(ev.ModelIndex),
+		Frame:      int(ev.Frame),
+		ColorMap:   int(ev.ColorMap),
+		Skin:       int(ev.Skin),
+		Effects:    int(ev.Effects),
+		Origin:     ev.Origin,
+		Angles:     ev.Angles,
+		Alpha:      int(edict.Alpha),
+		MoveStep:   ev.MoveType == progs.MoveTypeStep,
+		Interval:   edict.SendInterval,
+	}
+	if edict.SendInterval {
+		es.LerpFinish = int(math.Round((ev.NextThink - s.time) * 255))
+	}
+	return es
+}
+
+// writePacketEntities sends the visible entities as delta against the last
+// frame the client has acknowledged.
+func (s *Server) writePacketEntities(sc *SVClient, states []svc.EntityState) {
+	sc.frameNum++
+	f := &sc.frames[sc.frameNum%svc.UpdateBackup]
+	f.Number = sc.frameNum
+	f.Entities = states
+
+	var from *svc.EntityFrame
+	if ack := sc.frameAck; ack != 0 && ack < sc.frameNum && sc.frameNum-ack < svc.UpdateBackup {
+		if old := &sc.frames[ack%svc.UpdateBackup]; old.Number == ack {
+			from = old
+		}
+	}
+	svc.WritePacketEntities(f, from, s.protocolFlags, &s.msgBuf)
+	if s.msgBuf.Len() > s.msgBufMaxLen {
+		slog.Warn("Packet overflow!")
 	}
 }
 
@@ -958,23 +1059,24 @@ func init() {
 // Grabs the current state of each client for saving across the
 // transition to another level
 func (s *Server) saveSpawnparms() error {
-	svs.serverFlags = progsdat.Globals.ServerFlags
+	s.svs.serverFlags = s.progsdat.Globals.ServerFlags
 
-	for _, c := range sv_clients {
+	for _, c := range s.clients {
 		if !c.active {
 			continue
 		}
 		// call the progs to get default spawn parms for the new client
-		progsdat.Globals.Self = int32(c.edictId)
-		if err := s.vm.ExecuteProgram(progsdat.Globals.SetChangeParms, s); err != nil {
+		s.progsdat.Globals.Self = int32(c.edictId)
+		if err := s.vm.ExecuteProgram(s.progsdat.Globals.SetChangeParms, s); err != nil {
 			return err
 		}
-		c.spawnParams = progsdat.Globals.Parm
+		c.spawnParams = s.progsdat.Globals.Parm
 	}
 	return nil
 }
 
 func (s *Server) ChangeLevel(mapName string, pcl int) error {
+	s.match.endMap(s.clients)
 	if err := s.saveSpawnparms(); err != nil {
 		return err
 	}
@@ -998,7 +1100,7 @@ func (s *Server) SpawnSaveGameServer(data *protos.SaveGame, pcl int) error {
 		return err
 	}
 	s.time = data.GetMapTime()
-	copy(sv_clients[0].spawnParams[:], data.GetSpawnParams())
+	copy(s.clients[0].spawnParams[:], data.GetSpawnParams())
 	return nil
 }
 
@@ -1024,6 +1126,7 @@ func (s *Server) reset() {
 	s.datagram.Reset()
 	s.reliableDatagram.Reset()
 	s.signon.Reset()
+	s.csqcEvent.Reset()
 	s.numEdicts = 0
 	s.maxEdicts = 0
 	s.edicts = s.edicts[:0]
@@ -1039,6 +1142,8 @@ func (s *Server) reset() {
 	s.models = s.models[:0]
 	s.models = append(s.models, nil)
 	s.worldModel = nil
+	s.csqcCRC = 0
+	s.csqcSize = 0
 }
 
 // This is called at the start of each level
@@ -1062,6 +1167,7 @@ func (s *Server) SpawnServer(mapName string, pcl int) error {
 	s.reset()
 	s.name = mapName
 	s.protocol = pcl
+	s.match.start()
 
 	if s.protocol == protocol.RMQ {
 		s.protocolFlags = protocol.PRFL_INT32COORD | protocol.PRFL_SHORTANGLE
@@ -1073,26 +1179,32 @@ func (s *Server) SpawnServer(mapName string, pcl int) error {
 	if err != nil {
 		log.Fatalf("Failed to load progs.dat: %v", err)
 	}
-	progsdat = p
-	s.vm.prog = p
+	s.progsdat = p
+	s.vm.Prog = p
+
+	// csprogs.dat is optional, clients only run it if their copy matches
+	if b, err := filesystem.ReadFile(progs.CSProgsName); err == nil {
+		s.csqcCRC = crc.Update(b)
+		s.csqcSize = len(b)
+	}
 
 	// allocate server memory
 	s.maxEdicts = int(cvars.MaxEdicts.Value())
 	s.allocEdicts()
 
 	// leave slots at start for clients only
-	s.numEdicts = svs.maxClients + 1
+	s.numEdicts = s.svs.maxClients + 1
 	for i := 0; i < s.numEdicts; i++ {
 		s.clearEdict(i)
 	}
-	for i := 0; i < svs.maxClients; i++ {
-		sv_clients[i].edictId = i + 1
+	for i := 0; i < s.svs.maxClients; i++ {
+		s.clients[i].edictId = i + 1
 	}
 
 	modelName := fmt.Sprintf("maps/%s.bsp", mapName)
 
 	log.Printf("New world: %s", modelName)
-	sv_models = make(map[string]model.Model)
+	s.modelCache = make(map[string]model.Model)
 	mods, err := bsp.Load(modelName)
 	if err != nil || len(mods) < 1 {
 		slog.Warn("Couldn't spawn server", slog.String("modelname", modelName))
@@ -1105,27 +1217,28 @@ func (s *Server) SpawnServer(mapName string, pcl int) error {
 	}
 
 	s.clearWorld()
+	s.lag.clear()
 
 	// load the rest of the entities
-	entvars.Clear(0)
+	s.entvars.Clear(0)
 	s.edicts[0].Free = false
-	ev := entvars.Get(0)
-	ev.Model = progsdat.AddString(modelName)
+	ev := s.entvars.Get(0)
+	ev.Model = s.progsdat.AddString(modelName)
 	ev.ModelIndex = 1 // world model
 	ev.Solid = SOLID_BSP
 	ev.MoveType = progs.MoveTypePush
 
 	if cvars.Coop.Bool() {
-		progsdat.Globals.Coop = 1
+		s.progsdat.Globals.Coop = 1
 	} else {
-		progsdat.Globals.DeathMatch = cvars.DeathMatch.Value()
+		s.progsdat.Globals.DeathMatch = cvars.DeathMatch.Value()
 	}
-	progsdat.Globals.MapName = progsdat.AddString(mapName)
+	s.progsdat.Globals.MapName = s.progsdat.AddString(mapName)
 
 	// serverflags are for cross level information (sigils)
-	progsdat.Globals.ServerFlags = svs.serverFlags
+	s.progsdat.Globals.ServerFlags = s.svs.serverFlags
 
-	if err := s.loadEntities(s.worldModel.Entities, mapName); err != nil {
+	if err := s.loadEntities(mapEntities(s.worldModel, mapName), mapName); err != nil {
 		return err
 	}
 
@@ -1156,10 +1269,18 @@ func (s *Server) SpawnServer(mapName string, pcl int) error {
 	}
 
 	// send serverinfo to all connected clients
-	for i := 0; i < svs.maxClients; i++ {
-		if sv_clients[i].active {
-			s.SendServerinfo(sv_clients[i])
+	for i := 0; i < s.svs.maxClients; i++ {
+		c := s.clients[i]
+		if !c.active {
+			continue
 		}
+		if c.bot != nil {
+			if err := s.spawnBot(c); err != nil {
+				return err
+			}
+			continue
+		}
+		s.SendServerinfo(c)
 	}
 
 	slog.Debug("Server spawned.")
@@ -1179,7 +1300,7 @@ func (s *Server) Shutdown() error {
 	count := 1
 	for count != 0 {
 		count = 0
-		for _, c := range sv_clients {
+		for _, c := range s.clients {
 			if c.active && c.msg.HasMessage() {
 				if c.CanSendMessage() {
 					c.SendMessage()
@@ -1198,9 +1319,11 @@ func (s *Server) Shutdown() error {
 	}
 
 	// make sure all the clients know we're disconnecting
-	SendToAll([]byte{svc.Disconnect})
+	var m svc.Message
+	svc.WriteDisconnect(&m)
+	s.SendToAll(&m)
 
-	for _, c := range sv_clients {
+	for _, c := range s.clients {
 		if c.active {
 			if err := s.Drop(c, false); err != nil {
 				return nil
@@ -1210,7 +1333,7 @@ func (s *Server) Shutdown() error {
 
 	s.worldModel = nil
 
-	CreateSVClients()
+	s.createClients()
 	return nil
 }
 
@@ -1221,7 +1344,7 @@ func (s *Server) saveGameEdicts() []*protos.Edict {
 			eds = append(eds, &protos.Edict{})
 			continue
 		}
-		e := s.vm.saveGameEntVars(i)
+		e := s.vm.saveGameEntVars(i, s)
 
 		if /*!pr_alpha_supported &&*/ s.edicts[i].Alpha != 0 {
 			wa := s.edicts[i].Alpha
@@ -1238,9 +1361,12 @@ func (s *Server) saveGameEdicts() []*protos.Edict {
 }
 
 func (s *Server) loadGameEdicts(es []*protos.Edict) error {
+	if err := s.growEdicts(len(es)); err != nil {
+		return err
+	}
 	for i, e := range es {
 		if proto.Equal(e, &protos.Edict{}) {
-			s.edicts[i] = Edict{
+			*s.edicts[i] = Edict{
 				Free: true,
 			}
 			continue
@@ -1252,11 +1378,11 @@ func (s *Server) loadGameEdicts(es []*protos.Edict) error {
 			ta = math.Clamp(1, ta, 255)
 			a = byte(ta)
 		}
-		s.edicts[i] = Edict{
+		*s.edicts[i] = Edict{
 			Alpha: a,
 		}
 
-		s.vm.loadGameEntVars(i, e)
+		s.vm.loadGameEntVars(i, e, s)
 		if err := s.vm.LinkEdict(i, false, s); err != nil {
 			return err
 		}
@@ -0,0 +1,221 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"log"
+	"strings"
+
+	"goquake/cbuf"
+	"goquake/cvars"
+	"goquake/math/vec"
+	"goquake/progs"
+	svc "goquake/protocol/server"
+	"goquake/protos"
+)
+
+// playerCommands are the client commands which change the game and are
+// therefore not available to spectators.
+var playerCommands = map[string]bool{
+	"callvote": true,
+	"fly":      true,
+	"give":     true,
+	"god":      true,
+	"kick":     true,
+	"kill":     true,
+	"noclip":   true,
+	"notarget": true,
+	"notready": true,
+	"pause":    true,
+	"ready":    true,
+	"save":     true,
+	"setpos":   true,
+	"vote":     true,
+}
+
+func (s *Server) spectatorCount() int {
+	n := 0
+	for _, c := range s.clients {
+		if c.active && c.spectator {
+			n++
+		}
+	}
+	return n
+}
+
+// spectatorCmd switches the client between player and spectator. It can only
+// be used before the client spawned. Returns false if the client should be
+// dropped.
+func (s *Server) spectatorCmd(sc *SVClient, a cbuf.Arguments) bool {
+	args := a.Args()
+	if len(args) < 2 {
+		v := int32(0)
+		if sc.spectator {
+			v = 1
+		}
+		sc.Printf("spectator %v\n", qFormatI(v))
+		return true
+	}
+	if sc.spawned {
+		sc.Printf("Can't change spectator mode while in the game\n")
+		return true
+	}
+	want := args[1].Bool()
+	if want && !sc.spectator && s.spectatorCount() >= int(cvars.ServerMaxSpectators.Value()) {
+		sc.Printf("Server spectator limit is full.\n")
+		return false
+	}
+	sc.spectator = want
+	return true
+}
+
+// spawnSpectator places the spectator at the intermission spot or at a player
+// start. Its edict stays free so that neither QuakeC nor the physics see it.
+func (s *Server) spawnSpectator(sc *SVClient) {
+	s.entvars.Clear(sc.edictId)
+	s.edicts[sc.edictId].Free = true
+	ev := s.entvars.Get(sc.edictId)
+	ev.NetName = s.progsdat.AddString(sc.name)
+	ev.MoveType = progs.MoveTypeNoClip
+	ev.Health = 100
+	ev.ViewOfs = vec.Vec3{0, 0, svc.DEFAULT_VIEWHEIGHT}
+	for _, cn := range []string{"info_intermission", "info_player_start", "info_player_deathmatch"} {
+		if e, ok := s.findClass(cn); ok {
+			spot := s.entvars.Get(e)
+			ev.Origin = spot.Origin
+			ev.Angles = spot.Angles
+			if cn == "info_intermission" {
+				// intermission spots store the view angle in mangle
+				if d, err := s.progsdat.FindFieldDef("mangle"); err == nil {
+					if m, err := s.entvars.LoadVector(int32(e), int32(d.Offset)); err == nil {
+						ev.Angles = m
+					}
+				}
+			}
+			break
+		}
+	}
+	sc.follow = 0
+	log.Printf("%v entered as spectator\n", sc.name)
+}
+
+// findClass returns the first entity of the given classname.
+func (s *Server) findClass(name string) (int, bool) {
+	for e := 1; e < s.numEdicts; e++ {
+		if s.edicts[e].Free {
+			continue
+		}
+		if cn, err := s.progsdat.String(s.entvars.Get(e).ClassName); err == nil && cn == name {
+			return e, true
+		}
+	}
+	return 0, false
+}
+
+// canFollow returns true if ent is a player in the game.
+func (s *Server) canFollow(ent int) bool {
+	if ent < 1 || ent > s.svs.maxClients || ent > len(s.clients) {
+		return false
+	}
+	c := s.clients[ent-1]
+	return c.active && c.spawned && !c.spectator
+}
+
+// nextFollow returns the first player after ent, 0 if there is none.
+func (s *Server) nextFollow(ent int) int {
+	for i := 1; i <= s.svs.maxClients; i++ {
+		n := (ent+i-1)%s.svs.maxClients + 1
+		if s.canFollow(n) {
+			return n
+		}
+	}
+	return 0
+}
+
+// followCmd lets a spectator chase a player. Without an argument it cycles
+// through the players, "follow off" returns to free flight.
+func (s *Server) followCmd(sc *SVClient, a cbuf.Arguments) {
+	if !sc.spectator {
+		sc.Printf("Only spectators can follow players\n")
+		return
+	}
+	args := a.Args()
+	target := s.nextFollow(sc.follow)
+	if len(args) > 1 {
+		switch arg := args[1].String(); {
+		case strings.EqualFold(arg, "off"):
+			target = 0
+		default:
+			target = 0
+			for i, c := range s.clients {
+				if c.name == arg {
+					target = i + 1
+				}
+			}
+			if target == 0 {
+				target = args[1].Int()
+			}
+			if !s.canFollow(target) {
+				sc.Printf("Can't follow %s\n", arg)
+				return
+			}
+		}
+	}
+	s.setFollow(sc, target)
+}
+
+// setFollow switches the view of the spectator to the player edict ent or
+// back to its own edict if ent is 0.
+func (s *Server) setFollow(sc *SVClient, ent int) {
+	if sc.follow == ent {
+		return
+	}
+	if ent == 0 && sc.follow != 0 {
+		// continue the free flight where the view was
+		ev := s.entvars.Get(sc.edictId)
+		tev := s.entvars.Get(sc.follow)
+		ev.Origin = tev.Origin
+		ev.Angles = tev.VAngle
+		ev.FixAngle = 1
+	}
+	sc.follow = ent
+	svc.WriteSetView(sc.viewEdict(), &sc.msg)
+	if ent != 0 {
+		sc.Printf("Following %s\n", s.clients[ent-1].name)
+	} else {
+		sc.Printf("Free flight\n")
+	}
+}
+
+// viewEdict returns the edict the client is looking through.
+func (sc *SVClient) viewEdict() int {
+	if sc.spectator && sc.follow != 0 {
+		return sc.follow
+	}
+	return sc.edictId
+}
+
+// spectatorThink flies the spectator or checks the followed player is still
+// around.
+func (sc *SVClient) spectatorThink(s *Server) {
+	if sc.follow != 0 {
+		if !s.canFollow(sc.follow) {
+			s.setFollow(sc, s.nextFollow(sc.follow))
+		}
+		return
+	}
+	ev := s.entvars.Get(sc.edictId)
+	sc.Think(ev, s.time, s)
+	ev.Origin = vec.Add(ev.Origin, vec.Scale(float32(s.gametime.FrameTime()), ev.Velocity))
+}
+
+// writeSpectatorView makes the spectator look in the same direction as the
+// followed player.
+func (s *Server) writeSpectatorView(sc *SVClient) {
+	if sc.follow == 0 {
+		return
+	}
+	v := s.entvars.Get(sc.follow).VAngle
+	a := protos.Coord_builder{X: v[0], Y: v[1], Z: v[2]}.Build()
+	svc.WriteSetAngle(a, s.protocol, s.protocolFlags, &s.msgBuf)
+}
@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"testing"
+)
+
+func TestNextFollow(t *testing.T) {
+	s := NewServer(nil)
+	s.clients = []*SVClient{
+		{active: true, spawned: true, edictId: 1, spectator: true},
+		{active: true, spawned: true, edictId: 2},
+		{active: false, edictId: 3},
+		{active: true, spawned: true, edictId: 4},
+	}
+	s.svs.maxClients = len(s.clients)
+
+	tests := []struct {
+		from, want int
+	}{
+		{0, 2},
+		{2, 4},
+		{4, 2}, // wraps and skips the spectator
+	}
+	for _, tc := range tests {
+		if got := s.nextFollow(tc.from); got != tc.want {
+			t.Errorf("nextFollow(%d) = %d, want %d", tc.from, got, tc.want)
+		}
+	}
+
+	sc := s.clients[0]
+	if v := sc.viewEdict(); v != 1 {
+		t.Errorf("viewEdict = %d in free flight, want 1", v)
+	}
+	sc.follow = 4
+	if v := sc.viewEdict(); v != 4 {
+		t.Errorf("viewEdict = %d while following, want 4", v)
+	}
+
+	s.clients[1].spectator = true
+	s.clients[3].spawned = false
+	if got := s.nextFollow(0); got != 0 {
+		t.Errorf("nextFollow without players = %d, want 0", got)
+	}
+}
@@ -3,7 +3,6 @@
 package server
 
 import (
-	"bytes"
 	"fmt"
 	"log"
 	"log/slog"
@@ -44,7 +43,7 @@ type SVClient struct {
 
 	// can be added to at any time, copied and clear once per frame
 	//  had max length of 64000
-	msg net.Message
+	msg svc.Message
 
 	// reliable messages must be sent periodically
 	lastMessage float64
@@ -63,24 +62,38 @@ type SVClient struct {
 	// spawn params are carried from level to level
 	spawnParams [16]float32
 
-	cmd movecmd // movement
+	cmd          movecmd // movement
+	moveSequence uint32  // last applied move, acked for prediction
+	viewTime     float32 // server time of the last message the client got
+
+	// entity frames for delta compression, GoQuake protocol only
+	frames   [svc.UpdateBackup]svc.EntityFrame
+	frameNum uint32 // last sent frame
+	frameAck uint32 // last frame the client got, 0 if none
+
+	// send ProtoMessage instead of byte encoded messages, GoQuake protocol only
+	protobuf bool
 
 	active     bool // false = client is free
 	spawned    bool // false = don't send datagrams
 	sendSignon bool // only valid before spawned
 	admin      bool
 
+	spectator bool // connected without a player edict
+	follow    int  // edict of the player a spectator follows, 0 for free flight
+	ready     bool // wants the match to start, warmup only
+
+	moveCheck moveCheck
+
+	bot *Bot // nil for network clients
+
 	badRead bool
 }
 
-var (
-	sv_clients []*SVClient
-)
-
-func CreateSVClients() {
-	sv_clients = make([]*SVClient, svs.maxClientsLimit)
-	for i := range sv_clients {
-		sv_clients[i] = &SVClient{
+func (s *Server) createClients() {
+	s.clients = make([]*SVClient, s.svs.maxClientsLimit)
+	for i := range s.clients {
+		s.clients[i] = &SVClient{
 			id: i,
 		}
 	}
@@ -91,17 +104,17 @@ func (s *Server) BroadcastPrintf(format string, v ...interface{}) {
 }
 
 func (s *Server) BroadcastPrint(m string) {
-	for _, c := range sv_clients {
+	for _, c := range s.clients {
 		if c.active && c.spawned {
 			c.Printf(m)
 		}
 	}
 }
 
-// TODO: HostClient/host_client should get removed. It is only used in hostcmd.go
-// and the playerEdictId should be sufficient.
-func HostClient() *SVClient {
-	return sv_clients[host_client]
+// TODO: hostClient should get removed and the playerEdictId should be
+// sufficient.
+func (s *Server) HostClient() *SVClient {
+	return s.clients[s.hostClient]
 }
 
 func (sc *SVClient) Printf(format string, v ...interface{}) {
@@ -109,8 +122,7 @@ func (sc *SVClient) Printf(format string, v ...interface{}) {
 }
 
 func (sc *SVClient) print(msg string) {
-	sc.msg.WriteByte(svc.Print)
-	sc.msg.WriteString(msg)
+	svc.WritePrint(msg, &sc.msg)
 }
 
 func (sc *SVClient) PingTime() float32 {
@@ -123,12 +135,12 @@ func (sc *SVClient) PingTime() float32 {
 
 func (s *Server) checkForNewClients() error {
 	for {
-		con := net.CheckNewConnections()
+		con := s.listener.CheckNewConnections()
 		if con == nil {
 			return nil
 		}
 		foundFree := false
-		for _, c := range sv_clients {
+		for _, c := range s.clients {
 			if c.active {
 				continue
 			}
@@ -148,12 +160,18 @@ func (s *Server) checkForNewClients() error {
 }
 
 func (sc *SVClient) CanSendMessage() bool {
+	if sc.bot != nil {
+		return true
+	}
 	return sc.netConnection.CanSendMessage()
 }
 
 func (sc *SVClient) Close() {
-	sc.netConnection.Close()
+	if sc.netConnection != nil {
+		sc.netConnection.Close()
+	}
 	sc.netConnection = nil
+	sc.bot = nil
 	sc.admin = false
 	sc.active = false
 	sc.name = ""
@@ -161,19 +179,47 @@ func (sc *SVClient) Close() {
 }
 
 func (sc *SVClient) ConnectTime() time.Time {
+	if sc.bot != nil {
+		return sc.bot.connectTime
+	}
 	return sc.netConnection.ConnectTime()
 }
 
 func (sc *SVClient) Address() string {
+	if sc.bot != nil {
+		return "bot"
+	}
 	return sc.netConnection.Address()
 }
 
 func (sc *SVClient) SendMessage() int {
-	return sc.netConnection.SendMessage(sc.msg.Bytes())
+	if sc.bot != nil {
+		return 1
+	}
+	return sc.send(&sc.msg, true)
+}
+
+// send sends m and returns -1 on failure. Clients which asked for it get m as
+// ProtoMessage.
+func (sc *SVClient) send(m *svc.Message, reliable bool) int {
+	b := m.Bytes()
+	if sc.protobuf {
+		var err error
+		if b, err = m.Proto(); err != nil {
+			slog.Error("Could not encode message", slog.String("client", sc.name), slog.Any("err", err))
+			return -1
+		}
+	}
+	if reliable {
+		return sc.netConnection.SendMessage(b)
+	}
+	return sc.netConnection.SendUnreliableMessage(b)
 }
 
 func (s *Server) SendNop(sc *SVClient) error {
-	if sc.netConnection.SendUnreliableMessage([]byte{svc.Nop}) == -1 {
+	var m svc.Message
+	svc.WriteNop(&m)
+	if sc.send(&m, false) == -1 {
 		if err := s.Drop(sc, true); err != nil {
 			return err
 		}
@@ -186,19 +232,19 @@ func (s *Server) Drop(sc *SVClient, crash bool) error {
 	if !crash {
 		// send any final messages (don't check for errors)
 		if sc.CanSendMessage() {
-			sc.msg.WriteByte(svc.Disconnect)
+			svc.WriteDisconnect(&sc.msg)
 			sc.SendMessage()
 		}
 
-		if sc.spawned {
+		if sc.spawned && !sc.spectator {
 			// call the prog function for removing a client
 			// this will set the body to a dead frame, among other things
-			saveSelf := progsdat.Globals.Self
-			progsdat.Globals.Self = int32(sc.edictId)
-			if err := s.vm.ExecuteProgram(progsdat.Globals.ClientDisconnect, s); err != nil {
+			saveSelf := s.progsdat.Globals.Self
+			s.progsdat.Globals.Self = int32(sc.edictId)
+			if err := s.vm.ExecuteProgram(s.progsdat.Globals.ClientDisconnect, s); err != nil {
 				return err
 			}
-			progsdat.Globals.Self = saveSelf
+			s.progsdat.Globals.Self = saveSelf
 		}
 		log.Printf("Client %s removed", sc.name)
 	}
@@ -207,55 +253,52 @@ func (s *Server) Drop(sc *SVClient, crash bool) error {
 	sc.Close()
 
 	// send notification to all clients
-	for _, c := range sv_clients {
+	for _, c := range s.clients {
 		if !c.active {
 			continue
 		}
-		c.msg.WriteByte(svc.UpdateName)
-		c.msg.WriteByte(sc.id)
-		c.msg.WriteString("")
-		c.msg.WriteByte(svc.UpdateFrags)
-		c.msg.WriteByte(sc.id)
-		c.msg.WriteShort(0)
-		c.msg.WriteByte(svc.UpdateColors)
-		c.msg.WriteByte(sc.id)
-		c.msg.WriteByte(0)
+		svc.WriteUpdateName(protos.UpdateName_builder{
+			Player: int32(sc.id),
+		}.Build(), s.protocol, s.protocolFlags, &c.msg)
+		svc.WriteUpdateFrags(protos.UpdateFrags_builder{
+			Player: int32(sc.id),
+		}.Build(), s.protocol, s.protocolFlags, &c.msg)
+		svc.WriteUpdateColors(protos.UpdateColors_builder{
+			Player: int32(sc.id),
+		}.Build(), s.protocol, s.protocolFlags, &c.msg)
 	}
 	return nil
 }
 
-func SendReconnectToAll() {
-	s := "reconnect\n\x00"
-	m := make([]byte, 0, len(s)+1)
-	buf := bytes.NewBuffer(m)
-	buf.WriteByte(svc.StuffText)
-	buf.WriteString(s)
-	SendToAll(buf.Bytes())
+func (s *Server) SendReconnectToAll() {
+	var m svc.Message
+	svc.WriteStuffText("reconnect\n", &m)
+	s.SendToAll(&m)
 }
 
-func SendToAll(data []byte) {
+func (s *Server) SendToAll(m *svc.Message) {
 	// We try for 5 seconds to send the message to everyone
-	s := make([]bool, len(sv_clients))
+	sent := make([]bool, len(s.clients))
 	start := time.Now()
 TimeoutLoop:
 	for {
 		if time.Since(start) > 5*time.Second {
 			return
 		}
-		for i, c := range sv_clients {
-			if s[i] {
+		for i, c := range s.clients {
+			if sent[i] {
 				continue
 			}
-			if !c.active {
-				s[i] = true
+			if !c.active || c.bot != nil {
+				sent[i] = true
 				continue
 			}
 			if c.CanSendMessage() {
-				c.netConnection.SendMessage(data)
-				s[i] = true
+				c.send(m, true)
+				sent[i] = true
 			}
 		}
-		for _, c := range s {
+		for _, c := range sent {
 			if !c {
 				// There is no need to spin too fast, we are waiting for
 				// the last ACK of one of the clients.
@@ -273,58 +316,58 @@ func (sc *SVClient) GetMessage() error {
 }
 
 func (s *Server) SendServerinfo(sc *SVClient) {
+	// the client forgets all entities
+	sc.frames = [svc.UpdateBackup]svc.EntityFrame{}
+	sc.frameAck = 0
+	// the client asks again after parsing the server info
+	sc.protobuf = false
+	sc.moveCheck.reset()
+
 	m := &sc.msg
-	m.WriteByte(svc.Print)
-	m.WriteString(
+	svc.WritePrint(
 		fmt.Sprintf("%s\nGOQUAKE %1.2f SERVER (%d CRC)\n",
-			[]byte{2}, version.Base, progsdat.CRC))
-
-	m.WriteByte(int(svc.ServerInfo))
-	m.WriteLong(int(s.protocol))
+			[]byte{2}, version.Base, s.progsdat.CRC), m)
 
+	si := protos.ServerInfo_builder{
+		Protocol:   int32(s.protocol),
+		MaxClients: int32(s.svs.maxClients),
+		GameType:   svc.GameCoop,
+	}.Build()
 	if s.protocol == protocol.RMQ {
-		m.WriteLong(int(s.protocolFlags))
+		si.SetFlags(int32(s.protocolFlags))
 	}
-
-	sc.msg.WriteByte(svs.maxClients)
-
 	if !cvars.Coop.Bool() && cvars.DeathMatch.Bool() {
-		m.WriteByte(svc.GameDeathmatch)
-	} else {
-		m.WriteByte(svc.GameCoop)
+		si.SetGameType(svc.GameDeathmatch)
 	}
 
-	sm, err := progsdat.String(entvars.Get(0).Message)
+	sm, err := s.progsdat.String(s.entvars.Get(0).Message)
 	if err != nil {
 		sm = ""
 	}
-	m.WriteString(sm)
+	si.SetLevelName(sm)
 
-	for i, mn := range s.modelPrecache[1:] {
-		if s.protocol == protocol.NetQuake && i >= 256 {
-			break
-		}
-		m.WriteString(mn)
+	models := s.modelPrecache[1:]
+	sounds := s.soundPrecache[1:]
+	if s.protocol == protocol.NetQuake {
+		models = models[:min(len(models), 256)]
+		sounds = sounds[:min(len(sounds), 256)]
 	}
-	m.WriteByte(0)
+	si.SetModelPrecache(models)
+	si.SetSoundPrecache(sounds)
+	svc.WriteServerInfo(si, m)
 
-	for i, sn := range s.soundPrecache[1:] {
-		if s.protocol == protocol.NetQuake && i >= 256 {
-			break
-		}
-		m.WriteString(sn)
+	if s.csqcSize != 0 {
+		svc.WriteStuffText(fmt.Sprintf("csqc_progcrc %d\ncsqc_progsize %d\n", s.csqcCRC, s.csqcSize), m)
 	}
-	m.WriteByte(0)
 
-	m.WriteByte(svc.CDTrack)
-	m.WriteByte(int(entvars.Get(0).Sounds))
-	m.WriteByte(int(entvars.Get(0).Sounds))
+	svc.WriteCDTrack(protos.CDTrack_builder{
+		TrackNumber: int32(s.entvars.Get(0).Sounds),
+		LoopTrack:   int32(s.entvars.Get(0).Sounds),
+	}.Build(), m)
 
-	m.WriteByte(svc.SetView)
-	m.WriteShort(sc.edictId)
+	svc.WriteSetView(sc.edictId, m)
 
-	m.WriteByte(svc.SignonNum)
-	m.WriteByte(1)
+	svc.WriteSignonNum(1, m)
 
 	sc.sendSignon = true
 	sc.spawned = false
@@ -348,138 +391,179 @@ func (sc *SVClient) ReadClientMessage(s *Server) (bool, error) {
 			log.Printf("SV_ReadClientMessage: %v", err)
 			return false, nil
 		}
-		for _, cmd := range pb.GetCmds() {
-			if !sc.active {
-				// a command caused an error
-				return false, nil
+		if ok, err := sc.runClientMessage(s, pb); !ok || err != nil {
+			return ok, err
+		}
+	}
+}
+
+// Returns false if the client should be killed
+func (sc *SVClient) runClientMessage(s *Server, pb *protos.ClientMessage) (bool, error) {
+	for _, cmd := range pb.GetCmds() {
+		if !sc.active {
+			// a command caused an error
+			return false, nil
+		}
+		switch cmd.WhichUnion() {
+		default:
+			// nop
+		case protos.Cmd_Disconnect_case:
+			return false, nil
+		case protos.Cmd_Protobuf_case:
+			sc.protobuf = cmd.GetProtobuf() && s.protocol == protocol.GoQuake
+		case protos.Cmd_StringCmd_case:
+			if sc != s.HostClient() {
+				log.Fatalf("HostClient differs")
 			}
-			switch cmd.WhichUnion() {
+			scmd := cmd.GetStringCmd()
+			a := cbuf.Parse(scmd)
+			if len(a.Args()) == 0 {
+				continue
+			}
+			cmdName := strings.ToLower(a.Args()[0].String())
+			if sc.spectator && playerCommands[cmdName] {
+				sc.Printf("Spectators can't use %s\n", cmdName)
+				continue
+			}
+			switch cmdName {
 			default:
-				// nop
-			case protos.Cmd_Disconnect_case:
-				return false, nil
-			case protos.Cmd_StringCmd_case:
-				if sc != HostClient() {
-					log.Fatalf("HostClient differs")
+				slog.Warn("player tried something", slog.String("player", sc.name), slog.String("action", scmd))
+			case "begin":
+				sc.spawned = true
+			case "color":
+				color := s.colorCmd(sc, a)
+				svc.WriteUpdateColors(color, s.protocol, s.protocolFlags, &s.reliableDatagram)
+			case "fly":
+				s.flyCmd(sc, a)
+			case "kill":
+				if err := s.killCmd(sc, s.time, a); err != nil {
+					return false, err
 				}
-				scmd := cmd.GetStringCmd()
-				a := cbuf.Parse(scmd)
-				if len(a.Args()) == 0 {
+			case "noclip":
+				s.noClipCmd(sc, a)
+			case "notarget":
+				s.noTargetCmd(sc, a)
+			case "god":
+				s.godCmd(sc, a)
+
+			case "pause":
+				if cvars.Pausable.String() != "1" {
+					sc.Printf("Pause not allowed.\n")
 					continue
 				}
-				switch strings.ToLower(a.Args()[0].String()) {
-				default:
-					slog.Warn("player tried something", slog.String("player", sc.name), slog.String("action", scmd))
-				case "begin":
-					sc.spawned = true
-				case "color":
-					color := s.colorCmd(sc, a)
-					svc.WriteUpdateColors(color, s.protocol, s.protocolFlags, &s.reliableDatagram)
-				case "fly":
-					s.flyCmd(sc, a)
-				case "kill":
-					if err := s.killCmd(sc, s.time, a); err != nil {
-						return false, err
-					}
-				case "noclip":
-					s.noClipCmd(sc, a)
-				case "notarget":
-					s.noTargetCmd(sc, a)
-				case "god":
-					s.godCmd(sc, a)
-
-				case "pause":
-					if cvars.Pausable.String() != "1" {
-						sc.Printf("Pause not allowed.\n")
-						continue
-					}
-					s.paused = !s.paused
-					s.BroadcastPrintf("%s %s the game\n", s.playerName(sc), func() string {
-						if s.paused {
-							return "paused"
-						}
-						return "unpaused"
-					}())
-					svc.WriteSetPause(s.paused, s.protocol, s.protocolFlags, &s.reliableDatagram)
-				case "ping":
-					s.pingCmd(sc, a)
-				case "prespawn":
-					s.preSpawnCmd(sc, s.signon.Bytes())
-				case "setpos":
-					if err := s.setPosCmd(sc, a); err != nil {
-						return false, err
+				s.paused = !s.paused
+				s.BroadcastPrintf("%s %s the game\n", s.playerName(sc), func() string {
+					if s.paused {
+						return "paused"
 					}
-				case "spawn":
-					if err := s.spawnCmd(sc); err != nil {
-						return false, err
-					}
-				case "give":
-					s.giveCmd(sc, a)
-				case "mapname":
-					// this is for a dedicated server
-					if s.Active() {
-						fmt.Printf("\"mapname\" is %q", s.name)
-					} else {
-						fmt.Printf("no map loaded")
-					}
-				//case "map":
-				// TODO(therjak):
-				// see Host_Map_f in orig
-				// in case of hostFwd
-				case "edicts":
-					s.edictPrintEdicts()
-				case "edictcount":
-					s.edictCount()
-				case "edict":
-					s.edictPrintEdictFunc(a)
-				case "tell":
-					s.tellCmd(sc, a)
-				case "kick":
-					if err := s.kickCmd(sc, a); err != nil {
-						fmt.Printf("Drop error: %v", err)
-					}
-				case "name":
-					args := a.Args()
-					if len(args) < 2 {
-						continue
-					}
-					nn := s.nameCmd(sc, a)
-					svc.WriteUpdateName(nn, s.protocol, s.protocolFlags, &s.reliableDatagram)
-				case "save":
-					s.saveCmd(sc, a)
-				case "status":
-					s.statusCmd(sc, s.name)
-				case "say_team":
-					s.sayCmd(sc, true && cvars.TeamPlay.Bool(), a)
-				case "say":
-					s.sayCmd(sc, false, a)
+					return "unpaused"
+				}())
+				svc.WriteSetPause(s.paused, s.protocol, s.protocolFlags, &s.reliableDatagram)
+			case "ping":
+				s.pingCmd(sc, a)
+			case "prespawn":
+				s.preSpawnCmd(sc, &s.signon)
+			case "setpos":
+				if err := s.setPosCmd(sc, a); err != nil {
+					return false, err
+				}
+			case "spawn":
+				if err := s.spawnCmd(sc); err != nil {
+					return false, err
 				}
-			case protos.Cmd_MoveCmd_case:
-				mc := cmd.GetMoveCmd()
-				sc.pingTimes[sc.numPings%len(sc.pingTimes)] = s.time - mc.GetMessageTime()
-				sc.numPings++
-				sc.numPings %= len(sc.pingTimes)
-				ev := entvars.Get(sc.edictId)
-				ev.VAngle[0] = mc.GetPitch()
-				ev.VAngle[1] = mc.GetYaw()
-				ev.VAngle[2] = mc.GetRoll()
-				sc.cmd.forwardmove = mc.GetForward()
-				sc.cmd.sidemove = mc.GetSide()
-				sc.cmd.upmove = mc.GetUp()
-				ev.Button0 = 0
-				ev.Button2 = 0
-				if mc.GetAttack() {
-					ev.Button0 = 1
+			case "spectator":
+				if !s.spectatorCmd(sc, a) {
+					return false, nil
 				}
-				if mc.GetJump() {
-					ev.Button2 = 1
+			case "follow":
+				s.followCmd(sc, a)
+			case "ready":
+				s.readyCmd(sc, true)
+			case "notready":
+				s.readyCmd(sc, false)
+			case "callvote":
+				s.callVoteCmd(sc, a)
+			case "vote":
+				s.voteCmd(sc, a)
+			case "maplist":
+				s.mapListCmd(sc)
+			case "give":
+				s.giveCmd(sc, a)
+			case "mapname":
+				// this is for a dedicated server
+				if s.Active() {
+					fmt.Printf("\"mapname\" is %q", s.name)
+				} else {
+					fmt.Printf("no map loaded")
 				}
-				if impulse := mc.GetImpulse(); impulse != 0 {
-					ev.Impulse = float32(impulse)
+			//case "map":
+			// TODO(therjak):
+			// see Host_Map_f in orig
+			// in case of hostFwd
+			case "edicts":
+				s.edictPrintEdicts()
+			case "edictcount":
+				s.edictCount()
+			case "edict":
+				s.edictPrintEdictFunc(a)
+			case "tell":
+				s.tellCmd(sc, a)
+			case "addbot":
+				s.addBotCmd(sc, a)
+			case "removebot":
+				s.removeBotCmd(sc, a)
+			case "kick":
+				if err := s.kickCmd(sc, a); err != nil {
+					fmt.Printf("Drop error: %v", err)
 				}
+			case "name":
+				args := a.Args()
+				if len(args) < 2 {
+					continue
+				}
+				nn := s.nameCmd(sc, a)
+				svc.WriteUpdateName(nn, s.protocol, s.protocolFlags, &s.reliableDatagram)
+			case "save":
+				s.saveCmd(sc, a)
+			case "status":
+				s.statusCmd(sc, s.name)
+			case "say_team":
+				s.sayCmd(sc, true && cvars.TeamPlay.Bool(), a)
+			case "say":
+				s.sayCmd(sc, false, a)
+			}
+		case protos.Cmd_MoveCmd_case:
+			mc := cmd.GetMoveCmd()
+			if !sc.checkMove(s, mc) {
+				return false, nil
+			}
+			sc.pingTimes[sc.numPings%len(sc.pingTimes)] = s.time - mc.GetMessageTime()
+			sc.viewTime = mc.GetMessageTime()
+			sc.frameAck = mc.GetEntityFrame()
+			sc.numPings++
+			sc.numPings %= len(sc.pingTimes)
+			ev := s.entvars.Get(sc.edictId)
+			ev.VAngle[0] = mc.GetPitch()
+			ev.VAngle[1] = mc.GetYaw()
+			ev.VAngle[2] = mc.GetRoll()
+			sc.cmd.forwardmove = mc.GetForward()
+			sc.cmd.sidemove = mc.GetSide()
+			sc.cmd.upmove = mc.GetUp()
+			sc.moveSequence = mc.GetSequence()
+			ev.Button0 = 0
+			ev.Button2 = 0
+			if mc.GetAttack() {
+				ev.Button0 = 1
+			}
+			if mc.GetJump() {
+				ev.Button2 = 1
+			}
+			if impulse := mc.GetImpulse(); impulse != 0 {
+				ev.Impulse = float32(impulse)
 			}
 		}
 	}
+	return true, nil
 }
 
 func (s *Server) colorCmd(sc *SVClient, a cbuf.Arguments) *protos.UpdateColors {
@@ -499,7 +583,7 @@ func (s *Server) colorCmd(sc *SVClient, a cbuf.Arguments) *protos.UpdateColors {
 	}
 	color := t*16 + b
 	sc.colors = color
-	entvars.Get(sc.edictId).Team = float32(b + 1)
+	s.entvars.Get(sc.edictId).Team = float32(b + 1)
 	return protos.UpdateColors_builder{
 		Player:   int32(sc.id),
 		NewColor: int32(color),
@@ -507,10 +591,10 @@ func (s *Server) colorCmd(sc *SVClient, a cbuf.Arguments) *protos.UpdateColors {
 }
 
 func (s *Server) flyCmd(sc *SVClient, a cbuf.Arguments) {
-	if progsdat.Globals.DeathMatch != 0 {
+	if s.progsdat.Globals.DeathMatch != 0 {
 		return
 	}
-	ev := entvars.Get(sc.edictId)
+	ev := s.entvars.Get(sc.edictId)
 	m := int32(ev.MoveType)
 	args := a.Args()
 	switch len(args) {
@@ -539,10 +623,10 @@ func (s *Server) flyCmd(sc *SVClient, a cbuf.Arguments) {
 
 func (s *Server) godCmd(sc *SVClient, a cbuf.Arguments) {
 	args := a.Args()[1:]
-	if progsdat.Globals.DeathMatch != 0 {
+	if s.progsdat.Globals.DeathMatch != 0 {
 		return
 	}
-	ev := entvars.Get(sc.edictId)
+	ev := s.entvars.Get(sc.edictId)
 	f := int32(ev.Flags)
 	const flag = progs.FlagGodMode
 	switch len(args) {
@@ -562,26 +646,26 @@ func (s *Server) godCmd(sc *SVClient, a cbuf.Arguments) {
 }
 
 func (s *Server) killCmd(sc *SVClient, time float32, a cbuf.Arguments) error {
-	ev := entvars.Get(sc.edictId)
+	ev := s.entvars.Get(sc.edictId)
 
 	if ev.Health <= 0 {
 		sc.Printf("Can't suicide -- already dead!\n")
 		return nil
 	}
 
-	progsdat.Globals.Time = time
-	progsdat.Globals.Self = int32(sc.edictId)
-	if err := s.vm.ExecuteProgram(progsdat.Globals.ClientKill, s); err != nil {
+	s.progsdat.Globals.Time = time
+	s.progsdat.Globals.Self = int32(sc.edictId)
+	if err := s.vm.ExecuteProgram(s.progsdat.Globals.ClientKill, s); err != nil {
 		return err
 	}
 	return nil
 }
 
 func (s *Server) noClipCmd(sc *SVClient, a cbuf.Arguments) {
-	if progsdat.Globals.DeathMatch != 0 {
+	if s.progsdat.Globals.DeathMatch != 0 {
 		return
 	}
-	ev := entvars.Get(sc.edictId)
+	ev := s.entvars.Get(sc.edictId)
 	m := int32(ev.MoveType)
 	args := a.Args()[1:]
 	switch len(args) {
@@ -605,10 +689,10 @@ func (s *Server) noClipCmd(sc *SVClient, a cbuf.Arguments) {
 }
 
 func (s *Server) noTargetCmd(sc *SVClient, a cbuf.Arguments) {
-	if progsdat.Globals.DeathMatch != 0 {
+	if s.progsdat.Globals.DeathMatch != 0 {
 		return
 	}
-	ev := entvars.Get(sc.edictId)
+	ev := s.entvars.Get(sc.edictId)
 	f := int32(ev.Flags)
 	const flag = progs.FlagNoTarget
 	args := a.Args()[1:]
@@ -629,14 +713,14 @@ func (s *Server) noTargetCmd(sc *SVClient, a cbuf.Arguments) {
 }
 
 func (s *Server) playerName(sc *SVClient) string {
-	ev := entvars.Get(sc.edictId)
-	name, _ := progsdat.String(ev.NetName)
+	ev := s.entvars.Get(sc.edictId)
+	name, _ := s.progsdat.String(ev.NetName)
 	return name
 }
 
 func (s *Server) pingCmd(sc *SVClient, a cbuf.Arguments) {
 	sc.Printf("Client ping times:\n")
-	for _, ac := range sv_clients {
+	for _, ac := range s.clients {
 		if !ac.active {
 			continue
 		}
@@ -644,22 +728,21 @@ func (s *Server) pingCmd(sc *SVClient, a cbuf.Arguments) {
 	}
 }
 
-func (s *Server) preSpawnCmd(sc *SVClient, signon []byte) {
+func (s *Server) preSpawnCmd(sc *SVClient, signon *svc.Message) {
 	if sc.spawned {
 		slog.Warn("prespawn not valid -- already spawned")
 		return
 	}
-	sc.msg.WriteBytes(signon)
-	sc.msg.WriteByte(svc.SignonNum)
-	sc.msg.WriteByte(2)
+	sc.msg.Append(signon)
+	svc.WriteSignonNum(2, &sc.msg)
 	sc.sendSignon = true
 }
 
 func (s *Server) setPosCmd(sc *SVClient, a cbuf.Arguments) error {
-	if progsdat.Globals.DeathMatch != 0 {
+	if s.progsdat.Globals.DeathMatch != 0 {
 		return nil
 	}
-	ev := entvars.Get(sc.edictId)
+	ev := s.entvars.Get(sc.edictId)
 	args := a.Args()
 	switch len(args) {
 	case 7:
@@ -704,26 +787,28 @@ func (s *Server) spawnCmd(sc *SVClient) error {
 		return nil
 	}
 	// run the entrance script
-	if s.loadGame {
+	if sc.spectator {
+		s.spawnSpectator(sc)
+	} else if s.loadGame {
 		// loaded games are fully inited already
 		// if this is the last client to be connected, unpause
 		s.paused = false
 	} else {
-		entvars.Clear(sc.edictId)
-		ev := entvars.Get(sc.edictId)
+		s.entvars.Clear(sc.edictId)
+		ev := s.entvars.Get(sc.edictId)
 		ev.ColorMap = float32(sc.edictId)
 		ev.Team = float32((sc.colors & 15) + 1)
-		ev.NetName = progsdat.AddString(sc.name)
-		progsdat.Globals.Parm = sc.spawnParams
-		progsdat.Globals.Time = s.time
-		progsdat.Globals.Self = int32(sc.edictId)
-		if err := s.vm.ExecuteProgram(progsdat.Globals.ClientConnect, s); err != nil {
+		ev.NetName = s.progsdat.AddString(sc.name)
+		s.progsdat.Globals.Parm = sc.spawnParams
+		s.progsdat.Globals.Time = s.time
+		s.progsdat.Globals.Self = int32(sc.edictId)
+		if err := s.vm.ExecuteProgram(s.progsdat.Globals.ClientConnect, s); err != nil {
 			return err
 		}
 		if time.Since(sc.ConnectTime()).Seconds() <= float64(s.time) {
 			log.Printf("%v entered the game\n", sc.name)
 		}
-		if err := s.vm.ExecuteProgram(progsdat.Globals.PutClientInServer, s); err != nil {
+		if err := s.vm.ExecuteProgram(s.progsdat.Globals.PutClientInServer, s); err != nil {
 			return err
 		}
 	}
@@ -734,9 +819,9 @@ func (s *Server) spawnCmd(sc *SVClient) error {
 	// send time of update
 	svc.WriteTime(s.time, s.protocol, s.protocolFlags, &sc.msg)
 
-	for i, scs := range sv_clients {
-		if i >= svs.maxClients {
-			// TODO: figure out why it ever makes sense to have len(sv_clients) svs.maxClients
+	for i, scs := range s.clients {
+		if i >= s.svs.maxClients {
+			// TODO: figure out why it ever makes sense to have len(s.clients) s.svs.maxClients
 			break
 		}
 		un := protos.UpdateName_builder{
@@ -758,26 +843,27 @@ func (s *Server) spawnCmd(sc *SVClient) error {
 
 	// send all current light styles
 	for i, ls := range s.lightStyles {
-		sc.msg.WriteByte(svc.LightStyle)
-		sc.msg.WriteByte(i)
-		sc.msg.WriteString(ls)
+		svc.WriteLightStyle(protos.LightStyle_builder{
+			Idx:      int32(i),
+			NewStyle: ls,
+		}.Build(), &sc.msg)
 	}
 
-	sc.msg.WriteByte(svc.UpdateStat)
-	sc.msg.WriteByte(svc.StatTotalSecrets)
-	sc.msg.WriteLong(int(progsdat.Globals.TotalSecrets))
-
-	sc.msg.WriteByte(svc.UpdateStat)
-	sc.msg.WriteByte(svc.StatTotalMonsters)
-	sc.msg.WriteLong(int(progsdat.Globals.TotalMonsters))
-
-	sc.msg.WriteByte(svc.UpdateStat)
-	sc.msg.WriteByte(svc.StatSecrets)
-	sc.msg.WriteLong(int(progsdat.Globals.FoundSecrets))
-
-	sc.msg.WriteByte(svc.UpdateStat)
-	sc.msg.WriteByte(svc.StatMonsters)
-	sc.msg.WriteLong(int(progsdat.Globals.KilledMonsters))
+	stats := []struct {
+		stat  int32
+		value float32
+	}{
+		{svc.StatTotalSecrets, s.progsdat.Globals.TotalSecrets},
+		{svc.StatTotalMonsters, s.progsdat.Globals.TotalMonsters},
+		{svc.StatSecrets, s.progsdat.Globals.FoundSecrets},
+		{svc.StatMonsters, s.progsdat.Globals.KilledMonsters},
+	}
+	for _, st := range stats {
+		svc.WriteUpdateStat(protos.UpdateStat_builder{
+			Stat:  st.stat,
+			Value: int32(st.value),
+		}.Build(), &sc.msg)
+	}
 
 	// send a fixangle
 	// Never send a roll angle, because savegames can catch the server
@@ -785,28 +871,27 @@ func (s *Server) spawnCmd(sc *SVClient) error {
 	// and it won't happen if the game was just loaded, so you wind up
 	// with a permanent head tilt
 	sa := protos.Coord_builder{
-		X: entvars.Get(sc.edictId).Angles[0],
-		Y: entvars.Get(sc.edictId).Angles[1],
+		X: s.entvars.Get(sc.edictId).Angles[0],
+		Y: s.entvars.Get(sc.edictId).Angles[1],
 		Z: 0,
 	}.Build()
 	svc.WriteSetAngle(sa, s.protocol, s.protocolFlags, &sc.msg)
 
-	msgBuf.Reset()
-	msgBufMaxLen = protocol.MaxDatagram
+	s.msgBuf.Reset()
+	s.msgBufMaxLen = protocol.MaxDatagram
 	s.WriteClientdataToMessage(sc.edictId)
-	sc.msg.WriteBytes(msgBuf.Bytes())
+	sc.msg.Append(&s.msgBuf)
 
-	sc.msg.WriteByte(svc.SignonNum)
-	sc.msg.WriteByte(3)
+	svc.WriteSignonNum(3, &sc.msg)
 	sc.sendSignon = true
 	return nil
 }
 
 func (s *Server) giveCmd(sc *SVClient, a cbuf.Arguments) {
-	if progsdat.Globals.DeathMatch != 0 {
+	if s.progsdat.Globals.DeathMatch != 0 {
 		return
 	}
-	ev := entvars.Get(sc.edictId)
+	ev := s.entvars.Get(sc.edictId)
 	args := a.Args()
 	if len(args) == 1 {
 		return
@@ -1013,7 +1098,7 @@ func (s *Server) tellCmd(sc *SVClient, a cbuf.Arguments) {
 		return
 	}
 	text := fmt.Sprintf("%s: %s\n", sc.name, a.Message())
-	for _, ac := range sv_clients {
+	for _, ac := range s.clients {
 		if !ac.active || !ac.spawned {
 			continue
 		}
@@ -1032,7 +1117,7 @@ func (s *Server) kickCmd(sc *SVClient, a cbuf.Arguments) error {
 		return nil
 	}
 	// TODO(therjak): admin mode
-	if !sc.admin && progsdat.Globals.DeathMatch != 0 {
+	if !sc.admin && s.progsdat.Globals.DeathMatch != 0 {
 		return nil
 	}
 
@@ -1041,17 +1126,17 @@ func (s *Server) kickCmd(sc *SVClient, a cbuf.Arguments) error {
 
 	if len(args) > 1 && args[0].String() == "#" {
 		i := args[1].Int() - 1
-		if i < 0 || i >= svs.maxClients {
+		if i < 0 || i >= s.svs.maxClients {
 			return nil
 		}
-		toKick = sv_clients[i]
+		toKick = s.clients[i]
 		if !toKick.active {
 			return nil
 		}
 		message = strings.TrimLeft(message, "1234567890")
 		message = strings.TrimLeftFunc(message, unicode.IsSpace)
 	} else {
-		for _, c := range sv_clients {
+		for _, c := range s.clients {
 			if !c.active {
 				continue
 			}
@@ -1090,7 +1175,7 @@ func (s *Server) nameCmd(sc *SVClient, a cbuf.Arguments) *protos.UpdateName {
 		log.Printf("%s renamed to %s\n", sc.name, newName)
 	}
 	sc.name = newName
-	entvars.Get(sc.edictId).NetName = progsdat.AddString(newName)
+	s.entvars.Get(sc.edictId).NetName = s.progsdat.AddString(newName)
 
 	// send notification to all clients
 	return protos.UpdateName_builder{
@@ -1106,21 +1191,27 @@ func (s *Server) statusCmd(sc *SVClient, mapname string) {
 	sc.Printf("tcp/ip:  %s\n", net.Address())
 	sc.Printf("map:     %s\n", mapname)
 	active := 0
-	for _, ac := range sv_clients {
-		if ac.active {
+	for _, ac := range s.clients {
+		if ac.active && !ac.spectator {
 			active++
 		}
 	}
-	sc.Printf("players: %d active (%d max)\n\n", active, svs.maxClients)
+	sc.Printf("players: %d active (%d max)\n", active, s.svs.maxClients)
+	sc.Printf("spectators: %d (%d max)\n\n", s.spectatorCount(), int(cvars.ServerMaxSpectators.Value()))
 	ntime := net.Time()
-	for i, ac := range sv_clients {
+	for i, ac := range s.clients {
 		if !ac.active {
 			continue
 		}
 		d := ntime.Sub(ac.ConnectTime())
 		d = d.Truncate(time.Second)
-		ev := entvars.Get(sc.edictId)
-		sc.Printf("#%-2d %-16.16s  %3d  %9s\n", i+1, ac.name, int(ev.Frags), d.String())
+		if ac.spectator {
+			// spectators have no score
+			sc.Printf("#%-2d %-16.16s  spec  %9s\n", i+1, ac.name, d.String())
+		} else {
+			ev := s.entvars.Get(ac.edictId)
+			sc.Printf("#%-2d %-16.16s  %3d  %9s\n", i+1, ac.name, int(ev.Frags), d.String())
+		}
 		sc.Printf("   %s\n", ac.Address())
 	}
 }
@@ -1130,12 +1221,12 @@ func (s *Server) sayCmd(sc *SVClient, team bool, a cbuf.Arguments) {
 		return
 	}
 	text := fmt.Sprintf("\001%s: %s\n", sc.name, a.ArgumentString())
-	for _, ac := range sv_clients {
+	for _, ac := range s.clients {
 		if !ac.active || !ac.spawned {
 			continue
 		}
 		if team &&
-			entvars.Get(ac.edictId).Team != entvars.Get(sc.edictId).Team {
+			s.entvars.Get(ac.edictId).Team != s.entvars.Get(sc.edictId).Team {
 			continue
 		}
 		ac.Printf(text)
@@ -1146,14 +1237,22 @@ func (s *Server) sayCmd(sc *SVClient, team bool, a cbuf.Arguments) {
 }
 
 func (s *Server) runClients() error {
-	for i := 0; i < svs.maxClients; i++ {
-		host_client = i
+	for i := 0; i < s.svs.maxClients; i++ {
+		s.hostClient = i
 
-		hc := sv_clients[i]
+		hc := s.clients[i]
 		if !hc.active {
 			continue
 		}
 
+		if hc.bot != nil {
+			if hc.spawned && !s.paused {
+				hc.bot.think()
+				hc.Think(s.entvars.Get(hc.edictId), s.time, s)
+			}
+			continue
+		}
+
 		ok, err := hc.ReadClientMessage(s)
 		if err != nil {
 			return err
@@ -1171,11 +1270,16 @@ func (s *Server) runClients() error {
 			continue
 		}
 
+		if hc.spectator {
+			hc.spectatorThink(s)
+			continue
+		}
+
 		if !s.paused {
 			// TODO(therjak): is this pause stuff really needed?
 			// always pause in single player if in console or menus
-			//if svs.maxClients > 1 || keyDestination == keys.Game {
-			hc.Think(entvars.Get(hc.edictId), s.time, s)
+			//if s.svs.maxClients > 1 || keyDestination == keys.Game {
+			hc.Think(s.entvars.Get(hc.edictId), s.time, s)
 			//}
 		}
 	}
@@ -1189,9 +1293,9 @@ func (s *Server) edictPrint(ed int) {
 		return
 	}
 	fmt.Printf("\nEDICT %d:\n", ed)
-	for i := 1; i < len(progsdat.FieldDefs); i++ {
-		d := progsdat.FieldDefs[i]
-		name, err := progsdat.String(d.SName)
+	for i := 1; i < len(s.progsdat.FieldDefs); i++ {
+		d := s.progsdat.FieldDefs[i]
+		name, err := s.progsdat.String(d.SName)
 		if err != nil {
 			continue
 		}
@@ -1201,7 +1305,7 @@ func (s *Server) edictPrint(ed int) {
 			continue
 		}
 		// TODO: skip 0 values
-		fmt.Printf("%-15s %s\n", name, entvars.Sprint(ed, d))
+		fmt.Printf("%-15s %s\n", name, s.entvars.Sprint(ed, d))
 	}
 }
 
@@ -1247,13 +1351,13 @@ func (s *Server) edictCount() {
 			continue
 		}
 		active++
-		if entvars.Get(i).Solid != 0 {
+		if s.entvars.Get(i).Solid != 0 {
 			solid++
 		}
-		if entvars.Get(i).Model != 0 {
+		if s.entvars.Get(i).Model != 0 {
 			models++
 		}
-		if entvars.Get(i).MoveType == progs.MoveTypeStep {
+		if s.entvars.Get(i).MoveType == progs.MoveTypeStep {
 			step++
 		}
 	}
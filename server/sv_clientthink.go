@@ -3,279 +3,117 @@
 package server
 
 import (
-	"goquake/cvars"
+	"goquake/bsp"
 	"goquake/math/vec"
+	"goquake/pmove"
 	"goquake/progs"
-
-	"github.com/chewxy/math32"
 )
 
-func accelerate(ev *progs.EntVars, wishspeed float32, wishdir vec.Vec3, frametime float32) {
-	velocity := vec.VFromA(ev.Velocity)
-	currentspeed := vec.Dot(velocity, wishdir)
-	addspeed := wishspeed - currentspeed
-	if addspeed <= 0 {
-		return
-	}
-	accelspeed := cvars.ServerAccelerate.Value() * frametime * wishspeed
-	if accelspeed > addspeed {
-		accelspeed = addspeed
-	}
-	ev.Velocity = vec.Add(velocity, vec.Scale(accelspeed, wishdir))
+// pmoveWorld lets the shared player movement act on the entity ent.
+type pmoveWorld struct {
+	s   *Server
+	ent int
 }
 
-func airAccelerate(ev *progs.EntVars, wishspeed float32, wishveloc vec.Vec3, frametime float32) {
-	velocity := vec.VFromA(ev.Velocity)
-
-	wishspd := wishveloc.Length()
-	if wishspd <= 0 {
-		return
-	}
-	wishveloc = vec.Scale(1/wishspd, wishveloc)
-	if wishspd > 30 {
-		wishspd = 30
-	}
-	addspeed := wishspd - vec.Dot(velocity, wishveloc)
-	if addspeed <= 0 {
-		return
-	}
-	accelspeed := cvars.ServerAccelerate.Value() * frametime * wishspeed
-	if accelspeed > addspeed {
-		accelspeed = addspeed
+func toPmove(ev *progs.EntVars) pmove.State {
+	return pmove.State{
+		Origin:       ev.Origin,
+		Velocity:     ev.Velocity,
+		Angles:       ev.Angles,
+		VAngle:       ev.VAngle,
+		PunchAngle:   ev.PunchAngle,
+		Mins:         ev.Mins,
+		Maxs:         ev.Maxs,
+		ViewOfs:      ev.ViewOfs,
+		MoveDir:      ev.MoveDir,
+		MoveType:     int(ev.MoveType),
+		Solid:        int(ev.Solid),
+		Flags:        int(ev.Flags),
+		GroundEntity: int(ev.GroundEntity),
+		WaterLevel:   int(ev.WaterLevel),
+		WaterType:    int(ev.WaterType),
+		TeleportTime: ev.TeleportTime,
+		Health:       ev.Health,
+		FixAngle:     ev.FixAngle != 0,
 	}
-	ev.Velocity = vec.Add(velocity, vec.Scale(accelspeed, wishveloc))
 }
 
-func (sc *SVClient) noclipMove(ev *progs.EntVars) {
-	vangle := vec.VFromA(ev.VAngle)
-	forward, right, _ := vec.AngleVectors(vangle)
-
-	fmove := float32(sc.cmd.forwardmove)
-	smove := float32(sc.cmd.sidemove)
-	umove := float32(sc.cmd.upmove)
-
-	velocity := vec.Vec3{
-		forward[0]*fmove + right[0]*smove,
-		forward[1]*fmove + right[1]*smove,
-		forward[2]*fmove + right[2]*smove,
-	}
-	// doubled to match running speed
-	velocity[2] += umove * 2
-
-	max := cvars.ServerMaxSpeed.Value()
-	if velocity.Length() > max {
-		velocity = vec.Scale(max, velocity.Normalize())
-	}
-	ev.Velocity = velocity
+// fromPmove writes back the fields the movement code modifies.
+func fromPmove(p *pmove.State, ev *progs.EntVars) {
+	ev.Origin = p.Origin
+	ev.Velocity = p.Velocity
+	ev.Angles = p.Angles
+	ev.PunchAngle = p.PunchAngle
+	ev.Flags = float32(p.Flags)
+	ev.GroundEntity = int32(p.GroundEntity)
+	ev.WaterLevel = float32(p.WaterLevel)
+	ev.WaterType = float32(p.WaterType)
+	ev.TeleportTime = p.TeleportTime
 }
 
-func (sc *SVClient) waterMove(ev *progs.EntVars, frametime float32) {
-	// user intentions
-	vangle := vec.VFromA(ev.VAngle)
-	forward, right, _ := vec.AngleVectors(vangle)
-
-	fmove := float32(sc.cmd.forwardmove)
-	smove := float32(sc.cmd.sidemove)
-	umove := float32(sc.cmd.upmove)
-
-	wishvel := vec.Vec3{
-		forward[0]*fmove + right[0]*smove,
-		forward[1]*fmove + right[1]*smove,
-		forward[2]*fmove + right[2]*smove,
+func (w *pmoveWorld) Trace(start, mins, maxs, end vec.Vec3, noMonsters bool) bsp.Trace {
+	typ := MOVE_NORMAL
+	if noMonsters {
+		typ = MOVE_NOMONSTERS
 	}
-
-	if fmove == 0 && smove == 0 && umove == 0 {
-		// drift towards bottom
-		wishvel[2] -= 60
-	} else {
-		wishvel[2] += umove
-	}
-
-	wishspeed := wishvel.Length()
-	max := cvars.ServerMaxSpeed.Value()
-	if wishspeed > max {
-		wishvel = vec.Scale(max/wishspeed, wishvel)
-		wishspeed = max
-	}
-	wishspeed *= 0.7
-
-	// water friction
-	velocity := vec.VFromA(ev.Velocity)
-	speed := velocity.Length()
-	newspeed := float32(0)
-	if speed != 0 {
-		newspeed = speed - frametime*speed*cvars.ServerFriction.Value()
-		if newspeed < 0 {
-			newspeed = 0
-		}
-		velocity = vec.Scale(newspeed/speed, velocity)
-	}
-	// water acceleration
-	if wishspeed == 0 {
-		return
-	}
-
-	addspeed := wishspeed - newspeed
-	if addspeed <= 0 {
-		return
-	}
-
-	wishvel = wishvel.Normalize()
-	accelspeed := cvars.ServerAccelerate.Value() * wishspeed * frametime
-	if accelspeed > addspeed {
-		accelspeed = addspeed
-	}
-	ev.Velocity = vec.Add(velocity, vec.Scale(accelspeed, wishvel))
+	return svMove(start, mins, maxs, end, typ, w.ent, w.s)
 }
 
-func (sc *SVClient) userFriction(ev *progs.EntVars, s *Server) {
-	velocity := vec.VFromA(ev.Velocity)
-	speed2 := velocity[0]*velocity[0] + velocity[1]*velocity[1]
-	if speed2 == 0 {
-		return
-	}
-	origin := vec.VFromA(ev.Origin)
-	speed := math32.Sqrt(speed2)
-
-	// if the leading edge is over a dropoff, increase friction
-	start := vec.Vec3{
-		origin[0] + velocity[0]/speed*16,
-		origin[1] + velocity[1]/speed*16,
-		origin[2] + ev.Mins[2],
-	}
-	stop := start
-	stop[2] -= 34
-
-	t := svMove(start, vec.Vec3{}, vec.Vec3{}, stop, 1, sc.edictId, s)
-
-	friction := cvars.ServerFriction.Value()
-	if t.Fraction == 1.0 {
-		friction *= cvars.ServerEdgeFriction.Value()
-	}
-
-	control := func() float32 {
-		stopspeed := cvars.ServerStopSpeed.Value()
-		if speed < stopspeed {
-			return stopspeed
-		}
-		return speed
-	}()
-	newspeed := speed - float32(s.gametime.FrameTime())*control*friction
-
-	if newspeed <= 0 {
-		ev.Velocity = [3]float32{0, 0, 0}
-		return
-	}
-	newspeed /= speed
-	ev.Velocity = vec.Scale(newspeed, velocity)
+func (w *pmoveWorld) PointContents(p vec.Vec3) int {
+	return pointContents(p, w.s.worldModel)
 }
 
-func (sc *SVClient) airMove(ev *progs.EntVars, time float32, s *Server) {
-	forward, right, _ := vec.AngleVectors(vec.VFromA(ev.Angles))
-	fmove := float32(sc.cmd.forwardmove)
-	smove := float32(sc.cmd.sidemove)
-	umove := float32(sc.cmd.upmove)
-
-	// hack to not let you back into teleporter
-	if time < ev.TeleportTime && fmove < 0 {
-		fmove = 0
-	}
-
-	wishvel := vec.Vec3{
-		forward[0]*fmove + right[0]*smove,
-		forward[1]*fmove + right[1]*smove,
-		0,
+// Impact runs the touch functions. As they can modify the entity p gets
+// synced with the entity before and after.
+func (w *pmoveWorld) Impact(p *pmove.State, ent int) (bool, error) {
+	ev := w.s.entvars.Get(w.ent)
+	fromPmove(p, ev)
+	if err := w.s.impact(w.ent, ent); err != nil {
+		return false, err
 	}
-
-	if ev.MoveType != progs.MoveTypeWalk {
-		wishvel[2] = umove
-	}
-
-	wishspeed := wishvel.Length()
-	wishdir := func() vec.Vec3 {
-		if wishspeed != 0 {
-			return vec.Scale(1/wishspeed, wishvel)
-		}
-		return wishvel
-	}()
-
-	max := cvars.ServerMaxSpeed.Value()
-	if wishspeed > max {
-		wishvel = vec.Scale(max/wishspeed, wishvel)
-		wishspeed = max
+	if w.s.edicts[w.ent].Free {
+		return false, nil
 	}
+	*p = toPmove(ev)
+	return true, nil
+}
 
-	onground := int(ev.Flags)&FL_ONGROUND != 0
-	if ev.MoveType == progs.MoveTypeNoClip {
-		ev.Velocity = wishvel
-	} else if onground {
-
-		sc.userFriction(ev, s)
-		accelerate(ev, wishspeed, wishdir, float32(s.gametime.FrameTime()))
-	} else {
-		// not on ground, so little effect on velocity
-		airAccelerate(ev, wishspeed, wishvel, float32(s.gametime.FrameTime()))
+func (w *pmoveWorld) Push(p *pmove.State, push vec.Vec3) (bsp.Trace, error) {
+	ev := w.s.entvars.Get(w.ent)
+	fromPmove(p, ev)
+	t, err := w.s.pushEntity(w.ent, push)
+	if err != nil {
+		return t, err
 	}
+	*p = toPmove(ev)
+	return t, nil
 }
 
-func dropPunchAngle(ev *progs.EntVars, frametime float32) {
-	pa := vec.VFromA(ev.PunchAngle)
-	len := pa.Length()
-	if len == 0 {
-		len = 1
-	}
-	len2 := 1 - (10 * frametime / len)
-	if len2 < 0 {
-		len2 = 0
-	}
-	ev.PunchAngle = vec.Scale(len2, pa)
+func (w *pmoveWorld) Ground(ent int) bool {
+	return w.s.entvars.Get(ent).Solid == SOLID_BSP
 }
 
-func waterJump(ev *progs.EntVars, time float32) {
-	if time > ev.TeleportTime || ev.WaterLevel == 0 {
-		ev.Flags = float32(int(ev.Flags) &^ FL_WATERJUMP)
-		ev.TeleportTime = 0
+// run executes f on the state of the entity and writes back the result.
+func (w *pmoveWorld) run(f func(p *pmove.State) error) error {
+	ev := w.s.entvars.Get(w.ent)
+	p := toPmove(ev)
+	err := f(&p)
+	if !w.s.edicts[w.ent].Free {
+		fromPmove(&p, ev)
 	}
-	ev.Velocity[0] = ev.MoveDir[0]
-	ev.Velocity[1] = ev.MoveDir[1]
+	return err
 }
 
-// the move fields specify an intended velocity in pix/sec
-// the angle fields specify an exact angular motion in degrees
+// Think applies the movement command of the client to its entity.
 func (sc *SVClient) Think(ev *progs.EntVars, time float32, s *Server) {
-	if ev.MoveType == progs.MoveTypeNone {
-		return
-	}
-	dropPunchAngle(ev, float32(s.gametime.FrameTime()))
-	if ev.Health <= 0 {
-		// if dead, behave differently
-		return
-	}
-
-	// onground := int(ev.Flags)&FL_ONGROUND != 0
-	// origin := vec.VFromA(ev.Origin)
-	// velocity := vec.VFromA(ev.Velocity)
-	angles := vec.VFromA(ev.Angles)
-
-	// show 1/3 the pitch angle and all the roll angle
-	vAngle := vec.Add(vec.VFromA(ev.VAngle), vec.VFromA(ev.PunchAngle))
-	angles[2] = cvars.CalcRoll(angles, vec.VFromA(ev.Velocity)) * 4 // ROLL
-	if ev.FixAngle == 0 {
-		angles[0] = -vAngle[0] / 3 // PITCH
-		angles[1] = vAngle[1]      // YAW
-	}
-	ev.Angles = angles
-
-	if int(ev.Flags)&FL_WATERJUMP != 0 {
-		waterJump(ev, time)
-		return
-	}
-	// walk
-	if ev.MoveType == progs.MoveTypeNoClip && cvars.ServerAltNoClip.Bool() {
-		sc.noclipMove(ev)
-	} else if ev.WaterLevel >= 2 && ev.MoveType != progs.MoveTypeNoClip {
-		sc.waterMove(ev, float32(s.gametime.FrameTime()))
-	} else {
-		sc.airMove(ev, time, s)
-	}
+	w := &pmoveWorld{s, sc.edictId}
+	cmd := pmove.Cmd{
+		ForwardMove: sc.cmd.forwardmove,
+		SideMove:    sc.cmd.sidemove,
+		UpMove:      sc.cmd.upmove,
+	}
+	par := pmove.ServerParams(float32(s.gametime.FrameTime()))
+	p := toPmove(ev)
+	pmove.Think(&p, &cmd, time, par, w)
+	fromPmove(&p, ev)
 }
@@ -0,0 +1,182 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"goquake/bsp"
+	"goquake/cvars"
+	"goquake/math/vec"
+)
+
+// lagBackup is the number of server frames kept for lag compensation.
+const lagBackup = 128
+
+// lagEntity is the position of an entity as it was sent to the clients.
+type lagEntity struct {
+	origin vec.Vec3
+	mins   vec.Vec3
+	maxs   vec.Vec3
+}
+
+type lagFrame struct {
+	time     float32
+	entities map[int]lagEntity
+}
+
+// lagHistory stores the past positions of all client visible entities.
+type lagHistory struct {
+	frames [lagBackup]lagFrame
+	head   int // next frame to write
+	count  int
+
+	// client whose traces get compensated, 0 if none
+	client int
+	// entities moved by rewind which need to be restored
+	moved map[int]lagEntity
+}
+
+func (h *lagHistory) clear() {
+	*h = lagHistory{}
+}
+
+// record stores the current position of all visible entities.
+func (h *lagHistory) record(s *Server) {
+	f := &h.frames[h.head]
+	h.head = (h.head + 1) % lagBackup
+	if h.count < lagBackup {
+		h.count++
+	}
+	f.time = s.time
+	if f.entities == nil {
+		f.entities = make(map[int]lagEntity)
+	}
+	clear(f.entities)
+	for i := 1; i < s.numEdicts; i++ {
+		if s.edicts[i].Free {
+			continue
+		}
+		ev := s.entvars.Get(i)
+		if ev.ModelIndex == 0 {
+			continue
+		}
+		f.entities[i] = lagEntity{
+			origin: ev.Origin,
+			mins:   ev.Mins,
+			maxs:   ev.Maxs,
+		}
+	}
+}
+
+// frame returns the n-th newest frame.
+func (h *lagHistory) frame(n int) *lagFrame {
+	return &h.frames[(h.head-1-n+2*lagBackup)%lagBackup]
+}
+
+// position returns the position of ent at time t. The origin gets
+// interpolated between the recorded frames.
+func (h *lagHistory) position(ent int, t float32) (lagEntity, bool) {
+	for n := 0; n < h.count; n++ {
+		older := h.frame(n)
+		if older.time > t {
+			continue
+		}
+		oe, ok := older.entities[ent]
+		if !ok {
+			return lagEntity{}, false
+		}
+		if n == 0 {
+			return oe, true
+		}
+		newer := h.frame(n - 1)
+		ne, ok := newer.entities[ent]
+		if !ok || newer.time == older.time {
+			return oe, true
+		}
+		frac := (t - older.time) / (newer.time - older.time)
+		oe.origin = vec.Lerp(oe.origin, ne.origin, frac)
+		return oe, true
+	}
+	// older than the history
+	if h.count == 0 {
+		return lagEntity{}, false
+	}
+	e, ok := h.frame(h.count - 1).entities[ent]
+	return e, ok
+}
+
+// rewindTime returns the time the client saw, limited by sv_unlag.
+func (s *Server) rewindTime(client int) (float32, bool) {
+	limit := cvars.ServerUnlag.Value()
+	if limit <= 0 || client < 1 || client > len(s.clients) {
+		return 0, false
+	}
+	t := s.clients[client-1].viewTime
+	if t < s.time-limit {
+		t = s.time - limit
+	}
+	if t >= s.time {
+		return 0, false
+	}
+	return t, true
+}
+
+// rewind moves all other players to the positions the compensated client
+// has seen. It needs to be followed by a call to restore.
+func (s *Server) rewind() error {
+	h := &s.lag
+	t, ok := s.rewindTime(h.client)
+	if !ok {
+		return nil
+	}
+	if h.moved == nil {
+		h.moved = make(map[int]lagEntity)
+	}
+	for i := 1; i <= s.svs.maxClients && i < s.numEdicts; i++ {
+		if i == h.client || s.edicts[i].Free || !s.clients[i-1].active {
+			continue
+		}
+		p, ok := h.position(i, t)
+		if !ok {
+			continue
+		}
+		ev := s.entvars.Get(i)
+		h.moved[i] = lagEntity{
+			origin: ev.Origin,
+			mins:   ev.Mins,
+			maxs:   ev.Maxs,
+		}
+		ev.Origin = p.origin
+		ev.Mins = p.mins
+		ev.Maxs = p.maxs
+		if err := s.vm.LinkEdict(i, false, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restore undoes rewind.
+func (s *Server) restore() error {
+	h := &s.lag
+	for i, p := range h.moved {
+		ev := s.entvars.Get(i)
+		ev.Origin = p.origin
+		ev.Mins = p.mins
+		ev.Maxs = p.maxs
+		if err := s.vm.LinkEdict(i, false, s); err != nil {
+			return err
+		}
+	}
+	clear(h.moved)
+	return nil
+}
+
+// unlaggedMove traces a line with the other players at the positions the
+// compensated client has seen.
+func (s *Server) unlaggedMove(start, end vec.Vec3, typ, ent int) (bsp.Trace, error) {
+	if err := s.rewind(); err != nil {
+		return bsp.Trace{}, err
+	}
+	t := svMove(start, vec.Vec3{}, vec.Vec3{}, end, typ, ent, s)
+	return t, s.restore()
+}
@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"goquake/bsp"
+	"goquake/cvars"
+	"goquake/math/vec"
+	"goquake/model"
+	"goquake/progs"
+)
+
+// lagTestServer returns a server with an empty world and two clients.
+func lagTestServer(t *testing.T) *Server {
+	p, err := progs.ParseProgs(fuzzProgs(nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(nil)
+	s.maxEdicts = 4
+	s.numEdicts = 3
+	s.progsdat = p
+	s.vm.Prog = p
+	s.entvars = progs.AllocEntvars(s.maxEdicts, binary.Size(progs.EntVars{})/4, p)
+	s.edicts = newEdicts(s.maxEdicts)
+
+	leaf := &bsp.MLeaf{NodeBase: bsp.NewNodeBase(bsp.CONTENTS_EMPTY, 0, [6]float32{})}
+	world := &bsp.Model{Leafs: []*bsp.MLeaf{leaf}, Node: leaf}
+	for i := range world.Hulls {
+		world.Hulls[i].FirstClipNode = bsp.CONTENTS_EMPTY
+	}
+	s.worldModel = world
+	s.models = []model.Model{world, world}
+	wev := s.entvars.Get(0)
+	wev.Solid = SOLID_BSP
+	wev.MoveType = progs.MoveTypePush
+	s.clearWorld()
+
+	s.clients = []*SVClient{{active: true, edictId: 1}, {active: true, edictId: 2}}
+	s.svs.maxClients = 2
+
+	for i := 1; i <= 2; i++ {
+		ev := s.entvars.Get(i)
+		ev.Solid = SOLID_SLIDEBOX
+		ev.ModelIndex = 1
+		ev.Mins = vec.Vec3{-16, -16, -24}
+		ev.Maxs = vec.Vec3{16, 16, 32}
+		if err := s.vm.LinkEdict(i, false, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+// moveTarget places the player 2 at y and records the frame at time t.
+func moveTarget(t *testing.T, s *Server, time, y float32) {
+	s.entvars.Get(2).Origin = vec.Vec3{200, y, 0}
+	if err := s.vm.LinkEdict(2, false, s); err != nil {
+		t.Fatal(err)
+	}
+	s.time = time
+	s.lag.record(s)
+}
+
+func TestUnlagTrace(t *testing.T) {
+	cvars.ServerUnlag.SetByString("0.5")
+	defer cvars.ServerUnlag.Reset()
+	s := lagTestServer(t)
+	for i := 0; i <= 10; i++ {
+		moveTarget(t, s, float32(i)*0.1, float32(i)*100)
+	}
+	start := vec.Vec3{0, 0, 0}
+
+	tests := []struct {
+		name     string
+		viewTime float32
+		y        float32 // where the shot is aimed
+		hit      bool
+	}{
+		{"current position", 1, 1000, true},
+		{"recorded position", 0.7, 700, true},
+		{"not at current position", 0.7, 1000, false},
+		{"interpolated position", 0.75, 750, true},
+		{"capped by sv_unlag", 0.1, 100, false},
+		{"at the cap", 0.1, 500, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s.clients[0].viewTime = tc.viewTime
+			s.lag.client = 1
+			tr, err := s.unlaggedMove(start, vec.Vec3{400, 2 * tc.y, 0}, MOVE_NORMAL, 1)
+			s.lag.client = 0
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hit := tr.EntPointer && tr.EntNumber == 2; hit != tc.hit {
+				t.Errorf("hit = %v, want %v (trace %v)", hit, tc.hit, tr)
+			}
+			if o := s.entvars.Get(2).Origin; o != [3]float32{200, 1000, 0} {
+				t.Errorf("origin not restored: %v", o)
+			}
+		})
+	}
+}
+
+func TestUnlagPosition(t *testing.T) {
+	s := lagTestServer(t)
+	for i := 0; i < lagBackup+10; i++ {
+		moveTarget(t, s, float32(i), float32(i))
+	}
+	tests := []struct {
+		time float32
+		want float32
+	}{
+		{float32(lagBackup + 9), float32(lagBackup + 9)},
+		{100.5, 100.5},
+		{0, 10}, // older than the history
+	}
+	for _, tc := range tests {
+		p, ok := s.lag.position(2, tc.time)
+		if !ok {
+			t.Fatalf("position(%v): not found", tc.time)
+		}
+		if p.origin[1] != tc.want {
+			t.Errorf("position(%v) = %v, want y %v", tc.time, p.origin, tc.want)
+		}
+	}
+}
@@ -10,7 +10,6 @@ import (
 	"strings"
 
 	"goquake/bsp"
-	"goquake/cbuf"
 	"goquake/cvars"
 	"goquake/math"
 	"goquake/math/vec"
@@ -29,40 +28,40 @@ const (
 
 func (v *virtualMachine) LoadGameGlobals(g *protos.Globals) {
 	for _, st := range g.GetStrings() {
-		def, err := v.prog.FindGlobalDef(st.GetId())
+		def, err := v.Prog.FindGlobalDef(st.GetId())
 		if err != nil {
 			continue
 		}
-		id := v.prog.NewString(st.GetValue())
-		v.prog.RawGlobalsI[def.Offset] = id
+		id := v.Prog.NewString(st.GetValue())
+		v.Prog.RawGlobalsI[def.Offset] = id
 	}
 	for _, fl := range g.GetFloats() {
-		def, err := v.prog.FindGlobalDef(fl.GetId())
+		def, err := v.Prog.FindGlobalDef(fl.GetId())
 		if err != nil {
 			continue
 		}
-		v.prog.RawGlobalsF[def.Offset] = fl.GetValue()
+		v.Prog.RawGlobalsF[def.Offset] = fl.GetValue()
 	}
 	for _, ent := range g.GetEntities() {
-		def, err := v.prog.FindGlobalDef(ent.GetId())
+		def, err := v.Prog.FindGlobalDef(ent.GetId())
 		if err != nil {
 			continue
 		}
-		v.prog.RawGlobalsI[def.Offset] = ent.GetValue()
+		v.Prog.RawGlobalsI[def.Offset] = ent.GetValue()
 	}
 }
 
 func (v *virtualMachine) saveGlobalString(name string, offset uint16) *protos.StringDef {
-	val := v.prog.RawGlobalsI[offset]
-	s, _ := v.prog.String(val)
+	val := v.Prog.RawGlobalsI[offset]
+	str, _ := v.Prog.String(val)
 	return protos.StringDef_builder{
 		Id:    name,
-		Value: s,
+		Value: str,
 	}.Build()
 }
 
 func (v *virtualMachine) saveGlobalFloat(name string, offset uint16) *protos.FloatDef {
-	val := v.prog.RawGlobalsF[offset]
+	val := v.Prog.RawGlobalsF[offset]
 	return protos.FloatDef_builder{
 		Id:    name,
 		Value: val,
@@ -70,7 +69,7 @@ func (v *virtualMachine) saveGlobalFloat(name string, offset uint16) *protos.Flo
 }
 
 func (v *virtualMachine) saveGlobalEntity(name string, offset uint16) *protos.EntityDef {
-	val := v.prog.RawGlobalsI[offset]
+	val := v.Prog.RawGlobalsI[offset]
 	return protos.EntityDef_builder{
 		Id:    name,
 		Value: val,
@@ -81,13 +80,13 @@ func (v *virtualMachine) SaveGameGlobals() *protos.Globals {
 	entities := []*protos.EntityDef{}
 	floats := []*protos.FloatDef{}
 	ostrings := []*protos.StringDef{}
-	for _, d := range v.prog.GlobalDefs {
+	for _, d := range v.Prog.GlobalDefs {
 		t := d.Type
 		if t&saveGlobal == 0 {
 			continue
 		}
 		t &^= saveGlobal
-		name, _ := v.prog.String(d.SName)
+		name, _ := v.Prog.String(d.SName)
 		offset := d.Offset
 		switch t {
 		case progs.EV_String:
@@ -110,83 +109,83 @@ func (v *virtualMachine) SaveGameGlobals() *protos.Globals {
 	}.Build()
 }
 
-func (v *virtualMachine) loadGameEntVars(idx int, e *protos.Edict) {
-	entvars.Clear(idx)
+func (v *virtualMachine) loadGameEntVars(idx int, e *protos.Edict, s *Server) {
+	s.entvars.Clear(idx)
 	// TODO: keyname == "alpha"
 	for _, st := range e.GetStrings() {
-		def, err := v.prog.FindFieldDef(st.GetId())
+		def, err := v.Prog.FindFieldDef(st.GetId())
 		if err != nil {
 			slog.Warn("No string", slog.String("ID", st.GetId()))
 			continue
 		}
-		id := v.prog.NewString(st.GetValue())
-		entvars.SetRawI(int32(idx), int32(def.Offset), id)
+		id := v.Prog.NewString(st.GetValue())
+		s.entvars.SetRawI(int32(idx), int32(def.Offset), id)
 	}
 	for _, fl := range e.GetFloats() {
-		def, err := v.prog.FindFieldDef(fl.GetId())
+		def, err := v.Prog.FindFieldDef(fl.GetId())
 		if err != nil {
 			slog.Warn("No float", slog.String("ID", fl.GetId()))
 			continue
 		}
-		entvars.SetRawF(int32(idx), int32(def.Offset), fl.GetValue())
+		s.entvars.SetRawF(int32(idx), int32(def.Offset), fl.GetValue())
 	}
 	for _, ent := range e.GetEntities() {
-		def, err := v.prog.FindFieldDef(ent.GetId())
+		def, err := v.Prog.FindFieldDef(ent.GetId())
 		if err != nil {
 			slog.Warn("No field", slog.String("ID", ent.GetId()))
 			continue
 		}
-		entvars.SetRawI(int32(idx), int32(def.Offset), ent.GetValue())
+		s.entvars.SetRawI(int32(idx), int32(def.Offset), ent.GetValue())
 	}
 	for _, fnc := range e.GetFunctions() {
-		def, err := v.prog.FindFieldDef(fnc.GetId())
+		def, err := v.Prog.FindFieldDef(fnc.GetId())
 		if err != nil {
 			continue
 		}
-		fidx, err := v.prog.FindFunction(fnc.GetValue())
+		fidx, err := v.Prog.FindFunction(fnc.GetValue())
 		if err != nil {
 			continue
 		}
-		entvars.SetRawI(int32(idx), int32(def.Offset), int32(fidx))
+		s.entvars.SetRawI(int32(idx), int32(def.Offset), int32(fidx))
 	}
 	for _, field := range e.GetFields() {
-		def, err := v.prog.FindFieldDef(field.GetId())
+		def, err := v.Prog.FindFieldDef(field.GetId())
 		if err != nil {
 			continue
 		}
-		vdef, err := v.prog.FindFieldDef(field.GetValue())
+		vdef, err := v.Prog.FindFieldDef(field.GetValue())
 		if err != nil {
 			continue
 		}
-		entvars.SetRawI(int32(idx), int32(def.Offset), int32(vdef.Offset))
+		s.entvars.SetRawI(int32(idx), int32(def.Offset), int32(vdef.Offset))
 	}
 	for _, vector := range e.GetVectors() {
-		def, err := v.prog.FindFieldDef(vector.GetId())
+		def, err := v.Prog.FindFieldDef(vector.GetId())
 		if err != nil {
 			continue
 		}
 		val := vector.GetValue()
-		entvars.SetRawF(int32(idx), int32(def.Offset), val.GetX())
-		entvars.SetRawF(int32(idx), int32(def.Offset+1), val.GetY())
-		entvars.SetRawF(int32(idx), int32(def.Offset+2), val.GetZ())
+		s.entvars.SetRawF(int32(idx), int32(def.Offset), val.GetX())
+		s.entvars.SetRawF(int32(idx), int32(def.Offset+1), val.GetY())
+		s.entvars.SetRawF(int32(idx), int32(def.Offset+2), val.GetZ())
 	}
 
 }
 
-func (v *virtualMachine) saveEVString(idx int, name string, offset uint16) (*protos.StringDef, bool) {
-	val := entvars.RawI(int32(idx), int32(offset))
+func (v *virtualMachine) saveEVString(idx int, name string, offset uint16, s *Server) (*protos.StringDef, bool) {
+	val := s.entvars.RawI(int32(idx), int32(offset))
 	if val == 0 {
 		return nil, false
 	}
-	s, _ := v.prog.String(val)
+	str, _ := v.Prog.String(val)
 	return protos.StringDef_builder{
 		Id:    name,
-		Value: s,
+		Value: str,
 	}.Build(), true
 }
 
-func (v *virtualMachine) saveEVFloat(idx int, name string, offset uint16) (*protos.FloatDef, bool) {
-	val := entvars.RawF(int32(idx), int32(offset))
+func (v *virtualMachine) saveEVFloat(idx int, name string, offset uint16, s *Server) (*protos.FloatDef, bool) {
+	val := s.entvars.RawF(int32(idx), int32(offset))
 	if val == 0 {
 		return nil, false
 	}
@@ -196,8 +195,8 @@ func (v *virtualMachine) saveEVFloat(idx int, name string, offset uint16) (*prot
 	}.Build(), true
 }
 
-func (v *virtualMachine) saveEVEntity(idx int, name string, offset uint16) (*protos.EntityDef, bool) {
-	val := entvars.RawI(int32(idx), int32(offset))
+func (v *virtualMachine) saveEVEntity(idx int, name string, offset uint16, s *Server) (*protos.EntityDef, bool) {
+	val := s.entvars.RawI(int32(idx), int32(offset))
 	if val == 0 {
 		return nil, false
 	}
@@ -207,10 +206,10 @@ func (v *virtualMachine) saveEVEntity(idx int, name string, offset uint16) (*pro
 	}.Build(), true
 }
 
-func (v *virtualMachine) saveEVVector(idx int, name string, offset uint16) (*protos.VectorDef, bool) {
-	x := entvars.RawF(int32(idx), int32(offset))
-	y := entvars.RawF(int32(idx), int32(offset+1))
-	z := entvars.RawF(int32(idx), int32(offset+2))
+func (v *virtualMachine) saveEVVector(idx int, name string, offset uint16, s *Server) (*protos.VectorDef, bool) {
+	x := s.entvars.RawF(int32(idx), int32(offset))
+	y := s.entvars.RawF(int32(idx), int32(offset+1))
+	z := s.entvars.RawF(int32(idx), int32(offset+2))
 	if x == 0 && y == 0 && z == 0 {
 		return nil, false
 	}
@@ -221,48 +220,48 @@ func (v *virtualMachine) saveEVVector(idx int, name string, offset uint16) (*pro
 	}.Build(), true
 }
 
-func (v *virtualMachine) saveEVField(idx int, name string, offset uint16) (*protos.FieldDef, bool) {
-	s := ""
-	val := entvars.RawI(int32(idx), int32(offset))
+func (v *virtualMachine) saveEVField(idx int, name string, offset uint16, s *Server) (*protos.FieldDef, bool) {
+	str := ""
+	val := s.entvars.RawI(int32(idx), int32(offset))
 	if val == 0 {
 		return nil, false
 	}
-	for _, f := range v.prog.FieldDefs {
+	for _, f := range v.Prog.FieldDefs {
 		if int32(f.Offset) == val {
-			s, _ = v.prog.String(f.SName)
+			str, _ = v.Prog.String(f.SName)
 			break
 		}
 	}
 	return protos.FieldDef_builder{
 		Id:    name,
-		Value: s,
+		Value: str,
 	}.Build(), true
 }
 
-func (v *virtualMachine) saveEVFunction(idx int, name string, offset uint16) (*protos.FunctionDef, bool) {
-	val := entvars.RawI(int32(idx), int32(offset))
+func (v *virtualMachine) saveEVFunction(idx int, name string, offset uint16, s *Server) (*protos.FunctionDef, bool) {
+	val := s.entvars.RawI(int32(idx), int32(offset))
 	if val == 0 {
 		return nil, false
 	}
-	sname := v.prog.Functions[val].SName
-	s, _ := v.prog.String(sname)
+	sname := v.Prog.Functions[val].SName
+	str, _ := v.Prog.String(sname)
 	return protos.FunctionDef_builder{
 		Id:    name,
-		Value: s,
+		Value: str,
 	}.Build(), true
 }
 
-func (v *virtualMachine) saveGameEntVars(idx int) *protos.Edict {
+func (v *virtualMachine) saveGameEntVars(idx int, s *Server) *protos.Edict {
 	entities := []*protos.EntityDef{}
 	fields := []*protos.FieldDef{}
 	floats := []*protos.FloatDef{}
 	functions := []*protos.FunctionDef{}
 	ostrings := []*protos.StringDef{}
 	vectors := []*protos.VectorDef{}
-	for _, d := range v.prog.FieldDefs[1:] {
+	for _, d := range v.Prog.FieldDefs[1:] {
 		t := d.Type
 		t &^= saveGlobal
-		name, _ := v.prog.String(d.SName)
+		name, _ := v.Prog.String(d.SName)
 		if strings.HasPrefix(name, "_") {
 			// skip _x, _y, _z vars
 			continue
@@ -270,27 +269,27 @@ func (v *virtualMachine) saveGameEntVars(idx int) *protos.Edict {
 		offset := d.Offset
 		switch t {
 		case progs.EV_String:
-			if s, ok := v.saveEVString(idx, name, offset); ok {
-				ostrings = append(ostrings, s)
+			if sd, ok := v.saveEVString(idx, name, offset, s); ok {
+				ostrings = append(ostrings, sd)
 			}
 		case progs.EV_Float:
-			if f, ok := v.saveEVFloat(idx, name, offset); ok {
+			if f, ok := v.saveEVFloat(idx, name, offset, s); ok {
 				floats = append(floats, f)
 			}
 		case progs.EV_Entity:
-			if e, ok := v.saveEVEntity(idx, name, offset); ok {
+			if e, ok := v.saveEVEntity(idx, name, offset, s); ok {
 				entities = append(entities, e)
 			}
 		case progs.EV_Vector:
-			if ve, ok := v.saveEVVector(idx, name, offset); ok {
+			if ve, ok := v.saveEVVector(idx, name, offset, s); ok {
 				vectors = append(vectors, ve)
 			}
 		case progs.EV_Field:
-			if f, ok := v.saveEVField(idx, name, offset); ok {
+			if f, ok := v.saveEVField(idx, name, offset, s); ok {
 				fields = append(fields, f)
 			}
 		case progs.EV_Function:
-			if f, ok := v.saveEVFunction(idx, name, offset); ok {
+			if f, ok := v.saveEVFunction(idx, name, offset, s); ok {
 				functions = append(functions, f)
 			}
 		default:
@@ -315,9 +314,9 @@ func (v *virtualMachine) saveGameEntVars(idx int) *protos.Edict {
 // removed, but the level can continue.
 func (v *virtualMachine) objError(s *Server) error {
 	st := v.varString(0)
-	fs := v.funcName()
+	fs := v.FuncName()
 	slog.Error("======OBJECT ERROR======", slog.String("function", fs), slog.String("var", st))
-	ed := int(v.prog.Globals.Self)
+	ed := int(v.Prog.Globals.Self)
 	s.edictPrint(ed)
 	v.edictFree(ed, s)
 	return nil
@@ -327,9 +326,9 @@ func (v *virtualMachine) objError(s *Server) error {
 // Dumps self.
 func (v *virtualMachine) terminalError(s *Server) error {
 	st := v.varString(0)
-	fs := v.funcName()
+	fs := v.FuncName()
 	slog.Error("======SERVER ERROR======", slog.String("function", fs), slog.String("var", st))
-	s.edictPrint(int(v.prog.Globals.Self))
+	s.edictPrint(int(v.Prog.Globals.Self))
 	return fmt.Errorf("Program error")
 }
 
@@ -348,31 +347,29 @@ func (v *virtualMachine) bprint(s *Server) error {
 
 // single print to a specific client
 func (v *virtualMachine) sprint(s *Server) error {
-	e := int(v.prog.Globals.Parm0[0])
+	e := int(v.Prog.Globals.Parm0[0])
 	st := v.varString(1)
-	if e < 1 || e > svs.maxClients {
+	if e < 1 || e > s.svs.maxClients {
 		slog.Error("tried to sprint to a non-client", slog.Int("client", e))
 		return nil
 	}
 	e--
-	c := sv_clients[e]
-	c.msg.WriteChar(svc.Print)
-	c.msg.WriteString(st)
+	c := s.clients[e]
+	svc.WritePrint(st, &c.msg)
 	return nil
 }
 
 // single print to a specific client
 func (v *virtualMachine) centerPrint(s *Server) error {
-	e := int(v.prog.Globals.Parm0[0])
+	e := int(v.Prog.Globals.Parm0[0])
 	st := v.varString(1)
-	if e < 1 || e > svs.maxClients {
+	if e < 1 || e > s.svs.maxClients {
 		slog.Error("tried to sprint to a non-client", slog.Int("client", e))
 		return nil
 	}
 	e--
-	c := sv_clients[e]
-	c.msg.WriteChar(svc.CenterPrint)
-	c.msg.WriteString(st)
+	c := s.clients[e]
+	svc.WriteCenterPrint(st, &c.msg)
 	return nil
 }
 
@@ -385,9 +382,9 @@ This should be called when an object is spawned, and then only if it is
 teleported.
 */
 func (v *virtualMachine) setOrigin(s *Server) error {
-	e := int(v.prog.Globals.Parm0[0])
-	ev := entvars.Get(e)
-	ev.Origin = *v.prog.Globals.Parm1f()
+	e := int(v.Prog.Globals.Parm0[0])
+	ev := s.entvars.Get(e)
+	ev.Origin = *v.Prog.Globals.Parm1f()
 
 	if err := v.LinkEdict(e, false, s); err != nil {
 		return err
@@ -406,10 +403,10 @@ func setMinMaxSize(ev *progs.EntVars, min, max vec.Vec3) {
 }
 
 func (v *virtualMachine) setSize(s *Server) error {
-	e := int(v.prog.Globals.Parm0[0])
-	min := *v.prog.Globals.Parm1f()
-	max := *v.prog.Globals.Parm2f()
-	setMinMaxSize(entvars.Get(e), min, max)
+	e := int(v.Prog.Globals.Parm0[0])
+	min := *v.Prog.Globals.Parm1f()
+	max := *v.Prog.Globals.Parm2f()
+	setMinMaxSize(s.entvars.Get(e), min, max)
 	if err := v.LinkEdict(e, false, s); err != nil {
 		return err
 	}
@@ -417,12 +414,12 @@ func (v *virtualMachine) setSize(s *Server) error {
 }
 
 func (v *virtualMachine) setModel(s *Server) error {
-	e := int(v.prog.Globals.Parm0[0])
-	mi := v.prog.Globals.Parm1[0]
-	m, err := v.prog.String(mi)
+	e := int(v.Prog.Globals.Parm0[0])
+	mi := v.Prog.Globals.Parm1[0]
+	m, err := v.Prog.String(mi)
 	if err != nil {
 		slog.Error("no precache", slog.Int("model", int(mi)))
-		v.abort()
+		v.Abort()
 		return errProgram
 	}
 
@@ -435,11 +432,11 @@ func (v *virtualMachine) setModel(s *Server) error {
 	}
 	if idx == -1 {
 		slog.Error("no precache", slog.String("model", m))
-		v.abort()
+		v.Abort()
 		return errProgram
 	}
 
-	ev := entvars.Get(e)
+	ev := s.entvars.Get(e)
 	ev.Model = mi
 	ev.ModelIndex = float32(idx)
 
@@ -466,10 +463,10 @@ func (v *virtualMachine) setModel(s *Server) error {
 
 // TODO
 func (v *virtualMachine) normalize(s *Server) error {
-	ve := vec.VFromA(*v.prog.Globals.Parm0f())
+	ve := vec.VFromA(*v.Prog.Globals.Parm0f())
 	l := 1 / gmath.Sqrt(vec.DoublePrecDot(ve, ve))
 
-	*v.prog.Globals.Returnf() = vec.Vec3{
+	*v.Prog.Globals.Returnf() = vec.Vec3{
 		float32(float64(ve[0]) * l),
 		float32(float64(ve[1]) * l),
 		float32(float64(ve[2]) * l),
@@ -479,15 +476,15 @@ func (v *virtualMachine) normalize(s *Server) error {
 
 // TODO
 func (v *virtualMachine) vlen(s *Server) error {
-	ve := vec.VFromA(*v.prog.Globals.Parm0f())
+	ve := vec.VFromA(*v.Prog.Globals.Parm0f())
 	l := gmath.Sqrt(vec.DoublePrecDot(ve, ve))
-	v.prog.Globals.Returnf()[0] = float32(l)
+	v.Prog.Globals.Returnf()[0] = float32(l)
 	return nil
 }
 
 // TODO
 func (v *virtualMachine) vecToYaw(s *Server) error {
-	ve := vec.VFromA(*v.prog.Globals.Parm0f())
+	ve := vec.VFromA(*v.Prog.Globals.Parm0f())
 	yaw := func() float32 {
 		if ve[0] == 0 && ve[1] == 0 {
 			return 0
@@ -499,13 +496,13 @@ func (v *virtualMachine) vecToYaw(s *Server) error {
 		}
 		return y
 	}()
-	v.prog.Globals.Returnf()[0] = yaw
+	v.Prog.Globals.Returnf()[0] = yaw
 	return nil
 }
 
 // TODO
 func (v *virtualMachine) vecToAngles(s *Server) error {
-	ve := vec.VFromA(*v.prog.Globals.Parm0f())
+	ve := vec.VFromA(*v.Prog.Globals.Parm0f())
 	yaw, pitch := func() (float32, float32) {
 		if ve[0] == 0 && ve[1] == 0 {
 			p := func() float32 {
@@ -529,36 +526,35 @@ func (v *virtualMachine) vecToAngles(s *Server) error {
 		}
 		return float32(y), float32(p)
 	}()
-	*v.prog.Globals.Returnf() = [3]float32{pitch, yaw, 0}
+	*v.Prog.Globals.Returnf() = [3]float32{pitch, yaw, 0}
 	return nil
 }
 
 // TODO
 // Returns a number from 0 <= num < 1
 func (v *virtualMachine) random(s *Server) error {
-	v.prog.Globals.Returnf()[0] = s.rand.Float32()
+	v.Prog.Globals.Returnf()[0] = s.rand.Float32()
 	return nil
 }
 
 func (v *virtualMachine) particle(s *Server) error {
-	org := vec.VFromA(*v.prog.Globals.Parm0f())
-	dir := vec.VFromA(*v.prog.Globals.Parm1f())
-	color := v.prog.RawGlobalsF[progs.OffsetParm2]
-	count := v.prog.RawGlobalsF[progs.OffsetParm3]
+	org := vec.VFromA(*v.Prog.Globals.Parm0f())
+	dir := vec.VFromA(*v.Prog.Globals.Parm1f())
+	color := v.Prog.RawGlobalsF[progs.OffsetParm2]
+	count := v.Prog.RawGlobalsF[progs.OffsetParm3]
 	s.StartParticle(org, dir, int(color), int(count))
 	return nil
 }
 
 func (v *virtualMachine) ambientSound(s *Server) error {
-	large := false
-	pos := vec.VFromA(*v.prog.Globals.Parm0f())
-	sample, err := v.prog.String(v.prog.Globals.Parm1[0])
+	pos := vec.VFromA(*v.Prog.Globals.Parm0f())
+	sample, err := v.Prog.String(v.Prog.Globals.Parm1[0])
 	if err != nil {
 		slog.Error("ambientSound: no precache", slog.Any("pos", pos))
 		return nil
 	}
-	volume := v.prog.RawGlobalsF[progs.OffsetParm2] * 255
-	attenuation := v.prog.RawGlobalsF[progs.OffsetParm3] * 64
+	volume := v.Prog.RawGlobalsF[progs.OffsetParm2] * 255
+	attenuation := v.Prog.RawGlobalsF[progs.OffsetParm3] * 64
 
 	// check to see if samp was properly precached
 	soundnum := func() int {
@@ -575,33 +571,21 @@ func (v *virtualMachine) ambientSound(s *Server) error {
 		return nil
 	}
 
-	if soundnum > 255 {
-		if s.protocol == protocol.NetQuake {
-			return nil // don't send any info protocol can't support
-		} else {
-			large = true
-		}
+	if soundnum > 255 && s.protocol == protocol.NetQuake {
+		return nil // don't send any info protocol can't support
 	}
 
 	// add an svc_spawnambient command to the level signon packet
-	if large {
-		s.signon.WriteByte(svc.SpawnStaticSound2)
-	} else {
-		s.signon.WriteByte(svc.SpawnStaticSound)
-	}
-
-	s.signon.WriteCoord(pos[0], s.protocolFlags)
-	s.signon.WriteCoord(pos[1], s.protocolFlags)
-	s.signon.WriteCoord(pos[2], s.protocolFlags)
-
-	if large {
-		s.signon.WriteShort(soundnum)
-	} else {
-		s.signon.WriteByte(soundnum)
-	}
-
-	s.signon.WriteByte(int(volume))
-	s.signon.WriteByte(int(attenuation))
+	svc.WriteSpawnStaticSound(protos.StaticSound_builder{
+		Origin: protos.Coord_builder{
+			X: pos[0],
+			Y: pos[1],
+			Z: pos[2],
+		}.Build(),
+		Index:       int32(soundnum),
+		Volume:      int32(volume),
+		Attenuation: int32(attenuation),
+	}.Build(), s.protocol, s.protocolFlags, &s.signon)
 	return nil
 }
 
@@ -612,16 +596,16 @@ func (v *virtualMachine) ambientSound(s *Server) error {
 // An attenuation of 0 will play full volume everywhere in the level.
 // Larger attenuations will drop off.
 func (v *virtualMachine) sound(s *Server) error {
-	entity := v.prog.Globals.Parm0[0]
-	channel := v.prog.RawGlobalsF[progs.OffsetParm1]
-	sample, err := v.prog.String(v.prog.Globals.Parm2[0])
+	entity := v.Prog.Globals.Parm0[0]
+	channel := v.Prog.RawGlobalsF[progs.OffsetParm1]
+	sample, err := v.Prog.String(v.Prog.Globals.Parm2[0])
 	if err != nil {
 		slog.Error("PF_sound: no sample")
-		v.abort()
+		v.Abort()
 		return errProgram
 	}
-	volume := v.prog.RawGlobalsF[progs.OffsetParm3] * 255
-	attenuation := v.prog.RawGlobalsF[progs.OffsetParm4]
+	volume := v.Prog.RawGlobalsF[progs.OffsetParm3] * 255
+	attenuation := v.Prog.RawGlobalsF[progs.OffsetParm4]
 
 	if volume < 0 || volume > 255 {
 		return fmt.Errorf("SV_StartSound: volume = %v", volume)
@@ -650,10 +634,10 @@ func (v *virtualMachine) doBreak(s *Server) error {
 // Traces are blocked by bbox and exact bsp entityes, and also slide
 // box entities if the tryents flag is set.
 func (v *virtualMachine) traceline(s *Server) error {
-	v1 := vec.VFromA(*v.prog.Globals.Parm0f())
-	v2 := vec.VFromA(*v.prog.Globals.Parm1f())
-	nomonsters := v.prog.RawGlobalsF[progs.OffsetParm2]
-	ent := int(v.prog.Globals.Parm3[0])
+	v1 := vec.VFromA(*v.Prog.Globals.Parm0f())
+	v2 := vec.VFromA(*v.Prog.Globals.Parm1f())
+	nomonsters := v.Prog.RawGlobalsF[progs.OffsetParm2]
+	ent := int(v.Prog.Globals.Parm3[0])
 
 	// FIXME FIXME FIXME: Why do we hit this with certain progs.dat ??
 	if cvars.Developer.Bool() {
@@ -672,7 +656,10 @@ func (v *virtualMachine) traceline(s *Server) error {
 		v2 = vec.Vec3{}
 	}
 
-	t := svMove(v1, vec.Vec3{}, vec.Vec3{}, v2, int(nomonsters), ent, s)
+	t, err := s.unlaggedMove(v1, v2, int(nomonsters), ent)
+	if err != nil {
+		return err
+	}
 
 	b2f := func(b bool) float32 {
 		if b {
@@ -680,47 +667,39 @@ func (v *virtualMachine) traceline(s *Server) error {
 		}
 		return 0
 	}
-	v.prog.Globals.TraceAllSolid = b2f(t.AllSolid)
-	v.prog.Globals.TraceStartSolid = b2f(t.StartSolid)
-	v.prog.Globals.TraceFraction = t.Fraction
-	v.prog.Globals.TraceInWater = b2f(t.InWater)
-	v.prog.Globals.TraceInOpen = b2f(t.InOpen)
-	v.prog.Globals.TraceEndPos = t.EndPos
-	v.prog.Globals.TracePlaneNormal = t.Plane.Normal
-	v.prog.Globals.TracePlaneDist = t.Plane.Distance
+	v.Prog.Globals.TraceAllSolid = b2f(t.AllSolid)
+	v.Prog.Globals.TraceStartSolid = b2f(t.StartSolid)
+	v.Prog.Globals.TraceFraction = t.Fraction
+	v.Prog.Globals.TraceInWater = b2f(t.InWater)
+	v.Prog.Globals.TraceInOpen = b2f(t.InOpen)
+	v.Prog.Globals.TraceEndPos = t.EndPos
+	v.Prog.Globals.TracePlaneNormal = t.Plane.Normal
+	v.Prog.Globals.TracePlaneDist = t.Plane.Distance
 	if t.EntPointer {
-		v.prog.Globals.TraceEnt = int32(t.EntNumber)
+		v.Prog.Globals.TraceEnt = int32(t.EntNumber)
 	} else {
-		v.prog.Globals.TraceEnt = 0
+		v.Prog.Globals.TraceEnt = 0
 	}
 	return nil
 }
 
-var (
-	checkpvs []byte // to cache results
-)
-
-func init() {
-	checkpvs = make([]byte, bsp.MaxMapLeafs/8)
-}
-
 func (v *virtualMachine) newcheckclient(check int, s *Server) int {
 	// cycle to the next one
 	if check < 1 {
 		check = 1
 	}
-	if check > svs.maxClients {
-		check = svs.maxClients
+	if check > s.svs.maxClients {
+		check = s.svs.maxClients
 	}
 
 	i := check + 1
-	if check == svs.maxClients {
+	if check == s.svs.maxClients {
 		i = 1
 	}
 	ent := 0
 
 	for ; ; i++ {
-		if i == svs.maxClients+1 {
+		if i == s.svs.maxClients+1 {
 			i = 1
 		}
 
@@ -734,7 +713,7 @@ func (v *virtualMachine) newcheckclient(check int, s *Server) int {
 		if s.edicts[ent].Free {
 			continue
 		}
-		ev := entvars.Get(ent)
+		ev := s.entvars.Get(ent)
 		if ev.Health <= 0 {
 			continue
 		}
@@ -746,14 +725,14 @@ func (v *virtualMachine) newcheckclient(check int, s *Server) int {
 		break
 	}
 
-	ev := entvars.Get(ent)
+	ev := s.entvars.Get(ent)
 	// get the PVS for the entity
 	org := vec.Add(ev.Origin, ev.ViewOfs)
 	leaf, _ := s.worldModel.PointInLeaf(org)
 	pvs := s.worldModel.LeafPVS(leaf)
 
 	// we care only about the first (len(s.worldModel.Leafs)+7)/8 bytes
-	copy(checkpvs, pvs)
+	copy(s.checkPVS, pvs)
 	return i
 }
 
@@ -771,14 +750,14 @@ func (v *virtualMachine) checkClient(s *Server) error {
 
 	// return check if it might be visible
 	ent := s.lastCheck
-	if s.edicts[ent].Free || entvars.Get(ent).Health <= 0 {
-		v.prog.Globals.Return[0] = 0
+	if s.edicts[ent].Free || s.entvars.Get(ent).Health <= 0 {
+		v.Prog.Globals.Return[0] = 0
 		return nil
 	}
 
 	// if current entity can't possibly see the check entity, return 0
-	self := int(v.prog.Globals.Self)
-	es := entvars.Get(self)
+	self := int(v.Prog.Globals.Self)
+	es := s.entvars.Get(self)
 	view := vec.Add(es.Origin, es.ViewOfs)
 	leaf, _ := s.worldModel.PointInLeaf(view)
 	leafNum := -2
@@ -791,77 +770,80 @@ func (v *virtualMachine) checkClient(s *Server) error {
 		slog.Warn("checkclient: Got leafnum -2", slog.Int("len(leafs)", len(s.worldModel.Leafs)))
 	}
 
-	if (leafNum < 0) || (checkpvs[leafNum/8]&(1<<(uint(leafNum)&7)) == 0) {
-		v.prog.Globals.Return[0] = 0
+	if (leafNum < 0) || (s.checkPVS[leafNum/8]&(1<<(uint(leafNum)&7)) == 0) {
+		v.Prog.Globals.Return[0] = 0
 		return nil
 	}
 
 	// might be able to see it
-	v.prog.Globals.Return[0] = int32(ent)
+	v.Prog.Globals.Return[0] = int32(ent)
 	return nil
 }
 
 // Sends text over to the client's execution buffer
 func (v *virtualMachine) stuffCmd(s *Server) error {
-	entnum := int(v.prog.Globals.Parm0[0])
-	if entnum < 1 || entnum > svs.maxClients {
+	entnum := int(v.Prog.Globals.Parm0[0])
+	if entnum < 1 || entnum > s.svs.maxClients {
 		slog.Error("Parm 0 not a client")
-		v.abort()
+		v.Abort()
 		return errProgram
 	}
-	str, err := v.prog.String(v.prog.Globals.Parm1[0])
+	str, err := v.Prog.String(v.Prog.Globals.Parm1[0])
 	if err != nil {
 		slog.Error("stuffcmd: no string")
-		v.abort()
+		v.Abort()
 		return errProgram
 	}
 
-	c := sv_clients[entnum-1]
-	c.msg.WriteByte(svc.StuffText)
-	c.msg.WriteString(str)
+	c := s.clients[entnum-1]
+	svc.WriteStuffText(str, &c.msg)
 	return nil
 }
 
 // Sends text over to the client's execution buffer
 func (v *virtualMachine) localCmd(s *Server) error {
-	str, err := v.prog.String(v.prog.Globals.Parm0[0])
+	str, err := v.Prog.String(v.Prog.Globals.Parm0[0])
 	if err != nil {
 		slog.Error("localcmd: no string")
-		v.abort()
+		v.Abort()
 		return errProgram
 	}
-	cbuf.AddText(str)
+	s.commands.AddText(str)
 	return nil
 }
 
 func (v *virtualMachine) cvar(s *Server) error {
-	str, err := v.prog.String(v.prog.Globals.Parm0[0])
+	str, err := v.Prog.String(v.Prog.Globals.Parm0[0])
 	if err != nil {
 		slog.Error("PF_cvar: no string")
-		v.abort()
+		v.Abort()
 		return errProgram
 	}
 	f := func(n string) float32 {
+		if (n == "fraglimit" || n == "timelimit") && !s.match.limitsActive() {
+			// warmup does not end the map
+			return 0
+		}
 		if cv, ok := (*v.commandVars)[n]; ok {
 			return cv.Value()
 		}
 		return 0
 	}
-	v.prog.Globals.Returnf()[0] = f(str)
+	v.Prog.Globals.Returnf()[0] = f(str)
 	return nil
 }
 
 func (v *virtualMachine) cvarSet(s *Server) error {
-	name, err := v.prog.String(v.prog.Globals.Parm0[0])
+	name, err := v.Prog.String(v.Prog.Globals.Parm0[0])
 	if err != nil {
 		slog.Error("PF_cvar_set: no name string")
-		v.abort()
+		v.Abort()
 		return errProgram
 	}
-	val, err := v.prog.String(v.prog.Globals.Parm1[0])
+	val, err := v.Prog.String(v.Prog.Globals.Parm1[0])
 	if err != nil {
 		slog.Error("PF_cvar_set: no val string")
-		v.abort()
+		v.Abort()
 		return errProgram
 	}
 	if cv, ok := (*v.commandVars)[name]; ok {
@@ -875,14 +857,14 @@ func (v *virtualMachine) cvarSet(s *Server) error {
 // Returns a chain of entities that have origins within a spherical area
 func (v *virtualMachine) findRadius(s *Server) error {
 	chain := int32(0)
-	org := vec.VFromA(*v.prog.Globals.Parm0f())
-	rad := v.prog.RawGlobalsF[progs.OffsetParm1]
+	org := vec.VFromA(*v.Prog.Globals.Parm0f())
+	rad := v.Prog.RawGlobalsF[progs.OffsetParm1]
 
 	for ent := 1; ent < s.numEdicts; ent++ {
 		if s.edicts[ent].Free {
 			continue
 		}
-		ev := entvars.Get(ent)
+		ev := s.entvars.Get(ent)
 		if ev.Solid == SOLID_NOT {
 			continue
 		}
@@ -898,13 +880,13 @@ func (v *virtualMachine) findRadius(s *Server) error {
 		chain = int32(ent)
 	}
 
-	v.prog.Globals.Return[0] = chain
+	v.Prog.Globals.Return[0] = chain
 	return nil
 }
 
 // TODO
 func (v *virtualMachine) ftos(s *Server) error {
-	ve := v.prog.RawGlobalsF[progs.OffsetParm0]
+	ve := v.Prog.RawGlobalsF[progs.OffsetParm0]
 	st := func() string {
 		iv := int(ve)
 		if ve == float32(iv) {
@@ -912,22 +894,22 @@ func (v *virtualMachine) ftos(s *Server) error {
 		}
 		return fmt.Sprintf("%5.1f", ve)
 	}()
-	v.prog.Globals.Return[0] = v.prog.AddString(st)
+	v.Prog.Globals.Return[0] = v.Prog.AddString(st)
 	return nil
 }
 
 // TODO
 func (v *virtualMachine) fabs(s *Server) error {
-	f := v.prog.RawGlobalsF[progs.OffsetParm0]
-	v.prog.Globals.Returnf()[0] = math32.Abs(f)
+	f := v.Prog.RawGlobalsF[progs.OffsetParm0]
+	v.Prog.Globals.Returnf()[0] = math32.Abs(f)
 	return nil
 }
 
 // TODO
 func (v *virtualMachine) vtos(s *Server) error {
-	p := *v.prog.Globals.Parm0f()
+	p := *v.Prog.Globals.Parm0f()
 	st := fmt.Sprintf("'%5.1f %5.1f %5.1f'", p[0], p[1], p[2])
-	v.prog.Globals.Return[0] = v.prog.AddString(st)
+	v.Prog.Globals.Return[0] = v.Prog.AddString(st)
 	return nil
 }
 
@@ -936,69 +918,74 @@ func (v *virtualMachine) spawn(s *Server) error {
 	if err != nil {
 		return err
 	}
-	v.prog.Globals.Return[0] = int32(ed)
+	v.Prog.Globals.Return[0] = int32(ed)
 	return nil
 }
 
 func (v *virtualMachine) remove(s *Server) error {
-	ed := v.prog.Globals.Parm0[0]
+	ed := v.Prog.Globals.Parm0[0]
 	v.edictFree(int(ed), s)
 	return nil
 }
 
 func (v *virtualMachine) find(s *Server) error {
-	e := v.prog.Globals.Parm0[0]
-	f := v.prog.Globals.Parm1[0]
-	st, err := v.prog.String(v.prog.Globals.Parm2[0])
+	e := v.Prog.Globals.Parm0[0]
+	f := v.Prog.Globals.Parm1[0]
+	st, err := v.Prog.String(v.Prog.Globals.Parm2[0])
 	if err != nil {
 		slog.Error("PF_Find: bad search string")
-		v.abort()
+		v.Abort()
 		return errProgram
 	}
 	for e++; int(e) < s.numEdicts; e++ {
 		if s.edicts[e].Free {
 			continue
 		}
-		ti := entvars.RawI(e, f)
-		t, err := v.prog.String(ti)
+		ti, err := s.entvars.Load(e, f)
+		if err != nil {
+			slog.Error("PF_Find", slog.Any("err", err))
+			v.Abort()
+			return errProgram
+		}
+		t, err := v.Prog.String(ti)
 		if err != nil {
 			continue
 		}
 		if t == st {
-			v.prog.Globals.Return[0] = int32(e)
+			v.Prog.Globals.Return[0] = int32(e)
 			return nil
 		}
 	}
-	v.prog.Globals.Return[0] = 0
+	v.Prog.Globals.Return[0] = 0
 	return nil
 }
 
 func (v *virtualMachine) finaleFinished(s *Server) error {
 	// Used by 2021 release
 	// Expected to return a bool
-	v.prog.Globals.Return[0] = 0
+	v.Prog.Globals.Return[0] = 0
 	return nil
 }
 
 // precache_file is only used to copy  files with qcc, it does nothing
 func (v *virtualMachine) precacheFile(s *Server) error {
-	v.prog.Globals.Return[0] = v.prog.Globals.Parm0[0]
+	v.Prog.Globals.Return[0] = v.Prog.Globals.Parm0[0]
 	return nil
 }
 
 func (v *virtualMachine) precacheSound(s *Server) error {
 	if s.state != ServerStateLoading {
 		slog.Error("PF_Precache_*: Precache can only be done in spawn functions")
-		v.abort()
+		v.Abort()
 		return errProgram
 	}
 
-	si := v.prog.Globals.Parm0[0]
-	v.prog.Globals.Return[0] = si
-	st, err := v.prog.String(si)
+	si := v.Prog.Globals.Parm0[0]
+	v.Prog.Globals.Return[0] = si
+	st, err := v.Prog.String(si)
 	if err != nil {
 		slog.Error("precacheSound: Bad string", slog.Any("err", err))
-		v.abort()
+		v.Abort()
 		return errProgram
 	}
 
@@ -1013,9 +1000,9 @@ func (v *virtualMachine) precacheSound(s *Server) error {
 	if exist(st) {
 		return nil
 	}
-	if len(s.soundPrecache) >= 2048 {
-		slog.Error("PF_precache_sound: overflow")
-		v.abort()
+	if limit := protocol.MaxLimits(s.protocol).Sounds; len(s.soundPrecache) >= limit {
+		slog.Error("PF_precache_sound: overflow", slog.Int("protocol", s.protocol), slog.Int("limit", limit))
+		v.Abort()
 		return errProgram
 	}
 	s.soundPrecache = append(s.soundPrecache, st)
@@ -1025,16 +1012,16 @@ func (v *virtualMachine) precacheSound(s *Server) error {
 func (v *virtualMachine) precacheModel(s *Server) error {
 	if s.state != ServerStateLoading {
 		slog.Error("PF_Precache_*: Precache can only be done in spawn functions")
-		v.abort()
+		v.Abort()
 		return errProgram
 	}
 
-	si := v.prog.Globals.Parm0[0]
-	v.prog.Globals.Return[0] = si
-	st, err := v.prog.String(si)
+	si := v.Prog.Globals.Parm0[0]
+	v.Prog.Globals.Return[0] = si
+	st, err := v.Prog.String(si)
 	if err != nil {
 		slog.Error("precacheModel: Bad string", slog.Any("err", err))
-		v.abort()
+		v.Abort()
 		return errProgram
 	}
 
@@ -1049,14 +1036,14 @@ func (v *virtualMachine) precacheModel(s *Server) error {
 	if exist(st) {
 		return nil
 	}
-	if len(s.modelPrecache) >= 2048 {
-		slog.Error("PF_precache_sound: overflow")
-		v.abort()
+	if limit := protocol.MaxLimits(s.protocol).Models; len(s.modelPrecache) >= limit {
+		slog.Error("PF_precache_model: overflow", slog.Int("protocol", s.protocol), slog.Int("limit", limit))
+		v.Abort()
 		return errProgram
 	}
 	s.modelPrecache = append(s.modelPrecache, st)
 
-	m, err := svLoadModel(st)
+	m, err := s.loadModel(st)
 	if err != nil {
 		slog.Error("Model could not be loaded", slog.String("model", st), slog.Any("err", err))
 		return nil
@@ -1065,11 +1052,8 @@ func (v *virtualMachine) precacheModel(s *Server) error {
 	return nil
 }
 
-// create in SpawnServer
-var sv_models map[string]model.Model
-
-func svLoadModel(name string) (model.Model, error) {
-	m, ok := sv_models[name]
+func (s *Server) loadModel(name string) (model.Model, error) {
+	m, ok := s.modelCache[name]
 	if ok {
 		return m, nil
 	}
@@ -1078,9 +1062,9 @@ func svLoadModel(name string) (model.Model, error) {
 		return nil, err
 	}
 	for _, m := range mods {
-		sv_models[m.Name()] = m
+		s.modelCache[m.Name()] = m
 	}
-	m, ok = sv_models[name]
+	m, ok = s.modelCache[name]
 	if ok {
 		return m, nil
 	}
@@ -1093,28 +1077,28 @@ func (v *virtualMachine) coredump(s *Server) error {
 }
 
 func (v *virtualMachine) eprint(s *Server) error {
-	s.edictPrint(int(v.prog.Globals.Parm0[0]))
+	s.edictPrint(int(v.Prog.Globals.Parm0[0]))
 	return nil
 }
 
 func (v *virtualMachine) traceOn(s *Server) error {
-	v.trace = true
+	v.Trace = true
 	return nil
 }
 
 func (v *virtualMachine) traceOff(s *Server) error {
-	v.trace = false
+	v.Trace = false
 	return nil
 }
 
 func (v *virtualMachine) walkMove(s *Server) error {
-	ent := int(v.prog.Globals.Self)
-	yaw := v.prog.Globals.Parm0f()[0]
-	dist := v.prog.Globals.Parm1f()[0]
-	ev := entvars.Get(ent)
+	ent := int(v.Prog.Globals.Self)
+	yaw := v.Prog.Globals.Parm0f()[0]
+	dist := v.Prog.Globals.Parm1f()[0]
+	ev := s.entvars.Get(ent)
 
 	if int(ev.Flags)&(FL_ONGROUND|FL_FLY|FL_SWIM) == 0 {
-		(*(v.prog.Globals.Returnf()))[0] = 0
+		(*(v.Prog.Globals.Returnf()))[0] = 0
 		return nil
 	}
 
@@ -1124,28 +1108,28 @@ func (v *virtualMachine) walkMove(s *Server) error {
 	move := vec.Vec3{co * dist, si * dist, 0}
 
 	// save program state, because monsterMoveStep may call other progs
-	oldf := v.xfunction
-	oldself := v.prog.Globals.Self
+	oldf := v.XFunction
+	oldself := v.Prog.Globals.Self
 
 	r, err := v.monsterMoveStep(ent, move, true, s)
 	if err != nil {
 		return err
 	}
 	if r {
-		(*(v.prog.Globals.Returnf()))[0] = 1
+		(*(v.Prog.Globals.Returnf()))[0] = 1
 	} else {
-		(*(v.prog.Globals.Returnf()))[0] = 0
+		(*(v.Prog.Globals.Returnf()))[0] = 0
 	}
 
 	// restore program state
-	v.xfunction = oldf
-	v.prog.Globals.Self = oldself
+	v.XFunction = oldf
+	v.Prog.Globals.Self = oldself
 	return nil
 }
 
 func (v *virtualMachine) dropToFloor(s *Server) error {
-	ent := int(v.prog.Globals.Self)
-	ev := entvars.Get(ent)
+	ent := int(v.Prog.Globals.Self)
+	ev := s.entvars.Get(ent)
 	start := vec.VFromA(ev.Origin)
 	mins := vec.VFromA(ev.Mins)
 	maxs := vec.VFromA(ev.Maxs)
@@ -1155,7 +1139,7 @@ func (v *virtualMachine) dropToFloor(s *Server) error {
 	t := svMove(start, mins, maxs, end, MOVE_NORMAL, ent, s)
 
 	if t.Fraction == 1 || t.AllSolid {
-		v.prog.Globals.Returnf()[0] = 0
+		v.Prog.Globals.Returnf()[0] = 0
 	} else {
 		ev.Origin = t.EndPos
 		if err := v.LinkEdict(ent, false, s); err != nil {
@@ -1163,15 +1147,15 @@ func (v *virtualMachine) dropToFloor(s *Server) error {
 		}
 		ev.Flags = float32(int(ev.Flags) | FL_ONGROUND)
 		ev.GroundEntity = int32(t.EntNumber)
-		v.prog.Globals.Returnf()[0] = 1
+		v.Prog.Globals.Returnf()[0] = 1
 	}
 	return nil
 }
 
 func (v *virtualMachine) lightStyle(s *Server) error {
-	style := int(v.prog.RawGlobalsF[progs.OffsetParm0])
-	vi := v.prog.Globals.Parm1[0]
-	val, err := v.prog.String(vi)
+	style := int(v.Prog.RawGlobalsF[progs.OffsetParm0])
+	vi := v.Prog.Globals.Parm1[0]
+	val, err := v.Prog.String(vi)
 	if err != nil {
 		slog.Warn("Invalid light style", slog.Any("err", err))
 		return nil
@@ -1184,11 +1168,13 @@ func (v *virtualMachine) lightStyle(s *Server) error {
 		return nil
 	}
 
-	for _, c := range sv_clients {
+	ls := protos.LightStyle_builder{
+		Idx:      int32(style),
+		NewStyle: val,
+	}.Build()
+	for _, c := range s.clients {
 		if c.active || c.spawned {
-			c.msg.WriteChar(svc.LightStyle)
-			c.msg.WriteChar(style)
-			c.msg.WriteString(val)
+			svc.WriteLightStyle(ls, &c.msg)
 		}
 	}
 	return nil
@@ -1196,63 +1182,63 @@ func (v *virtualMachine) lightStyle(s *Server) error {
 
 // TODO
 func (v *virtualMachine) rint(s *Server) error {
-	f := v.prog.RawGlobalsF[progs.OffsetParm0]
-	v.prog.Globals.Returnf()[0] = math.RoundToEven(f)
+	f := v.Prog.RawGlobalsF[progs.OffsetParm0]
+	v.Prog.Globals.Returnf()[0] = math.RoundToEven(f)
 	return nil
 }
 
 // TODO
 func (v *virtualMachine) floor(s *Server) error {
-	f := v.prog.RawGlobalsF[progs.OffsetParm0]
-	v.prog.Globals.Returnf()[0] = math32.Floor(f)
+	f := v.Prog.RawGlobalsF[progs.OffsetParm0]
+	v.Prog.Globals.Returnf()[0] = math32.Floor(f)
 	return nil
 }
 
 // TODO
 func (v *virtualMachine) ceil(s *Server) error {
-	f := v.prog.RawGlobalsF[progs.OffsetParm0]
-	v.prog.Globals.Returnf()[0] = math32.Ceil(f)
+	f := v.Prog.RawGlobalsF[progs.OffsetParm0]
+	v.Prog.Globals.Returnf()[0] = math32.Ceil(f)
 	return nil
 }
 
 func (v *virtualMachine) checkBottom(s *Server) error {
-	entnum := int(v.prog.Globals.Parm0[0])
+	entnum := int(v.Prog.Globals.Parm0[0])
 	f := float32(0)
 	if checkBottom(entnum, s) {
 		f = 1
 	}
-	v.prog.Globals.Returnf()[0] = f
+	v.Prog.Globals.Returnf()[0] = f
 	return nil
 }
 
 // TODO
 // Writes new values for v_forward, v_up, and v_right based on angles makevectors(vector)
 func (v *virtualMachine) makeVectors(s *Server) error {
-	ve := vec.VFromA(*v.prog.Globals.Parm0f())
+	ve := vec.VFromA(*v.Prog.Globals.Parm0f())
 	f, r, u := vec.AngleVectors(ve)
-	v.prog.Globals.VForward = f
-	v.prog.Globals.VRight = r
-	v.prog.Globals.VUp = u
+	v.Prog.Globals.VForward = f
+	v.Prog.Globals.VRight = r
+	v.Prog.Globals.VUp = u
 	return nil
 }
 
 func (v *virtualMachine) pointContents(s *Server) error {
-	ve := vec.VFromA(*v.prog.Globals.Parm0f())
+	ve := vec.VFromA(*v.Prog.Globals.Parm0f())
 	pc := pointContents(ve, s.worldModel)
-	v.prog.Globals.Returnf()[0] = float32(pc)
+	v.Prog.Globals.Returnf()[0] = float32(pc)
 	return nil
 }
 
 func (v *virtualMachine) nextEnt(s *Server) error {
-	i := v.prog.Globals.Parm0[0]
+	i := v.Prog.Globals.Parm0[0]
 	for {
 		i++
 		if int(i) == s.numEdicts {
-			v.prog.Globals.Return[0] = 0
+			v.Prog.Globals.Return[0] = 0
 			return nil
 		}
 		if s.edicts[i].Free {
-			v.prog.Globals.Return[0] = i
+			v.Prog.Globals.Return[0] = i
 			return nil
 		}
 	}
@@ -1260,25 +1246,40 @@ func (v *virtualMachine) nextEnt(s *Server) error {
 
 // Pick a vector for the player to shoot along
 func (v *virtualMachine) aim(s *Server) error {
-	const DAMAGE_AIM = 2
-	ent := int(v.prog.Globals.Parm0[0])
-	ev := entvars.Get(ent)
+	ent := int(v.Prog.Globals.Parm0[0])
+	ev := s.entvars.Get(ent)
 	// variable set but not used
-	// speed := v.prog.RawGlobalsF[progs.OffsetParm1]
+	// speed := v.Prog.RawGlobalsF[progs.OffsetParm1]
 
 	start := vec.VFromA(ev.Origin)
 	start[2] += 20
+	dir := vec.VFromA(v.Prog.Globals.VForward)
+
+	if err := s.rewind(); err != nil {
+		return err
+	}
+	d := v.aimDirection(s, ent, start, dir)
+	if err := s.restore(); err != nil {
+		return err
+	}
+	*v.Prog.Globals.Returnf() = d
+	return nil
+}
+
+// aimDirection returns the direction in which ent at start should shoot when
+// looking along dir.
+func (v *virtualMachine) aimDirection(s *Server, ent int, start, dir vec.Vec3) vec.Vec3 {
+	const DAMAGE_AIM = 2
+	ev := s.entvars.Get(ent)
 
 	// try sending a trace straight
-	dir := vec.VFromA(v.prog.Globals.VForward)
 	end := vec.Add(start, vec.Scale(2048, dir))
 	tr := svMove(start, vec.Vec3{}, vec.Vec3{}, end, MOVE_NORMAL, ent, s)
 	if tr.EntPointer {
-		tev := entvars.Get(int(tr.EntNumber))
+		tev := s.entvars.Get(int(tr.EntNumber))
 		if tev.TakeDamage == DAMAGE_AIM &&
 			(!cvars.TeamPlay.Bool() || tev.Team <= 0 || ev.Team != tev.Team) {
-			*v.prog.Globals.Returnf() = v.prog.Globals.VForward
-			return nil
+			return vec.Vec3(v.Prog.Globals.VForward)
 		}
 	}
 
@@ -1288,7 +1289,7 @@ func (v *virtualMachine) aim(s *Server) error {
 	bestent := -1
 
 	for check := 1; check < s.numEdicts; check++ {
-		cev := entvars.Get(check)
+		cev := s.entvars.Get(check)
 		if cev.TakeDamage != DAMAGE_AIM {
 			continue
 		}
@@ -1306,7 +1307,7 @@ func (v *virtualMachine) aim(s *Server) error {
 		}
 		dir = vec.Sub(end, start)
 		dir = dir.Normalize()
-		vforward := v.prog.Globals.VForward
+		vforward := v.Prog.Globals.VForward
 		dist := vec.Dot(dir, vforward)
 		if dist < bestdist {
 			// to far to turn
@@ -1321,26 +1322,24 @@ func (v *virtualMachine) aim(s *Server) error {
 	}
 
 	if bestent >= 0 {
-		bev := entvars.Get(bestent)
+		bev := s.entvars.Get(bestent)
 		borigin := bev.Origin
 		eorigin := ev.Origin
 		dir := vec.Sub(borigin, eorigin)
-		vforward := vec.Vec3(v.prog.Globals.VForward)
+		vforward := vec.Vec3(v.Prog.Globals.VForward)
 		dist := vec.Dot(dir, vforward)
 		end := vec.Scale(dist, vforward)
 		end[2] = dir[2]
 		end = end.Normalize()
-		*v.prog.Globals.Returnf() = end
-	} else {
-		*v.prog.Globals.Returnf() = bestdir
+		return end
 	}
-	return nil
+	return bestdir
 }
 
 // This was a major timewaster in progs
 func (v *virtualMachine) changeYaw(s *Server) error {
-	ent := int(v.prog.Globals.Self)
-	changeYaw(ent)
+	ent := int(v.Prog.Globals.Self)
+	s.changeYaw(ent)
 	return nil
 }
 
@@ -1349,284 +1348,189 @@ const (
 	MSG_ONE              // reliable to one
 	MSG_ALL              // reliable to all
 	MSG_INIT             // write to the init string
+
+	MSG_CSQC = 16 // write to the payload of the next csqcevent
 )
 
-func (v *virtualMachine) writeClient() (*SVClient, error) {
-	entnum := int(v.prog.Globals.MsgEntity)
-	if entnum < 1 || entnum > svs.maxClients {
+func (v *virtualMachine) writeClient(s *Server) (*SVClient, error) {
+	entnum := int(v.Prog.Globals.MsgEntity)
+	if entnum < 1 || entnum > s.svs.maxClients {
 		slog.Error("WriteDest: not a client")
-		v.abort()
+		v.Abort()
 		return nil, errProgram
 	}
-	return sv_clients[entnum-1], nil
+	return s.clients[entnum-1], nil
 }
 
-func (v *virtualMachine) writeByte(s *Server) error {
-	dest := int(v.prog.RawGlobalsF[progs.OffsetParm0])
-	msg := v.prog.RawGlobalsF[progs.OffsetParm1]
-	switch dest {
+// writeDest returns the message selected by the destination in the first
+// argument.
+func (v *virtualMachine) writeDest(s *Server) (*svc.Message, error) {
+	switch int(v.Prog.RawGlobalsF[progs.OffsetParm0]) {
 	case MSG_ONE:
-		if c, err := v.writeClient(); err != nil {
-			return err
-		} else {
-			c.msg.WriteByte(int(msg))
+		c, err := v.writeClient(s)
+		if err != nil {
+			return nil, err
 		}
+		return &c.msg, nil
 	case MSG_INIT:
-		s.signon.WriteByte(int(msg))
+		return &s.signon, nil
 	case MSG_BROADCAST:
-		s.datagram.WriteByte(int(msg))
+		return &s.datagram, nil
 	case MSG_ALL:
-		s.reliableDatagram.WriteByte(int(msg))
-	default:
-		slog.Error("WriteDest: bad destination")
-		v.abort()
-		return errProgram
+		return &s.reliableDatagram, nil
+	case MSG_CSQC:
+		return &s.csqcEvent, nil
+	}
+	slog.Error("WriteDest: bad destination")
+	v.Abort()
+	return nil, errProgram
+}
+
+func (v *virtualMachine) writeByte(s *Server) error {
+	m, err := v.writeDest(s)
+	if err != nil {
+		return err
 	}
+	m.WriteByte(int(v.Prog.RawGlobalsF[progs.OffsetParm1]))
 	return nil
 }
 
 func (v *virtualMachine) writeChar(s *Server) error {
-	dest := int(v.prog.RawGlobalsF[progs.OffsetParm0])
-	msg := v.prog.RawGlobalsF[progs.OffsetParm1]
-	switch dest {
-	case MSG_ONE:
-		if c, err := v.writeClient(); err != nil {
-			return err
-		} else {
-			c.msg.WriteChar(int(msg))
-		}
-	case MSG_INIT:
-		s.signon.WriteChar(int(msg))
-	case MSG_BROADCAST:
-		s.datagram.WriteChar(int(msg))
-	case MSG_ALL:
-		s.reliableDatagram.WriteChar(int(msg))
-	default:
-		slog.Error("WriteDest: bad destination")
-		v.abort()
-		return errProgram
+	m, err := v.writeDest(s)
+	if err != nil {
+		return err
 	}
+	m.WriteChar(int(v.Prog.RawGlobalsF[progs.OffsetParm1]))
 	return nil
 }
 
 func (v *virtualMachine) writeShort(s *Server) error {
-	dest := int(v.prog.RawGlobalsF[progs.OffsetParm0])
-	msg := v.prog.RawGlobalsF[progs.OffsetParm1]
-	switch dest {
-	case MSG_ONE:
-		if c, err := v.writeClient(); err != nil {
-			return err
-		} else {
-			c.msg.WriteShort(int(msg))
-		}
-	case MSG_INIT:
-		s.signon.WriteShort(int(msg))
-	case MSG_BROADCAST:
-		s.datagram.WriteShort(int(msg))
-	case MSG_ALL:
-		s.reliableDatagram.WriteShort(int(msg))
-	default:
-		slog.Error("WriteDest: bad destination")
-		v.abort()
-		return errProgram
+	m, err := v.writeDest(s)
+	if err != nil {
+		return err
 	}
+	m.WriteShort(int(v.Prog.RawGlobalsF[progs.OffsetParm1]))
 	return nil
 }
 
 func (v *virtualMachine) writeLong(s *Server) error {
-	dest := int(v.prog.RawGlobalsF[progs.OffsetParm0])
-	msg := v.prog.RawGlobalsF[progs.OffsetParm1]
-	switch dest {
-	case MSG_ONE:
-		if c, err := v.writeClient(); err != nil {
-			return err
-		} else {
-			c.msg.WriteLong(int(msg))
-		}
-	case MSG_INIT:
-		s.signon.WriteLong(int(msg))
-	case MSG_BROADCAST:
-		s.datagram.WriteLong(int(msg))
-	case MSG_ALL:
-		s.reliableDatagram.WriteLong(int(msg))
-	default:
-		slog.Error("WriteDest: bad destination")
-		v.abort()
-		return errProgram
+	m, err := v.writeDest(s)
+	if err != nil {
+		return err
 	}
+	m.WriteLong(int(v.Prog.RawGlobalsF[progs.OffsetParm1]))
 	return nil
 }
 
 func (v *virtualMachine) writeAngle(s *Server) error {
-	dest := int(v.prog.RawGlobalsF[progs.OffsetParm0])
-	msg := v.prog.RawGlobalsF[progs.OffsetParm1]
-	switch dest {
-	case MSG_ONE:
-		if c, err := v.writeClient(); err != nil {
-			return err
-		} else {
-			c.msg.WriteAngle(msg, s.protocolFlags)
-		}
-	case MSG_INIT:
-		s.signon.WriteAngle(msg, s.protocolFlags)
-	case MSG_BROADCAST:
-		s.datagram.WriteAngle(msg, s.protocolFlags)
-	case MSG_ALL:
-		s.reliableDatagram.WriteAngle(msg, s.protocolFlags)
-	default:
-		slog.Error("WriteDest: bad destination")
-		v.abort()
-		return errProgram
+	m, err := v.writeDest(s)
+	if err != nil {
+		return err
 	}
+	m.WriteAngle(v.Prog.RawGlobalsF[progs.OffsetParm1], s.protocolFlags)
 	return nil
 }
 
 func (v *virtualMachine) writeCoord(s *Server) error {
-	dest := int(v.prog.RawGlobalsF[progs.OffsetParm0])
-	msg := v.prog.RawGlobalsF[progs.OffsetParm1]
-	switch dest {
-	case MSG_ONE:
-		if c, err := v.writeClient(); err != nil {
-			return err
-		} else {
-			c.msg.WriteCoord(msg, s.protocolFlags)
-		}
-	case MSG_INIT:
-		s.signon.WriteCoord(msg, s.protocolFlags)
-	case MSG_BROADCAST:
-		s.datagram.WriteCoord(msg, s.protocolFlags)
-	case MSG_ALL:
-		s.reliableDatagram.WriteCoord(msg, s.protocolFlags)
-	default:
-		slog.Error("WriteDest: bad destination")
-		v.abort()
-		return errProgram
+	m, err := v.writeDest(s)
+	if err != nil {
+		return err
 	}
+	m.WriteCoord(v.Prog.RawGlobalsF[progs.OffsetParm1], s.protocolFlags)
 	return nil
 }
 
 func (v *virtualMachine) writeString(s *Server) error {
-	dest := int(v.prog.RawGlobalsF[progs.OffsetParm0])
-	i := v.prog.Globals.Parm1[0]
-	msg, err := v.prog.String(i)
+	msg, err := v.Prog.String(v.Prog.Globals.Parm1[0])
 	if err != nil {
 		slog.Error("PF_WriteString: bad string")
-		v.abort()
+		v.Abort()
 		return errProgram
 	}
-	switch dest {
-	case MSG_ONE:
-		if c, err := v.writeClient(); err != nil {
-			return err
-		} else {
-			c.msg.WriteString(msg)
-		}
-	case MSG_INIT:
-		s.signon.WriteString(msg)
-	case MSG_BROADCAST:
-		s.datagram.WriteString(msg)
-	case MSG_ALL:
-		s.reliableDatagram.WriteString(msg)
-	default:
-		slog.Error("WriteDest: bad destination")
-		v.abort()
-		return errProgram
+	m, err := v.writeDest(s)
+	if err != nil {
+		return err
 	}
+	m.WriteString(msg)
 	return nil
 }
 
 func (v *virtualMachine) writeEntity(s *Server) error {
-	dest := int(v.prog.RawGlobalsF[progs.OffsetParm0])
-	msg := v.prog.RawGlobalsF[progs.OffsetParm1]
-	switch dest {
-	case MSG_ONE:
-		if c, err := v.writeClient(); err != nil {
-			return err
-		} else {
-			c.msg.WriteShort(int(msg))
-		}
-	case MSG_INIT:
-		s.signon.WriteShort(int(msg))
-	case MSG_BROADCAST:
-		s.datagram.WriteShort(int(msg))
-	case MSG_ALL:
-		s.reliableDatagram.WriteShort(int(msg))
-	default:
-		slog.Error("WriteDest: bad destination")
-		v.abort()
+	m, err := v.writeDest(s)
+	if err != nil {
+		return err
+	}
+	m.WriteShort(int(v.Prog.RawGlobalsF[progs.OffsetParm1]))
+	return nil
+}
+
+// csqcEvent sends the payload written to MSG_CSQC to CSQC_Parse_Event of the
+// clients selected by the destination in the first argument.
+// void(float to) csqcevent
+func (v *virtualMachine) csqcEvent(s *Server) error {
+	if int(v.Prog.RawGlobalsF[progs.OffsetParm0]) == MSG_CSQC {
+		slog.Error("PF_CSQCEvent: bad destination")
+		v.Abort()
 		return errProgram
 	}
+	m, err := v.writeDest(s)
+	if err != nil {
+		return err
+	}
+	defer s.csqcEvent.ClearMessage()
+	if s.protocol != protocol.GoQuake {
+		slog.Debug("PF_CSQCEvent: protocol has no csqc events")
+		return nil
+	}
+	if s.csqcEvent.Len() > 0xffff {
+		slog.Warn("PF_CSQCEvent: payload too long", slog.Int("len", s.csqcEvent.Len()))
+		return nil
+	}
+	svc.WriteCSQCEvent(s.csqcEvent.Bytes(), s.protocol, s.protocolFlags, m)
 	return nil
 }
 
 func (v *virtualMachine) makeStatic(s *Server) error {
-	bits := 0
-
-	ent := int(v.prog.Globals.Parm0[0])
-	e := &s.edicts[ent]
+	ent := int(v.Prog.Globals.Parm0[0])
+	e := s.edicts[ent]
 
 	// don't send invisible static entities
 	if e.Alpha == svc.EntityAlphaZero {
 		v.edictFree(ent, s)
 		return nil
 	}
-	ev := entvars.Get(ent)
+	ev := s.entvars.Get(ent)
 
-	m, err := v.prog.String(ev.Model)
+	m, err := v.Prog.String(ev.Model)
 	if err != nil {
 		slog.Warn("Error in PF_makstatic", slog.Any("err", err))
 		return nil
 	}
 	mi := s.ModelIndex(m)
 	frame := int(ev.Frame)
-	if s.protocol == protocol.NetQuake {
-		if mi&0xFF00 != 0 ||
-			frame&0xFF00 != 0 {
-			v.edictFree(ent, s)
-			// can't display the correct model & frame, so don't show it at all
-			return nil
-		}
-	} else {
-		if mi&0xFF00 != 0 {
-			bits |= svc.EntityBaselineLargeModel
-		}
-		if frame&0xFF00 != 0 {
-			bits |= svc.EntityBaselineLargeFrame
-		}
-		if e.Alpha != svc.EntityAlphaDefault {
-			bits |= svc.EntityBaselineAlpha
-		}
-	}
-
-	if bits != 0 {
-		s.signon.WriteByte(svc.SpawnStatic2)
-		s.signon.WriteByte(bits)
-	} else {
-		s.signon.WriteByte(svc.SpawnStatic)
-	}
-
-	if bits&svc.EntityBaselineLargeModel != 0 {
-		s.signon.WriteShort(mi)
-	} else {
-		s.signon.WriteByte(mi)
-	}
-
-	if bits&svc.EntityBaselineLargeFrame != 0 {
-		s.signon.WriteShort(frame)
-	} else {
-		s.signon.WriteByte(frame)
-	}
-
-	s.signon.WriteByte(int(ev.ColorMap))
-	s.signon.WriteByte(int(ev.Skin))
-	for i := 0; i < 3; i++ {
-		s.signon.WriteCoord(ev.Origin[i], s.protocolFlags)
-		s.signon.WriteAngle(ev.Angles[i], s.protocolFlags)
+	if s.protocol == protocol.NetQuake && (mi&^0xFF != 0 || frame&0xFF00 != 0) {
+		v.edictFree(ent, s)
+		// can't display the correct model & frame, so don't show it at all
+		return nil
 	}
 
-	if bits&svc.EntityBaselineAlpha != 0 {
-		s.signon.WriteByte(int(e.Alpha))
-	}
+	svc.WriteSpawnStatic(protos.Baseline_builder{
+		ModelIndex: int32(mi),
+		Frame:      int32(frame),
+		ColorMap:   int32(ev.ColorMap),
+		Skin:       int32(ev.Skin),
+		Origin: protos.Coord_builder{
+			X: ev.Origin[0],
+			Y: ev.Origin[1],
+			Z: ev.Origin[2],
+		}.Build(),
+		Angles: protos.Coord_builder{
+			X: ev.Angles[0],
+			Y: ev.Angles[1],
+			Z: ev.Angles[2],
+		}.Build(),
+		Alpha: int32(e.Alpha),
+	}.Build(), s.protocol, s.protocolFlags, &s.signon)
 
 	// throw the entity away now
 	v.edictFree(ent, s)
@@ -1634,25 +1538,25 @@ func (v *virtualMachine) makeStatic(s *Server) error {
 }
 
 func (v *virtualMachine) setSpawnParms(s *Server) error {
-	i := int(v.prog.Globals.Parm0[0])
-	if i < 1 || i > svs.maxClients {
+	i := int(v.Prog.Globals.Parm0[0])
+	if i < 1 || i > s.svs.maxClients {
 		slog.Error("Entity is not a client")
-		v.abort()
+		v.Abort()
 		return errProgram
 	}
 
 	// copy spawn parms out of the client_t
-	client := sv_clients[i-1]
+	client := s.clients[i-1]
 
 	for i := 0; i < NUM_SPAWN_PARMS; i++ {
-		v.prog.Globals.Parm[i] = client.spawnParams[i]
+		v.Prog.Globals.Parm[i] = client.spawnParams[i]
 	}
 	return nil
 }
 
 func (v *virtualMachine) fixme(s *Server) error {
 	slog.Error("unimplemented builtin")
-	v.abort()
+	v.Abort()
 	return errProgram
 }
 
@@ -1663,14 +1567,15 @@ func (v *virtualMachine) changeLevel(s *Server) error {
 	}
 	v.changeLevelIssued = true
 
-	i := v.prog.Globals.Parm0[0]
-	st, err := v.prog.String(i)
+	i := v.Prog.Globals.Parm0[0]
+	st, err := v.Prog.String(i)
 	if err != nil {
 		slog.Error("PF_changelevel: bad level name")
-		v.abort()
+		v.Abort()
 		return errProgram
 	}
-	cbuf.AddText(fmt.Sprintf("changelevel %s\n", st))
+	s.match.state = matchIntermission
+	s.commands.AddText(fmt.Sprintf("changelevel %s\n", s.rotationLevel(st)))
 	return nil
 }
 
@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"goquake/cvars"
+	"goquake/net"
+	"goquake/progs"
+	"goquake/progs/builtin"
+	"goquake/progs/op"
+	"goquake/progs/progstest"
+	"goquake/protocol"
+	svc "goquake/protocol/server"
+)
+
+const fuzzGlobals = 128
+
+// fuzzProgs builds a progs.dat with a function main running the statements
+// encoded in code. The globals get initialized from data.
+func fuzzProgs(code, data []byte) []byte {
+	st := []progs.Statement{{}}
+	num := int16(len(code)/8 + 2)
+	for ; len(code) >= 8; code = code[8:] {
+		s := progs.Statement{Operator: binary.LittleEndian.Uint16(code) % (op.BITOR + 1)}
+		o := [3]int16{}
+		for i := range o {
+			o[i] = int16(binary.LittleEndian.Uint16(code[2+2*i:]))
+		}
+		// jump offsets are relative and may be negative, the other
+		// operands address globals
+		jump := -1
+		switch s.Operator {
+		case op.IF, op.IFNOT:
+			jump = 1
+		case op.GOTO:
+			jump = 0
+		}
+		for i := range o {
+			if i == jump {
+				o[i] %= num
+			} else {
+				o[i] = int16(uint16(o[i]) % fuzzGlobals)
+			}
+		}
+		s.A, s.B, s.C = o[0], o[1], o[2]
+		st = append(st, s)
+	}
+	st = append(st, progs.Statement{Operator: op.DONE})
+
+	globals := make([]int32, fuzzGlobals)
+	for i := 0; i < len(globals) && len(data) >= 4; i, data = i+1, data[4:] {
+		globals[i] = int32(binary.LittleEndian.Uint32(data))
+	}
+	p := &progstest.Progs{
+		EntityFields: 4,
+		Statements:   st,
+		FieldDefs: []progs.Def{
+			{Type: progs.EV_Float, Offset: 0},
+			{Type: progs.EV_Vector, Offset: 1},
+		},
+		Functions: []progs.Function{{}, {FirstStatement: 1, SName: 1, Locals: 4}},
+		Strings:   []byte("\x00main\x00"),
+		Globals:   globals,
+	}
+	return p.Bytes()
+}
+
+func FuzzExecuteProgram(f *testing.F) {
+	statement := func(o, a, b, c uint16) []byte {
+		return binary.LittleEndian.AppendUint16(
+			binary.LittleEndian.AppendUint16(
+				binary.LittleEndian.AppendUint16(
+					binary.LittleEndian.AppendUint16(nil, o), uint16(a)), uint16(b)), uint16(c))
+	}
+	f.Add(statement(op.ADD_F, 40, 41, 42), []byte{})
+	f.Add(append(statement(op.ADDRESS, 40, 41, 42), statement(op.STOREP_V, 43, 42, 0)...),
+		[]byte{})
+	f.Add(statement(op.LOAD_V, 40, 41, 42), bytes.Repeat([]byte{0xff}, 4*fuzzGlobals))
+	f.Add(statement(op.GOTO, 0, 0, 0), []byte{})
+	f.Add(append(statement(op.ADD_F, 40, 41, 40), statement(op.IF, 40, 0xffff, 0)...),
+		[]byte{})
+	// abort endless loops early
+	cvars.ProgsMaxStatements.SetByString("10000")
+	defer cvars.ProgsMaxStatements.Reset()
+	f.Fuzz(func(t *testing.T, code, data []byte) {
+		p, err := progs.ParseProgs(fuzzProgs(code, data))
+		if err != nil {
+			return
+		}
+		s := NewServer(nil)
+		s.maxEdicts = 4
+		s.progsdat = p
+		s.vm.Prog = p
+		s.allocEdicts()
+		s.vm.ExecuteProgram(1, s)
+	})
+}
+
+func TestCSQCEvent(t *testing.T) {
+	s := lagTestServer(t)
+	s.protocol = protocol.GoQuake
+	call := func(b func(*Server) error, dest, value float32) {
+		t.Helper()
+		s.vm.Prog.RawGlobalsF[progs.OffsetParm0] = dest
+		s.vm.Prog.RawGlobalsF[progs.OffsetParm1] = value
+		if err := b(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	call(s.vm.writeByte, MSG_CSQC, 7)
+	call(s.vm.writeShort, MSG_CSQC, 300)
+	call(s.vm.csqcEvent, MSG_ALL, 0)
+
+	sm, err := svc.ParseServerMessage(net.NewQReader(s.reliableDatagram.Bytes()), s.protocol, s.protocolFlags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmds := sm.GetCmds()
+	if len(cmds) != 1 || !bytes.Equal(cmds[0].GetCsqcEvent(), []byte{7, 0x2c, 0x01}) {
+		t.Errorf("got %v, want a csqc event with payload [7 44 1]", cmds)
+	}
+	if s.csqcEvent.Len() != 0 {
+		t.Errorf("payload not cleared after sending")
+	}
+}
+
+func TestBuiltinTable(t *testing.T) {
+	v := NewVirtualMachine(nil)
+	if len(v.builtins) != builtin.Count {
+		t.Fatalf("server has %d builtins, the table %d", len(v.builtins), builtin.Count)
+	}
+	for i, b := range v.builtins {
+		if (b != nil) != builtin.Implemented(i) {
+			t.Errorf("builtin #%d: implemented %v, table says %v", i, b != nil, builtin.Implemented(i))
+		}
+	}
+}
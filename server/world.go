@@ -27,18 +27,11 @@ type areaNode struct {
 	dist          float32
 }
 
-var (
-	edictToRing map[int]*ring.Ring[int]
-	gArea       *areaNode
-
-	boxHull bsp.Hull
-)
-
 // called after the world model has been loaded, before linking any entities
 func (s *Server) clearWorld() {
-	edictToRing = make(map[int]*ring.Ring[int])
-	initBoxHull()
-	gArea = createAreaNode(0, s.worldModel.Mins(), s.worldModel.Maxs())
+	s.edictToRing = make(map[int]*ring.Ring[int])
+	s.initBoxHull()
+	s.area = createAreaNode(0, s.worldModel.Mins(), s.worldModel.Maxs())
 }
 
 func createAreaNode(depth int, mins, maxs vec.Vec3) *areaNode {
@@ -90,17 +83,17 @@ func createAreaNode(depth int, mins, maxs vec.Vec3) *areaNode {
 // flags ent->v.modified
 // sets ent->v.absmin and ent->v.absmax
 // if touchtriggers, calls prog functions for the intersected triggers
-func (v *virtualMachine) UnlinkEdict(e int) {
-	r, ok := edictToRing[e]
+func (v *virtualMachine) UnlinkEdict(e int, s *Server) {
+	r, ok := s.edictToRing[e]
 	if !ok {
 		return
 	}
 	r.Prev().Unlink(1)
 }
 
-func triggerEdicts(e int, a *areaNode) []int {
+func (s *Server) triggerEdicts(e int, a *areaNode) []int {
 	ret := []int{}
-	ev := entvars.Get(e)
+	ev := s.entvars.Get(e)
 
 	for l := a.triggerEdicts.Next(); l != a.triggerEdicts; l = l.Next() {
 		if l == nil {
@@ -112,7 +105,7 @@ func triggerEdicts(e int, a *areaNode) []int {
 		if touch == e {
 			continue
 		}
-		tv := entvars.Get(touch)
+		tv := s.entvars.Get(touch)
 		if tv == nil || tv.Touch == 0 || tv.Solid != SOLID_TRIGGER {
 			continue
 		}
@@ -132,23 +125,23 @@ func triggerEdicts(e int, a *areaNode) []int {
 	}
 
 	if ev.AbsMax[a.axis] > a.dist {
-		ret = append(ret, triggerEdicts(e, a.children[0])...)
+		ret = append(ret, s.triggerEdicts(e, a.children[0])...)
 	}
 	if ev.AbsMin[a.axis] < a.dist {
-		ret = append(ret, triggerEdicts(e, a.children[1])...)
+		ret = append(ret, s.triggerEdicts(e, a.children[1])...)
 	}
 	return ret
 }
 
 func (v *virtualMachine) touchLinks(e int, a *areaNode, s *Server) error {
-	te := triggerEdicts(e, a)
-	ev := entvars.Get(e)
+	te := s.triggerEdicts(e, a)
+	ev := s.entvars.Get(e)
 
 	for _, touch := range te {
 		if touch == e {
 			continue
 		}
-		tv := entvars.Get(touch)
+		tv := s.entvars.Get(touch)
 		if tv == nil || tv.Touch == 0 || tv.Solid != SOLID_TRIGGER {
 			continue
 		}
@@ -161,18 +154,18 @@ func (v *virtualMachine) touchLinks(e int, a *areaNode, s *Server) error {
 			continue
 		}
 
-		oldSelf := progsdat.Globals.Self
-		oldOther := progsdat.Globals.Other
+		oldSelf := s.progsdat.Globals.Self
+		oldOther := s.progsdat.Globals.Other
 
-		progsdat.Globals.Self = int32(touch)
-		progsdat.Globals.Other = int32(e)
-		progsdat.Globals.Time = s.time
+		s.progsdat.Globals.Self = int32(touch)
+		s.progsdat.Globals.Other = int32(e)
+		s.progsdat.Globals.Time = s.time
 		if err := v.ExecuteProgram(tv.Touch, s); err != nil {
 			return err
 		}
 
-		progsdat.Globals.Self = oldSelf
-		progsdat.Globals.Other = oldOther
+		s.progsdat.Globals.Self = oldSelf
+		s.progsdat.Globals.Other = oldOther
 	}
 	return nil
 }
@@ -182,15 +175,15 @@ func (v *virtualMachine) touchLinks(e int, a *areaNode, s *Server) error {
 // sets the related entvar.absmin and entvar.absmax
 // if touchTriggers calls prog functions for the intersected triggers
 func (v *virtualMachine) LinkEdict(e int, touchTriggers bool, s *Server) error {
-	v.UnlinkEdict(e)
+	v.UnlinkEdict(e, s)
 	if e == 0 {
 		return nil // don't add the world
 	}
-	ed := &s.edicts[e]
+	ed := s.edicts[e]
 	if ed.Free {
 		return nil
 	}
-	ev := entvars.Get(e)
+	ev := s.entvars.Get(e)
 
 	ev.AbsMin[0] = ev.Origin[0] + ev.Mins[0]
 	ev.AbsMin[1] = ev.Origin[1] + ev.Mins[1]
@@ -220,14 +213,14 @@ func (v *virtualMachine) LinkEdict(e int, touchTriggers bool, s *Server) error {
 
 	ed.num_leafs = 0
 	if ev.ModelIndex != 0 {
-		findTouchedLeafs(e, s.worldModel.Node, s.worldModel, ed)
+		s.findTouchedLeafs(e, s.worldModel.Node, s.worldModel, ed)
 	}
 
 	if ev.Solid == SOLID_NOT {
 		return nil
 	}
 
-	node := gArea
+	node := s.area
 	for {
 		if node.axis == -1 {
 			break
@@ -242,7 +235,7 @@ func (v *virtualMachine) LinkEdict(e int, touchTriggers bool, s *Server) error {
 	}
 
 	r := &ring.Ring[int]{Value: e}
-	edictToRing[e] = r
+	s.edictToRing[e] = r
 	if ev.Solid == SOLID_TRIGGER {
 		node.triggerEdicts.Prev().Link(r)
 	} else {
@@ -250,14 +243,14 @@ func (v *virtualMachine) LinkEdict(e int, touchTriggers bool, s *Server) error {
 	}
 
 	if touchTriggers {
-		if err := v.touchLinks(e, gArea, s); err != nil {
+		if err := v.touchLinks(e, s.area, s); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func findTouchedLeafs(e int, node bsp.Node, world *bsp.Model, ed *Edict) {
+func (s *Server) findTouchedLeafs(e int, node bsp.Node, world *bsp.Model, ed *Edict) {
 	if node.Contents() == bsp.CONTENTS_SOLID {
 		return
 	}
@@ -284,13 +277,13 @@ func findTouchedLeafs(e int, node bsp.Node, world *bsp.Model, ed *Edict) {
 	}
 	n := node.(*bsp.MNode)
 	splitplane := n.Plane
-	ev := entvars.Get(e)
+	ev := s.entvars.Get(e)
 	sides := splitplane.BoxOnPlaneSide(vec.VFromA(ev.AbsMin), vec.VFromA(ev.AbsMax))
 	if sides&1 != 0 {
-		findTouchedLeafs(e, n.Children[0], world, ed)
+		s.findTouchedLeafs(e, n.Children[0], world, ed)
 	}
 	if sides&2 != 0 {
-		findTouchedLeafs(e, n.Children[1], world, ed)
+		s.findTouchedLeafs(e, n.Children[1], world, ed)
 	}
 }
 
@@ -303,7 +296,7 @@ type moveClip struct {
 func clipToLinks(a *areaNode, clip *moveClip, s *Server) {
 	for l := a.solidEdicts.Next(); l != a.solidEdicts; l = l.Next() {
 		touch := l.Value
-		tv := entvars.Get(touch)
+		tv := s.entvars.Get(touch)
 		if tv.Solid == SOLID_NOT {
 			continue
 		}
@@ -327,7 +320,7 @@ func clipToLinks(a *areaNode, clip *moveClip, s *Server) {
 			continue
 		}
 
-		if clip.edict >= 0 && entvars.Get(clip.edict).Size[0] != 0 &&
+		if clip.edict >= 0 && s.entvars.Get(clip.edict).Size[0] != 0 &&
 			tv.Size[0] == 0 {
 			continue
 		}
@@ -338,7 +331,7 @@ func clipToLinks(a *areaNode, clip *moveClip, s *Server) {
 			if tv.Owner == int32(clip.edict) {
 				continue
 			}
-			if entvars.Get(clip.edict).Owner == int32(touch) {
+			if s.entvars.Get(clip.edict).Owner == int32(touch) {
 				continue
 			}
 		}
@@ -372,45 +365,45 @@ func clipToLinks(a *areaNode, clip *moveClip, s *Server) {
 	}
 }
 
-func initBoxHull() {
-	boxHull.ClipNodes = make([]*bsp.ClipNode, 6)
-	boxHull.Planes = make([]*bsp.Plane, 6)
-	boxHull.FirstClipNode = 0
-	boxHull.LastClipNode = 5
+func (s *Server) initBoxHull() {
+	s.boxHull.ClipNodes = make([]*bsp.ClipNode, 6)
+	s.boxHull.Planes = make([]*bsp.Plane, 6)
+	s.boxHull.FirstClipNode = 0
+	s.boxHull.LastClipNode = 5
 	for i := 0; i < 6; i++ {
-		boxHull.ClipNodes[i] = &bsp.ClipNode{}
-		boxHull.Planes[i] = &bsp.Plane{}
-		boxHull.ClipNodes[i].Plane = boxHull.Planes[i]
+		s.boxHull.ClipNodes[i] = &bsp.ClipNode{}
+		s.boxHull.Planes[i] = &bsp.Plane{}
+		s.boxHull.ClipNodes[i].Plane = s.boxHull.Planes[i]
 		side := i & 1
-		boxHull.ClipNodes[i].Children[side] = bsp.CONTENTS_EMPTY
+		s.boxHull.ClipNodes[i].Children[side] = bsp.CONTENTS_EMPTY
 		if i == 5 {
-			boxHull.ClipNodes[i].Children[side^1] = bsp.CONTENTS_SOLID
+			s.boxHull.ClipNodes[i].Children[side^1] = bsp.CONTENTS_SOLID
 		} else {
-			boxHull.ClipNodes[i].Children[side^1] = i + 1
+			s.boxHull.ClipNodes[i].Children[side^1] = i + 1
 		}
-		boxHull.Planes[i].Type = byte(i >> 1)
+		s.boxHull.Planes[i].Type = byte(i >> 1)
 		switch i >> 1 {
 		case 0:
-			boxHull.Planes[i].Normal[0] = 1
+			s.boxHull.Planes[i].Normal[0] = 1
 		case 1:
-			boxHull.Planes[i].Normal[1] = 1
+			s.boxHull.Planes[i].Normal[1] = 1
 		case 2:
-			boxHull.Planes[i].Normal[2] = 1
+			s.boxHull.Planes[i].Normal[2] = 1
 		}
 	}
 }
 
-func hullForBox(mins, maxs vec.Vec3) *bsp.Hull {
-	boxHull.Planes[0].Dist = maxs[0]
-	boxHull.Planes[1].Dist = mins[0]
-	boxHull.Planes[2].Dist = maxs[1]
-	boxHull.Planes[3].Dist = mins[1]
-	boxHull.Planes[4].Dist = maxs[2]
-	boxHull.Planes[5].Dist = mins[2]
-	return &boxHull
+func (s *Server) hullForBox(mins, maxs vec.Vec3) *bsp.Hull {
+	s.boxHull.Planes[0].Dist = maxs[0]
+	s.boxHull.Planes[1].Dist = mins[0]
+	s.boxHull.Planes[2].Dist = maxs[1]
+	s.boxHull.Planes[3].Dist = mins[1]
+	s.boxHull.Planes[4].Dist = maxs[2]
+	s.boxHull.Planes[5].Dist = mins[2]
+	return &s.boxHull
 }
 
-func hullForEntity(ent *progs.EntVars, mins, maxs vec.Vec3, m model.Model) (*bsp.Hull, vec.Vec3) {
+func (s *Server) hullForEntity(ent *progs.EntVars, mins, maxs vec.Vec3, m model.Model) (*bsp.Hull, vec.Vec3) {
 	if ent.Solid == SOLID_BSP {
 		if ent.MoveType != progs.MoveTypePush {
 			debug.PrintStack()
@@ -437,7 +430,12 @@ func hullForEntity(ent *progs.EntVars, mins, maxs vec.Vec3, m model.Model) (*bsp
 	hullmins := vec.Sub(vec.VFromA(ent.Mins), maxs)
 	hullmaxs := vec.Sub(vec.VFromA(ent.Maxs), mins)
 	origin := vec.VFromA(ent.Origin)
-	return hullForBox(hullmins, hullmaxs), origin
+	return s.hullForBox(hullmins, hullmaxs), origin
+}
+
+// hullFits reports if the hull was built for boxes of the size of mins, maxs.
+func hullFits(h *bsp.Hull, mins, maxs vec.Vec3) bool {
+	return vec.Sub(maxs, mins) == vec.Sub(h.ClipMaxs, h.ClipMins)
 }
 
 func pointContents(p vec.Vec3, m *bsp.Model) int {
@@ -449,12 +447,18 @@ func clipMoveToEntity(e int, start, mins, maxs, end vec.Vec3, s *Server) bsp.Tra
 	t.Fraction = 1
 	t.AllSolid = true
 	t.EndPos = end
-	ent := entvars.Get(e)
+	ent := s.entvars.Get(e)
 	m := s.models[int(ent.ModelIndex)]
-	hull, offset := hullForEntity(ent, mins, maxs, m)
-	startL := vec.Sub(start, offset)
-	endL := vec.Sub(end, offset)
-	hull.RecursiveCheck(hull.FirstClipNode, 0, 1, startL, endL, &t)
+	hull, offset := s.hullForEntity(ent, mins, maxs, m)
+	if qm, ok := m.(*bsp.Model); ok && ent.Solid == SOLID_BSP && qm.HasBrushes() && !hullFits(hull, mins, maxs) {
+		// The box does not match a hull, the brushes are exact.
+		offset = vec.VFromA(ent.Origin)
+		qm.TraceBrushes(vec.Sub(start, offset), vec.Sub(end, offset), mins, maxs, &t)
+	} else {
+		startL := vec.Sub(start, offset)
+		endL := vec.Sub(end, offset)
+		hull.RecursiveCheck(hull.FirstClipNode, 0, 1, startL, endL, &t)
+	}
 
 	if t.Fraction != 1 {
 		t.EndPos[0] += offset[0]
@@ -475,7 +479,7 @@ func (c *moveClip) moveBounds(s, e vec.Vec3) {
 }
 
 func testEntityPosition(ent int, s *Server) bool {
-	ev := entvars.Get(ent)
+	ev := s.entvars.Get(ent)
 	t := svMove(ev.Origin, ev.Mins, ev.Maxs, ev.Origin, MOVE_NORMAL, ent, s)
 	return t.StartSolid
 }
@@ -507,7 +511,7 @@ func svMove(start, mins, maxs, end vec.Vec3, typ, ed int, s *Server) bsp.Trace {
 	// create the bounding box of the entire move
 	clip.moveBounds(start, end)
 
-	clipToLinks(gArea, &clip, s)
+	clipToLinks(s.area, &clip, s)
 
 	return clip.trace
 }
@@ -519,7 +523,7 @@ const (
 // Returns false if any part of the bottom of the entity is off an edge that
 // is not a staircase.
 func checkBottom(ent int, s *Server) bool {
-	ev := entvars.Get(ent)
+	ev := s.entvars.Get(ent)
 	o := ev.Origin
 	mins := vec.Add(o, ev.Mins)
 	maxs := vec.Add(o, ev.Maxs)
@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package snd
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"goquake/filesystem"
+
+	"github.com/gopxl/beep/v2"
+	"github.com/gopxl/beep/v2/flac"
+	"github.com/gopxl/beep/v2/mp3"
+	"github.com/gopxl/beep/v2/vorbis"
+	"github.com/gopxl/beep/v2/wav"
+)
+
+// compressedExts are tried in this order if a sound file is missing.
+var compressedExts = []string{".ogg", ".flac", ".mp3"}
+
+// openSound opens filename. If there is no such file the same name with
+// the extensions of compressedExts is tried.
+func openSound(filename string) (filesystem.File, string, error) {
+	file, err := filesystem.Open(filename)
+	if err == nil {
+		return file, filename, nil
+	}
+	base := filesystem.StripExt(filename)
+	for _, ext := range compressedExts {
+		n := base + ext
+		if n == filename {
+			continue
+		}
+		if f, err := filesystem.Open(n); err == nil {
+			return f, n, nil
+		}
+	}
+	return nil, "", fmt.Errorf("Could not load file %v: %v", filename, err)
+}
+
+// magic returns the first 4 bytes of file and seeks back to the start.
+func magic(file io.ReadSeeker) ([4]byte, error) {
+	var m [4]byte
+	if _, err := io.ReadFull(file, m[:]); err != nil {
+		return m, err
+	}
+	_, err := file.Seek(0, io.SeekStart)
+	return m, err
+}
+
+// codec returns the codec of a file starting with the bytes m.
+func codec(m [4]byte) string {
+	switch string(m[:]) {
+	case "OggS":
+		return "vorbis"
+	case "fLaC":
+		return "flac"
+	case "RIFF":
+		return "wav"
+	}
+	// mp3 has no magic, it may start with an id3 tag or a frame sync
+	return "mp3"
+}
+
+// decode decodes ogg vorbis, flac, wav and mp3 depending on the content of
+// file. The returned streamer closes file.
+func decode(name string, file filesystem.File) (beep.StreamSeekCloser, beep.Format, error) {
+	m, err := magic(file)
+	if err != nil {
+		file.Close()
+		return nil, beep.Format{}, fmt.Errorf("%s: %v", name, err)
+	}
+	var s beep.StreamSeekCloser
+	var format beep.Format
+	switch codec(m) {
+	case "vorbis":
+		s, format, err = vorbis.Decode(file)
+	case "flac":
+		s, format, err = flac.Decode(file)
+	case "wav":
+		s, format, err = wav.Decode(file)
+	default:
+		s, format, err = mp3.Decode(file)
+	}
+	if err != nil {
+		file.Close()
+		return nil, beep.Format{}, fmt.Errorf("%s: %v", name, err)
+	}
+	return s, format, nil
+}
+
+// loadCompressed decodes the complete file into 16 bit stereo pcm.
+func loadCompressed(filename string, file filesystem.File) (*pcmSound, error) {
+	s, format, err := decode(filename, file)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	const bytesPerFrame = 4
+	data := make([]byte, 0, max(s.Len(), 0)*bytesPerFrame)
+	samples := make([][2]float64, 512)
+	for {
+		n, ok := s.Stream(samples)
+		for _, sample := range samples[:n] {
+			data = binary.LittleEndian.AppendUint16(data, uint16(toInt16(sample[0])))
+			data = binary.LittleEndian.AppendUint16(data, uint16(toInt16(sample[1])))
+		}
+		if !ok {
+			break
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return &pcmSound{
+		name:          filename,
+		numChans:      stereo,
+		loopStart:     math.MaxUint32,
+		sampleRate:    uint32(format.SampleRate),
+		byteRate:      uint32(format.SampleRate) * bytesPerFrame,
+		bytesPerFrame: bytesPerFrame,
+		bitsPerSample: 16,
+		data:          data,
+		dataSize:      uint32(len(data)),
+	}, nil
+}
+
+func toInt16(v float64) int16 {
+	v = min(max(v, -1), 1)
+	return int16(v * math.MaxInt16)
+}
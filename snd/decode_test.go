@@ -0,0 +1,192 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package snd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"goquake/filesystem"
+
+	"github.com/gopxl/beep/v2"
+	"github.com/gopxl/beep/v2/wav"
+)
+
+// useTestDir makes an empty game directory the root of the filesystem and
+// returns its path.
+func useTestDir(t *testing.T) string {
+	t.Helper()
+	old := filesystem.BaseDir()
+	t.Cleanup(func() { filesystem.UseBaseDir(old) })
+	base := t.TempDir()
+	game := filepath.Join(base, "id1")
+	for _, d := range []string{"sound", "music"} {
+		if err := os.MkdirAll(filepath.Join(game, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	filesystem.UseBaseDir(base)
+	return game
+}
+
+func writeFile(t *testing.T, name string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeWav writes a 16 bit stereo wav with frames samples of value v.
+func writeWav(t *testing.T, name string, frames int, v float64) {
+	t.Helper()
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	s := beep.StreamerFunc(func(samples [][2]float64) (int, bool) {
+		for i := range samples {
+			samples[i] = [2]float64{v, v}
+		}
+		return len(samples), true
+	})
+	format := beep.Format{SampleRate: beep.SampleRate(mustSampleRate), NumChannels: 2, Precision: 2}
+	if err := wav.Encode(f, beep.Take(frames, s), format); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// silentMP3 returns n silent mpeg 1 layer 3 frames, 128 kbit/s at 44.1 kHz.
+func silentMP3(n int) []byte {
+	const frameSize = 144 * 128000 / 44100
+	frame := make([]byte, frameSize)
+	copy(frame, []byte{0xff, 0xfb, 0x90, 0x00})
+	return bytes.Repeat(frame, n)
+}
+
+func TestCodec(t *testing.T) {
+	tests := []struct {
+		magic string
+		want  string
+	}{
+		{"OggS", "vorbis"},
+		{"fLaC", "flac"},
+		{"RIFF", "wav"},
+		{"ID3\x04", "mp3"},
+		{"\xff\xfb\x90\x00", "mp3"},
+	}
+	for _, tc := range tests {
+		var m [4]byte
+		copy(m[:], tc.magic)
+		if got := codec(m); got != tc.want {
+			t.Errorf("codec(%q) = %s, want %s", tc.magic, got, tc.want)
+		}
+	}
+}
+
+func TestOpenSound(t *testing.T) {
+	dir := filepath.Join(useTestDir(t), "sound")
+	for _, n := range []string{"a.wav", "a.ogg", "b.mp3", "b.flac", "c.mp3"} {
+		writeFile(t, filepath.Join(dir, n), []byte(n))
+	}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"sound/a.wav", "sound/a.wav"},
+		{"sound/b.wav", "sound/b.flac"},
+		{"sound/c.wav", "sound/c.mp3"},
+		{"sound/c.ogg", "sound/c.mp3"},
+		{"sound/d.wav", ""},
+	}
+	for _, tc := range tests {
+		f, got, err := openSound(tc.name)
+		if tc.want == "" {
+			if err == nil {
+				f.Close()
+				t.Errorf("openSound(%s) = %s, want an error", tc.name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("openSound(%s): %v", tc.name, err)
+			continue
+		}
+		f.Close()
+		if got != tc.want {
+			t.Errorf("openSound(%s) = %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	dir := filepath.Join(useTestDir(t), "sound")
+	writeWav(t, filepath.Join(dir, "pcm.wav"), 100, 0.5)
+	writeFile(t, filepath.Join(dir, "silence.mp3"), silentMP3(8))
+	writeFile(t, filepath.Join(dir, "broken.ogg"), []byte("OggS broken"))
+	writeFile(t, filepath.Join(dir, "broken.flac"), []byte("fLaC broken"))
+	writeFile(t, filepath.Join(dir, "short.wav"), []byte("RI"))
+	tests := []struct {
+		name       string
+		sampleRate beep.SampleRate
+		err        bool
+	}{
+		{"sound/pcm.wav", beep.SampleRate(mustSampleRate), false},
+		{"sound/silence.mp3", 44100, false},
+		{"sound/broken.ogg", 0, true},
+		{"sound/broken.flac", 0, true},
+		{"sound/short.wav", 0, true},
+	}
+	for _, tc := range tests {
+		f, err := filesystem.Open(tc.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, format, err := decode(tc.name, f)
+		if tc.err {
+			if err == nil {
+				s.Close()
+				t.Errorf("decode(%s): no error", tc.name)
+			} else if !strings.HasPrefix(err.Error(), tc.name) {
+				t.Errorf("decode(%s): error %q does not name the file", tc.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("decode(%s): %v", tc.name, err)
+			continue
+		}
+		s.Close()
+		if format.SampleRate != tc.sampleRate {
+			t.Errorf("decode(%s): sample rate %d, want %d", tc.name, format.SampleRate, tc.sampleRate)
+		}
+	}
+}
+
+func TestLoadCompressed(t *testing.T) {
+	dir := filepath.Join(useTestDir(t), "sound")
+	writeWav(t, filepath.Join(dir, "pcm.wav"), 100, 0.5)
+	f, name, err := openSound("sound/pcm.wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := loadCompressed(name, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.numChans != stereo || s.bitsPerSample != 16 || s.sampleRate != uint32(mustSampleRate) {
+		t.Errorf("got %d channels, %d bits at %d Hz", s.numChans, s.bitsPerSample, s.sampleRate)
+	}
+	if s.dataSize != 100*4 || len(s.data) != 100*4 {
+		t.Fatalf("got %d bytes, want %d", s.dataSize, 100*4)
+	}
+	// 0.5 is stored as 16383 or 16384 depending on the rounding
+	for i := 0; i < len(s.data); i += 2 {
+		if v := int16(s.data[i]) | int16(s.data[i+1])<<8; v < 16382 || v > 16384 {
+			t.Fatalf("sample %d = %d, want about 16383", i/2, v)
+		}
+	}
+}
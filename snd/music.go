@@ -0,0 +1,219 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package snd
+
+import (
+	"fmt"
+	"log"
+
+	"goquake/filesystem"
+	"goquake/snd/speaker"
+
+	"github.com/gopxl/beep/v2"
+)
+
+// musicExts are tried in this order to find a music file.
+var musicExts = []string{".ogg", ".flac", ".mp3", ".wav"}
+
+// findMusic returns the music file name with one of the musicExts added.
+func findMusic(name string) (string, bool) {
+	if filesystem.Ext(name) != "" {
+		if _, err := filesystem.Stat(name); err == nil {
+			return name, true
+		}
+	}
+	for _, ext := range musicExts {
+		if _, err := filesystem.Stat(name + ext); err == nil {
+			return name + ext, true
+		}
+	}
+	return "", false
+}
+
+// TrackName returns the name of the music file replacing cd track n.
+func TrackName(n int) string {
+	return fmt.Sprintf("music/track%02d", n)
+}
+
+type Music struct {
+	// Name is played once, then Loop is played repeatedly. An empty Loop
+	// stops the music after Name.
+	Name string
+	Loop string
+}
+
+// musicStream streams the music. It gets modified while playing, so all
+// changes need to lock the speaker.
+type musicStream struct {
+	Music
+	sound   beep.Streamer
+	closers []beep.StreamSeekCloser
+	volume  float64
+	paused  bool
+	done    bool
+}
+
+func (m *musicStream) Stream(samples [][2]float64) (int, bool) {
+	if m.done {
+		return 0, false
+	}
+	if m.paused {
+		clear(samples)
+		return len(samples), true
+	}
+	n, ok := m.sound.Stream(samples)
+	for i := range samples[:n] {
+		samples[i][0] *= m.volume
+		samples[i][1] *= m.volume
+	}
+	if !ok {
+		m.done = true
+	}
+	return n, ok
+}
+
+func (m *musicStream) Err() error {
+	return m.sound.Err()
+}
+
+func (m *musicStream) close() {
+	for _, c := range m.closers {
+		c.Close()
+	}
+	m.closers = nil
+}
+
+// openMusic opens the music file name.
+func openMusic(name string) (beep.StreamSeekCloser, beep.Format, error) {
+	fn, ok := findMusic(name)
+	if !ok {
+		return nil, beep.Format{}, fmt.Errorf("Could not find music %v", name)
+	}
+	file, err := filesystem.Open(fn)
+	if err != nil {
+		return nil, beep.Format{}, err
+	}
+	return decode(fn, file)
+}
+
+// resample converts s from the format f to the speaker rate.
+func resample(s beep.Streamer, f beep.Format) beep.Streamer {
+	if int(f.SampleRate) == mustSampleRate {
+		return s
+	}
+	return beep.Resample(3, f.SampleRate, beep.SampleRate(mustSampleRate), s)
+}
+
+func (s *SndSys) playMusic(m Music) {
+	if s.music != nil && !s.music.done && s.music.Music == m {
+		// keep playing instead of restarting
+		return
+	}
+	s.stopMusic()
+
+	ms, err := newMusicStream(m, s.musicVolume)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	s.music = ms
+	speaker.Play(ms)
+}
+
+// newMusicStream opens the files of m. A missing or broken loop only gets
+// logged, the intro is played anyway.
+func newMusicStream(m Music, volume float64) (*musicStream, error) {
+	ms := &musicStream{
+		Music:  m,
+		volume: volume,
+	}
+	intro, format, err := openMusic(m.Name)
+	if err != nil {
+		return nil, err
+	}
+	ms.closers = append(ms.closers, intro)
+	if m.Loop == m.Name {
+		l, err := beep.Loop2(intro)
+		if err != nil {
+			ms.close()
+			return nil, fmt.Errorf("%s: %v", m.Name, err)
+		}
+		ms.sound = resample(l, format)
+	} else {
+		parts := []beep.Streamer{resample(intro, format)}
+		if m.Loop != "" {
+			if loop, format, err := openMusic(m.Loop); err != nil {
+				log.Println(err)
+			} else if l, err := beep.Loop2(loop); err != nil {
+				log.Printf("%s: %v", m.Loop, err)
+				loop.Close()
+			} else {
+				ms.closers = append(ms.closers, loop)
+				parts = append(parts, resample(l, format))
+			}
+		}
+		ms.sound = beep.Seq(parts...)
+	}
+	return ms, nil
+}
+
+func (s *SndSys) stopMusic() {
+	if s.music == nil {
+		return
+	}
+	speaker.Lock()
+	s.music.done = true
+	speaker.Unlock()
+	s.music.close()
+	s.music = nil
+}
+
+func (s *SndSys) pauseMusic(pause bool) {
+	if s.music == nil {
+		return
+	}
+	speaker.Lock()
+	s.music.paused = pause
+	speaker.Unlock()
+}
+
+func (s *SndSys) setMusicVolume(v float32) {
+	s.musicVolume = float64(v)
+	if s.music == nil {
+		return
+	}
+	speaker.Lock()
+	s.music.volume = s.musicVolume
+	speaker.Unlock()
+}
+
+// The API
+
+// PlayMusic plays m, music which is already playing is not restarted.
+func (s *SndSys) PlayMusic(m Music) {
+	if s == nil {
+		return
+	}
+	s.playMusicCh <- m
+}
+
+func (s *SndSys) StopMusic() {
+	if s == nil {
+		return
+	}
+	s.stopMusicCh <- true
+}
+
+func (s *SndSys) PauseMusic(pause bool) {
+	if s == nil {
+		return
+	}
+	s.pauseMusicCh <- pause
+}
+
+func (s *SndSys) SetMusicVolume(v float32) {
+	if s == nil {
+		return
+	}
+	s.musicVolumeCh <- v
+}
@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package snd
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestTrackName(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{2, "music/track02"},
+		{11, "music/track11"},
+		{100, "music/track100"},
+	}
+	for _, tc := range tests {
+		if got := TrackName(tc.n); got != tc.want {
+			t.Errorf("TrackName(%d) = %s, want %s", tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestFindMusic(t *testing.T) {
+	dir := filepath.Join(useTestDir(t), "music")
+	for _, n := range []string{"a.wav", "a.mp3", "b.wav", "b.flac", "b.ogg", "c.wav", "d.mp3"} {
+		writeFile(t, filepath.Join(dir, n), []byte(n))
+	}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"music/a", "music/a.mp3"},
+		{"music/b", "music/b.ogg"},
+		{"music/c", "music/c.wav"},
+		{"music/a.wav", "music/a.wav"},
+		{"music/d.ogg", ""},
+		{"music/e", ""},
+	}
+	for _, tc := range tests {
+		got, ok := findMusic(tc.name)
+		if ok != (tc.want != "") || got != tc.want {
+			t.Errorf("findMusic(%s) = %s, %v, want %s", tc.name, got, ok, tc.want)
+		}
+	}
+}
+
+func TestMusicStream(t *testing.T) {
+	dir := filepath.Join(useTestDir(t), "music")
+	writeWav(t, filepath.Join(dir, "intro.wav"), 100, 0.5)
+	writeWav(t, filepath.Join(dir, "loop.wav"), 30, -0.25)
+	tests := []struct {
+		name  string
+		music Music
+		// want holds the expected values of the first 250 samples, NaN
+		// after the end of the music
+		want func(i int) float64
+	}{
+		{"intro then loop", Music{Name: "music/intro", Loop: "music/loop"}, func(i int) float64 {
+			if i < 100 {
+				return 0.5
+			}
+			return -0.25
+		}},
+		{"loop intro", Music{Name: "music/intro", Loop: "music/intro"}, func(i int) float64 {
+			return 0.5
+		}},
+		{"intro only", Music{Name: "music/intro"}, func(i int) float64 {
+			if i < 100 {
+				return 0.5
+			}
+			return math.NaN()
+		}},
+		{"missing loop", Music{Name: "music/intro", Loop: "music/missing"}, func(i int) float64 {
+			if i < 100 {
+				return 0.5
+			}
+			return math.NaN()
+		}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ms, err := newMusicStream(tc.music, 0.5)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ms.close()
+			samples := make([][2]float64, 250)
+			n := 0
+			for n < len(samples) {
+				m, ok := ms.Stream(samples[n:])
+				n += m
+				if !ok {
+					break
+				}
+			}
+			for i := range samples {
+				want := tc.want(i)
+				if math.IsNaN(want) {
+					if i < n {
+						t.Fatalf("got %d samples, want %d", n, i)
+					}
+					break
+				}
+				if i >= n {
+					t.Fatalf("got %d samples, want more", n)
+				}
+				// the volume is 0.5
+				if got := samples[i][0]; math.Abs(got-want*0.5) > 1e-3 {
+					t.Fatalf("sample %d = %f, want %f", i, got, want*0.5)
+				}
+			}
+		})
+	}
+
+	if _, err := newMusicStream(Music{Name: "music/missing"}, 1); err == nil {
+		t.Error("missing music: no error")
+	}
+}
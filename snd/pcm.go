@@ -136,13 +136,24 @@ type chunk struct {
 
 // http://www.piclist.com/techref/io/serial/midi/wave.html
 
-func loadSFX(filename string) (sound *pcmSound, err error) {
-	file, err := filesystem.Open(filename)
+// loadSFX loads a RIFF wave file. Other formats get decoded by
+// loadCompressed.
+func loadSFX(filename string) (*pcmSound, error) {
+	file, filename, err := openSound(filename)
 	if err != nil {
-		return nil, fmt.Errorf("Could not load file %v: %v", filename, err)
+		return nil, err
+	}
+	if m, err := magic(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read header: %v", err)
+	} else if m != [4]byte{'R', 'I', 'F', 'F'} {
+		return loadCompressed(filename, file)
 	}
 	defer file.Close()
+	return loadWAV(filename, file)
+}
 
+func loadWAV(filename string, file filesystem.File) (sound *pcmSound, err error) {
 	wh := waveHeader{} // 12 byte
 	if err := binary.Read(file, binary.LittleEndian, &wh); err != nil {
 		return nil, fmt.Errorf("failed to read header: %v", err)
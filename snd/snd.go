@@ -78,6 +78,9 @@ func (s *SndSys) startSound(start Start) {
 			return
 		}
 	}
+	if int(pres.sampleRate) != mustSampleRate {
+		ns = beep.Resample(3, beep.SampleRate(pres.sampleRate), beep.SampleRate(mustSampleRate), ns)
+	}
 
 	ps := &playingSound{
 		masterVolume:       float64(start.volume),
@@ -87,8 +90,6 @@ func (s *SndSys) startSound(start Start) {
 		distanceMultiplier: start.attenuation / clipDistance,
 		sound:              ns,
 	}
-	// TODO: we need to check the samplerate of the sound to match the speaker
-
 	ps.spatialize(s.listener.ID, s.listener.Origin, s.listener.Right) // update panning
 	activeSounds.add(ps)
 	speaker.Play(ps)
@@ -104,6 +105,10 @@ func (s *SndSys) stopSound(entnum, entchannel int) {
 func (s *SndSys) stopAllSound() {
 	speaker.Clear()
 	activeSounds = newASounds()
+	if s.music != nil && !s.music.done {
+		// the music keeps playing
+		speaker.Play(s.music)
+	}
 }
 
 type listener struct {
@@ -161,6 +166,12 @@ func InitSoundSystem(stop chan struct{}) *SndSys {
 		start:       make(chan Start),
 		removeCache: make(chan uuid.UUID),
 		addCache:    make(chan cacheRequest),
+
+		musicVolume:   1,
+		playMusicCh:   make(chan Music),
+		stopMusicCh:   make(chan bool),
+		pauseMusicCh:  make(chan bool),
+		musicVolumeCh: make(chan float32),
 	}
 	go s.run()
 	return s
@@ -178,6 +189,13 @@ type SndSys struct {
 	removeCache chan uuid.UUID
 	addCache    chan cacheRequest
 	listener    listener
+
+	music         *musicStream
+	musicVolume   float64
+	playMusicCh   chan Music
+	stopMusicCh   chan bool
+	pauseMusicCh  chan bool
+	musicVolumeCh chan float32
 }
 
 type cacheRequest struct {
@@ -232,6 +250,14 @@ func (s *SndSys) run() {
 			s.createCache(ac)
 		case start := <-s.start:
 			s.startSound(start)
+		case m := <-s.playMusicCh:
+			s.playMusic(m)
+		case <-s.stopMusicCh:
+			s.stopMusic()
+		case p := <-s.pauseMusicCh:
+			s.pauseMusic(p)
+		case v := <-s.musicVolumeCh:
+			s.setMusicVolume(v)
 		}
 	}
 }
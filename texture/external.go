@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package texture
+
+import (
+	"image"
+
+	"goquake/cvars"
+	qimage "goquake/image"
+)
+
+// FromImage creates an RGBA texture with the pixels of img.
+func FromImage(img *image.NRGBA, flags TexPref, name string) *Texture {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	data := make([]byte, 0, w*h*4)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		o := img.PixOffset(b.Min.X, y)
+		data = append(data, img.Pix[o:o+4*w]...)
+	}
+	return NewTexture(int32(w), int32(h), flags, name, ColorTypeRGBA, data)
+}
+
+// External returns the first of the external images paths as replacement
+// for the texture name. The fullbright texture is the image with the suffix
+// _glow or _luma, it is nil if there is none. Both are nil if
+// gl_externaltextures is disabled or no image could be found.
+func External(name string, flags TexPref, paths ...string) (t, fb *Texture) {
+	if !cvars.GlExternalTextures.Bool() {
+		return nil, nil
+	}
+	img, found := qimage.Replacement(paths...)
+	if img == nil {
+		return nil, nil
+	}
+	t = FromImage(img, flags, name)
+	if glow, _ := qimage.Replacement(found+"_glow", found+"_luma"); glow != nil {
+		fb = FromImage(glow, flags|TexPrefFullBright, name+"_glow")
+	}
+	return t, fb
+}
@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package texture
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"goquake/cvars"
+	"goquake/filesystem"
+)
+
+func TestExternal(t *testing.T) {
+	old := filesystem.BaseDir()
+	t.Cleanup(func() { filesystem.UseBaseDir(old) })
+	base := t.TempDir()
+	dir := filepath.Join(base, "id1", "textures")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name string, w, h int) {
+		t.Helper()
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if err := png.Encode(f, image.NewNRGBA(image.Rect(0, 0, w, h))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("wall.png", 4, 2)
+	write("wall_luma.png", 4, 2)
+	write("floor.png", 2, 2)
+	filesystem.UseBaseDir(base)
+
+	tx, fb := External("test:wall", TexPrefMipMap, "textures/e1m1/wall", "textures/wall")
+	if tx == nil || fb == nil {
+		t.Fatalf("External(wall) = %v, %v", tx, fb)
+	}
+	if tx.Width != 4 || tx.Height != 2 || tx.Typ != ColorTypeRGBA || len(tx.Data) != 4*2*4 {
+		t.Errorf("wall is %dx%d, type %d with %d bytes", tx.Width, tx.Height, tx.Typ, len(tx.Data))
+	}
+	if !fb.Flags(TexPrefFullBright) || fb.Name() != "test:wall_glow" {
+		t.Errorf("fullbright %s has wrong flags", fb.Name())
+	}
+
+	if tx, fb := External("test:floor", TexPrefMipMap, "textures/floor"); tx == nil || fb != nil {
+		t.Errorf("External(floor) = %v, %v", tx, fb)
+	}
+	if tx, _ := External("test:sky", TexPrefMipMap, "textures/sky"); tx != nil {
+		t.Errorf("External(sky) = %v", tx)
+	}
+
+	cvars.GlExternalTextures.SetByString("0")
+	defer cvars.GlExternalTextures.Reset()
+	if tx, _ := External("test:wall", TexPrefMipMap, "textures/wall"); tx != nil {
+		t.Errorf("External with gl_externaltextures 0 = %v", tx)
+	}
+}
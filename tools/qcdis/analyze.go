@@ -0,0 +1,332 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"goquake/bsp"
+	"goquake/progs"
+	"goquake/progs/builtin"
+	"goquake/progs/op"
+)
+
+// entryPoints are called by the engine and are never referenced by the progs
+// itself.
+var entryPoints = map[string]bool{
+	"main":              true,
+	"StartFrame":        true,
+	"PlayerPreThink":    true,
+	"PlayerPostThink":   true,
+	"ClientKill":        true,
+	"ClientConnect":     true,
+	"PutClientInServer": true,
+	"ClientDisconnect":  true,
+	"SetNewParms":       true,
+	"SetChangeParms":    true,
+	"worldspawn":        true,
+}
+
+// classPrefixes are the name prefixes of the entity classes of the original
+// game. Without map entities functions with these names are assumed to be
+// spawn functions.
+var classPrefixes = []string{
+	"ambient_", "event_", "func_", "info_", "item_", "light", "misc_",
+	"monster_", "path_", "trap_", "trigger_", "weapon_", "air_bubbles",
+	"viewthing",
+}
+
+type analyzer struct {
+	prog *progs.LoadedProg
+	// globals maps an offset to its global def
+	globals map[int16]progs.Def
+	// calls holds for each function the builtins it calls
+	calls map[int][]int
+	// referenced holds all functions which are used as a value
+	referenced map[int]bool
+	// classnames holds the known entity classnames. The engine calls the
+	// functions with these names to spawn the map entities.
+	classnames map[string]bool
+	// mapEntities is set if the classnames of real maps are known
+	mapEntities bool
+}
+
+func newAnalyzer(p *progs.LoadedProg) *analyzer {
+	a := &analyzer{
+		prog:       p,
+		globals:    make(map[int16]progs.Def),
+		calls:      make(map[int][]int),
+		referenced: make(map[int]bool),
+		classnames: make(map[string]bool),
+	}
+	for _, d := range p.GlobalDefs {
+		o := int16(d.Offset)
+		if _, ok := a.globals[o]; !ok {
+			a.globals[o] = d
+		}
+	}
+	// entities spawned or searched by the progs itself
+	for _, d := range p.GlobalDefs {
+		if d.Type&^saveGlobal == progs.EV_String {
+			if s, err := p.String(a.global(int16(d.Offset))); err == nil && s != "" {
+				a.classnames[s] = true
+			}
+		}
+	}
+	for fi, f := range p.Functions {
+		if f.FirstStatement <= 0 {
+			continue
+		}
+		for _, s := range a.statements(f) {
+			for _, o := range []int16{s.A, s.B, s.C} {
+				if d, ok := a.globals[o]; ok && d.Type&^saveGlobal == progs.EV_Function {
+					a.referenced[int(a.global(o))] = true
+				}
+			}
+			if isCall(s) {
+				if b := a.builtin(s); b > 0 && !contains(a.calls[fi], b) {
+					a.calls[fi] = append(a.calls[fi], b)
+				}
+			}
+		}
+	}
+	return a
+}
+
+const saveGlobal = 1 << 15
+
+// addEntities adds the classnames of the entities of a map.
+func (a *analyzer) addEntities(ents []*bsp.Entity) {
+	a.mapEntities = true
+	for _, e := range ents {
+		if n, ok := e.Name(); ok {
+			a.classnames[n] = true
+		}
+	}
+}
+
+// isSpawnFunction returns whether the function fi is called by the engine to
+// spawn entities of its classname.
+func (a *analyzer) isSpawnFunction(fi int) bool {
+	f := a.prog.Functions[fi]
+	if f.NumParms != 0 {
+		return false
+	}
+	n := a.funcName(fi)
+	if a.classnames[n] {
+		return true
+	}
+	if a.mapEntities {
+		return false
+	}
+	for _, p := range classPrefixes {
+		if strings.HasPrefix(n, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func isCall(s progs.Statement) bool {
+	return s.Operator >= op.CALL0 && s.Operator <= op.CALL8
+}
+
+func contains(l []int, v int) bool {
+	for _, e := range l {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *analyzer) global(o int16) int32 {
+	if o < 0 || int(o) >= len(a.prog.RawGlobalsI) {
+		return 0
+	}
+	return a.prog.RawGlobalsI[o]
+}
+
+func (a *analyzer) str(n int32) string {
+	s, err := a.prog.String(n)
+	if err != nil {
+		return fmt.Sprintf("<string %d>", n)
+	}
+	return s
+}
+
+func (a *analyzer) funcName(fi int) string {
+	if fi < 0 || fi >= len(a.prog.Functions) {
+		return fmt.Sprintf("<function %d>", fi)
+	}
+	return a.str(a.prog.Functions[fi].SName)
+}
+
+// statements returns the statements of f up to and including the first DONE.
+func (a *analyzer) statements(f progs.Function) []progs.Statement {
+	st := a.prog.Statements
+	if f.FirstStatement <= 0 || int(f.FirstStatement) >= len(st) {
+		return nil
+	}
+	for i := int(f.FirstStatement); i < len(st); i++ {
+		if st[i].Operator == op.DONE {
+			return st[f.FirstStatement : i+1]
+		}
+	}
+	return st[f.FirstStatement:]
+}
+
+// builtin returns the number of the builtin called by s or 0 if s calls a
+// normal function.
+func (a *analyzer) builtin(s progs.Statement) int {
+	fi := int(a.global(s.A))
+	if fi <= 0 || fi >= len(a.prog.Functions) {
+		return 0
+	}
+	if fs := a.prog.Functions[fi].FirstStatement; fs < 0 {
+		return int(-fs)
+	}
+	return 0
+}
+
+// builtinName returns the name the progs uses for the builtin num.
+func (a *analyzer) builtinName(num int) string {
+	for _, f := range a.prog.Functions {
+		if int(-f.FirstStatement) == num {
+			return a.str(f.SName)
+		}
+	}
+	return "?"
+}
+
+// operand formats the global at offset o.
+func (a *analyzer) operand(o int16) string {
+	d, ok := a.globals[o]
+	if !ok {
+		return fmt.Sprintf("%d", o)
+	}
+	n := a.str(d.SName)
+	if n != "" && n != "IMMEDIATE" {
+		return n
+	}
+	switch d.Type &^ saveGlobal {
+	case progs.EV_String:
+		return fmt.Sprintf("%q", a.str(a.global(o)))
+	case progs.EV_Float:
+		return fmt.Sprintf("%v", math.Float32frombits(uint32(a.global(o))))
+	case progs.EV_Vector:
+		return fmt.Sprintf("'%v %v %v'",
+			math.Float32frombits(uint32(a.global(o))),
+			math.Float32frombits(uint32(a.global(o+1))),
+			math.Float32frombits(uint32(a.global(o+2))))
+	case progs.EV_Function:
+		return a.funcName(int(a.global(o)))
+	}
+	return fmt.Sprintf("%d", o)
+}
+
+func (a *analyzer) statementString(i int, s progs.Statement) string {
+	name := "unknown"
+	if int(s.Operator) < len(op.Names) {
+		name = op.Names[s.Operator]
+	}
+	var args []string
+	switch {
+	case s.Operator == op.IF || s.Operator == op.IFNOT:
+		args = []string{a.operand(s.A), fmt.Sprintf("-> %d", i+int(s.B))}
+	case s.Operator == op.GOTO:
+		args = []string{fmt.Sprintf("-> %d", i+int(s.A))}
+	case isCall(s):
+		args = []string{a.operand(s.A)}
+		if b := a.builtin(s); b > 0 {
+			args[0] = fmt.Sprintf("%s (builtin #%d)", args[0], b)
+		}
+	default:
+		for _, o := range []int16{s.A, s.B, s.C} {
+			if o != 0 {
+				args = append(args, a.operand(o))
+			}
+		}
+	}
+	return fmt.Sprintf("%6d  %-10s %s", i, name, strings.Join(args, ", "))
+}
+
+func (a *analyzer) disassemble(w io.Writer) {
+	for fi, f := range a.prog.Functions {
+		if fi == 0 {
+			continue
+		}
+		if f.FirstStatement < 0 {
+			fmt.Fprintf(w, "builtin #%d %s\n\n", -f.FirstStatement, a.str(f.SName))
+			continue
+		}
+		fmt.Fprintf(w, "function %s (%s) parms %d, locals %d\n",
+			a.str(f.SName), a.str(f.SFile), f.NumParms, f.Locals)
+		for i, s := range a.statements(f) {
+			fmt.Fprintln(w, a.statementString(int(f.FirstStatement)+i, s))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// callers returns the functions calling a builtin, sorted by builtin number.
+func (a *analyzer) callers() ([]int, map[int][]string) {
+	c := make(map[int][]string)
+	for fi := range a.prog.Functions {
+		for _, b := range a.calls[fi] {
+			c[b] = append(c[b], a.funcName(fi))
+		}
+	}
+	nums := make([]int, 0, len(c))
+	for b := range c {
+		nums = append(nums, b)
+	}
+	sort.Ints(nums)
+	return nums, c
+}
+
+func (a *analyzer) crossReferences(w io.Writer) {
+	nums, c := a.callers()
+	for _, b := range nums {
+		fmt.Fprintf(w, "builtin #%d %s called by: %s\n",
+			b, a.builtinName(b), strings.Join(c[b], ", "))
+	}
+	fmt.Fprintln(w)
+}
+
+func (a *analyzer) lint() []string {
+	var r []string
+	for fi, f := range a.prog.Functions {
+		if fi == 0 || f.FirstStatement < 0 {
+			continue
+		}
+		n := a.funcName(fi)
+		if !a.referenced[fi] && !entryPoints[n] && !a.isSpawnFunction(fi) {
+			r = append(r, fmt.Sprintf("%s: function %s is never referenced", a.str(f.SFile), n))
+		}
+	}
+	world, err := a.prog.FindGlobalDef("world")
+	for fi, f := range a.prog.Functions {
+		if err != nil || fi == 0 || f.FirstStatement < 0 {
+			continue
+		}
+		for i, s := range a.statements(f) {
+			if s.Operator == op.ADDRESS && s.A == int16(world.Offset) {
+				r = append(r, fmt.Sprintf("%s: %s writes to world.%s (statement %d)",
+					a.str(f.SFile), a.funcName(fi), a.operand(s.B), int(f.FirstStatement)+i))
+			}
+		}
+	}
+	nums, c := a.callers()
+	for _, b := range nums {
+		if !builtin.Implemented(b) {
+			r = append(r, fmt.Sprintf("builtin #%d %s is not implemented by the engine, called by: %s",
+				b, a.builtinName(b), strings.Join(c[b], ", ")))
+		}
+	}
+	return r
+}
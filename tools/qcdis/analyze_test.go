@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"goquake/bsp"
+	"goquake/progs"
+	"goquake/progs/op"
+	"goquake/progs/progstest"
+)
+
+type testProgs struct {
+	progstest.Progs
+}
+
+func (t *testProgs) str(s string) int32 {
+	i := int32(len(t.Strings))
+	t.Strings = append(t.Strings, s...)
+	t.Strings = append(t.Strings, 0)
+	return i
+}
+
+// global adds a global def with value v and returns its offset.
+func (t *testProgs) global(name string, typ uint16, v int32) int16 {
+	o := len(t.Globals)
+	t.Globals = append(t.Globals, v)
+	t.GlobalDefs = append(t.GlobalDefs, progs.Def{Type: typ, Offset: uint16(o), SName: t.str(name)})
+	return int16(o)
+}
+
+func TestAnalyzer(t *testing.T) {
+	tp := &testProgs{}
+	tp.str("")
+	tp.Globals = make([]int32, progs.ReservedOffset)
+	tp.Functions = []progs.Function{{}}
+	tp.Statements = []progs.Statement{{}}
+
+	file := tp.str("test.qc")
+	fn := func(name string, first int32) int16 {
+		n := tp.str(name)
+		tp.Functions = append(tp.Functions, progs.Function{FirstStatement: first, SName: n, SFile: file})
+		return tp.global(name, progs.EV_Function, int32(len(tp.Functions)-1))
+	}
+	world := tp.global("world", progs.EV_Entity, 0)
+	health := tp.global("health", progs.EV_Field, 0)
+	tmp := tp.global("", progs.EV_Pointer, 0)
+	setabssize := fn("setabssize", -5) // not implemented by the engine
+	makevectors := fn("makevectors", -1)
+	fn("main", int32(len(tp.Statements)))
+	tp.Statements = append(tp.Statements,
+		progs.Statement{Operator: op.CALL0, A: setabssize},
+		progs.Statement{Operator: op.CALL0, A: makevectors},
+		progs.Statement{Operator: op.ADDRESS, A: world, B: health, C: tmp},
+		progs.Statement{Operator: op.DONE})
+	fn("unused", int32(len(tp.Statements)))
+	tp.Statements = append(tp.Statements, progs.Statement{Operator: op.DONE})
+	fn("monster_test", int32(len(tp.Statements)))
+	tp.Statements = append(tp.Statements, progs.Statement{Operator: op.DONE})
+	fn("custom_spawn", int32(len(tp.Statements)))
+	tp.Statements = append(tp.Statements, progs.Statement{Operator: op.DONE})
+
+	p, err := progs.ParseProgs(tp.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := newAnalyzer(p)
+
+	var b bytes.Buffer
+	a.crossReferences(&b)
+	want := "builtin #1 makevectors called by: main\nbuiltin #5 setabssize called by: main\n\n"
+	if b.String() != want {
+		t.Errorf("crossReferences: got %q, want %q", b.String(), want)
+	}
+
+	got := strings.Join(a.lint(), "\n")
+	want = "test.qc: function unused is never referenced\n" +
+		"test.qc: function custom_spawn is never referenced\n" +
+		"test.qc: main writes to world.health (statement 3)\n" +
+		"builtin #5 setabssize is not implemented by the engine, called by: main"
+	if got != want {
+		t.Errorf("lint: got\n%s\nwant\n%s", got, want)
+	}
+
+	b.Reset()
+	a.disassemble(&b)
+	if !strings.Contains(b.String(), "CALL0      setabssize (builtin #5)") {
+		t.Errorf("disassemble: missing builtin call in\n%s", b.String())
+	}
+
+	// with map entities only their classnames are spawn functions
+	a.addEntities(bsp.ParseEntities(strings.NewReader(`{ "classname" "custom_spawn" }`)))
+	got = strings.Join(a.lint(), "\n")
+	want = "test.qc: function unused is never referenced\n" +
+		"test.qc: function monster_test is never referenced\n" +
+		"test.qc: main writes to world.health (statement 3)\n" +
+		"builtin #5 setabssize is not implemented by the engine, called by: main"
+	if got != want {
+		t.Errorf("lint with entities: got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+// qcdis prints a disassembly of a progs.dat together with cross references
+// of the used builtins and some lint warnings.
+//
+//	qcdis [-dis] [-xref] [-lint] progs.dat [map.bsp|map.ent...]
+//
+// Without any of the flags everything is printed. The classnames of the
+// entities of the given maps tell the linter which functions are spawn
+// functions. Without maps the names of the entity classes of the original
+// game are assumed to be spawn functions.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"goquake/bsp"
+	"goquake/progs"
+)
+
+var (
+	disassemble = flag.Bool("dis", false, "print the disassembly of all functions")
+	xref        = flag.Bool("xref", false, "print which functions call which builtins")
+	lint        = flag.Bool("lint", false, "print unused functions, writes to world and unimplemented builtins")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: qcdis [flags] progs.dat [map.bsp|map.ent...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if !*disassemble && !*xref && !*lint {
+		*disassemble, *xref, *lint = true, true, true
+	}
+
+	b, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	p, err := progs.ParseProgs(b)
+	if err != nil {
+		log.Fatalf("Could not parse %s: %v", flag.Arg(0), err)
+	}
+	a := newAnalyzer(p)
+	for _, m := range flag.Args()[1:] {
+		ents, err := readEntities(m)
+		if err != nil {
+			log.Fatalf("Could not read entities of %s: %v", m, err)
+		}
+		a.addEntities(ents)
+	}
+
+	w := os.Stdout
+	if *disassemble {
+		a.disassemble(w)
+	}
+	if *xref {
+		a.crossReferences(w)
+	}
+	if *lint {
+		for _, m := range a.lint() {
+			fmt.Fprintln(w, m)
+		}
+	}
+}
+
+// readEntities returns the entities of a bsp or an entity file.
+func readEntities(name string) ([]*bsp.Entity, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if strings.ToLower(filepath.Ext(name)) == ".ent" {
+		return bsp.ParseEntities(f), nil
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return bsp.ReadEntities(f, fi.Size())
+}
@@ -0,0 +1,237 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+// qpak lists, extracts and creates Quake pak files.
+//
+//	qpak list [-l] file.pak
+//	qpak extract [-C dir] file.pak [name...]
+//	qpak create [-C dir] file.pak path...
+//	qpak add [-C dir] file.pak path...
+//
+// The paths given to create and add are relative to -C and are stored with
+// this name, directories are added recursively. add replaces files which are
+// already in the pak.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"goquake/pack"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `usage:
+  qpak list [-l] file.pak
+  qpak extract [-C dir] file.pak [name...]
+  qpak create [-C dir] file.pak path...
+  qpak add [-C dir] file.pak path...
+`)
+	os.Exit(2)
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("qpak: ")
+	if len(os.Args) < 2 {
+		usage()
+	}
+	fset := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	fset.Usage = usage
+	long := fset.Bool("l", false, "print the file sizes")
+	dir := fset.String("C", ".", "directory to extract to or to read files from")
+	fset.Parse(os.Args[2:])
+	args := fset.Args()
+	if len(args) < 1 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "list":
+		err = list(os.Stdout, args[0], *long)
+	case "extract":
+		err = extract(args[0], *dir, args[1:])
+	case "create":
+		if len(args) < 2 {
+			usage()
+		}
+		err = create(args[0], *dir, args[1:], nil)
+	case "add":
+		if len(args) < 2 {
+			usage()
+		}
+		err = add(args[0], *dir, args[1:])
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func list(w io.Writer, name string, long bool) error {
+	p, err := pack.NewPackReader(name)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+	for _, n := range p.Files() {
+		if !long {
+			fmt.Fprintln(w, n)
+			continue
+		}
+		f, err := p.Open(n)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%10d %s\n", f.Size(), n)
+	}
+	return nil
+}
+
+func extract(name, dir string, names []string) error {
+	p, err := pack.NewPackReader(name)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+	if len(names) == 0 {
+		names = p.Files()
+	}
+	for _, n := range names {
+		if !filepath.IsLocal(n) {
+			return fmt.Errorf("refusing to extract %q outside of %s", n, dir)
+		}
+		f, err := p.Open(n)
+		if err != nil {
+			return fmt.Errorf("%s: %w", n, err)
+		}
+		out := filepath.Join(dir, filepath.FromSlash(n))
+		if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+			return err
+		}
+		if err := writeFile(out, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(name string, r io.Reader) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// files returns the pak names of paths relative to dir. Directories are
+// walked.
+func files(dir string, paths []string) ([]string, error) {
+	var r []string
+	fsys := os.DirFS(dir)
+	for _, p := range paths {
+		p = filepath.ToSlash(filepath.Clean(p))
+		err := fs.WalkDir(fsys, p, func(n string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if err := pack.CheckName(n); err != nil {
+				return err
+			}
+			r = append(r, n)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// create writes a new pak with the files of old which are not replaced by
+// paths followed by paths.
+func create(name, dir string, paths []string, old *pack.Pack) error {
+	names, err := files(dir, paths)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	w, err := pack.NewWriter(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if err := writePak(w, dir, names, old); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writePak(w *pack.Writer, dir string, names []string, old *pack.Pack) error {
+	replaced := make(map[string]bool)
+	for _, n := range names {
+		replaced[n] = true
+	}
+	if old != nil {
+		for _, n := range old.Files() {
+			if replaced[n] {
+				continue
+			}
+			r, err := old.Open(n)
+			if err != nil {
+				return err
+			}
+			if err := w.Add(n, r); err != nil {
+				return err
+			}
+		}
+	}
+	for _, n := range names {
+		f, err := os.Open(filepath.Join(dir, filepath.FromSlash(n)))
+		if err != nil {
+			return err
+		}
+		err = w.Add(n, f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", n, err)
+		}
+	}
+	return nil
+}
+
+// add writes the new pak next to the old one and replaces it once it is
+// complete.
+func add(name, dir string, paths []string) error {
+	old, err := pack.NewPackReader(name)
+	if err != nil {
+		return err
+	}
+	defer old.Close()
+	tmp := name + ".tmp"
+	if err := create(tmp, dir, paths, old); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, name)
+}
@@ -0,0 +1,290 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+// qwad lists, extracts and creates Quake WAD2 files like gfx.wad.
+//
+//	qwad list [-l] file.wad
+//	qwad extract [-C dir] file.wad [name...]
+//	qwad create [-C dir] [-m] file.wad path...
+//	qwad add [-C dir] [-m] file.wad path...
+//
+// Pictures are extracted as png, all other lumps as lmp files with their raw
+// data. Png files get quantized to the Quake palette, they become qpics or
+// with -m textures. add replaces lumps which are already in the wad and keeps
+// their type. A '*' in lump names is written as '#' in file names.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"goquake/palette"
+	"goquake/wad"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `usage:
+  qwad list [-l] file.wad
+  qwad extract [-C dir] file.wad [name...]
+  qwad create [-C dir] [-m] file.wad path...
+  qwad add [-C dir] [-m] file.wad path...
+`)
+	os.Exit(2)
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("qwad: ")
+	if len(os.Args) < 2 {
+		usage()
+	}
+	fset := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	fset.Usage = usage
+	long := fset.Bool("l", false, "print the lump types and sizes")
+	dir := fset.String("C", ".", "directory to extract to or to read files from")
+	mipTex := fset.Bool("m", false, "store new pictures as textures instead of qpics")
+	fset.Parse(os.Args[2:])
+	args := fset.Args()
+	if len(args) < 1 {
+		usage()
+	}
+	typ := byte(wad.TypeQPic)
+	if *mipTex {
+		typ = wad.TypeMipTex
+	}
+	var err error
+	switch os.Args[1] {
+	case "list":
+		err = list(os.Stdout, args[0], *long)
+	case "extract":
+		err = extract(args[0], *dir, args[1:])
+	case "create":
+		if len(args) < 2 {
+			usage()
+		}
+		err = create(args[0], *dir, args[1:], typ, nil)
+	case "add":
+		if len(args) < 2 {
+			usage()
+		}
+		err = add(args[0], *dir, args[1:], typ)
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func open(name string) (*wad.File, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return wad.Parse(name, data)
+}
+
+func typeName(t byte) string {
+	switch t {
+	case wad.TypePalette:
+		return "lumpy"
+	case wad.TypeQPic:
+		return "qpic"
+	case wad.TypeMipTex3:
+		return "miptex3"
+	case wad.TypeMipTex:
+		return "miptex"
+	case wad.TypeConsolePic:
+		return "conpic"
+	}
+	return fmt.Sprintf("%#x", t)
+}
+
+func list(w io.Writer, name string, long bool) error {
+	f, err := open(name)
+	if err != nil {
+		return err
+	}
+	for _, l := range f.Lumps() {
+		if !long {
+			fmt.Fprintln(w, l.Name)
+			continue
+		}
+		fmt.Fprintf(w, "%-8s %8d %s\n", typeName(l.Type), len(l.Data), l.Name)
+	}
+	return nil
+}
+
+// lumpPalette returns the palette used to convert the pictures of the lump
+// name with type typ. Color 255 is transparent for qpics and alpha masked
+// textures, the console characters use color 0.
+func lumpPalette(name string, typ byte) *palette.Palette {
+	switch {
+	case name == "conchars":
+		return &palette.TableConsoleChars
+	case typ == wad.TypeMipTex && !strings.HasPrefix(name, "{"):
+		p := palette.Table
+		p[255*4+3] = 255
+		return &p
+	}
+	return &palette.Table
+}
+
+// fileName returns the file name of the lump name without extension.
+func fileName(name string) string {
+	return strings.ReplaceAll(name, "*", "#")
+}
+
+// lumpName returns the lump name of the file path.
+func lumpName(path string) string {
+	n := filepath.Base(path)
+	n = strings.TrimSuffix(n, filepath.Ext(n))
+	return strings.ToLower(strings.ReplaceAll(n, "#", "*"))
+}
+
+func extract(name, dir string, names []string) error {
+	f, err := open(name)
+	if err != nil {
+		return err
+	}
+	wanted := make(map[string]bool)
+	for _, n := range names {
+		wanted[strings.ToLower(n)] = true
+	}
+	for _, l := range f.Lumps() {
+		if len(wanted) != 0 && !wanted[l.Name] {
+			continue
+		}
+		delete(wanted, l.Name)
+		n := fileName(l.Name)
+		if !filepath.IsLocal(n) || strings.ContainsAny(n, `/\`) {
+			return fmt.Errorf("refusing to extract %q outside of %s", l.Name, dir)
+		}
+		if err := extractLump(filepath.Join(dir, n), l); err != nil {
+			return err
+		}
+	}
+	for n := range wanted {
+		return fmt.Errorf("%s: no lump %s", name, n)
+	}
+	return nil
+}
+
+func extractLump(name string, l wad.Lump) error {
+	if !l.IsImage() {
+		return os.WriteFile(name+".lmp", l.Data, 0o644)
+	}
+	img, err := l.Image(lumpPalette(l.Name, l.Type))
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(name + ".png")
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// newLump reads the file path relative to dir. Pictures are converted to
+// typ, lmp files are stored raw with type typ.
+func newLump(dir, path string, typ byte) (wad.Lump, error) {
+	name := lumpName(path)
+	fn := filepath.Join(dir, path)
+	if strings.ToLower(filepath.Ext(path)) == ".lmp" {
+		data, err := os.ReadFile(fn)
+		if err != nil {
+			return wad.Lump{}, err
+		}
+		return wad.Lump{Name: name, Type: typ, Data: data}, nil
+	}
+	f, err := os.Open(fn)
+	if err != nil {
+		return wad.Lump{}, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return wad.Lump{}, fmt.Errorf("%s: %w", path, err)
+	}
+	p := lumpPalette(name, typ)
+	switch {
+	case name == "conchars":
+		return wad.NewConsoleChars(img, p)
+	case typ == wad.TypeMipTex:
+		return wad.NewMipTex(name, img, p)
+	case typ == wad.TypeQPic:
+		return wad.NewQPic(name, img, p)
+	}
+	return wad.Lump{}, fmt.Errorf("%s: can not convert a picture to a lump of type %s", path, typeName(typ))
+}
+
+// create writes a new wad with the lumps of old followed by the new lumps of
+// paths. Lumps of old with the same name get replaced in place and keep
+// their type.
+func create(name, dir string, paths []string, typ byte, old *wad.File) error {
+	var lumps []wad.Lump
+	index := make(map[string]int)
+	if old != nil {
+		for _, l := range old.Lumps() {
+			index[l.Name] = len(lumps)
+			lumps = append(lumps, l)
+		}
+	}
+	for _, p := range paths {
+		t := typ
+		i, replace := index[lumpName(p)]
+		if replace {
+			t = lumps[i].Type
+		}
+		if strings.ToLower(filepath.Ext(p)) == ".lmp" && !replace {
+			t = wad.TypePalette
+			if lumpName(p) == "conchars" {
+				t = wad.TypeMipTex
+			}
+		}
+		l, err := newLump(dir, p, t)
+		if err != nil {
+			return err
+		}
+		if replace {
+			lumps[i] = l
+			continue
+		}
+		index[l.Name] = len(lumps)
+		lumps = append(lumps, l)
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := wad.Write(f, lumps); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// add writes the new wad next to the old one and replaces it once it is
+// complete.
+func add(name, dir string, paths []string, typ byte) error {
+	old, err := open(name)
+	if err != nil {
+		return err
+	}
+	tmp := name + ".tmp"
+	if err := create(tmp, dir, paths, typ, old); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, name)
+}
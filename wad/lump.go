@@ -0,0 +1,214 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package wad
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+
+	"goquake/palette"
+)
+
+// Lump is an entry of a wad file.
+type Lump struct {
+	Name string
+	Type byte
+	Data []byte
+}
+
+const (
+	// The console characters are stored as raw 128x128 pixels.
+	consoleCharsSize = 128
+	qpicHeaderSize   = 8
+)
+
+// mipTex is the header of TypeMipTex and TypeMipTex3 lumps.
+type mipTex struct {
+	Name    [16]byte
+	Width   uint32
+	Height  uint32
+	Offsets [4]uint32
+}
+
+const mipTexHeaderSize = 40
+
+func parseQPic(d []byte) (*QPic, error) {
+	if len(d) < qpicHeaderSize {
+		return nil, errors.New("qpic too short")
+	}
+	w := int(binary.LittleEndian.Uint32(d[0:]))
+	h := int(binary.LittleEndian.Uint32(d[4:]))
+	if w < 0 || h < 0 || int64(w)*int64(h) > int64(len(d)-qpicHeaderSize) {
+		return nil, fmt.Errorf("qpic of size %dx%d has only %d bytes", w, h, len(d)-qpicHeaderSize)
+	}
+	return &QPic{
+		Width:  w,
+		Height: h,
+		Data:   d[qpicHeaderSize:],
+	}, nil
+}
+
+// IsImage returns whether Image can convert the lump.
+func (l *Lump) IsImage() bool {
+	switch l.Type {
+	case TypeQPic, TypeMipTex, TypeMipTex3:
+		return true
+	}
+	return l.Name == consoleCharsLump && len(l.Data) == consoleCharsSize*consoleCharsSize
+}
+
+// Image returns the picture of a qpic, a miptex or the console characters.
+// The colors are looked up in p, WAD3 textures bring their own palette.
+// Only the first mip level of textures is returned.
+func (l *Lump) Image(p *palette.Palette) (*image.NRGBA, error) {
+	switch {
+	case l.Type == TypeQPic:
+		q, err := parseQPic(l.Data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", l.Name, err)
+		}
+		return p.Image(q.Data, q.Width, q.Height), nil
+	case l.Name == consoleCharsLump && len(l.Data) == consoleCharsSize*consoleCharsSize:
+		return p.Image(l.Data, consoleCharsSize, consoleCharsSize), nil
+	case l.Type == TypeMipTex, l.Type == TypeMipTex3:
+		img, err := l.mipTexImage(p)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", l.Name, err)
+		}
+		return img, nil
+	}
+	return nil, fmt.Errorf("%s: lump of type %#x is no picture", l.Name, l.Type)
+}
+
+func (l *Lump) mipTexImage(p *palette.Palette) (*image.NRGBA, error) {
+	var mt mipTex
+	if err := binary.Read(bytes.NewReader(l.Data), binary.LittleEndian, &mt); err != nil {
+		return nil, err
+	}
+	w, h := int(mt.Width), int(mt.Height)
+	start := int(mt.Offsets[0])
+	if w <= 0 || h <= 0 || start <= 0 || int64(start)+int64(w)*int64(h) > int64(len(l.Data)) {
+		return nil, fmt.Errorf("texture of size %dx%d does not fit into %d bytes", w, h, len(l.Data))
+	}
+	if l.Type == TypeMipTex3 {
+		pal := int(mt.Offsets[3]) + (w/8)*(h/8)
+		if pal+2 > len(l.Data) {
+			return nil, errors.New("texture has no palette")
+		}
+		colors := int(binary.LittleEndian.Uint16(l.Data[pal:]))
+		pal += 2
+		if colors > 256 || pal+colors*3 > len(l.Data) {
+			return nil, errors.New("texture has a broken palette")
+		}
+		// missing colors are black
+		var own palette.Palette
+		for i := range 256 {
+			if i < colors {
+				copy(own[i*4:], l.Data[pal+i*3:pal+i*3+3])
+			}
+			own[i*4+3] = 255
+		}
+		if strings.HasPrefix(l.Name, "{") {
+			own[255*4+3] = 0
+		}
+		p = &own
+	}
+	return p.Image(l.Data[start:], w, h), nil
+}
+
+func checkName(name string) error {
+	switch {
+	case name == "":
+		return errors.New("empty lump name")
+	case len(name) > len(lump{}.Name)-1:
+		return fmt.Errorf("lump name %q is longer than %d bytes", name, len(lump{}.Name)-1)
+	case strings.ContainsRune(name, 0):
+		return fmt.Errorf("lump name %q contains a 0 byte", name)
+	}
+	return nil
+}
+
+// NewQPic returns a qpic lump of img with the colors quantized to p.
+func NewQPic(name string, img image.Image, p *palette.Palette) (Lump, error) {
+	if err := checkName(name); err != nil {
+		return Lump{}, err
+	}
+	b := img.Bounds()
+	d := make([]byte, qpicHeaderSize, qpicHeaderSize+b.Dx()*b.Dy())
+	binary.LittleEndian.PutUint32(d[0:], uint32(b.Dx()))
+	binary.LittleEndian.PutUint32(d[4:], uint32(b.Dy()))
+	d = append(d, p.Quantize(img)...)
+	return Lump{Name: name, Type: TypeQPic, Data: d}, nil
+}
+
+// NewConsoleChars returns the console characters lump of the 128x128 image
+// img with the colors quantized to p.
+func NewConsoleChars(img image.Image, p *palette.Palette) (Lump, error) {
+	b := img.Bounds()
+	if b.Dx() != consoleCharsSize || b.Dy() != consoleCharsSize {
+		return Lump{}, fmt.Errorf("%s must be %dx%d, not %dx%d", consoleCharsLump,
+			consoleCharsSize, consoleCharsSize, b.Dx(), b.Dy())
+	}
+	return Lump{Name: consoleCharsLump, Type: TypeMipTex, Data: p.Quantize(img)}, nil
+}
+
+// NewMipTex returns a texture lump of img with 4 mip levels and the colors
+// quantized to p. The size of img must be a multiple of 16.
+func NewMipTex(name string, img image.Image, p *palette.Palette) (Lump, error) {
+	if err := checkName(name); err != nil {
+		return Lump{}, err
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= 0 || h <= 0 || w&15 != 0 || h&15 != 0 {
+		return Lump{}, fmt.Errorf("texture %s of size %dx%d is not 16 aligned", name, w, h)
+	}
+	mt := mipTex{
+		Width:  uint32(w),
+		Height: uint32(h),
+	}
+	copy(mt.Name[:], name)
+	var pixels []byte
+	for i := range mt.Offsets {
+		mt.Offsets[i] = uint32(mipTexHeaderSize + len(pixels))
+		pixels = append(pixels, p.Quantize(shrink(img, 1<<i))...)
+	}
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, mt); err != nil {
+		return Lump{}, err
+	}
+	buf.Write(pixels)
+	return Lump{Name: name, Type: TypeMipTex, Data: buf.Bytes()}, nil
+}
+
+// shrink returns img scaled down by factor, every pixel is the average of a
+// factor*factor block.
+func shrink(img image.Image, factor int) image.Image {
+	if factor == 1 {
+		return img
+	}
+	b := img.Bounds()
+	r := image.NewNRGBA(image.Rect(0, 0, b.Dx()/factor, b.Dy()/factor))
+	n := uint32(factor * factor)
+	for y := 0; y < r.Rect.Dy(); y++ {
+		for x := 0; x < r.Rect.Dx(); x++ {
+			var sr, sg, sb, sa uint32
+			for dy := 0; dy < factor; dy++ {
+				for dx := 0; dx < factor; dx++ {
+					c := color.NRGBAModel.Convert(img.At(b.Min.X+x*factor+dx, b.Min.Y+y*factor+dy)).(color.NRGBA)
+					sr += uint32(c.R)
+					sg += uint32(c.G)
+					sb += uint32(c.B)
+					sa += uint32(c.A)
+				}
+			}
+			r.SetNRGBA(x, y, color.NRGBA{uint8(sr / n), uint8(sg / n), uint8(sb / n), uint8(sa / n)})
+		}
+	}
+	return r
+}
@@ -6,20 +6,23 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"image"
 	"io"
 	"strings"
 
+	"goquake/cvars"
 	"goquake/filesystem"
+	qimage "goquake/image"
 	"goquake/palette"
 )
 
+// The lump types.
 const (
-	magic = 'W' | 'A'<<8 | 'D'<<16 | '2'<<24
-
-	typPalette    = 0x40
-	typQPic       = 0x42 // 66
-	typMipTex     = 0x44
-	typConsolePic = 0x45
+	TypePalette    = 0x40 // raw data like the palette
+	TypeQPic       = 0x42 // 66
+	TypeMipTex3    = 0x43 // WAD3, with palette
+	TypeMipTex     = 0x44
+	TypeConsolePic = 0x45
 )
 
 type header struct {
@@ -42,6 +45,11 @@ type QPic struct {
 	Data   []byte
 	Width  int
 	Height int
+	// Image is an external replacement of Data, nil if there is none. Width
+	// and Height are the size of the wad picture even with a replacement.
+	Image *image.NRGBA
+
+	searched bool // Image was searched for
 }
 
 func getWad() ([]byte, error) {
@@ -60,8 +68,8 @@ func getLumps(data []byte) ([]lump, error) {
 	if err != nil {
 		return nil, err
 	}
-	if h.M != [4]byte{'W', 'A', 'D', '2'} {
-		return nil, fmt.Errorf("Wad file doesn't have WAD2 id\n")
+	if h.M != [4]byte{'W', 'A', 'D', '2'} && h.M != [4]byte{'W', 'A', 'D', '3'} {
+		return nil, fmt.Errorf("Wad file doesn't have WAD2 or WAD3 id\n")
 	}
 	lumps := make([]lump, h.EntryCount)
 	_, err = buf.Seek(int64(h.DirOffset), io.SeekStart)
@@ -74,10 +82,86 @@ func getLumps(data []byte) ([]lump, error) {
 	}
 	for i := 0; i < len(lumps); i++ {
 		copy(lumps[i].Name[:], strings.ToLower(string(lumps[i].Name[:])))
+		if l := lumps[i]; l.Offset < 0 || l.Size < 0 || int64(l.Offset)+int64(l.Size) > int64(len(data)) {
+			return nil, fmt.Errorf("Wad lump %s out of bounds", l.name())
+		}
 	}
 	return lumps, nil
 }
 
+func (l *lump) name() string {
+	n := bytes.IndexByte(l.Name[:], 0)
+	if n == -1 {
+		n = len(l.Name)
+	}
+	return string(l.Name[:n])
+}
+
+// File is a WAD2 or WAD3 file. Half-Life maps take their textures from
+// WAD3 files.
+type File struct {
+	name  string
+	data  []byte
+	list  []lump // in directory order
+	lumps map[string]lump
+}
+
+// Open reads the wad file name from the game directory.
+func Open(name string) (*File, error) {
+	data, err := filesystem.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(name, data)
+}
+
+// Parse returns the wad file contained in data.
+func Parse(name string, data []byte) (*File, error) {
+	ls, err := getLumps(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	f := &File{
+		name:  name,
+		data:  data,
+		list:  ls,
+		lumps: make(map[string]lump, len(ls)),
+	}
+	for _, l := range ls {
+		f.lumps[l.name()] = l
+	}
+	return f, nil
+}
+
+// MipTex returns the data of the texture with the given name. The name is
+// case insensitive. The data has the same layout as a texture of the bsp
+// texture lump.
+func (f *File) MipTex(name string) ([]byte, bool) {
+	l, ok := f.lumps[strings.ToLower(name)]
+	if !ok || (l.Typ != TypeMipTex && l.Typ != TypeMipTex3) {
+		return nil, false
+	}
+	return f.data[l.Offset : l.Offset+l.Size], true
+}
+
+// Lumps returns all lumps in the order of the wad directory. The data is
+// shared with f.
+func (f *File) Lumps() []Lump {
+	r := make([]Lump, 0, len(f.list))
+	for _, l := range f.list {
+		r = append(r, Lump{
+			Name: l.name(),
+			Type: l.Typ,
+			Data: f.data[l.Offset : l.Offset+l.Size],
+		})
+	}
+	return r
+}
+
+func (f *File) String() string {
+	return f.name
+}
+
 const (
 	consoleCharsLump = "conchars"
 )
@@ -95,21 +179,14 @@ func getConChars(ls []lump, data []byte) ([]byte, error) {
 func getPics(ls []lump, data []byte) (map[string]*QPic, error) {
 	p := make(map[string]*QPic)
 	for _, l := range ls {
-		if l.Typ != typQPic {
+		if l.Typ != TypeQPic {
 			continue
 		}
-		d := data[l.Offset : l.Offset+l.Size]
-		q := &QPic{
-			Width:  int(binary.LittleEndian.Uint32(d[0:])),
-			Height: int(binary.LittleEndian.Uint32(d[4:])),
-			Data:   d[8:],
+		q, err := parseQPic(data[l.Offset : l.Offset+l.Size])
+		if err != nil {
+			return nil, fmt.Errorf("Wad lump %s: %w", l.name(), err)
 		}
-		ln := bytes.IndexByte(l.Name[:], 0)
-		if ln == -1 {
-			ln = len(l.Name)
-		}
-		name := string(l.Name[:ln])
-		p[name] = q
+		p[l.name()] = q
 	}
 	return p, nil
 }
@@ -139,9 +216,18 @@ func Load() error {
 	return nil
 }
 
+// GetPic returns the picture n. An external image gfx/<n> is used as
+// replacement if gl_externaltextures is set.
 func GetPic(n string) *QPic {
 	name := strings.ToLower(n)
-	return pics[name]
+	p := pics[name]
+	if p != nil && !p.searched {
+		p.searched = true
+		if cvars.GlExternalTextures.Bool() {
+			p.Image, _ = qimage.Replacement("gfx/" + name)
+		}
+	}
+	return p
 }
 
 func GetConsoleChars() []byte {
@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package wad
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Write writes a WAD2 file containing lumps in the given order. Lump names
+// must be unique.
+func Write(w io.Writer, lumps []Lump) error {
+	names := make(map[string]bool)
+	dir := make([]lump, 0, len(lumps))
+	var data bytes.Buffer
+	offset := binary.Size(header{})
+	for _, l := range lumps {
+		if err := checkName(l.Name); err != nil {
+			return err
+		}
+		if names[l.Name] {
+			return fmt.Errorf("lump %q is already in the wad", l.Name)
+		}
+		if l.Type == TypeMipTex3 {
+			return fmt.Errorf("lump %q: WAD3 textures can not be written to a WAD2 file", l.Name)
+		}
+		names[l.Name] = true
+		e := lump{
+			Offset: int32(offset + data.Len()),
+			Dsize:  int32(len(l.Data)),
+			Size:   int32(len(l.Data)),
+			Typ:    l.Type,
+		}
+		copy(e.Name[:], l.Name)
+		dir = append(dir, e)
+		data.Write(l.Data)
+		// keep the lumps 4 byte aligned
+		for data.Len()%4 != 0 {
+			data.WriteByte(0)
+		}
+	}
+	h := header{
+		M:          [4]byte{'W', 'A', 'D', '2'},
+		EntryCount: uint32(len(dir)),
+		DirOffset:  uint32(offset + data.Len()),
+	}
+	if err := binary.Write(w, binary.LittleEndian, h); err != nil {
+		return err
+	}
+	if _, err := w.Write(data.Bytes()); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, dir)
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: GPL-2.0-or-later
+
+package wad
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"goquake/palette"
+)
+
+// testImage returns a w*h image using the palette colors 0 to 15 and the
+// transparent color 255 in the top left corner.
+func testImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := ((x / 4) + (y/4)*4) % 16
+			c := palette.Table[i*4 : i*4+4]
+			img.SetNRGBA(x, y, color.NRGBA{c[0], c[1], c[2], 255})
+		}
+	}
+	img.SetNRGBA(0, 0, color.NRGBA{})
+	return img
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	img := testImage(8, 4)
+	pic, err := NewQPic("sb_test", img, &palette.Table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tex, err := NewMipTex("*water", testImage(32, 16), &palette.Table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := Lump{Name: "palette", Type: TypePalette, Data: []byte{1, 2, 3}}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, []Lump{raw, pic, tex}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	f, err := Parse("test.wad", buf.Bytes())
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	lumps := f.Lumps()
+	if len(lumps) != 3 {
+		t.Fatalf("got %d lumps, want 3", len(lumps))
+	}
+	for i, want := range []Lump{raw, pic, tex} {
+		got := lumps[i]
+		if got.Name != want.Name || got.Type != want.Type || !bytes.Equal(got.Data, want.Data) {
+			t.Errorf("lump %d is %s type %#x, want %s type %#x", i, got.Name, got.Type, want.Name, want.Type)
+		}
+	}
+	if lumps[0].IsImage() {
+		t.Errorf("raw lump is an image")
+	}
+
+	got, err := lumps[1].Image(&palette.Table)
+	if err != nil {
+		t.Fatalf("Image: %v", err)
+	}
+	if got.Bounds() != img.Bounds() || !bytes.Equal(got.Pix[4:], img.Pix[4:]) {
+		t.Errorf("qpic differs after the round trip")
+	}
+	if a := got.NRGBAAt(0, 0).A; a != 0 {
+		t.Errorf("transparent pixel has alpha %d", a)
+	}
+
+	b, ok := f.MipTex("*WATER")
+	if !ok {
+		t.Fatalf("MipTex(*WATER) not found")
+	}
+	// the second mip level is 16x8, every pixel the average of 2x2 pixels
+	if want := 40 + 32*16 + 16*8 + 8*4 + 4*2; len(b) != want {
+		t.Errorf("texture has %d bytes, want %d", len(b), want)
+	}
+	if b[40+32*16+1] != 0 || b[40+32*16+2] != 1 {
+		t.Errorf("second mip level starts with %v", b[40+32*16:40+32*16+4])
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	for _, lumps := range [][]Lump{
+		{{Name: "a"}, {Name: "a"}},
+		{{Name: ""}},
+		{{Name: "muchtoolongname0"}},
+		{{Name: "hl", Type: TypeMipTex3}},
+	} {
+		if err := Write(&bytes.Buffer{}, lumps); err == nil {
+			t.Errorf("Write(%v) succeeded", lumps)
+		}
+	}
+	if _, err := NewMipTex("odd", testImage(8, 16), &palette.Table); err == nil {
+		t.Errorf("NewMipTex of 8x16 succeeded")
+	}
+	if _, err := NewConsoleChars(testImage(16, 16), &palette.Table); err == nil {
+		t.Errorf("NewConsoleChars of 16x16 succeeded")
+	}
+}
+
+func TestConsoleChars(t *testing.T) {
+	img := testImage(128, 128)
+	l, err := NewConsoleChars(img, &palette.TableConsoleChars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !l.IsImage() || len(l.Data) != 128*128 {
+		t.Fatalf("conchars lump has %d bytes", len(l.Data))
+	}
+	// the console characters use color 0 as transparent color
+	if l.Data[0] != 0 || l.Data[4] != 1 {
+		t.Errorf("conchars starts with %v", l.Data[:8])
+	}
+	got, err := l.Image(&palette.TableConsoleChars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.NRGBAAt(0, 0).A != 0 || got.NRGBAAt(4, 0) != img.NRGBAAt(4, 0) {
+		t.Errorf("conchars image differs")
+	}
+}